@@ -0,0 +1,41 @@
+// eventschema 把总线消息的类型定义写到文件，供前端直接引用：
+//
+//	go run ./cmd/eventschema -ts ../frontend/src/types/events.gen.ts -json events.schema.json
+//
+// 不带参数时把 TypeScript 输出到标准输出。
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"sniping_engine/internal/eventschema"
+)
+
+func main() {
+	tsPath := flag.String("ts", "", "write TypeScript definitions to this file")
+	jsonPath := flag.String("json", "", "write JSON Schema to this file")
+	flag.Parse()
+
+	if *tsPath == "" && *jsonPath == "" {
+		fmt.Print(eventschema.TypeScript())
+		return
+	}
+	if *tsPath != "" {
+		if err := os.WriteFile(*tsPath, []byte(eventschema.TypeScript()), 0o644); err != nil {
+			log.Fatalf("write typescript: %v", err)
+		}
+	}
+	if *jsonPath != "" {
+		b, err := json.MarshalIndent(eventschema.JSONSchema(), "", "  ")
+		if err != nil {
+			log.Fatalf("marshal json schema: %v", err)
+		}
+		if err := os.WriteFile(*jsonPath, append(b, '\n'), 0o644); err != nil {
+			log.Fatalf("write json schema: %v", err)
+		}
+	}
+}
@@ -12,6 +12,8 @@ import (
 
 func main() {
 	addr := flag.String("addr", ":8080", "listen address")
+	replayPath := flag.String("replay", "", "replay render-order/create-order responses from a provider.captureFile recording")
+	rushAtFlag := flag.String("rush-at", "30s", "rehearsal rush time for -replay: unix ms or duration from now")
 	flag.Parse()
 
 	rand.Seed(time.Now().UnixNano())
@@ -204,6 +206,19 @@ func main() {
 		})
 	})
 
+	if *replayPath != "" {
+		rushAt, err := parseRushAt(*rushAtFlag, time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+		rp, err := loadReplay(*replayPath, rushAt)
+		if err != nil {
+			log.Fatalf("load replay: %v", err)
+		}
+		rp.register(mux)
+		log.Printf("replaying %s, set target rushAtMs=%d", *replayPath, rushAt.UnixMilli())
+	}
+
 	srv := &http.Server{
 		Addr:              *addr,
 		Handler:           mux,
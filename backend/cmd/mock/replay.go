@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/provider/standard"
+)
+
+// replayer 按录制文件（provider.captureFile）回放 render-order/create-order：
+// 以新的开抢时间为零点，返回原时间线上同一时刻的响应，并按原耗时延迟，用来在同样的上游条件下对比引擎配置。
+type replayer struct {
+	rushAt time.Time
+	byAPI  map[string][]standard.CaptureRecord
+}
+
+// parseRushAt 支持毫秒时间戳或相对现在的时长（如 30s、2m）。
+func parseRushAt(v string, now time.Time) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil && ms > 0 {
+		return time.UnixMilli(ms), nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -rush-at %q: want unix ms or duration", v)
+	}
+	return now.Add(d), nil
+}
+
+func loadReplay(path string, rushAt time.Time) (*replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var recs []standard.CaptureRecord
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var rec standard.CaptureRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		recs = append(recs, rec)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 {
+		return nil, fmt.Errorf("%s has no records", path)
+	}
+
+	// 没有开抢时间的记录（扫货）以文件中第一条记录为零点。
+	firstAtMs := recs[0].AtMs
+	for _, rec := range recs {
+		if rec.AtMs < firstAtMs {
+			firstAtMs = rec.AtMs
+		}
+	}
+	r := &replayer{rushAt: rushAt, byAPI: make(map[string][]standard.CaptureRecord)}
+	for _, rec := range recs {
+		if rec.RushAtMs <= 0 {
+			rec.OffsetMs = rec.AtMs - firstAtMs
+		}
+		r.byAPI[rec.API] = append(r.byAPI[rec.API], rec)
+	}
+	for api := range r.byAPI {
+		list := r.byAPI[api]
+		sort.SliceStable(list, func(i, j int) bool { return list[i].OffsetMs < list[j].OffsetMs })
+	}
+	return r, nil
+}
+
+// pick 返回当前时刻在原时间线上对应的记录：偏移不晚于当前的最后一条；开抢前取第一条。
+func (r *replayer) pick(api string, now time.Time) (standard.CaptureRecord, bool) {
+	list := r.byAPI[api]
+	if len(list) == 0 {
+		return standard.CaptureRecord{}, false
+	}
+	offset := now.Sub(r.rushAt).Milliseconds()
+	idx := sort.Search(len(list), func(i int) bool { return list[i].OffsetMs > offset }) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return list[idx], true
+}
+
+func (r *replayer) handler(api string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rec, ok := r.pick(api, time.Now())
+		if !ok {
+			http.Error(w, "no recorded "+api+" responses", http.StatusNotFound)
+			return
+		}
+		if rec.LatencyMs > 0 {
+			t := time.NewTimer(time.Duration(rec.LatencyMs) * time.Millisecond)
+			select {
+			case <-req.Context().Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+		status := rec.Status
+		if status <= 0 {
+			status = http.StatusOK
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write(rec.Body)
+	}
+}
+
+func (r *replayer) register(mux *http.ServeMux) {
+	mux.HandleFunc("/mock/api/trade/buy/render-order", r.handler("render-order"))
+	mux.HandleFunc("/mock/api/trade/buy/create-order", r.handler("create-order"))
+	mux.HandleFunc("/mock/replay/status", func(w http.ResponseWriter, _ *http.Request) {
+		counts := make(map[string]int, len(r.byAPI))
+		for api, list := range r.byAPI {
+			counts[api] = len(list)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"rushAtMs": r.rushAt.UnixMilli(),
+			"offsetMs": time.Since(r.rushAt).Milliseconds(),
+			"records":  counts,
+		})
+	})
+}
@@ -16,11 +16,13 @@ import (
 	"time"
 
 	"sniping_engine/internal/config"
+	"sniping_engine/internal/credentials"
 	"sniping_engine/internal/engine"
 	"sniping_engine/internal/httpapi"
 	"sniping_engine/internal/logbus"
 	"sniping_engine/internal/model"
 	"sniping_engine/internal/notify"
+	"sniping_engine/internal/provider"
 	"sniping_engine/internal/provider/standard"
 	"sniping_engine/internal/store/sqlite"
 	"sniping_engine/internal/utils"
@@ -35,7 +37,8 @@ func main() {
 		log.Fatalf("load config: %v", err)
 	}
 
-	bus := logbus.New(200)
+	bus := logbus.New(2000)
+	bus.SetRedaction(cfg.Log.RedactEnabled(), cfg.Log.RedactKeys)
 	stopConsole := startConsoleLogger(bus)
 	defer stopConsole()
 
@@ -46,13 +49,39 @@ func main() {
 	}
 	defer store.Close()
 
-	if v, ok, err := store.GetLimitsSettings(ctx); err == nil && ok {
-		if v.MaxPerTargetInFlight > 0 {
-			cfg.Limits.MaxPerTargetInFlight = v.MaxPerTargetInFlight
+	store.SetMobileCountry(cfg.Accounts.DefaultCountry)
+	if res, err := store.NormalizeMobiles(ctx); err != nil {
+		log.Fatalf("normalize mobiles: %v", err)
+	} else {
+		if res.Normalized > 0 {
+			bus.Log("info", "已规范化账号手机号", map[string]any{"accounts": res.Normalized})
+		}
+		for _, c := range res.Conflicts {
+			bus.Log("warn", "账号手机号规范化后重复，保留原值，请手动合并", map[string]any{"accountId": c.AccountID, "mobile": c.Mobile, "normalized": c.Normalized, "conflictsWith": c.ConflictsWith})
 		}
-		if v.CaptchaMaxInFlight > 0 {
-			cfg.Limits.CaptchaMaxInFlight = v.CaptchaMaxInFlight
+		if len(res.Invalid) > 0 {
+			bus.Log("warn", "账号手机号格式无法识别，保留原值", map[string]any{"accountIds": res.Invalid})
 		}
+	}
+
+	credSource, err := credentials.New(cfg.Credentials)
+	if err != nil {
+		log.Fatalf("credentials source: %v", err)
+	}
+	if credSource != nil {
+		store.SetCredentialSource(credSource)
+	}
+	// 默认 sqlite 来源也要检查：账号凭据仍在外部来源时拒绝启动，避免读到空凭据后写回。
+	if n, err := store.MigrateCredentials(ctx); err != nil {
+		log.Fatalf("migrate credentials: %v", err)
+	} else if n > 0 {
+		bus.Log("info", "账号凭据已迁移到外部来源", map[string]any{"source": credSource.Name(), "accounts": n})
+	}
+
+	var limitsAutoTune bool
+	if v, ok, err := store.GetLimitsSettings(ctx); err == nil && ok {
+		cfg.Limits = cfg.Limits.WithSettings(v)
+		limitsAutoTune = v.AutoTune
 	} else if err != nil {
 		bus.Log("warn", "读取并发设置失败", map[string]any{"error": err.Error()})
 	}
@@ -64,6 +93,7 @@ func main() {
 			WarmupSeconds:  v.WarmupSeconds,
 			PoolSize:       v.PoolSize,
 			ItemTTLSeconds: v.ItemTTLSeconds,
+			ScanPoolSize:   v.ScanPoolSize,
 		}
 	} else if err != nil {
 		bus.Log("warn", "读取验证码池设置失败", map[string]any{"error": err.Error()})
@@ -76,6 +106,37 @@ func main() {
 		bus.Log("warn", "读取通知设置失败", map[string]any{"error": err.Error()})
 	}
 
+	catalogSettings := engine.DefaultCatalogSettings()
+	if v, ok, err := store.GetCatalogSettings(ctx); err == nil && ok {
+		catalogSettings = v
+	} else if err != nil {
+		bus.Log("warn", "读取商品目录设置失败", map[string]any{"error": err.Error()})
+	}
+
+	retentionSettings := engine.DefaultRetentionSettings()
+	if v, ok, err := store.GetRetentionSettings(ctx); err == nil && ok {
+		retentionSettings = v
+	} else if err != nil {
+		bus.Log("warn", "读取数据保留设置失败", map[string]any{"error": err.Error()})
+	}
+
+	dropCalendar := engine.DefaultDropCalendar()
+	if v, ok, err := store.GetDropCalendar(ctx); err == nil && ok {
+		dropCalendar = v
+	} else if err != nil {
+		bus.Log("warn", "读取上新日历失败", map[string]any{"error": err.Error()})
+	}
+
+	if v, ok, err := store.GetCompatSettings(ctx); err == nil && ok {
+		if _, err := engine.ApplyCompatSettings(v); err != nil {
+			bus.Log("warn", "兼容性设置无效，使用默认值", map[string]any{"error": err.Error()})
+			_, _ = engine.ApplyCompatSettings(engine.DefaultCompatSettings())
+		}
+	} else if err != nil {
+		bus.Log("warn", "读取兼容性设置失败", map[string]any{"error": err.Error()})
+	}
+
+	utils.SetCaptchaOptions(utils.CaptchaOptionsFromEnv(captchaOptionsFromConfig(cfg.Captcha)))
 	utils.SetCaptchaMaxConcurrent(cfg.Limits.CaptchaMaxInFlight)
 	utils.SetCaptchaEngineState(utils.CaptchaEngineStateStarting, "", 0)
 	go func() {
@@ -91,18 +152,68 @@ func main() {
 		})
 	}()
 
+	codes := provider.NewCodeBook()
+	if known, err := store.ListBizCodes(ctx); err != nil {
+		bus.Log("warn", "读取业务码字典失败", map[string]any{"error": err.Error()})
+	} else {
+		unknown, err := store.ListUnknownBizCodes(ctx)
+		if err != nil {
+			bus.Log("warn", "读取未登记业务码失败", map[string]any{"error": err.Error()})
+		}
+		codes.Load(known, unknown)
+	}
+	codes.SetUnknownHandler(func(u model.UnknownBizCode) {
+		if u.Count == 1 {
+			bus.Log("warn", "遇到未登记的上游业务码", map[string]any{"code": u.Code, "api": u.API, "message": u.Message})
+		}
+		saveCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := store.UpsertUnknownBizCode(saveCtx, u); err != nil {
+			bus.Log("warn", "保存未登记业务码失败", map[string]any{"code": u.Code, "error": err.Error()})
+		}
+	})
+
 	prov := standard.New(cfg.Provider, cfg.Proxy, bus)
-	emailNotifier := notify.NewEmailNotifier(store, bus)
+	prov.SetCodeBook(codes)
+	prov.SetSlowRequestHandler(func(rec model.SlowRequest) {
+		saveCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := store.InsertSlowRequest(saveCtx, rec); err != nil {
+			bus.Log("warn", "保存慢请求记录失败", map[string]any{"path": rec.Path, "error": err.Error()})
+		}
+	})
+	// 演练 provider 不走代理池，请求只发往 mock 服务。
+	var practiceProv provider.Provider
+	if practiceCfg, ok := cfg.Provider.PracticeProviderConfig(); ok {
+		pp := standard.New(practiceCfg, config.ProxyConfig{}, bus)
+		pp.SetCodeBook(codes)
+		practiceProv = pp
+	}
+	emailNotifier := notify.NewEmailNotifierWithOptions(store, bus, notify.EmailNotifierOptions{
+		QueueSize: cfg.Notify.QueueSize,
+		Overflow:  cfg.Notify.Overflow,
+	})
 	eng := engine.New(engine.Options{
-		Store:    store,
-		Provider: prov,
-		Bus:      bus,
-		Limits:   cfg.Limits,
-		Task:     cfg.Task,
-		Notifier: emailNotifier,
+		Store:            store,
+		Provider:         prov,
+		PracticeProvider: practiceProv,
+		Bus:              bus,
+		Limits:           cfg.Limits,
+		Task:             cfg.Task,
+		Notifier:         emailNotifier,
+
+		CriticalCookies: cfg.Provider.CriticalCookies,
+		Environment:     cfg.Server.Environment,
 	})
 	_ = eng.SetCaptchaPoolSettings(captchaPoolSettings)
 	_ = eng.SetNotifySettings(notifySettings)
+	_ = eng.SetCatalogSettings(catalogSettings)
+	_ = eng.SetRetentionSettings(retentionSettings)
+	_ = eng.SetDropCalendar(dropCalendar)
+	eng.SetRateAutoTune(limitsAutoTune)
+	eng.StartCatalogRefresher(ctx)
+	eng.StartClockCalibrator(ctx)
+	eng.StartRetentionPruner(ctx)
 
 	api := httpapi.New(httpapi.Options{
 		Cfg:      cfg,
@@ -110,6 +221,7 @@ func main() {
 		Store:    store,
 		Engine:   eng,
 		Notifier: emailNotifier,
+		CodeBook: codes,
 	})
 
 	server := &http.Server{
@@ -167,12 +279,39 @@ func main() {
 	defer cancel()
 
 	_ = eng.StopAll(shutdownCtx)
+	_ = eng.WaitOrderHooks(shutdownCtx)
+	_ = eng.FlushAttemptLog(shutdownCtx)
 	_ = emailNotifier.Close(shutdownCtx)
 	_ = server.Shutdown(shutdownCtx)
 	_ = utils.CloseCaptchaBrowser()
 	bus.Log("info", "服务已停止", nil)
 }
 
+// captchaOptionsFromConfig 把配置文件中的 captcha 段转换为求解参数（环境变量覆盖在其后应用）。
+func captchaOptionsFromConfig(c config.CaptchaConfig) utils.CaptchaOptions {
+	o := utils.DefaultCaptchaOptions()
+	o.Headless = c.HeadlessEnabled()
+	if c.Fast {
+		o.SleepScale = 0.35
+	}
+	if c.SleepScale > 0 {
+		o.SleepScale = c.SleepScale
+	}
+	o.WarmPages = c.WarmPages
+	o.BlockResources = c.BlockResources
+	o.Debug = c.Debug
+	if c.MaxTries > 0 {
+		o.MaxTries = c.MaxTries
+	}
+	if c.Solver != "" {
+		o.Solver = c.Solver
+	}
+	if c.MockDelayMs > 0 {
+		o.MockDelayMs = c.MockDelayMs
+	}
+	return o
+}
+
 func startConsoleLogger(bus *logbus.Bus) func() {
 	if bus == nil {
 		return func() {}
@@ -234,6 +373,9 @@ func printStartupBanner(cfg config.Config, configPath string, hostPort string) {
 	if strings.TrimSpace(cfg.Provider.BaseURL) != "" {
 		fmt.Printf("Upstream  : %s\n", strings.TrimSpace(cfg.Provider.BaseURL))
 	}
+	if strings.TrimSpace(cfg.Provider.PracticeBaseURL) != "" {
+		fmt.Printf("Practice  : %s\n", strings.TrimSpace(cfg.Provider.PracticeBaseURL))
+	}
 	if strings.TrimSpace(cfg.Proxy.Global) != "" {
 		fmt.Printf("Proxy     : %s\n", strings.TrimSpace(cfg.Proxy.Global))
 	}
@@ -0,0 +1,70 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFakeTimerFiresOnlyAfterAdvance(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+	timer := f.NewTimer(5 * time.Second)
+
+	f.Advance(4 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	f.Advance(time.Second)
+	select {
+	case at := <-timer.C():
+		if !at.Equal(start.Add(5 * time.Second)) {
+			t.Fatalf("fired at %v", at)
+		}
+	default:
+		t.Fatal("timer did not fire")
+	}
+	if f.Waiters() != 0 {
+		t.Fatalf("waiters = %d, want 0", f.Waiters())
+	}
+}
+
+func TestFakeTickerAndStop(t *testing.T) {
+	f := NewFake(time.Unix(0, 0))
+	tk := f.NewTicker(time.Second)
+
+	fired := 0
+	for i := 0; i < 3; i++ {
+		f.Advance(time.Second)
+		select {
+		case <-tk.C():
+			fired++
+		default:
+		}
+	}
+	if fired != 3 {
+		t.Fatalf("fired = %d, want 3", fired)
+	}
+
+	tk.Stop()
+	f.Advance(time.Second)
+	select {
+	case <-tk.C():
+		t.Fatal("stopped ticker fired")
+	default:
+	}
+}
+
+func TestFakeBlockUntil(t *testing.T) {
+	f := NewFake(time.Unix(0, 0))
+	go func() { f.NewTimer(time.Minute) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if !f.BlockUntil(ctx, 1) {
+		t.Fatal("BlockUntil timed out")
+	}
+}
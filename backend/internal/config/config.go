@@ -1,11 +1,16 @@
 package config
 
 import (
+	"crypto/subtle"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"sniping_engine/internal/model"
 )
 
 type Config struct {
@@ -15,11 +20,115 @@ type Config struct {
 	Limits   LimitsConfig   `yaml:"limits"`
 	Task     TaskConfig     `yaml:"task"`
 	Provider ProviderConfig `yaml:"provider"`
+	// Captcha 验证码求解参数；同名环境变量 SNIPING_ENGINE_CAPTCHA_* 优先。
+	Captcha CaptchaConfig `yaml:"captcha"`
+	// Credentials 账号 token/cookie 的存放位置，默认直接存 sqlite。
+	Credentials CredentialsConfig `yaml:"credentials"`
+	Notify      NotifyConfig      `yaml:"notify"`
+	Accounts    AccountsConfig    `yaml:"accounts"`
+	Log         LogConfig         `yaml:"log"`
+}
+
+// LogConfig 日志总线参数。
+type LogConfig struct {
+	// Redact 是否隐去日志字段中的凭据（默认开启，本地调试可设为 false）。
+	Redact *bool `yaml:"redact"`
+	// RedactKeys 需要脱敏的字段名片段（不区分大小写），为空时使用 token/authorization/cookie/authCode。
+	RedactKeys []string `yaml:"redactKeys"`
+}
+
+func (c LogConfig) RedactEnabled() bool {
+	return c.Redact == nil || *c.Redact
+}
+
+// AccountsConfig 账号相关参数。
+type AccountsConfig struct {
+	// DefaultCountry 手机号的默认国家/地区区号，默认 86；该区号下的号码按本地号码保存，其他号码保存为 +区号号码。
+	DefaultCountry string `yaml:"defaultCountry"`
+}
+
+// NotifyConfig 下单邮件通知队列参数。
+type NotifyConfig struct {
+	// QueueSize 待发送事件的队列长度，默认 200。
+	QueueSize int `yaml:"queueSize"`
+	// Overflow 队列满时的策略：drop-newest（默认，丢弃新事件）/ drop-oldest（挤掉最早的事件）。
+	Overflow string `yaml:"overflow"`
+}
+
+func (c NotifyConfig) validate() error {
+	if c.QueueSize < 0 || c.QueueSize > 100000 {
+		return fmt.Errorf("notify.queueSize must be within 0-100000, got %d", c.QueueSize)
+	}
+	switch strings.TrimSpace(c.Overflow) {
+	case "", "drop-newest", "drop-oldest":
+	default:
+		return fmt.Errorf("notify.overflow must be drop-newest or drop-oldest, got %q", c.Overflow)
+	}
+	return nil
 }
 
 type ServerConfig struct {
 	Addr string     `yaml:"addr"`
 	Cors CorsConfig `yaml:"cors"`
+	// DeleteProtection 开启后，删除仍被启用目标或近期订单引用的账号/目标需要先调用 prepare-delete 取得确认令牌。
+	DeleteProtection bool `yaml:"deleteProtection"`
+	// Environment 本实例的环境标记（如 production/staging）；为 production 时启动带其他环境标记的目标会告警。为空表示不区分。
+	Environment string `yaml:"environment"`
+	// Auth 接入令牌；配置后 WebSocket（/ws）和最近消息接口（/api/v1/events/recent）必须携带令牌。
+	Auth AuthConfig `yaml:"auth"`
+}
+
+// 接入令牌的角色：admin 收到完整消息，viewer 收到的日志字段会隐去凭据类内容。
+const (
+	RoleAdmin  = "admin"
+	RoleViewer = "viewer"
+)
+
+type AuthConfig struct {
+	Tokens []AuthToken `yaml:"tokens"`
+}
+
+type AuthToken struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+	// Role 为 admin 或 viewer，留空按 viewer 处理。
+	Role string `yaml:"role"`
+}
+
+// Enabled 表示配置了接入令牌，需要鉴权。
+func (c AuthConfig) Enabled() bool { return len(c.Tokens) > 0 }
+
+// Lookup 按令牌查找接入身份。
+func (c AuthConfig) Lookup(token string) (AuthToken, bool) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return AuthToken{}, false
+	}
+	for _, t := range c.Tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			return t, true
+		}
+	}
+	return AuthToken{}, false
+}
+
+func (c AuthConfig) validate() error {
+	seen := map[string]bool{}
+	for i, t := range c.Tokens {
+		if strings.TrimSpace(t.Token) == "" {
+			return fmt.Errorf("server.auth.tokens[%d].token is required", i)
+		}
+		if seen[t.Token] {
+			return fmt.Errorf("server.auth.tokens[%d]: duplicate token", i)
+		}
+		seen[t.Token] = true
+		switch t.Role {
+		case RoleAdmin, RoleViewer:
+		default:
+			return fmt.Errorf("server.auth.tokens[%d].role must be admin or viewer, got %q", i, t.Role)
+		}
+	}
+	return nil
 }
 
 type CorsConfig struct {
@@ -31,6 +140,28 @@ type StorageConfig struct {
 	SQLitePath string `yaml:"sqlitePath"`
 }
 
+type CredentialsConfig struct {
+	// Source 可选 sqlite / file / vault。
+	Source string                 `yaml:"source"`
+	File   CredentialsFileConfig  `yaml:"file"`
+	Vault  CredentialsVaultConfig `yaml:"vault"`
+}
+
+type CredentialsFileConfig struct {
+	Path string `yaml:"path"`
+	// KeyEnv 保存加密口令的环境变量名，默认 SNIPING_CREDENTIALS_KEY。
+	KeyEnv string `yaml:"keyEnv"`
+}
+
+type CredentialsVaultConfig struct {
+	// Addr 为空时使用环境变量 VAULT_ADDR。
+	Addr       string `yaml:"addr"`
+	Mount      string `yaml:"mount"`
+	PathPrefix string `yaml:"pathPrefix"`
+	// TokenEnv 保存 Vault token 的环境变量名，默认 VAULT_TOKEN。
+	TokenEnv string `yaml:"tokenEnv"`
+}
+
 type ProxyConfig struct {
 	Global string `yaml:"global"`
 }
@@ -49,9 +180,68 @@ type LimitsConfig struct {
 	CaptchaMaxInFlight int `yaml:"captchaMaxInFlight"`
 }
 
+// WithSettings 用页面上保存的并发/限速设置覆盖配置文件中的值（设置为 0 的字段不覆盖）。
+func (c LimitsConfig) WithSettings(s model.LimitsSettings) LimitsConfig {
+	if s.MaxPerTargetInFlight > 0 {
+		c.MaxPerTargetInFlight = s.MaxPerTargetInFlight
+	}
+	if s.CaptchaMaxInFlight > 0 {
+		c.CaptchaMaxInFlight = s.CaptchaMaxInFlight
+	}
+	if s.GlobalQPS > 0 {
+		c.GlobalQPS = s.GlobalQPS
+	}
+	if s.GlobalBurst > 0 {
+		c.GlobalBurst = s.GlobalBurst
+	}
+	if s.PerAccountQPS > 0 {
+		c.PerAccountQPS = s.PerAccountQPS
+	}
+	if s.PerAccountBurst > 0 {
+		c.PerAccountBurst = s.PerAccountBurst
+	}
+	if s.MaxInFlight > 0 {
+		c.MaxInFlight = s.MaxInFlight
+	}
+	return c
+}
+
 type TaskConfig struct {
 	RushIntervalMs int `yaml:"rushIntervalMs"`
 	ScanIntervalMs int `yaml:"scanIntervalMs"`
+	// HighResTimer 开启后等待开抢/抢购节拍使用高精度计时：Windows 下调高系统计时器精度，
+	// 并在最后 SpinWaitMs 毫秒内自旋等待，减少 ~15ms 的计时误差。
+	HighResTimer bool `yaml:"highResTimer"`
+	SpinWaitMs   int  `yaml:"spinWaitMs"`
+	// ClockSync 定期用上游响应的 Date 头估算本机时钟与上游的偏差，等待开抢时按偏差修正。
+	ClockSync ClockSyncConfig `yaml:"clockSync"`
+	// StopGraceMs 停止引擎时等待进行中的 create-order 完成的最长时间，0 为默认 3000，-1 表示立即取消。
+	StopGraceMs int `yaml:"stopGraceMs"`
+}
+
+type ClockSyncConfig struct {
+	Disabled bool `yaml:"disabled"`
+	// IntervalMinutes 校准间隔，默认 10 分钟。
+	IntervalMinutes int `yaml:"intervalMinutes"`
+	// Samples 每次校准的采样次数，默认 8（Date 头只精确到秒，多次错开采样才能收窄偏差区间）。
+	Samples int `yaml:"samples"`
+}
+
+func (c ClockSyncConfig) Interval() time.Duration {
+	if c.IntervalMinutes <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(c.IntervalMinutes) * time.Minute
+}
+
+func (c ClockSyncConfig) SampleCount() int {
+	if c.Samples <= 0 {
+		return 8
+	}
+	if c.Samples > 30 {
+		return 30
+	}
+	return c.Samples
 }
 
 func (c TaskConfig) RushInterval() time.Duration {
@@ -61,6 +251,30 @@ func (c TaskConfig) RushInterval() time.Duration {
 	return time.Duration(c.RushIntervalMs) * time.Millisecond
 }
 
+func (c TaskConfig) SpinWait() time.Duration {
+	if !c.HighResTimer {
+		return 0
+	}
+	if c.SpinWaitMs <= 0 {
+		return 2 * time.Millisecond
+	}
+	if c.SpinWaitMs > 50 {
+		return 50 * time.Millisecond
+	}
+	return time.Duration(c.SpinWaitMs) * time.Millisecond
+}
+
+// StopGrace 返回停止引擎时进行中下单的宽限期。
+func (c TaskConfig) StopGrace() time.Duration {
+	if c.StopGraceMs < 0 {
+		return 0
+	}
+	if c.StopGraceMs == 0 {
+		return 3 * time.Second
+	}
+	return time.Duration(c.StopGraceMs) * time.Millisecond
+}
+
 func (c TaskConfig) ScanInterval() time.Duration {
 	if c.ScanIntervalMs <= 0 {
 		return 1 * time.Second
@@ -75,6 +289,159 @@ type ProviderConfig struct {
 	UserAgent  string           `yaml:"userAgent"`
 	DeviceID   string           `yaml:"deviceId"`
 	DeviceType string           `yaml:"deviceType"`
+	// OrderSource/DeviceSource 下单请求里的来源字段默认值，目标可单独覆盖。
+	OrderSource  string `yaml:"orderSource"`
+	DeviceSource string `yaml:"deviceSource"`
+	// CriticalCookies 下单链路依赖的 cookie 名；为空时检查所有带有效期的 cookie。
+	CriticalCookies []string `yaml:"criticalCookies"`
+	// VerifyToken 部分会话会下发“一键验证”免滑块凭证，识别到后优先于滑块求解使用。
+	VerifyToken VerifyTokenConfig `yaml:"verifyToken"`
+	// CaptureFile 非空时把 render-order/create-order 的上游响应（含耗时、相对开抢的时刻）追加写入该 JSONL 文件，
+	// 供 `cmd/mock -replay` 按原时间线回放（演练模式）。
+	CaptureFile string `yaml:"captureFile"`
+	// BootstrapPaths 补全会话（bootstrap-session）时依次访问的路径（相对 baseURL，可带查询串）；为空时使用内置列表。
+	BootstrapPaths []string `yaml:"bootstrapPaths"`
+	// SlowRequestMs 上游请求（含重试）耗时达到该值时记录分阶段追踪并发布 slow_request 消息；0 为默认 1500，负数关闭。
+	SlowRequestMs int `yaml:"slowRequestMs"`
+	// PracticeBaseURL 演练 provider 的上游地址（通常为 mock 服务）；非空时标记为演练（practice）的目标改走该地址，
+	// 其余目标仍使用 baseURL。为空时不能运行演练目标。
+	PracticeBaseURL string `yaml:"practiceBaseURL"`
+	// CircuitBreaker 上游接口熔断，见 CircuitBreakerConfig。
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuitBreaker"`
+}
+
+// PracticeProviderConfig 返回演练 provider 的配置：沿用 provider 的其余设置，只替换上游地址并关闭响应录制。
+// 未配置 practiceBaseURL 时返回 false。
+func (c ProviderConfig) PracticeProviderConfig() (ProviderConfig, bool) {
+	baseURL := strings.TrimSpace(c.PracticeBaseURL)
+	if baseURL == "" {
+		return ProviderConfig{}, false
+	}
+	out := c
+	out.BaseURL = baseURL
+	out.PracticeBaseURL = ""
+	out.CaptureFile = ""
+	return out, true
+}
+
+// VerifyTokenConfig 控制免滑块凭证的识别与使用；零值表示按默认字段名启用。
+type VerifyTokenConfig struct {
+	Disabled bool `yaml:"disabled"`
+	// ResponseKeys 在 render-order/create-order 响应（含 extra）中查找凭证的字段名。
+	ResponseKeys []string `yaml:"responseKeys"`
+	// RequestField 下单时把凭证放进 extra 的字段名。
+	RequestField string `yaml:"requestField"`
+	// TTLSeconds 响应未带过期时间时凭证的缓存时长。
+	TTLSeconds int `yaml:"ttlSeconds"`
+}
+
+func (c VerifyTokenConfig) Keys() []string {
+	if len(c.ResponseKeys) == 0 {
+		return []string{"verifyToken", "oneClickVerifyToken", "captchaPassToken"}
+	}
+	return c.ResponseKeys
+}
+
+func (c VerifyTokenConfig) Field() string {
+	if strings.TrimSpace(c.RequestField) == "" {
+		return "verifyToken"
+	}
+	return strings.TrimSpace(c.RequestField)
+}
+
+func (c VerifyTokenConfig) TTL() time.Duration {
+	if c.TTLSeconds <= 0 {
+		return 2 * time.Minute
+	}
+	return time.Duration(c.TTLSeconds) * time.Second
+}
+
+// CircuitBreakerConfig 控制上游接口熔断；零值表示按默认参数保护 render-order 与 create-order。
+// 统计窗口内请求数达到 minRequests 且失败（网络错误与 5xx）占比达到 errorRate 时打开，该接口的请求直接失败；
+// openSeconds 后放行 halfOpenProbes 个探测请求，探测成功即恢复，失败则重新打开。
+type CircuitBreakerConfig struct {
+	Disabled bool `yaml:"disabled"`
+	// APIs 受保护的接口名，为空时为 render-order、create-order。
+	APIs           []string `yaml:"apis"`
+	WindowSeconds  int      `yaml:"windowSeconds"`
+	MinRequests    int      `yaml:"minRequests"`
+	ErrorRate      float64  `yaml:"errorRate"`
+	OpenSeconds    int      `yaml:"openSeconds"`
+	HalfOpenProbes int      `yaml:"halfOpenProbes"`
+}
+
+func (c CircuitBreakerConfig) ProtectedAPIs() []string {
+	if c.Disabled {
+		return nil
+	}
+	if len(c.APIs) == 0 {
+		return []string{"render-order", "create-order"}
+	}
+	return c.APIs
+}
+
+func (c CircuitBreakerConfig) Window() time.Duration {
+	if c.WindowSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.WindowSeconds) * time.Second
+}
+
+func (c CircuitBreakerConfig) OpenFor() time.Duration {
+	if c.OpenSeconds <= 0 {
+		return 15 * time.Second
+	}
+	return time.Duration(c.OpenSeconds) * time.Second
+}
+
+func (c CircuitBreakerConfig) validate() error {
+	if c.ErrorRate < 0 || c.ErrorRate > 1 {
+		return fmt.Errorf("provider.circuitBreaker.errorRate must be within 0-1, got %v", c.ErrorRate)
+	}
+	return nil
+}
+
+// CaptchaConfig 验证码求解（无头浏览器）参数，零值即默认行为。
+type CaptchaConfig struct {
+	// Headless 为空时默认无头；本地调试可设为 false 打开浏览器窗口。
+	Headless *bool `yaml:"headless"`
+	// Fast 把等待倍率设为 0.35；SleepScale 显式配置时以 SleepScale 为准。
+	Fast       bool    `yaml:"fast"`
+	SleepScale float64 `yaml:"sleepScale"`
+	// WarmPages 预热页面数（1-8），0 表示跟随 limits.captchaMaxInFlight。
+	WarmPages      int  `yaml:"warmPages"`
+	BlockResources bool `yaml:"blockResources"`
+	Debug          bool `yaml:"debug"`
+	// MaxTries 单次求解的最大尝试次数（1-10），默认 3。
+	MaxTries int `yaml:"maxTries"`
+	// Solver 可选 browser（默认）/ mock；mock 不启动浏览器，延迟 MockDelayMs（默认 300）后返回伪造结果，供 CI/演示使用。
+	Solver      string `yaml:"solver"`
+	MockDelayMs int    `yaml:"mockDelayMs"`
+}
+
+func (c CaptchaConfig) HeadlessEnabled() bool {
+	return c.Headless == nil || *c.Headless
+}
+
+func (c CaptchaConfig) validate() error {
+	if c.SleepScale < 0 || c.SleepScale > 1 {
+		return fmt.Errorf("captcha.sleepScale must be within (0, 1] or 0 for default, got %v", c.SleepScale)
+	}
+	if c.WarmPages < 0 || c.WarmPages > 8 {
+		return fmt.Errorf("captcha.warmPages must be within 0-8, got %d", c.WarmPages)
+	}
+	if c.MaxTries < 0 || c.MaxTries > 10 {
+		return fmt.Errorf("captcha.maxTries must be within 0-10, got %d", c.MaxTries)
+	}
+	switch strings.ToLower(strings.TrimSpace(c.Solver)) {
+	case "", "browser", "mock":
+	default:
+		return fmt.Errorf("captcha.solver must be browser or mock, got %q", c.Solver)
+	}
+	if c.MockDelayMs < 0 || c.MockDelayMs > 60000 {
+		return fmt.Errorf("captcha.mockDelayMs must be within 0-60000, got %d", c.MockDelayMs)
+	}
+	return nil
 }
 
 type ProviderRetryCfg struct {
@@ -90,6 +457,17 @@ func (c ProviderConfig) Timeout() time.Duration {
 	return time.Duration(c.TimeoutMs) * time.Millisecond
 }
 
+// SlowRequestThreshold 返回慢请求追踪阈值，0 表示关闭。
+func (c ProviderConfig) SlowRequestThreshold() time.Duration {
+	if c.SlowRequestMs < 0 {
+		return 0
+	}
+	if c.SlowRequestMs == 0 {
+		return 1500 * time.Millisecond
+	}
+	return time.Duration(c.SlowRequestMs) * time.Millisecond
+}
+
 func (c ProviderRetryCfg) Wait() time.Duration {
 	if c.WaitMs <= 0 {
 		return 200 * time.Millisecond
@@ -152,9 +530,30 @@ func (c *Config) applyDefaults() {
 	if c.Provider.DeviceType == "" {
 		c.Provider.DeviceType = "WXAPP"
 	}
+	if c.Provider.OrderSource == "" {
+		c.Provider.OrderSource = model.OrderSourceProductDetail
+	}
+	if c.Provider.DeviceSource == "" {
+		c.Provider.DeviceSource = model.DeviceSourceWXAPP
+	}
 	if c.Provider.Retry.Count < 0 {
 		c.Provider.Retry.Count = 0
 	}
+	if env, err := model.NormalizeEnv(c.Server.Environment); err == nil {
+		c.Server.Environment = env
+	}
+	for i := range c.Server.Auth.Tokens {
+		t := &c.Server.Auth.Tokens[i]
+		t.Name = strings.TrimSpace(t.Name)
+		t.Token = strings.TrimSpace(t.Token)
+		t.Role = strings.ToLower(strings.TrimSpace(t.Role))
+		if t.Role == "" {
+			t.Role = RoleViewer
+		}
+	}
+	if c.Accounts.DefaultCountry == "" {
+		c.Accounts.DefaultCountry = model.DefaultMobileCountry
+	}
 }
 
 func (c Config) validate() error {
@@ -164,5 +563,26 @@ func (c Config) validate() error {
 	if c.Provider.BaseURL == "" {
 		return errors.New("provider.baseURL is required")
 	}
+	if err := model.ValidateTradeSources(c.Provider.OrderSource, c.Provider.DeviceSource); err != nil {
+		return fmt.Errorf("provider: %w", err)
+	}
+	if _, err := model.NormalizeEnv(c.Server.Environment); err != nil {
+		return fmt.Errorf("server.environment: %w", err)
+	}
+	if err := c.Provider.CircuitBreaker.validate(); err != nil {
+		return err
+	}
+	if err := c.Captcha.validate(); err != nil {
+		return err
+	}
+	if err := c.Server.Auth.validate(); err != nil {
+		return err
+	}
+	if err := c.Notify.validate(); err != nil {
+		return err
+	}
+	if err := model.ValidateMobileCountry(c.Accounts.DefaultCountry); err != nil {
+		return fmt.Errorf("accounts.defaultCountry: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,48 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sniping_engine/internal/config"
+	"sniping_engine/internal/model"
+)
+
+// 凭据来源：sqlite 表示 token/cookie 仍直接存在账号表里（默认）；
+// 其他来源只在账号表里保存一个引用，使用时再按账号 ID 到外部读取。
+const (
+	SourceSQLite = "sqlite"
+	SourceFile   = "file"
+	SourceVault  = "vault"
+)
+
+// Credentials 是账号的敏感登录态。
+type Credentials struct {
+	Token   string                 `json:"token,omitempty"`
+	Cookies []model.CookieJarEntry `json:"cookies,omitempty"`
+}
+
+// Source 按账号 ID 读写外部凭据。Load 在不存在时返回 ok=false。
+type Source interface {
+	Name() string
+	// Ref 返回写入账号表的引用（仅用于展示/排查，不含敏感信息）。
+	Ref(accountID string) string
+	Load(ctx context.Context, accountID string) (Credentials, bool, error)
+	Save(ctx context.Context, accountID string, c Credentials) error
+	Delete(ctx context.Context, accountID string) error
+}
+
+// New 按配置创建凭据来源；使用默认的 sqlite 时返回 nil。
+func New(cfg config.CredentialsConfig) (Source, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Source)) {
+	case "", SourceSQLite:
+		return nil, nil
+	case SourceFile:
+		return NewFileSource(cfg.File)
+	case SourceVault:
+		return NewVaultSource(cfg.Vault)
+	default:
+		return nil, fmt.Errorf("unknown credentials source: %s", cfg.Source)
+	}
+}
@@ -0,0 +1,135 @@
+package credentials
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"sniping_engine/internal/config"
+)
+
+// FileSource 把所有账号的凭据保存在一个 AES-GCM 加密的 JSON 文件里，密钥来自环境变量。
+type FileSource struct {
+	path string
+	aead cipher.AEAD
+	mu   sync.Mutex
+}
+
+func NewFileSource(cfg config.CredentialsFileConfig) (*FileSource, error) {
+	path := strings.TrimSpace(cfg.Path)
+	if path == "" {
+		return nil, errors.New("credentials.file.path is required")
+	}
+	keyEnv := strings.TrimSpace(cfg.KeyEnv)
+	if keyEnv == "" {
+		keyEnv = "SNIPING_CREDENTIALS_KEY"
+	}
+	secret := os.Getenv(keyEnv)
+	if secret == "" {
+		return nil, fmt.Errorf("credentials file key is empty (env %s)", keyEnv)
+	}
+	// 任意长度的口令都先派生成 32 字节密钥。
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSource{path: path, aead: aead}, nil
+}
+
+func (f *FileSource) Name() string { return SourceFile }
+
+func (f *FileSource) Ref(accountID string) string { return SourceFile + ":" + accountID }
+
+func (f *FileSource) Load(_ context.Context, accountID string) (Credentials, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	all, err := f.readLocked()
+	if err != nil {
+		return Credentials{}, false, err
+	}
+	c, ok := all[accountID]
+	return c, ok, nil
+}
+
+func (f *FileSource) Save(_ context.Context, accountID string, c Credentials) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	all, err := f.readLocked()
+	if err != nil {
+		return err
+	}
+	all[accountID] = c
+	return f.writeLocked(all)
+}
+
+func (f *FileSource) Delete(_ context.Context, accountID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	all, err := f.readLocked()
+	if err != nil {
+		return err
+	}
+	if _, ok := all[accountID]; !ok {
+		return nil
+	}
+	delete(all, accountID)
+	return f.writeLocked(all)
+}
+
+func (f *FileSource) readLocked() (map[string]Credentials, error) {
+	out := make(map[string]Credentials)
+	raw, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	n := f.aead.NonceSize()
+	if len(raw) < n {
+		return nil, errors.New("credentials file is corrupted")
+	}
+	plain, err := f.aead.Open(nil, raw[:n], raw[n:], nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt credentials file (wrong key?)")
+	}
+	if err := json.Unmarshal(plain, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (f *FileSource) writeLocked(all map[string]Credentials) error {
+	plain, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := f.aead.Seal(nonce, nonce, plain, nil)
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, sealed, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
@@ -0,0 +1,69 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sniping_engine/internal/config"
+	"sniping_engine/internal/model"
+)
+
+func TestFileSourceRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "creds.enc")
+	cfg := config.CredentialsFileConfig{Path: path, KeyEnv: "TEST_CREDENTIALS_KEY"}
+	t.Setenv("TEST_CREDENTIALS_KEY", "secret")
+
+	src, err := NewFileSource(cfg)
+	if err != nil {
+		t.Fatalf("new file source: %v", err)
+	}
+	if _, ok, err := src.Load(ctx, "a1"); err != nil || ok {
+		t.Fatalf("load before save = %v, %v", ok, err)
+	}
+	want := Credentials{Token: "tok-a1", Cookies: []model.CookieJarEntry{{URL: "https://example.com", Cookies: []model.Cookie{{Name: "sid", Value: "v"}}}}}
+	if err := src.Save(ctx, "a1", want); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := src.Save(ctx, "a2", Credentials{Token: "tok-a2"}); err != nil {
+		t.Fatalf("save a2: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	if bytes.Contains(raw, []byte("tok-a1")) {
+		t.Fatal("credentials file is not encrypted")
+	}
+
+	// 新实例用同一密钥读回。
+	reopened, err := NewFileSource(cfg)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	got, ok, err := reopened.Load(ctx, "a1")
+	if err != nil || !ok || got.Token != want.Token || len(got.Cookies) != 1 || got.Cookies[0].Cookies[0].Value != "v" {
+		t.Fatalf("load = %+v, %v, %v", got, ok, err)
+	}
+	if err := reopened.Delete(ctx, "a1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok, _ := reopened.Load(ctx, "a1"); ok {
+		t.Fatal("a1 still present after delete")
+	}
+	if got, ok, _ := reopened.Load(ctx, "a2"); !ok || got.Token != "tok-a2" {
+		t.Fatalf("a2 = %+v, %v", got, ok)
+	}
+
+	t.Setenv("TEST_CREDENTIALS_KEY", "wrong")
+	wrongKey, err := NewFileSource(cfg)
+	if err != nil {
+		t.Fatalf("new with wrong key: %v", err)
+	}
+	if _, _, err := wrongKey.Load(ctx, "a2"); err == nil {
+		t.Fatal("load with wrong key should fail")
+	}
+}
@@ -0,0 +1,132 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/config"
+)
+
+// VaultSource 使用 HashiCorp Vault 的 KV v2 引擎保存凭据，路径为 <mount>/data/<pathPrefix>/<accountId>。
+type VaultSource struct {
+	addr       string
+	mount      string
+	pathPrefix string
+	token      string
+	client     *http.Client
+}
+
+func NewVaultSource(cfg config.CredentialsVaultConfig) (*VaultSource, error) {
+	addr := strings.TrimRight(strings.TrimSpace(cfg.Addr), "/")
+	if addr == "" {
+		addr = strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")
+	}
+	if addr == "" {
+		return nil, errors.New("credentials.vault.addr is required")
+	}
+	tokenEnv := strings.TrimSpace(cfg.TokenEnv)
+	if tokenEnv == "" {
+		tokenEnv = "VAULT_TOKEN"
+	}
+	token := os.Getenv(tokenEnv)
+	if token == "" {
+		return nil, fmt.Errorf("vault token is empty (env %s)", tokenEnv)
+	}
+	mount := strings.Trim(strings.TrimSpace(cfg.Mount), "/")
+	if mount == "" {
+		mount = "secret"
+	}
+	prefix := strings.Trim(strings.TrimSpace(cfg.PathPrefix), "/")
+	if prefix == "" {
+		prefix = "sniping_engine/accounts"
+	}
+	return &VaultSource{
+		addr:       addr,
+		mount:      mount,
+		pathPrefix: prefix,
+		token:      token,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (v *VaultSource) Name() string { return SourceVault }
+
+func (v *VaultSource) Ref(accountID string) string {
+	return SourceVault + ":" + v.mount + "/" + v.pathPrefix + "/" + accountID
+}
+
+func (v *VaultSource) url(kind, accountID string) string {
+	return v.addr + "/v1/" + v.mount + "/" + kind + "/" + v.pathPrefix + "/" + url.PathEscape(accountID)
+}
+
+func (v *VaultSource) Load(ctx context.Context, accountID string) (Credentials, bool, error) {
+	var out struct {
+		Data struct {
+			Data Credentials `json:"data"`
+		} `json:"data"`
+	}
+	status, err := v.do(ctx, http.MethodGet, v.url("data", accountID), nil, &out)
+	if status == http.StatusNotFound {
+		return Credentials{}, false, nil
+	}
+	if err != nil {
+		return Credentials{}, false, err
+	}
+	return out.Data.Data, true, nil
+}
+
+func (v *VaultSource) Save(ctx context.Context, accountID string, c Credentials) error {
+	_, err := v.do(ctx, http.MethodPost, v.url("data", accountID), map[string]any{"data": c}, nil)
+	return err
+}
+
+func (v *VaultSource) Delete(ctx context.Context, accountID string) error {
+	status, err := v.do(ctx, http.MethodDelete, v.url("metadata", accountID), nil, nil)
+	if status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+func (v *VaultSource) do(ctx context.Context, method, u string, body any, out any) (int, error) {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, rd)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("vault %s: http %d", method, resp.StatusCode)
+	}
+	if out != nil && len(raw) > 0 {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,218 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// 选择下单地址时的参数错误：地址不在账号的上游收货地址列表中，或地址没有行政区划且请求也未提供。
+var (
+	ErrAddressNotFound   = errors.New("address not found in account shipping addresses")
+	ErrAddressNoDivision = errors.New("address has no divisionIds, please provide divisionIds")
+)
+
+// AccountAddresses 是账号的上游收货地址列表，以及账号当前保存的下单地址（AddressID 为 0 时下单自动选择）。
+type AccountAddresses struct {
+	AccountID   string                  `json:"accountId"`
+	AddressID   int64                   `json:"addressId,omitempty"`
+	DivisionIDs string                  `json:"divisionIds,omitempty"`
+	Addresses   []model.ShippingAddress `json:"addresses"`
+}
+
+// AccountAddresses 通过 GetShippingAddresses 拉取账号的收货地址列表（同时保存刷新后的 cookie）。
+func (e *Engine) AccountAddresses(ctx context.Context, accountID string) (AccountAddresses, error) {
+	acc, list, err := e.fetchAccountAddresses(ctx, accountID)
+	if err != nil {
+		return AccountAddresses{}, err
+	}
+	return accountAddresses(acc, list), nil
+}
+
+// SelectAccountAddress 设置账号下单使用的地址：addressID 必须在上游地址列表中，divisionIDs 为空时取该地址的行政区划；
+// addressID 为 0 时清空选择，恢复为下单时自动选择上游选中/默认地址。
+func (e *Engine) SelectAccountAddress(ctx context.Context, accountID string, addressID int64, divisionIDs string) (AccountAddresses, error) {
+	divisionIDs = strings.TrimSpace(divisionIDs)
+	acc, list, err := e.fetchAccountAddresses(ctx, accountID)
+	if err != nil {
+		return AccountAddresses{}, err
+	}
+	if addressID > 0 {
+		var found *model.ShippingAddress
+		for i := range list {
+			if list[i].ID == addressID {
+				found = &list[i]
+				break
+			}
+		}
+		if found == nil {
+			return AccountAddresses{}, ErrAddressNotFound
+		}
+		if divisionIDs == "" {
+			divisionIDs = found.DivisionIDs
+		}
+		if divisionIDs == "" {
+			return AccountAddresses{}, ErrAddressNoDivision
+		}
+	} else {
+		divisionIDs = ""
+	}
+
+	acc.AddressID = addressID
+	acc.DivisionIDs = divisionIDs
+	if err := e.persistAccount(ctx, acc); err != nil {
+		return AccountAddresses{}, err
+	}
+	if f, ok := e.provider.(provider.AddressCacheForgetter); ok {
+		f.ForgetAccountAddress(acc.ID)
+	}
+	if e.bus != nil {
+		e.bus.Log("info", "账号下单地址已更新", map[string]any{
+			"accountId":   acc.ID,
+			"addressId":   addressID,
+			"divisionIds": divisionIDs,
+		})
+	}
+	return accountAddresses(acc, list), nil
+}
+
+func (e *Engine) fetchAccountAddresses(ctx context.Context, accountID string) (model.Account, []model.ShippingAddress, error) {
+	if e == nil || e.store == nil {
+		return model.Account{}, nil, errors.New("store unavailable")
+	}
+	if e.provider == nil {
+		return model.Account{}, nil, errors.New("provider unavailable")
+	}
+	acc, err := e.store.GetAccount(ctx, strings.TrimSpace(accountID))
+	if err != nil {
+		return model.Account{}, nil, err
+	}
+	if strings.TrimSpace(acc.Token) == "" {
+		return model.Account{}, nil, errors.New("account not logged in")
+	}
+
+	e.ensureAccountLimiter(acc.ID)
+	if !e.waitLimits(ctx, acc.ID) {
+		return model.Account{}, nil, ctx.Err()
+	}
+	raw, updated, err := e.provider.GetShippingAddresses(ctx, acc, provider.ShippingAddressParams{App: "o2o", IsAllCover: 1})
+	if err != nil {
+		return model.Account{}, nil, err
+	}
+	if err := e.persistAccount(ctx, updated); err != nil {
+		return model.Account{}, nil, err
+	}
+	list, err := parseShippingAddresses(raw)
+	if err != nil {
+		return model.Account{}, nil, err
+	}
+	return updated, list, nil
+}
+
+func accountAddresses(acc model.Account, list []model.ShippingAddress) AccountAddresses {
+	for i := range list {
+		list[i].Selected = acc.AddressID > 0 && list[i].ID == acc.AddressID
+	}
+	if list == nil {
+		list = []model.ShippingAddress{}
+	}
+	return AccountAddresses{
+		AccountID:   acc.ID,
+		AddressID:   acc.AddressID,
+		DivisionIDs: acc.DivisionIDs,
+		Addresses:   list,
+	}
+}
+
+// parseShippingAddresses 解析 list-all 返回的地址数组；行政区划的取法与下单时自动选择地址一致
+// （divisionIds/divisionLevels 字符串，其次 divisionLevels 数组，最后 provinceId/cityId/regionId）。
+func parseShippingAddresses(raw json.RawMessage) ([]model.ShippingAddress, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var items []json.RawMessage
+	if err := decodeJSONNumber(raw, &items); err != nil {
+		return nil, err
+	}
+	out := make([]model.ShippingAddress, 0, len(items))
+	for _, itemRaw := range items {
+		var m map[string]any
+		if err := decodeJSONNumber(itemRaw, &m); err != nil {
+			continue
+		}
+		a := model.ShippingAddress{
+			ID:          addressInt64(m["id"]),
+			Receiver:    firstString(m, "receiveUserName", "receiverName", "consignee", "name"),
+			Mobile:      firstString(m, "mobile", "phone", "receiverMobile"),
+			DivisionIDs: addressDivisionIDs(m),
+			Raw:         itemRaw,
+		}
+		a.Checked, _ = m["checked"].(bool)
+		a.IsDefault, _ = m["isDefault"].(bool)
+		var parts []string
+		for _, k := range []string{"provinceName", "cityName", "regionName", "streetName"} {
+			if v := firstString(m, k); v != "" {
+				parts = append(parts, v)
+			}
+		}
+		if v := firstString(m, "detailAddress", "detail", "address"); v != "" {
+			parts = append(parts, v)
+		}
+		a.Address = strings.Join(parts, " ")
+		if a.ID <= 0 {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+func addressDivisionIDs(m map[string]any) string {
+	if v := firstString(m, "divisionIds", "divisionLevels", "divisionIdLevels"); v != "" {
+		return v
+	}
+	if levels, ok := m["divisionLevels"].([]any); ok {
+		var parts []string
+		for _, l := range levels {
+			if n := addressInt64(l); n > 0 {
+				parts = append(parts, strconv.FormatInt(n, 10))
+			}
+		}
+		if len(parts) > 0 {
+			return strings.Join(parts, ",")
+		}
+	}
+	var parts []string
+	for _, k := range []string{"provinceId", "cityId", "regionId"} {
+		if n := addressInt64(m[k]); n > 0 {
+			parts = append(parts, strconv.FormatInt(n, 10))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// addressInt64 读取数字或数字字符串形式的 id。
+func addressInt64(v any) int64 {
+	switch t := v.(type) {
+	case json.Number:
+		return jsonNumberInt64(t)
+	case string:
+		n, _ := strconv.ParseInt(strings.TrimSpace(t), 10, 64)
+		return n
+	}
+	return 0
+}
+
+func firstString(m map[string]any, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := m[k].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+type addressProvider struct {
+	idleProvider
+	forgotten []string
+}
+
+func (p *addressProvider) ForgetAccountAddress(accountID string) {
+	p.forgotten = append(p.forgotten, accountID)
+}
+
+func (addressProvider) GetShippingAddresses(_ context.Context, account model.Account, _ provider.ShippingAddressParams) (json.RawMessage, model.Account, error) {
+	return json.RawMessage(`[
+		{"id": 11, "receiveUserName": "张三", "mobile": "138****0000", "provinceName": "上海", "detailAddress": "A 路 1 号", "provinceId": 310000, "cityId": 310100, "regionId": 310104, "isDefault": true},
+		{"id": "12", "receiveUserName": "李四", "divisionIds": "1,2,3", "checked": true}
+	]`), account, nil
+}
+
+func TestSelectAccountAddress(t *testing.T) {
+	e, st, _ := newLifecycleEngine(t)
+	prov := &addressProvider{}
+	e.provider = prov
+	ctx := context.Background()
+	accounts, err := st.ListAccounts(ctx)
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("list accounts: %v", err)
+	}
+	accID := accounts[0].ID
+
+	res, err := e.AccountAddresses(ctx, accID)
+	if err != nil {
+		t.Fatalf("AccountAddresses: %v", err)
+	}
+	if len(res.Addresses) != 2 || res.AddressID != 0 {
+		t.Fatalf("addresses = %+v", res)
+	}
+	if a := res.Addresses[0]; a.ID != 11 || a.DivisionIDs != "310000,310100,310104" || a.Address != "上海 A 路 1 号" || !a.IsDefault {
+		t.Fatalf("first address = %+v", a)
+	}
+	if a := res.Addresses[1]; a.ID != 12 || a.DivisionIDs != "1,2,3" || !a.Checked {
+		t.Fatalf("second address = %+v", a)
+	}
+
+	if _, err := e.SelectAccountAddress(ctx, accID, 99, ""); !errors.Is(err, ErrAddressNotFound) {
+		t.Fatalf("unknown address err = %v", err)
+	}
+	res, err = e.SelectAccountAddress(ctx, accID, 11, "")
+	if err != nil {
+		t.Fatalf("SelectAccountAddress: %v", err)
+	}
+	if !res.Addresses[0].Selected || res.Addresses[1].Selected {
+		t.Fatalf("selected flags = %+v", res.Addresses)
+	}
+	acc, _ := st.GetAccount(ctx, accID)
+	if acc.AddressID != 11 || acc.DivisionIDs != "310000,310100,310104" {
+		t.Fatalf("saved account address = %d %q", acc.AddressID, acc.DivisionIDs)
+	}
+	if len(prov.forgotten) != 1 || prov.forgotten[0] != accID {
+		t.Fatalf("provider address cache should be cleared, got %v", prov.forgotten)
+	}
+
+	if _, err := e.SelectAccountAddress(ctx, accID, 0, "9,9"); err != nil {
+		t.Fatalf("clear selection: %v", err)
+	}
+	acc, _ = st.GetAccount(ctx, accID)
+	if acc.AddressID != 0 || acc.DivisionIDs != "" {
+		t.Fatalf("cleared account address = %d %q", acc.AddressID, acc.DivisionIDs)
+	}
+}
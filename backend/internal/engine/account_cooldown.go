@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// accountFailureMarkers 识别与账号本身相关的上游失败：鉴权被拒、风控拦截（限流另由 isThrottleError 识别）。
+var accountFailureMarkers = []string{"status 401", "status 403", "forbidden", "风险", "risk"}
+
+func isAccountFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if isThrottleError(err) {
+		return true
+	}
+	switch _, class := provider.BizCodeOf(err); class {
+	case model.BizClassAuth, model.BizClassRiskControl:
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range accountFailureMarkers {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+type accountCooldownState struct {
+	failures  int
+	untilMs   int64
+	lastError string
+}
+
+// noteAccountOutcome 记录账号一次上游请求的结果：连续失败达到阈值后暂停该账号的轮换，成功则清零。
+func (e *Engine) noteAccountOutcome(accountID string, err error) {
+	if e == nil || accountID == "" {
+		return
+	}
+	if isTokenExpiredError(err) {
+		e.noteTokenExpired(accountID)
+	}
+	if err != nil && !isAccountFailure(err) {
+		return
+	}
+	nowMs := e.now().UnixMilli()
+	settings := e.NotifySettings()
+
+	e.cooldownMu.Lock()
+	st := e.cooldowns[accountID]
+	if err == nil {
+		if st != nil && st.untilMs == 0 {
+			delete(e.cooldowns, accountID)
+		}
+		e.cooldownMu.Unlock()
+		return
+	}
+	if st == nil {
+		st = &accountCooldownState{}
+		e.cooldowns[accountID] = st
+	}
+	st.lastError = err.Error()
+	if st.untilMs > 0 {
+		// 已在冷却中（进行中的请求晚到的失败），不重复计时。
+		e.cooldownMu.Unlock()
+		return
+	}
+	st.failures++
+	if st.failures < settings.AccountCooldownFailures {
+		e.cooldownMu.Unlock()
+		return
+	}
+	st.untilMs = nowMs + int64(settings.AccountCooldownSeconds)*1000
+	ev := model.AccountCooldown{
+		AccountID: accountID,
+		Benched:   true,
+		Failures:  st.failures,
+		UntilMs:   st.untilMs,
+		LastError: st.lastError,
+	}
+	e.cooldownMu.Unlock()
+
+	if e.bus != nil {
+		e.bus.Log("warn", "账号连续失败，暂停使用", map[string]any{
+			"accountId":   accountID,
+			"failures":    ev.Failures,
+			"untilMs":     ev.UntilMs,
+			"cooldownSec": settings.AccountCooldownSeconds,
+			"error":       ev.LastError,
+		})
+		e.bus.Publish("account_cooldown", ev)
+	}
+}
+
+// accountCoolingDown 返回账号当前是否处于冷却中；冷却到期的账号在这里恢复并发出通知。
+func (e *Engine) accountCoolingDown(accountID string) bool {
+	if e == nil || accountID == "" {
+		return false
+	}
+	nowMs := e.now().UnixMilli()
+
+	e.cooldownMu.Lock()
+	st := e.cooldowns[accountID]
+	if st == nil || st.untilMs == 0 {
+		e.cooldownMu.Unlock()
+		return false
+	}
+	if nowMs < st.untilMs {
+		e.cooldownMu.Unlock()
+		return true
+	}
+	delete(e.cooldowns, accountID)
+	e.cooldownMu.Unlock()
+
+	if e.bus != nil {
+		e.bus.Log("info", "账号冷却结束，恢复使用", map[string]any{"accountId": accountID})
+		e.bus.Publish("account_cooldown", model.AccountCooldown{AccountID: accountID})
+	}
+	return false
+}
+
+// AccountCooldowns 返回当前处于冷却中的账号（按结束时间排序）。
+func (e *Engine) AccountCooldowns() []model.AccountCooldown {
+	if e == nil {
+		return nil
+	}
+	nowMs := e.now().UnixMilli()
+	e.cooldownMu.Lock()
+	var out []model.AccountCooldown
+	for id, st := range e.cooldowns {
+		if st.untilMs == 0 || nowMs >= st.untilMs {
+			continue
+		}
+		out = append(out, model.AccountCooldown{
+			AccountID: id,
+			Benched:   true,
+			Failures:  st.failures,
+			UntilMs:   st.untilMs,
+			LastError: st.lastError,
+		})
+	}
+	e.cooldownMu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].UntilMs < out[j].UntilMs })
+	return out
+}
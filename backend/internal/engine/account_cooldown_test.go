@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+func TestAccountBenchedAfterConsecutiveFailures(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	e.SetNotifySettings(model.NotifySettings{AccountCooldownFailures: 3, AccountCooldownSeconds: 60})
+	e.accounts = []model.Account{{ID: "a1"}, {ID: "a2"}}
+	e.accountLocks = map[string]chan struct{}{"a1": make(chan struct{}, 1), "a2": make(chan struct{}, 1)}
+
+	riskErr := errors.New("create-order status 403: forbidden")
+	e.noteAccountOutcome("a1", riskErr)
+	e.noteAccountOutcome("a1", errors.New("render-order failed: 库存不足"))
+	e.noteAccountOutcome("a1", riskErr)
+	if e.accountCoolingDown("a1") {
+		t.Fatal("a1 benched before reaching the failure threshold")
+	}
+	e.noteAccountOutcome("a1", riskErr)
+	if !e.accountCoolingDown("a1") {
+		t.Fatal("a1 should be benched after 3 consecutive failures")
+	}
+	if got := e.AccountCooldowns(); len(got) != 1 || got[0].AccountID != "a1" || got[0].Failures != 3 {
+		t.Fatalf("cooldowns = %+v", got)
+	}
+
+	for i := 0; i < 4; i++ {
+		acc, ok := e.tryPickAndLockAccount(len(e.accounts))
+		if !ok || acc.ID != "a2" {
+			t.Fatalf("pick %d = %q, %v; want a2", i, acc.ID, ok)
+		}
+		e.releaseAccount(acc.ID)
+	}
+
+	fc.Advance(60 * time.Second)
+	if e.accountCoolingDown("a1") {
+		t.Fatal("a1 should be restored after the cooldown window")
+	}
+	if got := e.AccountCooldowns(); len(got) != 0 {
+		t.Fatalf("cooldowns after restore = %+v", got)
+	}
+}
+
+func TestAccountFailuresResetOnSuccess(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	e.SetNotifySettings(model.NotifySettings{AccountCooldownFailures: 2, AccountCooldownSeconds: 60})
+
+	throttled := errors.New("render-order status 429: too many requests")
+	e.noteAccountOutcome("a1", throttled)
+	e.noteAccountOutcome("a1", nil)
+	e.noteAccountOutcome("a1", throttled)
+	if e.accountCoolingDown("a1") {
+		t.Fatal("a success in between should reset the consecutive failure count")
+	}
+}
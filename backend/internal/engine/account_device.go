@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/utils"
+)
+
+// ResetAccountDevice 清空账号的设备身份、token 与 cookie，换上一套新生成的设备信息。
+// 账号会被视为未登录（需要重新登录），并立即从正在运行的账号池中移除。
+func (e *Engine) ResetAccountDevice(ctx context.Context, accountID string) (model.Account, error) {
+	if e == nil || e.store == nil {
+		return model.Account{}, errors.New("store unavailable")
+	}
+	acc, err := e.store.GetAccount(ctx, strings.TrimSpace(accountID))
+	if err != nil {
+		return model.Account{}, err
+	}
+
+	profile, err := utils.NewDeviceProfile()
+	if err != nil {
+		return model.Account{}, err
+	}
+
+	if err := e.store.DeleteAccountCredentials(ctx, acc.ID); err != nil {
+		return model.Account{}, err
+	}
+	updated := acc
+	updated.Token = ""
+	updated.Cookies = nil
+	updated.DeviceID = profile.DeviceID
+	updated.UUID = profile.UUID
+	updated.UserAgent = profile.UserAgent
+	saved, err := e.store.UpsertAccount(ctx, updated)
+	if err != nil {
+		return model.Account{}, err
+	}
+
+	e.mu.Lock()
+	kept := make([]model.Account, 0, len(e.accounts))
+	for _, a := range e.accounts {
+		if a.ID != saved.ID {
+			kept = append(kept, a)
+		}
+	}
+	e.accounts = kept
+	delete(e.cookieHealth, saved.ID)
+	for key := range e.preflightCache {
+		if strings.HasPrefix(key, saved.ID+"|") {
+			delete(e.preflightCache, key)
+		}
+	}
+	e.mu.Unlock()
+
+	if e.bus != nil {
+		e.bus.Log("info", "账号设备身份已重置，需要重新登录", map[string]any{
+			"accountId": saved.ID,
+			"mobile":    saved.Mobile,
+		})
+	}
+	return saved, nil
+}
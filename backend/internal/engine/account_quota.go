@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// 账号每日额度（NotifySettings.AccountDailyQuota）：按本地日期累计账号跨目标成功下单的件数，
+// 与上游 purchaseLimit 一样以“件”计；达到额度后当天挑选账号时跳过，剩余额度不足一单时按剩余件数下单，
+// 过了零点自动恢复。
+// 计数保存在 SQLite 的 account_daily_purchases，重启后仍然生效。
+
+type accountDailyUsage struct {
+	day string
+	qty int
+}
+
+func quotaDay(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// dailyPurchased 返回账号在 day 已下单的件数；内存里没有当天的计数时从数据库加载，读库时不持有 quotaMu。
+// 读取失败时返回错误且不缓存，调用方按额度已用完处理，下次挑选账号时重试。
+func (e *Engine) dailyPurchased(ctx context.Context, accountID, day string) (int, error) {
+	e.quotaMu.Lock()
+	u, ok := e.dailyUsage[accountID]
+	e.quotaMu.Unlock()
+	if ok && u.day == day {
+		return u.qty, nil
+	}
+	qty := 0
+	if e.store != nil {
+		n, err := e.store.GetAccountDailyPurchase(ctx, accountID, day)
+		if err != nil {
+			if e.bus != nil {
+				e.bus.Log("warn", "读取账号每日下单数量失败，暂按额度已用完处理", map[string]any{"accountId": accountID, "error": err.Error()})
+			}
+			return 0, err
+		}
+		qty = n
+	}
+
+	e.quotaMu.Lock()
+	defer e.quotaMu.Unlock()
+	// 读库期间已有下单计入内存时以内存为准。
+	if u, ok := e.dailyUsage[accountID]; ok && u.day == day {
+		return u.qty, nil
+	}
+	e.dailyUsage[accountID] = accountDailyUsage{day: day, qty: qty}
+	return qty, nil
+}
+
+// dailyQuotaLeft 返回账号今天剩余的额度；未设置额度时 limited 为 false。读取计数失败时按没有剩余额度处理。
+func (e *Engine) dailyQuotaLeft(ctx context.Context, accountID string) (left int, limited bool) {
+	quota := e.NotifySettings().AccountDailyQuota
+	if quota <= 0 || accountID == "" {
+		return 0, false
+	}
+	used, err := e.dailyPurchased(ctx, accountID, quotaDay(e.now()))
+	if err != nil {
+		return 0, true
+	}
+	return quota - used, true
+}
+
+// accountQuotaReached 判断账号今天是否已用完每日额度；首次达到时记一条日志。
+func (e *Engine) accountQuotaReached(accountID string) bool {
+	if e == nil || accountID == "" {
+		return false
+	}
+	left, limited := e.dailyQuotaLeft(context.Background(), accountID)
+	if !limited || left > 0 {
+		return false
+	}
+	day := quotaDay(e.now())
+
+	e.quotaMu.Lock()
+	first := e.quotaNoted[accountID] != day
+	e.quotaNoted[accountID] = day
+	e.quotaMu.Unlock()
+
+	if first && e.bus != nil {
+		quota := e.NotifySettings().AccountDailyQuota
+		e.bus.Log("warn", "账号今日下单数量已达上限，暂停使用至次日", map[string]any{
+			"accountId": accountID,
+			"day":       day,
+			"purchased": quota - left,
+			"quota":     quota,
+		})
+	}
+	return true
+}
+
+// clampReserveToQuota 把已预占的 qty 件收缩到账号今天剩余的额度内，返回实际下单的件数；
+// 账号已没有剩余额度时释放整笔预占并返回 false。调用方持有该账号（同一账号不会并发下单）。
+func (e *Engine) clampReserveToQuota(target model.Target, accountID string, qty int, attemptID uint64) (int, bool) {
+	left, limited := e.dailyQuotaLeft(context.Background(), accountID)
+	if !limited || left >= qty {
+		return qty, true
+	}
+	if left <= 0 {
+		e.finishReservedTarget(target, qty, attemptID, model.AttemptResult{})
+		return 0, false
+	}
+
+	e.mu.Lock()
+	e.reserved[target.ID] -= qty - left
+	if m := e.liveAttempts[target.ID]; m != nil {
+		if _, ok := m[attemptID]; ok {
+			m[attemptID] = left
+		}
+	}
+	e.mu.Unlock()
+	return left, true
+}
+
+// noteDailyPurchase 下单成功后累加账号当天的件数（演练订单不计入）。
+func (e *Engine) noteDailyPurchase(ctx context.Context, accountID string, qty int) {
+	if e == nil || accountID == "" || qty <= 0 {
+		return
+	}
+	day := quotaDay(e.now())
+
+	// 先把当天计数载入内存再累加；读取失败时不缓存，之后从数据库读到的计数已包含本单。
+	if _, err := e.dailyPurchased(context.WithoutCancel(ctx), accountID, day); err == nil {
+		e.quotaMu.Lock()
+		if u, ok := e.dailyUsage[accountID]; ok && u.day == day {
+			e.dailyUsage[accountID] = accountDailyUsage{day: day, qty: u.qty + qty}
+		}
+		e.quotaMu.Unlock()
+	}
+
+	if e.store == nil {
+		return
+	}
+	if err := e.store.AddAccountDailyPurchase(context.WithoutCancel(ctx), accountID, day, qty); err != nil && e.bus != nil {
+		e.bus.Log("warn", "保存账号每日下单数量失败", map[string]any{
+			"accountId": accountID,
+			"qty":       qty,
+			"error":     err.Error(),
+		})
+	}
+}
+
+// AccountDailyPurchased 返回账号今天已成功下单的件数。
+func (e *Engine) AccountDailyPurchased(accountID string) int {
+	if e == nil || accountID == "" {
+		return 0
+	}
+	n, _ := e.dailyPurchased(context.Background(), accountID, quotaDay(e.now()))
+	return n
+}
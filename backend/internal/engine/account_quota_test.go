@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/clock"
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/store/sqlite"
+)
+
+func TestAccountDailyQuotaSkipsAccountUntilMidnight(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "engine.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	fc := clock.NewFake(testStart)
+	e := New(Options{Clock: fc, Store: st})
+	e.SetNotifySettings(model.NotifySettings{AccountDailyQuota: 3})
+	e.accounts = []model.Account{{ID: "a1"}, {ID: "a2"}}
+	e.accountLocks = map[string]chan struct{}{"a1": make(chan struct{}, 1), "a2": make(chan struct{}, 1)}
+
+	e.noteDailyPurchase(ctx, "a1", 2)
+	if e.accountQuotaReached("a1") {
+		t.Fatal("a1 should still have quota left after 2 of 3")
+	}
+	e.noteDailyPurchase(ctx, "a1", 1)
+	if !e.accountQuotaReached("a1") {
+		t.Fatal("a1 should be skipped after using its daily quota")
+	}
+	for i := 0; i < 4; i++ {
+		acc, ok := e.tryPickAndLockAccount(len(e.accounts))
+		if !ok || acc.ID != "a2" {
+			t.Fatalf("pick %d = %q, %v; want a2", i, acc.ID, ok)
+		}
+		e.releaseAccount(acc.ID)
+	}
+	if acc, ok := e.tryPickAndLockRoundRobin("t1"); !ok || acc.ID != "a2" {
+		t.Fatalf("round robin pick = %q, %v; want a2", acc.ID, ok)
+	}
+	e.releaseAccount("a2")
+
+	// 重启后从数据库恢复当天的计数。
+	restarted := New(Options{Clock: fc, Store: st})
+	restarted.SetNotifySettings(model.NotifySettings{AccountDailyQuota: 3})
+	if got := restarted.AccountDailyPurchased("a1"); got != 3 {
+		t.Fatalf("purchased after restart = %d, want 3", got)
+	}
+	if !restarted.accountQuotaReached("a1") {
+		t.Fatal("quota should survive a restart")
+	}
+
+	now := fc.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	fc.Advance(midnight.Sub(now))
+	if e.accountQuotaReached("a1") || restarted.accountQuotaReached("a1") {
+		t.Fatal("a1 should be usable again after midnight")
+	}
+	if got := e.AccountDailyPurchased("a1"); got != 0 {
+		t.Fatalf("purchased on the next day = %d, want 0", got)
+	}
+}
+
+func TestAccountDailyQuotaDisabledByDefault(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	e.noteDailyPurchase(context.Background(), "a1", 100)
+	if e.accountQuotaReached("a1") {
+		t.Fatal("zero quota should not limit accounts")
+	}
+}
+
+func TestAccountDailyQuotaClampsMultiQuantityOrder(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	ctx := context.Background()
+	e.SetNotifySettings(model.NotifySettings{AccountDailyQuota: 3})
+	target := model.Target{ID: "t1", TargetQty: 10, PerOrderQty: 2}
+
+	e.noteDailyPurchase(ctx, "a1", 2)
+	qty, id, ok := e.tryReserveTarget(target)
+	if !ok || qty != 2 {
+		t.Fatalf("reserve = %d, %v", qty, ok)
+	}
+	qty, ok = e.clampReserveToQuota(target, "a1", qty, id)
+	if !ok || qty != 1 {
+		t.Fatalf("clamped qty = %d, %v; want 1 so the account ends the day at 3", qty, ok)
+	}
+	e.mu.Lock()
+	reserved, live := e.reserved[target.ID], e.liveAttempts[target.ID][id]
+	e.mu.Unlock()
+	if reserved != 1 || live != 1 {
+		t.Fatalf("reserved = %d, live = %d; want 1", reserved, live)
+	}
+	e.finishReservedTarget(target, qty, id, model.AttemptResult{Success: true})
+	e.noteDailyPurchase(ctx, "a1", qty)
+	if got := e.AccountDailyPurchased("a1"); got != 3 {
+		t.Fatalf("purchased = %d, want 3", got)
+	}
+
+	// 额度用完时释放整笔预占。
+	qty, id, ok = e.tryReserveTarget(target)
+	if !ok {
+		t.Fatal("reserve should succeed")
+	}
+	if _, ok := e.clampReserveToQuota(target, "a1", qty, id); ok {
+		t.Fatal("account without quota left should be refused")
+	}
+	e.mu.Lock()
+	reserved = e.reserved[target.ID]
+	_, stillLive := e.liveAttempts[target.ID][id]
+	e.mu.Unlock()
+	if reserved != 0 || stillLive {
+		t.Fatalf("refused reservation should be released: reserved = %d, live = %v", reserved, stillLive)
+	}
+}
+
+// failingQuotaStore 读取每日下单计数一律失败。
+type failingQuotaStore struct {
+	Store
+}
+
+func (failingQuotaStore) GetAccountDailyPurchase(ctx context.Context, accountID, day string) (int, error) {
+	return 0, errors.New("database is locked")
+}
+
+func TestAccountDailyQuotaFailsClosedOnReadError(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	e.store = failingQuotaStore{}
+	e.SetNotifySettings(model.NotifySettings{AccountDailyQuota: 3})
+	target := model.Target{ID: "t1", TargetQty: 10, PerOrderQty: 1}
+
+	if !e.accountQuotaReached("a1") {
+		t.Fatal("unreadable count should be treated as quota reached")
+	}
+	qty, id, ok := e.tryReserveTarget(target)
+	if !ok {
+		t.Fatal("reserve should succeed")
+	}
+	if _, ok := e.clampReserveToQuota(target, "a1", qty, id); ok {
+		t.Fatal("unreadable count should refuse the order")
+	}
+	e.quotaMu.Lock()
+	_, cached := e.dailyUsage["a1"]
+	e.quotaMu.Unlock()
+	if cached {
+		t.Fatal("failed read should not be cached")
+	}
+}
+
+func TestTestBuyOnceRespectsDailyQuota(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	ctx := context.Background()
+	target.TargetQty, target.PerOrderQty = 10, 2
+	if _, err := st.UpsertTarget(ctx, target); err != nil {
+		t.Fatalf("upsert target: %v", err)
+	}
+	e.provider = buyProvider{}
+	e.SetNotifySettings(model.NotifySettings{AccountDailyQuota: 3})
+	accounts, err := st.ListAccounts(ctx)
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("accounts = %+v, %v", accounts, err)
+	}
+	acc := accounts[0]
+	e.noteDailyPurchase(ctx, acc.ID, 2)
+
+	res, err := e.TestBuyOnce(ctx, target.ID, acc.ID, "", "")
+	if err != nil || !res.Success {
+		t.Fatalf("test buy with 1 left: %+v, %v", res, err)
+	}
+	if got := e.AccountDailyPurchased(acc.ID); got != 3 {
+		t.Fatalf("purchased = %d, want the order clamped to the 1 left", got)
+	}
+
+	// 额度用完后测试下单不再下单。
+	e.ForgetTargetOrders(target.ID)
+	res, err = e.TestBuyOnce(ctx, target.ID, acc.ID, "", "")
+	if err != nil || res.Success || res.Message != "账号今日下单数量已达上限" {
+		t.Fatalf("test buy without quota: %+v, %v", res, err)
+	}
+	if orders, _ := st.ListOrders(ctx, model.OrderQuery{TargetID: target.ID}); len(orders) != 1 {
+		t.Fatalf("orders = %+v, want only the clamped one", orders)
+	}
+}
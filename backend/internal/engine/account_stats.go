@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"sort"
+
+	"sniping_engine/internal/model"
+)
+
+// isAccountError 判断未成功的尝试是否算作账号侧出错（售罄、价格超限等放弃不算）。
+func isAccountError(class model.AttemptErrorClass) bool {
+	switch class {
+	case model.AttemptErrorPreflight, model.AttemptErrorCaptcha, model.AttemptErrorCreate:
+		return true
+	}
+	return false
+}
+
+// recordAccountStatsLocked 把一次尝试结果计入账号统计，调用方需持有 e.attemptsMu。
+func (e *Engine) recordAccountStatsLocked(res model.AttemptResult) {
+	if res.AccountID == "" || !attemptReachedUpstream(res) {
+		return
+	}
+	if e.accountStats == nil {
+		e.accountStats = make(map[string]*model.AccountStats)
+	}
+	st := e.accountStats[res.AccountID]
+	if st == nil {
+		st = &model.AccountStats{AccountID: res.AccountID}
+		e.accountStats[res.AccountID] = st
+	}
+	st.Attempts++
+	st.LastAttemptMs = res.FinishedAtMs
+	st.LastLatencyMs = res.Latency.TotalMs
+	if res.Success {
+		st.Successes++
+		return
+	}
+	if isAccountError(res.ErrorClass) {
+		st.Errors++
+		st.LastError = res.Error
+		st.LastErrorClass = res.ErrorClass
+		st.LastErrorMs = res.FinishedAtMs
+	}
+}
+
+// AccountStats 返回各账号的尝试统计，按账号 ID 排序。
+func (e *Engine) AccountStats() []model.AccountStats {
+	if e == nil {
+		return nil
+	}
+	e.attemptsMu.Lock()
+	defer e.attemptsMu.Unlock()
+	out := make([]model.AccountStats, 0, len(e.accountStats))
+	for _, st := range e.accountStats {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AccountID < out[j].AccountID })
+	return out
+}
+
+func (e *Engine) resetAccountStats() {
+	e.attemptsMu.Lock()
+	e.accountStats = nil
+	e.attemptsMu.Unlock()
+}
@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"testing"
+
+	"sniping_engine/internal/model"
+)
+
+func TestAccountStatsCountsUpstreamAttempts(t *testing.T) {
+	e, _ := newFakeClockEngine()
+
+	e.recordAttempt(model.AttemptResult{AccountID: "a", Success: true, FinishedAtMs: 1, Latency: model.AttemptLatency{TotalMs: 40}})
+	e.recordAttempt(model.AttemptResult{AccountID: "a", ErrorClass: model.AttemptErrorCreate, Error: "boom", FinishedAtMs: 2, Latency: model.AttemptLatency{TotalMs: 90}})
+	e.recordAttempt(model.AttemptResult{AccountID: "a", ErrorClass: model.AttemptErrorNotPurchasable, FinishedAtMs: 3})
+	e.recordAttempt(model.AttemptResult{AccountID: "a", ErrorClass: model.AttemptErrorPreflightBackoff, FinishedAtMs: 4})
+	e.recordAttempt(model.AttemptResult{AccountID: "b", ErrorClass: model.AttemptErrorPreflight, Error: "risk", FinishedAtMs: 5})
+
+	stats := e.State().Accounts
+	if len(stats) != 2 || stats[0].AccountID != "a" || stats[1].AccountID != "b" {
+		t.Fatalf("stats = %+v", stats)
+	}
+	a := stats[0]
+	if a.Attempts != 3 || a.Successes != 1 || a.Errors != 1 {
+		t.Fatalf("account a counters = %+v", a)
+	}
+	if a.LastError != "boom" || a.LastErrorClass != model.AttemptErrorCreate || a.LastErrorMs != 2 || a.LastAttemptMs != 3 {
+		t.Fatalf("account a last error = %+v", a)
+	}
+	if stats[1].Errors != 1 || stats[1].LastError != "risk" {
+		t.Fatalf("account b = %+v", stats[1])
+	}
+
+	if _, err := e.ResetStats(); err != nil {
+		t.Fatalf("reset stats: %v", err)
+	}
+	if got := e.State().Accounts; len(got) != 0 {
+		t.Fatalf("stats after reset = %+v", got)
+	}
+}
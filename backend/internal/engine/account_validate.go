@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"sniping_engine/internal/provider"
+)
+
+// 账号校验结论。
+const (
+	AccountValid       = "valid"
+	AccountInvalid     = "invalid"
+	AccountUnreachable = "unreachable"
+)
+
+// AccountValidation 是一次账号校验的结果。Status 为 valid（全部接口正常）、invalid（上游拒绝或缺少收货地址）
+// 或 unreachable（请求未送达上游，结果不保存）；Error 为第一个失败接口的原因。
+type AccountValidation struct {
+	AccountID   string                  `json:"accountId"`
+	Status      string                  `json:"status"`
+	Valid       bool                    `json:"valid"`
+	Username    string                  `json:"username,omitempty"`
+	CheckedAtMs int64                   `json:"checkedAtMs"`
+	Checks      []provider.AccountCheck `json:"checks"`
+	Error       string                  `json:"error,omitempty"`
+}
+
+// ValidateAccount 访问上游 current-user（checkAddress 时再查收货地址）确认账号登录态，
+// 结论明确（valid/invalid）时保存到账号的 validatedAtMs/valid/validationError。
+func (e *Engine) ValidateAccount(ctx context.Context, accountID string, checkAddress bool) (AccountValidation, error) {
+	if e == nil || e.store == nil {
+		return AccountValidation{}, errors.New("store unavailable")
+	}
+	v, ok := e.provider.(provider.AccountValidator)
+	if !ok {
+		return AccountValidation{}, errors.New("provider does not support account validation")
+	}
+	acc, err := e.store.GetAccount(ctx, strings.TrimSpace(accountID))
+	if err != nil {
+		return AccountValidation{}, err
+	}
+
+	out := AccountValidation{AccountID: acc.ID, CheckedAtMs: e.now().UnixMilli(), Checks: []provider.AccountCheck{}}
+	if strings.TrimSpace(acc.Token) == "" {
+		out.Status = AccountInvalid
+		out.Error = "account not logged in"
+	} else {
+		e.ensureAccountLimiter(acc.ID)
+		if !e.waitLimits(ctx, acc.ID) {
+			return AccountValidation{}, ctx.Err()
+		}
+		updated, checks, err := v.ValidateAccount(ctx, acc, checkAddress)
+		if err != nil {
+			return AccountValidation{}, err
+		}
+		if err := e.persistAccount(ctx, updated); err != nil {
+			return AccountValidation{}, err
+		}
+		out.Username = updated.Username
+		out.Checks = checks
+		out.Status = accountValidationStatus(checks)
+		for _, c := range checks {
+			if !c.OK {
+				out.Error = c.Error
+				break
+			}
+		}
+	}
+	out.Valid = out.Status == AccountValid
+
+	if out.Status != AccountUnreachable {
+		if err := e.store.SetAccountValidation(ctx, acc.ID, out.CheckedAtMs, out.Valid, out.Error); err != nil {
+			return out, err
+		}
+	}
+	if e.bus != nil {
+		level := "info"
+		if !out.Valid {
+			level = "warn"
+		}
+		e.bus.Log(level, "账号校验完成", map[string]any{
+			"accountId": acc.ID,
+			"status":    out.Status,
+			"error":     out.Error,
+		})
+	}
+	return out, nil
+}
+
+// accountValidationStatus 汇总各接口的结果：有上游明确拒绝的为 invalid，只有请求未送达的失败为 unreachable。
+func accountValidationStatus(checks []provider.AccountCheck) string {
+	status := AccountValid
+	for _, c := range checks {
+		switch {
+		case c.OK:
+		case c.Status > 0:
+			return AccountInvalid
+		default:
+			status = AccountUnreachable
+		}
+	}
+	return status
+}
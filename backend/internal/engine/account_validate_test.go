@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// validatingProvider 的 current-user 按 status 返回：0 表示请求未送达，200 正常，其它为上游拒绝。
+type validatingProvider struct {
+	idleProvider
+	status int
+}
+
+func (p *validatingProvider) ValidateAccount(_ context.Context, acc model.Account, _ bool) (model.Account, []provider.AccountCheck, error) {
+	c := provider.AccountCheck{Path: "/api/user/web/current-user", Status: p.status, OK: p.status == 200}
+	switch {
+	case p.status == 0:
+		c.Error = "dial tcp: i/o timeout"
+	case !c.OK:
+		c.Error = "status 401"
+	}
+	return acc, []provider.AccountCheck{c}, nil
+}
+
+func TestValidateAccountPersistsConclusiveResults(t *testing.T) {
+	e, st, _ := newLifecycleEngine(t)
+	p := &validatingProvider{status: 200}
+	e.provider = p
+	ctx := context.Background()
+
+	acc, err := st.GetAccountByMobile(ctx, "13800000000")
+	if err != nil {
+		t.Fatalf("get account: %v", err)
+	}
+
+	res, err := e.ValidateAccount(ctx, acc.ID, false)
+	if err != nil || res.Status != AccountValid || !res.Valid {
+		t.Fatalf("validate = %+v, %v", res, err)
+	}
+	got, _ := st.GetAccount(ctx, acc.ID)
+	if got.Valid == nil || !*got.Valid || got.ValidatedAtMs != res.CheckedAtMs {
+		t.Fatalf("persisted = valid %v at %d", got.Valid, got.ValidatedAtMs)
+	}
+
+	p.status = 0
+	if res, err := e.ValidateAccount(ctx, acc.ID, false); err != nil || res.Status != AccountUnreachable {
+		t.Fatalf("unreachable validate = %+v, %v", res, err)
+	}
+	if got, _ := st.GetAccount(ctx, acc.ID); got.Valid == nil || !*got.Valid {
+		t.Fatalf("unreachable result must not overwrite the last conclusive one")
+	}
+
+	p.status = 401
+	if res, err := e.ValidateAccount(ctx, acc.ID, false); err != nil || res.Status != AccountInvalid || res.Error != "status 401" {
+		t.Fatalf("invalid validate = %+v, %v", res, err)
+	}
+	if got, _ := st.GetAccount(ctx, acc.ID); got.Valid == nil || *got.Valid || got.ValidationErr != "status 401" {
+		t.Fatalf("persisted invalid = %+v", got)
+	}
+
+	got.Token = "new-token"
+	if _, err := st.UpsertAccount(ctx, got); err != nil {
+		t.Fatalf("upsert account: %v", err)
+	}
+	if got, _ := st.GetAccount(ctx, acc.ID); got.Valid != nil || got.ValidatedAtMs != 0 {
+		t.Fatalf("changing the token should clear the validation, got %+v", got)
+	}
+}
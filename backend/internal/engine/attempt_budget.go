@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"fmt"
+
+	"sniping_engine/internal/model"
+)
+
+// 尝试次数预算：目标配置 maxAttempts 时，每次运行最多发起这么多次预下单/下单尝试，用完后自动关闭目标，
+// 避免付费验证码等按次计费的成本失控。名额在发起尝试时占用，没有真正请求上游的尝试（退避、暂停、取消等）会退还。
+
+// tryConsumeAttemptBudget 为一次尝试占用预算名额。名额已用完时 ok=false；
+// 若此时目标也没有在途尝试（不会再有名额退还），exhausted=true，调用方应关闭目标。
+func (e *Engine) tryConsumeAttemptBudget(t model.Target) (ok bool, exhausted bool) {
+	if t.MaxAttempts <= 0 {
+		return true, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.states[t.ID]
+	if st == nil {
+		return true, false
+	}
+	st.AttemptBudget = t.MaxAttempts
+	if st.BudgetUsed >= t.MaxAttempts {
+		return false, len(e.liveAttempts[t.ID]) == 0
+	}
+	st.BudgetUsed++
+	e.publishStateLocked(*st)
+	return true, false
+}
+
+// refundAttemptBudget 退还一个预算名额。
+func (e *Engine) refundAttemptBudget(t model.Target) {
+	if t.MaxAttempts <= 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if st := e.states[t.ID]; st != nil && st.BudgetUsed > 0 {
+		st.BudgetUsed--
+		e.publishStateLocked(*st)
+	}
+}
+
+// attemptReachedUpstream 判断尝试是否真正请求了上游（只有这些尝试消耗预算）。
+func attemptReachedUpstream(res model.AttemptResult) bool {
+	switch res.ErrorClass {
+	case model.AttemptErrorQuotaReached, model.AttemptErrorCanceled, model.AttemptErrorPaused, model.AttemptErrorPreflightBackoff, model.AttemptErrorCircuitOpen:
+		return false
+	}
+	return true
+}
+
+// exhaustAttemptBudget 把目标标记为预算用完并自动关闭。
+func (e *Engine) exhaustAttemptBudget(t model.Target) {
+	e.mu.Lock()
+	st := e.states[t.ID]
+	if st == nil || st.Status == model.TaskStatusBudgetExhausted {
+		e.mu.Unlock()
+		return
+	}
+	st.Running = false
+	st.Status = model.TaskStatusBudgetExhausted
+	st.StatusReason = fmt.Sprintf("已用完 %d 次尝试预算", t.MaxAttempts)
+	used := st.BudgetUsed
+	e.publishStateLocked(*st)
+	e.mu.Unlock()
+
+	e.disableTargetAsync(t.ID, "尝试次数预算用完自动关闭", map[string]any{
+		"maxAttempts": t.MaxAttempts,
+		"used":        used,
+		"status":      string(model.TaskStatusBudgetExhausted),
+	})
+}
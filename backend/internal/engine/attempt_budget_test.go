@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"testing"
+
+	"sniping_engine/internal/model"
+)
+
+func TestAttemptBudgetConsumeRefundExhaust(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	target := model.Target{ID: "t", MaxAttempts: 2}
+	e.states[target.ID] = &model.TaskState{TargetID: target.ID, Running: true}
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := e.tryConsumeAttemptBudget(target); !ok {
+			t.Fatalf("attempt %d should fit in the budget", i+1)
+		}
+	}
+	e.mu.Lock()
+	e.trackLiveAttemptLocked(target.ID, 1)
+	e.mu.Unlock()
+	if ok, exhausted := e.tryConsumeAttemptBudget(target); ok || exhausted {
+		t.Fatalf("ok/exhausted = %v/%v, want false/false while an attempt is in flight", ok, exhausted)
+	}
+
+	// 没有请求上游的尝试退还名额。
+	e.refundAttemptBudget(target)
+	if ok, _ := e.tryConsumeAttemptBudget(target); !ok {
+		t.Fatal("refunded slot should be reusable")
+	}
+
+	e.mu.Lock()
+	e.liveAttempts = map[string]map[uint64]int{}
+	e.mu.Unlock()
+	ok, exhausted := e.tryConsumeAttemptBudget(target)
+	if ok || !exhausted {
+		t.Fatalf("ok/exhausted = %v/%v, want false/true", ok, exhausted)
+	}
+	e.exhaustAttemptBudget(target)
+	e.mu.Lock()
+	st := *e.states[target.ID]
+	e.mu.Unlock()
+	if st.Status != model.TaskStatusBudgetExhausted || st.Running || st.BudgetUsed != 2 || st.AttemptBudget != 2 {
+		t.Fatalf("state = %+v", st)
+	}
+}
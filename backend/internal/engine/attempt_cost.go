@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// CostRates 返回设置中的验证码与代理流量单价。
+func (e *Engine) CostRates() model.CostRates {
+	st := e.NotifySettings()
+	return model.CostRates{CaptchaPerSolve: st.CaptchaFeePerSolve, ProxyPerGB: st.ProxyFeePerGB}
+}
+
+// priceAttempt 记下尝试经代理的流量并按当前单价计算花费。
+func (e *Engine) priceAttempt(res *model.AttemptResult, meter *provider.TrafficMeter) {
+	res.Cost.ProxyBytes = meter.Bytes()
+	res.Cost = res.Cost.Price(e.CostRates())
+}
+
+// addAttemptCost 把尝试的花费计入目标与本次运行的汇总。
+func (e *Engine) addAttemptCost(res model.AttemptResult) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// 重置统计前发起、之后才结束的尝试不计入新批次。
+	if res.RunID != "" && res.RunID != e.runID {
+		return
+	}
+	e.runCost.Add(res)
+	if st := e.states[res.TargetID]; st != nil {
+		if st.Cost == nil {
+			st.Cost = &model.CostSummary{}
+		}
+		st.Cost.Add(res)
+	}
+}
+
+// noteCaptchaSolves 把不属于某次尝试的验证码求解计入本次运行的汇总，targetID 非空时同时计入该目标。
+func (e *Engine) noteCaptchaSolves(targetID string, n int) {
+	if n <= 0 {
+		return
+	}
+	rates := e.CostRates()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.runCost.AddCaptchaSolves(n, rates)
+	if targetID == "" {
+		return
+	}
+	if st := e.states[targetID]; st != nil {
+		if st.Cost == nil {
+			st.Cost = &model.CostSummary{}
+		}
+		st.Cost.AddCaptchaSolves(n, rates)
+		e.publishStateLocked(*st)
+	}
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+func TestAttemptCostAggregatesIntoRunAndReport(t *testing.T) {
+	e, _, target := newLifecycleEngine(t)
+	ctx := context.Background()
+	settings := e.NotifySettings()
+	settings.CaptchaFeePerSolve = 0.01
+	settings.ProxyFeePerGB = 10
+	e.SetNotifySettings(settings)
+
+	e.mu.Lock()
+	runID := e.beginRunLocked()
+	e.taskStateLocked(target, true)
+	e.mu.Unlock()
+
+	meter := &provider.TrafficMeter{}
+	meter.Add(1<<20, 1<<20)
+	failed := model.AttemptResult{ID: 1, RunID: runID, TargetID: target.ID, ErrorClass: model.AttemptErrorCreate}
+	failed.Cost.CaptchaSolves = 1
+	e.priceAttempt(&failed, meter)
+	if failed.Cost.ProxyBytes != 2<<20 || failed.Cost.Total != 0.0295 {
+		t.Fatalf("failed cost = %+v", failed.Cost)
+	}
+	e.recordAttempt(failed)
+
+	ok := model.AttemptResult{ID: 2, RunID: runID, TargetID: target.ID, Success: true}
+	ok.Cost.CaptchaSolves = 1
+	e.priceAttempt(&ok, nil)
+	e.recordAttempt(ok)
+	e.recordAttempt(model.AttemptResult{ID: 3, RunID: "stale-run", TargetID: target.ID, Cost: model.AttemptCost{Total: 5}})
+
+	run := e.State().Metrics.Cost
+	if run.Attempts != 2 || run.Orders != 1 || run.CaptchaSolves != 2 || run.Total != 0.0395 || run.PerOrder != 0.0395 {
+		t.Fatalf("run cost = %+v", run)
+	}
+	if st := e.TaskStateOf(ctx, target); st.Cost == nil || st.Cost.Total != run.Total {
+		t.Fatalf("task cost = %+v", st.Cost)
+	}
+	rep, err := e.TargetRunReport(ctx, target.ID, runID)
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if rep.Cost != run {
+		t.Fatalf("report cost = %+v, want %+v", rep.Cost, run)
+	}
+
+	e.mu.Lock()
+	e.states[target.ID].Running = false
+	e.mu.Unlock()
+	if _, err := e.ResetStats(); err != nil {
+		t.Fatalf("reset stats: %v", err)
+	}
+	if c := e.State().Metrics.Cost; c.Attempts != 0 || c.Total != 0 {
+		t.Fatalf("cost after reset = %+v", c)
+	}
+}
+
+func TestUnattributedCaptchaSolvesCountTowardTargetAndRun(t *testing.T) {
+	e, _, target := newLifecycleEngine(t)
+	ctx := context.Background()
+	settings := e.NotifySettings()
+	settings.CaptchaFeePerSolve = 0.01
+	e.SetNotifySettings(settings)
+
+	e.mu.Lock()
+	runID := e.beginRunLocked()
+	e.taskStateLocked(target, true)
+	e.mu.Unlock()
+
+	// 一次现场求解的尝试，外加提前获取（未用上）与补充验证码池的求解。
+	res := model.AttemptResult{ID: 1, RunID: runID, TargetID: target.ID, ErrorClass: model.AttemptErrorCaptcha}
+	res.Cost.CaptchaSolves = 1
+	e.priceAttempt(&res, nil)
+	e.recordAttempt(res)
+	e.noteCaptchaSolves(target.ID, 2)
+	e.noteCaptchaSolves("", 3)
+
+	run := e.State().Metrics.Cost
+	if run.Attempts != 1 || run.CaptchaSolves != 6 || run.CaptchaFee != 0.06 || run.Total != 0.06 {
+		t.Fatalf("run cost = %+v", run)
+	}
+	st := e.TaskStateOf(ctx, target)
+	if st.Cost == nil || st.Cost.CaptchaSolves != 3 || st.Cost.Total != 0.03 {
+		t.Fatalf("task cost = %+v", st.Cost)
+	}
+	rep, err := e.TargetRunReport(ctx, target.ID, runID)
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if rep.Cost != *st.Cost {
+		t.Fatalf("report cost = %+v, want %+v", rep.Cost, *st.Cost)
+	}
+}
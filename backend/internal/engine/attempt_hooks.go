@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"sniping_engine/internal/model"
+)
+
+// AttemptHook 是下单尝试各阶段的扩展点：插件、自定义通知、指标采集等通过它挂接，不必修改 attemptWithAccount。
+// 回调在尝试所在的 goroutine 中同步执行，应尽快返回，耗时工作自行异步处理；回调 panic 会被恢复并记日志。
+// OnPreflight/OnCreateOrder 在发出 render-order/create-order 请求前调用（下单重试时每次都会调用），
+// OnSuccess/OnError 在尝试结束后调用；未请求上游的尝试（退避、暂停、取消、已达目标数量）不调用 OnError。
+type AttemptHook interface {
+	OnPreflight(ctx context.Context, info AttemptInfo)
+	OnCreateOrder(ctx context.Context, info AttemptInfo)
+	OnSuccess(ctx context.Context, res model.AttemptResult)
+	OnError(ctx context.Context, res model.AttemptResult)
+}
+
+// AttemptInfo 描述一次进行中的尝试。
+type AttemptInfo struct {
+	AttemptID uint64
+	RunID     string
+	Target    model.Target
+	AccountID string
+}
+
+// AttemptHookFuncs 用函数实现 AttemptHook，为空的回调忽略，便于只关心部分阶段的调用方。
+type AttemptHookFuncs struct {
+	Preflight   func(ctx context.Context, info AttemptInfo)
+	CreateOrder func(ctx context.Context, info AttemptInfo)
+	Success     func(ctx context.Context, res model.AttemptResult)
+	Error       func(ctx context.Context, res model.AttemptResult)
+}
+
+func (f AttemptHookFuncs) OnPreflight(ctx context.Context, info AttemptInfo) {
+	if f.Preflight != nil {
+		f.Preflight(ctx, info)
+	}
+}
+
+func (f AttemptHookFuncs) OnCreateOrder(ctx context.Context, info AttemptInfo) {
+	if f.CreateOrder != nil {
+		f.CreateOrder(ctx, info)
+	}
+}
+
+func (f AttemptHookFuncs) OnSuccess(ctx context.Context, res model.AttemptResult) {
+	if f.Success != nil {
+		f.Success(ctx, res)
+	}
+}
+
+func (f AttemptHookFuncs) OnError(ctx context.Context, res model.AttemptResult) {
+	if f.Error != nil {
+		f.Error(ctx, res)
+	}
+}
+
+// AddAttemptHook 在引擎创建后追加尝试钩子（对之后的尝试生效）。
+func (e *Engine) AddAttemptHook(h AttemptHook) {
+	if e == nil || h == nil {
+		return
+	}
+	e.attemptHooksMu.Lock()
+	e.attemptHooks = append(e.attemptHooks, h)
+	e.attemptHooksMu.Unlock()
+}
+
+func (e *Engine) attemptHookList() []AttemptHook {
+	e.attemptHooksMu.Lock()
+	defer e.attemptHooksMu.Unlock()
+	return e.attemptHooks
+}
+
+// fireAttemptHooks 依次调用所有钩子；单个钩子 panic 不影响其余钩子和抢购流程。
+func (e *Engine) fireAttemptHooks(stage string, call func(AttemptHook)) {
+	for i, h := range e.attemptHookList() {
+		func() {
+			defer func() {
+				if r := recover(); r != nil && e.bus != nil {
+					e.bus.Log("warn", "尝试钩子执行异常", map[string]any{
+						"hook":  i,
+						"stage": stage,
+						"error": fmt.Sprint(r),
+					})
+				}
+			}()
+			call(h)
+		}()
+	}
+}
+
+func (e *Engine) hookPreflight(ctx context.Context, info AttemptInfo) {
+	e.fireAttemptHooks("preflight", func(h AttemptHook) { h.OnPreflight(ctx, info) })
+}
+
+func (e *Engine) hookCreateOrder(ctx context.Context, info AttemptInfo) {
+	e.fireAttemptHooks("create_order", func(h AttemptHook) { h.OnCreateOrder(ctx, info) })
+}
+
+// hookAttemptDone 按尝试结果调用 OnSuccess 或 OnError；目标达成后抢购 ctx 会被取消，回调收到的 ctx 不随之取消。
+func (e *Engine) hookAttemptDone(ctx context.Context, res model.AttemptResult) {
+	ctx = context.WithoutCancel(ctx)
+	switch {
+	case res.Success:
+		e.fireAttemptHooks("success", func(h AttemptHook) { h.OnSuccess(ctx, res) })
+	case attemptReachedUpstream(res):
+		e.fireAttemptHooks("error", func(h AttemptHook) { h.OnError(ctx, res) })
+	}
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+type orderingProvider struct {
+	idleProvider
+}
+
+func (orderingProvider) Preflight(_ context.Context, acc model.Account, _ model.Target) (provider.PreflightResult, model.Account, error) {
+	return provider.PreflightResult{CanBuy: true, TotalFee: 100, Render: json.RawMessage(`{}`), AccountID: acc.ID}, acc, nil
+}
+
+func (orderingProvider) CreateOrder(_ context.Context, attempt *provider.AttemptContext) (provider.CreateResult, model.Account, error) {
+	return provider.CreateResult{Success: true, OrderID: "o1"}, attempt.Account(), nil
+}
+
+func TestAttemptHooksSeeEveryStage(t *testing.T) {
+	var stages []string
+	hook := AttemptHookFuncs{
+		Preflight:   func(_ context.Context, info AttemptInfo) { stages = append(stages, "preflight:"+info.AccountID) },
+		CreateOrder: func(context.Context, AttemptInfo) { stages = append(stages, "create") },
+		Success:     func(_ context.Context, res model.AttemptResult) { stages = append(stages, "success:"+res.OrderID) },
+	}
+	e := New(Options{Provider: orderingProvider{}, AttemptHooks: []AttemptHook{hook}})
+	// 出错的钩子不影响后面的钩子和下单。
+	e.AddAttemptHook(AttemptHookFuncs{Preflight: func(context.Context, AttemptInfo) { panic("boom") }})
+
+	target := model.Target{ID: "t", Mode: model.TargetModeScan, ItemID: 1, SKUID: 1, TargetQty: 1, PerOrderQty: 1}
+	res := e.attemptWithAccount(context.Background(), target, model.Account{ID: "a1", Token: "x"}, 1)
+	if !res.Success {
+		t.Fatalf("attempt failed: %+v", res)
+	}
+	e.hookAttemptDone(context.Background(), res)
+
+	want := []string{"preflight:a1", "create", "success:o1"}
+	if len(stages) != len(want) {
+		t.Fatalf("stages = %v, want %v", stages, want)
+	}
+	for i := range want {
+		if stages[i] != want[i] {
+			t.Fatalf("stages = %v, want %v", stages, want)
+		}
+	}
+}
+
+func TestAttemptHookErrorSkipsLocalOutcomes(t *testing.T) {
+	var errs int
+	e := New(Options{AttemptHooks: []AttemptHook{AttemptHookFuncs{Error: func(context.Context, model.AttemptResult) { errs++ }}}})
+	e.hookAttemptDone(context.Background(), model.AttemptResult{ErrorClass: model.AttemptErrorPreflightBackoff})
+	e.hookAttemptDone(context.Background(), model.AttemptResult{ErrorClass: model.AttemptErrorPreflight, Error: "x"})
+	if errs != 1 {
+		t.Fatalf("OnError calls = %d, want 1", errs)
+	}
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+const (
+	// attemptLogMaxPending 等待写入 SQLite 的尝试记录上限，超出时丢弃新记录（只影响事后分析，不影响抢购）。
+	attemptLogMaxPending = 5000
+	// attemptLogBatch 每个事务最多写入的条数。
+	attemptLogBatch = 200
+)
+
+// persistAttempt 把尝试结果交给后台写入 attempts 表；不阻塞抢购，写入由单个 goroutine 批量完成。
+func (e *Engine) persistAttempt(res model.AttemptResult) {
+	if e.store == nil {
+		return
+	}
+	e.attemptLogMu.Lock()
+	if len(e.attemptLogPending) >= attemptLogMaxPending {
+		e.attemptLogMu.Unlock()
+		if e.attemptLogDropped.Add(1) == 1 && e.bus != nil {
+			e.bus.Log("warn", "尝试记录写入积压，丢弃新的记录", map[string]any{"pending": attemptLogMaxPending})
+		}
+		return
+	}
+	e.attemptLogPending = append(e.attemptLogPending, res)
+	start := !e.attemptLogWriting
+	e.attemptLogWriting = true
+	if start {
+		e.attemptLogWG.Add(1)
+	}
+	e.attemptLogMu.Unlock()
+
+	if start {
+		go e.writeAttemptLog()
+	}
+}
+
+// writeAttemptLog 写完积压的记录后退出，下一条记录到来时再启动。
+func (e *Engine) writeAttemptLog() {
+	defer e.attemptLogWG.Done()
+	for {
+		e.attemptLogMu.Lock()
+		n := len(e.attemptLogPending)
+		if n == 0 {
+			e.attemptLogWriting = false
+			e.attemptLogMu.Unlock()
+			return
+		}
+		if n > attemptLogBatch {
+			n = attemptLogBatch
+		}
+		batch := append([]model.AttemptResult(nil), e.attemptLogPending[:n]...)
+		e.attemptLogPending = append(e.attemptLogPending[:0], e.attemptLogPending[n:]...)
+		e.attemptLogMu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := e.store.InsertAttempts(ctx, batch)
+		cancel()
+		if err != nil && e.bus != nil {
+			e.bus.Log("warn", "保存尝试记录失败", map[string]any{"count": len(batch), "error": err.Error()})
+		}
+	}
+}
+
+// FlushAttemptLog 等待积压的尝试记录写入完成，用于进程退出前收尾；ctx 结束时提前返回 false。
+func (e *Engine) FlushAttemptLog(ctx context.Context) bool {
+	if e == nil {
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		e.attemptLogWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
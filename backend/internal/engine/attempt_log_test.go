@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/store/sqlite"
+)
+
+func TestRecordAttemptPersistsAndPaginates(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "engine.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	e := New(Options{Store: st})
+
+	for i := 1; i <= 5; i++ {
+		acc := "a1"
+		if i%2 == 0 {
+			acc = "a2"
+		}
+		e.recordAttempt(model.AttemptResult{
+			ID:          uint64(i),
+			TargetID:    "t1",
+			AccountID:   acc,
+			Phase:       model.AttemptPhaseCreate,
+			ErrorClass:  model.AttemptErrorCreate,
+			Error:       fmt.Sprintf("err-%d", i),
+			TraceID:     fmt.Sprintf("trace-%d", i),
+			StartedAtMs: int64(i),
+			Latency:     model.AttemptLatency{TotalMs: int64(10 * i)},
+		})
+	}
+	e.recordAttempt(model.AttemptResult{ID: 6, TargetID: "t2", AccountID: "a1", Success: true})
+
+	fctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if !e.FlushAttemptLog(fctx) {
+		t.Fatal("attempt log was not flushed")
+	}
+
+	page1, total, err := st.ListAttempts(ctx, model.AttemptQuery{TargetID: "t1", PageSize: 2})
+	if err != nil || total != 5 || len(page1) != 2 || page1[0].ID != 5 || page1[1].ID != 4 {
+		t.Fatalf("page 1 = %+v, total %d, %v", page1, total, err)
+	}
+	if page1[0].TraceID != "trace-5" || page1[0].ErrorClass != model.AttemptErrorCreate || page1[0].Latency.TotalMs != 50 {
+		t.Fatalf("stored attempt = %+v", page1[0])
+	}
+	page3, _, _ := st.ListAttempts(ctx, model.AttemptQuery{TargetID: "t1", Page: 3, PageSize: 2})
+	if len(page3) != 1 || page3[0].ID != 1 {
+		t.Fatalf("page 3 = %+v", page3)
+	}
+	byAccount, total, _ := st.ListAttempts(ctx, model.AttemptQuery{AccountID: "a1"})
+	if total != 4 || len(byAccount) != 4 || !byAccount[0].Success {
+		t.Fatalf("a1 attempts = %+v, total %d", byAccount, total)
+	}
+}
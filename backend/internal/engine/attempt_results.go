@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"context"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/notify"
+)
+
+// maxRecentAttempts 内存中保留的最近尝试结果条数。
+const maxRecentAttempts = 500
+
+// recordAttempt 保存尝试结果（内存环形缓冲，并在后台写入 SQLite）并通过总线推送 attempt_result 事件。
+func (e *Engine) recordAttempt(res model.AttemptResult) {
+	e.attemptsMu.Lock()
+	if len(e.recentAttempts) >= maxRecentAttempts {
+		copy(e.recentAttempts, e.recentAttempts[1:])
+		e.recentAttempts = e.recentAttempts[:len(e.recentAttempts)-1]
+	}
+	e.recentAttempts = append(e.recentAttempts, res)
+	e.recordAccountStatsLocked(res)
+	e.attemptsMu.Unlock()
+	e.addAttemptCost(res)
+	e.persistAttempt(res)
+
+	if e.bus != nil {
+		e.bus.Publish("attempt_result", res)
+	}
+}
+
+// RecentAttempts 返回最近的尝试结果（新的在前）；targetID 为空时不过滤，limit<=0 时返回全部。
+func (e *Engine) RecentAttempts(targetID string, limit int) []model.AttemptResult {
+	if e == nil {
+		return nil
+	}
+	e.attemptsMu.Lock()
+	defer e.attemptsMu.Unlock()
+	out := make([]model.AttemptResult, 0)
+	for i := len(e.recentAttempts) - 1; i >= 0; i-- {
+		r := e.recentAttempts[i]
+		if targetID != "" && r.TargetID != targetID {
+			continue
+		}
+		out = append(out, r)
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	return out
+}
+
+func (e *Engine) notifyOrderCreated(ctx context.Context, target model.Target, acc model.Account, res model.AttemptResult) {
+	if e.notifier == nil {
+		return
+	}
+	e.notifier.NotifyOrderCreated(ctx, notify.OrderCreatedEvent{
+		At:         res.FinishedAtMs,
+		RunID:      res.RunID,
+		AccountID:  acc.ID,
+		Mobile:     acc.Mobile,
+		TargetID:   target.ID,
+		TargetName: target.Name,
+		Mode:       string(target.Mode),
+		ItemID:     target.ItemID,
+		SKUID:      target.SKUID,
+		ShopID:     target.ShopID,
+		Quantity:   res.Quantity,
+		OrderID:    res.OrderID,
+		TraceID:    res.TraceID,
+
+		ExpectedFee: res.TotalFee,
+		ActualFee:   res.ActualFee,
+		FeeMismatch: res.FeeMismatch,
+	})
+}
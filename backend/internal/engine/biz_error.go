@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// 业务错误分类：按上游业务码（字典分类）或提示文案把预下单/下单失败归为缺货、未开始、风控、重复下单四类，
+// 分别对应立即重试、等到开抢、退避、放弃该账号，其余错误沿用原有的失败退避。
+type bizErrorKind string
+
+const (
+	bizErrorSoldOut    bizErrorKind = "sold_out"
+	bizErrorNotStarted bizErrorKind = "not_started"
+	bizErrorRisk       bizErrorKind = "risk_control"
+	bizErrorDuplicate  bizErrorKind = "duplicate_order"
+)
+
+// notStartedRecheck 已过开抢时间仍提示未开始时（时钟偏差），预下单暂停的时长。
+const notStartedRecheck = 300 * time.Millisecond
+
+var (
+	duplicateOrderMarkers = []string{"重复下单", "重复提交", "已有未支付订单", "已存在订单", "duplicate order", "repeat order"}
+	notStartedMarkers     = []string{"未开始", "尚未开售", "未开售", "还没开始", "not started", "not yet on sale"}
+	soldOutMarkers        = []string{"库存不足", "已售罄", "售罄", "已抢光", "sold out", "out of stock"}
+)
+
+// classifyBizError 返回上游错误的业务分类；字典分类优先，其次按提示文案识别，无法识别时返回空。
+func classifyBizError(err error) bizErrorKind {
+	if err == nil {
+		return ""
+	}
+	switch _, class := provider.BizCodeOf(err); class {
+	case model.BizClassSoldOut:
+		return bizErrorSoldOut
+	case model.BizClassNotStarted:
+		return bizErrorNotStarted
+	case model.BizClassRiskControl:
+		return bizErrorRisk
+	case model.BizClassDuplicate:
+		return bizErrorDuplicate
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case containsAny(msg, duplicateOrderMarkers):
+		return bizErrorDuplicate
+	case containsAny(msg, notStartedMarkers):
+		return bizErrorNotStarted
+	case containsAny(msg, soldOutMarkers):
+		return bizErrorSoldOut
+	}
+	if classifyRiskResponse(err) == riskKindRiskControl {
+		return bizErrorRisk
+	}
+	return ""
+}
+
+func containsAny(s string, markers []string) bool {
+	for _, m := range markers {
+		if strings.Contains(s, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// disposition 返回该分类对应的处理方式。
+func (k bizErrorKind) disposition() model.AttemptDisposition {
+	switch k {
+	case bizErrorSoldOut:
+		return model.AttemptRetry
+	case bizErrorNotStarted:
+		return model.AttemptWaitStart
+	case bizErrorRisk:
+		return model.AttemptBackoff
+	case bizErrorDuplicate:
+		return model.AttemptAbort
+	}
+	return ""
+}
+
+// holdPreflightUntilStart 上游提示未开始时暂停该目标的预下单：开抢前等到开抢时刻，
+// 已过开抢时间（或扫货目标）则短暂停顿后再试，不累计失败次数。
+func (e *Engine) holdPreflightUntilStart(target model.Target, nowMs int64) int64 {
+	untilMs := nowMs + notStartedRecheck.Milliseconds()
+	if target.Mode == model.TargetModeRush && target.RushAtMs > 0 {
+		if startMs := e.rushStartAt(target).UnixMilli(); startMs > untilMs {
+			untilMs = startMs
+		}
+	}
+	e.mu.Lock()
+	if e.preflightBackoff == nil {
+		e.preflightBackoff = make(map[string]preflightBackoffState)
+	}
+	st := e.preflightBackoff[target.ID]
+	if st.UntilMs < untilMs {
+		st.UntilMs = untilMs
+	}
+	e.preflightBackoff[target.ID] = st
+	untilMs = st.UntilMs
+	e.mu.Unlock()
+	return untilMs
+}
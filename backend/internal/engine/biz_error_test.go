@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+func TestClassifyBizError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bizErrorKind
+	}{
+		{nil, ""},
+		{errors.New("create-order failed: 库存不足"), bizErrorSoldOut},
+		{errors.New("render-order failed: 活动尚未开售"), bizErrorNotStarted},
+		{errors.New("create-order failed: 请勿重复下单"), bizErrorDuplicate},
+		{errors.New("create-order failed: 触发风控"), bizErrorRisk},
+		{errors.New("render-order status 429: too many requests"), ""},
+		{&provider.BizCodeError{API: "create-order", Code: "7001", Class: model.BizClassNotStarted, Message: "x"}, bizErrorNotStarted},
+		{&provider.BizCodeError{API: "create-order", Code: "7002", Class: model.BizClassDuplicate, Message: "x"}, bizErrorDuplicate},
+	}
+	for _, c := range cases {
+		if got := classifyBizError(c.err); got != c.want {
+			t.Errorf("classifyBizError(%v) = %q, want %q", c.err, got, c.want)
+		}
+	}
+	if got := bizErrorSoldOut.disposition(); got != model.AttemptRetry {
+		t.Fatalf("sold out disposition = %q", got)
+	}
+}
+
+func TestNotStartedHoldsPreflightUntilRush(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	nowMs := fc.Now().UnixMilli()
+	rush := model.Target{ID: "r", Mode: model.TargetModeRush, RushAtMs: nowMs + 5000}
+
+	if got := e.holdPreflightUntilStart(rush, nowMs); got != rush.RushAtMs {
+		t.Fatalf("retryAt = %d, want rushAt %d", got, rush.RushAtMs)
+	}
+	if e.canPreflightNow("r", nowMs+4000) || !e.canPreflightNow("r", rush.RushAtMs) {
+		t.Fatal("preflight should resume exactly at rush time")
+	}
+
+	fc.Advance(10 * time.Second)
+	nowMs = fc.Now().UnixMilli()
+	if got := e.holdPreflightUntilStart(rush, nowMs); got != nowMs+notStartedRecheck.Milliseconds() {
+		t.Fatalf("past rushAt retryAt = %d, want short recheck", got)
+	}
+	if st := e.preflightBackoff["r"]; st.Failures != 0 {
+		t.Fatalf("not-started hold should not count failures, got %d", st.Failures)
+	}
+}
+
+func TestDuplicateOrderMarksSlotTaken(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	e.markOrderSlotTaken("a", "t1")
+	if !e.orderSlotTaken("a", "t1") || e.orderSlotTaken("b", "t1") {
+		t.Fatal("duplicate order should only block the reporting account")
+	}
+	e.releaseOrderSlot("a", "t1")
+	if !e.orderSlotTaken("a", "t1") {
+		t.Fatal("taken slot must survive release")
+	}
+}
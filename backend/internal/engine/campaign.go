@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"context"
+
+	"sniping_engine/internal/model"
+)
+
+// CampaignSummaries 汇总每个活动下目标的启用/运行数量与已购进度；未加载到内存的目标使用已保存的进度。
+func (e *Engine) CampaignSummaries(ctx context.Context) ([]model.CampaignSummary, error) {
+	if e == nil || e.store == nil {
+		return nil, nil
+	}
+	campaigns, err := e.store.ListCampaigns(ctx)
+	if err != nil || len(campaigns) == 0 {
+		return nil, err
+	}
+	targets, err := e.store.ListTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e.restoreTaskStates(ctx)
+
+	out := make([]model.CampaignSummary, len(campaigns))
+	index := make(map[string]int, len(campaigns))
+	for i, c := range campaigns {
+		out[i] = model.CampaignSummary{ID: c.ID, Name: c.Name, Done: true}
+		index[c.ID] = i
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, t := range targets {
+		i, ok := index[t.CampaignID]
+		if !ok {
+			continue
+		}
+		sum := &out[i]
+		sum.Targets++
+		sum.TargetQty += t.TargetQty
+		if t.Enabled {
+			sum.Enabled++
+		}
+		purchased := 0
+		if st := e.states[t.ID]; st != nil {
+			purchased = st.PurchasedQty
+			if st.Running {
+				sum.Running++
+			}
+		} else if saved, ok := e.savedStates[t.ID]; ok {
+			purchased = saved.PurchasedQty
+		}
+		sum.PurchasedQty += purchased
+		if purchased < t.TargetQty {
+			sum.Done = false
+		}
+	}
+	for i := range out {
+		if out[i].Targets == 0 {
+			out[i].Done = false
+		}
+	}
+	return out, nil
+}
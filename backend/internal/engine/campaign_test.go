@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"sniping_engine/internal/model"
+)
+
+func TestCampaignSummariesAggregateMembers(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	ctx := context.Background()
+
+	c, err := st.UpsertCampaign(ctx, model.Campaign{Name: "双11 首发"})
+	if err != nil {
+		t.Fatalf("upsert campaign: %v", err)
+	}
+	target.CampaignID = c.ID
+	target.TargetQty = 2
+	target.PerOrderQty = 1
+	if _, err := st.UpsertTarget(ctx, target); err != nil {
+		t.Fatalf("upsert target: %v", err)
+	}
+	other, err := st.UpsertTarget(ctx, model.Target{Name: "o", ItemID: 2, SKUID: 2, Mode: model.TargetModeScan, TargetQty: 3, PerOrderQty: 1, CampaignID: c.ID})
+	if err != nil {
+		t.Fatalf("upsert other: %v", err)
+	}
+
+	e.restoreTaskStates(ctx)
+	e.mu.Lock()
+	e.states[target.ID] = &model.TaskState{TargetID: target.ID, Running: true, PurchasedQty: 2, TargetQty: 2}
+	e.states[other.ID] = &model.TaskState{TargetID: other.ID, PurchasedQty: 1, TargetQty: 3}
+	e.mu.Unlock()
+
+	sums, err := e.CampaignSummaries(ctx)
+	if err != nil {
+		t.Fatalf("summaries: %v", err)
+	}
+	if len(sums) != 1 {
+		t.Fatalf("got %d summaries, want 1", len(sums))
+	}
+	got := sums[0]
+	if got.Targets != 2 || got.Enabled != 1 || got.Running != 1 || got.PurchasedQty != 3 || got.TargetQty != 5 || got.Done {
+		t.Fatalf("summary = %+v", got)
+	}
+}
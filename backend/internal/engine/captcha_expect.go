@@ -0,0 +1,73 @@
+package engine
+
+import "sniping_engine/internal/model"
+
+// 抢购目标要到开抢后的第一次预下单才知道是否需要验证码，来不及据此预热验证码池。
+// 这里结合人工覆盖（captchaOverride）与上次保存的观察结果，在开抢前给出预期。
+const (
+	captchaExpectOverride = "override"
+	captchaExpectObserved = "observed"
+	captchaExpectUnknown  = "unknown"
+)
+
+// targetExpectsCaptchaLocked 返回目标预计是否需要验证码及依据；从未观察过时按需要处理。
+// 调用方需持有 e.mu。
+func (e *Engine) targetExpectsCaptchaLocked(t model.Target) (need bool, source string) {
+	switch t.CaptchaOverride {
+	case model.CaptchaOverrideRequired:
+		return true, captchaExpectOverride
+	case model.CaptchaOverrideNone:
+		return false, captchaExpectOverride
+	}
+	if st := e.states[t.ID]; st != nil && st.NeedCaptcha != nil {
+		return *st.NeedCaptcha, captchaExpectObserved
+	}
+	if saved, ok := e.savedStates[t.ID]; ok && saved.NeedCaptcha != nil {
+		return *saved.NeedCaptcha, captchaExpectObserved
+	}
+	return true, captchaExpectUnknown
+}
+
+// hasCaptchaRushTarget 判断是否有待开抢的抢购目标预计需要验证码。
+func (e *Engine) hasCaptchaRushTarget() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, t := range e.targets {
+		if t.Mode != model.TargetModeRush {
+			continue
+		}
+		if need, _ := e.targetExpectsCaptchaLocked(t); need {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCaptchaReadiness 在等待开抢前记录目标的验证码预期与验证码池准备情况。
+func (e *Engine) checkCaptchaReadiness(target model.Target) {
+	if e.bus == nil {
+		return
+	}
+	e.mu.Lock()
+	need, source := e.targetExpectsCaptchaLocked(target)
+	e.mu.Unlock()
+	if !need {
+		e.bus.Log("info", "就绪检查：预计不需要验证码", map[string]any{
+			"targetId": target.ID,
+			"source":   source,
+		})
+		return
+	}
+	settings := e.captchaPool.Settings()
+	fields := map[string]any{
+		"targetId":      target.ID,
+		"source":        source,
+		"poolSize":      settings.PoolSize,
+		"warmupSeconds": settings.WarmupSeconds,
+	}
+	if settings.PoolSize <= 0 && target.CaptchaVerifyParam == "" {
+		e.bus.Log("warn", "就绪检查：预计需要验证码，但验证码池未开启", fields)
+		return
+	}
+	e.bus.Log("info", "就绪检查：预计需要验证码，开抢前预热验证码池", fields)
+}
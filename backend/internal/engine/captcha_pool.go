@@ -8,8 +8,8 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
-	"time"
 
+	"sniping_engine/internal/clock"
 	"sniping_engine/internal/model"
 )
 
@@ -25,6 +25,8 @@ type CaptchaPoolStatus struct {
 	Activated    bool                    `json:"activated"`
 	ActivateAtMs int64                   `json:"activateAtMs"`
 	DesiredSize  int                     `json:"desiredSize"`
+	// ScanDemand 表示当前因需要验证码的扫货目标而维护常驻池。
+	ScanDemand   bool                    `json:"scanDemand"`
 	Size         int                     `json:"size"`
 	Settings     model.CaptchaPoolSettings `json:"settings"`
 	Items        []CaptchaPoolItemView   `json:"items"`
@@ -45,6 +47,8 @@ type CaptchaPool struct {
 	nextID atomic.Uint64
 
 	settings atomic.Value // model.CaptchaPoolSettings
+
+	clock clock.Clock
 }
 
 func DefaultCaptchaPoolSettings() model.CaptchaPoolSettings {
@@ -52,6 +56,7 @@ func DefaultCaptchaPoolSettings() model.CaptchaPoolSettings {
 		WarmupSeconds:  30,
 		PoolSize:       2,
 		ItemTTLSeconds: 120,
+		ScanPoolSize:   1,
 	}
 }
 
@@ -75,12 +80,26 @@ func normalizeCaptchaPoolSettings(in model.CaptchaPoolSettings) model.CaptchaPoo
 	if out.WarmupSeconds > 3600 {
 		out.WarmupSeconds = 3600
 	}
+	if out.ScanPoolSize < 0 {
+		out.ScanPoolSize = 0
+	}
+	if out.ScanPoolSize > out.PoolSize {
+		out.ScanPoolSize = out.PoolSize
+	}
 	return out
 }
 
 func NewCaptchaPool(settings model.CaptchaPoolSettings) *CaptchaPool {
+	return newCaptchaPoolWithClock(settings, nil)
+}
+
+func newCaptchaPoolWithClock(settings model.CaptchaPoolSettings, clk clock.Clock) *CaptchaPool {
+	if clk == nil {
+		clk = clock.Real()
+	}
 	p := &CaptchaPool{
-		ch: make(chan struct{}),
+		ch:    make(chan struct{}),
+		clock: clk,
 	}
 	p.settings.Store(normalizeCaptchaPoolSettings(settings))
 	return p
@@ -143,7 +162,7 @@ func (p *CaptchaPool) Add(verifyParam string, createdAtMs int64) (captchaPoolIte
 		return captchaPoolItem{}, false
 	}
 	if createdAtMs <= 0 {
-		createdAtMs = time.Now().UnixMilli()
+		createdAtMs = p.clock.Now().UnixMilli()
 	}
 	st := p.Settings()
 	item := captchaPoolItem{
@@ -153,7 +172,7 @@ func (p *CaptchaPool) Add(verifyParam string, createdAtMs int64) (captchaPoolIte
 		ExpiresAtMs: createdAtMs + int64(st.ItemTTLSeconds)*1000,
 	}
 	p.mu.Lock()
-	p.pruneLocked(time.Now().UnixMilli())
+	p.pruneLocked(p.clock.Now().UnixMilli())
 	p.items = append(p.items, item)
 	p.mu.Unlock()
 	p.signalChanged()
@@ -162,7 +181,7 @@ func (p *CaptchaPool) Add(verifyParam string, createdAtMs int64) (captchaPoolIte
 
 func (p *CaptchaPool) Acquire(ctx context.Context) (captchaPoolItem, bool) {
 	for {
-		nowMs := time.Now().UnixMilli()
+		nowMs := p.clock.Now().UnixMilli()
 		p.mu.Lock()
 		p.pruneLocked(nowMs)
 		if len(p.items) > 0 {
@@ -9,7 +9,9 @@ import (
 	"sync"
 	"time"
 
+	"sniping_engine/internal/logbus"
 	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
 	"sniping_engine/internal/utils"
 )
 
@@ -31,7 +33,7 @@ func (e *Engine) SetCaptchaPoolSettings(v model.CaptchaPoolSettings) model.Captc
 }
 
 func (e *Engine) CaptchaPoolStatus() CaptchaPoolStatus {
-	nowMs := time.Now().UnixMilli()
+	nowMs := e.now().UnixMilli()
 	st := DefaultCaptchaPoolSettings()
 	items := []CaptchaPoolItemView(nil)
 	if e != nil && e.captchaPool != nil {
@@ -40,15 +42,19 @@ func (e *Engine) CaptchaPoolStatus() CaptchaPoolStatus {
 	}
 	activated := false
 	activateAt := int64(0)
+	desired := 0
+	scanDemand := false
 	if e != nil {
 		activated = e.captchaPoolActivated.Load()
 		activateAt = e.captchaPoolActivateAtMs.Load()
+		desired, scanDemand = e.captchaPoolDesiredSize(st)
 	}
 	return CaptchaPoolStatus{
 		NowMs:        nowMs,
 		Activated:    activated,
 		ActivateAtMs: activateAt,
-		DesiredSize:  st.PoolSize,
+		DesiredSize:  desired,
+		ScanDemand:   scanDemand,
 		Size:         len(items),
 		Settings:     st,
 		Items:        items,
@@ -62,26 +68,36 @@ func (e *Engine) startCaptchaPoolMaintainer(ctx context.Context) {
 	if !e.captchaPoolMaintainerRunning.CompareAndSwap(false, true) {
 		return
 	}
+	const interval = 800 * time.Millisecond
+	e.registerLoop(LoopInfo{Key: loopKeyCaptchaPool, Kind: LoopKindCaptchaPool, Phase: loopPhaseRunning, IntervalMs: interval.Milliseconds()})
 	e.wg.Add(1)
 	go func() {
 		defer e.wg.Done()
 		defer e.captchaPoolMaintainerRunning.Store(false)
-		ticker := time.NewTicker(800 * time.Millisecond)
+		defer e.unregisterLoop(loopKeyCaptchaPool)
+		ticker := e.clk().NewTicker(interval)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-ticker.C:
+			case <-ticker.C():
 				e.tickCaptchaPool(ctx)
+				outcome := "inactive"
+				if e.captchaPoolActivated.Load() {
+					outcome = "active"
+				} else if _, scan := e.captchaPoolDesiredSize(e.captchaPool.Settings()); scan {
+					outcome = "scan"
+				}
+				e.recordLoopFire(loopKeyCaptchaPool, outcome, interval)
 			}
 		}
 	}()
 }
 
 func (e *Engine) tickCaptchaPool(ctx context.Context) {
-	nowMs := time.Now().UnixMilli()
+	nowMs := e.now().UnixMilli()
 	activateAtMs := e.captchaPoolActivateAtMs.Load()
 	if !e.captchaPoolActivated.Load() && activateAtMs > 0 && nowMs >= activateAtMs {
 		e.captchaPoolActivated.Store(true)
@@ -93,12 +109,7 @@ func (e *Engine) tickCaptchaPool(ctx context.Context) {
 		}
 	}
 
-	if !e.captchaPoolActivated.Load() {
-		return
-	}
-
-	settings := e.captchaPool.Settings()
-	desired := settings.PoolSize
+	desired, _ := e.captchaPoolDesiredSize(e.captchaPool.Settings())
 	if desired <= 0 {
 		return
 	}
@@ -114,6 +125,40 @@ func (e *Engine) tickCaptchaPool(ctx context.Context) {
 	_, _, _ = e.FillCaptchaPool(fillCtx, missing)
 }
 
+// captchaPoolDesiredSize 返回验证码池当前应维护的数量：临近开抢且有抢购目标预计需要验证码时为 PoolSize；
+// 处于上新日历窗口（含预热期）且有需要验证码的扫货目标时同样按 PoolSize 预热；
+// 否则只要有需要验证码的扫货目标在运行，就维护较小的常驻数量 ScanPoolSize（scan=true）。
+func (e *Engine) captchaPoolDesiredSize(settings model.CaptchaPoolSettings) (desired int, scan bool) {
+	if e.captchaPoolActivated.Load() && e.hasCaptchaRushTarget() {
+		return settings.PoolSize, false
+	}
+	if e.dropWindowActive(e.now().UnixMilli()) && e.hasCaptchaScanTarget() {
+		return settings.PoolSize, false
+	}
+	if settings.ScanPoolSize <= 0 || !e.hasCaptchaScanTarget() {
+		return 0, false
+	}
+	return settings.ScanPoolSize, true
+}
+
+// hasCaptchaScanTarget 判断是否有运行中的扫货目标确定需要验证码（人工指定或预下单观察到）。
+func (e *Engine) hasCaptchaScanTarget() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, t := range e.targets {
+		if t.Mode != model.TargetModeScan {
+			continue
+		}
+		if st := e.states[t.ID]; st == nil || !st.Running {
+			continue
+		}
+		if need, source := e.targetExpectsCaptchaLocked(t); need && source != captchaExpectUnknown {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *Engine) recalcCaptchaPoolActivateAtMs() {
 	if e == nil {
 		return
@@ -144,34 +189,35 @@ func (e *Engine) recalcCaptchaPoolActivateAtMs() {
 	}
 
 	e.captchaPoolActivateAtMs.Store(minActivateAt)
-	if time.Now().UnixMilli() >= minActivateAt {
+	if e.now().UnixMilli() >= minActivateAt {
 		e.captchaPoolActivated.Store(true)
 	}
 }
 
 func (e *Engine) FillCaptchaPool(ctx context.Context, count int) (added int, failed int, err error) {
-	return e.fillCaptchaPool(ctx, count, false)
+	return e.fillCaptchaPool(ctx, count, false, "")
 }
 
-func (e *Engine) FillCaptchaPoolManual(ctx context.Context, count int) (added int, failed int, err error) {
+// FillCaptchaPoolManual 手动补充验证码池；opID 非空时按每次求解结果推送 captcha_pool_fill 进度。
+func (e *Engine) FillCaptchaPoolManual(ctx context.Context, count int, opID string) (added int, failed int, err error) {
 	if e != nil && e.bus != nil {
 		e.bus.Log("info", "验证码池：手动补充开始", map[string]any{"count": count})
 	}
-	added, failed, err = e.fillCaptchaPool(ctx, count, true)
+	added, failed, err = e.fillCaptchaPool(ctx, count, true, normalizeOpID(opID))
 	if e != nil && e.bus != nil {
 		fields := map[string]any{"added": added, "failed": failed}
 		if err != nil {
 			fields["error"] = err.Error()
 			e.bus.Log("warn", "验证码池：手动补充失败", fields)
 		} else {
-			fields["size"] = e.captchaPool.Size(time.Now().UnixMilli())
+			fields["size"] = e.captchaPool.Size(e.now().UnixMilli())
 			e.bus.Log("info", "验证码池：手动补充完成", fields)
 		}
 	}
 	return added, failed, err
 }
 
-func (e *Engine) fillCaptchaPool(ctx context.Context, count int, manual bool) (added int, failed int, err error) {
+func (e *Engine) fillCaptchaPool(ctx context.Context, count int, manual bool, opID string) (added int, failed int, err error) {
 	if e == nil || e.captchaPool == nil {
 		return 0, 0, errors.New("engine unavailable")
 	}
@@ -210,7 +256,13 @@ func (e *Engine) fillCaptchaPool(ctx context.Context, count int, manual bool) (a
 		_, _ = utils.RefreshCaptchaPages(ctx, utils.CaptchaPagesRefreshOptions{EnsurePages: desiredPages})
 	}
 
-	dracoToken, _ := e.pickDracoToken(ctx)
+	dracoToken, dracoAccountID := e.pickDracoToken(ctx)
+	if !manual {
+		if wait := utils.CaptchaBackoffRemaining(dracoAccountID, utils.CaptchaScenePool); wait > 0 {
+			return 0, 0, nil
+		}
+	}
+	solveCtx := utils.WithCaptchaPriority(ctx, utils.CaptchaPriorityBackground)
 
 	type result struct {
 		param      string
@@ -225,9 +277,9 @@ func (e *Engine) fillCaptchaPool(ctx context.Context, count int, manual bool) (a
 	for i := 0; i < count; i++ {
 		go func() {
 			defer wg.Done()
-			ts := time.Now().UnixMilli()
-			param, metrics, solveErr := utils.SolveAliyunCaptchaWithMetrics(ctx, ts, dracoToken)
-			out <- result{param: strings.TrimSpace(param), solvedAtMs: time.Now().UnixMilli(), metrics: metrics, err: solveErr}
+			ts := e.now().UnixMilli()
+			param, metrics, solveErr := utils.SolveAliyunCaptchaWithMetrics(solveCtx, ts, dracoToken)
+			out <- result{param: strings.TrimSpace(param), solvedAtMs: e.now().UnixMilli(), metrics: metrics, err: solveErr}
 		}()
 	}
 
@@ -236,9 +288,28 @@ func (e *Engine) fillCaptchaPool(ctx context.Context, count int, manual bool) (a
 		close(out)
 	}()
 
+	progress := func(phase, message string) {
+		if !e.progressEnabled(opID) {
+			return
+		}
+		e.publishProgress(logbus.ProgressData{
+			OpID:    opID,
+			Kind:    "captcha_pool_fill",
+			Step:    "solve",
+			Phase:   phase,
+			Message: message,
+			Fields:  map[string]any{"added": added, "failed": failed, "total": count},
+		})
+	}
+
 	for r := range out {
 		if r.err != nil || strings.TrimSpace(r.param) == "" {
 			failed++
+			if r.err != nil {
+				progress("error", r.err.Error())
+			} else {
+				progress("error", "captcha solve failed")
+			}
 			if e.bus != nil {
 				msg := "captcha solve failed"
 				if r.err != nil {
@@ -254,8 +325,26 @@ func (e *Engine) fillCaptchaPool(ctx context.Context, count int, manual bool) (a
 		}
 		if _, ok := e.captchaPool.Add(r.param, r.solvedAtMs); ok {
 			added++
+			progress("success", "已加入验证码池")
 		} else {
 			failed++
+			progress("error", "验证码池拒绝加入")
+		}
+	}
+
+	// 每个结果对应一次求解调用，无论是否成功加入验证码池、之后是否过期都已计费。
+	e.noteCaptchaSolves("", added+failed)
+
+	// 整批都失败才退避，部分成功说明求解服务仍可用。
+	if added > 0 {
+		utils.CaptchaBackoffReset(dracoAccountID, utils.CaptchaScenePool)
+	} else if failed > 0 {
+		wait := utils.CaptchaBackoffFail(dracoAccountID, utils.CaptchaScenePool, errors.New("all captcha solves in batch failed"))
+		if e.bus != nil {
+			e.bus.Log("warn", "验证码池：生成失败，进入退避", map[string]any{
+				"failed":    failed,
+				"backoffMs": wait.Milliseconds(),
+			})
 		}
 	}
 
@@ -270,13 +359,13 @@ func (e *Engine) AddCaptchaVerifyParamManual(verifyParam string) (bool, error) {
 	if param == "" {
 		return false, errors.New("verifyParam is required")
 	}
-	if _, ok := e.captchaPool.Add(param, time.Now().UnixMilli()); !ok {
+	if _, ok := e.captchaPool.Add(param, e.now().UnixMilli()); !ok {
 		return false, errors.New("failed to add verifyParam")
 	}
 	if e.bus != nil {
 		e.bus.Log("info", "验证码池：人工补充完成", map[string]any{
 			"added": 1,
-			"size":  e.captchaPool.Size(time.Now().UnixMilli()),
+			"size":  e.captchaPool.Size(e.now().UnixMilli()),
 		})
 	}
 	return true, nil
@@ -317,10 +406,13 @@ func (e *Engine) pickDracoToken(ctx context.Context) (string, string) {
 	return dracoToken, acc.ID
 }
 
+// dracoCookieName 验证码求解依赖的 cookie，登录或补全会话（bootstrap-session）时由上游下发。
+const dracoCookieName = "draco_local"
+
 func extractDracoToken(acc model.Account) string {
 	for _, cookieEntry := range acc.Cookies {
 		for _, cookie := range cookieEntry.Cookies {
-			if cookie.Name == "draco_local" {
+			if cookie.Name == dracoCookieName {
 				return cookie.Value
 			}
 		}
@@ -328,41 +420,84 @@ func extractDracoToken(acc model.Account) string {
 	return ""
 }
 
-func (e *Engine) captchaVerifyParamForOrder(ctx context.Context, acc model.Account, target model.Target, needCaptcha bool) (string, bool, error) {
+// captchaRequired 判断下单前是否需要求解验证码：账号有可用的免滑块凭证时直接跳过。
+func captchaRequired(pre provider.PreflightResult) bool {
+	return pre.NeedCaptcha && !pre.VerifyTokenAvailable
+}
+
+// captchaSource 是下单用的验证码凭证来源，用于区分命中验证码池和需要计费的现场求解。
+type captchaSource int
+
+const (
+	// captchaNone 不需要验证码，或使用目标上配置的凭证。
+	captchaNone captchaSource = iota
+	captchaPooled
+	// captchaSolved 现场调用了求解服务；求解失败同样计费。
+	captchaSolved
+)
+
+func (e *Engine) captchaVerifyParamForOrder(ctx context.Context, acc model.Account, target model.Target, needCaptcha bool) (string, captchaSource, error) {
 	if !needCaptcha {
-		return "", false, nil
+		return "", captchaNone, nil
 	}
 	if v := strings.TrimSpace(target.CaptchaVerifyParam); v != "" {
-		return v, false, nil
+		return v, captchaNone, nil
 	}
 
 	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 	if v, ok := e.AcquireCaptchaVerifyParam(waitCtx); ok {
-		return v, true, nil
+		return v, captchaPooled, nil
+	}
+
+	if wait := utils.CaptchaBackoffRemaining(acc.ID, utils.CaptchaSceneOrder); wait > 0 {
+		return "", captchaNone, fmt.Errorf("captcha solving backing off, retry in %dms", wait.Milliseconds())
 	}
 
 	dracoToken := extractDracoToken(acc)
 	if _, err := utils.EnsureCaptchaEngineReady(ctx, 0); err != nil {
-		return "", false, err
+		return "", captchaNone, err
+	}
+	ts := e.now().UnixMilli()
+	solveCtx := utils.WithCaptchaPriority(ctx, captchaPriorityFor(target, ts))
+	verifyParam, metrics, err := utils.SolveAliyunCaptchaWithMetrics(solveCtx, ts, dracoToken)
+	verifyParam = strings.TrimSpace(verifyParam)
+	if err == nil && verifyParam == "" {
+		err = errors.New("captcha solving returned empty result")
 	}
-	ts := time.Now().UnixMilli()
-	verifyParam, metrics, err := utils.SolveAliyunCaptchaWithMetrics(ctx, ts, dracoToken)
 	if err != nil {
+		wait := utils.CaptchaBackoffFail(acc.ID, utils.CaptchaSceneOrder, err)
 		if e.bus != nil {
 			e.bus.Log("warn", "验证码处理失败", map[string]any{
 				"accountId": acc.ID,
 				"targetId":  target.ID,
 				"attempts":  metrics.Attempts,
 				"costMs":    metrics.Duration.Milliseconds(),
+				"backoffMs": wait.Milliseconds(),
 				"error":     err.Error(),
 			})
 		}
-		return "", false, fmt.Errorf("failed to solve captcha: %w", err)
+		return "", captchaSolved, fmt.Errorf("failed to solve captcha: %w", err)
 	}
-	verifyParam = strings.TrimSpace(verifyParam)
-	if verifyParam == "" {
-		return "", false, errors.New("captcha solving returned empty result")
+	utils.CaptchaBackoffReset(acc.ID, utils.CaptchaSceneOrder)
+	return verifyParam, captchaSolved, nil
+}
+
+// captchaImminentWindow 开抢前后这段时间内的抢购视为“紧急”，优先占用浏览器页面。
+const captchaImminentWindow = 10 * time.Second
+
+func captchaPriorityFor(target model.Target, nowMs int64) utils.CaptchaPriority {
+	if target.Mode != model.TargetModeRush {
+		return utils.CaptchaPriorityScan
+	}
+	if target.RushAtMs > 0 {
+		d := target.RushAtMs - nowMs
+		if d < 0 {
+			d = -d
+		}
+		if d <= captchaImminentWindow.Milliseconds() {
+			return utils.CaptchaPriorityImminent
+		}
 	}
-	return verifyParam, false, nil
+	return utils.CaptchaPriorityRush
 }
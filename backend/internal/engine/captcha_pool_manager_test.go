@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"testing"
+
+	"sniping_engine/internal/model"
+)
+
+func TestCaptchaPoolScanDemand(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	settings := e.SetCaptchaPoolSettings(model.CaptchaPoolSettings{PoolSize: 4, ScanPoolSize: 1})
+
+	needCaptcha := true
+	e.targets = []model.Target{{ID: "scan-1", Mode: model.TargetModeScan}}
+	e.states["scan-1"] = &model.TaskState{TargetID: "scan-1", Running: true}
+	if n, scan := e.captchaPoolDesiredSize(settings); n != 0 || scan {
+		t.Fatalf("unknown captcha requirement: desired = %d, scan = %v", n, scan)
+	}
+
+	e.states["scan-1"].NeedCaptcha = &needCaptcha
+	if n, scan := e.captchaPoolDesiredSize(settings); n != 1 || !scan {
+		t.Fatalf("captcha scan target: desired = %d, scan = %v; want 1, true", n, scan)
+	}
+
+	e.targets = append(e.targets, model.Target{ID: "rush-1", Mode: model.TargetModeRush})
+	e.captchaPoolActivated.Store(true)
+	if n, scan := e.captchaPoolDesiredSize(settings); n != 4 || scan {
+		t.Fatalf("rush activated: desired = %d, scan = %v; want 4, false", n, scan)
+	}
+
+	e.captchaPoolActivated.Store(false)
+	settings = e.SetCaptchaPoolSettings(model.CaptchaPoolSettings{PoolSize: 4})
+	if n, _ := e.captchaPoolDesiredSize(settings); n != 0 {
+		t.Fatalf("scanPoolSize=0 should disable the scan pool, desired = %d", n)
+	}
+}
+
+func TestCaptchaPoolRushSizingUsesCaptchaMemory(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	settings := e.SetCaptchaPoolSettings(model.CaptchaPoolSettings{PoolSize: 4})
+	e.captchaPoolActivated.Store(true)
+
+	rush := model.Target{ID: "rush-1", Mode: model.TargetModeRush}
+	e.targets = []model.Target{rush}
+	if n, _ := e.captchaPoolDesiredSize(settings); n != 4 {
+		t.Fatalf("never observed: desired = %d, want 4", n)
+	}
+
+	noCaptcha := false
+	e.savedStates = map[string]model.TaskState{"rush-1": {TargetID: "rush-1", NeedCaptcha: &noCaptcha}}
+	if n, _ := e.captchaPoolDesiredSize(settings); n != 0 {
+		t.Fatalf("saved needCaptcha=false: desired = %d, want 0", n)
+	}
+
+	e.targets[0].CaptchaOverride = model.CaptchaOverrideRequired
+	if n, _ := e.captchaPoolDesiredSize(settings); n != 4 {
+		t.Fatalf("override required: desired = %d, want 4", n)
+	}
+}
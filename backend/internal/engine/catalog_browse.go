@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// catalogBrowseMaxPageSize 实时浏览商品时每页的最大条数。
+const catalogBrowseMaxPageSize = 100
+
+// BrowseCatalogCategories 用 accountID（为空时按商品目录设置选择账号）实时查询前台分类的分类树，
+// 返回展开后的分类列表；结果不写入缓存。
+func (e *Engine) BrowseCatalogCategories(ctx context.Context, accountID string, frontID int64) ([]model.CatalogCategory, error) {
+	if frontID <= 0 {
+		return nil, errors.New("frontCategoryId is required")
+	}
+	acc, err := e.catalogBrowseAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	raw, updated, err := e.provider.GetCategoryTree(ctx, acc, provider.CategoryTreeParams{FrontCategoryID: frontID, IsFinish: true})
+	if err != nil {
+		return nil, err
+	}
+	_ = e.persistAccount(ctx, updated)
+	list, err := flattenCatalogTree(frontID, raw)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []model.CatalogCategory{}
+	}
+	return list, nil
+}
+
+// BrowseCatalogSkus 实时查询前台分类下第 page 页（从 1 开始，每页 q.Limit 条，默认 50、最多 100）的门店商品，
+// 再按 q 的 categoryId/storeId/关键字过滤；结果不写入缓存。
+func (e *Engine) BrowseCatalogSkus(ctx context.Context, accountID string, q model.CatalogSkuQuery, page int) ([]model.CatalogSku, error) {
+	if q.FrontCategoryID <= 0 {
+		return nil, errors.New("frontCategoryId is required")
+	}
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := q.Limit
+	if pageSize <= 0 {
+		pageSize = catalogPageSize
+	}
+	if pageSize > catalogBrowseMaxPageSize {
+		pageSize = catalogBrowseMaxPageSize
+	}
+	acc, err := e.catalogBrowseAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	raw, updated, err := e.provider.GetStoreSkuByCategory(ctx, acc, provider.StoreSkuByCategoryParams{
+		PageNo:          page,
+		PageSize:        pageSize,
+		FrontCategoryID: q.FrontCategoryID,
+		IsFinish:        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = e.persistAccount(ctx, updated)
+	items, err := parseCatalogSkus(q.FrontCategoryID, raw)
+	if err != nil {
+		return nil, err
+	}
+	kw := strings.ToLower(strings.TrimSpace(q.Keyword))
+	out := make([]model.CatalogSku, 0, len(items))
+	for _, it := range items {
+		if q.CategoryID > 0 && it.CategoryID != q.CategoryID {
+			continue
+		}
+		if q.StoreID > 0 && it.StoreID != q.StoreID {
+			continue
+		}
+		if kw != "" && !strings.Contains(strings.ToLower(it.Name), kw) {
+			continue
+		}
+		out = append(out, it)
+	}
+	return out, nil
+}
+
+// catalogBrowseAccount 选出实时浏览用的账号并等待它的限流配额。
+func (e *Engine) catalogBrowseAccount(ctx context.Context, accountID string) (model.Account, error) {
+	if e == nil || e.store == nil {
+		return model.Account{}, errors.New("store unavailable")
+	}
+	if e.provider == nil {
+		return model.Account{}, errors.New("provider unavailable")
+	}
+	accountID = strings.TrimSpace(accountID)
+	if accountID == "" {
+		accountID = e.CatalogSettings().AccountID
+	}
+	acc, err := e.catalogAccount(ctx, accountID)
+	if err != nil {
+		return model.Account{}, err
+	}
+	e.ensureAccountLimiter(acc.ID)
+	if !e.waitLimits(ctx, acc.ID) {
+		return model.Account{}, ctx.Err()
+	}
+	return acc, nil
+}
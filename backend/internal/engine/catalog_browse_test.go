@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+type catalogProvider struct {
+	idleProvider
+	skuParams *provider.StoreSkuByCategoryParams
+}
+
+func (catalogProvider) GetCategoryTree(_ context.Context, account model.Account, _ provider.CategoryTreeParams) (json.RawMessage, model.Account, error) {
+	return json.RawMessage(`[{"id": 10, "pid": 0, "level": 1, "name": "文创", "hasChildren": true,
+		"childrenList": [{"id": 11, "pid": 10, "level": 2, "name": "徽章"}]}]`), account, nil
+}
+
+func (p catalogProvider) GetStoreSkuByCategory(_ context.Context, account model.Account, params provider.StoreSkuByCategoryParams) (json.RawMessage, model.Account, error) {
+	*p.skuParams = params
+	return json.RawMessage(`[{"categoryId": 11, "categoryName": "徽章", "storeSkuModelList": [
+		{"skuId": 101, "itemId": 201, "storeId": 7, "shopId": 8, "name": "招财纳福牌", "price": 1800, "inStock": 10, "purchaseLimit": 2},
+		{"skuId": 102, "itemId": 202, "storeId": 7, "shopId": 8, "name": "瑞蛇起舞扣", "price": 2800, "inStock": 0, "purchaseLimit": 1}
+	]}]`), account, nil
+}
+
+func TestBrowseCatalogUsesSelectedAccount(t *testing.T) {
+	e, st, _ := newLifecycleEngine(t)
+	var params provider.StoreSkuByCategoryParams
+	e.provider = catalogProvider{skuParams: &params}
+	ctx := context.Background()
+
+	if _, err := e.BrowseCatalogCategories(ctx, "missing", 10); err == nil {
+		t.Fatalf("expected error for unknown account")
+	}
+	cats, err := e.BrowseCatalogCategories(ctx, "", 10)
+	if err != nil {
+		t.Fatalf("BrowseCatalogCategories: %v", err)
+	}
+	if len(cats) != 2 || cats[1].ID != 11 || cats[1].Name != "徽章" {
+		t.Fatalf("categories = %+v", cats)
+	}
+
+	skus, err := e.BrowseCatalogSkus(ctx, "", model.CatalogSkuQuery{FrontCategoryID: 10, Keyword: "纳福", Limit: 500}, 2)
+	if err != nil {
+		t.Fatalf("BrowseCatalogSkus: %v", err)
+	}
+	if params.PageNo != 2 || params.PageSize != catalogBrowseMaxPageSize || params.FrontCategoryID != 10 {
+		t.Fatalf("upstream params = %+v", params)
+	}
+	if len(skus) != 1 {
+		t.Fatalf("skus = %+v", skus)
+	}
+	if s := skus[0]; s.SKUID != 101 || s.ItemID != 201 || s.ShopID != 8 || s.Price != 1800 || s.InStock != 10 || s.PurchaseLimit != 2 {
+		t.Fatalf("sku = %+v", s)
+	}
+	if cached, err := st.ListCatalogSkus(ctx, model.CatalogSkuQuery{}); err != nil || len(cached) != 0 {
+		t.Fatalf("live browse should not touch the cache: %+v, %v", cached, err)
+	}
+}
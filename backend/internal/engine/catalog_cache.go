@@ -0,0 +1,411 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+const (
+	catalogPageSize      = 50
+	catalogCheckInterval = time.Minute
+	// catalogRushGuard 抢购目标开抢前后这段时间内不刷新目录，避免与抢购争用限速令牌。
+	catalogRushGuard = 5 * time.Minute
+)
+
+var errCatalogRushWindow = errors.New("catalog refresh paused: a rush target is inside its rush window")
+
+func DefaultCatalogSettings() model.CatalogSettings {
+	return model.CatalogSettings{
+		Enabled:                false,
+		RefreshIntervalMinutes: 60,
+		MaxPages:               10,
+	}
+}
+
+func NormalizeCatalogSettings(in model.CatalogSettings) model.CatalogSettings {
+	out := in
+	if out.RefreshIntervalMinutes <= 0 {
+		out.RefreshIntervalMinutes = 60
+	}
+	if out.RefreshIntervalMinutes < 5 {
+		out.RefreshIntervalMinutes = 5
+	}
+	if out.RefreshIntervalMinutes > 1440 {
+		out.RefreshIntervalMinutes = 1440
+	}
+	if out.MaxPages <= 0 {
+		out.MaxPages = 10
+	}
+	if out.MaxPages > 100 {
+		out.MaxPages = 100
+	}
+	out.AccountID = strings.TrimSpace(out.AccountID)
+
+	seen := make(map[int64]bool, len(out.FrontCategoryIDs))
+	ids := make([]int64, 0, len(out.FrontCategoryIDs))
+	for _, id := range out.FrontCategoryIDs {
+		if id <= 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	out.FrontCategoryIDs = ids
+	return out
+}
+
+func (e *Engine) CatalogSettings() model.CatalogSettings {
+	if e == nil {
+		return DefaultCatalogSettings()
+	}
+	if s, ok := e.catalogSettings.Load().(model.CatalogSettings); ok {
+		return NormalizeCatalogSettings(s)
+	}
+	return DefaultCatalogSettings()
+}
+
+func (e *Engine) SetCatalogSettings(next model.CatalogSettings) model.CatalogSettings {
+	next = NormalizeCatalogSettings(next)
+	if e == nil {
+		return next
+	}
+	e.catalogSettings.Store(next)
+	return next
+}
+
+func (e *Engine) CatalogStatus() model.CatalogRefreshStatus {
+	if e == nil {
+		return model.CatalogRefreshStatus{}
+	}
+	e.catalogMu.Lock()
+	defer e.catalogMu.Unlock()
+	out := e.catalogStatus
+	if st := e.CatalogSettings(); st.Enabled && len(st.FrontCategoryIDs) > 0 {
+		base := out.LastFinishMs
+		if base == 0 {
+			base = e.now().UnixMilli()
+		}
+		out.NextRefreshMs = base + int64(st.RefreshIntervalMinutes)*time.Minute.Milliseconds()
+	}
+	return out
+}
+
+// StartCatalogRefresher 启动分类/商品缓存的定时刷新（与引擎启停无关，随 ctx 结束）。
+func (e *Engine) StartCatalogRefresher(ctx context.Context) {
+	if e == nil || e.store == nil || e.provider == nil {
+		return
+	}
+	e.registerLoop(LoopInfo{Key: loopKeyCatalog, Kind: LoopKindCatalog, Phase: loopPhaseRunning, IntervalMs: catalogCheckInterval.Milliseconds()})
+	go func() {
+		defer e.unregisterLoop(loopKeyCatalog)
+		ticker := e.clk().NewTicker(catalogCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				outcome := "idle"
+				nowMs := e.now().UnixMilli()
+				if e.catalogRefreshDue(nowMs) {
+					if e.inRushWindow(nowMs) {
+						outcome = "deferred: rush window"
+					} else {
+						refreshCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+						if _, err := e.RefreshCatalog(refreshCtx); err != nil {
+							outcome = "error: " + err.Error()
+						} else {
+							outcome = "refreshed"
+						}
+						cancel()
+					}
+				}
+				e.recordLoopFire(loopKeyCatalog, outcome, catalogCheckInterval)
+			}
+		}
+	}()
+}
+
+func (e *Engine) catalogRefreshDue(nowMs int64) bool {
+	st := e.CatalogSettings()
+	if !st.Enabled || len(st.FrontCategoryIDs) == 0 {
+		return false
+	}
+	e.catalogMu.Lock()
+	last := e.catalogStatus.LastFinishMs
+	e.catalogMu.Unlock()
+	return last == 0 || nowMs-last >= int64(st.RefreshIntervalMinutes)*time.Minute.Milliseconds()
+}
+
+// inRushWindow 判断是否有运行中的抢购目标处在开抢前后 catalogRushGuard 之内。
+func (e *Engine) inRushWindow(nowMs int64) bool {
+	guardMs := catalogRushGuard.Milliseconds()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, t := range e.targets {
+		if t.Mode != model.TargetModeRush || t.RushAtMs <= 0 {
+			continue
+		}
+		if nowMs >= t.RushAtMs-guardMs && nowMs <= t.RushAtMs+guardMs {
+			return true
+		}
+	}
+	return false
+}
+
+// RefreshCatalog 立即从上游拉取配置的前台分类树和商品，整体替换本地缓存。
+func (e *Engine) RefreshCatalog(ctx context.Context) (model.CatalogRefreshStatus, error) {
+	if e == nil || e.store == nil || e.provider == nil {
+		return model.CatalogRefreshStatus{}, errors.New("engine unavailable")
+	}
+	st := e.CatalogSettings()
+	if len(st.FrontCategoryIDs) == 0 {
+		return model.CatalogRefreshStatus{}, errors.New("no frontCategoryIds configured")
+	}
+	if !e.catalogRefreshing.CompareAndSwap(false, true) {
+		return e.CatalogStatus(), errors.New("catalog refresh already running")
+	}
+	defer e.catalogRefreshing.Store(false)
+
+	status := model.CatalogRefreshStatus{Running: true, LastStartMs: e.now().UnixMilli()}
+	e.setCatalogStatus(status)
+
+	err := e.refreshCatalog(ctx, st, &status)
+	status.Running = false
+	status.LastFinishMs = e.now().UnixMilli()
+	if err != nil {
+		status.LastError = err.Error()
+	}
+	e.setCatalogStatus(status)
+
+	if e.bus != nil {
+		fields := map[string]any{
+			"accountId":  status.AccountID,
+			"categories": status.Categories,
+			"skus":       status.Skus,
+			"costMs":     status.LastFinishMs - status.LastStartMs,
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+			e.bus.Log("warn", "商品目录缓存刷新失败", fields)
+		} else {
+			e.bus.Log("info", "商品目录缓存已刷新", fields)
+		}
+	}
+	return e.CatalogStatus(), err
+}
+
+func (e *Engine) setCatalogStatus(st model.CatalogRefreshStatus) {
+	e.catalogMu.Lock()
+	e.catalogStatus = st
+	e.catalogMu.Unlock()
+}
+
+func (e *Engine) refreshCatalog(ctx context.Context, st model.CatalogSettings, status *model.CatalogRefreshStatus) error {
+	acc, err := e.catalogAccount(ctx, st.AccountID)
+	if err != nil {
+		return err
+	}
+	status.AccountID = acc.ID
+	e.ensureAccountLimiter(acc.ID)
+
+	for _, frontID := range st.FrontCategoryIDs {
+		if e.inRushWindow(e.now().UnixMilli()) {
+			return errCatalogRushWindow
+		}
+		if !e.waitLimits(ctx, acc.ID) {
+			return ctx.Err()
+		}
+		treeRaw, updated, err := e.provider.GetCategoryTree(ctx, acc, provider.CategoryTreeParams{FrontCategoryID: frontID, IsFinish: true})
+		if err != nil {
+			return err
+		}
+		acc = updated
+		categories, err := flattenCatalogTree(frontID, treeRaw)
+		if err != nil {
+			return err
+		}
+		if err := e.store.ReplaceCatalogCategories(ctx, frontID, categories); err != nil {
+			return err
+		}
+		status.Categories += len(categories)
+
+		var skus []model.CatalogSku
+		for page := 1; page <= st.MaxPages; page++ {
+			if e.inRushWindow(e.now().UnixMilli()) {
+				return errCatalogRushWindow
+			}
+			if !e.waitLimits(ctx, acc.ID) {
+				return ctx.Err()
+			}
+			raw, updated, err := e.provider.GetStoreSkuByCategory(ctx, acc, provider.StoreSkuByCategoryParams{
+				PageNo:          page,
+				PageSize:        catalogPageSize,
+				FrontCategoryID: frontID,
+				IsFinish:        true,
+			})
+			if err != nil {
+				return err
+			}
+			acc = updated
+			items, err := parseCatalogSkus(frontID, raw)
+			if err != nil {
+				return err
+			}
+			skus = append(skus, items...)
+			if len(items) < catalogPageSize {
+				break
+			}
+		}
+		if err := e.store.ReplaceCatalogSkus(ctx, frontID, skus); err != nil {
+			return err
+		}
+		status.Skus += len(skus)
+	}
+	_ = e.persistAccount(ctx, acc)
+	return nil
+}
+
+func (e *Engine) catalogAccount(ctx context.Context, accountID string) (model.Account, error) {
+	if accountID != "" {
+		acc, err := e.store.GetAccount(ctx, accountID)
+		if err != nil {
+			return model.Account{}, err
+		}
+		if strings.TrimSpace(acc.Token) == "" {
+			return model.Account{}, errors.New("catalog account not logged in")
+		}
+		return acc, nil
+	}
+	accounts, err := e.store.ListAccounts(ctx)
+	if err != nil {
+		return model.Account{}, err
+	}
+	accounts = filterLoggedInAccounts(accounts)
+	if len(accounts) == 0 {
+		return model.Account{}, errors.New("no logged-in account for catalog refresh")
+	}
+	return accounts[0], nil
+}
+
+func jsonNumberInt64(n json.Number) int64 {
+	if v, err := n.Int64(); err == nil {
+		return v
+	}
+	if f, err := n.Float64(); err == nil {
+		return int64(f)
+	}
+	return 0
+}
+
+func decodeJSONNumber(raw []byte, out any) error {
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.UseNumber()
+	return dec.Decode(out)
+}
+
+// flattenCatalogTree 把上游返回的分类树（childrenList 嵌套）展开成平铺列表。
+func flattenCatalogTree(frontID int64, raw json.RawMessage) ([]model.CatalogCategory, error) {
+	var nodes []map[string]any
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	if err := decodeJSONNumber(raw, &nodes); err != nil {
+		return nil, err
+	}
+	var out []model.CatalogCategory
+	var walk func(list []any)
+	walk = func(list []any) {
+		for _, v := range list {
+			node, ok := v.(map[string]any)
+			if !ok {
+				continue
+			}
+			children, _ := node["childrenList"].([]any)
+			delete(node, "childrenList")
+
+			c := model.CatalogCategory{FrontCategoryID: frontID}
+			if n, ok := node["id"].(json.Number); ok {
+				c.ID = jsonNumberInt64(n)
+			}
+			if n, ok := node["pid"].(json.Number); ok {
+				c.PID = jsonNumberInt64(n)
+			}
+			if n, ok := node["level"].(json.Number); ok {
+				c.Level = int(jsonNumberInt64(n))
+			}
+			c.Name, _ = node["name"].(string)
+			c.HasChildren, _ = node["hasChildren"].(bool)
+			c.Raw, _ = json.Marshal(node)
+			if c.ID != 0 {
+				out = append(out, c)
+			}
+			walk(children)
+		}
+	}
+	list := make([]any, 0, len(nodes))
+	for _, n := range nodes {
+		list = append(list, n)
+	}
+	walk(list)
+	return out, nil
+}
+
+func parseCatalogSkus(frontID int64, raw json.RawMessage) ([]model.CatalogSku, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var groups []struct {
+		CategoryID        json.Number       `json:"categoryId"`
+		CategoryName      string            `json:"categoryName"`
+		StoreSkuModelList []json.RawMessage `json:"storeSkuModelList"`
+	}
+	if err := decodeJSONNumber(raw, &groups); err != nil {
+		return nil, err
+	}
+	var out []model.CatalogSku
+	for _, g := range groups {
+		for _, skuRaw := range g.StoreSkuModelList {
+			var sku struct {
+				SKUID         json.Number `json:"skuId"`
+				ItemID        json.Number `json:"itemId"`
+				StoreID       json.Number `json:"storeId"`
+				ShopID        json.Number `json:"shopId"`
+				Name          string      `json:"name"`
+				MainImage     string      `json:"mainImage"`
+				Price         json.Number `json:"price"`
+				InStock       json.Number `json:"inStock"`
+				PurchaseLimit json.Number `json:"purchaseLimit"`
+			}
+			if err := decodeJSONNumber(skuRaw, &sku); err != nil {
+				continue
+			}
+			item := model.CatalogSku{
+				FrontCategoryID: frontID,
+				CategoryID:      jsonNumberInt64(g.CategoryID),
+				CategoryName:    g.CategoryName,
+				StoreID:         jsonNumberInt64(sku.StoreID),
+				ShopID:          jsonNumberInt64(sku.ShopID),
+				SKUID:           jsonNumberInt64(sku.SKUID),
+				ItemID:          jsonNumberInt64(sku.ItemID),
+				Name:            sku.Name,
+				MainImage:       sku.MainImage,
+				Price:           jsonNumberInt64(sku.Price),
+				InStock:         jsonNumberInt64(sku.InStock),
+				PurchaseLimit:   jsonNumberInt64(sku.PurchaseLimit),
+				Raw:             skuRaw,
+			}
+			if item.SKUID == 0 {
+				continue
+			}
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
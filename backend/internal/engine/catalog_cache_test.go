@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+func TestRefreshCatalogPausedInsideRushWindow(t *testing.T) {
+	e, _, _ := newLifecycleEngine(t)
+	var params provider.StoreSkuByCategoryParams
+	e.provider = catalogProvider{skuParams: &params}
+	e.SetCatalogSettings(model.CatalogSettings{Enabled: true, FrontCategoryIDs: []int64{10}, MaxPages: 1})
+	ctx := context.Background()
+
+	rush := model.Target{ID: "r1", Mode: model.TargetModeRush, RushAtMs: e.now().Add(time.Minute).UnixMilli()}
+	e.mu.Lock()
+	e.targets = []model.Target{rush}
+	e.mu.Unlock()
+	if !e.inRushWindow(e.now().UnixMilli()) {
+		t.Fatal("target one minute before rush should be inside the window")
+	}
+	if _, err := e.RefreshCatalog(ctx); !errors.Is(err, errCatalogRushWindow) {
+		t.Fatalf("refresh inside rush window: err = %v", err)
+	}
+	if params.FrontCategoryID != 0 {
+		t.Fatalf("no upstream page should be fetched inside the rush window: %+v", params)
+	}
+
+	e.mu.Lock()
+	e.targets[0].RushAtMs = e.now().Add(time.Hour).UnixMilli()
+	e.mu.Unlock()
+	status, err := e.RefreshCatalog(ctx)
+	if err != nil || status.Skus != 2 {
+		t.Fatalf("refresh outside rush window: %+v, %v", status, err)
+	}
+}
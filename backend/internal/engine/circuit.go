@@ -0,0 +1,24 @@
+package engine
+
+import (
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// createCircuitOpen 判断目标所用 provider 的 create-order 是否正在熔断。熔断期间所有目标一起跳过尝试，
+// 不再预下单、求解验证码，也不计入目标的连续失败；熔断恢复后按原节拍继续。
+func (e *Engine) createCircuitOpen(target model.Target) bool {
+	cr, ok := e.providerFor(target).(provider.CircuitReporter)
+	return ok && !cr.CircuitAllows("create-order")
+}
+
+// CircuitStates 返回主 provider 各上游接口的熔断状态；provider 不支持熔断时为空。
+func (e *Engine) CircuitStates() []model.CircuitState {
+	if e == nil {
+		return nil
+	}
+	if cr, ok := e.provider.(provider.CircuitReporter); ok {
+		return cr.CircuitStates()
+	}
+	return nil
+}
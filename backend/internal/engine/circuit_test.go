@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// circuitProvider 的 create-order 处于熔断中，预下单被调用时计数。
+type circuitProvider struct {
+	orderingProvider
+	preflights *int
+}
+
+func (p circuitProvider) Preflight(ctx context.Context, acc model.Account, t model.Target) (provider.PreflightResult, model.Account, error) {
+	*p.preflights++
+	return p.orderingProvider.Preflight(ctx, acc, t)
+}
+
+func (circuitProvider) CircuitAllows(api string) bool { return api != "create-order" }
+
+func (circuitProvider) CircuitStates() []model.CircuitState {
+	return []model.CircuitState{{API: "create-order", State: provider.CircuitOpen, Trips: 1}}
+}
+
+func TestAttemptSkipsWhileCreateCircuitOpen(t *testing.T) {
+	var preflights int
+	e := New(Options{Provider: circuitProvider{preflights: &preflights}})
+
+	target := model.Target{ID: "t", Mode: model.TargetModeScan, ItemID: 1, SKUID: 1, TargetQty: 1, PerOrderQty: 1}
+	res := e.attemptWithAccount(context.Background(), target, model.Account{ID: "a1", Token: "x"}, 1)
+	if res.ErrorClass != model.AttemptErrorCircuitOpen {
+		t.Fatalf("errorClass = %q, want %q", res.ErrorClass, model.AttemptErrorCircuitOpen)
+	}
+	if preflights != 0 {
+		t.Fatalf("preflight called %d times while circuit open", preflights)
+	}
+	if attemptReachedUpstream(res) {
+		t.Fatalf("circuit_open attempt should not count as reaching upstream")
+	}
+
+	cbs := e.State().Metrics.CircuitBreakers
+	if len(cbs) != 1 || cbs[0].State != provider.CircuitOpen {
+		t.Fatalf("metrics.circuitBreakers = %+v", cbs)
+	}
+}
@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"sniping_engine/internal/clock"
+)
+
+// now 返回引擎时钟的当前时间（测试里可注入 clock.Fake）。
+func (e *Engine) now() time.Time {
+	if e == nil || e.clock == nil {
+		return time.Now()
+	}
+	return e.clock.Now()
+}
+
+func (e *Engine) clk() clock.Clock {
+	if e == nil || e.clock == nil {
+		return clock.Real()
+	}
+	return e.clock
+}
+
+// sleepUntil 睡到 t；ctx 取消时返回 false。
+func (e *Engine) sleepUntil(ctx context.Context, t time.Time) bool {
+	d := t.Sub(e.now())
+	if d <= 0 {
+		return true
+	}
+	timer := e.clk().NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,209 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+const (
+	// clockSampleSpacing 相邻两次采样的间隔；取一个不整除 1 秒的值，让各次采样落在秒内不同位置。
+	clockSampleSpacing = 137 * time.Millisecond
+	// clockApplyUncertainty 偏差区间的半宽超过该值时只记录、不用于修正开抢时刻。
+	clockApplyUncertainty = 250 * time.Millisecond
+	// clockMaxOffset 偏差超过该值视为异常（上游 Date 头不可信），不用于修正。
+	clockMaxOffset = 5 * time.Minute
+	// clockOffsetWarn 偏差超过该值时记告警日志，提示检查本机时间同步。
+	clockOffsetWarn = time.Second
+)
+
+// ClockCalibration 是最近一次时钟校准的结果。OffsetMs 为上游时间减本机时间（正值表示本机慢），
+// Applied 表示该偏差已用于修正开抢时刻。
+type ClockCalibration struct {
+	OffsetMs       int64  `json:"offsetMs"`
+	UncertaintyMs  int64  `json:"uncertaintyMs"`
+	Samples        int    `json:"samples"`
+	Failures       int    `json:"failures,omitempty"`
+	MedianRTTMs    int64  `json:"medianRttMs"`
+	CalibratedAtMs int64  `json:"calibratedAtMs"`
+	Applied        bool   `json:"applied"`
+	Error          string `json:"error,omitempty"`
+	// AppliedOffsetMs 当前用于修正开抢时刻的偏差（可能来自更早的一次校准）。
+	AppliedOffsetMs int64 `json:"appliedOffsetMs"`
+}
+
+// clockSample 是一次 Date 头采样：请求发出、收到响应的本机时间与上游 Date（截断到秒）。
+type clockSample struct {
+	sent     time.Time
+	received time.Time
+	date     time.Time
+}
+
+// estimateClockOffset 由多次采样估算 上游时间-本机时间。每次采样说明上游时间在 [date, date+1s) 内、
+// 且落在本机 [sent, received] 之间，即偏差在 [date-received, date+1s-sent] 内；多次采样取交集收窄区间。
+// 交集为空（采样间上游时间跳变等）时退回各次中点的中位数，区间半宽按 0.5s+RTT/2 计。
+func estimateClockOffset(samples []clockSample) (offset time.Duration, uncertainty time.Duration, ok bool) {
+	if len(samples) == 0 {
+		return 0, 0, false
+	}
+	var lo, hi time.Duration
+	for i, s := range samples {
+		l := s.date.Sub(s.received)
+		h := s.date.Add(time.Second).Sub(s.sent)
+		if i == 0 || l > lo {
+			lo = l
+		}
+		if i == 0 || h < hi {
+			hi = h
+		}
+	}
+	if lo <= hi {
+		return lo + (hi-lo)/2, (hi - lo) / 2, true
+	}
+
+	mids := make([]time.Duration, 0, len(samples))
+	var maxRTT time.Duration
+	for _, s := range samples {
+		rtt := s.received.Sub(s.sent)
+		if rtt > maxRTT {
+			maxRTT = rtt
+		}
+		mids = append(mids, s.date.Add(500*time.Millisecond).Sub(s.sent.Add(rtt/2)))
+	}
+	sort.Slice(mids, func(i, j int) bool { return mids[i] < mids[j] })
+	return mids[len(mids)/2], 500*time.Millisecond + maxRTT/2, true
+}
+
+// ClockOffset 返回当前用于修正开抢时刻的偏差（上游时间-本机时间）。
+func (e *Engine) ClockOffset() time.Duration {
+	return time.Duration(e.clockOffsetMs.Load()) * time.Millisecond
+}
+
+// rushStartAt 返回目标开抢时刻对应的本机时间：rushAtMs 按上游时间理解，本机慢 offset 时需要提前 offset 触发。
+func (e *Engine) rushStartAt(target model.Target) time.Time {
+	return time.UnixMilli(target.RushAtMs).Add(-e.ClockOffset())
+}
+
+// ClockCalibration 返回最近一次时钟校准结果（从未校准时 CalibratedAtMs 为 0）。
+func (e *Engine) ClockCalibration() ClockCalibration {
+	e.clockMu.Lock()
+	out := e.clockCalib
+	e.clockMu.Unlock()
+	out.AppliedOffsetMs = e.clockOffsetMs.Load()
+	return out
+}
+
+// CalibrateClock 立即采样上游 Date 头估算时钟偏差；区间足够窄且偏差在合理范围内时用于修正开抢时刻。
+func (e *Engine) CalibrateClock(ctx context.Context) (ClockCalibration, error) {
+	src, ok := e.provider.(provider.ServerClock)
+	if !ok {
+		return ClockCalibration{}, errors.New("provider does not support clock calibration")
+	}
+	n := e.task.ClockSync.SampleCount()
+	samples := make([]clockSample, 0, n)
+	failures := 0
+	var lastErr error
+	for i := 0; i < n; i++ {
+		if i > 0 && !e.sleepUntil(ctx, e.now().Add(clockSampleSpacing)) {
+			return ClockCalibration{}, ctx.Err()
+		}
+		sent := e.now()
+		date, err := src.ServerDate(ctx)
+		received := e.now()
+		if err != nil {
+			failures++
+			lastErr = err
+			continue
+		}
+		samples = append(samples, clockSample{sent: sent, received: received, date: date})
+	}
+
+	cal := ClockCalibration{Samples: len(samples), Failures: failures, CalibratedAtMs: e.now().UnixMilli()}
+	offset, uncertainty, ok := estimateClockOffset(samples)
+	if !ok {
+		if lastErr == nil {
+			lastErr = errors.New("no clock samples")
+		}
+		cal.Error = lastErr.Error()
+		e.setClockCalibration(cal)
+		return e.ClockCalibration(), lastErr
+	}
+	cal.OffsetMs = offset.Milliseconds()
+	cal.UncertaintyMs = uncertainty.Milliseconds()
+	cal.MedianRTTMs = medianRTT(samples).Milliseconds()
+	if uncertainty <= clockApplyUncertainty && offset <= clockMaxOffset && offset >= -clockMaxOffset {
+		cal.Applied = true
+		e.clockOffsetMs.Store(cal.OffsetMs)
+	}
+	e.setClockCalibration(cal)
+
+	if e.bus != nil {
+		level := "info"
+		if offset > clockOffsetWarn || offset < -clockOffsetWarn || !cal.Applied {
+			level = "warn"
+		}
+		e.bus.Log(level, "时钟校准完成", map[string]any{
+			"offsetMs":      cal.OffsetMs,
+			"uncertaintyMs": cal.UncertaintyMs,
+			"samples":       cal.Samples,
+			"applied":       cal.Applied,
+		})
+	}
+	return e.ClockCalibration(), nil
+}
+
+func (e *Engine) setClockCalibration(cal ClockCalibration) {
+	e.clockMu.Lock()
+	e.clockCalib = cal
+	e.clockMu.Unlock()
+}
+
+func medianRTT(samples []clockSample) time.Duration {
+	rtts := make([]time.Duration, 0, len(samples))
+	for _, s := range samples {
+		rtts = append(rtts, s.received.Sub(s.sent))
+	}
+	sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+	return rtts[len(rtts)/2]
+}
+
+// StartClockCalibrator 启动定时时钟校准（与引擎启停无关，随 ctx 结束）；provider 不支持或配置关闭时不启动。
+func (e *Engine) StartClockCalibrator(ctx context.Context) {
+	if e == nil || e.task.ClockSync.Disabled {
+		return
+	}
+	if _, ok := e.provider.(provider.ServerClock); !ok {
+		return
+	}
+	interval := e.task.ClockSync.Interval()
+	e.registerLoop(LoopInfo{Key: loopKeyClockSync, Kind: LoopKindClockSync, Phase: loopPhaseRunning, IntervalMs: interval.Milliseconds()})
+	go func() {
+		defer e.unregisterLoop(loopKeyClockSync)
+		calibrate := func() {
+			calCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			defer cancel()
+			outcome := "calibrated"
+			if cal, err := e.CalibrateClock(calCtx); err != nil {
+				outcome = "error: " + err.Error()
+			} else if !cal.Applied {
+				outcome = "not applied"
+			}
+			e.recordLoopFire(loopKeyClockSync, outcome, interval)
+		}
+		calibrate()
+		ticker := e.clk().NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				calibrate()
+			}
+		}
+	}()
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/clock"
+	"sniping_engine/internal/model"
+)
+
+// skewedServer 模拟比本机快 offset 的上游：每次请求往返 rtt，Date 头截断到秒。
+type skewedServer struct {
+	idleProvider
+	fc     *clock.Fake
+	offset time.Duration
+	rtt    time.Duration
+}
+
+func (s skewedServer) ServerDate(context.Context) (time.Time, error) {
+	s.fc.Advance(s.rtt / 2)
+	date := s.fc.Now().Add(s.offset).Truncate(time.Second)
+	s.fc.Advance(s.rtt / 2)
+	return date, nil
+}
+
+func TestEstimateClockOffsetNarrowsWithSamples(t *testing.T) {
+	base := testStart.Add(123 * time.Millisecond)
+	offset := 1234 * time.Millisecond
+	rtt := 20 * time.Millisecond
+	var samples []clockSample
+	for i := 0; i < 8; i++ {
+		sent := base.Add(time.Duration(i) * clockSampleSpacing)
+		samples = append(samples, clockSample{
+			sent:     sent,
+			received: sent.Add(rtt),
+			date:     sent.Add(rtt / 2).Add(offset).Truncate(time.Second),
+		})
+	}
+	got, uncertainty, ok := estimateClockOffset(samples)
+	if !ok {
+		t.Fatal("no estimate")
+	}
+	if diff := got - offset; diff > uncertainty || diff < -uncertainty {
+		t.Fatalf("offset = %v ± %v, want %v", got, uncertainty, offset)
+	}
+	if uncertainty > 100*time.Millisecond {
+		t.Fatalf("uncertainty = %v, want <= 100ms after 8 spaced samples", uncertainty)
+	}
+}
+
+func TestCalibrateClockShiftsRushStart(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	e.provider = skewedServer{fc: fc, offset: -800 * time.Millisecond, rtt: 10 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil {
+			if fc.Waiters() > 0 {
+				fc.Advance(clockSampleSpacing)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	cal, err := e.CalibrateClock(ctx)
+	if err != nil {
+		t.Fatalf("calibrate: %v", err)
+	}
+	if !cal.Applied || cal.OffsetMs > -700 || cal.OffsetMs < -900 {
+		t.Fatalf("calibration = %+v, want applied offset near -800ms", cal)
+	}
+	target := model.Target{Mode: model.TargetModeRush, RushAtMs: testStart.Add(time.Hour).UnixMilli()}
+	if got := e.rushStartAt(target).UnixMilli() - target.RushAtMs; got != -cal.OffsetMs {
+		t.Fatalf("rush start shifted by %dms, want %dms", got, -cal.OffsetMs)
+	}
+}
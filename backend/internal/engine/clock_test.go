@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/clock"
+	"sniping_engine/internal/model"
+)
+
+var testStart = time.Date(2025, 6, 1, 9, 59, 0, 0, time.Local)
+
+func newFakeClockEngine() (*Engine, *clock.Fake) {
+	fc := clock.NewFake(testStart)
+	return New(Options{Clock: fc}), fc
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func loopOf(e *Engine, key string) (LoopInfo, bool) {
+	for _, l := range e.Loops() {
+		if l.Key == key {
+			return l, true
+		}
+	}
+	return LoopInfo{}, false
+}
+
+func TestRushTargetFiresExactlyAtRushAt(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	rushAt := testStart.Add(30 * time.Second)
+	target := model.Target{ID: "t1", Mode: model.TargetModeRush, RushAtMs: rushAt.UnixMilli(), TargetQty: 1}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.runTarget(ctx, target)
+
+	bctx, bcancel := context.WithTimeout(ctx, 2*time.Second)
+	defer bcancel()
+	if !fc.BlockUntil(bctx, 1) {
+		t.Fatal("runTarget did not start waiting")
+	}
+
+	fc.Advance(29 * time.Second)
+	l, ok := loopOf(e, targetLoopKey("t1"))
+	if !ok || l.Phase != loopPhaseWaitingRush || l.Fires != 0 {
+		t.Fatalf("before rushAt: loop = %+v", l)
+	}
+
+	fc.Advance(time.Second)
+	waitFor(t, "first fire", func() bool {
+		l, ok := loopOf(e, targetLoopKey("t1"))
+		return ok && l.Fires > 0
+	})
+	l, _ = loopOf(e, targetLoopKey("t1"))
+	if l.LastFireMs != rushAt.UnixMilli() {
+		t.Fatalf("lastFireMs = %d, want %d", l.LastFireMs, rushAt.UnixMilli())
+	}
+	if l.Phase != loopPhaseRunning {
+		t.Fatalf("phase = %q, want running", l.Phase)
+	}
+}
+
+func TestRushTargetAutoDisabledAfterExpiry(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	target := model.Target{ID: "t2", Mode: model.TargetModeRush, RushAtMs: testStart.Add(time.Minute).UnixMilli(), TargetQty: 1}
+	e.states[target.ID] = &model.TaskState{TargetID: target.ID, Running: true}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		e.runTarget(ctx, target)
+		close(done)
+	}()
+
+	bctx, bcancel := context.WithTimeout(ctx, 2*time.Second)
+	defer bcancel()
+	if !fc.BlockUntil(bctx, 1) {
+		t.Fatal("runTarget did not start waiting")
+	}
+
+	// 默认 10 分钟后过期：醒来时已经过了过期时间，应直接关闭而不是开始抢购。
+	fc.Advance(12 * time.Minute)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("runTarget did not exit after expiry")
+	}
+	waitFor(t, "task stopped", func() bool {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return !e.states[target.ID].Running
+	})
+}
+
+func TestCaptchaPoolItemsExpireWithClock(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	e.SetCaptchaPoolSettings(model.CaptchaPoolSettings{WarmupSeconds: 30, PoolSize: 2, ItemTTLSeconds: 60})
+
+	if _, ok := e.captchaPool.Add("param-1", 0); !ok {
+		t.Fatal("add failed")
+	}
+	if n := e.captchaPool.Size(e.now().UnixMilli()); n != 1 {
+		t.Fatalf("size = %d, want 1", n)
+	}
+
+	fc.Advance(59 * time.Second)
+	if n := e.captchaPool.Size(e.now().UnixMilli()); n != 1 {
+		t.Fatalf("size before ttl = %d, want 1", n)
+	}
+	fc.Advance(time.Second)
+	if n := e.captchaPool.Size(e.now().UnixMilli()); n != 0 {
+		t.Fatalf("size after ttl = %d, want 0", n)
+	}
+}
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/utils"
+)
+
+func DefaultCompatSettings() model.CompatSettings {
+	return model.CompatSettings{
+		EncryptVersion: utils.DefaultEncryptVersion,
+		UAMode:         utils.DefaultUAMode,
+	}
+}
+
+func NormalizeCompatSettings(in model.CompatSettings) model.CompatSettings {
+	out := in
+	out.EncryptVersion = strings.TrimSpace(out.EncryptVersion)
+	if out.EncryptVersion == "" {
+		out.EncryptVersion = utils.DefaultEncryptVersion
+	}
+	if out.EncryptVersion != utils.EncryptVersionCustom {
+		out.EncryptKey = ""
+		out.EncryptOffset = 0
+	}
+	out.UAMode = strings.TrimSpace(out.UAMode)
+	if out.UAMode == "" {
+		out.UAMode = utils.DefaultUAMode
+	}
+	out.DefaultUserAgent = strings.TrimSpace(out.DefaultUserAgent)
+	return out
+}
+
+// ValidateCompatSettings 规范化并校验兼容设置，不修改全局状态；保存前先调用，避免把无效设置写进数据库。
+func ValidateCompatSettings(in model.CompatSettings) (model.CompatSettings, error) {
+	next := NormalizeCompatSettings(in)
+	if next.UAMode != utils.UAModeWXAppV1 && next.UAMode != utils.UAModePassthrough {
+		return next, fmt.Errorf("unknown user agent mode: %s", next.UAMode)
+	}
+	if err := utils.ValidateEncryptVersion(next.EncryptVersion, next.EncryptKey); err != nil {
+		return next, err
+	}
+	return next, nil
+}
+
+// ApplyCompatSettings 把加密/UA 兼容设置应用到全局（登录代理、Provider 请求都会立即生效）。
+// 先整体校验，校验失败时全局设置保持不变。
+func ApplyCompatSettings(in model.CompatSettings) (model.CompatSettings, error) {
+	next, err := ValidateCompatSettings(in)
+	if err != nil {
+		return next, err
+	}
+	if err := utils.SetEncryptVersion(next.EncryptVersion, next.EncryptKey, next.EncryptOffset); err != nil {
+		return next, err
+	}
+	if err := utils.SetUserAgentMode(next.UAMode, next.DefaultUserAgent); err != nil {
+		return next, err
+	}
+	return next, nil
+}
@@ -0,0 +1,239 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+const (
+	cookieCheckInterval = time.Minute
+	// cookieExpiryMargin 关键 cookie 至少要比下一次开抢多活这么久，否则视为“即将过期”。
+	cookieExpiryMargin = 5 * time.Minute
+	// cookieIdleHorizon 没有排期的抢购时，只关注这段时间内就会过期的 cookie。
+	cookieIdleHorizon = 10 * time.Minute
+)
+
+// CookieHealth 描述账号关键 cookie 的有效期情况。
+type CookieHealth struct {
+	AccountID        string   `json:"accountId"`
+	Expiring         []string `json:"expiring,omitempty"`
+	EarliestExpireMs int64    `json:"earliestExpireMs,omitempty"`
+	DeadlineMs       int64    `json:"deadlineMs"`
+	CheckedAtMs      int64    `json:"checkedAtMs"`
+	RefreshedAtMs    int64    `json:"refreshedAtMs,omitempty"`
+	RefreshError     string   `json:"refreshError,omitempty"`
+}
+
+// expiringCookies 返回在 deadlineMs 之前过期的关键 cookie（按名称排序）以及其中最早的过期时间。
+func expiringCookies(acc model.Account, critical []string, deadlineMs int64) ([]string, int64) {
+	want := make(map[string]bool, len(critical))
+	for _, name := range critical {
+		if name = strings.TrimSpace(name); name != "" {
+			want[name] = true
+		}
+	}
+
+	var names []string
+	var earliest int64
+	for _, entry := range acc.Cookies {
+		for _, c := range entry.Cookies {
+			if c.Expires <= 0 {
+				continue
+			}
+			if len(want) > 0 && !want[c.Name] {
+				continue
+			}
+			if c.Expires >= deadlineMs {
+				continue
+			}
+			names = append(names, c.Name)
+			if earliest == 0 || c.Expires < earliest {
+				earliest = c.Expires
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, earliest
+}
+
+// nextRushAtMs 返回已启用抢购目标中最近的一次开抢时间（没有则为 0）。
+func nextRushAtMs(targets []model.Target, nowMs int64) int64 {
+	var next int64
+	for _, t := range targets {
+		if !t.Enabled || t.Mode != model.TargetModeRush || t.RushAtMs <= nowMs {
+			continue
+		}
+		if next == 0 || t.RushAtMs < next {
+			next = t.RushAtMs
+		}
+	}
+	return next
+}
+
+func (e *Engine) cookieDeadlineMs(ctx context.Context, nowMs int64) int64 {
+	deadline := nowMs + cookieIdleHorizon.Milliseconds()
+	if e.store == nil {
+		return deadline
+	}
+	targets, err := e.store.ListEnabledTargets(ctx)
+	if err != nil {
+		return deadline
+	}
+	if next := nextRushAtMs(targets, nowMs); next > 0 {
+		return next + cookieExpiryMargin.Milliseconds()
+	}
+	return deadline
+}
+
+// maybeCheckCookieHealth 节流执行 cookie 与 token 有效期检查（AutoRunByStore 每轮都会调用）。
+func (e *Engine) maybeCheckCookieHealth(ctx context.Context) {
+	if e == nil || e.store == nil || e.provider == nil {
+		return
+	}
+	nowMs := e.now().UnixMilli()
+	last := e.cookieCheckAtMs.Load()
+	if last > 0 && nowMs-last < cookieCheckInterval.Milliseconds() {
+		return
+	}
+	if !e.cookieCheckAtMs.CompareAndSwap(last, nowMs) {
+		return
+	}
+	go func() {
+		checkCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		_, _ = e.CheckCookieHealth(checkCtx)
+		_, _ = e.CheckTokenExpiry(checkCtx)
+	}()
+}
+
+// CheckCookieHealth 检查所有已登录账号的关键 cookie，对会在下一次开抢前过期的账号尝试定向刷新。
+func (e *Engine) CheckCookieHealth(ctx context.Context) ([]CookieHealth, error) {
+	if e == nil || e.store == nil {
+		return nil, errors.New("store unavailable")
+	}
+	accounts, err := e.store.ListAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accounts = filterLoggedInAccounts(accounts)
+
+	nowMs := e.now().UnixMilli()
+	deadlineMs := e.cookieDeadlineMs(ctx, nowMs)
+
+	out := make([]CookieHealth, 0, len(accounts))
+	for _, acc := range accounts {
+		out = append(out, e.checkAccountCookies(ctx, acc, deadlineMs))
+	}
+	return out, nil
+}
+
+func (e *Engine) checkAccountCookies(ctx context.Context, acc model.Account, deadlineMs int64) CookieHealth {
+	nowMs := e.now().UnixMilli()
+	h := CookieHealth{AccountID: acc.ID, DeadlineMs: deadlineMs, CheckedAtMs: nowMs}
+	h.Expiring, h.EarliestExpireMs = expiringCookies(acc, e.criticalCookies, deadlineMs)
+	if len(h.Expiring) == 0 {
+		e.setCookieHealth(h)
+		return h
+	}
+
+	if e.bus != nil {
+		e.bus.Log("warn", "账号关键 Cookie 将在开抢前过期，尝试刷新", map[string]any{
+			"accountId":        acc.ID,
+			"cookies":          h.Expiring,
+			"earliestExpireMs": h.EarliestExpireMs,
+			"deadlineMs":       deadlineMs,
+		})
+	}
+
+	updated, err := e.refreshAccountSession(ctx, acc)
+	h.RefreshedAtMs = e.now().UnixMilli()
+	if err != nil {
+		h.RefreshError = err.Error()
+		e.setCookieHealth(h)
+		if e.bus != nil {
+			e.bus.Log("warn", "Cookie 刷新失败，建议重新登录", map[string]any{
+				"accountId": acc.ID,
+				"error":     err.Error(),
+			})
+		}
+		return h
+	}
+
+	h.Expiring, h.EarliestExpireMs = expiringCookies(updated, e.criticalCookies, deadlineMs)
+	e.setCookieHealth(h)
+	if e.bus != nil {
+		if len(h.Expiring) > 0 {
+			e.bus.Log("warn", "Cookie 刷新后仍会在开抢前过期，建议重新登录", map[string]any{
+				"accountId": acc.ID,
+				"cookies":   h.Expiring,
+			})
+		} else {
+			e.bus.Log("info", "Cookie 已刷新", map[string]any{"accountId": acc.ID})
+		}
+	}
+	return h
+}
+
+func (e *Engine) refreshAccountSession(ctx context.Context, acc model.Account) (model.Account, error) {
+	e.ensureAccountLimiter(acc.ID)
+	if !e.waitLimits(ctx, acc.ID) {
+		return model.Account{}, ctx.Err()
+	}
+	updated, err := e.provider.RefreshSession(ctx, acc)
+	if err != nil {
+		return model.Account{}, err
+	}
+	if err := e.persistAccount(ctx, updated); err != nil {
+		return model.Account{}, err
+	}
+	return updated, nil
+}
+
+// RefreshAccountCookies 手动触发某个账号的定向刷新并返回最新的有效期情况。
+func (e *Engine) RefreshAccountCookies(ctx context.Context, accountID string) (CookieHealth, error) {
+	if e == nil || e.store == nil {
+		return CookieHealth{}, errors.New("store unavailable")
+	}
+	if e.provider == nil {
+		return CookieHealth{}, errors.New("provider unavailable")
+	}
+	acc, err := e.store.GetAccount(ctx, strings.TrimSpace(accountID))
+	if err != nil {
+		return CookieHealth{}, err
+	}
+	if strings.TrimSpace(acc.Token) == "" {
+		return CookieHealth{}, errors.New("account not logged in")
+	}
+	updated, err := e.refreshAccountSession(ctx, acc)
+	if err != nil {
+		return CookieHealth{}, err
+	}
+	nowMs := e.now().UnixMilli()
+	deadlineMs := e.cookieDeadlineMs(ctx, nowMs)
+	h := CookieHealth{AccountID: acc.ID, DeadlineMs: deadlineMs, CheckedAtMs: nowMs, RefreshedAtMs: nowMs}
+	h.Expiring, h.EarliestExpireMs = expiringCookies(updated, e.criticalCookies, deadlineMs)
+	e.setCookieHealth(h)
+	return h, nil
+}
+
+// CookieHealthOf 返回最近一次检查的结果；未检查过时 ok=false。
+func (e *Engine) CookieHealthOf(accountID string) (CookieHealth, bool) {
+	if e == nil {
+		return CookieHealth{}, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	h, ok := e.cookieHealth[accountID]
+	return h, ok
+}
+
+func (e *Engine) setCookieHealth(h CookieHealth) {
+	e.mu.Lock()
+	e.cookieHealth[h.AccountID] = h
+	e.mu.Unlock()
+}
@@ -0,0 +1,116 @@
+package engine
+
+import "context"
+
+// 停止时的下单收尾：StopAll 先取消 runCtx，目标循环和尚未提交订单的尝试立即结束；
+// 已在请求 create-order 的尝试改用 orderCtx，最多再等 task.stopGraceMs 完成，超时后才取消。
+
+// orderContext 返回提交订单使用的上下文：不随抢购 ctx 取消，只在停止宽限期结束时取消。
+// ctx 已取消（停止或目标已停）时返回 false，不再发起新的下单；引擎未运行时直接沿用 ctx。
+func (e *Engine) orderContext(ctx context.Context) (context.Context, context.CancelFunc, bool) {
+	if ctx.Err() != nil {
+		return nil, nil, false
+	}
+	e.mu.Lock()
+	octx := e.orderCtx
+	e.mu.Unlock()
+	if octx == nil {
+		return ctx, func() {}, true
+	}
+	cctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(octx, cancel)
+	e.creatingOrders.Add(1)
+	return cctx, func() {
+		stop()
+		cancel()
+		e.creatingOrders.Add(-1)
+	}, true
+}
+
+// CreatingOrders 返回正在请求 create-order 的尝试数。
+func (e *Engine) CreatingOrders() int64 {
+	return e.creatingOrders.Load()
+}
+
+// waitDrained 等待运行中的 goroutine 全部退出：宽限期内进行中的下单继续完成，
+// 宽限期结束或 ctx 结束时取消 orderCancel。返回 ctx 的错误（等待完成时为 nil）。
+func (e *Engine) waitDrained(ctx context.Context, done <-chan struct{}, orderCancel context.CancelFunc) error {
+	if orderCancel == nil {
+		orderCancel = func() {}
+	}
+	defer orderCancel()
+
+	grace := e.task.StopGrace()
+	if n := e.creatingOrders.Load(); n > 0 && grace > 0 && e.bus != nil {
+		e.bus.Log("info", "等待进行中的下单完成", map[string]any{
+			"creatingOrders": n,
+			"graceMs":        grace.Milliseconds(),
+		})
+	}
+	if grace <= 0 {
+		orderCancel()
+	}
+	timer := e.clk().NewTimer(grace)
+	defer timer.Stop()
+	graceC := timer.C()
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-graceC:
+			graceC = nil
+			if n := e.creatingOrders.Load(); n > 0 && e.bus != nil {
+				e.bus.Log("warn", "下单宽限期已到，取消进行中的下单", map[string]any{
+					"creatingOrders": n,
+					"graceMs":        grace.Milliseconds(),
+				})
+			}
+			orderCancel()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// 滚动升级的排空模式：Drain 后目标循环不再发起新的尝试（与暂停一样跳过节拍），
+// 已经发出的尝试照常完成；接口层同时拒绝修改类请求，/health 上报 draining 与进行中的尝试数供守护脚本判断何时替换进程。
+
+// drainingOutcome 排空时目标循环本次节拍的结果描述。
+const drainingOutcome = "engine draining"
+
+// SetDraining 开启或关闭排空模式，返回是否发生了变化。
+func (e *Engine) SetDraining(on bool) bool {
+	if e == nil || !e.draining.CompareAndSwap(!on, on) {
+		return false
+	}
+	if e.bus != nil {
+		if on {
+			e.bus.Log("warn", "引擎进入排空模式，不再发起新的尝试", map[string]any{"liveAttempts": e.LiveAttempts()})
+		} else {
+			e.bus.Log("info", "引擎退出排空模式", nil)
+		}
+	}
+	return true
+}
+
+// IsDraining 返回引擎是否处于排空模式。
+func (e *Engine) IsDraining() bool {
+	if e == nil {
+		return false
+	}
+	return e.draining.Load()
+}
+
+// LiveAttempts 返回已预占数量、尚未结算的尝试数（排空时降到 0 即可安全替换进程）。
+func (e *Engine) LiveAttempts() int {
+	if e == nil {
+		return 0
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	n := 0
+	for _, m := range e.liveAttempts {
+		n += len(m)
+	}
+	return n
+}
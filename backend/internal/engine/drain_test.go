@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/config"
+	"sniping_engine/internal/model"
+)
+
+func TestOrderContextOutlivesStoppedAttempt(t *testing.T) {
+	e := New(Options{Task: config.TaskConfig{StopGraceMs: 50}})
+	e.orderCtx, e.orderCancel = context.WithCancel(context.Background())
+
+	attemptCtx, stop := context.WithCancel(context.Background())
+	octx, done, ok := e.orderContext(attemptCtx)
+	if !ok {
+		t.Fatal("live attempt should get an order context")
+	}
+	if got := e.CreatingOrders(); got != 1 {
+		t.Fatalf("creatingOrders = %d, want 1", got)
+	}
+
+	stop()
+	if _, _, ok := e.orderContext(attemptCtx); ok {
+		t.Fatal("stopped attempt must not start a new create-order")
+	}
+	if octx.Err() != nil {
+		t.Fatal("in-flight create-order was canceled together with the run")
+	}
+
+	// create-order 一直不返回：宽限期结束后取消。
+	finished := make(chan struct{})
+	go func() {
+		<-octx.Done()
+		done()
+		close(finished)
+	}()
+	if err := e.waitDrained(context.Background(), finished, e.orderCancel); err != nil {
+		t.Fatalf("waitDrained: %v", err)
+	}
+	if got := e.CreatingOrders(); got != 0 {
+		t.Fatalf("creatingOrders after drain = %d", got)
+	}
+}
+
+func TestDrainReturnsOnceInFlightOrderFinishes(t *testing.T) {
+	e := New(Options{Task: config.TaskConfig{StopGraceMs: 60_000}})
+	e.orderCtx, e.orderCancel = context.WithCancel(context.Background())
+
+	octx, done, _ := e.orderContext(context.Background())
+	finished := make(chan struct{})
+	go func() {
+		done()
+		close(finished)
+	}()
+	if err := e.waitDrained(context.Background(), finished, e.orderCancel); err != nil {
+		t.Fatalf("waitDrained: %v", err)
+	}
+	if octx.Err() == nil {
+		t.Fatal("order context should be released after the drain")
+	}
+}
+
+func TestDrainingSkipsNewAttempts(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	e.accounts = []model.Account{{ID: "a1"}}
+	e.accountLocks = map[string]chan struct{}{"a1": make(chan struct{}, 1)}
+	target := model.Target{ID: "t1", Mode: model.TargetModeRush, RushAtMs: testStart.Add(time.Second).UnixMilli(), TargetQty: 1}
+	if !e.SetDraining(true) || e.SetDraining(true) {
+		t.Fatal("SetDraining should only report the first change")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.runTarget(ctx, target)
+
+	bctx, bcancel := context.WithTimeout(ctx, 2*time.Second)
+	defer bcancel()
+	if !fc.BlockUntil(bctx, 1) {
+		t.Fatal("runTarget did not start waiting")
+	}
+	fc.Advance(time.Second)
+	waitFor(t, "first fire", func() bool {
+		l, ok := loopOf(e, targetLoopKey("t1"))
+		return ok && l.Fires > 0
+	})
+	if l, _ := loopOf(e, targetLoopKey("t1")); l.LastOutcome != drainingOutcome {
+		t.Fatalf("outcome = %q, want %q", l.LastOutcome, drainingOutcome)
+	}
+	if n := e.LiveAttempts(); n != 0 {
+		t.Fatalf("live attempts = %d, want 0", n)
+	}
+	if !e.State().Draining {
+		t.Fatal("state should report draining")
+	}
+}
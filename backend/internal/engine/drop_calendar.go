@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"slices"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+const (
+	defaultDropLeadSeconds          = 120
+	maxDropLeadSeconds              = 3600
+	defaultDropWindowScanIntervalMs = 300
+	maxDropCalendarWindows          = 500
+	// dropWindowKeepAfterEnd 已结束超过这段时间的窗口在保存时丢弃，避免日历无限增长。
+	dropWindowKeepAfterEnd = 7 * 24 * time.Hour
+)
+
+// DefaultDropCalendar 默认不启用日历，扫货间隔完全由扫货间隔设置决定。
+func DefaultDropCalendar() model.DropCalendar {
+	return model.DropCalendar{
+		LeadSeconds:          defaultDropLeadSeconds,
+		WindowScanIntervalMs: defaultDropWindowScanIntervalMs,
+		Windows:              []model.DropWindow{},
+	}
+}
+
+// NormalizeDropCalendar 补齐默认值、限制取值范围，按开始时间排序并丢弃早已结束的窗口。
+func NormalizeDropCalendar(in model.DropCalendar, now time.Time) model.DropCalendar {
+	clampInterval := func(v int) int {
+		if v <= 0 {
+			return 0
+		}
+		if v < 100 {
+			return 100
+		}
+		if v > 60000 {
+			return 60000
+		}
+		return v
+	}
+	out := in
+	if out.LeadSeconds <= 0 {
+		out.LeadSeconds = defaultDropLeadSeconds
+	}
+	if out.LeadSeconds > maxDropLeadSeconds {
+		out.LeadSeconds = maxDropLeadSeconds
+	}
+	out.WindowScanIntervalMs = clampInterval(out.WindowScanIntervalMs)
+	if out.WindowScanIntervalMs == 0 {
+		out.WindowScanIntervalMs = defaultDropWindowScanIntervalMs
+	}
+	out.QuietScanIntervalMs = clampInterval(out.QuietScanIntervalMs)
+
+	cutoffMs := now.Add(-dropWindowKeepAfterEnd).UnixMilli()
+	windows := make([]model.DropWindow, 0, len(in.Windows))
+	for _, w := range in.Windows {
+		if w.EndMs < cutoffMs {
+			continue
+		}
+		w.ScanIntervalMs = clampInterval(w.ScanIntervalMs)
+		windows = append(windows, w)
+	}
+	slices.SortStableFunc(windows, func(a, b model.DropWindow) int {
+		switch {
+		case a.StartMs < b.StartMs:
+			return -1
+		case a.StartMs > b.StartMs:
+			return 1
+		}
+		return 0
+	})
+	if len(windows) > maxDropCalendarWindows {
+		windows = windows[:maxDropCalendarWindows]
+	}
+	out.Windows = windows
+	return out
+}
+
+func (e *Engine) DropCalendar() model.DropCalendar {
+	if e == nil {
+		return DefaultDropCalendar()
+	}
+	if c, ok := e.dropCalendar.Load().(model.DropCalendar); ok {
+		return c
+	}
+	return DefaultDropCalendar()
+}
+
+// SetDropCalendar 替换上新日历，运行中的扫货目标在下一拍按新间隔重建节拍。
+func (e *Engine) SetDropCalendar(next model.DropCalendar) model.DropCalendar {
+	if e == nil {
+		return NormalizeDropCalendar(next, time.Now())
+	}
+	next = NormalizeDropCalendar(next, e.now())
+	e.dropCalendar.Store(next)
+	return next
+}
+
+// DropCalendarStatus 是日历当前的生效情况。
+type DropCalendarStatus struct {
+	NowMs int64 `json:"nowMs"`
+	// Active 当前所在的窗口（含开始前的预热期），不在窗口内时为空。
+	Active *model.DropWindow `json:"active,omitempty"`
+	Next   *model.DropWindow `json:"next,omitempty"`
+	// ScanIntervalMs 当前生效的扫货间隔。
+	ScanIntervalMs int64 `json:"scanIntervalMs"`
+}
+
+func (e *Engine) DropCalendarStatus() DropCalendarStatus {
+	nowMs := e.now().UnixMilli()
+	out := DropCalendarStatus{NowMs: nowMs, ScanIntervalMs: e.ScanInterval().Milliseconds()}
+	cal := e.DropCalendar()
+	if !cal.Enabled {
+		return out
+	}
+	if w, ok := cal.ActiveWindow(nowMs); ok {
+		out.Active = &w
+	}
+	if w, ok := cal.NextWindow(nowMs); ok {
+		out.Next = &w
+	}
+	return out
+}
+
+// dropWindowActive 判断当前是否处于启用日历中某个窗口（含预热期）内。
+func (e *Engine) dropWindowActive(nowMs int64) bool {
+	cal := e.DropCalendar()
+	if !cal.Enabled {
+		return false
+	}
+	_, ok := cal.ActiveWindow(nowMs)
+	return ok
+}
+
+// dropCalendarScanInterval 返回日历决定的扫货间隔：窗口内收紧、窗口外放宽；日历未启用或窗口外未设置放宽间隔时 ok=false。
+func (e *Engine) dropCalendarScanInterval(nowMs int64) (time.Duration, bool) {
+	cal := e.DropCalendar()
+	if !cal.Enabled {
+		return 0, false
+	}
+	if w, ok := cal.ActiveWindow(nowMs); ok {
+		ms := w.ScanIntervalMs
+		if ms <= 0 {
+			ms = cal.WindowScanIntervalMs
+		}
+		return time.Duration(ms) * time.Millisecond, true
+	}
+	if cal.QuietScanIntervalMs > 0 {
+		return time.Duration(cal.QuietScanIntervalMs) * time.Millisecond, true
+	}
+	return 0, false
+}
@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+func TestDropCalendarDrivesScanInterval(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	base := e.ScanInterval()
+
+	startMs := fc.Now().Add(10 * time.Minute).UnixMilli()
+	cal := e.SetDropCalendar(model.DropCalendar{
+		Enabled:             true,
+		LeadSeconds:         60,
+		QuietScanIntervalMs: 5000,
+		Windows: []model.DropWindow{
+			{Name: "drop", StartMs: startMs, EndMs: startMs + 5*60*1000},
+			{Name: "old", StartMs: fc.Now().Add(-30 * 24 * time.Hour).UnixMilli(), EndMs: fc.Now().Add(-29 * 24 * time.Hour).UnixMilli()},
+		},
+	})
+	if len(cal.Windows) != 1 || cal.WindowScanIntervalMs != defaultDropWindowScanIntervalMs {
+		t.Fatalf("normalized calendar = %+v", cal)
+	}
+
+	if got := e.ScanInterval(); got != 5*time.Second {
+		t.Fatalf("quiet interval = %v, want 5s", got)
+	}
+	fc.Advance(9 * time.Minute)
+	if got := e.ScanInterval(); got != defaultDropWindowScanIntervalMs*time.Millisecond {
+		t.Fatalf("lead-period interval = %v", got)
+	}
+	if st := e.DropCalendarStatus(); st.Active == nil || st.Active.Name != "drop" {
+		t.Fatalf("status = %+v", st)
+	}
+	fc.Advance(7 * time.Minute)
+	if got := e.ScanInterval(); got != 5*time.Second {
+		t.Fatalf("after window interval = %v, want 5s", got)
+	}
+
+	cal.Enabled = false
+	e.SetDropCalendar(cal)
+	if got := e.ScanInterval(); got != base {
+		t.Fatalf("disabled calendar interval = %v, want %v", got, base)
+	}
+}
@@ -0,0 +1,191 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+const (
+	egressProbeSamples = 5
+	// egressProbeLead 开抢前多久做出口探测；egressProbeMinInterval 内已探测过则不重复（多个目标同一时间开抢）。
+	egressProbeLead        = 2 * time.Minute
+	egressProbeMinInterval = 5 * time.Minute
+	egressProbeTimeout     = 30 * time.Second
+	egressDirectPath       = "direct"
+)
+
+// ProbeEgress 测量每个出口（已登录账号用到的代理，以及直连）到上游主机的延迟与失败率，
+// 结果写入 egress_probes 表；使用明显偏慢出口的账号会记一条告警。
+func (e *Engine) ProbeEgress(ctx context.Context) ([]model.EgressProbe, error) {
+	prober, ok := e.provider.(provider.EgressProber)
+	if !ok {
+		return nil, errors.New("provider does not support egress probes")
+	}
+	if e.store == nil {
+		return nil, errors.New("store unavailable")
+	}
+	accounts, err := e.store.ListAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按实际出口分组；直连总是参与探测，作为比较基准。
+	paths := map[string][]string{"": nil}
+	for _, acc := range filterLoggedInAccounts(accounts) {
+		proxy := prober.EgressProxy(acc)
+		paths[proxy] = append(paths[proxy], acc.ID)
+	}
+
+	probedAtMs := e.now().UnixMilli()
+	out := make([]model.EgressProbe, 0, len(paths))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for proxy, ids := range paths {
+		wg.Add(1)
+		go func(proxy string, ids []string) {
+			defer wg.Done()
+			latencies, failures, err := prober.ProbeEgress(ctx, proxy, egressProbeSamples)
+			res := summarizeEgress(latencies, failures)
+			res.Path = egressPathLabel(proxy)
+			res.AccountIDs = ids
+			res.ProbedAtMs = probedAtMs
+			if err != nil {
+				res.Error = err.Error()
+			}
+			mu.Lock()
+			out = append(out, res)
+			mu.Unlock()
+		}(proxy, ids)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	markSlowEgress(out)
+	sort.Slice(out, func(i, j int) bool { return out[i].P50Ms < out[j].P50Ms })
+	if err := e.store.ReplaceEgressProbes(ctx, out); err != nil {
+		return out, err
+	}
+	e.egressProbedAtMs.Store(probedAtMs)
+	return out, nil
+}
+
+// EgressProbes 返回最近一次保存的出口探测结果。
+func (e *Engine) EgressProbes(ctx context.Context) ([]model.EgressProbe, error) {
+	if e.store == nil {
+		return nil, errors.New("store unavailable")
+	}
+	return e.store.ListEgressProbes(ctx)
+}
+
+func summarizeEgress(latencies []time.Duration, failures int) model.EgressProbe {
+	res := model.EgressProbe{Samples: len(latencies) + failures, Failures: failures}
+	if res.Samples > 0 {
+		res.LossPct = float64(failures) * 100 / float64(res.Samples)
+	}
+	if len(latencies) == 0 {
+		return res
+	}
+	ms := make([]int64, len(latencies))
+	var sum int64
+	for i, d := range latencies {
+		ms[i] = d.Milliseconds()
+		sum += ms[i]
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i] < ms[j] })
+	res.AvgMs = sum / int64(len(ms))
+	res.P50Ms = ms[len(ms)/2]
+	res.MaxMs = ms[len(ms)-1]
+	return res
+}
+
+// markSlowEgress 把明显差于其它出口的结果标记为 Slow：失败率不低于 20%，
+// 或中位延迟至少是其它出口中位延迟的两倍且多出 50ms 以上。
+func markSlowEgress(probes []model.EgressProbe) {
+	for i := range probes {
+		p := &probes[i]
+		if p.Samples == 0 || p.Failures == p.Samples || p.LossPct >= 20 {
+			p.Slow = true
+			continue
+		}
+		var others []int64
+		for j, o := range probes {
+			if j != i && o.Failures < o.Samples {
+				others = append(others, o.P50Ms)
+			}
+		}
+		if len(others) == 0 {
+			continue
+		}
+		sort.Slice(others, func(a, b int) bool { return others[a] < others[b] })
+		ref := others[len(others)/2]
+		p.Slow = p.P50Ms >= 2*ref && p.P50Ms-ref >= 50
+	}
+}
+
+// egressPathLabel 返回出口的展示名：去掉代理地址中的账号密码，直连为 "direct"。
+func egressPathLabel(proxy string) string {
+	proxy = strings.TrimSpace(proxy)
+	if proxy == "" {
+		return egressDirectPath
+	}
+	return model.RedactProxyURL(proxy)
+}
+
+// probeEgressBeforeRush 在开抢前 egressProbeLead 做一次出口探测，结果作为就绪检查的一部分写入日志。
+func (e *Engine) probeEgressBeforeRush(ctx context.Context, target model.Target) {
+	if _, ok := e.provider.(provider.EgressProber); !ok || e.store == nil {
+		return
+	}
+	if !e.sleepUntil(ctx, time.UnixMilli(target.RushAtMs).Add(-egressProbeLead)) {
+		return
+	}
+	nowMs := e.now().UnixMilli()
+	if target.RushAtMs-nowMs < (30 * time.Second).Milliseconds() {
+		// 离开抢太近（例如临近开抢才启动），不再占用网络。
+		return
+	}
+	if last := e.egressProbedAtMs.Load(); last > 0 && nowMs-last < egressProbeMinInterval.Milliseconds() {
+		return
+	}
+	if !e.egressProbing.CompareAndSwap(false, true) {
+		return
+	}
+	defer e.egressProbing.Store(false)
+
+	probeCtx, cancel := context.WithTimeout(ctx, egressProbeTimeout)
+	defer cancel()
+	probes, err := e.ProbeEgress(probeCtx)
+	if e.bus == nil {
+		return
+	}
+	if err != nil && len(probes) == 0 {
+		if ctx.Err() == nil {
+			e.bus.Log("warn", "就绪检查：出口网络探测失败", map[string]any{"targetId": target.ID, "error": err.Error()})
+		}
+		return
+	}
+	for _, p := range probes {
+		fields := map[string]any{
+			"targetId": target.ID,
+			"path":     p.Path,
+			"p50Ms":    p.P50Ms,
+			"maxMs":    p.MaxMs,
+			"lossPct":  p.LossPct,
+		}
+		if p.Slow && len(p.AccountIDs) > 0 {
+			fields["accountIds"] = p.AccountIDs
+			e.bus.Log("warn", "就绪检查：账号使用的出口明显慢于其它出口", fields)
+			continue
+		}
+		e.bus.Log("info", "就绪检查：出口网络探测", fields)
+	}
+}
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/store/sqlite"
+)
+
+type egressStubProvider struct {
+	idleProvider
+	latency map[string]time.Duration
+}
+
+func (p egressStubProvider) EgressProxy(account model.Account) string { return account.Proxy }
+
+func (p egressStubProvider) ProbeEgress(_ context.Context, proxy string, samples int) ([]time.Duration, int, error) {
+	out := make([]time.Duration, samples)
+	for i := range out {
+		out[i] = p.latency[proxy]
+	}
+	return out, 0, nil
+}
+
+func TestProbeEgressFlagsSlowAccountProxy(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "engine.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	fast, err := st.UpsertAccount(ctx, model.Account{Mobile: "13800000001", Token: "a", Proxy: "http://user:pw@fast:8080"})
+	if err != nil {
+		t.Fatalf("upsert account: %v", err)
+	}
+	slow, err := st.UpsertAccount(ctx, model.Account{Mobile: "13800000002", Token: "b", Proxy: "http://slow:8080"})
+	if err != nil {
+		t.Fatalf("upsert account: %v", err)
+	}
+
+	e := New(Options{Store: st, Provider: egressStubProvider{latency: map[string]time.Duration{
+		"":                         20 * time.Millisecond,
+		"http://user:pw@fast:8080": 30 * time.Millisecond,
+		"http://slow:8080":         400 * time.Millisecond,
+	}}})
+
+	probes, err := e.ProbeEgress(ctx)
+	if err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if len(probes) != 3 {
+		t.Fatalf("probes = %d, want 3 (direct + 2 proxies)", len(probes))
+	}
+	byPath := map[string]model.EgressProbe{}
+	for _, p := range probes {
+		byPath[p.Path] = p
+	}
+	if p := byPath["http://slow:8080"]; !p.Slow || len(p.AccountIDs) != 1 || p.AccountIDs[0] != slow.ID {
+		t.Fatalf("slow proxy result = %+v", p)
+	}
+	if p, ok := byPath["http://fast:8080"]; !ok || p.Slow || p.AccountIDs[0] != fast.ID {
+		t.Fatalf("fast proxy result = %+v (credentials must be stripped from path)", p)
+	}
+	if p := byPath["direct"]; p.Slow || p.P50Ms != 20 {
+		t.Fatalf("direct result = %+v", p)
+	}
+
+	saved, err := e.EgressProbes(ctx)
+	if err != nil || len(saved) != 3 {
+		t.Fatalf("saved probes = %d, %v", len(saved), err)
+	}
+}
@@ -2,7 +2,9 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -10,28 +12,43 @@ import (
 
 	"golang.org/x/time/rate"
 
+	"sniping_engine/internal/clock"
 	"sniping_engine/internal/config"
 	"sniping_engine/internal/logbus"
 	"sniping_engine/internal/model"
 	"sniping_engine/internal/notify"
 	"sniping_engine/internal/provider"
-	"sniping_engine/internal/store/sqlite"
 )
 
 type Options struct {
-	Store    *sqlite.Store
+	Store    Store
 	Provider provider.Provider
-	Bus      *logbus.Bus
-	Limits   config.LimitsConfig
-	Task     config.TaskConfig
-	Notifier notify.Notifier
+	// PracticeProvider 演练目标（Target.Practice）使用的 provider，通常指向 mock 服务；为空时不允许运行演练目标。
+	PracticeProvider provider.Provider
+	Bus              *logbus.Bus
+	Limits           config.LimitsConfig
+	Task             config.TaskConfig
+	Notifier         notify.Notifier
+	// Clock 为空时使用真实时钟；测试可注入 clock.Fake。
+	Clock clock.Clock
+	// CriticalCookies 参与有效期检查的 cookie 名，为空时检查所有带有效期的 cookie。
+	CriticalCookies []string
+	// OnOrderCreated 下单成功后依次分发的钩子（异步执行，失败重试），见 OrderHook。
+	OnOrderCreated []OrderHook
+	// AttemptHooks 下单尝试各阶段的钩子（同步执行），见 AttemptHook。
+	AttemptHooks []AttemptHook
+	// Environment 本实例的环境标记（server.environment），见 warnEnvMismatchLocked。
+	Environment string
 }
 
 type Engine struct {
-	store    *sqlite.Store
+	store    Store
 	provider provider.Provider
-	bus      *logbus.Bus
-	notifier notify.Notifier
+	// practiceProvider 演练目标使用的 provider，见 providerFor。
+	practiceProvider provider.Provider
+	bus              *logbus.Bus
+	notifier         notify.Notifier
+	clock            clock.Clock
 
 	limits config.LimitsConfig
 	task   config.TaskConfig
@@ -44,12 +61,36 @@ type Engine struct {
 	captchaPoolActivated         atomic.Bool
 	captchaPoolMaintainerRunning atomic.Bool
 
-	mu      sync.Mutex
-	running bool
-	runCtx  context.Context
-	cancel  context.CancelFunc
-	wg      sync.WaitGroup
-	states  map[string]*model.TaskState
+	// egressProbedAtMs 为最近一次出口探测时间，egressProbing 防止多个目标同时发起探测。
+	egressProbedAtMs atomic.Int64
+	egressProbing    atomic.Bool
+
+	// lifecycleMu 串行化 StartAll/StopAll/AutoRunByStore 的整个状态切换过程，
+	// lifecycle 本身由 mu 保护，便于其它路径快速读取。
+	lifecycleMu sync.Mutex
+	lifecycle   model.EngineLifecycle
+	// manualRun 表示本次运行由 StartAll 手动发起，定时启停不会在窗口外停止它；
+	// scheduleWaitMs 为最近一次记录的下一个开抢窗口开始时间，避免重复打日志。二者由 lifecycleMu 保护。
+	manualRun      bool
+	scheduleWaitMs int64
+	paused         atomic.Bool
+	// draining 排空模式（滚动升级前），见 drain.go；停止引擎不会清除。
+	draining atomic.Bool
+
+	mu     sync.Mutex
+	runCtx context.Context
+	cancel context.CancelFunc
+	// orderCtx 为进行中的 create-order 使用的上下文（独立于 runCtx），停止宽限期结束时取消，见 drain.go。
+	orderCtx       context.Context
+	orderCancel    context.CancelFunc
+	creatingOrders atomic.Int64
+	wg             sync.WaitGroup
+	states         map[string]*model.TaskState
+	// stateDirty 记录待落库的任务进度；savedStates 为启动时读到、尚未被内存状态接管的进度。
+	stateDirty        map[string]struct{}
+	savedStates       map[string]model.TaskState
+	savedStatesLoaded bool
+	stateFlushMu      sync.Mutex
 
 	accounts        []model.Account
 	targets         []model.Target
@@ -58,20 +99,106 @@ type Engine struct {
 
 	globalLimiter *rate.Limiter
 	perLimiter    map[string]*rate.Limiter
-	inFlight      chan struct{}
-	accountLocks  map[string]chan struct{}
-	reserved      map[string]int
+	// targetLimiters 为配置了 qps 的目标专用的限速器，见 targetLimiter。
+	targetLimiters map[string]*rate.Limiter
+	inFlight       *inFlightSem
+	accountLocks   map[string]chan struct{}
+	reserved       map[string]int
+	taskRates      map[string]*taskRateWindow
+
+	attemptsMu     sync.Mutex
+	recentAttempts []model.AttemptResult
+	// accountStats 各账号的尝试统计，见 account_stats.go。
+	accountStats map[string]*model.AccountStats
+	liveAttempts map[string]map[uint64]int
+
+	attemptSeq          atomic.Uint64
+	reservedDriftTotal  atomic.Int64
+	reservedDriftLastMs atomic.Int64
 
 	maxPerTargetInFlight atomic.Int64
 
 	preflightCache   map[string]preflightCacheEntry
+	prerendered      map[string]prerenderedOrder
 	preflightBackoff map[string]preflightBackoffState
+	targetBackoff    map[string]*targetBackoffState
 
 	rr atomic.Uint64
+	// rrCursors 轮询模式下每个目标下一次使用的账号下标。
+	rrCursors map[string]int
+
+	rateMu          sync.Mutex
+	rateStats       map[string]*accountRateStats
+	rateAutoTune    atomic.Bool
+	rateAutoTunedMs atomic.Int64
+
+	cooldownMu sync.Mutex
+	cooldowns  map[string]*accountCooldownState
+
+	// dailyUsage 账号当天已下单件数的缓存，quotaNoted 记录已提示过额度用完的日期。
+	quotaMu    sync.Mutex
+	dailyUsage map[string]accountDailyUsage
+	quotaNoted map[string]string
+
+	orderHooksMu sync.Mutex
+	orderHooks   []OrderHook
+	hooksWG      sync.WaitGroup
+
+	// attemptHooks 只追加不修改，读取时在锁内取切片即可安全遍历。
+	attemptHooksMu sync.Mutex
+	attemptHooks   []AttemptHook
+
+	// attemptLogPending 等待写入 attempts 表的尝试记录，见 attempt_log.go。
+	attemptLogMu      sync.Mutex
+	attemptLogPending []model.AttemptResult
+	attemptLogWriting bool
+	attemptLogWG      sync.WaitGroup
+	attemptLogDropped atomic.Int64
+
+	// orderClaims 账号+目标的下单占位（进行中或已成功），防止并发尝试重复下单。
+	orderClaimsMu sync.Mutex
+	orderClaims   map[string]bool
+	// orderLedgerGen 每次写入下单记录时递增，claimOrderSlot 据此发现锁外查询期间的写入。
+	orderLedgerGen uint64
+
+	runID        string
+	runStartedMs int64
+	// runCost 本次运行所有尝试的估算花费，由 e.mu 保护。
+	runCost model.CostSummary
+
+	loopsMu sync.Mutex
+	loops   map[string]*LoopInfo
+
+	catalogSettings   atomic.Value // model.CatalogSettings
+	catalogRefreshing atomic.Bool
+	catalogMu         sync.Mutex
+	catalogStatus     model.CatalogRefreshStatus
+
+	retentionSettings atomic.Value // model.RetentionSettings
+	dropCalendar      atomic.Value // model.DropCalendar
+
+	criticalCookies []string
+	cookieCheckAtMs atomic.Int64
+	cookieHealth    map[string]CookieHealth
+	rushAtAlerted   map[string]string
+	// tokenReminded 记录每个账号最近一次 token 过期提醒的内容，内容不变时不重复提醒。
+	tokenReminded map[string]string
+
+	// clockOffsetMs 用于修正开抢时刻的时钟偏差（上游-本机），clockCalib 为最近一次校准结果。
+	clockOffsetMs atomic.Int64
+	clockMu       sync.Mutex
+	clockCalib    ClockCalibration
+
+	// env 为本实例的环境标记，envAlerted 记录已告警过的目标及其环境标记（受 mu 保护）。
+	env        string
+	envAlerted map[string]string
 }
 
 const preflightCacheTTL = 3 * time.Second
 
+// maxCreateRetries 为同一次尝试内沿用同一 render 重试下单的最大次数。
+const maxCreateRetries = 1
+
 type preflightCacheEntry struct {
 	AtMs  int64
 	Value provider.PreflightResult
@@ -97,96 +224,126 @@ type PreflightCheckResult struct {
 	TotalFee    int64  `json:"totalFee"`
 	TraceID     string `json:"traceId,omitempty"`
 	Message     string `json:"message,omitempty"`
-}
 
-func New(opts Options) *Engine {
-	maxInFlight := opts.Limits.MaxInFlight
-	if maxInFlight <= 0 {
-		maxInFlight = 20
-	}
+	RushAtCheck *RushAtCheck `json:"rushAtCheck,omitempty"`
+	// VerifyTokenAvailable 表示账号有可用的免滑块凭证，需要验证码时也可直接下单。
+	VerifyTokenAvailable bool `json:"verifyTokenAvailable"`
 
-	globalBurst := opts.Limits.GlobalBurst
-	if globalBurst <= 0 {
-		globalBurst = 10
-	}
-	globalQPS := opts.Limits.GlobalQPS
-	if globalQPS <= 0 {
-		globalQPS = 5
-	}
+	// Render 为 includeRender 时返回的原始 render 报文（已脱敏、限长）；RenderTruncated 表示超出上限被截断为字符串。
+	Render          json.RawMessage `json:"render,omitempty"`
+	RenderTruncated bool            `json:"renderTruncated,omitempty"`
+}
 
-	maxPerTarget := opts.Limits.MaxPerTargetInFlight
-	if maxPerTarget <= 0 {
-		maxPerTarget = 1
-	}
+func New(opts Options) *Engine {
+	limits := NormalizeLimits(opts.Limits)
 
 	e := &Engine{
 		store:            opts.Store,
 		provider:         opts.Provider,
+		practiceProvider: opts.PracticeProvider,
 		bus:              opts.Bus,
 		notifier:         opts.Notifier,
-		limits:           opts.Limits,
+		clock:            opts.Clock,
+		limits:           limits,
 		task:             opts.Task,
-		captchaPool:      NewCaptchaPool(DefaultCaptchaPoolSettings()),
+		captchaPool:      newCaptchaPoolWithClock(DefaultCaptchaPoolSettings(), opts.Clock),
+		lifecycle:        model.EngineStopped,
 		states:           make(map[string]*model.TaskState),
 		targetCancels:    make(map[string]context.CancelFunc),
 		targetSnapshots:  make(map[string]model.Target),
 		perLimiter:       make(map[string]*rate.Limiter),
-		inFlight:         make(chan struct{}, maxInFlight),
+		inFlight:         newInFlightSem(limits.MaxInFlight),
 		accountLocks:     make(map[string]chan struct{}),
 		reserved:         make(map[string]int),
-		globalLimiter:    rate.NewLimiter(rate.Limit(globalQPS), globalBurst),
+		taskRates:        make(map[string]*taskRateWindow),
+		liveAttempts:     make(map[string]map[uint64]int),
+		globalLimiter:    rate.NewLimiter(rate.Limit(limits.GlobalQPS), limits.GlobalBurst),
 		preflightCache:   make(map[string]preflightCacheEntry),
 		preflightBackoff: make(map[string]preflightBackoffState),
+		targetBackoff:    make(map[string]*targetBackoffState),
+		rateStats:        make(map[string]*accountRateStats),
+		cooldowns:        make(map[string]*accountCooldownState),
+		dailyUsage:       make(map[string]accountDailyUsage),
+		quotaNoted:       make(map[string]string),
+		orderClaims:      make(map[string]bool),
+		orderHooks:       append([]OrderHook(nil), opts.OnOrderCreated...),
+		attemptHooks:     append([]AttemptHook(nil), opts.AttemptHooks...),
+		criticalCookies:  opts.CriticalCookies,
+		cookieHealth:     make(map[string]CookieHealth),
+		rushAtAlerted:    make(map[string]string),
+		tokenReminded:    make(map[string]string),
+		targetLimiters:   make(map[string]*rate.Limiter),
+		env:              opts.Environment,
+		envAlerted:       make(map[string]string),
+		loops:            make(map[string]*LoopInfo),
+		rrCursors:        make(map[string]int),
+	}
+	if opts.Task.HighResTimer {
+		if err := enableHighResTimer(); err != nil && e.bus != nil {
+			e.bus.Log("warn", "开启高精度计时失败", map[string]any{"error": err.Error()})
+		}
 	}
-	e.maxPerTargetInFlight.Store(int64(maxPerTarget))
+	e.maxPerTargetInFlight.Store(int64(limits.MaxPerTargetInFlight))
 	e.notifySettings.Store(DefaultNotifySettings())
 	return e
 
 }
 
 func (e *Engine) StartAll(ctx context.Context) error {
+	e.lifecycleMu.Lock()
+	defer e.lifecycleMu.Unlock()
+	if err := e.startAllLocked(ctx); err != nil {
+		return err
+	}
+	e.manualRun = true
+	return nil
+}
+
+// startAllLocked 调用方需持有 e.lifecycleMu。
+func (e *Engine) startAllLocked(ctx context.Context) error {
 	e.mu.Lock()
-	if e.running {
+	if e.lifecycle != model.EngineStopped {
 		e.mu.Unlock()
 		return nil
 	}
-	e.running = true
+	e.setLifecycleLocked(model.EngineStarting)
 	runCtx, cancel := context.WithCancel(context.Background())
 	e.cancel = cancel
 	e.runCtx = runCtx
+	e.orderCtx, e.orderCancel = context.WithCancel(context.Background())
+	e.beginRunLocked()
 	e.mu.Unlock()
 
 	if e.bus != nil {
 		e.bus.Log("info", "引擎已启动", map[string]any{"provider": e.provider.Name()})
 	}
+	e.restoreTaskStates(ctx)
 
 	accounts, err := e.store.ListAccounts(ctx)
 	if err != nil {
-		_ = e.StopAll(ctx)
+		_ = e.stopAllLocked(ctx)
 		return err
 	}
 	accounts = filterLoggedInAccounts(accounts)
 	if len(accounts) == 0 {
-		_ = e.StopAll(ctx)
+		_ = e.stopAllLocked(ctx)
 		return errors.New("no logged-in accounts in storage")
 	}
 	targets, err := e.store.ListEnabledTargets(ctx)
 	if err != nil {
-		_ = e.StopAll(ctx)
+		_ = e.stopAllLocked(ctx)
 		return err
 	}
 	if len(targets) == 0 {
-		_ = e.StopAll(ctx)
+		_ = e.stopAllLocked(ctx)
 		return errors.New("no enabled targets in storage")
 	}
-
-	perQPS := e.limits.PerAccountQPS
-	if perQPS <= 0 {
-		perQPS = 1
-	}
-	perBurst := e.limits.PerAccountBurst
-	if perBurst <= 0 {
-		perBurst = 2
+	e.mu.Lock()
+	targets = e.filterRunnableTargetsLocked(targets)
+	e.mu.Unlock()
+	if len(targets) == 0 {
+		_ = e.stopAllLocked(ctx)
+		return errors.New("no enabled targets with valid config")
 	}
 
 	e.mu.Lock()
@@ -195,19 +352,29 @@ func (e *Engine) StartAll(ctx context.Context) error {
 	e.targetCancels = make(map[string]context.CancelFunc)
 	e.targetSnapshots = make(map[string]model.Target)
 	e.preflightCache = make(map[string]preflightCacheEntry)
+	e.prerendered = make(map[string]prerenderedOrder)
 	e.preflightBackoff = make(map[string]preflightBackoffState)
+	e.targetBackoff = make(map[string]*targetBackoffState)
+	e.rrCursors = make(map[string]int)
 	e.perLimiter = make(map[string]*rate.Limiter)
 	e.accountLocks = make(map[string]chan struct{})
+	e.taskRates = make(map[string]*taskRateWindow)
 	for _, acc := range accounts {
-		e.perLimiter[acc.ID] = rate.NewLimiter(rate.Limit(perQPS), perBurst)
+		e.perLimiter[acc.ID] = e.newAccountLimiterLocked()
 		e.accountLocks[acc.ID] = make(chan struct{}, 1)
 	}
 	for _, t := range targets {
+		// 已购数量等进度沿用上次保存的值（含进程重启前），其余统计随本次启动重新开始。
+		prev := e.taskStateLocked(t, true)
 		state := &model.TaskState{
-			TargetID:     t.ID,
-			Running:      true,
-			PurchasedQty: 0,
-			TargetQty:    t.TargetQty,
+			TargetID:      t.ID,
+			Running:       true,
+			PurchasedQty:  prev.PurchasedQty,
+			TargetQty:     t.TargetQty,
+			LastError:     prev.LastError,
+			LastAttemptMs: prev.LastAttemptMs,
+			LastSuccessMs: prev.LastSuccessMs,
+			AttemptBudget: t.MaxAttempts,
 		}
 		e.states[t.ID] = state
 		e.publishStateLocked(*state)
@@ -223,27 +390,57 @@ func (e *Engine) StartAll(ctx context.Context) error {
 	e.mu.Unlock()
 
 	e.startCaptchaPoolMaintainer(runCtx)
+	e.startReservedDriftChecker(runCtx)
+	e.startTaskStateFlusher(runCtx)
 	e.recalcCaptchaPoolActivateAtMs()
+
+	e.mu.Lock()
+	e.setLifecycleLocked(model.EngineRunning)
+	e.mu.Unlock()
 	return nil
 }
 
 func (e *Engine) StopAll(ctx context.Context) error {
+	e.lifecycleMu.Lock()
+	defer e.lifecycleMu.Unlock()
+	return e.stopAllLocked(ctx)
+}
+
+// stopAllLocked 调用方需持有 e.lifecycleMu。等待超时也会进入 stopped：
+// 运行上下文已取消，剩余的 goroutine 会自行退出，不应阻止下一次启动。
+func (e *Engine) stopAllLocked(ctx context.Context) error {
+	e.manualRun = false
 	e.mu.Lock()
 	cancel := e.cancel
+	orderCancel := e.orderCancel
 	e.cancel = nil
 	e.runCtx = nil
+	e.orderCancel = nil
 	e.targetCancels = make(map[string]context.CancelFunc)
 	e.targetSnapshots = make(map[string]model.Target)
-	wasRunning := e.running
-	e.running = false
+	wasStopped := e.lifecycle == model.EngineStopped
+	if !wasStopped {
+		e.setLifecycleLocked(model.EngineStopping)
+	}
 	e.mu.Unlock()
 
 	if cancel != nil {
 		cancel()
 	}
-	if !wasRunning {
+	if wasStopped {
+		if orderCancel != nil {
+			orderCancel()
+		}
 		return nil
 	}
+	defer func() {
+		e.mu.Lock()
+		e.setLifecycleLocked(model.EngineStopped)
+		e.orderCtx = nil
+		e.mu.Unlock()
+		e.paused.Store(false)
+		_, _ = e.flushTaskStates(context.Background())
+	}()
 
 	done := make(chan struct{})
 	go func() {
@@ -251,28 +448,52 @@ func (e *Engine) StopAll(ctx context.Context) error {
 		close(done)
 	}()
 
-	select {
-	case <-done:
-		if e.bus != nil {
-			e.bus.Log("info", "引擎已停止", nil)
-		}
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
+	if err := e.waitDrained(ctx, done, orderCancel); err != nil {
+		return err
+	}
+	if e.bus != nil {
+		e.bus.Log("info", "引擎已停止", nil)
 	}
+	return nil
 }
 
 func (e *Engine) State() model.EngineState {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	out := model.EngineState{Running: e.running}
+	out := model.EngineState{
+		Running:      e.lifecycle == model.EngineRunning,
+		Lifecycle:    e.lifecycle,
+		Paused:       e.paused.Load(),
+		Draining:     e.draining.Load(),
+		RushMode:     e.RushMode(),
+		RunID:        e.runID,
+		RunStartedMs: e.runStartedMs,
+	}
+	out.Metrics = model.EngineMetrics{
+		ReservedDriftTotal:  e.reservedDriftTotal.Load(),
+		ReservedDriftLastMs: e.reservedDriftLastMs.Load(),
+		CreatingOrders:      e.creatingOrders.Load(),
+		CircuitBreakers:     e.CircuitStates(),
+		Cost:                e.runCost,
+	}
+	now := e.now()
 	for _, st := range e.states {
+		e.refreshTaskRatesLocked(st, now)
 		out.Tasks = append(out.Tasks, *st)
 	}
+	out.AccountCooldowns = e.AccountCooldowns()
+	out.Accounts = e.AccountStats()
+	if sr, ok := e.notifier.(notify.StatusReporter); ok {
+		st := sr.Status()
+		out.Notifier = &st
+	}
 	return out
 }
 
 func (e *Engine) runTarget(ctx context.Context, target model.Target) {
+	loopKey := targetLoopKey(target.ID)
+	loop := e.registerLoop(LoopInfo{Key: loopKey, Kind: LoopKindTarget, TargetID: target.ID, Mode: target.Mode, Phase: loopPhaseRunning})
+	defer e.unregisterLoopInstance(loopKey, loop)
 	defer func() {
 		e.mu.Lock()
 		st := e.states[target.ID]
@@ -284,20 +505,32 @@ func (e *Engine) runTarget(ctx context.Context, target model.Target) {
 	}()
 
 	if target.Mode == model.TargetModeRush && target.RushAtMs > 0 {
-		startAt := time.UnixMilli(target.RushAtMs)
+		startAt := e.rushStartAt(target)
+		e.updateLoop(loopKey, func(l *LoopInfo) {
+			l.Phase = loopPhaseWaitingRush
+			l.NextFireMs = startAt.UnixMilli()
+		})
 		if e.bus != nil {
 			e.bus.Log("info", "等待开抢时间", map[string]any{
 				"targetId": target.ID,
-				"startAt":  startAt.Format(time.RFC3339Nano),
+				"startAt":  model.FormatTimeMs(startAt.UnixMilli()),
 				"rushAtMs": target.RushAtMs,
+				"offsetMs": target.RushAtMs - startAt.UnixMilli(),
 			})
 		}
-		if !sleepUntil(ctx, startAt) {
+		if startAt.After(e.now()) {
+			e.checkCaptchaReadiness(target)
+			go e.checkRushAtBeforeRush(ctx, target)
+			go e.probeEgressBeforeRush(ctx, target)
+			go e.prerenderBeforeRush(ctx, target)
+		}
+		if !e.sleepUntilPrecise(ctx, startAt, e.rushSpin()) {
 			return
 		}
+		e.recordRushFireSkew(target.ID, e.now().Sub(startAt))
 	}
 
-	if expired, expireAtMs, expireMinutes := e.shouldDisableRushTargetNow(target, time.Now().UnixMilli()); expired {
+	if expired, expireAtMs, expireMinutes := e.shouldDisableRushTargetNow(target, e.now().UnixMilli()); expired {
 		e.disableTargetAsync(target.ID, "抢购过时自动关闭", map[string]any{
 			"rushAtMs":     target.RushAtMs,
 			"expireAtMs":   expireAtMs,
@@ -306,26 +539,50 @@ func (e *Engine) runTarget(ctx context.Context, target model.Target) {
 		return
 	}
 
-	interval := e.task.ScanInterval()
-	if target.Mode == model.TargetModeRush {
-		interval = e.task.RushInterval()
-		if e.RushMode() == "round_robin" {
-			interval = e.RoundRobinInterval()
+	interval := e.targetInterval(target)
+
+	e.updateLoop(loopKey, func(l *LoopInfo) {
+		l.Phase = loopPhaseRunning
+		l.IntervalMs = interval.Milliseconds()
+	})
+
+	var tick uint64
+	fire := func() {
+		if e.IsPaused() {
+			e.recordLoopFire(loopKey, pausedOutcome, interval)
+			return
+		}
+		if e.IsDraining() {
+			e.recordLoopFire(loopKey, drainingOutcome, interval)
+			return
 		}
-	} else if target.Mode == model.TargetModeScan {
-		interval = e.ScanInterval()
+		if e.targetBackoffSkip(target.ID, interval) {
+			e.recordLoopFire(loopKey, backoffOutcome, interval)
+			return
+		}
+		var outcome string
+		if target.Mode == model.TargetModeScan {
+			outcome = e.scanTick(ctx, target, tick)
+		} else {
+			outcome = e.launchAttempts(ctx, target)
+		}
+		tick++
+		e.recordLoopFire(loopKey, outcome, interval)
 	}
 
-	e.launchAttempts(ctx, target)
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	fire()
+	ticker := e.newTargetTicker(ctx, target, interval)
+	defer func() { ticker.Stop() }()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			if expired, expireAtMs, expireMinutes := e.shouldDisableRushTargetNow(target, time.Now().UnixMilli()); expired {
+		case scheduled := <-ticker.C:
+			if target.Mode == model.TargetModeRush {
+				e.recordFireSkew(target.ID, e.now().Sub(scheduled))
+			}
+			if expired, expireAtMs, expireMinutes := e.shouldDisableRushTargetNow(target, e.now().UnixMilli()); expired {
 				e.disableTargetAsync(target.ID, "抢购过时自动关闭", map[string]any{
 					"rushAtMs":     target.RushAtMs,
 					"expireAtMs":   expireAtMs,
@@ -333,14 +590,35 @@ func (e *Engine) runTarget(ctx context.Context, target model.Target) {
 				})
 				return
 			}
-			e.launchAttempts(ctx, target)
+			fire()
+			// 运行中切换抢购模式/轮询间隔时，按新间隔重建节拍。
+			if next := e.targetInterval(target); next != interval {
+				interval = next
+				ticker.Stop()
+				ticker = e.newTargetTicker(ctx, target, interval)
+				e.updateLoop(loopKey, func(l *LoopInfo) { l.IntervalMs = interval.Milliseconds() })
+			}
+		}
+	}
+}
+
+// targetInterval 返回目标当前应使用的触发间隔（随抢购模式、扫货间隔设置变化）。
+func (e *Engine) targetInterval(target model.Target) time.Duration {
+	switch target.Mode {
+	case model.TargetModeRush:
+		if e.roundRobinActive(target) {
+			return e.RoundRobinInterval()
 		}
+		return e.task.RushInterval()
+	case model.TargetModeScan:
+		return e.ScanInterval()
 	}
+	return e.task.ScanInterval()
 }
 
 func (e *Engine) attemptOnce(ctx context.Context, target model.Target) {
 	if target.Mode == model.TargetModeRush && target.RushAtMs > 0 {
-		if time.Now().UnixMilli() < target.RushAtMs {
+		if e.now().Before(e.rushStartAt(target)) {
 			return
 		}
 	}
@@ -385,18 +663,14 @@ func (e *Engine) attemptOnce(ctx context.Context, target model.Target) {
 	}
 
 	e.mu.Lock()
-	st := e.states[target.ID]
-	if st == nil {
-		st = &model.TaskState{TargetID: target.ID, Running: true, TargetQty: target.TargetQty}
-		e.states[target.ID] = st
-	}
+	st := e.taskStateLocked(target, true)
 	if st.PurchasedQty >= st.TargetQty {
 		st.Running = false
 		e.publishStateLocked(*st)
 		e.mu.Unlock()
 		return
 	}
-	st.LastAttemptMs = time.Now().UnixMilli()
+	st.LastAttemptMs = e.now().UnixMilli()
 	e.publishStateLocked(*st)
 	e.mu.Unlock()
 
@@ -409,12 +683,12 @@ func (e *Engine) attemptOnce(ctx context.Context, target model.Target) {
 		return
 	}
 
-	pre, updatedAcc, err := e.provider.Preflight(ctx, acc, target)
+	pre, updatedAcc, err := e.providerFor(target).Preflight(ctx, acc, target)
 	if err != nil {
 		e.setError(target.ID, err)
 		return
 	}
-	_ = e.persistAccount(ctx, updatedAcc)
+	_ = e.persistTargetAccount(ctx, target, updatedAcc)
 
 	e.mu.Lock()
 	if st := e.states[target.ID]; st != nil {
@@ -439,38 +713,46 @@ func (e *Engine) attemptOnce(ctx context.Context, target model.Target) {
 		return
 	}
 
-	captchaVerifyParam, fromPool, err := e.captchaVerifyParamForOrder(ctx, acc, target, pre.NeedCaptcha)
+	captchaVerifyParam, src, err := e.captchaVerifyParamForOrder(ctx, acc, target, captchaRequired(pre))
+	if src == captchaSolved {
+		e.noteCaptchaSolves(target.ID, 1)
+	}
 	if err != nil {
 		e.setError(target.ID, err)
 		return
 	}
-	if pre.NeedCaptcha && fromPool && e.bus != nil {
+	if pre.NeedCaptcha && src == captchaPooled && e.bus != nil {
 		e.bus.Log("debug", "验证码池命中（下单）", map[string]any{
 			"targetId":  target.ID,
 			"accountId": acc.ID,
 		})
 	}
 
-	nextTarget := target
-	nextTarget.CaptchaVerifyParam = strings.TrimSpace(captchaVerifyParam)
+	attempt, err := provider.NewAttemptContext(updatedAcc, target, pre, e.now().UnixMilli())
+	if err != nil {
+		e.setError(target.ID, err)
+		return
+	}
+	attempt.SetCaptchaVerifyParam(captchaVerifyParam)
 
-	res, updatedAcc2, err := e.provider.CreateOrder(ctx, acc, nextTarget, pre)
+	res, updatedAcc2, err := e.providerFor(target).CreateOrder(ctx, attempt)
 	if err != nil {
 		e.setError(target.ID, err)
 		return
 	}
-	_ = e.persistAccount(ctx, updatedAcc2)
+	_ = e.persistTargetAccount(ctx, target, updatedAcc2)
 
 	if res.Success {
 		e.mu.Lock()
 		st := e.states[target.ID]
 		if st != nil {
 			st.PurchasedQty += target.PerOrderQty
-			st.LastSuccessMs = time.Now().UnixMilli()
+			st.LastSuccessMs = e.now().UnixMilli()
 			st.LastError = ""
 			e.publishStateLocked(*st)
 		}
 		e.mu.Unlock()
+		_, _ = e.flushTaskStates(context.Background())
 		if e.bus != nil {
 			e.bus.Log("info", "下单成功", map[string]any{
 				"targetId":  target.ID,
@@ -481,7 +763,8 @@ func (e *Engine) attemptOnce(ctx context.Context, target model.Target) {
 		}
 		if e.notifier != nil {
 			e.notifier.NotifyOrderCreated(ctx, notify.OrderCreatedEvent{
-				At:         time.Now().UnixMilli(),
+				At:         e.now().UnixMilli(),
+				RunID:      e.RunID(),
 				AccountID:  acc.ID,
 				Mobile:     acc.Mobile,
 				TargetID:   target.ID,
@@ -498,15 +781,29 @@ func (e *Engine) attemptOnce(ctx context.Context, target model.Target) {
 	}
 }
 
-func (e *Engine) launchAttempts(ctx context.Context, target model.Target) {
-	max := int(e.maxPerTargetInFlight.Load())
+// launchAttempts 为目标发起一轮并发尝试，返回本轮结果的简短描述（供 /engine/loops 排查）。
+func (e *Engine) launchAttempts(ctx context.Context, target model.Target) string {
+	return e.launchAttemptsWith(ctx, target, model.Account{})
+}
+
+// launchAttemptsWith 同 launchAttempts；held 非空时是调用方已占用的账号（如刚做过库存探测的账号），
+// 本轮第一次尝试直接使用它，不再另挑账号，没用上时由这里释放。
+func (e *Engine) launchAttemptsWith(ctx context.Context, target model.Target, held model.Account) string {
+	defer func() {
+		if held.ID != "" {
+			e.releaseAccount(held.ID)
+		}
+	}()
+
+	max := e.targetMaxInFlight(target)
 	if max <= 0 {
 		max = 1
 	}
 	if target.Mode == model.TargetModeScan {
 		max = 1
 	}
-	if target.Mode == model.TargetModeRush && e.RushMode() == "round_robin" {
+	roundRobin := e.roundRobinActive(target)
+	if roundRobin {
 		max = 1
 	}
 
@@ -514,45 +811,107 @@ func (e *Engine) launchAttempts(ctx context.Context, target model.Target) {
 	nAccounts := len(e.accounts)
 	e.mu.Unlock()
 	if nAccounts == 0 {
-		return
+		return "no logged-in accounts"
 	}
 	if max > nAccounts {
 		max = nAccounts
 	}
+	max = backoffInFlight(max, e.targetBackoffLevel(target.ID))
 
+	launched := 0
+	outcome := func(blocked string) string {
+		if launched > 0 {
+			return fmt.Sprintf("launched %d", launched)
+		}
+		return blocked
+	}
 	for i := 0; i < max; i++ {
 		select {
 		case <-ctx.Done():
-			return
+			return outcome("canceled")
 		default:
 		}
 
-		acc, ok := e.tryPickAndLockAccount(nAccounts)
+		var acc model.Account
+		var ok bool
+		if held.ID != "" {
+			acc, ok, held = held, true, model.Account{}
+		} else if roundRobin {
+			acc, ok = e.tryPickAndLockRoundRobin(target.ID)
+		} else {
+			acc, ok = e.tryPickAndLockAccount(nAccounts)
+		}
 		if !ok {
-			return
+			return outcome("all accounts busy")
 		}
 
 		if !e.tryAcquireInFlight() {
 			e.releaseAccount(acc.ID)
-			return
+			return outcome("global in-flight limit reached")
+		}
+
+		if ok, exhausted := e.tryConsumeAttemptBudget(target); !ok {
+			e.releaseInFlight()
+			e.releaseAccount(acc.ID)
+			if exhausted {
+				e.exhaustAttemptBudget(target)
+			}
+			return outcome("attempt budget exhausted")
 		}
 
-		reserveQty, reserved := e.tryReserveTarget(target)
+		reserveQty, attemptID, reserved := e.tryReserveTarget(target)
 		if !reserved {
+			e.refundAttemptBudget(target)
 			e.releaseInFlight()
 			e.releaseAccount(acc.ID)
-			return
+			return outcome("remaining quantity already reserved")
+		}
+		if reserveQty, reserved = e.clampReserveToQuota(target, acc.ID, reserveQty, attemptID); !reserved {
+			e.refundAttemptBudget(target)
+			e.releaseInFlight()
+			e.releaseAccount(acc.ID)
+			return outcome("account daily quota reached")
 		}
+		launched++
 
 		e.wg.Add(1)
-		go func(a model.Account, qty int) {
+		go func(a model.Account, qty int, id uint64) {
 			defer e.wg.Done()
 			defer e.releaseInFlight()
 			defer e.releaseAccount(a.ID)
-			success := e.attemptWithAccount(ctx, target, a)
-			e.finishReservedTarget(target, qty, success)
-		}(acc, reserveQty)
+			defer e.dropLiveAttempt(target.ID, id)
+			attemptTarget := target
+			if qty != e.normalizePerOrderQty(target.PerOrderQty) {
+				// 按剩余数量下单：不复用按整单数量生成的 render，用完也不留给之后的整单尝试。
+				attemptTarget.PerOrderQty = qty
+				e.dropPreparedRenders(a.ID, target.ID)
+				defer e.dropPreparedRenders(a.ID, target.ID)
+			}
+			// 尝试及下单后的确认请求都计入这次尝试的代理流量。
+			meter := &provider.TrafficMeter{}
+			actx := provider.WithTrafficMeter(ctx, meter)
+			res := e.attemptWithAccount(actx, attemptTarget, a, id)
+			if !attemptReachedUpstream(res) {
+				e.refundAttemptBudget(target)
+			}
+			e.finishReservedTarget(target, qty, id, res)
+			e.noteTargetFailureStreak(target, res)
+			if res.Success && !target.Practice {
+				e.verifyCreatedOrder(actx, a, &res)
+				e.verifyOrderFee(actx, &res)
+			}
+			e.priceAttempt(&res, meter)
+			e.recordAttempt(res)
+			e.hookAttemptDone(ctx, res)
+			if res.Success {
+				if !target.Practice {
+					e.noteDailyPurchase(ctx, a.ID, qty)
+				}
+				e.orderCreated(ctx, target, a, res)
+			}
+		}(acc, reserveQty, attemptID)
 	}
+	return outcome("no attempt launched")
 }
 
 // SetMaxPerTargetInFlight 设置同一商品/任务允许的并发抢购账号数。
@@ -565,20 +924,19 @@ func (e *Engine) SetMaxPerTargetInFlight(n int) {
 }
 
 func (e *Engine) tryAcquireInFlight() bool {
-	select {
-	case e.inFlight <- struct{}{}:
-		return true
-	default:
-		return false
-	}
+	return e.inFlight.tryAcquire()
 }
 
+// tryPickAndLockAccount 轮询挑选一个空闲、不在冷却中且未用完每日额度的账号并占用。
 func (e *Engine) tryPickAndLockAccount(nAccounts int) (model.Account, bool) {
 	for i := 0; i < nAccounts; i++ {
 		candidate := e.pickAccount()
 		if candidate.ID == "" {
 			return model.Account{}, false
 		}
+		if e.accountCoolingDown(candidate.ID) || e.accountQuotaReached(candidate.ID) {
+			continue
+		}
 		if !e.tryAcquireAccount(candidate.ID) {
 			continue
 		}
@@ -594,33 +952,43 @@ func (e *Engine) normalizePerOrderQty(qty int) int {
 	return qty
 }
 
-func (e *Engine) tryReserveTarget(target model.Target) (int, bool) {
+// tryReserveTarget 为一次尝试预占购买数量，保证已购 + 预占不超过目标数量。
+// 剩余数量不足一单时只预占剩余部分（最后一单按剩余数量下单），否则 targetQty 不是 perOrderQty 的整数倍时永远买不满。
+func (e *Engine) tryReserveTarget(target model.Target) (int, uint64, bool) {
 	qty := e.normalizePerOrderQty(target.PerOrderQty)
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	st := e.states[target.ID]
-	if st == nil {
-		st = &model.TaskState{TargetID: target.ID, Running: true, TargetQty: target.TargetQty}
-		e.states[target.ID] = st
-	}
+	st := e.taskStateLocked(target, true)
 	if st.TargetQty > 0 {
-		remaining := st.TargetQty - (st.PurchasedQty + e.reserved[target.ID])
+		remaining := st.TargetQty - (filledQty(st, target) + e.reserved[target.ID])
+		if remaining <= 0 {
+			return 0, 0, false
+		}
 		if remaining < qty {
-			return 0, false
+			qty = remaining
 		}
 	}
 	e.reserved[target.ID] += qty
-	return qty, true
+	return qty, e.trackLiveAttemptLocked(target.ID, qty), true
+}
+
+// filledQty 返回目标已买到的件数：演练目标按演练成功件数计，真实目标按已购数量计。
+func filledQty(st *model.TaskState, target model.Target) int {
+	if target.Practice {
+		return st.PracticeQty
+	}
+	return st.PurchasedQty
 }
 
-func (e *Engine) finishReservedTarget(target model.Target, qty int, success bool) {
+func (e *Engine) finishReservedTarget(target model.Target, qty int, attemptID uint64, res model.AttemptResult) {
 	qty = e.normalizePerOrderQty(qty)
-	nowMs := time.Now().UnixMilli()
+	nowMs := e.now().UnixMilli()
 
 	autoDisable := false
 
 	e.mu.Lock()
+	e.untrackLiveAttemptLocked(target.ID, attemptID)
 
 	if qty > 0 {
 		e.reserved[target.ID] -= qty
@@ -629,7 +997,7 @@ func (e *Engine) finishReservedTarget(target model.Target, qty int, success bool
 		}
 	}
 
-	if !success {
+	if !res.Success {
 		e.mu.Unlock()
 		return
 	}
@@ -639,22 +1007,68 @@ func (e *Engine) finishReservedTarget(target model.Target, qty int, success bool
 		e.mu.Unlock()
 		return
 	}
-	st.PurchasedQty += qty
-	st.LastSuccessMs = nowMs
+	if target.Practice {
+		st.PracticeQty += qty
+	} else {
+		st.PurchasedQty += qty
+	}
+	st.LastSuccessMs = res.FinishedAtMs
+	if st.LastSuccessMs == 0 {
+		st.LastSuccessMs = nowMs
+	}
 	st.LastError = ""
-	if st.TargetQty > 0 && st.PurchasedQty >= st.TargetQty {
+	if st.TargetQty > 0 && filledQty(st, target) >= st.TargetQty {
 		st.Running = false
 		autoDisable = true
 	}
 	e.publishStateLocked(*st)
 	e.mu.Unlock()
+	// 已购数量变化立即落库，不等定时刷新，避免此刻崩溃后重启超买。
+	_, _ = e.flushTaskStates(context.Background())
 
 	if autoDisable {
 		e.disableTargetAsync(target.ID, "抢购完成自动关闭", nil)
 	}
 }
 
-func (e *Engine) attemptWithAccount(ctx context.Context, target model.Target, acc model.Account) bool {
+func (e *Engine) attemptWithAccount(ctx context.Context, target model.Target, acc model.Account, attemptID uint64) model.AttemptResult {
+	startedAt := e.now()
+	res := model.AttemptResult{
+		ID:          attemptID,
+		RunID:       e.RunID(),
+		TargetID:    target.ID,
+		AccountID:   acc.ID,
+		StartedAtMs: startedAt.UnixMilli(),
+		Phase:       model.AttemptPhaseStart,
+		Quantity:    e.normalizePerOrderQty(target.PerOrderQty),
+		Practice:    target.Practice,
+	}
+	hookInfo := AttemptInfo{AttemptID: attemptID, RunID: res.RunID, Target: target, AccountID: acc.ID}
+	finish := func(class model.AttemptErrorClass, err error) model.AttemptResult {
+		finishedAt := e.now()
+		res.FinishedAtMs = finishedAt.UnixMilli()
+		res.Latency.TotalMs = finishedAt.Sub(startedAt).Milliseconds()
+		res.ErrorClass = class
+		if err != nil {
+			res.Error = err.Error()
+			res.BizCode, res.BizClass = provider.BizCodeOf(err)
+			res.Disposition = classifyBizError(err).disposition()
+		}
+		if class == "" {
+			res.Success = true
+			res.Phase = model.AttemptPhaseDone
+		}
+		donePhase, msg := "success", "下单成功"
+		if class != "" {
+			donePhase, msg = "error", string(class)
+			if err != nil {
+				msg = err.Error()
+			}
+		}
+		e.attemptProgress(&res, startedAt, "done", donePhase, res.Latency.TotalMs, msg)
+		return res
+	}
+
 	// 刷新账号快照，尽量保持 cookie/token/proxy/UA 与最近登录态一致
 	if e.store != nil {
 		if latest, err := e.store.GetAccount(ctx, acc.ID); err == nil {
@@ -663,55 +1077,100 @@ func (e *Engine) attemptWithAccount(ctx context.Context, target model.Target, ac
 	}
 
 	e.mu.Lock()
-	st := e.states[target.ID]
-	if st == nil {
-		st = &model.TaskState{TargetID: target.ID, Running: true, TargetQty: target.TargetQty}
-		e.states[target.ID] = st
-	}
-	if st.TargetQty > 0 && st.PurchasedQty >= st.TargetQty {
+	st := e.taskStateLocked(target, true)
+	if st.TargetQty > 0 && filledQty(st, target) >= st.TargetQty {
 		st.Running = false
 		e.publishStateLocked(*st)
 		e.mu.Unlock()
-		return false
+		return finish(model.AttemptErrorQuotaReached, nil)
 	}
-	st.LastAttemptMs = time.Now().UnixMilli()
+	st.LastAttemptMs = startedAt.UnixMilli()
+	if target.Mode == model.TargetModeRush {
+		res.FireSkewMs = st.LastFireSkewMs
+	}
+	e.bumpTaskCounterLocked(st, counterAttempt, startedAt)
 	e.publishStateLocked(*st)
 	e.mu.Unlock()
 
-	nowMs := time.Now().UnixMilli()
-	pre, ok := e.getCachedPreflight(acc.ID, target.ID, nowMs)
+	// 演练单不是真实订单，不占用也不检查“一账号一单”的下单记录。
+	guardOrder := !target.AllowMultiplePerAccount && !target.Practice
+	if guardOrder && e.orderSlotTaken(acc.ID, target.ID) {
+		return finish(model.AttemptErrorDuplicateOrder, nil)
+	}
+	if e.createCircuitOpen(target) {
+		return finish(model.AttemptErrorCircuitOpen, nil)
+	}
+
+	res.Phase = model.AttemptPhasePreflight
+	nowMs := e.now().UnixMilli()
+	pre, renderedAtMs, ok := e.getCachedPreflight(acc.ID, target.ID, nowMs, e.preflightCacheTTLFor(target))
+	prepared, havePrepared := prerenderedOrder{}, false
 	if !ok {
+		if prepared, havePrepared = e.takePrerendered(acc.ID, target, nowMs); havePrepared {
+			pre, renderedAtMs, ok = prepared.pre, prepared.renderedAtMs, true
+		}
+	}
+	res.PreflightCached = ok
+	if !ok {
+		renderedAtMs = nowMs
 		if !e.canPreflightNow(target.ID, nowMs) {
-			return false
+			return finish(model.AttemptErrorPreflightBackoff, nil)
 		}
-		if !e.waitLimits(ctx, acc.ID) {
-			return false
+		if !e.waitTargetLimits(ctx, target, acc.ID) {
+			return finish(model.AttemptErrorCanceled, ctx.Err())
 		}
 		var updatedAcc model.Account
 		var err error
-		pre, updatedAcc, err = e.provider.Preflight(ctx, acc, target)
+		e.hookPreflight(ctx, hookInfo)
+		preStart := e.now()
+		pre, updatedAcc, err = e.providerFor(target).Preflight(ctx, acc, target)
+		res.Latency.PreflightMs = e.now().Sub(preStart).Milliseconds()
+		if provider.IsCircuitOpen(err) {
+			return finish(model.AttemptErrorCircuitOpen, err)
+		}
+		e.observeTargetOutcome(target, acc.ID, err)
+		e.noteTargetRisk(target.ID, err)
 		if err != nil {
-			errAtMs := time.Now().UnixMilli()
-			minUntilMs := int64(0)
-			if target.Mode == model.TargetModeRush && target.RushAtMs > 0 && errAtMs < target.RushAtMs {
-				minUntilMs = target.RushAtMs
-			}
-			failures, wait, untilMs := e.bumpPreflightBackoff(target.ID, errAtMs, minUntilMs)
+			e.attemptProgress(&res, startedAt, "render_order", "error", res.Latency.PreflightMs, err.Error())
+			errAtMs := e.now().UnixMilli()
+			e.countTask(target.ID, counterPreflightFailure)
 			e.setError(target.ID, err)
+			kind := classifyBizError(err)
+			fields := map[string]any{
+				"targetId":  target.ID,
+				"accountId": acc.ID,
+				"error":     err.Error(),
+				"kind":      string(kind),
+			}
+			switch kind {
+			case bizErrorSoldOut:
+				// 缺货不退避，下一拍立即重试。
+			case bizErrorNotStarted:
+				fields["retryAtMs"] = e.holdPreflightUntilStart(target, errAtMs)
+			case bizErrorDuplicate:
+				e.markOrderSlotTaken(acc.ID, target.ID)
+				if e.bus != nil {
+					e.bus.Log("warn", "上游提示重复下单，账号不再尝试该目标", fields)
+				}
+				return finish(model.AttemptErrorDuplicateOrder, err)
+			default:
+				minUntilMs := int64(0)
+				if target.Mode == model.TargetModeRush && target.RushAtMs > 0 && errAtMs < target.RushAtMs {
+					minUntilMs = target.RushAtMs
+				}
+				failures, wait, untilMs := e.bumpPreflightBackoff(target.ID, errAtMs, minUntilMs)
+				fields["backoffMs"] = wait.Milliseconds()
+				fields["failures"] = failures
+				fields["retryAtMs"] = untilMs
+			}
 			if e.bus != nil {
-				e.bus.Log("warn", "预下单失败", map[string]any{
-					"targetId":  target.ID,
-					"accountId": acc.ID,
-					"error":     err.Error(),
-					"backoffMs": wait.Milliseconds(),
-					"failures":  failures,
-					"retryAtMs": untilMs,
-				})
+				e.bus.Log("warn", "预下单失败", fields)
 			}
-			return false
+			return finish(model.AttemptErrorPreflight, err)
 		}
 		e.resetPreflightBackoff(target.ID)
-		_ = e.persistAccount(ctx, updatedAcc)
+		e.attemptProgress(&res, startedAt, "render_order", "success", res.Latency.PreflightMs, "render-order 返回")
+		_ = e.persistTargetAccount(ctx, target, updatedAcc)
 		acc = updatedAcc
 		if pre.CanBuy {
 			e.setCachedPreflight(acc.ID, target.ID, pre, nowMs)
@@ -719,6 +1178,9 @@ func (e *Engine) attemptWithAccount(ctx context.Context, target model.Target, ac
 			e.clearCachedPreflight(acc.ID, target.ID)
 		}
 	}
+	res.NeedCaptcha = pre.NeedCaptcha
+	res.TotalFee = pre.TotalFee
+	res.TraceID = pre.TraceID
 
 	e.mu.Lock()
 	if st := e.states[target.ID]; st != nil {
@@ -746,7 +1208,13 @@ func (e *Engine) attemptWithAccount(ctx context.Context, target model.Target, ac
 			}
 			e.disableTargetAsync(target.ID, "当前不可购买", nil)
 		}
-		return false
+		return finish(model.AttemptErrorNotPurchasable, nil)
+	}
+	if !e.meetsMinStock(target, acc.ID, pre) {
+		return finish(model.AttemptErrorBelowMinStock, nil)
+	}
+	if !e.withinPriceCap(target, acc.ID, pre) {
+		return finish(model.AttemptErrorPriceOverCap, nil)
 	}
 
 	if e.bus != nil {
@@ -756,11 +1224,12 @@ func (e *Engine) attemptWithAccount(ctx context.Context, target model.Target, ac
 			"needCaptcha":  pre.NeedCaptcha,
 			"traceId":      pre.TraceID,
 			"captchaParam": strings.TrimSpace(target.CaptchaVerifyParam) != "",
+			"verifyToken":  pre.VerifyTokenAvailable,
 		})
 	}
 
-	if !e.waitLimits(ctx, acc.ID) {
-		return false
+	if !e.waitTargetLimits(ctx, target, acc.ID) {
+		return finish(model.AttemptErrorCanceled, ctx.Err())
 	}
 
 	if e.bus != nil {
@@ -770,8 +1239,34 @@ func (e *Engine) attemptWithAccount(ctx context.Context, target model.Target, ac
 		})
 	}
 
-	captchaVerifyParam, fromPool, err := e.captchaVerifyParamForOrder(ctx, acc, target, pre.NeedCaptcha)
+	res.Phase = model.AttemptPhaseCaptcha
+	if pre.NeedCaptcha && pre.VerifyTokenAvailable && e.bus != nil {
+		e.bus.Log("debug", "使用免滑块凭证，跳过验证码", map[string]any{
+			"targetId":  target.ID,
+			"accountId": acc.ID,
+		})
+	}
+	captchaStart := e.now()
+	var (
+		captchaVerifyParam string
+		fromPool           bool
+		err                error
+	)
+	if havePrepared && prepared.captchaParam != "" {
+		// 开抢前已取好的验证码凭证，求解花费在准备时已计入。
+		captchaVerifyParam, fromPool = prepared.captchaParam, prepared.fromPool
+	} else {
+		var src captchaSource
+		captchaVerifyParam, src, err = e.captchaVerifyParamForOrder(ctx, acc, target, captchaRequired(pre))
+		fromPool = src == captchaPooled
+		if src == captchaSolved {
+			res.Cost.CaptchaSolves = 1
+		}
+	}
+	res.Latency.CaptchaMs = e.now().Sub(captchaStart).Milliseconds()
+	res.CaptchaFromPool = fromPool
 	if err != nil {
+		e.attemptProgress(&res, startedAt, "captcha", "error", res.Latency.CaptchaMs, err.Error())
 		e.setError(target.ID, err)
 		if e.bus != nil {
 			e.bus.Log("warn", "验证码处理失败（下单前）", map[string]any{
@@ -780,7 +1275,11 @@ func (e *Engine) attemptWithAccount(ctx context.Context, target model.Target, ac
 				"error":     err.Error(),
 			})
 		}
-		return false
+		return finish(model.AttemptErrorCaptcha, err)
+	}
+	if pre.NeedCaptcha && strings.TrimSpace(captchaVerifyParam) != "" {
+		e.countTask(target.ID, counterCaptchaSolve)
+		e.attemptProgress(&res, startedAt, "captcha", "success", res.Latency.CaptchaMs, "验证码已准备")
 	}
 	if pre.NeedCaptcha && fromPool && e.bus != nil {
 		e.bus.Log("debug", "验证码池命中（下单）", map[string]any{
@@ -789,73 +1288,160 @@ func (e *Engine) attemptWithAccount(ctx context.Context, target model.Target, ac
 		})
 	}
 
-	nextTarget := target
-	nextTarget.CaptchaVerifyParam = strings.TrimSpace(captchaVerifyParam)
-
-	res, updatedAcc2, err := e.provider.CreateOrder(ctx, acc, nextTarget, pre)
+	attempt, err := provider.NewAttemptContext(acc, target, pre, renderedAtMs)
 	if err != nil {
+		e.clearCachedPreflight(acc.ID, target.ID)
 		e.setError(target.ID, err)
+		return finish(model.AttemptErrorCreate, err)
+	}
+	attempt.SetCaptchaVerifyParam(captchaVerifyParam)
+
+	if e.IsPaused() {
+		return finish(model.AttemptErrorPaused, nil)
+	}
+
+	if guardOrder {
+		claimed, err := e.claimOrderSlot(ctx, acc.ID, target.ID)
+		if err != nil {
+			return finish(model.AttemptErrorDuplicateOrder, err)
+		}
+		if !claimed {
+			if e.bus != nil {
+				e.bus.Log("warn", "账号已在该目标下过单，跳过重复下单", map[string]any{
+					"targetId":  target.ID,
+					"accountId": acc.ID,
+				})
+			}
+			return finish(model.AttemptErrorDuplicateOrder, nil)
+		}
+	}
+
+	// 停止后不再发起新的下单；已发出的下单改用 orderCtx，停止时在宽限期内继续完成。
+	orderCtx, orderDone, ok := e.orderContext(ctx)
+	if !ok {
+		if guardOrder {
+			e.releaseOrderSlot(acc.ID, target.ID)
+		}
+		return finish(model.AttemptErrorCanceled, ctx.Err())
+	}
+	defer orderDone()
+
+	res.Phase = model.AttemptPhaseCreate
+	var created provider.CreateResult
+	for {
+		e.hookCreateOrder(orderCtx, hookInfo)
+		createStart := e.now()
+		var updatedAcc2 model.Account
+		created, updatedAcc2, err = e.providerFor(target).CreateOrder(orderCtx, attempt)
+		res.Latency.CreateMs += e.now().Sub(createStart).Milliseconds()
+		if provider.IsCircuitOpen(err) {
+			if guardOrder {
+				e.releaseOrderSlot(acc.ID, target.ID)
+			}
+			return finish(model.AttemptErrorCircuitOpen, err)
+		}
+		e.observeTargetOutcome(target, acc.ID, err)
+		e.noteTargetRisk(target.ID, err)
+		if err == nil {
+			_ = e.persistTargetAccount(orderCtx, target, updatedAcc2)
+			break
+		}
+		if !e.canRetryCreate(attempt, res.CreateRetries, err) {
+			e.attemptProgress(&res, startedAt, "create_order", "error", res.Latency.CreateMs, err.Error())
+			e.countTask(target.ID, counterCreateFailure)
+			e.setError(target.ID, err)
+			if e.bus != nil {
+				e.bus.Log("warn", "下单失败", map[string]any{
+					"targetId":  target.ID,
+					"accountId": acc.ID,
+					"error":     err.Error(),
+					"retries":   res.CreateRetries,
+				})
+			}
+			switch classifyBizError(err) {
+			case bizErrorDuplicate:
+				// 上游已有该账号的订单：保留占位，本次运行不再用该账号尝试。
+				e.markOrderSlotTaken(acc.ID, target.ID)
+				e.clearCachedPreflight(acc.ID, target.ID)
+				return finish(model.AttemptErrorDuplicateOrder, err)
+			case bizErrorNotStarted:
+				e.holdPreflightUntilStart(target, e.now().UnixMilli())
+				e.clearCachedPreflight(acc.ID, target.ID)
+			}
+			if guardOrder {
+				e.releaseOrderSlot(acc.ID, target.ID)
+			}
+			e.dropRenderAfterCreate(target, acc.ID, err)
+			return finish(model.AttemptErrorCreate, err)
+		}
+		res.CreateRetries++
 		if e.bus != nil {
-			e.bus.Log("warn", "下单失败", map[string]any{
+			e.bus.Log("debug", "下单被限流或缺货，沿用同一 render 重试", map[string]any{
 				"targetId":  target.ID,
 				"accountId": acc.ID,
 				"error":     err.Error(),
 			})
 		}
-		return false
+		if !e.waitTargetLimits(ctx, target, acc.ID) {
+			if guardOrder {
+				e.releaseOrderSlot(acc.ID, target.ID)
+			}
+			return finish(model.AttemptErrorCanceled, ctx.Err())
+		}
+		if e.IsPaused() {
+			if guardOrder {
+				e.releaseOrderSlot(acc.ID, target.ID)
+			}
+			return finish(model.AttemptErrorPaused, nil)
+		}
 	}
-	_ = e.persistAccount(ctx, updatedAcc2)
 
+	e.attemptProgress(&res, startedAt, "create_order", "success", res.Latency.CreateMs, "create-order 成功")
+	if guardOrder {
+		e.recordOrderSlot(ctx, acc.ID, target.ID, created.OrderID)
+	}
+	e.dropRenderAfterCreate(target, acc.ID, nil)
+	res.OrderID = created.OrderID
+	res.ActualFee = created.TotalFee
+	res.VerifyTokenUsed = created.UsedVerifyToken
+	if created.TraceID != "" {
+		res.TraceID = created.TraceID
+	}
 	if e.bus != nil {
 		e.bus.Log("info", "下单成功", map[string]any{
 			"targetId":  target.ID,
 			"accountId": acc.ID,
-			"orderId":   res.OrderID,
-			"traceId":   res.TraceID,
-		})
-	}
-	if e.notifier != nil {
-		e.notifier.NotifyOrderCreated(ctx, notify.OrderCreatedEvent{
-			At:         time.Now().UnixMilli(),
-			AccountID:  acc.ID,
-			Mobile:     acc.Mobile,
-			TargetID:   target.ID,
-			TargetName: target.Name,
-			Mode:       string(target.Mode),
-			ItemID:     target.ItemID,
-			SKUID:      target.SKUID,
-			ShopID:     target.ShopID,
-			Quantity:   e.normalizePerOrderQty(target.PerOrderQty),
-			OrderID:    res.OrderID,
-			TraceID:    res.TraceID,
+			"orderId":   created.OrderID,
+			"traceId":   created.TraceID,
 		})
 	}
-	return true
+	return finish("", nil)
 }
 
 func (e *Engine) preflightCacheKey(accountID string, targetID string) string {
 	return accountID + "|" + targetID
 }
 
-func (e *Engine) getCachedPreflight(accountID string, targetID string, nowMs int64) (provider.PreflightResult, bool) {
+// getCachedPreflight 返回该账号在该目标上 ttl 内生成的预下单结果及其生成时间。
+func (e *Engine) getCachedPreflight(accountID string, targetID string, nowMs int64, ttl time.Duration) (provider.PreflightResult, int64, bool) {
 	if e == nil || accountID == "" || targetID == "" {
-		return provider.PreflightResult{}, false
+		return provider.PreflightResult{}, 0, false
 	}
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	if e.preflightCache == nil {
-		return provider.PreflightResult{}, false
+		return provider.PreflightResult{}, 0, false
 	}
 	key := e.preflightCacheKey(accountID, targetID)
 	entry, ok := e.preflightCache[key]
 	if !ok {
-		return provider.PreflightResult{}, false
+		return provider.PreflightResult{}, 0, false
 	}
-	if entry.AtMs <= 0 || nowMs-entry.AtMs > preflightCacheTTL.Milliseconds() || len(entry.Value.Render) == 0 {
+	if entry.AtMs <= 0 || nowMs-entry.AtMs > ttl.Milliseconds() || len(entry.Value.Render) == 0 {
 		delete(e.preflightCache, key)
-		return provider.PreflightResult{}, false
+		return provider.PreflightResult{}, 0, false
 	}
-	return entry.Value, true
+	return entry.Value, entry.AtMs, true
 }
 
 func (e *Engine) setCachedPreflight(accountID string, targetID string, v provider.PreflightResult, nowMs int64) {
@@ -866,7 +1452,7 @@ func (e *Engine) setCachedPreflight(accountID string, targetID string, v provide
 		return
 	}
 	if nowMs <= 0 {
-		nowMs = time.Now().UnixMilli()
+		nowMs = e.now().UnixMilli()
 	}
 	e.mu.Lock()
 	if e.preflightCache == nil {
@@ -876,6 +1462,14 @@ func (e *Engine) setCachedPreflight(accountID string, targetID string, v provide
 	e.mu.Unlock()
 }
 
+// dropPreparedRenders 丢弃该账号在该目标上缓存的预下单结果和提前准备的下单参数。
+func (e *Engine) dropPreparedRenders(accountID string, targetID string) {
+	e.clearCachedPreflight(accountID, targetID)
+	e.mu.Lock()
+	delete(e.prerendered, e.preflightCacheKey(accountID, targetID))
+	e.mu.Unlock()
+}
+
 func (e *Engine) clearCachedPreflight(accountID string, targetID string) {
 	if e == nil || accountID == "" || targetID == "" {
 		return
@@ -887,6 +1481,21 @@ func (e *Engine) clearCachedPreflight(accountID string, targetID string) {
 	e.mu.Unlock()
 }
 
+// canRetryCreate 判断下单失败后能否沿用同一 render 重试：仅限上游明确限流或缺货（请求未被受理，不会重复下单）、
+// 未超过重试次数、render 仍在缓存有效期内，且不是靠免滑块凭证下的单（失败后凭证已作废）。
+func (e *Engine) canRetryCreate(attempt *provider.AttemptContext, retries int, err error) bool {
+	if retries >= maxCreateRetries {
+		return false
+	}
+	if !isThrottleError(err) && classifyBizError(err) != bizErrorSoldOut {
+		return false
+	}
+	if attempt.Preflight().NeedCaptcha && attempt.CaptchaVerifyParam() == "" {
+		return false
+	}
+	return e.now().UnixMilli()-attempt.RenderedAtMs() <= e.preflightCacheTTLFor(attempt.Target()).Milliseconds()
+}
+
 func (e *Engine) canPreflightNow(targetID string, nowMs int64) bool {
 	if e == nil || targetID == "" {
 		return true
@@ -919,7 +1528,7 @@ func (e *Engine) bumpPreflightBackoff(targetID string, nowMs int64, minUntilMs i
 		return 0, 0, 0
 	}
 	if nowMs <= 0 {
-		nowMs = time.Now().UnixMilli()
+		nowMs = e.now().UnixMilli()
 	}
 
 	const base = 1500 * time.Millisecond
@@ -956,29 +1565,28 @@ func (e *Engine) bumpPreflightBackoff(targetID string, nowMs int64, minUntilMs i
 	return failures, wait, untilMs
 }
 
-func (e *Engine) TestBuyOnce(ctx context.Context, targetID string, captchaVerifyParam string, opID string) (TestBuyResult, error) {
-	opID = strings.TrimSpace(opID)
-	if len(opID) > 120 {
-		opID = opID[:120]
-	}
+// TestBuyOnce 对目标执行一次完整的测试下单；requestedAccountID 非空时固定使用该账号，
+// 为空时在已登录账号间轮询。
+func (e *Engine) TestBuyOnce(ctx context.Context, targetID string, requestedAccountID string, captchaVerifyParam string, opID string) (TestBuyResult, error) {
+	opID = normalizeOpID(opID)
 	accountID := ""
+	timer := e.newOpTimer()
 	progress := func(step, phase, message string, fields map[string]any) {
-		if opID == "" || e.bus == nil {
+		if !e.progressEnabled(opID) {
 			return
 		}
-		if fields == nil {
-			fields = map[string]any{}
-		}
-		e.bus.Publish("progress", logbus.ProgressData{
+		d := logbus.ProgressData{
 			OpID:      opID,
 			Kind:      "test_buy",
-			Step:      strings.TrimSpace(step),
-			Phase:     strings.TrimSpace(phase),
-			Message:   strings.TrimSpace(message),
+			Step:      step,
+			Phase:     phase,
+			Message:   message,
 			TargetID:  strings.TrimSpace(targetID),
 			AccountID: strings.TrimSpace(accountID),
 			Fields:    fields,
-		})
+		}
+		timer.stamp(e.now(), &d)
+		e.publishProgress(d)
 	}
 
 	progress("start", "start", "开始测试抢购", nil)
@@ -986,15 +1594,15 @@ func (e *Engine) TestBuyOnce(ctx context.Context, targetID string, captchaVerify
 		progress("init", "error", "store unavailable", nil)
 		return TestBuyResult{}, errors.New("store unavailable")
 	}
-	if e.provider == nil {
-		progress("init", "error", "provider unavailable", nil)
-		return TestBuyResult{}, errors.New("provider unavailable")
-	}
 	target, err := e.store.GetTarget(ctx, targetID)
 	if err != nil {
 		progress("load_target", "error", err.Error(), nil)
 		return TestBuyResult{}, err
 	}
+	if e.providerFor(target) == nil {
+		progress("init", "error", "provider unavailable", nil)
+		return TestBuyResult{}, errors.New("provider unavailable")
+	}
 	if strings.TrimSpace(captchaVerifyParam) != "" {
 		target.CaptchaVerifyParam = strings.TrimSpace(captchaVerifyParam)
 	}
@@ -1005,22 +1613,12 @@ func (e *Engine) TestBuyOnce(ctx context.Context, targetID string, captchaVerify
 		"shopId": target.ShopID,
 	})
 
-	accounts, err := e.store.ListAccounts(ctx)
+	e.restoreTaskStates(ctx)
+	acc, err := e.testBuyAccount(ctx, strings.TrimSpace(requestedAccountID))
 	if err != nil {
 		progress("load_accounts", "error", err.Error(), nil)
 		return TestBuyResult{}, err
 	}
-	accounts = filterLoggedInAccounts(accounts)
-	if len(accounts) == 0 {
-		progress("load_accounts", "error", "没有已登录账号（缺少 token/cookie）", nil)
-		return TestBuyResult{}, errors.New("no logged-in accounts")
-	}
-
-	n := e.rr.Add(1)
-	acc := accounts[int(n-1)%len(accounts)]
-	if latest, err := e.store.GetAccount(ctx, acc.ID); err == nil {
-		acc = latest
-	}
 	accountID = acc.ID
 	progress("select_account", "success", "已选择账号", map[string]any{
 		"mobile": acc.Mobile,
@@ -1035,12 +1633,8 @@ func (e *Engine) TestBuyOnce(ctx context.Context, targetID string, captchaVerify
 	e.ensureAccountLimiter(acc.ID)
 
 	e.mu.Lock()
-	st := e.states[target.ID]
-	if st == nil {
-		st = &model.TaskState{TargetID: target.ID, Running: false, TargetQty: target.TargetQty}
-		e.states[target.ID] = st
-	}
-	st.LastAttemptMs = time.Now().UnixMilli()
+	st := e.taskStateLocked(target, false)
+	st.LastAttemptMs = e.now().UnixMilli()
 	e.publishStateLocked(*st)
 	e.mu.Unlock()
 
@@ -1054,19 +1648,32 @@ func (e *Engine) TestBuyOnce(ctx context.Context, targetID string, captchaVerify
 	}
 	defer e.releaseInFlight()
 
+	// 与抢购尝试相同，按账号今天剩余的每日额度收缩件数，额度用完时不下单（演练订单不计入额度）。
+	if !target.Practice {
+		left, limited := e.dailyQuotaLeft(ctx, acc.ID)
+		if limited && left <= 0 {
+			progress("done", "warning", "账号今日下单数量已达上限，结束", nil)
+			return TestBuyResult{Message: "账号今日下单数量已达上限"}, nil
+		}
+		if limited && left < e.normalizePerOrderQty(target.PerOrderQty) {
+			target.PerOrderQty = left
+			progress("quota", "warning", "账号今日剩余额度不足一单，按剩余件数下单", map[string]any{"qty": left})
+		}
+	}
+
 	if !e.waitLimits(ctx, acc.ID) {
 		progress("limits", "error", "等待限速失败", nil)
 		return TestBuyResult{}, ctx.Err()
 	}
 
 	progress("render_order", "start", "请求 render-order", map[string]any{"api": "/api/trade/buy/render-order"})
-	pre, updatedAcc, err := e.provider.Preflight(ctx, acc, target)
+	pre, updatedAcc, err := e.providerFor(target).Preflight(ctx, acc, target)
 	if err != nil {
 		e.setError(target.ID, err)
 		progress("render_order", "error", err.Error(), nil)
 		return TestBuyResult{}, err
 	}
-	_ = e.persistAccount(ctx, updatedAcc)
+	_ = e.persistTargetAccount(ctx, target, updatedAcc)
 	acc = updatedAcc
 	progress("render_order", "success", "render-order 返回", map[string]any{
 		"canBuy":      pre.CanBuy,
@@ -1090,29 +1697,67 @@ func (e *Engine) TestBuyOnce(ctx context.Context, targetID string, captchaVerify
 		})
 		return TestBuyResult{CanBuy: false, NeedCaptcha: pre.NeedCaptcha, Success: false, TraceID: pre.TraceID, Message: "当前不可购买"}, nil
 	}
+	if !e.withinPriceCap(target, acc.ID, pre) {
+		msg := priceOverCapMessage(pre)
+		progress("done", "warning", msg, map[string]any{
+			"totalFee":    pre.TotalFee,
+			"maxTotalFee": target.MaxTotalFee,
+		})
+		return TestBuyResult{CanBuy: true, NeedCaptcha: pre.NeedCaptcha, TraceID: pre.TraceID, Message: msg}, nil
+	}
 
-	captchaVerifyParam, fromPool, err := e.captchaVerifyParamForOrder(ctx, acc, target, pre.NeedCaptcha)
+	if captchaRequired(pre) {
+		progress("captcha", "start", "准备验证码", nil)
+	}
+	captchaVerifyParam, src, err := e.captchaVerifyParamForOrder(ctx, acc, target, captchaRequired(pre))
+	if src == captchaSolved {
+		e.noteCaptchaSolves(target.ID, 1)
+	}
 	if err != nil {
 		progress("captcha", "error", "验证码处理失败："+err.Error(), nil)
 		return TestBuyResult{}, err
 	}
 	if pre.NeedCaptcha {
-		if fromPool {
+		if !captchaRequired(pre) {
+			progress("captcha", "success", "使用免滑块凭证，跳过验证码", nil)
+		} else if src == captchaPooled {
 			progress("captcha_pool", "success", "已从验证码池获取", nil)
 		} else {
 			progress("captcha", "success", "验证码已准备", nil)
 		}
 	}
 	target.CaptchaVerifyParam = strings.TrimSpace(captchaVerifyParam)
+	attempt, err := provider.NewAttemptContext(acc, target, pre, e.now().UnixMilli())
+	if err != nil {
+		progress("create_order", "error", err.Error(), nil)
+		return TestBuyResult{}, err
+	}
 
 	if !e.waitLimits(ctx, acc.ID) {
 		progress("limits", "error", "等待限速失败", nil)
 		return TestBuyResult{}, ctx.Err()
 	}
 
+	guardOrder := !target.AllowMultiplePerAccount && !target.Practice
+	if guardOrder {
+		claimed, err := e.claimOrderSlot(ctx, acc.ID, target.ID)
+		if err != nil {
+			progress("create_order", "error", err.Error(), nil)
+			return TestBuyResult{}, err
+		}
+		if !claimed {
+			progress("create_order", "error", "账号已在该目标下过单，跳过重复下单", nil)
+			return TestBuyResult{}, errors.New("account already ordered this target")
+		}
+	}
+
 	progress("create_order", "start", "请求 create-order", map[string]any{"api": "/api/trade/buy/create-order"})
-	res, updatedAcc2, err := e.provider.CreateOrder(ctx, acc, target, pre)
+	startedAt := e.now()
+	created, updatedAcc2, err := e.providerFor(target).CreateOrder(ctx, attempt)
 	if err != nil {
+		if guardOrder {
+			e.releaseOrderSlot(acc.ID, target.ID)
+		}
 		e.setError(target.ID, err)
 		if e.bus != nil {
 			e.bus.Log("warn", "测试下单失败", map[string]any{
@@ -1124,22 +1769,54 @@ func (e *Engine) TestBuyOnce(ctx context.Context, targetID string, captchaVerify
 		progress("create_order", "error", err.Error(), nil)
 		return TestBuyResult{}, err
 	}
-	_ = e.persistAccount(ctx, updatedAcc2)
+	_ = e.persistTargetAccount(ctx, target, updatedAcc2)
 	progress("create_order", "success", "create-order 成功", map[string]any{
-		"orderId": res.OrderID,
-		"traceId": res.TraceID,
+		"orderId": created.OrderID,
+		"traceId": created.TraceID,
 	})
 
+	// 与抢购尝试相同的结果结构，成功后同样经 orderCreated 保存订单、通知并执行下单后钩子。
+	res := model.AttemptResult{
+		ID:           e.attemptSeq.Add(1),
+		RunID:        e.RunID(),
+		TargetID:     target.ID,
+		AccountID:    acc.ID,
+		StartedAtMs:  startedAt.UnixMilli(),
+		FinishedAtMs: e.now().UnixMilli(),
+		Phase:        model.AttemptPhaseDone,
+		Success:      created.Success,
+		Quantity:     e.normalizePerOrderQty(target.PerOrderQty),
+		Practice:     target.Practice,
+		OrderID:      created.OrderID,
+		TraceID:      created.TraceID,
+		TotalFee:     pre.TotalFee,
+		ActualFee:    created.TotalFee,
+
+		VerifyTokenUsed: created.UsedVerifyToken,
+	}
+	if guardOrder {
+		if res.Success {
+			e.recordOrderSlot(ctx, acc.ID, target.ID, res.OrderID)
+		} else {
+			e.releaseOrderSlot(acc.ID, target.ID)
+		}
+	}
+
 	if res.Success {
 		e.mu.Lock()
 		st := e.states[target.ID]
 		if st != nil {
-			st.PurchasedQty += target.PerOrderQty
-			st.LastSuccessMs = time.Now().UnixMilli()
+			if target.Practice {
+				st.PracticeQty += res.Quantity
+			} else {
+				st.PurchasedQty += res.Quantity
+			}
+			st.LastSuccessMs = res.FinishedAtMs
 			st.LastError = ""
 			e.publishStateLocked(*st)
 		}
 		e.mu.Unlock()
+		_, _ = e.flushTaskStates(context.Background())
 		if e.bus != nil {
 			e.bus.Log("info", "测试下单成功", map[string]any{
 				"targetId":  target.ID,
@@ -1148,22 +1825,15 @@ func (e *Engine) TestBuyOnce(ctx context.Context, targetID string, captchaVerify
 				"traceId":   res.TraceID,
 			})
 		}
-		if e.notifier != nil {
-			e.notifier.NotifyOrderCreated(ctx, notify.OrderCreatedEvent{
-				At:         time.Now().UnixMilli(),
-				AccountID:  acc.ID,
-				Mobile:     acc.Mobile,
-				TargetID:   target.ID,
-				TargetName: target.Name,
-				Mode:       string(target.Mode),
-				ItemID:     target.ItemID,
-				SKUID:      target.SKUID,
-				ShopID:     target.ShopID,
-				Quantity:   target.PerOrderQty,
-				OrderID:    res.OrderID,
-				TraceID:    res.TraceID,
-			})
+		if !target.Practice {
+			e.noteDailyPurchase(ctx, acc.ID, res.Quantity)
+			e.verifyCreatedOrder(ctx, acc, &res)
+			e.verifyOrderFee(ctx, &res)
 		}
+		// 订单记录仍标记为测试下单，与定时抢购的订单区分。
+		orderTarget := target
+		orderTarget.Mode = "test_buy"
+		e.orderCreated(ctx, orderTarget, acc, res)
 	}
 
 	progress("done", "success", "测试抢购完成", map[string]any{
@@ -1186,18 +1856,43 @@ func (e *Engine) TestBuyOnce(ctx context.Context, targetID string, captchaVerify
 	}, nil
 }
 
-func (e *Engine) PreflightOnce(ctx context.Context, targetID string) (PreflightCheckResult, error) {
+// PreflightOnce 用一个账号对目标执行一次 render-order 预检；opID 非空时推送 preflight 进度（含各步骤耗时）。
+// includeRender 为 true 时在结果中附带脱敏、限长后的原始 render 报文，便于排查解析问题。
+func (e *Engine) PreflightOnce(ctx context.Context, targetID string, opID string, includeRender bool) (PreflightCheckResult, error) {
+	opID = normalizeOpID(opID)
+	accountID := ""
+	timer := e.newOpTimer()
+	progress := func(step, phase, message string, fields map[string]any) {
+		if !e.progressEnabled(opID) {
+			return
+		}
+		d := logbus.ProgressData{
+			OpID:      opID,
+			Kind:      "preflight",
+			Step:      step,
+			Phase:     phase,
+			Message:   message,
+			TargetID:  strings.TrimSpace(targetID),
+			AccountID: accountID,
+			Fields:    fields,
+		}
+		timer.stamp(e.now(), &d)
+		e.publishProgress(d)
+	}
+
 	if e.store == nil {
 		return PreflightCheckResult{}, errors.New("store unavailable")
 	}
-	if e.provider == nil {
-		return PreflightCheckResult{}, errors.New("provider unavailable")
-	}
 	target, err := e.store.GetTarget(ctx, targetID)
 	if err != nil {
+		progress("load_target", "error", err.Error(), nil)
 		return PreflightCheckResult{}, err
 	}
+	if e.providerFor(target) == nil {
+		return PreflightCheckResult{}, errors.New("provider unavailable")
+	}
 
+	e.restoreTaskStates(ctx)
 	accounts, err := e.store.ListAccounts(ctx)
 	if err != nil {
 		return PreflightCheckResult{}, err
@@ -1212,15 +1907,12 @@ func (e *Engine) PreflightOnce(ctx context.Context, targetID string) (PreflightC
 	if latest, err := e.store.GetAccount(ctx, acc.ID); err == nil {
 		acc = latest
 	}
+	accountID = acc.ID
 	e.ensureAccountLimiter(acc.ID)
 
 	e.mu.Lock()
-	st := e.states[target.ID]
-	if st == nil {
-		st = &model.TaskState{TargetID: target.ID, Running: false, TargetQty: target.TargetQty}
-		e.states[target.ID] = st
-	}
-	st.LastAttemptMs = time.Now().UnixMilli()
+	st := e.taskStateLocked(target, false)
+	st.LastAttemptMs = e.now().UnixMilli()
 	e.publishStateLocked(*st)
 	e.mu.Unlock()
 
@@ -1238,12 +1930,20 @@ func (e *Engine) PreflightOnce(ctx context.Context, targetID string) (PreflightC
 		return PreflightCheckResult{}, ctx.Err()
 	}
 
-	pre, updatedAcc, err := e.provider.Preflight(ctx, acc, target)
+	progress("render_order", "start", "请求 render-order", map[string]any{"api": "/api/trade/buy/render-order"})
+	pre, updatedAcc, err := e.providerFor(target).Preflight(ctx, acc, target)
 	if err != nil {
+		progress("render_order", "error", err.Error(), nil)
 		e.setError(target.ID, err)
 		return PreflightCheckResult{}, err
 	}
-	_ = e.persistAccount(ctx, updatedAcc)
+	progress("render_order", "success", "render-order 返回", map[string]any{
+		"canBuy":      pre.CanBuy,
+		"needCaptcha": pre.NeedCaptcha,
+		"totalFee":    pre.TotalFee,
+		"traceId":     pre.TraceID,
+	})
+	_ = e.persistTargetAccount(ctx, target, updatedAcc)
 
 	e.mu.Lock()
 	if st := e.states[target.ID]; st != nil {
@@ -1253,21 +1953,42 @@ func (e *Engine) PreflightOnce(ctx context.Context, targetID string) (PreflightC
 	}
 	e.mu.Unlock()
 
+	var rushAt *RushAtCheck
+	if rushAtCheckApplicable(target) && e.waitLimits(ctx, acc.ID) {
+		progress("rush_at_check", "start", "核对开抢时间", nil)
+		if chk, err := e.checkRushAt(ctx, updatedAcc, target); err == nil {
+			rushAt = &chk
+			progress("rush_at_check", "success", "开抢时间已核对", nil)
+		} else {
+			progress("rush_at_check", "error", err.Error(), nil)
+		}
+	}
+
 	msg := "预检完成"
 	if !pre.CanBuy {
 		msg = "当前不可购买"
+	} else if pre.NeedCaptcha && pre.VerifyTokenAvailable {
+		msg = "需要验证码（可用免滑块凭证）"
 	} else if pre.NeedCaptcha {
 		msg = "需要验证码"
 	} else {
 		msg = "无需验证码"
 	}
-	return PreflightCheckResult{
+	progress("done", "success", msg, nil)
+	res := PreflightCheckResult{
 		CanBuy:      pre.CanBuy,
 		NeedCaptcha: pre.NeedCaptcha,
 		TotalFee:    pre.TotalFee,
 		TraceID:     pre.TraceID,
 		Message:     msg,
-	}, nil
+		RushAtCheck: rushAt,
+
+		VerifyTokenAvailable: pre.VerifyTokenAvailable,
+	}
+	if includeRender {
+		res.Render, res.RenderTruncated = renderDebugPayload(pre.Render)
+	}
+	return res, nil
 }
 
 func (e *Engine) persistAccount(ctx context.Context, acc model.Account) error {
@@ -1302,6 +2023,35 @@ func (e *Engine) pickAccount() model.Account {
 	return e.accounts[int(n-1)%len(e.accounts)]
 }
 
+// testBuyAccount 选出测试抢购使用的账号：指定 accountID 时必须存在且已登录，否则在已登录账号间轮询。
+func (e *Engine) testBuyAccount(ctx context.Context, accountID string) (model.Account, error) {
+	if accountID != "" {
+		acc, err := e.store.GetAccount(ctx, accountID)
+		if err != nil {
+			return model.Account{}, fmt.Errorf("account %s: %w", accountID, err)
+		}
+		if strings.TrimSpace(acc.Token) == "" {
+			return model.Account{}, fmt.Errorf("account %s is not logged in", accountID)
+		}
+		return acc, nil
+	}
+
+	accounts, err := e.store.ListAccounts(ctx)
+	if err != nil {
+		return model.Account{}, err
+	}
+	accounts = filterLoggedInAccounts(accounts)
+	if len(accounts) == 0 {
+		return model.Account{}, errors.New("no logged-in accounts")
+	}
+	n := e.rr.Add(1)
+	acc := accounts[int(n-1)%len(accounts)]
+	if latest, err := e.store.GetAccount(ctx, acc.ID); err == nil {
+		acc = latest
+	}
+	return acc, nil
+}
+
 func filterLoggedInAccounts(accounts []model.Account) []model.Account {
 	out := make([]model.Account, 0, len(accounts))
 	for _, a := range accounts {
@@ -1314,19 +2064,11 @@ func filterLoggedInAccounts(accounts []model.Account) []model.Account {
 }
 
 func (e *Engine) acquireInFlight(ctx context.Context) bool {
-	select {
-	case e.inFlight <- struct{}{}:
-		return true
-	case <-ctx.Done():
-		return false
-	}
+	return e.inFlight.acquire(ctx)
 }
 
 func (e *Engine) releaseInFlight() {
-	select {
-	case <-e.inFlight:
-	default:
-	}
+	e.inFlight.release()
 }
 
 func (e *Engine) acquireAccount(ctx context.Context, accountID string) bool {
@@ -1373,20 +2115,13 @@ func (e *Engine) releaseAccount(accountID string) {
 }
 
 func (e *Engine) publishStateLocked(st model.TaskState) {
+	e.markStateDirtyLocked(st.TargetID)
 	if e.bus != nil {
 		e.bus.Publish("task_state", st)
 	}
 }
 
 func (e *Engine) ensureAccountLimiter(accountID string) {
-	perQPS := e.limits.PerAccountQPS
-	if perQPS <= 0 {
-		perQPS = 1
-	}
-	perBurst := e.limits.PerAccountBurst
-	if perBurst <= 0 {
-		perBurst = 2
-	}
 	e.mu.Lock()
 	if e.perLimiter == nil {
 		e.perLimiter = make(map[string]*rate.Limiter)
@@ -1395,7 +2130,7 @@ func (e *Engine) ensureAccountLimiter(accountID string) {
 		e.accountLocks = make(map[string]chan struct{})
 	}
 	if e.perLimiter[accountID] == nil {
-		e.perLimiter[accountID] = rate.NewLimiter(rate.Limit(perQPS), perBurst)
+		e.perLimiter[accountID] = e.newAccountLimiterLocked()
 	}
 	if e.accountLocks[accountID] == nil {
 		e.accountLocks[accountID] = make(chan struct{}, 1)
@@ -1418,18 +2153,3 @@ func (e *Engine) waitLimits(ctx context.Context, accountID string) bool {
 	}
 	return true
 }
-
-func sleepUntil(ctx context.Context, t time.Time) bool {
-	d := time.Until(t)
-	if d <= 0 {
-		return true
-	}
-	timer := time.NewTimer(d)
-	defer timer.Stop()
-	select {
-	case <-timer.C:
-		return true
-	case <-ctx.Done():
-		return false
-	}
-}
@@ -0,0 +1,23 @@
+package engine
+
+import "sniping_engine/internal/model"
+
+// warnEnvMismatchLocked 生产环境实例即将运行带有其他环境标记（如从 staging 导入）的目标时告警一次；
+// 只告警不拦截，目标的环境标记改变后会再次告警。调用方需持有 mu。
+func (e *Engine) warnEnvMismatchLocked(t model.Target) {
+	if e.env != model.EnvProduction || t.Env == "" || t.Env == model.EnvProduction {
+		return
+	}
+	if e.envAlerted[t.ID] == t.Env {
+		return
+	}
+	e.envAlerted[t.ID] = t.Env
+	if e.bus != nil {
+		e.bus.Log("warn", "生产环境即将运行标记为其他环境的目标，请确认配置", map[string]any{
+			"targetId":  t.ID,
+			"name":      t.Name,
+			"targetEnv": t.Env,
+			"engineEnv": e.env,
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"time"
+)
+
+// fireSkewWarn 开抢触发偏差超过该值时记一条告警日志。
+const fireSkewWarn = 10 * time.Millisecond
+
+// recordRushFireSkew 记录开抢那一次触发相对 rushAt 的偏差并立即推送任务状态。
+func (e *Engine) recordRushFireSkew(targetID string, skew time.Duration) {
+	ms := skew.Milliseconds()
+	e.mu.Lock()
+	if st := e.states[targetID]; st != nil {
+		st.RushFireSkewMs = &ms
+		noteFireSkewLocked(&st.LastFireSkewMs, &st.MaxFireSkewMs, ms)
+		e.publishStateLocked(*st)
+	}
+	e.mu.Unlock()
+	if e.bus == nil {
+		return
+	}
+	level := "debug"
+	if skew > fireSkewWarn || skew < -fireSkewWarn {
+		level = "warn"
+	}
+	e.bus.Log(level, "开抢触发", map[string]any{"targetId": targetID, "skewMs": ms, "skewUs": skew.Microseconds()})
+}
+
+// recordFireSkew 记录一次抢购节拍的触发偏差；只更新状态，随下一次状态推送带出，避免每个节拍都广播。
+func (e *Engine) recordFireSkew(targetID string, skew time.Duration) {
+	ms := skew.Milliseconds()
+	e.mu.Lock()
+	if st := e.states[targetID]; st != nil {
+		noteFireSkewLocked(&st.LastFireSkewMs, &st.MaxFireSkewMs, ms)
+	}
+	e.mu.Unlock()
+}
+
+func noteFireSkewLocked(last, max *int64, ms int64) {
+	*last = ms
+	if absInt64(ms) > absInt64(*max) {
+		*max = ms
+	}
+}
+
+func absInt64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+func TestRushFireSkewRecordedInTaskState(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	rushAt := testStart.Add(10 * time.Second)
+	target := model.Target{ID: "t1", Mode: model.TargetModeRush, RushAtMs: rushAt.UnixMilli(), TargetQty: 1}
+	e.states[target.ID] = &model.TaskState{TargetID: target.ID, Running: true}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.runTarget(ctx, target)
+
+	bctx, bcancel := context.WithTimeout(ctx, 2*time.Second)
+	defer bcancel()
+	if !fc.BlockUntil(bctx, 1) {
+		t.Fatal("runTarget did not start waiting")
+	}
+	// 粗睡在 rushAt-rushSpinWindow 醒来，之后自旋到 rushAt。
+	fc.Advance(10*time.Second - rushSpinWindow)
+	time.Sleep(5 * time.Millisecond)
+	fc.Advance(rushSpinWindow + 3*time.Millisecond)
+
+	waitFor(t, "rush fire skew", func() bool {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return e.states[target.ID].RushFireSkewMs != nil
+	})
+	e.mu.Lock()
+	st := *e.states[target.ID]
+	e.mu.Unlock()
+	if got := *st.RushFireSkewMs; got != 3 {
+		t.Fatalf("rushFireSkewMs = %d, want 3", got)
+	}
+}
+
+func TestFireSkewKeepsLargestDeviation(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	e.states["t"] = &model.TaskState{TargetID: "t"}
+
+	for _, d := range []time.Duration{2 * time.Millisecond, -7 * time.Millisecond, 4 * time.Millisecond} {
+		e.recordFireSkew("t", d)
+	}
+	st := e.states["t"]
+	if st.LastFireSkewMs != 4 || st.MaxFireSkewMs != -7 {
+		t.Fatalf("last/max = %d/%d, want 4/-7", st.LastFireSkewMs, st.MaxFireSkewMs)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !windows
+
+package engine
+
+// enableHighResTimer 非 Windows 平台的计时精度已足够，无需处理。
+func enableHighResTimer() error { return nil }
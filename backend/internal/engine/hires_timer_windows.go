@@ -0,0 +1,19 @@
+//go:build windows
+
+package engine
+
+import "syscall"
+
+var procTimeBeginPeriod = syscall.NewLazyDLL("winmm.dll").NewProc("timeBeginPeriod")
+
+// enableHighResTimer 把系统计时器精度调到 1ms（进程级，退出时由系统恢复）。
+func enableHighResTimer() error {
+	if err := procTimeBeginPeriod.Find(); err != nil {
+		return err
+	}
+	r, _, _ := procTimeBeginPeriod.Call(1)
+	if r != 0 {
+		return syscall.Errno(r)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"context"
+
+	"sniping_engine/internal/model"
+)
+
+// setLifecycleLocked 切换生命周期状态并记录日志；调用方需持有 e.mu。
+func (e *Engine) setLifecycleLocked(next model.EngineLifecycle) {
+	prev := e.lifecycle
+	if prev == next {
+		return
+	}
+	e.lifecycle = next
+	if e.bus != nil {
+		e.bus.Log("debug", "引擎状态切换", map[string]any{"from": prev, "to": next})
+	}
+}
+
+// Lifecycle 返回引擎当前的生命周期状态。
+func (e *Engine) Lifecycle() model.EngineLifecycle {
+	if e == nil {
+		return model.EngineStopped
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lifecycle
+}
+
+// stopIfIdle 在没有任何运行中的目标时停止引擎。拿到 lifecycleMu 后重新检查，
+// 避免异步触发的停止与并发的启动/同步交错，把刚启动的目标一起停掉。
+func (e *Engine) stopIfIdle(ctx context.Context) error {
+	e.lifecycleMu.Lock()
+	defer e.lifecycleMu.Unlock()
+
+	e.mu.Lock()
+	idle := e.lifecycle == model.EngineRunning && len(e.targetCancels) == 0
+	e.mu.Unlock()
+	if !idle {
+		return nil
+	}
+	return e.stopAllLocked(ctx)
+}
@@ -0,0 +1,255 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+	"sniping_engine/internal/store/sqlite"
+)
+
+// idleProvider 只实现生命周期测试会用到的方法；开抢时间在很远的将来，不会触发任何上游请求。
+type idleProvider struct {
+	provider.Provider
+}
+
+func (idleProvider) Name() string { return "idle" }
+
+func newLifecycleEngine(t *testing.T) (*Engine, *sqlite.Store, model.Target) {
+	t.Helper()
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "engine.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	if _, err := st.UpsertAccount(ctx, model.Account{Mobile: "13800000000", Token: "token"}); err != nil {
+		t.Fatalf("upsert account: %v", err)
+	}
+	target, err := st.UpsertTarget(ctx, model.Target{
+		Name:        "t",
+		ItemID:      1,
+		SKUID:       1,
+		Mode:        model.TargetModeRush,
+		RushAtMs:    time.Now().Add(time.Hour).UnixMilli(),
+		TargetQty:   1,
+		PerOrderQty: 1,
+		Enabled:     true,
+	})
+	if err != nil {
+		t.Fatalf("upsert target: %v", err)
+	}
+
+	e := New(Options{Store: st, Provider: idleProvider{}})
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = e.StopAll(ctx)
+	})
+	return e, st, target
+}
+
+func targetLoopCount(e *Engine) int {
+	n := 0
+	for _, l := range e.Loops() {
+		if l.Kind == LoopKindTarget {
+			n++
+		}
+	}
+	return n
+}
+
+func TestAutoRunByStoreIsIdempotent(t *testing.T) {
+	e, _, target := newLifecycleEngine(t)
+	ctx := context.Background()
+
+	if err := e.AutoRunByStore(ctx); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	runID := e.RunID()
+	for i := 0; i < 5; i++ {
+		if err := e.AutoRunByStore(ctx); err != nil {
+			t.Fatalf("sync %d: %v", i, err)
+		}
+	}
+
+	if got := e.Lifecycle(); got != model.EngineRunning {
+		t.Fatalf("lifecycle = %q, want running", got)
+	}
+	if e.RunID() != runID {
+		t.Fatalf("runId changed across idempotent syncs")
+	}
+	waitFor(t, "single target loop", func() bool { return targetLoopCount(e) == 1 })
+	e.mu.Lock()
+	_, ok := e.targetCancels[target.ID]
+	e.mu.Unlock()
+	if !ok {
+		t.Fatalf("target %s not running", target.ID)
+	}
+}
+
+func TestRapidToggleLeavesLiveRunContext(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(4)
+		go func(enabled bool) {
+			defer wg.Done()
+			_ = st.SetTargetEnabled(ctx, target.ID, enabled)
+		}(i%2 == 0)
+		go func() {
+			defer wg.Done()
+			_ = e.AutoRunByStore(ctx)
+		}()
+		go func() {
+			defer wg.Done()
+			_ = e.StartAll(ctx)
+		}()
+		go func() {
+			defer wg.Done()
+			stopCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			defer cancel()
+			_ = e.StopAll(stopCtx)
+		}()
+	}
+	wg.Wait()
+
+	if err := st.SetTargetEnabled(ctx, target.ID, true); err != nil {
+		t.Fatalf("enable target: %v", err)
+	}
+	if err := e.AutoRunByStore(ctx); err != nil {
+		t.Fatalf("final sync: %v", err)
+	}
+
+	if got := e.Lifecycle(); got != model.EngineRunning {
+		t.Fatalf("lifecycle = %q, want running", got)
+	}
+	e.mu.Lock()
+	runCtx := e.runCtx
+	_, ok := e.targetCancels[target.ID]
+	e.mu.Unlock()
+	if runCtx == nil || runCtx.Err() != nil {
+		t.Fatalf("run context is dead after toggles")
+	}
+	if !ok {
+		t.Fatalf("target %s not running after final sync", target.ID)
+	}
+	waitFor(t, "single target loop", func() bool { return targetLoopCount(e) == 1 })
+
+	stopCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := e.StopAll(stopCtx); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if got := e.Lifecycle(); got != model.EngineStopped {
+		t.Fatalf("lifecycle = %q, want stopped", got)
+	}
+	if n := targetLoopCount(e); n != 0 {
+		t.Fatalf("target loops after stop = %d, want 0", n)
+	}
+}
+
+func TestStopIfIdleKeepsRunningTargets(t *testing.T) {
+	e, _, _ := newLifecycleEngine(t)
+	ctx := context.Background()
+
+	if err := e.StartAll(ctx); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if err := e.stopIfIdle(ctx); err != nil {
+		t.Fatalf("stopIfIdle: %v", err)
+	}
+	if got := e.Lifecycle(); got != model.EngineRunning {
+		t.Fatalf("lifecycle = %q, want running", got)
+	}
+}
+
+func TestStartAllRestoresPersistedTaskState(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	ctx := context.Background()
+
+	if err := e.StartAll(ctx); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	e.mu.Lock()
+	e.states[target.ID].PurchasedQty = 1
+	e.publishStateLocked(*e.states[target.ID])
+	e.mu.Unlock()
+	stopCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := e.StopAll(stopCtx); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	// 模拟进程重启：同一个库上的新引擎。
+	restarted := New(Options{Store: st, Provider: idleProvider{}})
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = restarted.StopAll(ctx)
+	})
+	if err := restarted.StartAll(ctx); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	restarted.mu.Lock()
+	got := restarted.states[target.ID].PurchasedQty
+	restarted.mu.Unlock()
+	if got != 1 {
+		t.Fatalf("purchasedQty after restart = %d, want 1", got)
+	}
+}
+
+func TestTaskStateOfRestoresSavedProgress(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	ctx := context.Background()
+	if err := st.UpsertTaskStates(ctx, []model.TaskState{{TargetID: target.ID, PurchasedQty: 1, LastError: "boom"}}); err != nil {
+		t.Fatalf("save task state: %v", err)
+	}
+
+	got := e.TaskStateOf(ctx, target)
+	if got.TargetID != target.ID || got.PurchasedQty != 1 || got.TargetQty != target.TargetQty || got.Running {
+		t.Fatalf("state = %+v", got)
+	}
+
+	if err := e.AutoRunByStore(ctx); err != nil {
+		t.Fatalf("AutoRunByStore: %v", err)
+	}
+	waitFor(t, "target running", func() bool { return e.TaskStateOf(ctx, target).Running })
+}
+
+func TestTestBuyAccountUsesRequestedAccount(t *testing.T) {
+	e, st, _ := newLifecycleEngine(t)
+	ctx := context.Background()
+
+	second, err := st.UpsertAccount(ctx, model.Account{Mobile: "13800000001", Token: "token-2"})
+	if err != nil {
+		t.Fatalf("upsert account: %v", err)
+	}
+	loggedOut, err := st.UpsertAccount(ctx, model.Account{Mobile: "13800000002"})
+	if err != nil {
+		t.Fatalf("upsert account: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		acc, err := e.testBuyAccount(ctx, second.ID)
+		if err != nil {
+			t.Fatalf("testBuyAccount: %v", err)
+		}
+		if acc.ID != second.ID {
+			t.Fatalf("account = %s, want %s", acc.ID, second.ID)
+		}
+	}
+	if _, err := e.testBuyAccount(ctx, loggedOut.ID); err == nil {
+		t.Fatalf("expected error for logged-out account")
+	}
+	if _, err := e.testBuyAccount(ctx, "missing"); err == nil {
+		t.Fatalf("expected error for unknown account")
+	}
+}
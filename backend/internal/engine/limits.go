@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+
+	"sniping_engine/internal/config"
+)
+
+// inFlightSem 是可在运行中调整容量的并发信号量（固定容量的 channel 无法扩缩容）。
+// 缩容时已占用的名额不会被收回，只是在释放到新容量以下之前不再发放新名额。
+type inFlightSem struct {
+	mu   sync.Mutex
+	size int
+	used int
+	wake chan struct{}
+}
+
+func newInFlightSem(size int) *inFlightSem {
+	if size <= 0 {
+		size = 1
+	}
+	return &inFlightSem{size: size, wake: make(chan struct{})}
+}
+
+func (s *inFlightSem) tryAcquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.used >= s.size {
+		return false
+	}
+	s.used++
+	return true
+}
+
+func (s *inFlightSem) acquire(ctx context.Context) bool {
+	for {
+		s.mu.Lock()
+		if s.used < s.size {
+			s.used++
+			s.mu.Unlock()
+			return true
+		}
+		wake := s.wake
+		s.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func (s *inFlightSem) release() {
+	s.mu.Lock()
+	if s.used > 0 {
+		s.used--
+	}
+	s.broadcastLocked()
+	s.mu.Unlock()
+}
+
+func (s *inFlightSem) resize(size int) {
+	if size <= 0 {
+		size = 1
+	}
+	s.mu.Lock()
+	s.size = size
+	s.broadcastLocked()
+	s.mu.Unlock()
+}
+
+func (s *inFlightSem) broadcastLocked() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+// NormalizeLimits 补齐限速参数的默认值（与配置文件缺省时的行为一致）。
+func NormalizeLimits(in config.LimitsConfig) config.LimitsConfig {
+	out := in
+	if out.MaxInFlight <= 0 {
+		out.MaxInFlight = 20
+	}
+	if out.GlobalQPS <= 0 {
+		out.GlobalQPS = 5
+	}
+	if out.GlobalBurst <= 0 {
+		out.GlobalBurst = 10
+	}
+	if out.PerAccountQPS <= 0 {
+		out.PerAccountQPS = 1
+	}
+	if out.PerAccountBurst <= 0 {
+		out.PerAccountBurst = 2
+	}
+	if out.MaxPerTargetInFlight <= 0 {
+		out.MaxPerTargetInFlight = 1
+	}
+	if out.CaptchaMaxInFlight <= 0 {
+		out.CaptchaMaxInFlight = 1
+	}
+	return out
+}
+
+// Limits 返回当前生效的限速参数。
+func (e *Engine) Limits() config.LimitsConfig {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := e.limits
+	out.MaxPerTargetInFlight = int(e.maxPerTargetInFlight.Load())
+	return out
+}
+
+// ApplyLimits 运行中调整全部限速参数：全局/账号限速器原地更新速率与突发，
+// 在途并发信号量直接扩缩容，正在执行的请求不受影响，新参数立即生效。
+func (e *Engine) ApplyLimits(next config.LimitsConfig) config.LimitsConfig {
+	next = NormalizeLimits(next)
+
+	e.mu.Lock()
+	prev := e.limits
+	e.limits = next
+	e.globalLimiter.SetLimit(rate.Limit(next.GlobalQPS))
+	e.globalLimiter.SetBurst(next.GlobalBurst)
+	for _, l := range e.perLimiter {
+		l.SetLimit(rate.Limit(next.PerAccountQPS))
+		l.SetBurst(next.PerAccountBurst)
+	}
+	e.mu.Unlock()
+
+	e.inFlight.resize(next.MaxInFlight)
+	e.SetMaxPerTargetInFlight(next.MaxPerTargetInFlight)
+
+	if e.bus != nil && prev != next {
+		e.bus.Log("info", "限速参数已更新", map[string]any{
+			"globalQPS":            next.GlobalQPS,
+			"globalBurst":          next.GlobalBurst,
+			"perAccountQPS":        next.PerAccountQPS,
+			"perAccountBurst":      next.PerAccountBurst,
+			"maxInFlight":          next.MaxInFlight,
+			"maxPerTargetInFlight": next.MaxPerTargetInFlight,
+			"prevGlobalQPS":        prev.GlobalQPS,
+			"prevPerAccountQPS":    prev.PerAccountQPS,
+			"prevMaxInFlight":      prev.MaxInFlight,
+		})
+	}
+	return next
+}
+
+func (e *Engine) newAccountLimiterLocked() *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(e.limits.PerAccountQPS), e.limits.PerAccountBurst)
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"testing"
+
+	"golang.org/x/time/rate"
+
+	"sniping_engine/internal/config"
+)
+
+func TestApplyLimitsUpdatesRunningLimiters(t *testing.T) {
+	e := New(Options{Limits: config.LimitsConfig{MaxInFlight: 1, GlobalQPS: 5, GlobalBurst: 10, PerAccountQPS: 1, PerAccountBurst: 2}})
+	e.ensureAccountLimiter("a1")
+	if !e.tryAcquireInFlight() || e.tryAcquireInFlight() {
+		t.Fatal("maxInFlight=1 should allow exactly one slot")
+	}
+
+	got := e.ApplyLimits(config.LimitsConfig{MaxInFlight: 2, GlobalQPS: 20, GlobalBurst: 30, PerAccountQPS: 3, PerAccountBurst: 4, MaxPerTargetInFlight: 2})
+	if got.CaptchaMaxInFlight != 1 {
+		t.Fatalf("zero fields should be normalized, got %+v", got)
+	}
+	if e.globalLimiter.Limit() != rate.Limit(20) || e.globalLimiter.Burst() != 30 {
+		t.Fatalf("global limiter = %v/%d", e.globalLimiter.Limit(), e.globalLimiter.Burst())
+	}
+	e.mu.Lock()
+	acc := e.perLimiter["a1"]
+	e.mu.Unlock()
+	if acc.Limit() != rate.Limit(3) || acc.Burst() != 4 {
+		t.Fatalf("existing account limiter = %v/%d", acc.Limit(), acc.Burst())
+	}
+	e.ensureAccountLimiter("a2")
+	e.mu.Lock()
+	fresh := e.perLimiter["a2"]
+	e.mu.Unlock()
+	if fresh.Limit() != rate.Limit(3) || fresh.Burst() != 4 {
+		t.Fatalf("new account limiter = %v/%d", fresh.Limit(), fresh.Burst())
+	}
+	if !e.tryAcquireInFlight() || e.tryAcquireInFlight() {
+		t.Fatal("grown semaphore should hand out exactly one more slot")
+	}
+	if l := e.Limits(); l.MaxInFlight != 2 || l.MaxPerTargetInFlight != 2 {
+		t.Fatalf("Limits() = %+v", l)
+	}
+}
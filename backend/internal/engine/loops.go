@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// 后台循环的种类。
+const (
+	LoopKindTarget         = "target"
+	LoopKindCaptchaPool    = "captcha_pool"
+	LoopKindReservedDrift  = "reserved_drift"
+	LoopKindCatalog        = "catalog"
+	LoopKindTaskStateFlush = "task_state_flush"
+	LoopKindClockSync      = "clock_sync"
+	LoopKindRetention      = "retention"
+)
+
+const (
+	loopPhaseWaitingRush = "waiting_rush"
+	loopPhaseRunning     = "running"
+
+	loopKeyCaptchaPool    = "captcha-pool-maintainer"
+	loopKeyReservedDrift  = "reserved-drift-checker"
+	loopKeyCatalog        = "catalog-refresher"
+	loopKeyTaskStateFlush = "task-state-flusher"
+	loopKeyClockSync      = "clock-calibrator"
+	loopKeyRetention      = "retention-pruner"
+)
+
+// LoopInfo 描述一个正在运行的后台循环（由引擎内部登记，不解析运行时栈）。
+type LoopInfo struct {
+	Key           string           `json:"key"`
+	Kind          string           `json:"kind"`
+	TargetID      string           `json:"targetId,omitempty"`
+	Mode          model.TargetMode `json:"mode,omitempty"`
+	Phase         string           `json:"phase"`
+	StartedAtMs   int64            `json:"startedAtMs"`
+	IntervalMs    int64            `json:"intervalMs,omitempty"`
+	NextFireMs    int64            `json:"nextFireMs,omitempty"`
+	LastFireMs    int64            `json:"lastFireMs,omitempty"`
+	Fires         uint64           `json:"fires"`
+	LastOutcome   string           `json:"lastOutcome,omitempty"`
+	LastError     string           `json:"lastError,omitempty"`
+	LastAttemptMs int64            `json:"lastAttemptMs,omitempty"`
+	LastSuccessMs int64            `json:"lastSuccessMs,omitempty"`
+}
+
+func targetLoopKey(targetID string) string { return "target:" + targetID }
+
+func (e *Engine) registerLoop(info LoopInfo) *LoopInfo {
+	if info.StartedAtMs == 0 {
+		info.StartedAtMs = e.now().UnixMilli()
+	}
+	e.loopsMu.Lock()
+	e.loops[info.Key] = &info
+	e.loopsMu.Unlock()
+	return &info
+}
+
+func (e *Engine) unregisterLoop(key string) {
+	e.loopsMu.Lock()
+	delete(e.loops, key)
+	e.loopsMu.Unlock()
+}
+
+// unregisterLoopInstance 只在 key 仍指向 info 时移除；目标配置变更重启时新循环可能先于旧循环退出前完成登记。
+func (e *Engine) unregisterLoopInstance(key string, info *LoopInfo) {
+	e.loopsMu.Lock()
+	if e.loops[key] == info {
+		delete(e.loops, key)
+	}
+	e.loopsMu.Unlock()
+}
+
+func (e *Engine) updateLoop(key string, fn func(*LoopInfo)) {
+	e.loopsMu.Lock()
+	if info := e.loops[key]; info != nil {
+		fn(info)
+	}
+	e.loopsMu.Unlock()
+}
+
+// recordLoopFire 记录一次触发及其结果，interval>0 时顺带估算下一次触发时间。
+func (e *Engine) recordLoopFire(key string, outcome string, interval time.Duration) {
+	nowMs := e.now().UnixMilli()
+	e.updateLoop(key, func(l *LoopInfo) {
+		l.Fires++
+		l.LastFireMs = nowMs
+		l.LastOutcome = outcome
+		if interval > 0 {
+			l.NextFireMs = nowMs + interval.Milliseconds()
+		}
+	})
+}
+
+// Loops 返回所有正在运行的后台循环；目标循环会附带对应任务的最近尝试/错误信息。
+func (e *Engine) Loops() []LoopInfo {
+	if e == nil {
+		return nil
+	}
+	e.loopsMu.Lock()
+	out := make([]LoopInfo, 0, len(e.loops))
+	for _, l := range e.loops {
+		out = append(out, *l)
+	}
+	e.loopsMu.Unlock()
+
+	e.mu.Lock()
+	for i := range out {
+		if out[i].TargetID == "" {
+			continue
+		}
+		if st := e.states[out[i].TargetID]; st != nil {
+			out[i].LastError = st.LastError
+			out[i].LastAttemptMs = st.LastAttemptMs
+			out[i].LastSuccessMs = st.LastSuccessMs
+		}
+	}
+	e.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
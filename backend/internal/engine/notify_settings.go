@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"math"
 	"strings"
 	"time"
 
@@ -10,9 +11,18 @@ import (
 func DefaultNotifySettings() model.NotifySettings {
 	return model.NotifySettings{
 		RushExpireDisableMinutes: 10,
-		RushMode:                 "concurrent",
+		RushMode:                 RushModeConcurrent,
 		RoundRobinIntervalMs:     120,
 		ScanIntervalMs:           1000,
+		ScanFullEvery:            10,
+		ScanRenderCacheMs:        5000,
+		RushAtDriftWarnSeconds:   60,
+		AccountCooldownFailures:  5,
+		AccountCooldownSeconds:   120,
+		TargetFailureLimit:       50,
+		ScheduleLeadSeconds:      120,
+		ScheduleWindowSeconds:    300,
+		DuplicateTargetPolicy:    DuplicateTargetsWarn,
 	}
 }
 
@@ -25,10 +35,10 @@ func normalizeNotifySettings(in model.NotifySettings) model.NotifySettings {
 		out.RushExpireDisableMinutes = 1440
 	}
 	switch strings.ToLower(strings.TrimSpace(out.RushMode)) {
-	case "round_robin":
-		out.RushMode = "round_robin"
+	case RushModeRoundRobin:
+		out.RushMode = RushModeRoundRobin
 	default:
-		out.RushMode = "concurrent"
+		out.RushMode = RushModeConcurrent
 	}
 	if out.RoundRobinIntervalMs <= 0 {
 		out.RoundRobinIntervalMs = 120
@@ -48,6 +58,84 @@ func normalizeNotifySettings(in model.NotifySettings) model.NotifySettings {
 	if out.ScanIntervalMs > 60000 {
 		out.ScanIntervalMs = 60000
 	}
+	if out.ScanRenderCacheMs <= 0 {
+		out.ScanRenderCacheMs = 5000
+	}
+	if out.ScanRenderCacheMs < 500 {
+		out.ScanRenderCacheMs = 500
+	}
+	if out.ScanRenderCacheMs > 60000 {
+		out.ScanRenderCacheMs = 60000
+	}
+	if out.ScanFullEvery <= 0 {
+		out.ScanFullEvery = 10
+	}
+	if out.ScanFullEvery > 1000 {
+		out.ScanFullEvery = 1000
+	}
+	if out.RushAtDriftWarnSeconds <= 0 {
+		out.RushAtDriftWarnSeconds = 60
+	}
+	if out.RushAtDriftWarnSeconds > 86400 {
+		out.RushAtDriftWarnSeconds = 86400
+	}
+	if out.AccountCooldownFailures <= 0 {
+		out.AccountCooldownFailures = 5
+	}
+	if out.AccountCooldownFailures > 100 {
+		out.AccountCooldownFailures = 100
+	}
+	if out.AccountCooldownSeconds <= 0 {
+		out.AccountCooldownSeconds = 120
+	}
+	if out.AccountCooldownSeconds > 86400 {
+		out.AccountCooldownSeconds = 86400
+	}
+	if out.AccountDailyQuota < 0 {
+		out.AccountDailyQuota = 0
+	}
+	if out.AccountDailyQuota > 10000 {
+		out.AccountDailyQuota = 10000
+	}
+	if out.TargetFailureLimit <= 0 {
+		out.TargetFailureLimit = 50
+	}
+	if out.TargetFailureLimit > 100000 {
+		out.TargetFailureLimit = 100000
+	}
+	if out.ScheduleLeadSeconds <= 0 {
+		out.ScheduleLeadSeconds = 120
+	}
+	if out.ScheduleLeadSeconds < 10 {
+		out.ScheduleLeadSeconds = 10
+	}
+	if out.ScheduleLeadSeconds > 3600 {
+		out.ScheduleLeadSeconds = 3600
+	}
+	if out.ScheduleWindowSeconds <= 0 {
+		out.ScheduleWindowSeconds = 300
+	}
+	if out.ScheduleWindowSeconds > 86400 {
+		out.ScheduleWindowSeconds = 86400
+	}
+	switch strings.ToLower(strings.TrimSpace(out.DuplicateTargetPolicy)) {
+	case DuplicateTargetsBlock:
+		out.DuplicateTargetPolicy = DuplicateTargetsBlock
+	default:
+		out.DuplicateTargetPolicy = DuplicateTargetsWarn
+	}
+	if out.CaptchaFeePerSolve < 0 || math.IsNaN(out.CaptchaFeePerSolve) {
+		out.CaptchaFeePerSolve = 0
+	}
+	if out.CaptchaFeePerSolve > 100 {
+		out.CaptchaFeePerSolve = 100
+	}
+	if out.ProxyFeePerGB < 0 || math.IsNaN(out.ProxyFeePerGB) {
+		out.ProxyFeePerGB = 0
+	}
+	if out.ProxyFeePerGB > 10000 {
+		out.ProxyFeePerGB = 10000
+	}
 	return out
 }
 
@@ -91,10 +179,14 @@ func (e *Engine) RoundRobinInterval() time.Duration {
 	return time.Duration(st.RoundRobinIntervalMs) * time.Millisecond
 }
 
+// ScanInterval 返回扫货间隔：启用上新日历时由日历决定（窗口内收紧、窗口外放宽），否则取扫货间隔设置。
 func (e *Engine) ScanInterval() time.Duration {
 	if e == nil {
 		return 1 * time.Second
 	}
+	if d, ok := e.dropCalendarScanInterval(e.now().UnixMilli()); ok {
+		return d
+	}
 	st := e.NotifySettings()
 	if st.ScanIntervalMs <= 0 {
 		return e.task.ScanInterval()
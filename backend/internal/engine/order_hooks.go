@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// OrderHook 是下单成功后的扩展点：自动取消、付款跟踪、外部回调等都通过它挂接，而不是修改下单流程。
+// 钩子在独立 goroutine 中执行，不阻塞抢购；返回错误时按退避重试，重试耗尽后记录告警日志。
+// 同一订单可能因重试被调用多次，钩子应按 OrderID 保持幂等。
+type OrderHook func(ctx context.Context, rec model.OrderRecord) error
+
+const (
+	// orderHookAttempts 每个钩子最多执行的次数（含首次）。
+	orderHookAttempts = 3
+	// orderHookRetryBase 第一次重试前的等待时间，之后每次翻倍。
+	orderHookRetryBase = time.Second
+	// orderHookTimeout 单次执行的超时。
+	orderHookTimeout = 30 * time.Second
+)
+
+// AddOrderHook 在引擎创建后追加下单后钩子（对之后成功的订单生效）。
+func (e *Engine) AddOrderHook(h OrderHook) {
+	if e == nil || h == nil {
+		return
+	}
+	e.orderHooksMu.Lock()
+	e.orderHooks = append(e.orderHooks, h)
+	e.orderHooksMu.Unlock()
+}
+
+// orderCreated 在下单成功后保存订单历史、发送通知并分发给所有钩子。
+// 演练目标的订单来自 mock 服务，只记日志，不保存、不通知也不分发钩子。
+func (e *Engine) orderCreated(ctx context.Context, target model.Target, acc model.Account, res model.AttemptResult) {
+	if target.Practice {
+		if e.bus != nil {
+			e.bus.Log("info", "演练下单成功", map[string]any{
+				"targetId":  target.ID,
+				"accountId": acc.ID,
+				"orderId":   res.OrderID,
+			})
+		}
+		return
+	}
+	rec := model.OrderRecord{
+		AttemptID:   res.ID,
+		RunID:       res.RunID,
+		TargetID:    target.ID,
+		TargetName:  target.Name,
+		Mode:        string(target.Mode),
+		AccountID:   acc.ID,
+		Mobile:      acc.Mobile,
+		ItemID:      target.ItemID,
+		SKUID:       target.SKUID,
+		ShopID:      target.ShopID,
+		Quantity:    res.Quantity,
+		OrderID:     res.OrderID,
+		TraceID:     res.TraceID,
+		CreatedAtMs: res.FinishedAtMs,
+		ExpectedFee: res.TotalFee,
+		ActualFee:   res.ActualFee,
+		FeeMismatch: res.FeeMismatch,
+
+		OrderVerified: res.OrderVerified,
+		OrderStatus:   res.OrderStatus,
+		PayDeadlineMs: res.PayDeadlineMs,
+	}
+	e.saveOrder(ctx, rec)
+	e.notifyOrderCreated(ctx, target, acc, res)
+
+	e.orderHooksMu.Lock()
+	hooks := append([]OrderHook(nil), e.orderHooks...)
+	e.orderHooksMu.Unlock()
+	for i, h := range hooks {
+		e.hooksWG.Add(1)
+		go func(idx int, h OrderHook) {
+			defer e.hooksWG.Done()
+			e.runOrderHook(idx, h, rec)
+		}(i, h)
+	}
+}
+
+// saveOrder 把成功的订单写入订单历史；写入失败只记日志，不影响通知与钩子。
+func (e *Engine) saveOrder(ctx context.Context, rec model.OrderRecord) {
+	if e.store == nil {
+		return
+	}
+	if rec.CreatedAtMs == 0 {
+		rec.CreatedAtMs = e.now().UnixMilli()
+	}
+	if err := e.store.InsertOrder(context.WithoutCancel(ctx), rec); err != nil && e.bus != nil {
+		e.bus.Log("warn", "保存订单记录失败", map[string]any{
+			"targetId":  rec.TargetID,
+			"accountId": rec.AccountID,
+			"orderId":   rec.OrderID,
+			"error":     err.Error(),
+		})
+	}
+}
+
+// runOrderHook 执行单个钩子；不使用抢购的 ctx，目标停止后钩子仍会完成。
+func (e *Engine) runOrderHook(idx int, h OrderHook, rec model.OrderRecord) {
+	wait := orderHookRetryBase
+	var err error
+	for attempt := 1; attempt <= orderHookAttempts; attempt++ {
+		err = callOrderHook(h, rec)
+		if err == nil {
+			return
+		}
+		if attempt == orderHookAttempts {
+			break
+		}
+		if e.bus != nil {
+			e.bus.Log("debug", "下单后钩子执行失败，稍后重试", map[string]any{
+				"hook":    idx,
+				"orderId": rec.OrderID,
+				"attempt": attempt,
+				"error":   err.Error(),
+			})
+		}
+		t := e.clk().NewTimer(wait)
+		<-t.C()
+		wait *= 2
+	}
+	if e.bus != nil {
+		e.bus.Log("warn", "下单后钩子执行失败", map[string]any{
+			"hook":      idx,
+			"orderId":   rec.OrderID,
+			"targetId":  rec.TargetID,
+			"accountId": rec.AccountID,
+			"attempts":  orderHookAttempts,
+			"error":     err.Error(),
+		})
+	}
+}
+
+func callOrderHook(h OrderHook, rec model.OrderRecord) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("order hook panic: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), orderHookTimeout)
+	defer cancel()
+	return h(ctx, rec)
+}
+
+// WaitOrderHooks 等待已分发的下单后钩子执行完（含重试），用于进程退出前收尾；ctx 结束时提前返回 false。
+func (e *Engine) WaitOrderHooks(ctx context.Context) bool {
+	if e == nil {
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		e.hooksWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
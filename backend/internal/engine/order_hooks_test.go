@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/store/sqlite"
+)
+
+func TestOrderHooksRetryUntilSuccess(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	var calls atomic.Int32
+	got := make(chan model.OrderRecord, 1)
+	e.AddOrderHook(func(ctx context.Context, rec model.OrderRecord) error {
+		if calls.Add(1) < 2 {
+			return errors.New("temporary")
+		}
+		got <- rec
+		return nil
+	})
+
+	target := model.Target{ID: "t1", Name: "商品", SKUID: 7}
+	res := model.AttemptResult{ID: 3, Success: true, OrderID: "o-1", Quantity: 1}
+	e.orderCreated(context.Background(), target, model.Account{ID: "a1"}, res)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if !fc.BlockUntil(ctx, 1) {
+		t.Fatal("hook did not wait for a retry")
+	}
+	fc.Advance(orderHookRetryBase)
+
+	select {
+	case rec := <-got:
+		if rec.OrderID != "o-1" || rec.TargetID != "t1" || rec.AccountID != "a1" || rec.SKUID != 7 {
+			t.Fatalf("record = %+v", rec)
+		}
+	case <-ctx.Done():
+		t.Fatal("hook was not retried")
+	}
+	if !e.WaitOrderHooks(ctx) || calls.Load() != 2 {
+		t.Fatalf("calls = %d", calls.Load())
+	}
+}
+
+func TestOrderCreatedSavesOrderHistory(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "engine.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	e := New(Options{Store: st})
+
+	base := time.Date(2025, 6, 1, 10, 0, 0, 0, time.Local).UnixMilli()
+	t1 := model.Target{ID: "t1", Name: "商品", SKUID: 7}
+	e.orderCreated(ctx, t1, model.Account{ID: "a1", Mobile: "138"}, model.AttemptResult{ID: 1, Success: true, OrderID: "o-1", TraceID: "tr-1", Quantity: 2, TotalFee: 500, FinishedAtMs: base})
+	e.orderCreated(ctx, t1, model.Account{ID: "a2"}, model.AttemptResult{ID: 2, Success: true, OrderID: "o-2", Quantity: 1, FinishedAtMs: base + 1000})
+	e.orderCreated(ctx, model.Target{ID: "t2"}, model.Account{ID: "a1"}, model.AttemptResult{ID: 3, Success: true, OrderID: "o-3", Quantity: 1, FinishedAtMs: base + 86400000})
+	e.orderCreated(ctx, model.Target{ID: "t1", Practice: true}, model.Account{ID: "a1"}, model.AttemptResult{ID: 4, Success: true, OrderID: "mock", FinishedAtMs: base})
+
+	all, err := st.ListOrders(ctx, model.OrderQuery{})
+	if err != nil || len(all) != 3 || all[0].OrderID != "o-3" {
+		t.Fatalf("all orders = %+v, %v", all, err)
+	}
+	byTarget, _ := st.ListOrders(ctx, model.OrderQuery{TargetID: "t1", AccountID: "a1"})
+	if len(byTarget) != 1 {
+		t.Fatalf("t1/a1 orders = %+v", byTarget)
+	}
+	if got := byTarget[0]; got.TraceID != "tr-1" || got.Quantity != 2 || got.ExpectedFee != 500 || got.Mobile != "138" || got.AttemptID != 1 {
+		t.Fatalf("saved order = %+v", got)
+	}
+	sameDay, _ := st.ListOrders(ctx, model.OrderQuery{FromMs: base, ToMs: base + 86400000})
+	if len(sameDay) != 2 {
+		t.Fatalf("orders on the first day = %+v", sameDay)
+	}
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"context"
+	"strings"
+)
+
+// 重复下单保护：同一账号在同一目标上默认只成功下单一次（目标 allowMultiplePerAccount=true 时不限制）。
+// 进程内以占位表拦截并发尝试和本次进程里已成功的账号，成功后写入 SQLite 的 order_ledger，重启后仍然生效。
+
+func orderClaimKey(accountID, targetID string) string {
+	return accountID + "|" + targetID
+}
+
+// claimOrderSlot 在下单前占位；账号已在该目标下过单或有并发尝试正在下单时返回 false。
+// 数据库查询在占位锁外进行，查询期间如有下单记录写入则重新查询；查询失败时拒绝下单并返回错误。
+func (e *Engine) claimOrderSlot(ctx context.Context, accountID, targetID string) (bool, error) {
+	key := orderClaimKey(accountID, targetID)
+	for {
+		e.orderClaimsMu.Lock()
+		_, claimed := e.orderClaims[key]
+		gen := e.orderLedgerGen
+		e.orderClaimsMu.Unlock()
+		if claimed {
+			return false, nil
+		}
+
+		if e.store != nil {
+			_, exists, err := e.store.GetOrderLedger(ctx, accountID, targetID)
+			if err != nil {
+				if e.bus != nil {
+					e.bus.Log("warn", "读取下单记录失败，拒绝下单", map[string]any{
+						"targetId":  targetID,
+						"accountId": accountID,
+						"error":     err.Error(),
+					})
+				}
+				return false, err
+			}
+			if exists {
+				return false, nil
+			}
+		}
+
+		e.orderClaimsMu.Lock()
+		if _, ok := e.orderClaims[key]; ok {
+			e.orderClaimsMu.Unlock()
+			return false, nil
+		}
+		if e.orderLedgerGen != gen {
+			e.orderClaimsMu.Unlock()
+			continue
+		}
+		e.orderClaims[key] = false
+		e.orderClaimsMu.Unlock()
+		return true, nil
+	}
+}
+
+// releaseOrderSlot 下单未成功时释放占位，允许后续尝试。
+func (e *Engine) releaseOrderSlot(accountID, targetID string) {
+	key := orderClaimKey(accountID, targetID)
+	e.orderClaimsMu.Lock()
+	if done, ok := e.orderClaims[key]; ok && !done {
+		delete(e.orderClaims, key)
+	}
+	e.orderClaimsMu.Unlock()
+}
+
+// recordOrderSlot 下单成功后持久化记录，并在进程内保留成功占位，让 orderSlotTaken 在预下单前就跳过该账号；
+// 删除目标、合并目标或清除隐私数据时由 forgetOrderSlots 一并清除。
+func (e *Engine) recordOrderSlot(ctx context.Context, accountID, targetID, orderID string) {
+	if e.store != nil {
+		_, err := e.store.ClaimOrderLedger(context.WithoutCancel(ctx), accountID, targetID, orderID)
+		if err != nil && e.bus != nil {
+			e.bus.Log("warn", "保存下单记录失败", map[string]any{
+				"targetId":  targetID,
+				"accountId": accountID,
+				"orderId":   orderID,
+				"error":     err.Error(),
+			})
+		}
+	}
+
+	e.orderClaimsMu.Lock()
+	e.orderLedgerGen++
+	e.orderClaims[orderClaimKey(accountID, targetID)] = true
+	e.orderClaimsMu.Unlock()
+}
+
+// ForgetTargetOrders 清除目标在进程内的下单占位，删除目标（连同其下单记录）后调用。
+func (e *Engine) ForgetTargetOrders(targetID string) {
+	if e == nil {
+		return
+	}
+	e.forgetOrderSlots(func(_, tid string) bool { return tid == targetID })
+}
+
+// forgetOrderSlots 删除 match 命中的已成功占位；进行中的占位由下单流程自己释放。
+func (e *Engine) forgetOrderSlots(match func(accountID, targetID string) bool) {
+	e.orderClaimsMu.Lock()
+	defer e.orderClaimsMu.Unlock()
+	for key, done := range e.orderClaims {
+		accountID, targetID, _ := strings.Cut(key, "|")
+		if done && match(accountID, targetID) {
+			delete(e.orderClaims, key)
+		}
+	}
+}
+
+// markOrderSlotTaken 上游提示重复下单时调用：该账号本次运行不再尝试这个目标。
+// 订单并非本次创建，只在进程内占位，不写入 order_ledger。
+func (e *Engine) markOrderSlotTaken(accountID, targetID string) {
+	e.orderClaimsMu.Lock()
+	e.orderClaims[orderClaimKey(accountID, targetID)] = true
+	e.orderClaimsMu.Unlock()
+}
+
+// orderSlotTaken 判断账号在该目标上是否已有进程内的成功占位（不查数据库），用于在预下单前提前放弃。
+func (e *Engine) orderSlotTaken(accountID, targetID string) bool {
+	e.orderClaimsMu.Lock()
+	defer e.orderClaimsMu.Unlock()
+	return e.orderClaims[orderClaimKey(accountID, targetID)]
+}
@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+func TestOrderSlotBlocksConcurrentAndRepeatOrders(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	ctx := context.Background()
+
+	if !claim(e, "a", "t1") {
+		t.Fatal("first claim should succeed")
+	}
+	if claim(e, "a", "t1") {
+		t.Fatal("concurrent claim for the same account/target should be refused")
+	}
+	if !claim(e, "b", "t1") || !claim(e, "a", "t2") {
+		t.Fatal("other account/target pairs should not be blocked")
+	}
+
+	e.releaseOrderSlot("a", "t1")
+	if !claim(e, "a", "t1") {
+		t.Fatal("claim should succeed again after a failed order released it")
+	}
+
+	e.recordOrderSlot(ctx, "a", "t1", "o-1")
+	e.releaseOrderSlot("a", "t1")
+	if claim(e, "a", "t1") {
+		t.Fatal("account that already ordered should be refused")
+	}
+}
+
+func TestOrderSlotRefusedWhenLedgerLookupFails(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	_ = st.Close()
+
+	ok, err := e.claimOrderSlot(context.Background(), "a", target.ID)
+	if ok || err == nil {
+		t.Fatalf("claim should be refused with an error when the ledger is unreadable, got ok=%v err=%v", ok, err)
+	}
+	e.orderClaimsMu.Lock()
+	_, held := e.orderClaims[orderClaimKey("a", target.ID)]
+	e.orderClaimsMu.Unlock()
+	if held {
+		t.Fatal("refused claim should not leave a placeholder")
+	}
+}
+
+type countingBuyProvider struct {
+	buyProvider
+	preflights *atomic.Int32
+}
+
+func (p countingBuyProvider) Preflight(ctx context.Context, acc model.Account, target model.Target) (provider.PreflightResult, model.Account, error) {
+	p.preflights.Add(1)
+	return p.buyProvider.Preflight(ctx, acc, target)
+}
+
+func TestLedgeredAccountSkipsPreflight(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	ctx := context.Background()
+	var preflights atomic.Int32
+	e.provider = countingBuyProvider{preflights: &preflights}
+	accounts, err := st.ListAccounts(ctx)
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("accounts = %+v, %v", accounts, err)
+	}
+	acc := accounts[0]
+
+	if res := e.attemptWithAccount(ctx, target, acc, 0); !res.Success {
+		t.Fatalf("first attempt: %+v", res)
+	}
+	if _, ok, err := st.GetOrderLedger(ctx, acc.ID, target.ID); err != nil || !ok {
+		t.Fatalf("order ledger after success = %v, %v", ok, err)
+	}
+	res := e.attemptWithAccount(ctx, target, acc, 0)
+	if res.Success || res.ErrorClass != model.AttemptErrorDuplicateOrder {
+		t.Fatalf("second attempt = %+v, want duplicate_order", res)
+	}
+	if n := preflights.Load(); n != 1 {
+		t.Fatalf("preflight calls = %d, want 1 (ledgered account must be skipped before preflight)", n)
+	}
+
+	// 删除目标连同下单记录后，占位一并清除。
+	if err := st.DeleteOrderLedger(ctx, target.ID); err != nil {
+		t.Fatalf("delete ledger: %v", err)
+	}
+	e.ForgetTargetOrders(target.ID)
+	if e.orderSlotTaken(acc.ID, target.ID) {
+		t.Fatal("slot should be forgotten after the target's ledger is deleted")
+	}
+}
+
+func claim(e *Engine, accountID, targetID string) bool {
+	ok, _ := e.claimOrderSlot(context.Background(), accountID, targetID)
+	return ok
+}
@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// orderFeeLookback 按订单列表核对金额时，从尝试开始时间往前多查的时间窗口（容忍本地与上游时钟偏差）。
+const orderFeeLookback = time.Minute
+
+const (
+	// orderVerifyAttempts 下单后查询订单详情的次数（含首次）：刚创建的订单可能尚未落库，查不到时稍后重试。
+	orderVerifyAttempts = 3
+	// orderVerifyRetryWait 查不到订单时重试前的等待时间。
+	orderVerifyRetryWait = time.Second
+	// orderVerifyTimeout 整个核对过程的超时。
+	orderVerifyTimeout = 10 * time.Second
+)
+
+// verifyCreatedOrder 下单成功后查询上游订单详情，确认订单确实存在并记下订单状态与付款截止时间，
+// 结果写回 res 和下单记录（order_ledger）；确认不了时发告警日志，但不改变下单结果。
+// Provider 未实现 provider.OrderDetailer 时跳过。目标达成后抢购的 ctx 会被取消，核对不受影响。
+func (e *Engine) verifyCreatedOrder(ctx context.Context, acc model.Account, res *model.AttemptResult) {
+	if e == nil || res == nil || !res.Success || strings.TrimSpace(res.OrderID) == "" {
+		return
+	}
+	detailer, ok := e.provider.(provider.OrderDetailer)
+	if !ok {
+		return
+	}
+	vctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), orderVerifyTimeout)
+	defer cancel()
+
+	var detail provider.OrderDetail
+	var err error
+	for attempt := 1; attempt <= orderVerifyAttempts; attempt++ {
+		var updated model.Account
+		e.ensureAccountLimiter(acc.ID)
+		if !e.waitLimits(vctx, acc.ID) {
+			err = vctx.Err()
+			break
+		}
+		detail, updated, err = detailer.GetOrderDetail(vctx, acc, res.OrderID)
+		if err == nil {
+			if e.store != nil {
+				_ = e.persistAccount(vctx, updated)
+			}
+			break
+		}
+		if !errors.Is(err, provider.ErrOrderNotFound) || attempt == orderVerifyAttempts {
+			break
+		}
+		t := e.clk().NewTimer(orderVerifyRetryWait)
+		select {
+		case <-t.C():
+		case <-vctx.Done():
+			t.Stop()
+		}
+	}
+
+	entry := model.OrderLedgerEntry{
+		AccountID:    acc.ID,
+		TargetID:     res.TargetID,
+		OrderID:      res.OrderID,
+		VerifiedAtMs: e.now().UnixMilli(),
+	}
+	if err != nil {
+		res.OrderVerifyError = err.Error()
+		entry.VerifyError = res.OrderVerifyError
+		if e.bus != nil {
+			e.bus.Log("error", "下单后未能确认订单存在，请人工核对", map[string]any{
+				"targetId":  res.TargetID,
+				"accountId": acc.ID,
+				"orderId":   res.OrderID,
+				"error":     res.OrderVerifyError,
+			})
+		}
+	} else {
+		res.OrderVerified = true
+		res.OrderStatus = detail.Status
+		res.PayDeadlineMs = detail.PayDeadlineMs
+		if res.ActualFee <= 0 && detail.TotalFee > 0 {
+			res.ActualFee = detail.TotalFee
+		}
+		entry.OrderStatus, entry.PayDeadlineMs = detail.Status, detail.PayDeadlineMs
+		if e.bus != nil {
+			e.bus.Log("info", "已确认订单", map[string]any{
+				"targetId":      res.TargetID,
+				"accountId":     acc.ID,
+				"orderId":       res.OrderID,
+				"status":        detail.Status,
+				"payDeadlineMs": detail.PayDeadlineMs,
+			})
+		}
+	}
+	if e.store != nil {
+		if err := e.store.UpdateOrderLedgerVerification(vctx, entry); err != nil && e.bus != nil {
+			e.bus.Log("warn", "保存订单核对结果失败", map[string]any{"orderId": res.OrderID, "error": err.Error()})
+		}
+	}
+}
+
+// verifyOrderFee 核对下单成功后的实际订单金额与预检金额是否一致。
+// create-order 响应里带了金额就直接用，否则查一次上游订单列表；查不到时只记录未核对，不影响下单结果。
+// 与 verifyCreatedOrder 一样不受目标达成后抢购 ctx 取消的影响。
+func (e *Engine) verifyOrderFee(ctx context.Context, res *model.AttemptResult) {
+	if e == nil || res == nil || !res.Success {
+		return
+	}
+
+	if res.ActualFee <= 0 && strings.TrimSpace(res.OrderID) != "" {
+		vctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), orderVerifyTimeout)
+		defer cancel()
+		since := time.UnixMilli(res.StartedAtMs).Add(-orderFeeLookback)
+		orders, err := e.ListRecentOrders(vctx, res.AccountID, since)
+		if err != nil {
+			if e.bus != nil {
+				e.bus.Log("warn", "订单金额核对失败", map[string]any{
+					"targetId":  res.TargetID,
+					"accountId": res.AccountID,
+					"orderId":   res.OrderID,
+					"error":     err.Error(),
+				})
+			}
+			return
+		}
+		for _, o := range orders {
+			if o.OrderID == res.OrderID {
+				res.ActualFee = o.TotalFee
+				break
+			}
+		}
+	}
+	if res.ActualFee <= 0 {
+		return
+	}
+
+	res.FeeVerified = true
+	res.FeeMismatch = res.TotalFee > 0 && res.ActualFee != res.TotalFee
+	if res.FeeMismatch && e.bus != nil {
+		e.bus.Log("warn", "订单金额与预检不一致，付款前请核对", map[string]any{
+			"targetId":    res.TargetID,
+			"accountId":   res.AccountID,
+			"orderId":     res.OrderID,
+			"expectedFee": res.TotalFee,
+			"actualFee":   res.ActualFee,
+		})
+	}
+}
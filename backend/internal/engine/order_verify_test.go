@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+type detailProvider struct {
+	provider.Provider
+	detail provider.OrderDetail
+	err    error
+	calls  int
+}
+
+func (p *detailProvider) GetOrderDetail(_ context.Context, acc model.Account, orderID string) (provider.OrderDetail, model.Account, error) {
+	p.calls++
+	if p.err != nil {
+		return provider.OrderDetail{}, model.Account{}, p.err
+	}
+	d := p.detail
+	d.OrderID = orderID
+	return d, acc, nil
+}
+
+func TestVerifyCreatedOrderCapturesDetail(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	p := &detailProvider{detail: provider.OrderDetail{Status: "WAIT_PAY", TotalFee: 1299, PayDeadlineMs: 1700000900000}}
+	e.provider = p
+
+	res := model.AttemptResult{TargetID: "t", AccountID: "a", Success: true, OrderID: "o1", TotalFee: 1299}
+	e.verifyCreatedOrder(context.Background(), model.Account{ID: "a"}, &res)
+	if !res.OrderVerified || res.OrderStatus != "WAIT_PAY" || res.PayDeadlineMs != 1700000900000 || res.OrderVerifyError != "" {
+		t.Fatalf("res = %+v", res)
+	}
+	if res.ActualFee != 1299 {
+		t.Fatalf("actualFee = %d, want fee from detail", res.ActualFee)
+	}
+}
+
+func TestVerifyCreatedOrderReportsFailure(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	p := &detailProvider{err: errors.New("risk control")}
+	e.provider = p
+
+	res := model.AttemptResult{TargetID: "t", AccountID: "a", Success: true, OrderID: "o1"}
+	e.verifyCreatedOrder(context.Background(), model.Account{ID: "a"}, &res)
+	if res.OrderVerified || res.OrderVerifyError != "risk control" {
+		t.Fatalf("res = %+v", res)
+	}
+	if !res.Success {
+		t.Fatal("verification failure must not change the order result")
+	}
+	if p.calls != 1 {
+		t.Fatalf("calls = %d; only not-found is retried", p.calls)
+	}
+}
+
+// ordersProvider 只在 ctx 未取消时返回订单列表。
+type ordersProvider struct {
+	idleProvider
+}
+
+func (ordersProvider) ListRecentOrders(ctx context.Context, acc model.Account, since time.Time) ([]provider.UpstreamOrder, model.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, acc, err
+	}
+	return []provider.UpstreamOrder{{OrderID: "o1", TotalFee: 1500}}, acc, nil
+}
+
+func TestVerifyOrderFeeSurvivesCanceledAttemptContext(t *testing.T) {
+	e, st, _ := newLifecycleEngine(t)
+	e.provider = ordersProvider{}
+	accounts, err := st.ListAccounts(context.Background())
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("accounts = %+v, %v", accounts, err)
+	}
+
+	// 目标达成后抢购的 ctx 会被取消，金额核对仍应完成。
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res := model.AttemptResult{TargetID: "t", AccountID: accounts[0].ID, Success: true, OrderID: "o1", TotalFee: 1299, StartedAtMs: time.Now().UnixMilli()}
+	e.verifyOrderFee(ctx, &res)
+	if !res.FeeVerified || res.ActualFee != 1500 || !res.FeeMismatch {
+		t.Fatalf("res = %+v", res)
+	}
+}
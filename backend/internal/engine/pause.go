@@ -0,0 +1,40 @@
+package engine
+
+import "errors"
+
+// Pause 暂停发起新的预下单/下单尝试。目标协程、开抢等待、定时器和验证码池都保持运行，
+// 已经发出的请求照常完成；Resume 后从下一个节拍立即恢复。
+func (e *Engine) Pause() error {
+	if e == nil {
+		return errors.New("engine unavailable")
+	}
+	if !e.IsRunning() {
+		return errors.New("engine not running")
+	}
+	if e.paused.CompareAndSwap(false, true) && e.bus != nil {
+		e.bus.Log("info", "引擎已暂停", nil)
+	}
+	return nil
+}
+
+// Resume 恢复被 Pause 暂停的尝试。
+func (e *Engine) Resume() error {
+	if e == nil {
+		return errors.New("engine unavailable")
+	}
+	if e.paused.CompareAndSwap(true, false) && e.bus != nil {
+		e.bus.Log("info", "引擎已恢复", nil)
+	}
+	return nil
+}
+
+// IsPaused 返回引擎是否处于暂停状态。
+func (e *Engine) IsPaused() bool {
+	if e == nil {
+		return false
+	}
+	return e.paused.Load()
+}
+
+// pausedOutcome 暂停时目标循环本次节拍的结果描述。
+const pausedOutcome = "engine paused"
@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"context"
+	"errors"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// 演练模式：引擎同时持有真实 provider 与演练 provider（指向 mock 服务），按目标的 Practice 开关选择，
+// 便于在同一实例里让新手先对着 mock 练习完整的预下单/验证码/下单流程。
+
+var errPracticeProviderUnavailable = errors.New("practice provider unavailable")
+
+// providerFor 返回目标应使用的 provider：演练目标使用演练 provider（未配置时为 nil），其余使用真实 provider。
+func (e *Engine) providerFor(target model.Target) provider.Provider {
+	if target.Practice {
+		return e.practiceProvider
+	}
+	return e.provider
+}
+
+// validatePracticeTarget 演练目标要求配置了演练 provider，避免演练目标误用真实 provider 下单。
+func (e *Engine) validatePracticeTarget(target model.Target) error {
+	if target.Practice && e.practiceProvider == nil {
+		return errPracticeProviderUnavailable
+	}
+	return nil
+}
+
+// persistTargetAccount 写回目标流程中 provider 返回的账号。演练目标拿到的是 mock 服务的 cookie、地址和区划，
+// 写回会覆盖真实账号的会话，因此只留在本次尝试的内存里。
+func (e *Engine) persistTargetAccount(ctx context.Context, target model.Target, acc model.Account) error {
+	if target.Practice {
+		return nil
+	}
+	return e.persistAccount(ctx, acc)
+}
+
+// observeTargetOutcome 把目标流程中一次上游请求的结果计入限流估计和账号冷却；演练目标的 mock 结果不影响真实账号。
+func (e *Engine) observeTargetOutcome(target model.Target, accountID string, err error) {
+	if target.Practice {
+		return
+	}
+	e.observeUpstream(accountID, err)
+	e.noteAccountOutcome(accountID, err)
+}
@@ -0,0 +1,202 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+type namedProvider struct {
+	idleProvider
+	name string
+}
+
+func (p namedProvider) Name() string { return p.name }
+
+func TestProviderForPracticeTarget(t *testing.T) {
+	e := New(Options{Provider: namedProvider{name: "real"}, PracticeProvider: namedProvider{name: "mock"}})
+	if got := e.providerFor(model.Target{ID: "live"}).Name(); got != "real" {
+		t.Fatalf("live target provider = %q", got)
+	}
+	if got := e.providerFor(model.Target{ID: "p", Practice: true}).Name(); got != "mock" {
+		t.Fatalf("practice target provider = %q", got)
+	}
+}
+
+func TestPracticeTargetWithoutPracticeProviderIsConfigError(t *testing.T) {
+	e := New(Options{Provider: namedProvider{name: "real"}})
+	practice := model.Target{ID: "p", Mode: model.TargetModeScan, ItemID: 1, SKUID: 2, TargetQty: 1, PerOrderQty: 1, Practice: true}
+	live := practice
+	live.ID, live.Practice = "live", false
+
+	e.mu.Lock()
+	out := e.filterRunnableTargetsLocked([]model.Target{practice, live})
+	st := e.states["p"]
+	e.mu.Unlock()
+	if len(out) != 1 || out[0].ID != "live" {
+		t.Fatalf("runnable = %+v, want only live target", out)
+	}
+	if st == nil || st.Status != model.TaskStatusConfigError {
+		t.Fatalf("practice target state = %+v, want config_error", st)
+	}
+}
+
+func TestPracticeThenLiveOnSameTarget(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	ctx := context.Background()
+	e.provider = buyProvider{}
+	e.practiceProvider = buyProvider{}
+	accounts, err := st.ListAccounts(ctx)
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("accounts = %+v, %v", accounts, err)
+	}
+	acc := accounts[0]
+
+	attempt := func(target model.Target) model.AttemptResult {
+		t.Helper()
+		qty, id, ok := e.tryReserveTarget(target)
+		if !ok {
+			t.Fatalf("reserve practice=%v: remaining quantity already reserved", target.Practice)
+		}
+		res := e.attemptWithAccount(ctx, target, acc, id)
+		e.finishReservedTarget(target, qty, id, res)
+		return res
+	}
+
+	practice := target
+	practice.Practice = true
+	if res := attempt(practice); !res.Success {
+		t.Fatalf("practice attempt: %+v", res)
+	}
+	e.mu.Lock()
+	state := *e.states[target.ID]
+	e.mu.Unlock()
+	if state.PurchasedQty != 0 || state.PracticeQty != 1 {
+		t.Fatalf("after practice: purchased = %d, practice = %d", state.PurchasedQty, state.PracticeQty)
+	}
+	if _, ok, err := st.GetOrderLedger(ctx, acc.ID, target.ID); err != nil || ok {
+		t.Fatalf("practice order should not be in the ledger: %v, %v", ok, err)
+	}
+
+	res := attempt(target)
+	if !res.Success {
+		t.Fatalf("live attempt after practice: %+v", res)
+	}
+	e.mu.Lock()
+	purchased := e.states[target.ID].PurchasedQty
+	e.mu.Unlock()
+	if purchased != 1 {
+		t.Fatalf("after live: purchased = %d", purchased)
+	}
+	if orderID, ok, err := st.GetOrderLedger(ctx, acc.ID, target.ID); err != nil || !ok || orderID != res.OrderID {
+		t.Fatalf("order ledger = %q, %v, %v", orderID, ok, err)
+	}
+}
+
+func TestPracticeTargetRefillsAfterResetAndRestart(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	ctx := context.Background()
+	e.provider = buyProvider{}
+	e.practiceProvider = buyProvider{}
+	accounts, err := st.ListAccounts(ctx)
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("accounts = %+v, %v", accounts, err)
+	}
+	acc := accounts[0]
+	practice := target
+	practice.Practice = true
+
+	fill := func(step string) {
+		t.Helper()
+		qty, id, ok := e.tryReserveTarget(practice)
+		if !ok {
+			t.Fatalf("%s: practice target should fire again", step)
+		}
+		res := e.attemptWithAccount(ctx, practice, acc, id)
+		e.finishReservedTarget(practice, qty, id, res)
+		if !res.Success {
+			t.Fatalf("%s: practice attempt: %+v", step, res)
+		}
+		if _, _, ok := e.tryReserveTarget(practice); ok {
+			t.Fatalf("%s: practice target should be filled", step)
+		}
+	}
+
+	fill("first run")
+	if _, err := e.ResetStats(); err != nil {
+		t.Fatalf("ResetStats: %v", err)
+	}
+	fill("after reset")
+
+	e.mu.Lock()
+	e.runCtx = ctx
+	e.registerTargetLocked(practice, e.now().UnixMilli())
+	e.mu.Unlock()
+	fill("after restart")
+}
+
+// mockSessionProvider 模拟 mock 服务：预下单换上 mock 的 cookie 和地址，下单被风控拒绝。
+type mockSessionProvider struct {
+	idleProvider
+}
+
+func mockSession(acc model.Account) model.Account {
+	acc.AddressID = 999
+	acc.DivisionIDs = "mock"
+	acc.Cookies = []model.CookieJarEntry{{URL: "http://mock.local", Cookies: []model.Cookie{{Name: "sid", Value: "mock"}}}}
+	return acc
+}
+
+func (mockSessionProvider) Preflight(ctx context.Context, acc model.Account, target model.Target) (provider.PreflightResult, model.Account, error) {
+	return provider.PreflightResult{AccountID: acc.ID, CanBuy: true, TotalFee: 100}, mockSession(acc), nil
+}
+
+func (mockSessionProvider) CreateOrder(ctx context.Context, attempt *provider.AttemptContext) (provider.CreateResult, model.Account, error) {
+	return provider.CreateResult{}, mockSession(attempt.Account()), errors.New("status 403 forbidden")
+}
+
+func TestPracticeAttemptLeavesLiveAccountUntouched(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	ctx := context.Background()
+	e.practiceProvider = mockSessionProvider{}
+	settings := e.NotifySettings()
+	settings.AccountCooldownFailures = 1
+	e.SetNotifySettings(settings)
+	accounts, err := st.ListAccounts(ctx)
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("accounts = %+v, %v", accounts, err)
+	}
+	acc := accounts[0]
+
+	practice := target
+	practice.Practice = true
+	qty, id, ok := e.tryReserveTarget(practice)
+	if !ok {
+		t.Fatal("reserve practice target")
+	}
+	res := e.attemptWithAccount(ctx, practice, acc, id)
+	e.finishReservedTarget(practice, qty, id, res)
+	if res.Success {
+		t.Fatalf("practice attempt should fail: %+v", res)
+	}
+
+	stored, err := st.GetAccount(ctx, acc.ID)
+	if err != nil {
+		t.Fatalf("GetAccount: %v", err)
+	}
+	if stored.AddressID != acc.AddressID || stored.DivisionIDs != acc.DivisionIDs || len(stored.Cookies) != len(acc.Cookies) {
+		t.Fatalf("stored account = %+v, want the live session kept", stored)
+	}
+	if e.accountCoolingDown(acc.ID) {
+		t.Fatal("mock failures should not put the live account into cooldown")
+	}
+	e.cooldownMu.Lock()
+	_, tracked := e.cooldowns[acc.ID]
+	e.cooldownMu.Unlock()
+	if tracked {
+		t.Fatal("mock failures should not count toward the live account cooldown")
+	}
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// rushSpinWindow 等待开抢时刻时最后自旋的时长：普通定时器在负载高时可能晚醒几十毫秒，
+// 开抢这一次总是先粗睡到 t-rushSpinWindow 再自旋（配置的 spinWaitMs 更大时以配置为准）。
+const rushSpinWindow = 30 * time.Millisecond
+
+// rushSpin 返回等待开抢时刻使用的自旋时长。
+func (e *Engine) rushSpin() time.Duration {
+	if spin := e.task.SpinWait(); spin > rushSpinWindow {
+		return spin
+	}
+	return rushSpinWindow
+}
+
+// sleepUntilPrecise 先用普通定时器睡到 t-spin，再自旋等待剩余时间；spin<=0 时等价于 sleepUntil。
+func (e *Engine) sleepUntilPrecise(ctx context.Context, t time.Time, spin time.Duration) bool {
+	if spin <= 0 {
+		return e.sleepUntil(ctx, t)
+	}
+	if !e.sleepUntil(ctx, t.Add(-spin)) {
+		return false
+	}
+	for e.now().Before(t) {
+		if ctx.Err() != nil {
+			return false
+		}
+		runtime.Gosched()
+	}
+	return true
+}
+
+// targetTicker 统一普通 ticker 和高精度 ticker 的使用方式。
+type targetTicker struct {
+	C    <-chan time.Time
+	stop func()
+}
+
+func (t *targetTicker) Stop() {
+	if t != nil && t.stop != nil {
+		t.stop()
+	}
+}
+
+// newTargetTicker 抢购模式且开启高精度计时时，按 start+n*interval 的绝对时间点触发（不累计漂移）；
+// 其他情况使用标准 time.Ticker。
+func (e *Engine) newTargetTicker(ctx context.Context, target model.Target, interval time.Duration) *targetTicker {
+	spin := e.task.SpinWait()
+	if target.Mode != model.TargetModeRush || spin <= 0 {
+		tk := e.clk().NewTicker(interval)
+		return &targetTicker{C: tk.C(), stop: tk.Stop}
+	}
+
+	ch := make(chan time.Time, 1)
+	tickCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		next := e.now().Add(interval)
+		for {
+			if !e.sleepUntilPrecise(tickCtx, next, spin) {
+				return
+			}
+			// 发送计划时刻（而不是醒来的时刻），接收方据此计算触发偏差。
+			select {
+			case ch <- next:
+			default:
+			}
+			next = next.Add(interval)
+			if now := e.now(); next.Before(now) {
+				next = now.Add(interval)
+			}
+		}
+	}()
+	return &targetTicker{C: ch, stop: cancel}
+}
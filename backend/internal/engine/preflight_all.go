@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sniping_engine/internal/model"
+)
+
+// PreflightAllRow 是批量预检中单个目标的结果；Error 非空表示该目标预检失败。
+type PreflightAllRow struct {
+	TargetID             string           `json:"targetId"`
+	Name                 string           `json:"name,omitempty"`
+	Mode                 model.TargetMode `json:"mode"`
+	RushAtMs             int64            `json:"rushAtMs,omitempty"`
+	CanBuy               bool             `json:"canBuy"`
+	NeedCaptcha          bool             `json:"needCaptcha"`
+	VerifyTokenAvailable bool             `json:"verifyTokenAvailable"`
+	TotalFee             int64            `json:"totalFee"`
+	TraceID              string           `json:"traceId,omitempty"`
+	Message              string           `json:"message,omitempty"`
+	RushAtCheck          *RushAtCheck     `json:"rushAtCheck,omitempty"`
+	Error                string           `json:"error,omitempty"`
+}
+
+// PreflightAllResult 是对全部已启用目标做一次预检的汇总，用于开抢前的就绪检查。
+type PreflightAllResult struct {
+	CheckedAtMs int64             `json:"checkedAtMs"`
+	Total       int               `json:"total"`
+	CanBuy      int               `json:"canBuy"`
+	NeedCaptcha int               `json:"needCaptcha"`
+	Failed      int               `json:"failed"`
+	Rows        []PreflightAllRow `json:"rows"`
+}
+
+// PreflightAll 依次对每个已启用目标执行 PreflightOnce（与单个预检一样占用账号并等待限流），
+// 单个目标失败不影响其它目标；ctx 结束后剩余目标记为失败。opID 非空时各目标的进度用 "<opID>-<序号>"。
+func (e *Engine) PreflightAll(ctx context.Context, opID string) (PreflightAllResult, error) {
+	if e == nil || e.store == nil {
+		return PreflightAllResult{}, errors.New("store unavailable")
+	}
+	targets, err := e.store.ListEnabledTargets(ctx)
+	if err != nil {
+		return PreflightAllResult{}, err
+	}
+
+	out := PreflightAllResult{CheckedAtMs: e.now().UnixMilli(), Rows: make([]PreflightAllRow, 0, len(targets))}
+	for i, t := range targets {
+		row := PreflightAllRow{TargetID: t.ID, Name: t.Name, Mode: t.Mode, RushAtMs: t.RushAtMs}
+		if err := ctx.Err(); err != nil {
+			row.Error = err.Error()
+		} else {
+			rowOpID := ""
+			if opID != "" {
+				rowOpID = fmt.Sprintf("%s-%d", opID, i+1)
+			}
+			res, err := e.PreflightOnce(ctx, t.ID, rowOpID, false)
+			if err != nil {
+				row.Error = err.Error()
+			} else {
+				row.CanBuy = res.CanBuy
+				row.NeedCaptcha = res.NeedCaptcha
+				row.VerifyTokenAvailable = res.VerifyTokenAvailable
+				row.TotalFee = res.TotalFee
+				row.TraceID = res.TraceID
+				row.Message = res.Message
+				row.RushAtCheck = res.RushAtCheck
+			}
+		}
+
+		out.Total++
+		switch {
+		case row.Error != "":
+			out.Failed++
+		case row.CanBuy:
+			out.CanBuy++
+		}
+		if row.Error == "" && row.NeedCaptcha {
+			out.NeedCaptcha++
+		}
+		out.Rows = append(out.Rows, row)
+	}
+	return out, nil
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// preflightTableProvider 按 SKU 返回预设的 render-order 结果，skuId=3 时报错。
+type preflightTableProvider struct {
+	provider.Provider
+}
+
+func (preflightTableProvider) Preflight(_ context.Context, acc model.Account, target model.Target) (provider.PreflightResult, model.Account, error) {
+	switch target.SKUID {
+	case 3:
+		return provider.PreflightResult{}, acc, errors.New("render failed")
+	case 2:
+		return provider.PreflightResult{CanBuy: true, NeedCaptcha: true, TotalFee: 200, AccountID: acc.ID}, acc, nil
+	}
+	return provider.PreflightResult{CanBuy: true, TotalFee: 100, AccountID: acc.ID}, acc, nil
+}
+
+func TestPreflightAllReportsEveryEnabledTarget(t *testing.T) {
+	e, st, first := newLifecycleEngine(t)
+	e.provider = preflightTableProvider{}
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		sku     int64
+		enabled bool
+	}{{2, true}, {3, true}, {4, false}} {
+		if _, err := st.UpsertTarget(ctx, model.Target{
+			Name:        "t",
+			ItemID:      1,
+			SKUID:       tc.sku,
+			Mode:        model.TargetModeRush,
+			RushAtMs:    time.Now().Add(time.Hour).UnixMilli(),
+			TargetQty:   1,
+			PerOrderQty: 1,
+			Enabled:     tc.enabled,
+		}); err != nil {
+			t.Fatalf("upsert target: %v", err)
+		}
+	}
+
+	res, err := e.PreflightAll(ctx, "")
+	if err != nil {
+		t.Fatalf("PreflightAll: %v", err)
+	}
+	if res.Total != 3 || res.CanBuy != 2 || res.NeedCaptcha != 1 || res.Failed != 1 || len(res.Rows) != 3 {
+		t.Fatalf("summary = %+v", res)
+	}
+	byID := make(map[string]PreflightAllRow)
+	for _, row := range res.Rows {
+		byID[row.TargetID] = row
+	}
+	if row := byID[first.ID]; !row.CanBuy || row.TotalFee != 100 || row.Error != "" {
+		t.Fatalf("first row = %+v", row)
+	}
+	for _, row := range res.Rows {
+		if row.TargetID != first.ID && row.Error == "" && row.TotalFee != 200 {
+			t.Fatalf("unexpected row %+v", row)
+		}
+	}
+}
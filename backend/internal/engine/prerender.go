@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// prerenderGrace 开抢后提前准备的下单参数仍可使用的宽限时间（超过后按常规流程重新 render）。
+const prerenderGrace = 5 * time.Second
+
+// prerenderMargin 提前预下单必须在开抢前这么久结束，避免占用账号锁拖慢开抢时刻的下单。
+const prerenderMargin = 200 * time.Millisecond
+
+// prerenderedOrder 开抢前准备好的下单参数：render 结果与验证码凭证，开抢时只需提交 create-order。
+type prerenderedOrder struct {
+	pre          provider.PreflightResult
+	renderedAtMs int64
+	captchaParam string
+	fromPool     bool
+}
+
+// prerenderBeforeRush 在 rushAt - prerenderSeconds 为每个已登录账号执行 render-order（需要时一并取好验证码），
+// 结果按账号暂存，开抢时刻的尝试直接取用。任何一步失败都只记日志，开抢时按常规流程处理。
+func (e *Engine) prerenderBeforeRush(ctx context.Context, target model.Target) {
+	if target.Mode != model.TargetModeRush || target.RushAtMs <= 0 || target.PrerenderSeconds <= 0 {
+		return
+	}
+	rushAt := e.rushStartAt(target)
+	if !e.sleepUntil(ctx, rushAt.Add(-time.Duration(target.PrerenderSeconds)*time.Second)) {
+		return
+	}
+	deadline := rushAt.Add(-prerenderMargin)
+	if !e.now().Before(deadline) {
+		return
+	}
+	prepCtx, cancel := context.WithTimeout(ctx, deadline.Sub(e.now()))
+	defer cancel()
+
+	e.mu.Lock()
+	accounts := append([]model.Account(nil), e.accounts...)
+	e.mu.Unlock()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		prepared int
+		captcha  int
+	)
+	for _, acc := range accounts {
+		wg.Add(1)
+		go func(acc model.Account) {
+			defer wg.Done()
+			p, ok := e.prerenderAccount(prepCtx, target, acc)
+			if !ok {
+				return
+			}
+			mu.Lock()
+			prepared++
+			if p.captchaParam != "" {
+				captcha++
+			}
+			mu.Unlock()
+		}(acc)
+	}
+	wg.Wait()
+
+	if e.bus != nil {
+		e.bus.Log("info", "提前预下单完成，开抢时直接提交订单", map[string]any{
+			"targetId":         target.ID,
+			"accounts":         len(accounts),
+			"prepared":         prepared,
+			"captchaPrepared":  captcha,
+			"prerenderSeconds": target.PrerenderSeconds,
+		})
+	}
+}
+
+func (e *Engine) prerenderAccount(ctx context.Context, target model.Target, acc model.Account) (prerenderedOrder, bool) {
+	if !e.acquireAccount(ctx, acc.ID) {
+		return prerenderedOrder{}, false
+	}
+	defer e.releaseAccount(acc.ID)
+
+	if e.store != nil {
+		if latest, err := e.store.GetAccount(ctx, acc.ID); err == nil {
+			acc = latest
+		}
+	}
+	if !e.waitTargetLimits(ctx, target, acc.ID) {
+		return prerenderedOrder{}, false
+	}
+	pre, updatedAcc, err := e.providerFor(target).Preflight(ctx, acc, target)
+	if !target.Practice {
+		e.observeUpstream(acc.ID, err)
+	}
+	if err != nil || !pre.CanBuy || len(pre.Render) == 0 {
+		if e.bus != nil && ctx.Err() == nil {
+			fields := map[string]any{"targetId": target.ID, "accountId": acc.ID, "canBuy": pre.CanBuy}
+			if err != nil {
+				fields["error"] = err.Error()
+			}
+			e.bus.Log("warn", "提前预下单未成功，开抢时按常规流程预下单", fields)
+		}
+		return prerenderedOrder{}, false
+	}
+	_ = e.persistTargetAccount(ctx, target, updatedAcc)
+
+	p := prerenderedOrder{pre: pre, renderedAtMs: e.now().UnixMilli()}
+	if captchaRequired(pre) {
+		param, src, err := e.captchaVerifyParamForOrder(ctx, updatedAcc, target, true)
+		// 提前求解的凭证即使开抢时没用上（过期或被丢弃）也已计费，计入目标的花费。
+		if src == captchaSolved {
+			e.noteCaptchaSolves(target.ID, 1)
+		}
+		if err != nil {
+			if e.bus != nil && ctx.Err() == nil {
+				e.bus.Log("warn", "提前获取验证码失败，开抢时重新获取", map[string]any{
+					"targetId":  target.ID,
+					"accountId": acc.ID,
+					"error":     err.Error(),
+				})
+			}
+		} else {
+			p.captchaParam, p.fromPool = param, src == captchaPooled
+		}
+	}
+
+	e.mu.Lock()
+	if e.prerendered == nil {
+		e.prerendered = make(map[string]prerenderedOrder)
+	}
+	e.prerendered[e.preflightCacheKey(acc.ID, target.ID)] = p
+	e.mu.Unlock()
+	return p, true
+}
+
+// takePrerendered 取出（并移除）该账号在该目标上提前准备的下单参数；只在开抢后的宽限时间内有效，每份只用一次。
+func (e *Engine) takePrerendered(accountID string, target model.Target, nowMs int64) (prerenderedOrder, bool) {
+	startMs := e.rushStartAt(target).UnixMilli()
+	if target.PrerenderSeconds <= 0 || nowMs < startMs {
+		return prerenderedOrder{}, false
+	}
+	key := e.preflightCacheKey(accountID, target.ID)
+	e.mu.Lock()
+	p, ok := e.prerendered[key]
+	if ok {
+		delete(e.prerendered, key)
+	}
+	e.mu.Unlock()
+	if !ok || nowMs > startMs+prerenderGrace.Milliseconds() {
+		return prerenderedOrder{}, false
+	}
+	return p, true
+}
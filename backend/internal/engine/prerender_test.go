@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// renderProvider 的 render-order 按 canBuy 返回是否可买，且总是需要验证码。
+type renderProvider struct {
+	provider.Provider
+	canBuy  bool
+	renders int
+}
+
+func (p *renderProvider) Preflight(_ context.Context, acc model.Account, _ model.Target) (provider.PreflightResult, model.Account, error) {
+	p.renders++
+	return provider.PreflightResult{CanBuy: p.canBuy, NeedCaptcha: true, Render: json.RawMessage(`{}`), AccountID: acc.ID}, acc, nil
+}
+
+func TestPrerenderedOrderIsUsedOnceAtRush(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	p := &renderProvider{canBuy: true}
+	e.provider = p
+	target := model.Target{
+		ID:                 "r",
+		Mode:               model.TargetModeRush,
+		RushAtMs:           fc.Now().Add(5 * time.Second).UnixMilli(),
+		PrerenderSeconds:   5,
+		CaptchaVerifyParam: "captcha",
+	}
+
+	got, ok := e.prerenderAccount(context.Background(), target, model.Account{ID: "a"})
+	if !ok || p.renders != 1 || got.captchaParam != "captcha" {
+		t.Fatalf("prerender = %+v, %v (renders %d); want render with captcha prepared", got, ok, p.renders)
+	}
+	if _, ok := e.takePrerendered("a", target, fc.Now().UnixMilli()); ok {
+		t.Fatal("prepared order must not be used before rushAt")
+	}
+
+	fc.Advance(5 * time.Second)
+	if _, ok := e.takePrerendered("a", target, fc.Now().UnixMilli()); !ok {
+		t.Fatal("prepared order should be available at rushAt")
+	}
+	if _, ok := e.takePrerendered("a", target, fc.Now().UnixMilli()); ok {
+		t.Fatal("prepared order must be used only once")
+	}
+
+	if _, ok := e.prerenderAccount(context.Background(), target, model.Account{ID: "a"}); !ok {
+		t.Fatal("second prerender failed")
+	}
+	fc.Advance(prerenderGrace + time.Second)
+	if _, ok := e.takePrerendered("a", target, fc.Now().UnixMilli()); ok {
+		t.Fatal("prepared order past the grace period must be discarded")
+	}
+}
+
+func TestPrerenderSkipsWhenNotPurchasable(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	e.provider = &renderProvider{canBuy: false}
+	target := model.Target{ID: "r", Mode: model.TargetModeRush, RushAtMs: fc.Now().UnixMilli(), PrerenderSeconds: 5}
+
+	if _, ok := e.prerenderAccount(context.Background(), target, model.Account{ID: "a"}); ok {
+		t.Fatal("render that cannot buy must not be kept")
+	}
+	if _, ok := e.takePrerendered("a", target, fc.Now().UnixMilli()); ok {
+		t.Fatal("nothing should be prepared")
+	}
+}
@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// withinPriceCap 检查预下单金额是否在目标的 MaxTotalFee 以内（未设置时不限制）。
+// render 没有带出金额（TotalFee<=0）时无法确认价格，同样放弃本次下单。
+func (e *Engine) withinPriceCap(target model.Target, accountID string, pre provider.PreflightResult) bool {
+	if target.MaxTotalFee <= 0 || (pre.TotalFee > 0 && pre.TotalFee <= target.MaxTotalFee) {
+		return true
+	}
+	if e.bus != nil {
+		e.bus.Log("warn", priceOverCapMessage(pre), map[string]any{
+			"targetId":    target.ID,
+			"accountId":   accountID,
+			"totalFee":    pre.TotalFee,
+			"maxTotalFee": target.MaxTotalFee,
+			"perOrderQty": target.PerOrderQty,
+			"traceId":     pre.TraceID,
+		})
+	}
+	return false
+}
+
+// priceOverCapMessage 是 withinPriceCap 拒绝下单时的说明。
+func priceOverCapMessage(pre provider.PreflightResult) string {
+	if pre.TotalFee <= 0 {
+		return "预下单未返回订单金额，无法确认价格，放弃下单"
+	}
+	return "订单金额超过上限，放弃下单"
+}
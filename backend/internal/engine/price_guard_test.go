@@ -0,0 +1,31 @@
+package engine
+
+import (
+	"testing"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+func TestPriceCapAbortsOverpricedOrUnknownFee(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	capped := model.Target{ID: "t", Mode: model.TargetModeRush, MaxTotalFee: 1000}
+
+	cases := []struct {
+		fee  int64
+		want bool
+	}{
+		{999, true},
+		{1000, true},
+		{1001, false},
+		{0, false},
+	}
+	for _, c := range cases {
+		if got := e.withinPriceCap(capped, "a", provider.PreflightResult{CanBuy: true, TotalFee: c.fee}); got != c.want {
+			t.Fatalf("withinPriceCap(fee=%d) = %v, want %v", c.fee, got, c.want)
+		}
+	}
+	if !e.withinPriceCap(model.Target{ID: "u"}, "a", provider.PreflightResult{CanBuy: true}) {
+		t.Fatal("targets without maxTotalFee must not be gated")
+	}
+}
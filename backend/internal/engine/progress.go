@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/logbus"
+	"sniping_engine/internal/model"
+)
+
+// opTimer 为一次操作的进度消息填写耗时：操作内的步骤串行执行，phase=start 的消息开启一个步骤，
+// 之后第一条非 start 消息视为该步骤结束并带上 PhaseMs。
+type opTimer struct {
+	startedAt time.Time
+	openAt    time.Time
+}
+
+func (e *Engine) newOpTimer() *opTimer {
+	return &opTimer{startedAt: e.now()}
+}
+
+func (t *opTimer) stamp(now time.Time, d *logbus.ProgressData) {
+	if t == nil {
+		return
+	}
+	d.ElapsedMs = now.Sub(t.startedAt).Milliseconds()
+	if d.Phase == "start" {
+		t.openAt = now
+		return
+	}
+	if !t.openAt.IsZero() {
+		if d.PhaseMs == 0 {
+			d.PhaseMs = now.Sub(t.openAt).Milliseconds()
+		}
+		t.openAt = time.Time{}
+	}
+}
+
+// progressEnabled 判断是否值得为 opId 构造进度消息：没有 opId 或没有在线订阅者时直接跳过，
+// 调用方应先检查它再拼装字段，避免无人监听时的分配与总线写入。
+func (e *Engine) progressEnabled(opID string) bool {
+	return e != nil && e.bus != nil && opID != "" && e.bus.HasSubscribers()
+}
+
+func (e *Engine) publishProgress(d logbus.ProgressData) {
+	if !e.progressEnabled(d.OpID) {
+		return
+	}
+	d.Step = strings.TrimSpace(d.Step)
+	d.Phase = strings.TrimSpace(d.Phase)
+	d.Message = strings.TrimSpace(d.Message)
+	if d.Fields == nil {
+		d.Fields = map[string]any{}
+	}
+	e.bus.Publish("progress", d)
+}
+
+// attemptProgress 推送下单尝试的阶段进度（render/验证码/下单各一条，结束时一条 done），
+// opId 为 "attempt-<runId>-<attemptId>"。没有订阅者时不产生任何消息。
+func (e *Engine) attemptProgress(res *model.AttemptResult, startedAt time.Time, step, phase string, phaseMs int64, message string) {
+	opID := fmt.Sprintf("attempt-%s-%d", res.RunID, res.ID)
+	if !e.progressEnabled(opID) {
+		return
+	}
+	d := logbus.ProgressData{
+		OpID:      opID,
+		Kind:      "attempt",
+		Step:      step,
+		Phase:     phase,
+		Message:   message,
+		TargetID:  res.TargetID,
+		AccountID: res.AccountID,
+		PhaseMs:   phaseMs,
+	}
+	if step == "done" {
+		d.Fields = map[string]any{"errorClass": string(res.ErrorClass), "latency": res.Latency}
+	}
+	(&opTimer{startedAt: startedAt}).stamp(e.now(), &d)
+	e.publishProgress(d)
+}
+
+// normalizeOpID 截断过长的 opId，与测试下单保持一致。
+func normalizeOpID(opID string) string {
+	opID = strings.TrimSpace(opID)
+	if len(opID) > 120 {
+		opID = opID[:120]
+	}
+	return opID
+}
@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"sniping_engine/internal/logbus"
+)
+
+func TestOpTimerStampsElapsedAndPhaseDuration(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	timer := e.newOpTimer()
+
+	stamp := func(step, phase string) logbus.ProgressData {
+		d := logbus.ProgressData{Step: step, Phase: phase}
+		timer.stamp(e.now(), &d)
+		return d
+	}
+
+	fc.Advance(5 * time.Millisecond)
+	if d := stamp("render_order", "start"); d.ElapsedMs != 5 || d.PhaseMs != 0 {
+		t.Fatalf("start: elapsed/phase = %d/%d, want 5/0", d.ElapsedMs, d.PhaseMs)
+	}
+	fc.Advance(40 * time.Millisecond)
+	if d := stamp("render_order", "success"); d.ElapsedMs != 45 || d.PhaseMs != 40 {
+		t.Fatalf("success: elapsed/phase = %d/%d, want 45/40", d.ElapsedMs, d.PhaseMs)
+	}
+	// 没有对应 start 的消息不带步骤耗时。
+	fc.Advance(10 * time.Millisecond)
+	if d := stamp("captcha_pool", "success"); d.ElapsedMs != 55 || d.PhaseMs != 0 {
+		t.Fatalf("unpaired: elapsed/phase = %d/%d, want 55/0", d.ElapsedMs, d.PhaseMs)
+	}
+}
@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+const (
+	// throttleObserveSeconds 被限流时回看多少秒的请求数，用来估计当时账号的实际 QPS。
+	throttleObserveSeconds = 10
+	// rateEstimateSafety 推荐值取观测到的限流 QPS 的比例，留出余量。
+	rateEstimateSafety = 0.7
+	rateEstimateMinQPS = 0.2
+	// rateEstimateStale 超过这段时间没有再被限流，之前的估计视为过期。
+	rateEstimateStale = 30 * time.Minute
+	// autoTuneCooldown 自动调参的最小间隔，避免一次限流风暴里反复下调。
+	autoTuneCooldown = 30 * time.Second
+	// rateEstimateMinRequests 没有被限流时，至少观测到这么多请求才认为当前 QPS 已验证可用。
+	rateEstimateMinRequests = 50
+)
+
+const (
+	RateEstimateBasisThrottled    = "throttled"
+	RateEstimateBasisClean        = "clean"
+	RateEstimateBasisInsufficient = "insufficient"
+)
+
+// throttleMarkers 识别上游限流/风控响应：HTTP 429 以及常见的“操作频繁”类提示。
+var throttleMarkers = []string{"status 429", "too many", "频繁", "限流", "繁忙", "稍后再试", "风控"}
+
+func isThrottleError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if _, class := provider.BizCodeOf(err); class == model.BizClassThrottle {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range throttleMarkers {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+type accountRateStats struct {
+	reqs           rollingCounter
+	requests       int64
+	throttles      int64
+	ceilingQPS     float64
+	lastThrottleMs int64
+}
+
+// RateEstimate 是根据上游限流响应推算出的账号 QPS 建议。
+// Basis：throttled=按观测到的限流点推算；clean=请求量足够且未被限流，沿用当前值；insufficient=样本不足。
+type RateEstimate struct {
+	RecommendedPerAccountQPS float64 `json:"recommendedPerAccountQps"`
+	CurrentPerAccountQPS     float64 `json:"currentPerAccountQps"`
+	Basis                    string  `json:"basis"`
+	ObservedRequests         int64   `json:"observedRequests"`
+	ThrottleEvents           int64   `json:"throttleEvents"`
+	ThrottledAccounts        int     `json:"throttledAccounts"`
+	LastThrottleMs           int64   `json:"lastThrottleMs,omitempty"`
+	AutoTune                 bool    `json:"autoTune"`
+	AutoTunedAtMs            int64   `json:"autoTunedAtMs,omitempty"`
+}
+
+// observeUpstream 记录一次账号级上游请求的结果；被限流时更新该账号的 QPS 上限估计，并按需自动调参。
+func (e *Engine) observeUpstream(accountID string, err error) {
+	if e == nil || accountID == "" {
+		return
+	}
+	now := e.now()
+	sec := now.Unix()
+
+	e.rateMu.Lock()
+	st := e.rateStats[accountID]
+	if st == nil {
+		st = &accountRateStats{}
+		e.rateStats[accountID] = st
+	}
+	st.requests++
+	st.reqs.add(sec)
+	if !isThrottleError(err) {
+		e.rateMu.Unlock()
+		return
+	}
+	st.throttles++
+	observed := float64(st.reqs.sumLast(sec, throttleObserveSeconds)) / throttleObserveSeconds
+	stale := st.lastThrottleMs > 0 && now.UnixMilli()-st.lastThrottleMs > rateEstimateStale.Milliseconds()
+	if st.ceilingQPS == 0 || stale || observed < st.ceilingQPS {
+		st.ceilingQPS = observed
+	}
+	st.lastThrottleMs = now.UnixMilli()
+	e.rateMu.Unlock()
+
+	if e.bus != nil {
+		e.bus.Log("warn", "上游限流", map[string]any{
+			"accountId":   accountID,
+			"observedQps": observed,
+			"error":       err.Error(),
+		})
+	}
+	e.maybeAutoTuneRate()
+}
+
+// RateEstimate 汇总所有账号的观测结果，给出推荐的 PerAccountQPS（取最保守账号的估计）。
+func (e *Engine) RateEstimate() RateEstimate {
+	cur := e.Limits()
+	out := RateEstimate{
+		CurrentPerAccountQPS: cur.PerAccountQPS,
+		AutoTune:             e.rateAutoTune.Load(),
+		AutoTunedAtMs:        e.rateAutoTunedMs.Load(),
+	}
+	nowMs := e.now().UnixMilli()
+
+	var ceiling float64
+	e.rateMu.Lock()
+	for _, st := range e.rateStats {
+		out.ObservedRequests += st.requests
+		out.ThrottleEvents += st.throttles
+		if st.lastThrottleMs > out.LastThrottleMs {
+			out.LastThrottleMs = st.lastThrottleMs
+		}
+		if st.lastThrottleMs == 0 || nowMs-st.lastThrottleMs > rateEstimateStale.Milliseconds() {
+			continue
+		}
+		out.ThrottledAccounts++
+		if ceiling == 0 || st.ceilingQPS < ceiling {
+			ceiling = st.ceilingQPS
+		}
+	}
+	e.rateMu.Unlock()
+
+	switch {
+	case out.ThrottledAccounts > 0:
+		out.Basis = RateEstimateBasisThrottled
+		rec := math.Floor(ceiling*rateEstimateSafety*10) / 10
+		if rec < rateEstimateMinQPS {
+			rec = rateEstimateMinQPS
+		}
+		out.RecommendedPerAccountQPS = rec
+	case out.ObservedRequests >= rateEstimateMinRequests:
+		out.Basis = RateEstimateBasisClean
+		out.RecommendedPerAccountQPS = cur.PerAccountQPS
+	default:
+		out.Basis = RateEstimateBasisInsufficient
+		out.RecommendedPerAccountQPS = cur.PerAccountQPS
+	}
+	return out
+}
+
+// SetRateAutoTune 开关自动调参：开启后被限流时自动把 PerAccountQPS 下调到推荐值（只降不升，且不写回设置）。
+func (e *Engine) SetRateAutoTune(enabled bool) {
+	if e == nil {
+		return
+	}
+	e.rateAutoTune.Store(enabled)
+}
+
+func (e *Engine) maybeAutoTuneRate() {
+	if !e.rateAutoTune.Load() {
+		return
+	}
+	est := e.RateEstimate()
+	if est.Basis != RateEstimateBasisThrottled || est.RecommendedPerAccountQPS >= est.CurrentPerAccountQPS {
+		return
+	}
+	nowMs := e.now().UnixMilli()
+	last := e.rateAutoTunedMs.Load()
+	if last > 0 && nowMs-last < autoTuneCooldown.Milliseconds() {
+		return
+	}
+	if !e.rateAutoTunedMs.CompareAndSwap(last, nowMs) {
+		return
+	}
+
+	next := e.Limits()
+	next.PerAccountQPS = est.RecommendedPerAccountQPS
+	e.ApplyLimits(next)
+	if e.bus != nil {
+		e.bus.Log("warn", "已根据上游限流自动下调账号 QPS", map[string]any{
+			"perAccountQPS":     est.RecommendedPerAccountQPS,
+			"prevPerAccountQPS": est.CurrentPerAccountQPS,
+			"throttledAccounts": est.ThrottledAccounts,
+		})
+	}
+}
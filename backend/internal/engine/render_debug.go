@@ -0,0 +1,37 @@
+package engine
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+
+	"sniping_engine/internal/logbus"
+)
+
+// renderDebugMaxBytes 预检调试返回的原始 render 报文上限，超出部分截断。
+const renderDebugMaxBytes = 64 << 10
+
+// renderDebugRedactKeys 在日志默认脱敏字段之外，再隐去收货人、手机号、地址等个人信息。
+var renderDebugRedactKeys = append(append([]string{}, logbus.DefaultRedactKeys...),
+	"mobile", "phone", "receiver", "address", "idCard", "verifyParam", "sign")
+
+// renderDebugPayload 返回脱敏后的 render 报文；超过上限时以截断后的字符串返回并标记 truncated。
+func renderDebugPayload(raw json.RawMessage) (json.RawMessage, bool) {
+	if len(raw) == 0 {
+		return nil, false
+	}
+	clean, err := logbus.RedactJSON(raw, renderDebugRedactKeys)
+	if err != nil {
+		// 无法解析的报文不原样透出，避免带出未脱敏的敏感值。
+		msg, _ := json.Marshal("render payload is not valid JSON: " + err.Error())
+		return msg, false
+	}
+	if len(clean) <= renderDebugMaxBytes {
+		return clean, false
+	}
+	cut := clean[:renderDebugMaxBytes]
+	for len(cut) > 0 && !utf8.Valid(cut) {
+		cut = cut[:len(cut)-1]
+	}
+	out, _ := json.Marshal(string(cut))
+	return out, true
+}
@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRenderDebugPayloadRedactsAndCaps(t *testing.T) {
+	raw := json.RawMessage(`{"data":{"canBuy":true,"receiverName":"张三","receiverMobile":"13800000000","address":{"detail":"某路 1 号"},"token":"tok"}}`)
+	out, truncated := renderDebugPayload(raw)
+	if truncated {
+		t.Fatal("small payload should not be truncated")
+	}
+	s := string(out)
+	for _, secret := range []string{"张三", "13800000000", "某路", "tok\""} {
+		if strings.Contains(s, secret) {
+			t.Fatalf("payload leaks %q: %s", secret, s)
+		}
+	}
+	if !strings.Contains(s, `"canBuy":true`) {
+		t.Fatalf("payload lost non-sensitive fields: %s", s)
+	}
+
+	big := json.RawMessage(`{"items":"` + strings.Repeat("商", renderDebugMaxBytes) + `"}`)
+	out, truncated = renderDebugPayload(big)
+	if !truncated {
+		t.Fatal("oversized payload should be truncated")
+	}
+	var text string
+	if err := json.Unmarshal(out, &text); err != nil {
+		t.Fatalf("truncated payload should be a JSON string: %v", err)
+	}
+	if len(text) > renderDebugMaxBytes {
+		t.Fatalf("truncated len = %d", len(text))
+	}
+
+	if out, _ := renderDebugPayload(json.RawMessage(`token=abc`)); strings.Contains(string(out), "abc") {
+		t.Fatalf("invalid JSON must not be echoed: %s", out)
+	}
+}
@@ -0,0 +1,245 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/config"
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// 预占数量的不变量：任何时刻 已购 + 预占 <= 目标数量，上游实际成交数量不超过目标数量，
+// 所有尝试结束后预占归零、已购等于上游成交数量。配合 go test -race 运行。
+
+// checkReservation 在持锁状态下检查不变量，返回目标当前的已购与预占数量。
+func checkReservation(t testing.TB, e *Engine, target model.Target) (purchased, reserved int) {
+	t.Helper()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if st := e.states[target.ID]; st != nil {
+		purchased = st.PurchasedQty
+	}
+	reserved = e.reserved[target.ID]
+	if reserved < 0 {
+		t.Fatalf("reserved = %d, must not be negative", reserved)
+	}
+	if purchased+reserved > target.TargetQty {
+		t.Fatalf("purchased %d + reserved %d exceeds targetQty %d", purchased, reserved, target.TargetQty)
+	}
+	return purchased, reserved
+}
+
+func TestReservationInvariantsUnderConcurrency(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		e := New(Options{})
+		target := model.Target{ID: "t", Mode: model.TargetModeRush, TargetQty: 7, PerOrderQty: 2}
+		rng := rand.New(rand.NewSource(seed))
+		outcomes := make([]bool, 200)
+		for i := range outcomes {
+			outcomes[i] = rng.Intn(3) == 0
+		}
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		next := 0
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					mu.Lock()
+					if next >= len(outcomes) {
+						mu.Unlock()
+						return
+					}
+					ok := outcomes[next]
+					next++
+					mu.Unlock()
+
+					qty, id, reserved := e.tryReserveTarget(target)
+					checkReservation(t, e, target)
+					if !reserved {
+						continue
+					}
+					e.finishReservedTarget(target, qty, id, model.AttemptResult{Success: ok})
+					checkReservation(t, e, target)
+				}
+			}()
+		}
+		wg.Wait()
+
+		purchased, reserved := checkReservation(t, e, target)
+		if reserved != 0 {
+			t.Fatalf("seed %d: reserved = %d after all attempts finished", seed, reserved)
+		}
+		if purchased != target.TargetQty {
+			t.Fatalf("seed %d: purchased = %d, want %d", seed, purchased, target.TargetQty)
+		}
+	}
+}
+
+// scriptedProvider 的 create-order 会阻塞到测试按脚本放行，以此控制并发尝试的完成顺序和成败。
+type scriptedProvider struct {
+	idleProvider
+
+	mu       sync.Mutex
+	pending  []*scriptedCall
+	ordered  int // 上游已成交的数量
+	orderSeq int
+}
+
+type scriptedCall struct {
+	qty    int
+	result chan bool
+}
+
+func (p *scriptedProvider) Preflight(_ context.Context, acc model.Account, _ model.Target) (provider.PreflightResult, model.Account, error) {
+	return provider.PreflightResult{CanBuy: true, TotalFee: 100, Render: json.RawMessage(`{}`), AccountID: acc.ID}, acc, nil
+}
+
+func (p *scriptedProvider) CreateOrder(ctx context.Context, attempt *provider.AttemptContext) (provider.CreateResult, model.Account, error) {
+	call := &scriptedCall{qty: attempt.Target().OrderLines()[0].Qty, result: make(chan bool, 1)}
+	p.mu.Lock()
+	p.pending = append(p.pending, call)
+	p.mu.Unlock()
+
+	var ok bool
+	select {
+	case ok = <-call.result:
+	case <-ctx.Done():
+		return provider.CreateResult{}, attempt.Account(), ctx.Err()
+	}
+	if !ok {
+		return provider.CreateResult{}, attempt.Account(), errors.New("create-order failed: scripted failure")
+	}
+	p.mu.Lock()
+	p.ordered += call.qty
+	p.orderSeq++
+	id := fmt.Sprintf("o%d", p.orderSeq)
+	p.mu.Unlock()
+	return provider.CreateResult{Success: true, OrderID: id}, attempt.Account(), nil
+}
+
+// release 按 pick 选出一个等待中的 create-order 并以 ok 放行；没有等待中的调用时返回 false。
+func (p *scriptedProvider) release(pick byte, ok bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.pending) == 0 {
+		return false
+	}
+	i := int(pick) % len(p.pending)
+	call := p.pending[i]
+	p.pending = append(p.pending[:i], p.pending[i+1:]...)
+	call.result <- ok
+	return true
+}
+
+func (p *scriptedProvider) orderedQty() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ordered
+}
+
+func (p *scriptedProvider) pendingCalls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pending)
+}
+
+func (e *Engine) inFlightUsed() int {
+	e.inFlight.mu.Lock()
+	defer e.inFlight.mu.Unlock()
+	return e.inFlight.used
+}
+
+// waitSettled 等到所有在途尝试要么阻塞在 create-order，要么已经结束。
+func waitSettled(t testing.TB, e *Engine, p *scriptedProvider) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for e.inFlightUsed() != p.pendingCalls() {
+		if time.Now().After(deadline) {
+			t.Fatalf("attempts did not settle: inFlight=%d pending=%d", e.inFlightUsed(), p.pendingCalls())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// runReservationScript 用 script 驱动整条抢购链路：每个字节决定是否再发起一轮尝试、放行哪个 create-order 以及成败。
+func runReservationScript(t testing.TB, script []byte, targetQty, perOrderQty int) *scriptedProvider {
+	t.Helper()
+	p := &scriptedProvider{}
+	e := New(Options{
+		Provider: p,
+		Limits: config.LimitsConfig{
+			MaxInFlight: 8, MaxPerTargetInFlight: 3,
+			GlobalQPS: 1e6, GlobalBurst: 1e6, PerAccountQPS: 1e6, PerAccountBurst: 1e6,
+		},
+	})
+	for i := 0; i < 4; i++ {
+		acc := model.Account{ID: fmt.Sprintf("a%d", i), Token: "x"}
+		e.accounts = append(e.accounts, acc)
+		e.ensureAccountLimiter(acc.ID)
+	}
+	target := model.Target{ID: "t", Mode: model.TargetModeRush, ItemID: 1, SKUID: 1, TargetQty: targetQty, PerOrderQty: perOrderQty, AllowMultiplePerAccount: true}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, b := range script {
+		if b&0x80 != 0 || p.pendingCalls() == 0 {
+			e.launchAttempts(ctx, target)
+			waitSettled(t, e, p)
+		}
+		if p.release(b>>1, b&1 == 1) {
+			waitSettled(t, e, p)
+		}
+		purchased, _ := checkReservation(t, e, target)
+		if got := p.orderedQty(); got > targetQty || got < purchased {
+			t.Fatalf("upstream ordered %d, purchased %d, targetQty %d", got, purchased, targetQty)
+		}
+	}
+	// 剩余的尝试全部失败收尾。
+	for p.release(0, false) {
+		waitSettled(t, e, p)
+	}
+	e.wg.Wait()
+
+	purchased, reserved := checkReservation(t, e, target)
+	if reserved != 0 {
+		t.Fatalf("reserved = %d after all attempts finished", reserved)
+	}
+	if got := p.orderedQty(); got != purchased {
+		t.Fatalf("upstream ordered %d but purchased %d", got, purchased)
+	}
+	return p
+}
+
+func TestReservationReachesTargetWithPartialLastOrder(t *testing.T) {
+	// 7 件、每单 2 件：前三单成功后只剩 1 件，最后一单应按 1 件下单，而不是永远买不满。
+	script := make([]byte, 12)
+	for i := range script {
+		script[i] = 1
+	}
+	p := runReservationScript(t, script, 7, 2)
+	if got := p.orderedQty(); got != 7 {
+		t.Fatalf("ordered = %d, want 7", got)
+	}
+}
+
+func FuzzReservationOrdering(f *testing.F) {
+	f.Add([]byte{0x81, 0x03, 0x05, 0x80, 0x01, 0x00, 0x82, 0x07, 0x01, 0x01})
+	f.Add([]byte{0x80, 0x00, 0x00, 0x00, 0x81, 0x01, 0x01, 0x01, 0x01, 0x01})
+	f.Add([]byte{0xff, 0xfe, 0xfd, 0xfc, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08})
+	f.Fuzz(func(t *testing.T, script []byte) {
+		if len(script) > 64 {
+			script = script[:64]
+		}
+		runReservationScript(t, script, 7, 2)
+	})
+}
@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const reservedDriftCheckInterval = 5 * time.Second
+
+// trackLiveAttemptLocked 登记一次占用了 qty 份额度的在途尝试，返回尝试编号。调用方需持有 e.mu。
+func (e *Engine) trackLiveAttemptLocked(targetID string, qty int) uint64 {
+	id := e.attemptSeq.Add(1)
+	m := e.liveAttempts[targetID]
+	if m == nil {
+		m = make(map[uint64]int)
+		e.liveAttempts[targetID] = m
+	}
+	m[id] = qty
+	return id
+}
+
+// untrackLiveAttemptLocked 注销在途尝试；已注销过则返回 false。调用方需持有 e.mu。
+func (e *Engine) untrackLiveAttemptLocked(targetID string, id uint64) bool {
+	m := e.liveAttempts[targetID]
+	if _, ok := m[id]; !ok {
+		return false
+	}
+	delete(m, id)
+	if len(m) == 0 {
+		delete(e.liveAttempts, targetID)
+	}
+	return true
+}
+
+// dropLiveAttempt 在尝试 goroutine 退出时兜底注销（正常情况下 finishReservedTarget 已注销）。
+// 如果走到这里说明结束路径被遗漏，对应的 reserved 会由漂移检查修正。
+func (e *Engine) dropLiveAttempt(targetID string, id uint64) {
+	e.mu.Lock()
+	missed := e.untrackLiveAttemptLocked(targetID, id)
+	e.mu.Unlock()
+	if missed && e.bus != nil {
+		e.bus.Log("warn", "抢购尝试未经过结算路径即退出", map[string]any{"targetId": targetID})
+	}
+}
+
+func (e *Engine) startReservedDriftChecker(ctx context.Context) {
+	e.registerLoop(LoopInfo{Key: loopKeyReservedDrift, Kind: LoopKindReservedDrift, Phase: loopPhaseRunning, IntervalMs: reservedDriftCheckInterval.Milliseconds()})
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		defer e.unregisterLoop(loopKeyReservedDrift)
+		ticker := e.clk().NewTicker(reservedDriftCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				outcome := "ok"
+				if n := e.checkReservedDrift(); n > 0 {
+					outcome = fmt.Sprintf("corrected %d", n)
+				}
+				e.recordLoopFire(loopKeyReservedDrift, outcome, reservedDriftCheckInterval)
+			}
+		}
+	}()
+}
+
+// checkReservedDrift 比对 reserved 与在途尝试实际占用的数量，不一致时记录日志并以在途数量为准修正。
+func (e *Engine) checkReservedDrift() int {
+	type drift struct {
+		targetID string
+		reserved int
+		live     int
+	}
+	var drifts []drift
+
+	e.mu.Lock()
+	live := make(map[string]int, len(e.liveAttempts))
+	for targetID, m := range e.liveAttempts {
+		for _, qty := range m {
+			live[targetID] += qty
+		}
+	}
+	for targetID, n := range e.reserved {
+		if n != live[targetID] {
+			drifts = append(drifts, drift{targetID: targetID, reserved: n, live: live[targetID]})
+		}
+	}
+	for targetID, n := range live {
+		if _, ok := e.reserved[targetID]; !ok && n != 0 {
+			drifts = append(drifts, drift{targetID: targetID, reserved: 0, live: n})
+		}
+	}
+	for _, d := range drifts {
+		if d.live == 0 {
+			delete(e.reserved, d.targetID)
+		} else {
+			e.reserved[d.targetID] = d.live
+		}
+	}
+	e.mu.Unlock()
+
+	if len(drifts) == 0 {
+		return 0
+	}
+	e.reservedDriftTotal.Add(int64(len(drifts)))
+	e.reservedDriftLastMs.Store(e.now().UnixMilli())
+	if e.bus != nil {
+		for _, d := range drifts {
+			e.bus.Log("warn", "预占数量与在途尝试不一致，已自动修正", map[string]any{
+				"targetId": d.targetID,
+				"reserved": d.reserved,
+				"inFlight": d.live,
+			})
+		}
+	}
+	return len(drifts)
+}
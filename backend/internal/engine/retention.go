@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// retentionPruneInterval 按保留期限清理的检查间隔。
+const retentionPruneInterval = time.Hour
+
+const (
+	maxRetentionDays     = 3650
+	maxRetentionLogHours = 24 * 30
+)
+
+// DefaultRetentionSettings 默认不按时间清理任何数据，与引入保留设置之前的行为一致。
+func DefaultRetentionSettings() model.RetentionSettings {
+	return model.RetentionSettings{}
+}
+
+func NormalizeRetentionSettings(in model.RetentionSettings) model.RetentionSettings {
+	clamp := func(v, max int) int {
+		if v < 0 {
+			return 0
+		}
+		if v > max {
+			return max
+		}
+		return v
+	}
+	out := in
+	out.OrdersDays = clamp(out.OrdersDays, maxRetentionDays)
+	out.AttemptsDays = clamp(out.AttemptsDays, maxRetentionDays)
+	out.SlowRequestsDays = clamp(out.SlowRequestsDays, maxRetentionDays)
+	out.TokenLifetimesDays = clamp(out.TokenLifetimesDays, maxRetentionDays)
+	out.DailyPurchasesDays = clamp(out.DailyPurchasesDays, maxRetentionDays)
+	out.LogsHours = clamp(out.LogsHours, maxRetentionLogHours)
+	return out
+}
+
+func (e *Engine) RetentionSettings() model.RetentionSettings {
+	if e == nil {
+		return DefaultRetentionSettings()
+	}
+	if s, ok := e.retentionSettings.Load().(model.RetentionSettings); ok {
+		return NormalizeRetentionSettings(s)
+	}
+	return DefaultRetentionSettings()
+}
+
+func (e *Engine) SetRetentionSettings(next model.RetentionSettings) model.RetentionSettings {
+	next = NormalizeRetentionSettings(next)
+	if e == nil {
+		return next
+	}
+	e.retentionSettings.Store(next)
+	return next
+}
+
+// PruneRetention 立即按当前保留设置清理过期的持久化数据与内存日志缓冲。
+func (e *Engine) PruneRetention(ctx context.Context) (model.RetentionPruneResult, error) {
+	if e == nil || e.store == nil {
+		return model.RetentionPruneResult{}, errors.New("store unavailable")
+	}
+	st := e.RetentionSettings()
+	now := e.now()
+	out, err := e.store.PruneRetention(ctx, st, now)
+	if err != nil {
+		return out, err
+	}
+	if st.LogsHours > 0 && e.bus != nil {
+		out.Logs = int64(e.bus.PruneBefore(now.Add(-time.Duration(st.LogsHours) * time.Hour).UnixMilli()))
+	}
+	if out.Total() > 0 && e.bus != nil {
+		e.bus.Log("info", "已按保留期限清理历史数据", map[string]any{
+			"orders":         out.Orders,
+			"attempts":       out.Attempts,
+			"slowRequests":   out.SlowRequests,
+			"tokenLifetimes": out.TokenLifetimes,
+			"dailyPurchases": out.DailyPurchases,
+			"logs":           out.Logs,
+		})
+	}
+	return out, nil
+}
+
+// StartRetentionPruner 启动按保留期限的定时清理（与引擎启停无关，随 ctx 结束）。
+func (e *Engine) StartRetentionPruner(ctx context.Context) {
+	if e == nil || e.store == nil {
+		return
+	}
+	e.registerLoop(LoopInfo{Key: loopKeyRetention, Kind: LoopKindRetention, Phase: loopPhaseRunning, IntervalMs: retentionPruneInterval.Milliseconds()})
+	go func() {
+		defer e.unregisterLoop(loopKeyRetention)
+		ticker := e.clk().NewTicker(retentionPruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				pruneCtx, cancel := context.WithTimeout(ctx, time.Minute)
+				outcome := "pruned"
+				if _, err := e.PruneRetention(pruneCtx); err != nil {
+					outcome = "error: " + err.Error()
+				}
+				cancel()
+				e.recordLoopFire(loopKeyRetention, outcome, retentionPruneInterval)
+			}
+		}
+	}()
+}
+
+// PrivacyWipe 一次性删除所有能关联到上游身份的数据（账号 token/cookie/手机号/地址、订单历史、尝试记录、
+// 外部凭据源中的凭据、演练录制文件等），保留目标配置与各项设置，用于把工具借给别人或下线服务器前。引擎运行中会先停止；整个过程持有 lifecycleMu，
+// 避免自动同步在清除过程中重新启动引擎。
+func (e *Engine) PrivacyWipe(ctx context.Context) (model.PrivacyWipeResult, error) {
+	if e == nil || e.store == nil {
+		return model.PrivacyWipeResult{}, errors.New("store unavailable")
+	}
+
+	e.lifecycleMu.Lock()
+	defer e.lifecycleMu.Unlock()
+
+	if e.Lifecycle() != model.EngineStopped {
+		if err := e.stopAllLocked(ctx); err != nil {
+			return model.PrivacyWipeResult{}, err
+		}
+	}
+	// 先等待积压的尝试记录写完，避免清除后又被写回。
+	if !e.FlushAttemptLog(ctx) {
+		return model.PrivacyWipeResult{}, ctx.Err()
+	}
+
+	out, err := e.store.WipePrivateData(ctx)
+	if err != nil {
+		return out, err
+	}
+	e.forgetAccounts()
+	e.forgetOrderSlots(func(_, _ string) bool { return true })
+	for _, p := range []provider.Provider{e.provider, e.practiceProvider} {
+		w, ok := p.(provider.CaptureWiper)
+		if !ok {
+			continue
+		}
+		path, err := w.WipeCapture()
+		if err != nil {
+			return out, fmt.Errorf("wipe capture file %s: %w", path, err)
+		}
+		if path != "" {
+			out.CaptureFiles = append(out.CaptureFiles, path)
+		}
+	}
+	if e.bus != nil {
+		out.Logs = int64(e.bus.PruneBefore(0))
+		e.bus.Log("warn", "已清除账号、订单与尝试记录等隐私数据", map[string]any{
+			"accounts": out.Accounts,
+			"orders":   out.Orders,
+			"attempts": out.Attempts,
+		})
+	}
+	return out, nil
+}
+
+// forgetAccounts 清空内存中按账号记录的状态（统计、冷却、额度、cookie 健康、token 提醒等）。
+func (e *Engine) forgetAccounts() {
+	e.mu.Lock()
+	e.accounts = nil
+	e.cookieHealth = make(map[string]CookieHealth)
+	e.tokenReminded = make(map[string]string)
+	e.mu.Unlock()
+
+	e.attemptsMu.Lock()
+	e.recentAttempts = nil
+	e.accountStats = make(map[string]*model.AccountStats)
+	e.attemptsMu.Unlock()
+
+	e.cooldownMu.Lock()
+	e.cooldowns = make(map[string]*accountCooldownState)
+	e.cooldownMu.Unlock()
+
+	e.quotaMu.Lock()
+	e.dailyUsage = make(map[string]accountDailyUsage)
+	e.quotaNoted = make(map[string]string)
+	e.quotaMu.Unlock()
+
+	e.rateMu.Lock()
+	e.rateStats = make(map[string]*accountRateStats)
+	e.rateMu.Unlock()
+}
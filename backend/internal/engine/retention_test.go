@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/clock"
+	"sniping_engine/internal/credentials"
+	"sniping_engine/internal/logbus"
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/store/sqlite"
+)
+
+func newRetentionEngine(t *testing.T) (*Engine, *sqlite.Store, *logbus.Bus) {
+	t.Helper()
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "engine.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	bus := logbus.New(100)
+	return New(Options{Store: st, Bus: bus, Clock: clock.NewFake(testStart)}), st, bus
+}
+
+func TestPruneRetentionDeletesExpiredRows(t *testing.T) {
+	e, st, _ := newRetentionEngine(t)
+	ctx := context.Background()
+	old := testStart.Add(-10 * 24 * time.Hour).UnixMilli()
+	recent := testStart.Add(-time.Hour).UnixMilli()
+	for _, at := range []int64{old, recent} {
+		if err := st.InsertOrder(ctx, model.OrderRecord{TargetID: "t1", AccountID: "a1", OrderID: "o", CreatedAtMs: at}); err != nil {
+			t.Fatalf("insert order: %v", err)
+		}
+	}
+
+	if res, err := e.PruneRetention(ctx); err != nil || res.Total() != 0 {
+		t.Fatalf("default settings should keep everything, got %+v, %v", res, err)
+	}
+
+	e.SetRetentionSettings(model.RetentionSettings{OrdersDays: 7})
+	res, err := e.PruneRetention(ctx)
+	if err != nil || res.Orders != 1 {
+		t.Fatalf("prune = %+v, %v", res, err)
+	}
+	left, _ := st.ListOrders(ctx, model.OrderQuery{})
+	if len(left) != 1 || left[0].CreatedAtMs != recent {
+		t.Fatalf("orders left = %+v", left)
+	}
+}
+
+func TestPrivacyWipeKeepsTargets(t *testing.T) {
+	e, st, bus := newRetentionEngine(t)
+	ctx := context.Background()
+	acc, err := st.UpsertAccount(ctx, model.Account{Mobile: "13800000000", Token: "tok", AddressID: 9})
+	if err != nil {
+		t.Fatalf("upsert account: %v", err)
+	}
+	target, err := st.UpsertTarget(ctx, model.Target{Name: "t", ItemID: 1, SKUID: 1, Mode: model.TargetModeScan, TargetQty: 1, PerOrderQty: 1})
+	if err != nil {
+		t.Fatalf("upsert target: %v", err)
+	}
+	e.orderCreated(ctx, target, acc, model.AttemptResult{ID: 1, Success: true, OrderID: "o-1", Quantity: 1})
+	e.noteDailyPurchase(ctx, acc.ID, 1)
+	bus.Log("info", "登录成功", map[string]any{"mobile": acc.Mobile})
+
+	res, err := e.PrivacyWipe(ctx)
+	if err != nil {
+		t.Fatalf("PrivacyWipe: %v", err)
+	}
+	if res.Accounts != 1 || res.Orders != 1 || res.DailyPurchases != 1 || res.Logs == 0 {
+		t.Fatalf("wipe result = %+v", res)
+	}
+	if accounts, _ := st.ListAccounts(ctx); len(accounts) != 0 {
+		t.Fatalf("accounts left = %+v", accounts)
+	}
+	if orders, _ := st.ListOrders(ctx, model.OrderQuery{}); len(orders) != 0 {
+		t.Fatalf("orders left = %+v", orders)
+	}
+	if _, err := st.GetTarget(ctx, target.ID); err != nil {
+		t.Fatalf("target should be kept: %v", err)
+	}
+	if got := e.AccountDailyPurchased(acc.ID); got != 0 {
+		t.Fatalf("daily usage cache not cleared: %d", got)
+	}
+}
+
+// memCredentials 是内存里的外部凭据源。
+type memCredentials map[string]credentials.Credentials
+
+func (memCredentials) Name() string         { return "mem" }
+func (memCredentials) Ref(id string) string { return "mem:" + id }
+func (m memCredentials) Load(ctx context.Context, id string) (credentials.Credentials, bool, error) {
+	c, ok := m[id]
+	return c, ok, nil
+}
+func (m memCredentials) Save(ctx context.Context, id string, c credentials.Credentials) error {
+	m[id] = c
+	return nil
+}
+func (m memCredentials) Delete(ctx context.Context, id string) error {
+	delete(m, id)
+	return nil
+}
+
+type captureProvider struct {
+	idleProvider
+	wiped int
+}
+
+func (p *captureProvider) WipeCapture() (string, error) {
+	p.wiped++
+	return "capture.jsonl", nil
+}
+
+func TestPrivacyWipeDeletesExternalCredentialsAndCapture(t *testing.T) {
+	e, st, _ := newRetentionEngine(t)
+	ctx := context.Background()
+	creds := memCredentials{}
+	st.SetCredentialSource(creds)
+	prov := &captureProvider{}
+	e.provider = prov
+	if _, err := st.UpsertAccount(ctx, model.Account{Mobile: "13800000000", Token: "tok"}); err != nil {
+		t.Fatalf("upsert account: %v", err)
+	}
+	if len(creds) != 1 {
+		t.Fatalf("credentials = %+v", creds)
+	}
+
+	res, err := e.PrivacyWipe(ctx)
+	if err != nil {
+		t.Fatalf("PrivacyWipe: %v", err)
+	}
+	if res.Credentials != 1 || len(creds) != 0 {
+		t.Fatalf("credentials not wiped: result=%+v left=%+v", res, creds)
+	}
+	if prov.wiped != 1 || len(res.CaptureFiles) != 1 || res.CaptureFiles[0] != "capture.jsonl" {
+		t.Fatalf("capture file not wiped: result=%+v calls=%d", res, prov.wiped)
+	}
+}
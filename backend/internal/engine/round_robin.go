@@ -0,0 +1,37 @@
+package engine
+
+import "sniping_engine/internal/model"
+
+// 抢购模式（NotifySettings.RushMode）。
+const (
+	RushModeConcurrent = "concurrent"
+	// RushModeRoundRobin 每个节拍（RoundRobinIntervalMs）只由一个账号发起尝试，账号按固定顺序依次轮换。
+	RushModeRoundRobin = "round_robin"
+)
+
+// roundRobinActive 判断目标当前是否按轮询模式抢购（扫货目标不受抢购模式影响）。
+func (e *Engine) roundRobinActive(target model.Target) bool {
+	return target.Mode == model.TargetModeRush && e.RushMode() == RushModeRoundRobin
+}
+
+// tryPickAndLockRoundRobin 按目标自己的游标依次选取下一个空闲、不在冷却中且未用完每日额度的账号，
+// 使同一目标的账号严格 A -> B -> C 轮换，不受其它目标共用的全局游标影响。
+func (e *Engine) tryPickAndLockRoundRobin(targetID string) (model.Account, bool) {
+	e.mu.Lock()
+	accounts := append([]model.Account(nil), e.accounts...)
+	start := e.rrCursors[targetID]
+	e.mu.Unlock()
+
+	for i := 0; i < len(accounts); i++ {
+		idx := (start + i) % len(accounts)
+		candidate := accounts[idx]
+		if e.accountCoolingDown(candidate.ID) || e.accountQuotaReached(candidate.ID) || !e.tryAcquireAccount(candidate.ID) {
+			continue
+		}
+		e.mu.Lock()
+		e.rrCursors[targetID] = idx + 1
+		e.mu.Unlock()
+		return candidate, true
+	}
+	return model.Account{}, false
+}
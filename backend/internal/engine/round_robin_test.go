@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"testing"
+
+	"sniping_engine/internal/model"
+)
+
+func TestRoundRobinRotatesAccountsPerTarget(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	e.SetNotifySettings(model.NotifySettings{RushMode: RushModeRoundRobin})
+	e.accounts = []model.Account{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	e.accountLocks = map[string]chan struct{}{
+		"a": make(chan struct{}, 1),
+		"b": make(chan struct{}, 1),
+		"c": make(chan struct{}, 1),
+	}
+
+	rush := model.Target{ID: "t1", Mode: model.TargetModeRush}
+	if !e.roundRobinActive(rush) || e.roundRobinActive(model.Target{ID: "s1", Mode: model.TargetModeScan}) {
+		t.Fatal("round robin should apply to rush targets only")
+	}
+
+	pick := func(targetID string) string {
+		acc, ok := e.tryPickAndLockRoundRobin(targetID)
+		if !ok {
+			t.Fatalf("no account picked for %s", targetID)
+		}
+		e.releaseAccount(acc.ID)
+		return acc.ID
+	}
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, pick("t1"))
+		// 另一个目标的轮换不应打乱 t1 的顺序。
+		pick("t2")
+	}
+	want := []string{"a", "b", "c", "a"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("t1 order = %v, want %v", got, want)
+		}
+	}
+
+	// 正被占用的账号会被跳过，轮到下一个。
+	e.tryAcquireAccount("b")
+	if id := pick("t1"); id != "c" {
+		t.Fatalf("busy account not skipped: got %s, want c", id)
+	}
+}
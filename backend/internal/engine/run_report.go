@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"sniping_engine/internal/logbus"
+	"sniping_engine/internal/model"
+)
+
+// TargetRunReport 汇总一个目标在一次运行批次（runId）中的全部记录，便于导出后分享排查。
+// 日志与尝试结果来自内存缓冲（日志环形缓冲、最近 maxRecentAttempts 条尝试），进程重启或被新记录挤出后不再包含。
+type TargetRunReport struct {
+	GeneratedAtMs int64                    `json:"generatedAtMs"`
+	RunID         string                   `json:"runId"`
+	Target        model.Target             `json:"target"`
+	State         *model.TaskState         `json:"state,omitempty"`
+	Attempts      []model.AttemptResult    `json:"attempts"`
+	Orders        []model.OrderLedgerEntry `json:"orders"`
+	Captcha       TargetCaptchaUsage       `json:"captcha"`
+	Cost          model.CostSummary        `json:"cost"`
+	Logs          []logbus.Message         `json:"logs"`
+}
+
+// TargetCaptchaUsage 是本批次尝试中的验证码使用统计。
+type TargetCaptchaUsage struct {
+	NeedCaptcha     int `json:"needCaptcha"`
+	FromPool        int `json:"fromPool"`
+	VerifyTokenUsed int `json:"verifyTokenUsed"`
+	Errors          int `json:"errors"`
+	PoolSize        int `json:"poolSize"`
+}
+
+// TargetRunReport 生成目标在 runID（为空时取当前批次）中的导出报告；尝试结果按时间先后排列。
+func (e *Engine) TargetRunReport(ctx context.Context, targetID, runID string) (TargetRunReport, error) {
+	targetID = strings.TrimSpace(targetID)
+	if e.store == nil {
+		return TargetRunReport{}, errors.New("store unavailable")
+	}
+	target, err := e.store.GetTarget(ctx, targetID)
+	if err != nil {
+		return TargetRunReport{}, err
+	}
+	runID = strings.TrimSpace(runID)
+	if runID == "" {
+		runID = e.RunID()
+	}
+	out := TargetRunReport{
+		GeneratedAtMs: e.now().UnixMilli(),
+		RunID:         runID,
+		Target:        target,
+		Attempts:      []model.AttemptResult{},
+		Logs:          []logbus.Message{},
+	}
+
+	e.mu.Lock()
+	if st := e.states[targetID]; st != nil {
+		cp := *st
+		out.State = &cp
+	}
+	e.mu.Unlock()
+
+	recent := e.RecentAttempts(targetID, 0)
+	for i := len(recent) - 1; i >= 0; i-- {
+		a := recent[i]
+		if a.RunID != runID {
+			continue
+		}
+		out.Attempts = append(out.Attempts, a)
+		out.Cost.Add(a)
+		if a.NeedCaptcha {
+			out.Captcha.NeedCaptcha++
+		}
+		if a.CaptchaFromPool {
+			out.Captcha.FromPool++
+		}
+		if a.VerifyTokenUsed {
+			out.Captcha.VerifyTokenUsed++
+		}
+		if a.ErrorClass == model.AttemptErrorCaptcha {
+			out.Captcha.Errors++
+		}
+	}
+	out.Captcha.PoolSize = e.CaptchaPoolStatus().Size
+	// 当前批次以任务状态里的汇总为准：它还包含不属于某次尝试的验证码求解（提前获取、测试下单），
+	// 也不受最近尝试缓冲长度的限制。
+	if runID == e.RunID() && out.State != nil && out.State.Cost != nil {
+		out.Cost = *out.State.Cost
+	}
+
+	if out.Orders, err = e.store.ListOrderLedger(ctx, targetID); err != nil {
+		return TargetRunReport{}, err
+	}
+	if out.Orders == nil {
+		out.Orders = []model.OrderLedgerEntry{}
+	}
+
+	if e.bus != nil {
+		for _, m := range e.bus.Snapshot() {
+			// 尝试结果已在 Attempts 中，任务状态推送只是计数快照，不重复导出。
+			if m.Type == "attempt_result" || m.Type == "task_state" {
+				continue
+			}
+			if m.RunID == runID && messageTargetID(m) == targetID {
+				out.Logs = append(out.Logs, m)
+			}
+		}
+	}
+	return out, nil
+}
+
+// messageTargetID 取总线消息关联的目标：日志看 fields.targetId，事件看数据中的 targetId 字段。
+func messageTargetID(m logbus.Message) string {
+	switch d := m.Data.(type) {
+	case logbus.LogData:
+		if id, ok := d.Fields["targetId"].(string); ok {
+			return id
+		}
+	case map[string]any:
+		if id, ok := d["targetId"].(string); ok {
+			return id
+		}
+	}
+	return ""
+}
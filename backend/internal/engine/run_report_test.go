@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"sniping_engine/internal/logbus"
+	"sniping_engine/internal/model"
+)
+
+func TestTargetRunReportFiltersByRunAndTarget(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	bus := logbus.New(100)
+	e.bus = bus
+	ctx := context.Background()
+
+	bus.SetRunID("run-1")
+	bus.Log("info", "预下单成功", map[string]any{"targetId": target.ID})
+	bus.Log("info", "其它目标", map[string]any{"targetId": "other"})
+	e.recordAttempt(model.AttemptResult{ID: 1, RunID: "run-1", TargetID: target.ID, NeedCaptcha: true, CaptchaFromPool: true, Success: true, OrderID: "o-1"})
+	e.recordAttempt(model.AttemptResult{ID: 2, RunID: "run-1", TargetID: "other"})
+	bus.SetRunID("run-2")
+	bus.Log("info", "下一批次", map[string]any{"targetId": target.ID})
+	e.recordAttempt(model.AttemptResult{ID: 3, RunID: "run-2", TargetID: target.ID})
+
+	if _, err := st.ClaimOrderLedger(ctx, "acc", target.ID, "o-1"); err != nil {
+		t.Fatalf("claim ledger: %v", err)
+	}
+
+	rep, err := e.TargetRunReport(ctx, target.ID, "run-1")
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if len(rep.Attempts) != 1 || rep.Attempts[0].ID != 1 {
+		t.Fatalf("attempts = %+v, want only attempt 1", rep.Attempts)
+	}
+	if len(rep.Logs) != 1 || rep.Logs[0].Data.(logbus.LogData).Msg != "预下单成功" {
+		t.Fatalf("logs = %+v, want only this target's run-1 log", rep.Logs)
+	}
+	if len(rep.Orders) != 1 || rep.Orders[0].OrderID != "o-1" {
+		t.Fatalf("orders = %+v", rep.Orders)
+	}
+	if rep.Captcha.NeedCaptcha != 1 || rep.Captcha.FromPool != 1 {
+		t.Fatalf("captcha usage = %+v", rep.Captcha)
+	}
+}
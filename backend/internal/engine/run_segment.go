@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"sniping_engine/internal/model"
+)
+
+// beginRunLocked 开启新的运行批次：生成 runId 并同步给日志总线，之后的日志/订单都会带上它。
+// 调用方需持有 e.mu。
+func (e *Engine) beginRunLocked() string {
+	e.runID = uuid.NewString()
+	e.runStartedMs = e.now().UnixMilli()
+	e.runCost = model.CostSummary{}
+	if e.bus != nil {
+		e.bus.SetRunID(e.runID)
+	}
+	return e.runID
+}
+
+func (e *Engine) RunID() string {
+	if e == nil {
+		return ""
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.runID
+}
+
+// ErrStatsResetBusy 表示还有任务在运行或持有未完成的下单预占，此时重置已购数量可能导致超买。
+var ErrStatsResetBusy = errors.New("targets are running or holding reservations, stop them before resetting stats")
+
+// ResetStats 清空任务统计（已购数量、最近错误/尝试时间等）并开启新的运行批次，
+// 用于正式开抢前把测试下单的数据和真实数据分开。有任务在运行或持有预占时拒绝重置，返回 ErrStatsResetBusy。
+func (e *Engine) ResetStats() (string, error) {
+	if e == nil {
+		return "", nil
+	}
+	e.mu.Lock()
+	for _, st := range e.states {
+		if st.Running {
+			e.mu.Unlock()
+			return "", ErrStatsResetBusy
+		}
+	}
+	for _, n := range e.reserved {
+		if n > 0 {
+			e.mu.Unlock()
+			return "", ErrStatsResetBusy
+		}
+	}
+	runID := e.beginRunLocked()
+	for _, st := range e.states {
+		st.PurchasedQty = 0
+		st.PracticeQty = 0
+		st.LastError = ""
+		st.LastAttemptMs = 0
+		st.LastSuccessMs = 0
+		resetTaskCounters(st)
+		e.publishStateLocked(*st)
+	}
+	e.taskRates = make(map[string]*taskRateWindow)
+	e.preflightCache = make(map[string]preflightCacheEntry)
+	e.preflightBackoff = make(map[string]preflightBackoffState)
+	e.mu.Unlock()
+	e.reservedDriftTotal.Store(0)
+	e.reservedDriftLastMs.Store(0)
+	e.resetAccountStats()
+	e.clearSavedTaskStates(context.Background())
+
+	if e.bus != nil {
+		e.bus.Log("info", "统计已重置，开始新的运行批次", map[string]any{"runId": runID})
+	}
+	return runID, nil
+}
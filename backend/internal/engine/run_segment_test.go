@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"sniping_engine/internal/model"
+)
+
+func TestResetStatsRefusedWhileTargetsBusy(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	e.states["t1"] = &model.TaskState{TargetID: "t1", Running: true, PurchasedQty: 2}
+
+	if _, err := e.ResetStats(); !errors.Is(err, ErrStatsResetBusy) {
+		t.Fatalf("running target: err = %v", err)
+	}
+	if e.states["t1"].PurchasedQty != 2 {
+		t.Fatalf("purchased qty should be kept, got %d", e.states["t1"].PurchasedQty)
+	}
+
+	e.states["t1"].Running = false
+	e.reserved["t1"] = 1
+	if _, err := e.ResetStats(); !errors.Is(err, ErrStatsResetBusy) {
+		t.Fatalf("live reservation: err = %v", err)
+	}
+
+	e.reserved["t1"] = 0
+	runID, err := e.ResetStats()
+	if err != nil || runID == "" {
+		t.Fatalf("idle reset: %q, %v", runID, err)
+	}
+	if e.states["t1"].PurchasedQty != 0 {
+		t.Fatalf("purchased qty after reset = %d", e.states["t1"].PurchasedQty)
+	}
+}
@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/notify"
+)
+
+// RushAtCheck 是目标 rushAtMs 与商品数据中实际开售时间的对比结果。
+type RushAtCheck struct {
+	TargetID    string `json:"targetId"`
+	RushAtMs    int64  `json:"rushAtMs"`
+	SaleStartMs int64  `json:"saleStartMs,omitempty"`
+	// DiffMs = SaleStartMs - RushAtMs，正数表示实际开售比配置晚。
+	DiffMs      int64 `json:"diffMs,omitempty"`
+	Known       bool  `json:"known"`
+	Stale       bool  `json:"stale"`
+	CheckedAtMs int64 `json:"checkedAtMs"`
+}
+
+func rushAtCheckApplicable(target model.Target) bool {
+	return target.Mode == model.TargetModeRush && target.RushAtMs > 0 && target.CategoryID > 0 && target.SKUID > 0
+}
+
+// checkRushAt 用商品列表探测拿到开售时间并与 rushAtMs 对比；调用方负责账号占用与限流。
+func (e *Engine) checkRushAt(ctx context.Context, acc model.Account, target model.Target) (RushAtCheck, error) {
+	out := RushAtCheck{TargetID: target.ID, RushAtMs: target.RushAtMs, CheckedAtMs: e.now().UnixMilli()}
+	if !rushAtCheckApplicable(target) || e.providerFor(target) == nil {
+		return out, nil
+	}
+	res, updated, err := e.providerFor(target).ProbeStock(ctx, acc, target)
+	if err != nil {
+		return out, err
+	}
+	if e.store != nil {
+		_ = e.persistTargetAccount(ctx, target, updated)
+	}
+	if res.SaleStartMs <= 0 {
+		return out, nil
+	}
+
+	out.Known = true
+	out.SaleStartMs = res.SaleStartMs
+	out.DiffMs = res.SaleStartMs - target.RushAtMs
+	threshold := int64(e.NotifySettings().RushAtDriftWarnSeconds) * 1000
+	diff := out.DiffMs
+	if diff < 0 {
+		diff = -diff
+	}
+	out.Stale = diff > threshold
+	if out.Stale {
+		e.alertStaleRushAt(ctx, target, out)
+	} else {
+		e.mu.Lock()
+		delete(e.rushAtAlerted, target.ID)
+		e.mu.Unlock()
+	}
+	return out, nil
+}
+
+// alertStaleRushAt 同一组 (rushAtMs, saleStartMs) 只告警一次，避免每次预检都重复发邮件。
+func (e *Engine) alertStaleRushAt(ctx context.Context, target model.Target, chk RushAtCheck) {
+	key := fmt.Sprintf("%d|%d", chk.RushAtMs, chk.SaleStartMs)
+	e.mu.Lock()
+	if e.rushAtAlerted[target.ID] == key {
+		e.mu.Unlock()
+		return
+	}
+	e.rushAtAlerted[target.ID] = key
+	e.mu.Unlock()
+
+	fields := map[string]any{
+		"targetId":    target.ID,
+		"targetName":  target.Name,
+		"rushAtMs":    chk.RushAtMs,
+		"rushAt":      model.FormatTimeMs(chk.RushAtMs),
+		"saleStartMs": chk.SaleStartMs,
+		"saleStartAt": model.FormatTimeMs(chk.SaleStartMs),
+		"diffSeconds": chk.DiffMs / 1000,
+	}
+	if e.bus != nil {
+		e.bus.Log("error", "开抢时间与商品实际开售时间不一致，请检查 rushAtMs", fields)
+	}
+	if e.notifier != nil {
+		e.notifier.NotifyAlert(ctx, notify.AlertEvent{
+			At:       chk.CheckedAtMs,
+			Title:    "开抢时间可能已过期",
+			Message:  fmt.Sprintf("目标「%s」配置的开抢时间与商品开售时间相差 %d 秒", target.Name, chk.DiffMs/1000),
+			TargetID: target.ID,
+			Fields:   fields,
+		})
+	}
+}
+
+// checkRushAtBeforeRush 在等待开抢前做一次就绪检查，只记录日志/告警，不影响抢购流程。
+func (e *Engine) checkRushAtBeforeRush(ctx context.Context, target model.Target) {
+	if !rushAtCheckApplicable(target) {
+		return
+	}
+	e.mu.Lock()
+	nAccounts := len(e.accounts)
+	e.mu.Unlock()
+	if nAccounts == 0 {
+		return
+	}
+	acc, ok := e.tryPickAndLockAccount(nAccounts)
+	if !ok {
+		return
+	}
+	defer e.releaseAccount(acc.ID)
+	if !e.waitLimits(ctx, acc.ID) {
+		return
+	}
+	if _, err := e.checkRushAt(ctx, acc, target); err != nil && e.bus != nil {
+		e.bus.Log("debug", "开售时间检查失败", map[string]any{"targetId": target.ID, "error": err.Error()})
+	}
+}
@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"context"
+
+	"sniping_engine/internal/model"
+)
+
+// scanTick 扫货模式的单次触发：开启探测时先用商品列表接口查库存，
+// 明确无货就跳过 render/create；每 ScanFullEvery 次仍强制走完整流程，避免探测结果滞后导致漏单。
+func (e *Engine) scanTick(ctx context.Context, target model.Target, tick uint64) string {
+	st := e.NotifySettings()
+	if !st.ScanProbeEnabled || target.CategoryID <= 0 {
+		return e.launchAttempts(ctx, target)
+	}
+	if st.ScanFullEvery > 0 && tick%uint64(st.ScanFullEvery) == 0 {
+		return e.launchAttempts(ctx, target)
+	}
+	acc, proceed, outcome := e.probeTargetInStock(ctx, target)
+	if !proceed {
+		return outcome
+	}
+	return e.launchAttemptsWith(ctx, target, acc)
+}
+
+// probeTargetInStock 用一个账号探测库存。有货、探测失败或结果未知时 proceed 为 true（放行完整流程），
+// 此时探测成功的账号仍处于占用状态，交给 launchAttemptsWith 直接下单，避免探测和下单各占一个账号打乱轮换；
+// 探测失败时账号已释放，acc 为空。proceed 为 false 时 outcome 说明跳过的原因（无货、没有空闲账号、限速等待被取消）。
+func (e *Engine) probeTargetInStock(ctx context.Context, target model.Target) (model.Account, bool, string) {
+	e.mu.Lock()
+	nAccounts := len(e.accounts)
+	e.mu.Unlock()
+	if nAccounts == 0 {
+		return model.Account{}, false, "no logged-in accounts"
+	}
+
+	acc, ok := e.tryPickAndLockAccount(nAccounts)
+	if !ok {
+		return model.Account{}, false, "probe: all accounts busy"
+	}
+	handedOff := false
+	defer func(id string) {
+		if !handedOff {
+			e.releaseAccount(id)
+		}
+	}(acc.ID)
+
+	if !e.waitLimits(ctx, acc.ID) {
+		return model.Account{}, false, "probe: limiter wait canceled"
+	}
+
+	res, updated, err := e.providerFor(target).ProbeStock(ctx, acc, target)
+	e.observeTargetOutcome(target, acc.ID, err)
+	if err != nil {
+		if e.bus != nil {
+			e.bus.Log("debug", "库存探测失败，改走完整流程", map[string]any{
+				"targetId":  target.ID,
+				"accountId": acc.ID,
+				"error":     err.Error(),
+			})
+		}
+		// 出错的账号可能进入冷却，由下单流程另挑账号。
+		return model.Account{}, true, ""
+	}
+	if e.store != nil {
+		_ = e.persistTargetAccount(ctx, target, updated)
+	}
+	if updated.ID == acc.ID {
+		acc = updated
+	}
+	if !res.Known {
+		handedOff = true
+		return acc, true, ""
+	}
+	if res.InStock <= 0 {
+		if e.bus != nil {
+			e.bus.Log("debug", "库存探测无货，跳过本次下单", map[string]any{
+				"targetId":  target.ID,
+				"accountId": acc.ID,
+			})
+		}
+		return model.Account{}, false, "probe: not in stock"
+	}
+	if need := minStockFor(target); need > 0 && res.InStock < need {
+		if e.bus != nil {
+			e.bus.Log("debug", "库存探测低于门槛，跳过本次下单", map[string]any{
+				"targetId":  target.ID,
+				"accountId": acc.ID,
+				"inStock":   res.InStock,
+				"minStock":  need,
+			})
+		}
+		return model.Account{}, false, "probe: below min stock"
+	}
+	if e.bus != nil {
+		e.bus.Log("info", "库存探测有货", map[string]any{
+			"targetId":  target.ID,
+			"accountId": acc.ID,
+			"inStock":   res.InStock,
+		})
+	}
+	handedOff = true
+	return acc, true, ""
+}
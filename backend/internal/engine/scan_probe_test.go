@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"sniping_engine/internal/config"
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// probeProvider 按配置返回库存探测结果，并记录探测和预下单用的账号；预下单一律失败，让尝试尽快结束。
+type probeProvider struct {
+	idleProvider
+	result provider.ProbeResult
+	err    error
+
+	mu         sync.Mutex
+	probed     []string
+	preflights []string
+}
+
+func (p *probeProvider) ProbeStock(ctx context.Context, acc model.Account, target model.Target) (provider.ProbeResult, model.Account, error) {
+	p.mu.Lock()
+	p.probed = append(p.probed, acc.ID)
+	p.mu.Unlock()
+	return p.result, acc, p.err
+}
+
+func (p *probeProvider) Preflight(ctx context.Context, acc model.Account, target model.Target) (provider.PreflightResult, model.Account, error) {
+	p.mu.Lock()
+	p.preflights = append(p.preflights, acc.ID)
+	p.mu.Unlock()
+	return provider.PreflightResult{}, acc, errors.New("preflight stub")
+}
+
+func (p *probeProvider) calls() (probed, preflights []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.probed...), append([]string(nil), p.preflights...)
+}
+
+func newScanProbeEngine(p *probeProvider) (*Engine, model.Target) {
+	e := New(Options{
+		Provider: p,
+		Limits:   config.LimitsConfig{GlobalQPS: 1e6, GlobalBurst: 1e6, PerAccountQPS: 1e6, PerAccountBurst: 1e6},
+	})
+	for _, id := range []string{"a1", "a2"} {
+		e.accounts = append(e.accounts, model.Account{ID: id, Token: "x"})
+		e.ensureAccountLimiter(id)
+	}
+	settings := e.NotifySettings()
+	settings.ScanProbeEnabled = true
+	settings.ScanFullEvery = 5
+	e.SetNotifySettings(settings)
+	target := model.Target{ID: "t", Mode: model.TargetModeScan, ItemID: 1, SKUID: 1, CategoryID: 9, TargetQty: 1, PerOrderQty: 1, AllowMultiplePerAccount: true}
+	return e, target
+}
+
+func TestScanTickProbeOutcomes(t *testing.T) {
+	cases := []struct {
+		name        string
+		result      provider.ProbeResult
+		err         error
+		wantOutcome string
+		wantLaunch  bool
+	}{
+		{name: "in stock", result: provider.ProbeResult{Known: true, InStock: 3}, wantOutcome: "launched 1", wantLaunch: true},
+		{name: "out of stock", result: provider.ProbeResult{Known: true}, wantOutcome: "probe: not in stock"},
+		{name: "unknown", result: provider.ProbeResult{}, wantOutcome: "launched 1", wantLaunch: true},
+		{name: "error", err: errors.New("probe failed"), wantOutcome: "launched 1", wantLaunch: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &probeProvider{result: tc.result, err: tc.err}
+			e, target := newScanProbeEngine(p)
+			if got := e.scanTick(context.Background(), target, 1); got != tc.wantOutcome {
+				t.Fatalf("outcome = %q, want %q", got, tc.wantOutcome)
+			}
+			e.wg.Wait()
+			probed, preflights := p.calls()
+			if len(probed) != 1 {
+				t.Fatalf("probe calls = %v, want 1", probed)
+			}
+			if launched := len(preflights) == 1; launched != tc.wantLaunch {
+				t.Fatalf("preflights = %v, want launch=%v", preflights, tc.wantLaunch)
+			}
+			// 探测和下单结束后账号都应释放。
+			for _, id := range []string{"a1", "a2"} {
+				if !e.tryAcquireAccount(id) {
+					t.Fatalf("account %s still held", id)
+				}
+				e.releaseAccount(id)
+			}
+		})
+	}
+}
+
+func TestScanTickHandsProbedAccountToLaunch(t *testing.T) {
+	p := &probeProvider{result: provider.ProbeResult{Known: true, InStock: 1}}
+	e, target := newScanProbeEngine(p)
+	for tick := uint64(1); tick <= 3; tick++ {
+		if got := e.scanTick(context.Background(), target, tick); got != "launched 1" {
+			t.Fatalf("tick %d outcome = %q", tick, got)
+		}
+		e.wg.Wait()
+		probed, _ := p.calls()
+		attempts := e.RecentAttempts(target.ID, 1)
+		if len(probed) != int(tick) || len(attempts) != 1 {
+			t.Fatalf("tick %d: probed = %v, attempts = %+v", tick, probed, attempts)
+		}
+		if got, want := attempts[0].AccountID, probed[len(probed)-1]; got != want {
+			t.Fatalf("tick %d probed with %s but attempted with %s", tick, want, got)
+		}
+	}
+}
+
+func TestScanTickForcesFullFlowEveryN(t *testing.T) {
+	p := &probeProvider{result: provider.ProbeResult{Known: true}}
+	e, target := newScanProbeEngine(p)
+	outcomes := make([]string, 0, 5)
+	for tick := uint64(1); tick <= 5; tick++ {
+		outcomes = append(outcomes, e.scanTick(context.Background(), target, tick))
+		e.wg.Wait()
+	}
+	probed, preflights := p.calls()
+	if len(probed) != 4 || len(preflights) != 1 {
+		t.Fatalf("probed = %v, preflights = %v; want 4 probes and 1 forced full run", probed, preflights)
+	}
+	if outcomes[3] != "probe: not in stock" || outcomes[4] != "launched 1" {
+		t.Fatalf("outcomes = %q", outcomes)
+	}
+}
+
+func TestScanTickReportsBusyAndCanceledProbe(t *testing.T) {
+	p := &probeProvider{result: provider.ProbeResult{Known: true, InStock: 1}}
+	e, target := newScanProbeEngine(p)
+
+	e.tryAcquireAccount("a1")
+	e.tryAcquireAccount("a2")
+	if got := e.scanTick(context.Background(), target, 1); got != "probe: all accounts busy" {
+		t.Fatalf("busy outcome = %q", got)
+	}
+	e.releaseAccount("a1")
+	e.releaseAccount("a2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := e.scanTick(ctx, target, 1); got != "probe: limiter wait canceled" {
+		t.Fatalf("canceled outcome = %q", got)
+	}
+	if probed, _ := p.calls(); len(probed) != 0 {
+		t.Fatalf("probe should not run, got %v", probed)
+	}
+	if !e.tryAcquireAccount("a1") || !e.tryAcquireAccount("a2") {
+		t.Fatal("canceled probe should release its account")
+	}
+}
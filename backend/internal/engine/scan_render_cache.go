@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// preflightCacheTTLFor 返回目标的 render 复用时长：扫货目标按 scanRenderCacheMs（长时间扫货时每次触发只需下单，
+// 不必重复 render-order），其它目标沿用 preflightCacheTTL。
+func (e *Engine) preflightCacheTTLFor(target model.Target) time.Duration {
+	if target.Mode != model.TargetModeScan {
+		return preflightCacheTTL
+	}
+	return time.Duration(e.NotifySettings().ScanRenderCacheMs) * time.Millisecond
+}
+
+// dropRenderAfterCreate 在扫货目标下单结束后决定是否丢弃缓存的 render：
+// 下单成功（render 已用掉）或失败原因不是售罄/限流（render 可能已失效）时丢弃，下一次触发重新 render。
+func (e *Engine) dropRenderAfterCreate(target model.Target, accountID string, err error) {
+	if target.Mode != model.TargetModeScan {
+		return
+	}
+	if err != nil {
+		switch _, class := provider.BizCodeOf(err); class {
+		case model.BizClassSoldOut, model.BizClassThrottle:
+			return
+		}
+	}
+	e.clearCachedPreflight(accountID, target.ID)
+}
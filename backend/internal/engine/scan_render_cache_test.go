@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+func TestScanTargetsReuseRenderAcrossTicks(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	scan := model.Target{ID: "s", Mode: model.TargetModeScan}
+	rush := model.Target{ID: "r", Mode: model.TargetModeRush}
+	pre := provider.PreflightResult{CanBuy: true, Render: json.RawMessage(`{}`), AccountID: "a"}
+
+	renderedAt := fc.Now().UnixMilli()
+	e.setCachedPreflight("a", scan.ID, pre, renderedAt)
+	e.setCachedPreflight("a", rush.ID, pre, renderedAt)
+	fc.Advance(4 * time.Second)
+	nowMs := fc.Now().UnixMilli()
+
+	if _, _, ok := e.getCachedPreflight("a", scan.ID, nowMs, e.preflightCacheTTLFor(scan)); !ok {
+		t.Fatal("scan render should still be reused after 4s (default 5s)")
+	}
+	if _, _, ok := e.getCachedPreflight("a", rush.ID, nowMs, e.preflightCacheTTLFor(rush)); ok {
+		t.Fatal("rush render must keep the 3s TTL")
+	}
+
+	soldOut := &provider.BizCodeError{API: "create-order", Code: "1001", Class: model.BizClassSoldOut, Message: "sold out"}
+	e.dropRenderAfterCreate(scan, "a", soldOut)
+	if _, _, ok := e.getCachedPreflight("a", scan.ID, nowMs, e.preflightCacheTTLFor(scan)); !ok {
+		t.Fatal("sold-out create failure should keep the render for the next tick")
+	}
+	e.dropRenderAfterCreate(scan, "a", errors.New("render expired"))
+	if _, _, ok := e.getCachedPreflight("a", scan.ID, nowMs, e.preflightCacheTTLFor(scan)); ok {
+		t.Fatal("other create failures should drop the cached render")
+	}
+}
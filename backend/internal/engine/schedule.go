@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// scheduleWindow 计算定时启停：每个已启用抢购目标的运行窗口为 [rushAtMs-提前量, rushAtMs+窗口时长]，
+// 提前量取 scheduleLeadSeconds 与验证码池 warmupSeconds 中较大者，保证验证码池来得及预热。
+// 返回当前是否处于任一窗口内，以及下一个窗口的开始时间（没有则为 0）。
+// 有启用的扫货目标（或没有开抢时间的目标）时始终视为处于窗口内，扫货不受定时限制。
+func (e *Engine) scheduleWindow(targets []model.Target, nowMs int64) (active bool, nextStartMs int64) {
+	st := e.NotifySettings()
+	lead := time.Duration(st.ScheduleLeadSeconds) * time.Second
+	if e.captchaPool != nil {
+		if warmup := time.Duration(e.captchaPool.Settings().WarmupSeconds) * time.Second; warmup > lead {
+			lead = warmup
+		}
+	}
+	window := time.Duration(st.ScheduleWindowSeconds) * time.Second
+
+	for _, t := range targets {
+		if t.Mode != model.TargetModeRush || t.RushAtMs <= 0 {
+			return true, 0
+		}
+		startMs := t.RushAtMs - lead.Milliseconds()
+		endMs := t.RushAtMs + window.Milliseconds()
+		if nowMs >= startMs && nowMs < endMs {
+			active = true
+			continue
+		}
+		if startMs > nowMs && (nextStartMs == 0 || startMs < nextStartMs) {
+			nextStartMs = startMs
+		}
+	}
+	return active, nextStartMs
+}
+
+// applySchedule 在开启 scheduleEnabled 时由 AutoRunByStore 调用（持有 lifecycleMu）：
+// 窗口外不自动启动引擎，窗口结束后停止由自动同步启动的引擎；通过 StartAll 手动启动的运行不受影响。
+// 返回 handled=true 表示本轮已处理完毕，AutoRunByStore 不再继续同步。
+func (e *Engine) applySchedule(ctx context.Context, targets []model.Target) (handled bool, err error) {
+	active, nextStartMs := e.scheduleWindow(targets, e.now().UnixMilli())
+	running := e.IsRunning()
+	if active {
+		e.scheduleWaitMs = 0
+		if running {
+			return false, nil
+		}
+		if e.bus != nil {
+			e.bus.Log("info", "临近开抢，定时启动引擎", map[string]any{"targets": len(targets)})
+		}
+		return true, e.startAllLocked(ctx)
+	}
+
+	if running {
+		if e.manualRun {
+			return false, nil
+		}
+		if e.bus != nil {
+			e.bus.Log("info", "开抢窗口已结束，定时停止引擎", map[string]any{"nextStartAtMs": nextStartMs})
+		}
+		e.scheduleWaitMs = nextStartMs
+		return true, e.stopAllLocked(ctx)
+	}
+
+	if nextStartMs != e.scheduleWaitMs {
+		e.scheduleWaitMs = nextStartMs
+		if e.bus != nil && nextStartMs > 0 {
+			e.bus.Log("info", "定时启停：等待开抢窗口", map[string]any{"startAtMs": nextStartMs})
+		}
+	}
+	return true, nil
+}
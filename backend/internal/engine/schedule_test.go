@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/clock"
+	"sniping_engine/internal/model"
+)
+
+func TestScheduleStartsBeforeRushAndStopsOutsideWindow(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	fc := clock.NewFake(time.Now())
+	e.clock = fc
+	ctx := context.Background()
+
+	s := e.NotifySettings()
+	s.ScheduleEnabled = true
+	s.ScheduleLeadSeconds = 120
+	e.SetNotifySettings(s)
+
+	if err := e.AutoRunByStore(ctx); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if e.IsRunning() {
+		t.Fatalf("engine started an hour before rushAt")
+	}
+
+	fc.Advance(time.Hour - time.Minute)
+	if err := e.AutoRunByStore(ctx); err != nil {
+		t.Fatalf("sync in window: %v", err)
+	}
+	if !e.IsRunning() {
+		t.Fatalf("engine not started one minute before rushAt")
+	}
+
+	// 开抢时间推迟，当前不再处于任何窗口：自动启动的运行应被停止。
+	target.RushAtMs = fc.Now().Add(time.Hour).UnixMilli()
+	if _, err := st.UpsertTarget(ctx, target); err != nil {
+		t.Fatalf("update target: %v", err)
+	}
+	if err := e.AutoRunByStore(ctx); err != nil {
+		t.Fatalf("sync after move: %v", err)
+	}
+	if e.IsRunning() {
+		t.Fatalf("scheduled run not stopped outside window")
+	}
+
+	// 手动启动不受定时停止影响。
+	if err := e.StartAll(ctx); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if err := e.AutoRunByStore(ctx); err != nil {
+		t.Fatalf("sync after manual start: %v", err)
+	}
+	if !e.IsRunning() {
+		t.Fatalf("manual run stopped by schedule")
+	}
+}
+
+func TestScheduleWindowKeepsScanTargetsRunning(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	nowMs := e.now().UnixMilli()
+	rush := model.Target{ID: "r", Mode: model.TargetModeRush, RushAtMs: nowMs + 3600_000}
+
+	active, next := e.scheduleWindow([]model.Target{rush}, nowMs)
+	if active || next != rush.RushAtMs-120_000 {
+		t.Fatalf("active=%v next=%d, want inactive with start at rushAt-120s", active, next)
+	}
+	active, _ = e.scheduleWindow([]model.Target{rush, {ID: "s", Mode: model.TargetModeScan}}, nowMs)
+	if !active {
+		t.Fatalf("scan target should keep the engine active")
+	}
+}
@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// SessionBootstrapResult 是补全会话的结果：每一步的访问情况、账号现有的 cookie，以及仍缺少的关键 cookie。
+type SessionBootstrapResult struct {
+	AccountID string                   `json:"accountId"`
+	Steps     []provider.BootstrapStep `json:"steps"`
+	Cookies   []string                 `json:"cookies"`
+	// Missing 仍缺少的关键 cookie（draco_local 与 provider.criticalCookies）；为空表示可以参与开抢。
+	Missing   []string `json:"missing,omitempty"`
+	RushReady bool     `json:"rushReady"`
+}
+
+// BootstrapAccountSession 为只有 token 的账号访问上游入口页面与接口，补全登录流程中才会下发的 cookie 并保存。
+func (e *Engine) BootstrapAccountSession(ctx context.Context, accountID string) (SessionBootstrapResult, error) {
+	if e == nil || e.store == nil {
+		return SessionBootstrapResult{}, errors.New("store unavailable")
+	}
+	b, ok := e.provider.(provider.SessionBootstrapper)
+	if !ok {
+		return SessionBootstrapResult{}, errors.New("provider does not support session bootstrap")
+	}
+	acc, err := e.store.GetAccount(ctx, strings.TrimSpace(accountID))
+	if err != nil {
+		return SessionBootstrapResult{}, err
+	}
+	if strings.TrimSpace(acc.Token) == "" {
+		return SessionBootstrapResult{}, errors.New("account not logged in")
+	}
+
+	e.ensureAccountLimiter(acc.ID)
+	if !e.waitLimits(ctx, acc.ID) {
+		return SessionBootstrapResult{}, ctx.Err()
+	}
+	updated, steps, err := b.BootstrapSession(ctx, acc)
+	out := SessionBootstrapResult{AccountID: acc.ID, Steps: steps}
+	if err != nil {
+		return out, err
+	}
+	if err := e.persistAccount(ctx, updated); err != nil {
+		return out, err
+	}
+
+	out.Cookies = cookieNames(updated)
+	for _, name := range append([]string{dracoCookieName}, e.criticalCookies...) {
+		if !slices.Contains(out.Cookies, name) && !slices.Contains(out.Missing, name) {
+			out.Missing = append(out.Missing, name)
+		}
+	}
+	out.RushReady = len(out.Missing) == 0
+
+	nowMs := e.now().UnixMilli()
+	deadlineMs := e.cookieDeadlineMs(ctx, nowMs)
+	h := CookieHealth{AccountID: acc.ID, DeadlineMs: deadlineMs, CheckedAtMs: nowMs, RefreshedAtMs: nowMs}
+	h.Expiring, h.EarliestExpireMs = expiringCookies(updated, e.criticalCookies, deadlineMs)
+	e.setCookieHealth(h)
+
+	if e.bus != nil {
+		level := "info"
+		if !out.RushReady {
+			level = "warn"
+		}
+		e.bus.Log(level, "账号会话补全完成", map[string]any{
+			"accountId": acc.ID,
+			"cookies":   len(out.Cookies),
+			"missing":   out.Missing,
+		})
+	}
+	return out, nil
+}
+
+func cookieNames(acc model.Account) []string {
+	var names []string
+	for _, entry := range acc.Cookies {
+		for _, c := range entry.Cookies {
+			if !slices.Contains(names, c.Name) {
+				names = append(names, c.Name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// bootstrapProvider 补全会话时下发给定的 cookie。
+type bootstrapProvider struct {
+	idleProvider
+	cookies []string
+}
+
+func (p bootstrapProvider) BootstrapSession(_ context.Context, acc model.Account) (model.Account, []provider.BootstrapStep, error) {
+	entry := model.CookieJarEntry{URL: "https://example.com/"}
+	for _, name := range p.cookies {
+		entry.Cookies = append(entry.Cookies, model.Cookie{Name: name, Value: "v"})
+	}
+	acc.Cookies = []model.CookieJarEntry{entry}
+	return acc, []provider.BootstrapStep{{Path: "/", Status: 200, CookiesSet: p.cookies}}, nil
+}
+
+func TestBootstrapSessionPersistsCookiesAndReportsMissing(t *testing.T) {
+	e, st, _ := newLifecycleEngine(t)
+	ctx := context.Background()
+	accounts, err := st.ListAccounts(ctx)
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("list accounts: %v (%d)", err, len(accounts))
+	}
+	id := accounts[0].ID
+	e.criticalCookies = []string{"SESSION"}
+
+	e.provider = bootstrapProvider{cookies: []string{"acw_tc"}}
+	res, err := e.BootstrapAccountSession(ctx, id)
+	if err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+	if res.RushReady || len(res.Missing) != 2 {
+		t.Fatalf("missing = %v, want draco_local and SESSION", res.Missing)
+	}
+
+	e.provider = bootstrapProvider{cookies: []string{dracoCookieName, "SESSION"}}
+	res, err = e.BootstrapAccountSession(ctx, id)
+	if err != nil || !res.RushReady {
+		t.Fatalf("bootstrap = %+v, %v; want rush ready", res, err)
+	}
+	acc, err := st.GetAccount(ctx, id)
+	if err != nil {
+		t.Fatalf("get account: %v", err)
+	}
+	if extractDracoToken(acc) != "v" {
+		t.Fatalf("draco_local not persisted: %+v", acc.Cookies)
+	}
+}
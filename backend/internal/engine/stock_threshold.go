@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// minStockFor 返回目标生效的库存门槛；只对扫货目标生效，抢购目标开抢时库存通常尚未刷新。
+func minStockFor(target model.Target) int64 {
+	if target.Mode != model.TargetModeScan || target.MinStock <= 0 {
+		return 0
+	}
+	return target.MinStock
+}
+
+// meetsMinStock 判断预下单结果是否满足库存门槛；上游没有返回库存时视为不满足，宁可不下单。
+func (e *Engine) meetsMinStock(target model.Target, accountID string, pre provider.PreflightResult) bool {
+	need := minStockFor(target)
+	if need == 0 || (pre.StockKnown && pre.InStock >= need) {
+		return true
+	}
+	if e.bus != nil {
+		e.bus.Log("debug", "库存低于门槛，跳过本次下单", map[string]any{
+			"targetId":   target.ID,
+			"accountId":  accountID,
+			"minStock":   need,
+			"inStock":    pre.InStock,
+			"stockKnown": pre.StockKnown,
+		})
+	}
+	return false
+}
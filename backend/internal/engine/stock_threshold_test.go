@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"testing"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+func TestMinStockOnlyGatesScanTargets(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	scan := model.Target{ID: "s", Mode: model.TargetModeScan, MinStock: 5}
+
+	cases := []struct {
+		pre  provider.PreflightResult
+		want bool
+	}{
+		{provider.PreflightResult{CanBuy: true, StockKnown: true, InStock: 5}, true},
+		{provider.PreflightResult{CanBuy: true, StockKnown: true, InStock: 1}, false},
+		{provider.PreflightResult{CanBuy: true}, false},
+	}
+	for _, c := range cases {
+		if got := e.meetsMinStock(scan, "a", c.pre); got != c.want {
+			t.Fatalf("meetsMinStock(%+v) = %v, want %v", c.pre, got, c.want)
+		}
+	}
+
+	rush := model.Target{ID: "r", Mode: model.TargetModeRush, MinStock: 5}
+	if !e.meetsMinStock(rush, "a", provider.PreflightResult{CanBuy: true}) {
+		t.Fatal("rush targets must ignore minStock")
+	}
+	if !e.meetsMinStock(model.Target{ID: "s0", Mode: model.TargetModeScan}, "a", provider.PreflightResult{CanBuy: true}) {
+		t.Fatal("minStock=0 must not gate")
+	}
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// Store 是引擎用到的持久化接口，内置实现为 sqlite.Store；嵌入方可以换成自己的存储。
+type Store interface {
+	// 账号。
+	ListAccounts(ctx context.Context) ([]model.Account, error)
+	GetAccount(ctx context.Context, id string) (model.Account, error)
+	UpsertAccount(ctx context.Context, acc model.Account) (model.Account, error)
+	SetAccountValidation(ctx context.Context, id string, atMs int64, valid bool, errMsg string) error
+	DeleteAccountCredentials(ctx context.Context, id string) error
+	GetAccountDailyPurchase(ctx context.Context, accountID, day string) (int, error)
+	AddAccountDailyPurchase(ctx context.Context, accountID, day string, qty int) error
+
+	// 登录凭证有效期。
+	ListAccountTokens(ctx context.Context) ([]model.TokenExpiry, error)
+	ListTokenLifetimes(ctx context.Context) ([]int64, error)
+	RecordTokenExpired(ctx context.Context, accountID string, atMs int64) (bool, error)
+	SetTokenExpectedExpiry(ctx context.Context, accountID string, expectedMs int64) error
+
+	// 目标。
+	ListTargets(ctx context.Context) ([]model.Target, error)
+	ListEnabledTargets(ctx context.Context) ([]model.Target, error)
+	GetTarget(ctx context.Context, id string) (model.Target, error)
+	SetTargetEnabled(ctx context.Context, id string, enabled bool) error
+	ListArmDueTargets(ctx context.Context, nowMs int64) ([]model.Target, error)
+	ClearTargetArm(ctx context.Context, id string) error
+	MergeTargets(ctx context.Context, keepID string, sourceIDs []string) (model.TargetMergeResult, error)
+	ListCampaigns(ctx context.Context) ([]model.Campaign, error)
+
+	// 任务进度。
+	ListTaskStates(ctx context.Context) ([]model.TaskState, error)
+	UpsertTaskStates(ctx context.Context, states []model.TaskState) error
+	ClearTaskStates(ctx context.Context) error
+
+	// 尝试、订单与下单记录（每个账号每个目标一笔）。
+	InsertAttempts(ctx context.Context, results []model.AttemptResult) error
+	InsertOrder(ctx context.Context, rec model.OrderRecord) error
+	ClaimOrderLedger(ctx context.Context, accountID, targetID, orderID string) (bool, error)
+	GetOrderLedger(ctx context.Context, accountID, targetID string) (orderID string, ok bool, err error)
+	ListOrderLedger(ctx context.Context, targetID string) ([]model.OrderLedgerEntry, error)
+	UpdateOrderLedgerVerification(ctx context.Context, en model.OrderLedgerEntry) error
+
+	// 商品目录缓存。
+	ReplaceCatalogCategories(ctx context.Context, frontCategoryID int64, items []model.CatalogCategory) error
+	ReplaceCatalogSkus(ctx context.Context, frontCategoryID int64, items []model.CatalogSku) error
+
+	// 出口探测结果。
+	ListEgressProbes(ctx context.Context) ([]model.EgressProbe, error)
+	ReplaceEgressProbes(ctx context.Context, probes []model.EgressProbe) error
+
+	// 历史清理。
+	PruneRetention(ctx context.Context, r model.RetentionSettings, now time.Time) (model.RetentionPruneResult, error)
+	WipePrivateData(ctx context.Context) (model.PrivacyWipeResult, error)
+}
@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"context"
+
+	"sniping_engine/internal/model"
+)
+
+// armDueTargets 启用定时启用时间（armAtMs）已到的目标并发布 target_armed 事件；
+// 由 AutoRunByStore 在持有 lifecycleMu 时调用，随后的同步会把它们启动起来。
+// 启用失败（如配置不完整）时清除定时并在事件中带上原因，避免每轮重复尝试。
+func (e *Engine) armDueTargets(ctx context.Context) {
+	nowMs := e.now().UnixMilli()
+	due, err := e.store.ListArmDueTargets(ctx, nowMs)
+	if err != nil || len(due) == 0 {
+		return
+	}
+	for _, t := range due {
+		ev := model.TargetArmed{TargetID: t.ID, Name: t.Name, ArmAtMs: t.ArmAtMs, ArmedAtMs: nowMs}
+		if err := e.store.SetTargetEnabled(ctx, t.ID, true); err != nil {
+			ev.Error = err.Error()
+			_ = e.store.ClearTargetArm(ctx, t.ID)
+		}
+		if e.bus == nil {
+			continue
+		}
+		if ev.Error != "" {
+			e.bus.Log("warn", "定时启用任务失败", map[string]any{"targetId": t.ID, "armAtMs": t.ArmAtMs, "error": ev.Error})
+		} else {
+			e.bus.Log("info", "任务已按定时启用", map[string]any{"targetId": t.ID, "armAtMs": t.ArmAtMs})
+		}
+		e.bus.Publish("target_armed", ev)
+	}
+}
@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAutoRunByStoreArmsDueTargets(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	ctx := context.Background()
+
+	// 已启用但定时启用时间未到：不应启动。
+	target.ArmAtMs = time.Now().Add(30 * time.Minute).UnixMilli()
+	saved, err := st.UpsertTarget(ctx, target)
+	if err != nil {
+		t.Fatalf("upsert target: %v", err)
+	}
+	if err := e.AutoRunByStore(ctx); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if e.IsRunning() {
+		t.Fatalf("engine started before armAtMs")
+	}
+
+	// 停用且定时启用时间已到：引擎应自动启用、清除定时并启动。
+	saved.Enabled = false
+	saved.ArmAtMs = time.Now().Add(-time.Second).UnixMilli()
+	if _, err := st.UpsertTarget(ctx, saved); err != nil {
+		t.Fatalf("upsert target: %v", err)
+	}
+	if err := e.AutoRunByStore(ctx); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	got, err := st.GetTarget(ctx, saved.ID)
+	if err != nil {
+		t.Fatalf("get target: %v", err)
+	}
+	if !got.Enabled || got.ArmAtMs != 0 {
+		t.Fatalf("after arm: enabled=%v armAtMs=%d, want enabled and cleared", got.Enabled, got.ArmAtMs)
+	}
+	waitFor(t, "armed target loop", func() bool { return targetLoopCount(e) == 1 })
+}
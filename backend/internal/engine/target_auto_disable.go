@@ -56,9 +56,22 @@ func (e *Engine) disableTarget(targetID string, reason string, fields map[string
 		cancel()
 	}
 
+	shouldStop := e.detachTarget(targetID)
+	e.recalcCaptchaPoolActivateAtMs()
+
+	if shouldStop {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			_ = e.stopIfIdle(ctx)
+			cancel()
+		}()
+	}
+}
+
+// detachTarget 取消目标的运行上下文并把它从运行列表中移除，返回此后引擎是否已没有运行中的目标。
+func (e *Engine) detachTarget(targetID string) (idle bool) {
 	var cancel context.CancelFunc
-	shouldStop := false
-	nowMs := time.Now().UnixMilli()
+	nowMs := e.now().UnixMilli()
 
 	e.mu.Lock()
 	if e.targetCancels != nil {
@@ -84,20 +97,11 @@ func (e *Engine) disableTarget(targetID string, reason string, fields map[string
 		st.LastAttemptMs = nowMs
 		e.publishStateLocked(*st)
 	}
-	shouldStop = e.running && (e.targetCancels == nil || len(e.targetCancels) == 0)
+	idle = e.lifecycle == model.EngineRunning && len(e.targetCancels) == 0
 	e.mu.Unlock()
 
 	if cancel != nil {
 		cancel()
 	}
-
-	e.recalcCaptchaPoolActivateAtMs()
-
-	if shouldStop {
-		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			_ = e.StopAll(ctx)
-			cancel()
-		}()
-	}
+	return idle
 }
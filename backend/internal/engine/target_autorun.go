@@ -3,7 +3,6 @@ package engine
 import (
 	"context"
 	"errors"
-	"time"
 
 	"sniping_engine/internal/model"
 )
@@ -14,30 +13,47 @@ func (e *Engine) IsRunning() bool {
 	}
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	return e.running
+	return e.lifecycle == model.EngineRunning
 }
 
 // AutoRunByStore 根据数据库中“已启用任务”自动启动/停止引擎，并在引擎运行中动态同步任务列表。
 // 目标：
 // - 单个商品开关打开：无需点“开启全部”，也会生效并开始抢购/预热
 // - 运行中启用/停用任务：无需重启引擎
+// - 开启 scheduleEnabled 时只在开抢窗口内自动启动，窗口结束后自动停止（见 applySchedule）
+// 整个过程持有 lifecycleMu，与 StartAll/StopAll 串行；数据库状态不变时重复调用不会产生任何变化。
 func (e *Engine) AutoRunByStore(ctx context.Context) error {
 	if e == nil || e.store == nil {
 		return errors.New("store unavailable")
 	}
+	e.maybeCheckCookieHealth(ctx)
+	e.restoreTaskStates(ctx)
+
+	e.lifecycleMu.Lock()
+	defer e.lifecycleMu.Unlock()
+
+	e.armDueTargets(ctx)
 	enabledTargets, err := e.store.ListEnabledTargets(ctx)
 	if err != nil {
 		return err
 	}
+	e.mu.Lock()
+	enabledTargets = e.filterRunnableTargetsLocked(enabledTargets)
+	e.mu.Unlock()
 	if len(enabledTargets) == 0 {
 		if e.IsRunning() {
-			_ = e.StopAll(ctx)
+			_ = e.stopAllLocked(ctx)
 		}
 		return nil
 	}
 
+	if e.NotifySettings().ScheduleEnabled {
+		if handled, err := e.applySchedule(ctx, enabledTargets); handled {
+			return err
+		}
+	}
 	if !e.IsRunning() {
-		return e.StartAll(ctx)
+		return e.startAllLocked(ctx)
 	}
 
 	e.SyncEnabledTargets(enabledTargets)
@@ -58,14 +74,15 @@ func (e *Engine) SyncEnabledTargets(enabledTargets []model.Target) {
 	var cancels []context.CancelFunc
 	var starts []startItem
 
-	nowMs := time.Now().UnixMilli()
+	nowMs := e.now().UnixMilli()
 
 	e.mu.Lock()
-	if !e.running || e.runCtx == nil {
+	if e.lifecycle != model.EngineRunning || e.runCtx == nil {
 		e.mu.Unlock()
 		return
 	}
 
+	enabledTargets = e.filterRunnableTargetsLocked(enabledTargets)
 	enabledMap := make(map[string]model.Target, len(enabledTargets))
 	for _, t := range enabledTargets {
 		if t.ID == "" {
@@ -95,7 +112,7 @@ func (e *Engine) SyncEnabledTargets(enabledTargets []model.Target) {
 
 		// 2) 配置变更：重启该目标 goroutine（避免“抢购时间/模式变了但不生效”）
 		if prev, ok := e.targetSnapshots[id]; ok {
-			if !prev.UpdatedAt.Equal(next.UpdatedAt) {
+			if !prev.UpdatedAt.Equal(next.UpdatedAt.Time) {
 				cancels = append(cancels, cancel)
 				delete(e.targetCancels, id)
 				delete(e.targetSnapshots, id)
@@ -108,22 +125,7 @@ func (e *Engine) SyncEnabledTargets(enabledTargets []model.Target) {
 		if _, ok := e.targetCancels[id]; ok {
 			continue
 		}
-		targetCtx, targetCancel := context.WithCancel(e.runCtx)
-		e.targetCancels[id] = targetCancel
-		e.targetSnapshots[id] = t
-
-		st := e.states[id]
-		if st == nil {
-			st = &model.TaskState{TargetID: id, Running: true, TargetQty: t.TargetQty}
-			e.states[id] = st
-		} else {
-			st.Running = true
-			st.TargetQty = t.TargetQty
-		}
-		st.LastAttemptMs = nowMs
-		e.publishStateLocked(*st)
-
-		starts = append(starts, startItem{ctx: targetCtx, target: t})
+		starts = append(starts, startItem{ctx: e.registerTargetLocked(t, nowMs), target: t})
 	}
 	e.mu.Unlock()
 
@@ -134,11 +136,36 @@ func (e *Engine) SyncEnabledTargets(enabledTargets []model.Target) {
 	}
 
 	for _, s := range starts {
-		e.wg.Add(1)
-		go func(si startItem) {
-			defer e.wg.Done()
-			e.runTarget(si.ctx, si.target)
-		}(s)
+		e.spawnTarget(s.ctx, s.target)
 	}
 }
 
+// registerTargetLocked 为目标创建运行上下文并把状态标记为运行中，返回的 ctx 交给 spawnTarget。
+// 调用方需持有 e.mu，且引擎处于运行状态（e.runCtx 非空）。
+func (e *Engine) registerTargetLocked(t model.Target, nowMs int64) context.Context {
+	targetCtx, targetCancel := context.WithCancel(e.runCtx)
+	e.targetCancels[t.ID] = targetCancel
+	e.targetSnapshots[t.ID] = t
+
+	st := e.taskStateLocked(t, true)
+	st.Running = true
+	st.TargetQty = t.TargetQty
+	st.Status = ""
+	st.StatusReason = ""
+	st.ConsecutiveFailures = 0
+	st.AttemptBudget = t.MaxAttempts
+	st.BudgetUsed = 0
+	// 演练件数只对本次运行有效，重新启动后演练目标从头再来一遍。
+	st.PracticeQty = 0
+	st.LastAttemptMs = nowMs
+	e.publishStateLocked(*st)
+	return targetCtx
+}
+
+func (e *Engine) spawnTarget(ctx context.Context, t model.Target) {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		e.runTarget(ctx, t)
+	}()
+}
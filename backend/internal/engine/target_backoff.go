@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// 目标级风控退避：预下单/下单遇到限流或风控时逐级放慢该目标（拉长触发间隔、减少并发账号数），
+// 一段时间没有再遇到时逐级恢复，避免按固定节拍持续撞风控。
+const (
+	targetBackoffMaxLevel = 4
+	// targetBackoffDecay 多久没有再遇到限流/风控，退避等级下降一级。
+	targetBackoffDecay = 10 * time.Second
+)
+
+// 上游响应的风控分类。
+const (
+	riskKindThrottle    = "throttle"
+	riskKindRiskControl = "risk_control"
+)
+
+// riskControlMarkers 识别风控拦截（区别于单纯的频率限制）。
+var riskControlMarkers = []string{"风控", "risk", "安全验证", "行为异常"}
+
+// classifyRiskResponse 返回上游错误的风控分类；不属于限流/风控时返回空。
+func classifyRiskResponse(err error) string {
+	if err == nil {
+		return ""
+	}
+	if _, class := provider.BizCodeOf(err); class == model.BizClassRiskControl {
+		return riskKindRiskControl
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range riskControlMarkers {
+		if strings.Contains(msg, m) {
+			return riskKindRiskControl
+		}
+	}
+	if isThrottleError(err) {
+		return riskKindThrottle
+	}
+	return ""
+}
+
+type targetBackoffState struct {
+	level     int
+	lastHitMs int64
+	lastFire  int64
+}
+
+// decayLocked 按距离上次命中的时间降低等级；调用方需持有 e.mu。
+func (st *targetBackoffState) decayLocked(nowMs int64) {
+	if st.level <= 0 || st.lastHitMs <= 0 {
+		return
+	}
+	steps := int((nowMs - st.lastHitMs) / targetBackoffDecay.Milliseconds())
+	if steps <= 0 {
+		return
+	}
+	st.level -= steps
+	if st.level < 0 {
+		st.level = 0
+	}
+	st.lastHitMs += int64(steps) * targetBackoffDecay.Milliseconds()
+}
+
+// noteTargetRisk 在预下单/下单失败后调用：限流/风控响应会提升目标的退避等级。
+func (e *Engine) noteTargetRisk(targetID string, err error) {
+	kind := classifyRiskResponse(err)
+	if e == nil || targetID == "" || kind == "" {
+		return
+	}
+	nowMs := e.now().UnixMilli()
+
+	e.mu.Lock()
+	st := e.targetBackoff[targetID]
+	if st == nil {
+		st = &targetBackoffState{}
+		e.targetBackoff[targetID] = st
+	}
+	st.decayLocked(nowMs)
+	prev := st.level
+	if st.level < targetBackoffMaxLevel {
+		st.level++
+	}
+	st.lastHitMs = nowMs
+	level := st.level
+	if ts := e.states[targetID]; ts != nil && ts.BackoffLevel != level {
+		ts.BackoffLevel = level
+		e.publishStateLocked(*ts)
+	}
+	e.mu.Unlock()
+
+	if e.bus != nil && level != prev {
+		e.bus.Log("warn", "目标触发风控退避", map[string]any{
+			"targetId": targetID,
+			"kind":     kind,
+			"level":    level,
+			"error":    err.Error(),
+		})
+	}
+}
+
+// targetBackoffLevel 返回目标当前（已衰减）的退避等级。
+func (e *Engine) targetBackoffLevel(targetID string) int {
+	nowMs := e.now().UnixMilli()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.targetBackoffLevelLocked(targetID, nowMs)
+}
+
+func (e *Engine) targetBackoffLevelLocked(targetID string, nowMs int64) int {
+	st := e.targetBackoff[targetID]
+	if st == nil {
+		return 0
+	}
+	st.decayLocked(nowMs)
+	if ts := e.states[targetID]; ts != nil && ts.BackoffLevel != st.level {
+		ts.BackoffLevel = st.level
+		e.publishStateLocked(*ts)
+	}
+	if st.level == 0 {
+		delete(e.targetBackoff, targetID)
+		return 0
+	}
+	return st.level
+}
+
+// targetBackoffSkip 判断本次节拍是否因退避而跳过：等级 n 时实际间隔为 interval*2^n。
+func (e *Engine) targetBackoffSkip(targetID string, interval time.Duration) bool {
+	nowMs := e.now().UnixMilli()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	level := e.targetBackoffLevelLocked(targetID, nowMs)
+	if level == 0 {
+		return false
+	}
+	st := e.targetBackoff[targetID]
+	if nowMs-st.lastFire < (interval << level).Milliseconds() {
+		return true
+	}
+	st.lastFire = nowMs
+	return false
+}
+
+// backoffInFlight 按退避等级减少同一目标的并发账号数（每级减半，至少 1）。
+func backoffInFlight(max int, level int) int {
+	if level <= 0 {
+		return max
+	}
+	max >>= level
+	if max < 1 {
+		max = 1
+	}
+	return max
+}
+
+// backoffOutcome 退避跳过节拍时目标循环的结果描述。
+const backoffOutcome = "risk backoff"
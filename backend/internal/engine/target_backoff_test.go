@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTargetBackoffEscalatesAndDecays(t *testing.T) {
+	e, fc := newFakeClockEngine()
+	interval := 100 * time.Millisecond
+
+	e.noteTargetRisk("t1", errors.New("create-order failed: 库存不足"))
+	if got := e.targetBackoffLevel("t1"); got != 0 {
+		t.Fatalf("non-risk error raised level to %d", got)
+	}
+
+	throttled := errors.New("render-order status 429: too many requests")
+	e.noteTargetRisk("t1", throttled)
+	e.noteTargetRisk("t1", throttled)
+	if got := e.targetBackoffLevel("t1"); got != 2 {
+		t.Fatalf("level = %d, want 2", got)
+	}
+	if got := backoffInFlight(8, 2); got != 2 {
+		t.Fatalf("backoffInFlight(8, 2) = %d, want 2", got)
+	}
+
+	if e.targetBackoffSkip("t1", interval) {
+		t.Fatal("first tick under backoff should fire")
+	}
+	fc.Advance(interval)
+	if !e.targetBackoffSkip("t1", interval) {
+		t.Fatal("tick within interval*4 should be skipped")
+	}
+	fc.Advance(3 * interval)
+	if e.targetBackoffSkip("t1", interval) {
+		t.Fatal("tick after interval*4 should fire")
+	}
+
+	fc.Advance(targetBackoffDecay)
+	if got := e.targetBackoffLevel("t1"); got != 1 {
+		t.Fatalf("level after one decay period = %d, want 1", got)
+	}
+	fc.Advance(targetBackoffDecay)
+	if got := e.targetBackoffLevel("t1"); got != 0 {
+		t.Fatalf("level after full decay = %d, want 0", got)
+	}
+}
+
+func TestClassifyRiskResponse(t *testing.T) {
+	cases := map[string]string{
+		"create-order failed: 触发风控，请稍后再试": riskKindRiskControl,
+		"render-order status 429: busy":   riskKindThrottle,
+		"render-order failed: 操作过于频繁":     riskKindThrottle,
+		"create-order failed: 商品已售罄":      "",
+	}
+	for msg, want := range cases {
+		if got := classifyRiskResponse(errors.New(msg)); got != want {
+			t.Errorf("classifyRiskResponse(%q) = %q, want %q", msg, got, want)
+		}
+	}
+}
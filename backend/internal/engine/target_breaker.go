@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"fmt"
+
+	"sniping_engine/internal/model"
+)
+
+// 目标级熔断：同一目标连续多次预下单/下单失败，多半是配置已失效（商品下架、SKU 变更、地址不可用等），
+// 继续跑只会消耗限流额度，因此标记为 failed 并自动关闭。
+
+// noteTargetFailureStreak 根据一次尝试的结果更新目标的连续失败次数，达到阈值时关闭目标。
+// 只有预下单/下单失败计入；成功或得到正常业务响应（如当前不可购买）时清零，其它结果（取消、暂停、退避等）不影响计数。
+func (e *Engine) noteTargetFailureStreak(target model.Target, res model.AttemptResult) {
+	var failed bool
+	switch res.ErrorClass {
+	case model.AttemptErrorPreflight, model.AttemptErrorCreate:
+		failed = true
+	case "", model.AttemptErrorNotPurchasable:
+		failed = false
+	default:
+		return
+	}
+	limit := e.NotifySettings().TargetFailureLimit
+
+	e.mu.Lock()
+	st := e.states[target.ID]
+	if st == nil {
+		e.mu.Unlock()
+		return
+	}
+	if !failed {
+		if st.ConsecutiveFailures != 0 {
+			st.ConsecutiveFailures = 0
+			e.publishStateLocked(*st)
+		}
+		e.mu.Unlock()
+		return
+	}
+	st.ConsecutiveFailures++
+	tripped := st.ConsecutiveFailures >= limit && st.Status != model.TaskStatusFailed
+	if tripped {
+		st.Running = false
+		st.Status = model.TaskStatusFailed
+		st.StatusReason = fmt.Sprintf("连续 %d 次失败: %s", st.ConsecutiveFailures, res.Error)
+	}
+	failures := st.ConsecutiveFailures
+	reason := st.StatusReason
+	e.publishStateLocked(*st)
+	e.mu.Unlock()
+
+	if tripped {
+		e.disableTargetAsync(target.ID, "连续失败自动关闭", map[string]any{
+			"failures":   failures,
+			"limit":      limit,
+			"errorClass": string(res.ErrorClass),
+			"lastError":  res.Error,
+			"status":     string(model.TaskStatusFailed),
+			"detail":     reason,
+		})
+	}
+}
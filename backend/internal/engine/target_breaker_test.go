@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"testing"
+
+	"sniping_engine/internal/model"
+)
+
+func TestTargetFailsAfterConsecutiveErrors(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	e.SetNotifySettings(model.NotifySettings{TargetFailureLimit: 3})
+	target := model.Target{ID: "t1", Mode: model.TargetModeScan}
+	e.states["t1"] = &model.TaskState{TargetID: "t1", Running: true}
+
+	preflightErr := model.AttemptResult{ErrorClass: model.AttemptErrorPreflight, Error: "render-order status 500"}
+	e.noteTargetFailureStreak(target, preflightErr)
+	e.noteTargetFailureStreak(target, preflightErr)
+	e.noteTargetFailureStreak(target, model.AttemptResult{ErrorClass: model.AttemptErrorPaused})
+	if st := e.states["t1"]; st.ConsecutiveFailures != 2 || st.Status != "" {
+		t.Fatalf("after 2 failures: %+v", *st)
+	}
+	e.noteTargetFailureStreak(target, model.AttemptResult{ErrorClass: model.AttemptErrorNotPurchasable})
+	if st := e.states["t1"]; st.ConsecutiveFailures != 0 {
+		t.Fatalf("healthy response should reset the streak, got %d", st.ConsecutiveFailures)
+	}
+
+	for i := 0; i < 3; i++ {
+		e.noteTargetFailureStreak(target, model.AttemptResult{ErrorClass: model.AttemptErrorCreate, Error: "create-order failed"})
+	}
+	// 触发后 disableTargetAsync 会在后台 detachTarget 写 states，需等它完成并在锁内读取。
+	waitFor(t, "async disable", func() bool {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return e.states["t1"].LastAttemptMs > 0
+	})
+	e.mu.Lock()
+	st := *e.states["t1"]
+	e.mu.Unlock()
+	if st.Status != model.TaskStatusFailed || st.Running || st.StatusReason == "" {
+		t.Fatalf("target should be marked failed: %+v", st)
+	}
+}
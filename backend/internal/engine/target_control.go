@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"sniping_engine/internal/model"
+)
+
+// StartTarget 启用并立即启动单个目标，不影响其它正在运行的目标。
+// 引擎未运行时会先启动引擎（与自动同步一致，其它已启用的目标也会随之启动）。
+// 整个过程持有 lifecycleMu，避免与 AutoRunByStore 交错导致目标被立即停掉或重复启动。
+func (e *Engine) StartTarget(ctx context.Context, targetID string) (model.Target, error) {
+	if e == nil || e.store == nil {
+		return model.Target{}, errors.New("store unavailable")
+	}
+	targetID = strings.TrimSpace(targetID)
+	if targetID == "" {
+		return model.Target{}, errors.New("target id is required")
+	}
+
+	e.lifecycleMu.Lock()
+	defer e.lifecycleMu.Unlock()
+
+	if err := e.store.SetTargetEnabled(ctx, targetID, true); err != nil {
+		return model.Target{}, err
+	}
+	target, err := e.store.GetTarget(ctx, targetID)
+	if err != nil {
+		return model.Target{}, err
+	}
+
+	if e.Lifecycle() != model.EngineRunning {
+		if err := e.startAllLocked(ctx); err != nil {
+			return model.Target{}, err
+		}
+		return target, nil
+	}
+
+	e.mu.Lock()
+	if _, ok := e.targetCancels[target.ID]; ok || e.runCtx == nil {
+		e.mu.Unlock()
+		return target, nil
+	}
+	targetCtx := e.registerTargetLocked(target, e.now().UnixMilli())
+	e.targets = append(e.targets, target)
+	e.mu.Unlock()
+
+	e.spawnTarget(targetCtx, target)
+	e.recalcCaptchaPoolActivateAtMs()
+	if e.bus != nil {
+		e.bus.Log("info", "任务已手动启动", map[string]any{"targetId": target.ID})
+	}
+	return target, nil
+}
+
+// StopTarget 停用并停止单个目标，其它目标继续运行；停掉的是最后一个运行中的目标时引擎随之停止。
+func (e *Engine) StopTarget(ctx context.Context, targetID string) error {
+	if e == nil || e.store == nil {
+		return errors.New("store unavailable")
+	}
+	targetID = strings.TrimSpace(targetID)
+	if targetID == "" {
+		return errors.New("target id is required")
+	}
+
+	e.lifecycleMu.Lock()
+	defer e.lifecycleMu.Unlock()
+
+	if _, err := e.store.GetTarget(ctx, targetID); err != nil {
+		return err
+	}
+	if err := e.store.SetTargetEnabled(ctx, targetID, false); err != nil {
+		return err
+	}
+
+	idle := e.detachTarget(targetID)
+	e.recalcCaptchaPoolActivateAtMs()
+	if e.bus != nil {
+		e.bus.Log("info", "任务已手动停止", map[string]any{"targetId": targetID})
+	}
+	if idle {
+		return e.stopAllLocked(ctx)
+	}
+	return nil
+}
+
+// TaskStateOf 返回目标当前的任务进度（含已购数量、运行状态），目标尚未运行过时用已保存的进度构造。
+func (e *Engine) TaskStateOf(ctx context.Context, target model.Target) model.TaskState {
+	e.restoreTaskStates(ctx)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.taskStateLocked(target, false)
+	st.TargetQty = target.TargetQty
+	e.refreshTaskRatesLocked(st, e.now())
+	return *st
+}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"context"
+	"math"
+
+	"golang.org/x/time/rate"
+
+	"sniping_engine/internal/model"
+)
+
+// targetLimiter 返回目标专用的限速器（target.QPS > 0 时），参数随目标配置原地更新；未覆盖时返回 nil。
+// burst 未配置时取 ceil(qps)，至少为 1。
+func (e *Engine) targetLimiter(t model.Target) *rate.Limiter {
+	if t.QPS <= 0 {
+		return nil
+	}
+	burst := t.Burst
+	if burst <= 0 {
+		burst = int(math.Ceil(t.QPS))
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	l := e.targetLimiters[t.ID]
+	if l == nil {
+		l = rate.NewLimiter(rate.Limit(t.QPS), burst)
+		e.targetLimiters[t.ID] = l
+		return l
+	}
+	if l.Limit() != rate.Limit(t.QPS) {
+		l.SetLimit(rate.Limit(t.QPS))
+	}
+	if l.Burst() != burst {
+		l.SetBurst(burst)
+	}
+	return l
+}
+
+// waitTargetLimits 是下单链路使用的限速：目标配置了 QPS 时用目标专用限速器代替全局限速，账号限速照常生效。
+func (e *Engine) waitTargetLimits(ctx context.Context, t model.Target, accountID string) bool {
+	l := e.targetLimiter(t)
+	if l == nil {
+		return e.waitLimits(ctx, accountID)
+	}
+	if err := l.Wait(ctx); err != nil {
+		return false
+	}
+	e.mu.Lock()
+	limiter := e.perLimiter[accountID]
+	e.mu.Unlock()
+	if limiter == nil {
+		return true
+	}
+	return limiter.Wait(ctx) == nil
+}
+
+// targetMaxInFlight 返回目标同时下单的账号数上限：目标覆盖优先，否则为全局 maxPerTargetInFlight。
+func (e *Engine) targetMaxInFlight(t model.Target) int {
+	if t.MaxInFlight > 0 {
+		return t.MaxInFlight
+	}
+	return int(e.maxPerTargetInFlight.Load())
+}
@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"testing"
+
+	"golang.org/x/time/rate"
+
+	"sniping_engine/internal/model"
+)
+
+func TestTargetLimiterOverride(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	target := model.Target{ID: "t"}
+	if e.targetLimiter(target) != nil {
+		t.Fatal("target without qps should use the global limiter")
+	}
+
+	target.QPS = 2.5
+	l := e.targetLimiter(target)
+	if l == nil || l.Limit() != rate.Limit(2.5) || l.Burst() != 3 {
+		t.Fatalf("limiter = %v/%d, want 2.5/3", l.Limit(), l.Burst())
+	}
+	target.QPS, target.Burst = 10, 4
+	if again := e.targetLimiter(target); again != l || l.Limit() != 10 || l.Burst() != 4 {
+		t.Fatalf("limiter not updated in place: %v/%d", l.Limit(), l.Burst())
+	}
+}
+
+func TestTargetMaxInFlightOverride(t *testing.T) {
+	e, _ := newFakeClockEngine()
+	e.SetMaxPerTargetInFlight(2)
+	if got := e.targetMaxInFlight(model.Target{}); got != 2 {
+		t.Fatalf("global fallback = %d, want 2", got)
+	}
+	if got := e.targetMaxInFlight(model.Target{MaxInFlight: 6}); got != 6 {
+		t.Fatalf("override = %d, want 6", got)
+	}
+}
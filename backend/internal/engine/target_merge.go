@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sniping_engine/internal/model"
+)
+
+// 重复目标策略：同一商品规格已有目标时，warn 照常保存并提示，block 拒绝保存。
+const (
+	DuplicateTargetsWarn  = "warn"
+	DuplicateTargetsBlock = "block"
+)
+
+// DuplicateTargetPolicy 返回当前的重复目标策略。
+func (e *Engine) DuplicateTargetPolicy() string {
+	return e.NotifySettings().DuplicateTargetPolicy
+}
+
+// MergeTargets 把 sourceIDs 合并进 keepID（见 sqlite.Store.MergeTargets）：先停掉被合并目标的循环并落库进度，
+// 合并后把转入的已购数量加到保留目标的内存进度上，避免下次落库用旧值覆盖。
+// 调用方随后应调用 AutoRunByStore，让保留目标按新的目标数量继续运行。
+func (e *Engine) MergeTargets(ctx context.Context, keepID string, sourceIDs []string) (model.TargetMergeResult, error) {
+	if e == nil || e.store == nil {
+		return model.TargetMergeResult{}, errors.New("store unavailable")
+	}
+	keepID = strings.TrimSpace(keepID)
+
+	e.lifecycleMu.Lock()
+	defer e.lifecycleMu.Unlock()
+
+	keep, err := e.store.GetTarget(ctx, keepID)
+	if err != nil {
+		return model.TargetMergeResult{}, err
+	}
+	// 先校验再停循环，避免不能合并的目标被停掉。
+	for _, id := range sourceIDs {
+		if id = strings.TrimSpace(id); id == "" || id == keepID {
+			continue
+		}
+		src, err := e.store.GetTarget(ctx, id)
+		if err != nil {
+			return model.TargetMergeResult{}, err
+		}
+		if !src.DuplicateOf(keep) {
+			return model.TargetMergeResult{}, fmt.Errorf("target %s: %w", id, model.ErrTargetMergeMismatch)
+		}
+	}
+	e.restoreTaskStates(ctx)
+	for _, id := range sourceIDs {
+		if id = strings.TrimSpace(id); id != "" && id != keepID {
+			e.detachTarget(id)
+		}
+	}
+	if _, err := e.flushTaskStates(ctx); err != nil {
+		return model.TargetMergeResult{}, err
+	}
+
+	res, err := e.store.MergeTargets(ctx, keepID, sourceIDs)
+	if err != nil {
+		return model.TargetMergeResult{}, err
+	}
+
+	merged := make(map[string]bool, len(res.MergedIDs))
+	for _, id := range res.MergedIDs {
+		merged[id] = true
+	}
+	e.forgetOrderSlots(func(_, targetID string) bool { return merged[targetID] })
+
+	e.mu.Lock()
+	for _, id := range res.MergedIDs {
+		delete(e.states, id)
+		delete(e.savedStates, id)
+		delete(e.stateDirty, id)
+	}
+	if st := e.states[keepID]; st != nil {
+		st.PurchasedQty += res.PurchasedQty
+		st.TargetQty = res.Target.TargetQty
+		e.markStateDirtyLocked(keepID)
+		e.publishStateLocked(*st)
+	} else if saved, ok := e.savedStates[keepID]; ok {
+		saved.PurchasedQty += res.PurchasedQty
+		e.savedStates[keepID] = saved
+	} else if res.PurchasedQty > 0 && e.savedStatesLoaded {
+		if e.savedStates == nil {
+			e.savedStates = make(map[string]model.TaskState)
+		}
+		e.savedStates[keepID] = model.TaskState{TargetID: keepID, PurchasedQty: res.PurchasedQty}
+	}
+	e.mu.Unlock()
+	e.recalcCaptchaPoolActivateAtMs()
+
+	if e.bus != nil {
+		e.bus.Log("info", "重复目标已合并", map[string]any{
+			"targetId":     keepID,
+			"mergedIds":    res.MergedIDs,
+			"targetQty":    res.Target.TargetQty,
+			"purchasedQty": res.PurchasedQty,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+func TestMergeTargetsCombinesQuantityAndHistory(t *testing.T) {
+	e, st, keep := newLifecycleEngine(t)
+	ctx := context.Background()
+
+	dup := keep
+	dup.ID, dup.Name, dup.TargetQty = "", "dup", 2
+	dup, err := st.UpsertTarget(ctx, dup)
+	if err != nil {
+		t.Fatalf("upsert dup: %v", err)
+	}
+	other, err := st.UpsertTarget(ctx, model.Target{Name: "other", ItemID: 2, SKUID: 2, Mode: model.TargetModeScan, TargetQty: 1, PerOrderQty: 1})
+	if err != nil {
+		t.Fatalf("upsert other: %v", err)
+	}
+	if dups, err := st.FindDuplicateTargets(ctx, keep); err != nil || len(dups) != 1 || dups[0].ID != dup.ID {
+		t.Fatalf("FindDuplicateTargets = %+v, %v", dups, err)
+	}
+
+	if err := st.UpsertTaskStates(ctx, []model.TaskState{{TargetID: dup.ID, PurchasedQty: 1}}); err != nil {
+		t.Fatalf("upsert task states: %v", err)
+	}
+	if _, err := st.ClaimOrderLedger(ctx, "acc-1", keep.ID, "order-keep"); err != nil {
+		t.Fatalf("claim ledger: %v", err)
+	}
+	for acc, order := range map[string]string{"acc-1": "order-dup", "acc-2": "order-2"} {
+		if _, err := st.ClaimOrderLedger(ctx, acc, dup.ID, order); err != nil {
+			t.Fatalf("claim ledger: %v", err)
+		}
+	}
+	if err := st.InsertAttempts(ctx, []model.AttemptResult{{ID: 1, TargetID: dup.ID, AccountID: "acc-2", StartedAtMs: 1}}); err != nil {
+		t.Fatalf("insert attempts: %v", err)
+	}
+
+	if err := e.AutoRunByStore(ctx); err != nil {
+		t.Fatalf("AutoRunByStore: %v", err)
+	}
+	waitFor(t, "both targets running", func() bool { return targetLoopCount(e) == 2 })
+
+	if _, err := e.MergeTargets(ctx, keep.ID, []string{other.ID}); err == nil {
+		t.Fatalf("expected error merging a different sku")
+	}
+
+	res, err := e.MergeTargets(ctx, keep.ID, []string{dup.ID})
+	if err != nil {
+		t.Fatalf("MergeTargets: %v", err)
+	}
+	if res.Target.TargetQty != 3 || res.PurchasedQty != 1 || res.Attempts != 1 || len(res.MergedIDs) != 1 {
+		t.Fatalf("result = %+v", res)
+	}
+	if _, err := st.GetTarget(ctx, dup.ID); err == nil {
+		t.Fatalf("merged target should be deleted")
+	}
+	if got := e.TaskStateOf(ctx, res.Target).PurchasedQty; got != 1 {
+		t.Fatalf("purchasedQty = %d, want 1", got)
+	}
+	if order, ok, _ := st.GetOrderLedger(ctx, "acc-1", keep.ID); !ok || order != "order-keep" {
+		t.Fatalf("acc-1 ledger = %q, %v", order, ok)
+	}
+	if order, ok, _ := st.GetOrderLedger(ctx, "acc-2", keep.ID); !ok || order != "order-2" {
+		t.Fatalf("acc-2 ledger = %q, %v", order, ok)
+	}
+	attempts, _, err := st.ListAttempts(ctx, model.AttemptQuery{TargetID: keep.ID})
+	if err != nil || len(attempts) != 1 || attempts[0].TargetID != keep.ID {
+		t.Fatalf("attempts = %+v, %v", attempts, err)
+	}
+
+	if err := e.AutoRunByStore(ctx); err != nil {
+		t.Fatalf("AutoRunByStore: %v", err)
+	}
+	waitFor(t, "only the kept target running", func() bool { return targetLoopCount(e) == 1 })
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if _, err := e.flushTaskStates(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	saved, err := st.ListTaskStates(ctx)
+	if err != nil {
+		t.Fatalf("list task states: %v", err)
+	}
+	for _, s := range saved {
+		if s.TargetID == dup.ID {
+			t.Fatalf("merged target state should not be saved again")
+		}
+		if s.TargetID == keep.ID && s.PurchasedQty != 1 {
+			t.Fatalf("saved purchasedQty = %d, want 1", s.PurchasedQty)
+		}
+	}
+}
+
+func TestMergeTargetsRefusesPracticeIntoLive(t *testing.T) {
+	e, st, keep := newLifecycleEngine(t)
+	ctx := context.Background()
+
+	practice := keep
+	practice.ID, practice.Name, practice.Practice = "", "practice", true
+	practice, err := st.UpsertTarget(ctx, practice)
+	if err != nil {
+		t.Fatalf("upsert practice: %v", err)
+	}
+
+	if _, err := e.MergeTargets(ctx, keep.ID, []string{practice.ID}); !errors.Is(err, model.ErrTargetMergeMismatch) {
+		t.Fatalf("engine merge = %v, want ErrTargetMergeMismatch", err)
+	}
+	if _, err := st.MergeTargets(ctx, keep.ID, []string{practice.ID}); !errors.Is(err, model.ErrTargetMergeMismatch) {
+		t.Fatalf("store merge = %v, want ErrTargetMergeMismatch", err)
+	}
+	if _, err := st.GetTarget(ctx, practice.ID); err != nil {
+		t.Fatalf("practice target should be kept: %v", err)
+	}
+	if got, _ := st.GetTarget(ctx, keep.ID); got.TargetQty != keep.TargetQty {
+		t.Fatalf("kept targetQty = %d, want %d", got.TargetQty, keep.TargetQty)
+	}
+}
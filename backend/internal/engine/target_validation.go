@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"sniping_engine/internal/model"
+)
+
+// filterRunnableTargetsLocked 过滤掉配置不完整的目标：不为它们启动 goroutine，
+// 而是把 TaskState 标记为 config_error 并给出原因；定时启用时间（armAtMs）未到的目标也跳过。调用方需持有 e.mu。
+func (e *Engine) filterRunnableTargetsLocked(targets []model.Target) []model.Target {
+	out := make([]model.Target, 0, len(targets))
+	nowMs := e.now().UnixMilli()
+	for _, t := range targets {
+		if t.ID == "" || t.ArmPending(nowMs) {
+			continue
+		}
+		err := t.ValidateForRun()
+		if err == nil {
+			err = e.validatePracticeTarget(t)
+		}
+		st := e.states[t.ID]
+		if err == nil {
+			e.warnEnvMismatchLocked(t)
+			if st != nil && st.Status == model.TaskStatusConfigError {
+				st.Status = ""
+				st.StatusReason = ""
+				e.publishStateLocked(*st)
+			}
+			out = append(out, t)
+			continue
+		}
+
+		reason := err.Error()
+		if st == nil {
+			st = e.taskStateLocked(t, false)
+		}
+		if st.Status == model.TaskStatusConfigError && st.StatusReason == reason && !st.Running {
+			continue
+		}
+		st.Running = false
+		st.Status = model.TaskStatusConfigError
+		st.StatusReason = reason
+		e.publishStateLocked(*st)
+		if e.bus != nil {
+			e.bus.Log("warn", "任务配置不完整，未启动", map[string]any{
+				"targetId": t.ID,
+				"reason":   reason,
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+type taskCounter int
+
+const (
+	counterAttempt taskCounter = iota
+	counterPreflightFailure
+	counterCreateFailure
+	counterCaptchaSolve
+	numTaskCounters
+)
+
+// rateWindowSeconds 滚动速率的统计窗口（按秒分桶）。
+const rateWindowSeconds = 60
+
+// rollingCounter 是按秒分桶的环形计数器，用于计算最近一分钟的事件数。
+type rollingCounter struct {
+	secs   [rateWindowSeconds]int64
+	counts [rateWindowSeconds]int64
+}
+
+func (r *rollingCounter) add(nowSec int64) {
+	i := nowSec % rateWindowSeconds
+	if r.secs[i] != nowSec {
+		r.secs[i] = nowSec
+		r.counts[i] = 0
+	}
+	r.counts[i]++
+}
+
+func (r *rollingCounter) sum(nowSec int64) int64 {
+	return r.sumLast(nowSec, rateWindowSeconds)
+}
+
+// sumLast 返回最近 n 秒（n 不超过统计窗口）的事件数。
+func (r *rollingCounter) sumLast(nowSec int64, n int64) int64 {
+	var total int64
+	for i := range r.secs {
+		if nowSec-r.secs[i] < n {
+			total += r.counts[i]
+		}
+	}
+	return total
+}
+
+type taskRateWindow [numTaskCounters]rollingCounter
+
+// bumpTaskCounterLocked 累加任务计数并刷新每分钟速率；调用方需持有 e.mu，是否推送由调用方决定。
+func (e *Engine) bumpTaskCounterLocked(st *model.TaskState, c taskCounter, now time.Time) {
+	switch c {
+	case counterAttempt:
+		st.Attempts++
+	case counterPreflightFailure:
+		st.PreflightFailures++
+	case counterCreateFailure:
+		st.CreateFailures++
+	case counterCaptchaSolve:
+		st.CaptchaSolves++
+	}
+	w := e.taskRates[st.TargetID]
+	if w == nil {
+		w = &taskRateWindow{}
+		e.taskRates[st.TargetID] = w
+	}
+	w[c].add(now.Unix())
+	e.refreshTaskRatesLocked(st, now)
+}
+
+// refreshTaskRatesLocked 重新计算最近一分钟的速率（空闲时速率也会随时间回落）。调用方需持有 e.mu。
+func (e *Engine) refreshTaskRatesLocked(st *model.TaskState, now time.Time) {
+	w := e.taskRates[st.TargetID]
+	if w == nil {
+		st.RatesPerMin = model.TaskRates{}
+		return
+	}
+	sec := now.Unix()
+	st.RatesPerMin = model.TaskRates{
+		Attempts:          w[counterAttempt].sum(sec),
+		PreflightFailures: w[counterPreflightFailure].sum(sec),
+		CreateFailures:    w[counterCreateFailure].sum(sec),
+		CaptchaSolves:     w[counterCaptchaSolve].sum(sec),
+	}
+}
+
+// countTask 记录一次计数但不推送状态（失败路径紧接着的 setError 会推送）。
+func (e *Engine) countTask(targetID string, c taskCounter) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.states[targetID]
+	if st == nil {
+		return
+	}
+	e.bumpTaskCounterLocked(st, c, e.now())
+}
+
+func resetTaskCounters(st *model.TaskState) {
+	st.Attempts = 0
+	st.PreflightFailures = 0
+	st.CreateFailures = 0
+	st.CaptchaSolves = 0
+	st.Cost = nil
+	st.ConsecutiveFailures = 0
+	st.RatesPerMin = model.TaskRates{}
+}
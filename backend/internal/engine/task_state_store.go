@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// 任务进度只在内存里会在崩溃重启后清零，导致按 TargetQty 超买；这里把变化过的进度定期落库，
+// 已购数量变化时立即落库，StartAll 时恢复。
+const taskStateFlushInterval = time.Second
+
+// markStateDirtyLocked 记录需要落库的任务；调用方需持有 e.mu。
+func (e *Engine) markStateDirtyLocked(targetID string) {
+	if e.store == nil || targetID == "" {
+		return
+	}
+	if e.stateDirty == nil {
+		e.stateDirty = make(map[string]struct{})
+	}
+	e.stateDirty[targetID] = struct{}{}
+}
+
+// flushTaskStates 把变化过的任务进度写入存储，返回写入条数；写入失败的任务会重新标记，等待下次重试。
+func (e *Engine) flushTaskStates(ctx context.Context) (int, error) {
+	if e == nil || e.store == nil {
+		return 0, nil
+	}
+	// 串行化写入，避免较旧的快照覆盖较新的。
+	e.stateFlushMu.Lock()
+	defer e.stateFlushMu.Unlock()
+
+	e.mu.Lock()
+	if len(e.stateDirty) == 0 {
+		e.mu.Unlock()
+		return 0, nil
+	}
+	batch := make([]model.TaskState, 0, len(e.stateDirty))
+	for id := range e.stateDirty {
+		if st := e.states[id]; st != nil {
+			batch = append(batch, *st)
+		}
+	}
+	e.stateDirty = nil
+	e.mu.Unlock()
+
+	if err := e.store.UpsertTaskStates(ctx, batch); err != nil {
+		e.mu.Lock()
+		for _, st := range batch {
+			e.markStateDirtyLocked(st.TargetID)
+		}
+		e.mu.Unlock()
+		if e.bus != nil {
+			e.bus.Log("warn", "任务进度保存失败", map[string]any{"error": err.Error(), "count": len(batch)})
+		}
+		return 0, err
+	}
+	return len(batch), nil
+}
+
+// clearSavedTaskStates 在重置统计后清空已保存的进度，并写入归零后的内存进度。
+func (e *Engine) clearSavedTaskStates(ctx context.Context) {
+	if e == nil || e.store == nil {
+		return
+	}
+	e.mu.Lock()
+	e.savedStates = nil
+	e.savedStatesLoaded = true
+	e.mu.Unlock()
+
+	e.stateFlushMu.Lock()
+	err := e.store.ClearTaskStates(ctx)
+	e.stateFlushMu.Unlock()
+	if err != nil && e.bus != nil {
+		e.bus.Log("warn", "任务进度清空失败", map[string]any{"error": err.Error()})
+	}
+	_, _ = e.flushTaskStates(ctx)
+}
+
+// restoreTaskStates 首次调用时读取上次保存的任务进度，之后以内存状态为准。
+// 读取失败时不标记为已加载，下次启动/测试时重试，避免用零值覆盖库里的已购数量。
+func (e *Engine) restoreTaskStates(ctx context.Context) {
+	if e == nil || e.store == nil {
+		return
+	}
+	e.mu.Lock()
+	loaded := e.savedStatesLoaded
+	e.mu.Unlock()
+	if loaded {
+		return
+	}
+
+	states, err := e.store.ListTaskStates(ctx)
+	if err != nil {
+		if e.bus != nil {
+			e.bus.Log("warn", "任务进度恢复失败", map[string]any{"error": err.Error()})
+		}
+		return
+	}
+	saved := make(map[string]model.TaskState, len(states))
+	for _, st := range states {
+		saved[st.TargetID] = st
+	}
+
+	e.mu.Lock()
+	if !e.savedStatesLoaded {
+		e.savedStates = saved
+		e.savedStatesLoaded = true
+	}
+	e.mu.Unlock()
+	if len(states) > 0 && e.bus != nil {
+		e.bus.Log("info", "已恢复任务进度", map[string]any{"count": len(states)})
+	}
+}
+
+// taskStateLocked 返回目标的内存进度，不存在时用已保存的进度创建；调用方需持有 e.mu。
+func (e *Engine) taskStateLocked(t model.Target, running bool) *model.TaskState {
+	if st := e.states[t.ID]; st != nil {
+		return st
+	}
+	st := &model.TaskState{TargetID: t.ID, Running: running, TargetQty: t.TargetQty}
+	if saved, ok := e.savedStates[t.ID]; ok {
+		st.PurchasedQty = saved.PurchasedQty
+		st.LastError = saved.LastError
+		st.LastAttemptMs = saved.LastAttemptMs
+		st.LastSuccessMs = saved.LastSuccessMs
+		st.NeedCaptcha = saved.NeedCaptcha
+		delete(e.savedStates, t.ID)
+	}
+	e.states[t.ID] = st
+	return st
+}
+
+func (e *Engine) startTaskStateFlusher(ctx context.Context) {
+	if e.store == nil {
+		return
+	}
+	e.registerLoop(LoopInfo{Key: loopKeyTaskStateFlush, Kind: LoopKindTaskStateFlush, Phase: loopPhaseRunning, IntervalMs: taskStateFlushInterval.Milliseconds()})
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		defer e.unregisterLoop(loopKeyTaskStateFlush)
+		ticker := e.clk().NewTicker(taskStateFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				outcome := "idle"
+				if n, err := e.flushTaskStates(ctx); err != nil {
+					outcome = "error: " + err.Error()
+				} else if n > 0 {
+					outcome = fmt.Sprintf("saved %d", n)
+				}
+				e.recordLoopFire(loopKeyTaskStateFlush, outcome, taskStateFlushInterval)
+			}
+		}
+	}()
+}
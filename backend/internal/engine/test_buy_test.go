@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/notify"
+	"sniping_engine/internal/provider"
+)
+
+// buyProvider 预下单可买、不需要验证码，下单直接成功。
+type buyProvider struct {
+	idleProvider
+}
+
+func (buyProvider) Preflight(ctx context.Context, acc model.Account, target model.Target) (provider.PreflightResult, model.Account, error) {
+	return provider.PreflightResult{AccountID: acc.ID, CanBuy: true, TotalFee: 100}, acc, nil
+}
+
+func (buyProvider) CreateOrder(ctx context.Context, attempt *provider.AttemptContext) (provider.CreateResult, model.Account, error) {
+	return provider.CreateResult{Success: true, OrderID: "o-1", TotalFee: 100}, attempt.Account(), nil
+}
+
+type recordingNotifier struct {
+	mu     sync.Mutex
+	orders []notify.OrderCreatedEvent
+}
+
+func (n *recordingNotifier) NotifyOrderCreated(ctx context.Context, evt notify.OrderCreatedEvent) {
+	n.mu.Lock()
+	n.orders = append(n.orders, evt)
+	n.mu.Unlock()
+}
+
+func (n *recordingNotifier) NotifyAlert(ctx context.Context, evt notify.AlertEvent) {}
+
+func TestTestBuyOnceRoutesSuccessThroughOrderCreated(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	ctx := context.Background()
+	n := &recordingNotifier{}
+	e.provider = buyProvider{}
+	e.notifier = n
+
+	res, err := e.TestBuyOnce(ctx, target.ID, "", "", "")
+	if err != nil || !res.Success {
+		t.Fatalf("test buy: %+v, %v", res, err)
+	}
+	orders, err := st.ListOrders(ctx, model.OrderQuery{TargetID: target.ID})
+	if err != nil || len(orders) != 1 || orders[0].OrderID != "o-1" || orders[0].Mode != "test_buy" {
+		t.Fatalf("orders = %+v, %v", orders, err)
+	}
+	if len(n.orders) != 1 || n.orders[0].OrderID != "o-1" {
+		t.Fatalf("notifications = %+v", n.orders)
+	}
+	if e.states[target.ID].PurchasedQty != 1 {
+		t.Fatalf("purchased = %d", e.states[target.ID].PurchasedQty)
+	}
+	if orderID, ok, err := st.GetOrderLedger(ctx, orders[0].AccountID, target.ID); err != nil || !ok || orderID != "o-1" {
+		t.Fatalf("order ledger = %q, %v, %v", orderID, ok, err)
+	}
+}
+
+func TestTestBuyOncePracticeSkipsNotifyAndQuantity(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	ctx := context.Background()
+	target.Practice = true
+	if _, err := st.UpsertTarget(ctx, target); err != nil {
+		t.Fatalf("upsert target: %v", err)
+	}
+	n := &recordingNotifier{}
+	e.practiceProvider = buyProvider{}
+	e.notifier = n
+
+	res, err := e.TestBuyOnce(ctx, target.ID, "", "", "")
+	if err != nil || !res.Success {
+		t.Fatalf("test buy: %+v, %v", res, err)
+	}
+	if len(n.orders) != 0 {
+		t.Fatalf("practice order should not notify: %+v", n.orders)
+	}
+	if orders, _ := st.ListOrders(ctx, model.OrderQuery{TargetID: target.ID}); len(orders) != 0 {
+		t.Fatalf("practice order should not be saved: %+v", orders)
+	}
+	if e.states[target.ID].PurchasedQty != 0 {
+		t.Fatalf("practice order should not count as purchased: %d", e.states[target.ID].PurchasedQty)
+	}
+}
+
+func TestTestBuyOnceRespectsPriceCap(t *testing.T) {
+	e, st, target := newLifecycleEngine(t)
+	ctx := context.Background()
+	target.MaxTotalFee = 50
+	if _, err := st.UpsertTarget(ctx, target); err != nil {
+		t.Fatalf("upsert target: %v", err)
+	}
+	e.provider = buyProvider{}
+
+	res, err := e.TestBuyOnce(ctx, target.ID, "", "", "")
+	if err != nil || res.Success || res.Message != "订单金额超过上限，放弃下单" {
+		t.Fatalf("test buy over the price cap: %+v, %v", res, err)
+	}
+	if orders, _ := st.ListOrders(ctx, model.OrderQuery{TargetID: target.ID}); len(orders) != 0 {
+		t.Fatalf("no order should be placed over the price cap: %+v", orders)
+	}
+}
@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/notify"
+	"sniping_engine/internal/provider"
+)
+
+// token 有效期估计：账号保存时记下 token 的首次出现时间，签发后第一次被上游拒绝（401/鉴权失败）视为过期，
+// 这段时长作为一次寿命观测；取最近观测的中位数推算每个账号当前 token 的过期时间。
+// 预计在已启用抢购目标开抢前（含 cookieExpiryMargin 余量）过期时，通过总线和通知器提醒重新登录。
+
+// isTokenExpiredError 判断上游是否因鉴权失效拒绝了请求。
+func isTokenExpiredError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if _, class := provider.BizCodeOf(err); class == model.BizClassAuth {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "status 401")
+}
+
+// noteTokenExpired 记录账号 token 首次被拒的时间，作为寿命观测（同一 token 只记第一次）。
+func (e *Engine) noteTokenExpired(accountID string) {
+	if e == nil || e.store == nil || accountID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	added, err := e.store.RecordTokenExpired(ctx, accountID, e.now().UnixMilli())
+	if err != nil || !added || e.bus == nil {
+		return
+	}
+	e.bus.Log("warn", "账号 token 已失效，已记录有效期观测", map[string]any{"accountId": accountID})
+}
+
+// estimateTokenLifetime 返回观测寿命的中位数；没有观测时为 0。
+func estimateTokenLifetime(lifetimesMs []int64) int64 {
+	valid := make([]int64, 0, len(lifetimesMs))
+	for _, ms := range lifetimesMs {
+		if ms > 0 {
+			valid = append(valid, ms)
+		}
+	}
+	if len(valid) == 0 {
+		return 0
+	}
+	sort.Slice(valid, func(i, j int) bool { return valid[i] < valid[j] })
+	return valid[len(valid)/2]
+}
+
+// tokenAtRiskTargets 返回开抢时（含余量）token 预计已过期的已启用抢购目标，按开抢时间排序。
+func tokenAtRiskTargets(targets []model.Target, expectedMs, nowMs int64) []model.TokenExpiryTarget {
+	var out []model.TokenExpiryTarget
+	for _, t := range targets {
+		if !t.Enabled || t.Mode != model.TargetModeRush || t.RushAtMs <= nowMs {
+			continue
+		}
+		if expectedMs >= t.RushAtMs+cookieExpiryMargin.Milliseconds() {
+			continue
+		}
+		out = append(out, model.TokenExpiryTarget{TargetID: t.ID, TargetName: t.Name, RushAtMs: t.RushAtMs})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].RushAtMs < out[j].RushAtMs })
+	return out
+}
+
+// CheckTokenExpiry 推算所有已登录账号的 token 过期时间并保存，对会在开抢前过期的账号发出提醒。
+func (e *Engine) CheckTokenExpiry(ctx context.Context) ([]model.TokenExpiry, error) {
+	out, err := e.tokenExpiries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range out {
+		if t.ExpectedExpiryMs > 0 {
+			_ = e.store.SetTokenExpectedExpiry(ctx, t.AccountID, t.ExpectedExpiryMs)
+		}
+		if len(t.AtRisk) > 0 {
+			e.remindTokenExpiry(ctx, t)
+		}
+	}
+	return out, nil
+}
+
+// TokenExpiryOf 返回单个账号当前 token 的有效期估计；没有记录时 ok=false。
+func (e *Engine) TokenExpiryOf(ctx context.Context, accountID string) (model.TokenExpiry, bool, error) {
+	all, err := e.tokenExpiries(ctx)
+	if err != nil {
+		return model.TokenExpiry{}, false, err
+	}
+	for _, t := range all {
+		if t.AccountID == accountID {
+			return t, true, nil
+		}
+	}
+	return model.TokenExpiry{}, false, nil
+}
+
+func (e *Engine) tokenExpiries(ctx context.Context) ([]model.TokenExpiry, error) {
+	if e == nil || e.store == nil {
+		return nil, errors.New("store unavailable")
+	}
+	lifetimes, err := e.store.ListTokenLifetimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := e.store.ListAccountTokens(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := e.store.ListAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := e.store.ListEnabledTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loggedIn := make(map[string]bool, len(accounts))
+	for _, acc := range filterLoggedInAccounts(accounts) {
+		loggedIn[acc.ID] = true
+	}
+
+	nowMs := e.now().UnixMilli()
+	lifetimeMs := estimateTokenLifetime(lifetimes)
+	out := make([]model.TokenExpiry, 0, len(tokens))
+	for _, t := range tokens {
+		if !loggedIn[t.AccountID] {
+			continue
+		}
+		if lifetimeMs > 0 && t.ExpiredAtMs == 0 {
+			t.LifetimeMs = lifetimeMs
+			t.ExpectedExpiryMs = t.IssuedAtMs + lifetimeMs
+			t.AtRisk = tokenAtRiskTargets(targets, t.ExpectedExpiryMs, nowMs)
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// remindTokenExpiry 同一账号在预计过期时间与受影响目标不变时只提醒一次。
+func (e *Engine) remindTokenExpiry(ctx context.Context, t model.TokenExpiry) {
+	ids := make([]string, 0, len(t.AtRisk))
+	names := make([]string, 0, len(t.AtRisk))
+	for _, r := range t.AtRisk {
+		ids = append(ids, r.TargetID)
+		name := r.TargetName
+		if name == "" {
+			name = r.TargetID
+		}
+		names = append(names, fmt.Sprintf("「%s」%s", name, time.UnixMilli(r.RushAtMs).Format("01-02 15:04:05")))
+	}
+	key := fmt.Sprintf("%d|%s", t.ExpectedExpiryMs, strings.Join(ids, ","))
+	e.mu.Lock()
+	if e.tokenReminded[t.AccountID] == key {
+		e.mu.Unlock()
+		return
+	}
+	e.tokenReminded[t.AccountID] = key
+	e.mu.Unlock()
+
+	expectedAt := time.UnixMilli(t.ExpectedExpiryMs).Format("2006-01-02 15:04:05")
+	fields := map[string]any{
+		"accountId":      t.AccountID,
+		"expectedExpiry": expectedAt,
+		"targetIds":      ids,
+	}
+	if e.bus != nil {
+		e.bus.Publish("token_expiry", t)
+		e.bus.Log("warn", "账号 token 预计在开抢前过期，请提前重新登录", fields)
+	}
+	if e.notifier != nil {
+		e.notifier.NotifyAlert(ctx, notify.AlertEvent{
+			At:      e.now().UnixMilli(),
+			Title:   "账号 token 即将过期",
+			Message: fmt.Sprintf("账号 %s 的 token 预计于 %s 过期，以下目标开抢时将无法使用：%s", t.AccountID, expectedAt, strings.Join(names, "、")),
+			Fields:  fields,
+		})
+	}
+}
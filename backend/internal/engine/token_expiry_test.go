@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/logbus"
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/store/sqlite"
+)
+
+func TestEstimateTokenLifetime(t *testing.T) {
+	if got := estimateTokenLifetime(nil); got != 0 {
+		t.Fatalf("no observations = %d", got)
+	}
+	if got := estimateTokenLifetime([]int64{300, -1, 100, 200}); got != 200 {
+		t.Fatalf("median = %d, want 200", got)
+	}
+	if !isTokenExpiredError(errors.New("render-order status 401: unauthorized")) || isTokenExpiredError(errors.New("status 500")) {
+		t.Fatal("only 401 should count as token expiry")
+	}
+}
+
+func TestCheckTokenExpiryRemindsAtRiskTargets(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "engine.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	now := time.Now()
+	acc, err := st.UpsertAccount(ctx, model.Account{Mobile: "13800000000", Token: "current"})
+	if err != nil {
+		t.Fatalf("upsert account: %v", err)
+	}
+	soon, _ := st.UpsertTarget(ctx, model.Target{Name: "soon", ItemID: 1, SKUID: 1, Mode: model.TargetModeRush, RushAtMs: now.Add(30 * time.Minute).UnixMilli(), TargetQty: 1, PerOrderQty: 1, Enabled: true})
+	late, _ := st.UpsertTarget(ctx, model.Target{Name: "late", ItemID: 2, SKUID: 2, Mode: model.TargetModeRush, RushAtMs: now.Add(3 * time.Hour).UnixMilli(), TargetQty: 1, PerOrderQty: 1, Enabled: true})
+
+	// 上一个 token 活了 2 小时；当前 token 1 小时前签发，预计 1 小时后过期。
+	_ = st.NoteTokenIssued(ctx, acc.ID, "previous", now.Add(-5*time.Hour).UnixMilli())
+	if added, err := st.RecordTokenExpired(ctx, acc.ID, now.Add(-3*time.Hour).UnixMilli()); err != nil || !added {
+		t.Fatalf("record expiry = %v, %v", added, err)
+	}
+	if added, _ := st.RecordTokenExpired(ctx, acc.ID, now.Add(-2*time.Hour).UnixMilli()); added {
+		t.Fatal("only the first rejection of a token should be recorded")
+	}
+	_ = st.NoteTokenIssued(ctx, acc.ID, "current", now.Add(-time.Hour).UnixMilli())
+
+	bus := logbus.New(100)
+	e := New(Options{Store: st, Bus: bus})
+	out, err := e.CheckTokenExpiry(ctx)
+	if err != nil || len(out) != 1 {
+		t.Fatalf("check = %+v, %v", out, err)
+	}
+	got := out[0]
+	wantExpiry := now.Add(time.Hour).UnixMilli()
+	if got.LifetimeMs != (2*time.Hour).Milliseconds() || got.ExpectedExpiryMs != wantExpiry {
+		t.Fatalf("estimate = %+v, want expiry %d", got, wantExpiry)
+	}
+	if len(got.AtRisk) != 1 || got.AtRisk[0].TargetID != late.ID {
+		t.Fatalf("at risk = %+v, want only %s (not %s)", got.AtRisk, late.ID, soon.ID)
+	}
+
+	_, _ = e.CheckTokenExpiry(ctx)
+	if n := len(bus.Query(logbus.Query{Types: []string{"token_expiry"}}).Messages); n != 1 {
+		t.Fatalf("token_expiry messages = %d, want 1", n)
+	}
+}
@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/provider"
+)
+
+// ListRecentOrders 查询账号在上游的近期订单，供对账、付款跟踪和重复下单检测复用。
+func (e *Engine) ListRecentOrders(ctx context.Context, accountID string, since time.Time) ([]provider.UpstreamOrder, error) {
+	if e.store == nil {
+		return nil, errors.New("store unavailable")
+	}
+	if e.provider == nil {
+		return nil, errors.New("provider unavailable")
+	}
+	accountID = strings.TrimSpace(accountID)
+	if accountID == "" {
+		return nil, errors.New("account id is required")
+	}
+
+	acc, err := e.store.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(acc.Token) == "" {
+		return nil, errors.New("account not logged in")
+	}
+	e.ensureAccountLimiter(acc.ID)
+	if !e.waitLimits(ctx, acc.ID) {
+		return nil, ctx.Err()
+	}
+
+	orders, updated, err := e.provider.ListRecentOrders(ctx, acc, since)
+	if err != nil {
+		return nil, err
+	}
+	_ = e.persistAccount(ctx, updated)
+	return orders, nil
+}
@@ -0,0 +1,82 @@
+// Package eventschema 从总线消息的 Go 结构体生成 TypeScript 类型与 JSON Schema，
+// 让前端不必再手写 LogData/ProgressData/task_state 等消息的解析类型。
+// 新增消息类型时只需在 Events 中登记一行。
+package eventschema
+
+import (
+	"reflect"
+
+	"sniping_engine/internal/logbus"
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/ws"
+)
+
+// Event 描述一种总线消息：Type 为消息的 type 字段，Data 为 data 字段的结构。
+type Event struct {
+	Type        string
+	Description string
+	Data        reflect.Type
+}
+
+// TargetDisabledData 是 target_disabled 消息的数据；除固定字段外还会带上触发关闭时的附加字段。
+type TargetDisabledData struct {
+	TargetID string `json:"targetId"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Events 返回所有已登记的总线消息类型（顺序即生成顺序）。
+func Events() []Event {
+	return []Event{
+		{Type: "log", Description: "日志", Data: reflect.TypeOf(logbus.LogData{})},
+		{Type: "progress", Description: "测试下单等操作的分步进度", Data: reflect.TypeOf(logbus.ProgressData{})},
+		{Type: "task_state", Description: "任务运行状态与计数", Data: reflect.TypeOf(model.TaskState{})},
+		{Type: "attempt_result", Description: "一次下单尝试的结果", Data: reflect.TypeOf(model.AttemptResult{})},
+		{Type: "target_disabled", Description: "任务被自动关闭", Data: reflect.TypeOf(TargetDisabledData{})},
+		{Type: "target_armed", Description: "任务到达定时启用时间后被自动启用", Data: reflect.TypeOf(model.TargetArmed{})},
+		{Type: "account_cooldown", Description: "账号因连续失败暂停使用或恢复", Data: reflect.TypeOf(model.AccountCooldown{})},
+		{Type: "auth", Description: "WS 鉴权成功后的接入身份（仅开启鉴权时发送）", Data: reflect.TypeOf(ws.Identity{})},
+		{Type: "slow_request", Description: "耗时超过阈值的上游请求追踪", Data: reflect.TypeOf(model.SlowRequest{})},
+		{Type: "token_expiry", Description: "账号 token 预计在开抢前过期的提醒", Data: reflect.TypeOf(model.TokenExpiry{})},
+		{Type: "circuit_breaker", Description: "上游接口熔断状态变化（打开、半开、恢复）", Data: reflect.TypeOf(model.CircuitState{})},
+	}
+}
+
+// enums 登记字符串枚举类型的可选值（反射拿不到常量列表）。
+var enums = map[reflect.Type][]string{
+	reflect.TypeOf(model.TaskStatus("")): {
+		string(model.TaskStatusConfigError),
+		string(model.TaskStatusFailed),
+		string(model.TaskStatusBudgetExhausted),
+	},
+	reflect.TypeOf(model.AttemptPhase("")): {
+		string(model.AttemptPhaseStart),
+		string(model.AttemptPhasePreflight),
+		string(model.AttemptPhaseCaptcha),
+		string(model.AttemptPhaseCreate),
+		string(model.AttemptPhaseDone),
+	},
+	reflect.TypeOf(model.AttemptErrorClass("")): {
+		string(model.AttemptErrorQuotaReached),
+		string(model.AttemptErrorCanceled),
+		string(model.AttemptErrorPaused),
+		string(model.AttemptErrorPreflightBackoff),
+		string(model.AttemptErrorCircuitOpen),
+		string(model.AttemptErrorPreflight),
+		string(model.AttemptErrorNotPurchasable),
+		string(model.AttemptErrorBelowMinStock),
+		string(model.AttemptErrorPriceOverCap),
+		string(model.AttemptErrorCaptcha),
+		string(model.AttemptErrorDuplicateOrder),
+		string(model.AttemptErrorCreate),
+	},
+}
+
+// EventTypes 返回所有消息的 type 取值。
+func EventTypes() []string {
+	events := Events()
+	out := make([]string, 0, len(events))
+	for _, ev := range events {
+		out = append(out, ev.Type)
+	}
+	return out
+}
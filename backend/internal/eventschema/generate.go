@@ -0,0 +1,248 @@
+package eventschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+type field struct {
+	Name     string
+	Type     reflect.Type
+	Optional bool
+}
+
+// jsonFields 按 encoding/json 的规则展开结构体字段（含匿名嵌入），跳过未导出和 json:"-" 的字段。
+func jsonFields(t reflect.Type) []field {
+	var out []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			et := ft
+			if et.Kind() == reflect.Pointer {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				out = append(out, jsonFields(et)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		optional := strings.Contains(opts, "omitempty")
+		if ft.Kind() == reflect.Pointer {
+			optional = true
+		}
+		out = append(out, field{Name: name, Type: ft, Optional: optional})
+	}
+	return out
+}
+
+// generator 记录遇到的具名类型，按首次出现的顺序输出定义。
+type generator struct {
+	seen  map[reflect.Type]bool
+	order []reflect.Type
+}
+
+func newGenerator() *generator {
+	return &generator{seen: make(map[reflect.Type]bool)}
+}
+
+// named 判断 t 是否需要单独输出定义（具名结构体或已登记的枚举）。
+func (g *generator) named(t reflect.Type) bool {
+	if t.Name() == "" || t == timeType {
+		return false
+	}
+	if _, ok := enums[t]; ok {
+		return true
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func (g *generator) use(t reflect.Type) string {
+	if !g.seen[t] {
+		g.seen[t] = true
+		g.order = append(g.order, t)
+	}
+	return t.Name()
+}
+
+// JSONSchema 生成总线消息（Message 信封 + 各 data 结构）的 JSON Schema（draft-07）。
+func JSONSchema() map[string]any {
+	g := newGenerator()
+	variants := make([]any, 0)
+	for _, ev := range Events() {
+		variants = append(variants, map[string]any{
+			"type":        "object",
+			"description": ev.Description,
+			"required":    []string{"type", "time", "data"},
+			"properties": map[string]any{
+				"type":  map[string]any{"const": ev.Type},
+				"time":  map[string]any{"type": "integer"},
+				"runId": map[string]any{"type": "string"},
+				"data":  g.schemaOf(ev.Data),
+			},
+		})
+	}
+
+	defs := make(map[string]any)
+	for i := 0; i < len(g.order); i++ {
+		t := g.order[i]
+		if values, ok := enums[t]; ok {
+			defs[t.Name()] = map[string]any{"type": "string", "enum": values}
+			continue
+		}
+		defs[t.Name()] = g.structSchema(t)
+	}
+
+	return map[string]any{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"title":       "BusMessage",
+		"oneOf":       variants,
+		"definitions": defs,
+	}
+}
+
+func (g *generator) schemaOf(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		return g.schemaOf(t.Elem())
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]any{}
+	case g.named(t):
+		return map[string]any{"$ref": "#/definitions/" + g.use(t)}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		return map[string]any{}
+	}
+}
+
+func (g *generator) structSchema(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	required := make([]string, 0)
+	for _, f := range jsonFields(t) {
+		props[f.Name] = g.schemaOf(f.Type)
+		if !f.Optional {
+			required = append(required, f.Name)
+		}
+	}
+	return map[string]any{"type": "object", "properties": props, "required": required}
+}
+
+// TypeScript 生成总线消息的 TypeScript 类型定义，BusMessage 为按 type 区分的联合类型。
+func TypeScript() string {
+	g := newGenerator()
+	events := Events()
+	variants := make([]string, 0, len(events))
+	for _, ev := range events {
+		variants = append(variants, fmt.Sprintf("  | { type: %q; time: number; runId?: string; data: %s }", ev.Type, g.tsOf(ev.Data)))
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by cmd/eventschema from backend event structs. DO NOT EDIT.\n")
+	for i := 0; i < len(g.order); i++ {
+		t := g.order[i]
+		b.WriteString("\n")
+		if values, ok := enums[t]; ok {
+			quoted := make([]string, 0, len(values))
+			for _, v := range values {
+				quoted = append(quoted, fmt.Sprintf("%q", v))
+			}
+			fmt.Fprintf(&b, "export type %s = %s;\n", t.Name(), strings.Join(quoted, " | "))
+			continue
+		}
+		fmt.Fprintf(&b, "export interface %s %s\n", t.Name(), g.tsStruct(t, ""))
+	}
+	b.WriteString("\nexport type BusMessage =\n")
+	b.WriteString(strings.Join(variants, "\n"))
+	b.WriteString(";\n\nexport type BusMessageType = BusMessage[\"type\"];\n")
+	return b.String()
+}
+
+func (g *generator) tsOf(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		return g.tsOf(t.Elem())
+	}
+	switch {
+	case t == timeType:
+		return "string"
+	case t == rawMessageType:
+		return "unknown"
+	case g.named(t):
+		return g.use(t)
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		return g.tsOf(t.Elem()) + "[]"
+	case reflect.Map:
+		return "Record<string, " + g.tsOf(t.Elem()) + ">"
+	case reflect.Struct:
+		return g.tsStruct(t, "  ")
+	default:
+		return "unknown"
+	}
+}
+
+func (g *generator) tsStruct(t reflect.Type, indent string) string {
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, f := range jsonFields(t) {
+		opt := ""
+		if f.Optional {
+			opt = "?"
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, f.Name, opt, g.tsOf(f.Type))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
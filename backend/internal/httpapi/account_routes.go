@@ -0,0 +1,292 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/engine"
+	"sniping_engine/internal/model"
+)
+
+// handleAccountSubroutes 处理 /api/v1/accounts/{id}/{action} 形式的账号子资源接口。
+func (s *Server) handleAccountSubroutes(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/accounts/"), "/")
+	parts := strings.Split(rest, "/")
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not found"})
+		return
+	}
+	id := strings.TrimSpace(parts[0])
+
+	switch parts[1] {
+	case "upstream-orders":
+		s.handleAccountUpstreamOrders(w, r, id)
+	case "cookie-health":
+		s.handleAccountCookieHealth(w, r, id)
+	case "token-expiry":
+		s.handleAccountTokenExpiry(w, r, id)
+	case "reset-device":
+		s.handleAccountResetDevice(w, r, id)
+	case "bootstrap-session":
+		s.handleAccountBootstrapSession(w, r, id)
+	case "attempts":
+		s.handleAccountAttempts(w, r, id)
+	case "validate":
+		s.handleAccountValidate(w, r, id)
+	case "addresses":
+		s.handleAccountAddresses(w, r, id)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not found"})
+	}
+}
+
+func (s *Server) handleAccountUpstreamOrders(w http.ResponseWriter, r *http.Request, accountID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+	if v := strings.TrimSpace(r.URL.Query().Get("sinceMs")); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || ms < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid sinceMs"})
+			return
+		}
+		since = time.UnixMilli(ms)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	orders, err := s.engine.ListRecentOrders(ctx, accountID, since)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": orders})
+}
+
+// handleAccountCookieHealth GET 返回最近一次检查结果，POST 立即定向刷新该账号的会话 cookie。
+func (s *Server) handleAccountCookieHealth(w http.ResponseWriter, r *http.Request, accountID string) {
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		h, ok := s.engine.CookieHealthOf(accountID)
+		if !ok {
+			writeJSON(w, http.StatusOK, map[string]any{"data": nil})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": h})
+	case http.MethodPost:
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		h, err := s.engine.RefreshAccountCookies(ctx, accountID)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": h})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAccountTokenExpiry 返回账号当前 token 的有效期估计及开抢时 token 预计已过期的目标。
+func (s *Server) handleAccountTokenExpiry(w http.ResponseWriter, r *http.Request, accountID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	t, ok, err := s.engine.TokenExpiryOf(r.Context(), accountID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	if !ok {
+		writeJSON(w, http.StatusOK, map[string]any{"data": nil})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": t})
+}
+
+// handleAccountResetDevice 账号被风控后清空设备身份并生成新设备信息，之后需要重新登录。
+func (s *Server) handleAccountResetDevice(w http.ResponseWriter, r *http.Request, accountID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	acc, err := s.engine.ResetAccountDevice(r.Context(), accountID)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": acc})
+}
+
+// handleAccountBootstrapSession 为只粘贴了 token 的账号补全上游 cookie（含验证码需要的 draco_local）。
+func (s *Server) handleAccountBootstrapSession(w http.ResponseWriter, r *http.Request, accountID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	res, err := s.engine.BootstrapAccountSession(ctx, accountID)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error(), "data": res})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": res})
+}
+
+// handleAccountAttempts 返回账号最近的尝试记录（目标、结果、错误分类、耗时、时间，新的在前）及按结果的计数；
+// ?limit= 默认 50，最多 500。
+func (s *Server) handleAccountAttempts(w http.ResponseWriter, r *http.Request, accountID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := model.AttemptQuery{AccountID: accountID}
+	if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid limit"})
+			return
+		}
+		q.PageSize = n
+	}
+	if _, err := s.store.GetAccount(r.Context(), accountID); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+		return
+	}
+
+	q = q.Normalized()
+	list, total, err := s.store.ListAttempts(r.Context(), q)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	entries := make([]model.AttemptJournalEntry, 0, len(list))
+	for _, a := range list {
+		entries = append(entries, a.JournalEntry())
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"data":     entries,
+		"outcomes": model.AttemptOutcomeCounts(list),
+		"total":    total,
+	})
+}
+
+// handleAccountValidate 访问上游 current-user 校验账号 token/cookie 是否仍有效并保存结果；
+// ?address=true（或 body {"checkAddress": true}）时同时校验收货地址。
+func (s *Server) handleAccountValidate(w http.ResponseWriter, r *http.Request, accountID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	var body struct {
+		CheckAddress bool `json:"checkAddress,omitempty"`
+	}
+	if err := readJSON(r, &body); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	if v, err := strconv.ParseBool(strings.TrimSpace(r.URL.Query().Get("address"))); err == nil && v {
+		body.CheckAddress = true
+	}
+	if _, err := s.store.GetAccount(r.Context(), accountID); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	res, err := s.engine.ValidateAccount(ctx, accountID, body.CheckAddress)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": res})
+}
+
+// handleAccountAddresses GET 返回账号在上游的收货地址列表（标出当前下单使用的地址）；
+// PUT body {"addressId": 11, "divisionIds": "..."} 选择下单地址，divisionIds 省略时取该地址的行政区划，addressId 为 0 时恢复自动选择。
+func (s *Server) handleAccountAddresses(w http.ResponseWriter, r *http.Request, accountID string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	var body struct {
+		AddressID   int64  `json:"addressId"`
+		DivisionIDs string `json:"divisionIds,omitempty"`
+	}
+	if r.Method == http.MethodPut {
+		if err := readJSON(r, &body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		if body.AddressID < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid addressId"})
+			return
+		}
+	}
+	if _, err := s.store.GetAccount(r.Context(), accountID); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	if r.Method == http.MethodGet {
+		res, err := s.engine.AccountAddresses(ctx, accountID)
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": res})
+		return
+	}
+	res, err := s.engine.SelectAccountAddress(ctx, accountID, body.AddressID, body.DivisionIDs)
+	if errors.Is(err, engine.ErrAddressNotFound) || errors.Is(err, engine.ErrAddressNoDivision) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": res})
+}
@@ -0,0 +1,64 @@
+package httpapi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// drainRetryAfterSeconds 排空期间拒绝修改类请求时建议客户端多久后重试（新进程通常已接管）。
+const drainRetryAfterSeconds = 30
+
+type drainPayload struct {
+	// Enabled 省略时视为 true；false 用于取消排空（例如升级中止）。
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+func (s *Server) drainStatus() map[string]any {
+	return map[string]any{
+		"draining":       s.engine.IsDraining(),
+		"liveAttempts":   s.engine.LiveAttempts(),
+		"creatingOrders": s.engine.CreatingOrders(),
+	}
+}
+
+// handleAdminDrain GET 返回排空状态；POST 开启（或 enabled=false 关闭）排空模式。
+func (s *Server) handleAdminDrain(w http.ResponseWriter, r *http.Request) {
+	if s.engine == nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"data": s.drainStatus()})
+	case http.MethodPost:
+		var body drainPayload
+		if err := readJSON(r, &body); err != nil && !errors.Is(err, io.EOF) {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		on := body.Enabled == nil || *body.Enabled
+		s.engine.SetDraining(on)
+		writeJSON(w, http.StatusOK, map[string]any{"data": s.drainStatus()})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+	}
+}
+
+// drainMiddleware 排空期间只放行读请求和排空开关本身，其它修改类请求返回 503 + Retry-After。
+func (s *Server) drainMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.engine == nil || !s.engine.IsDraining() || r.URL.Path == "/api/v1/admin/drain" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(drainRetryAfterSeconds))
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "server is draining for upgrade"})
+	})
+}
@@ -4,14 +4,15 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"net/http"
-	"net/http/cookiejar"
 	"strings"
 	"sync"
 	"time"
+
+	"sniping_engine/internal/utils"
 )
 
 type anonSession struct {
-	jar      *cookiejar.Jar
+	jar      *utils.CookieJar
 	lastUsed time.Time
 }
 
@@ -36,7 +37,7 @@ func newAnonSessionStore(ttl time.Duration, max int) *anonSessionStore {
 	}
 }
 
-func (s *anonSessionStore) GetOrCreate(w http.ResponseWriter, r *http.Request) (*cookiejar.Jar, error) {
+func (s *anonSessionStore) GetOrCreate(w http.ResponseWriter, r *http.Request) (*utils.CookieJar, error) {
 	if s == nil {
 		return nil, nil
 	}
@@ -62,7 +63,7 @@ func (s *anonSessionStore) GetOrCreate(w http.ResponseWriter, r *http.Request) (
 		s.cleanupLocked(now.Add(s.ttl))
 	}
 
-	jar, err := cookiejar.New(nil)
+	jar, err := utils.NewCookieJar()
 	if err != nil {
 		return nil, err
 	}
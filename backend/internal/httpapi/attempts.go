@@ -0,0 +1,44 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sniping_engine/internal/model"
+)
+
+// handleAttempts 分页返回持久化的尝试记录（新的在前）：?targetId=、?accountId=、?runId= 过滤，
+// ?page= 从 1 开始，?pageSize= 默认 50（最多 500）。
+func (s *Server) handleAttempts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	q := model.AttemptQuery{
+		TargetID:  strings.TrimSpace(query.Get("targetId")),
+		AccountID: strings.TrimSpace(query.Get("accountId")),
+		RunID:     strings.TrimSpace(query.Get("runId")),
+	}
+	for name, dst := range map[string]*int{"page": &q.Page, "pageSize": &q.PageSize} {
+		v := strings.TrimSpace(query.Get(name))
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid " + name})
+			return
+		}
+		*dst = n
+	}
+
+	q = q.Normalized()
+	list, total, err := s.store.ListAttempts(r.Context(), q)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": list, "page": q.Page, "pageSize": q.PageSize, "total": total})
+}
@@ -0,0 +1,63 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+
+	"sniping_engine/internal/model"
+)
+
+// handleBizCodes 维护上游业务码字典：GET 返回字典与未登记的业务码，POST 新增/修改，DELETE ?code= 删除。
+func (s *Server) handleBizCodes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		codes, err := s.store.ListBizCodes(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		unknown := []model.UnknownBizCode(nil)
+		if s.codes != nil {
+			unknown = s.codes.Unknown()
+		} else if unknown, err = s.store.ListUnknownBizCodes(r.Context()); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+			"codes":   codes,
+			"unknown": unknown,
+			"classes": model.KnownBizClasses,
+		}})
+	case http.MethodPost:
+		var body model.BizCode
+		if err := readJSON(r, &body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		saved, err := s.store.UpsertBizCode(r.Context(), body)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		if s.codes != nil {
+			s.codes.Put(saved)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": saved})
+	case http.MethodDelete:
+		code := strings.TrimSpace(r.URL.Query().Get("code"))
+		if code == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "code is required"})
+			return
+		}
+		if err := s.store.DeleteBizCode(r.Context(), code); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		if s.codes != nil {
+			s.codes.Delete(code)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
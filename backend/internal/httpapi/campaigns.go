@@ -0,0 +1,136 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/store/sqlite"
+)
+
+// handleCampaigns 维护活动分组：GET 列表（含汇总进度），POST 新增/修改，DELETE ?id= 删除（成员目标解除分组）。
+func (s *Server) handleCampaigns(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		campaigns, err := s.store.ListCampaigns(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		var summaries []model.CampaignSummary
+		if s.engine != nil {
+			if summaries, err = s.engine.CampaignSummaries(r.Context()); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": campaigns, "summaries": summaries})
+	case http.MethodPost:
+		var body model.Campaign
+		if err := readJSON(r, &body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		body.ID = strings.TrimSpace(body.ID)
+		if body.ID != "" {
+			current, err := s.store.GetCampaign(r.Context(), body.ID)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]any{"error": "campaign not found"})
+				return
+			}
+			body.CreatedAt = current.CreatedAt
+		}
+		saved, err := s.store.UpsertCampaign(r.Context(), body)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": saved})
+	case http.MethodDelete:
+		id := strings.TrimSpace(r.URL.Query().Get("id"))
+		if id == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "id is required"})
+			return
+		}
+		if err := s.store.DeleteCampaign(r.Context(), id); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCampaignSubroutes 处理 /api/v1/campaigns/{id}/{start|stop}：启用/停用全部成员目标并同步引擎。
+func (s *Server) handleCampaignSubroutes(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/campaigns/"), "/")
+	parts := strings.Split(rest, "/")
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not found"})
+		return
+	}
+	id := strings.TrimSpace(parts[0])
+
+	var enabled bool
+	switch parts[1] {
+	case "start":
+		enabled = true
+	case "stop":
+		enabled = false
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not found"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	campaign, err := s.store.GetCampaign(r.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, sql.ErrNoRows) {
+			status = http.StatusNotFound
+		}
+		writeJSON(w, status, map[string]any{"error": err.Error()})
+		return
+	}
+	ids, err := s.store.CampaignTargetIDs(r.Context(), id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	if len(ids) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "campaign has no targets"})
+		return
+	}
+	targets, err := s.store.BulkUpdateTargets(r.Context(), ids, sqlite.TargetBulkPatch{Enabled: &enabled})
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+
+	if s.bus != nil {
+		msg := "活动已停止"
+		if enabled {
+			msg = "活动已启动"
+		}
+		s.bus.Log("info", msg, map[string]any{"campaignId": campaign.ID, "name": campaign.Name, "targets": len(targets)})
+	}
+	if s.engine != nil {
+		syncCtx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		if err := s.engine.AutoRunByStore(syncCtx); err != nil && s.bus != nil {
+			s.bus.Log("warn", "启停活动后同步引擎失败", map[string]any{
+				"campaignId": campaign.ID,
+				"error":      err.Error(),
+			})
+		}
+		cancel()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": targets})
+}
@@ -0,0 +1,252 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/engine"
+	"sniping_engine/internal/model"
+)
+
+type catalogSettingsPayload struct {
+	Enabled                *bool    `json:"enabled,omitempty"`
+	FrontCategoryIDs       *[]int64 `json:"frontCategoryIds,omitempty"`
+	RefreshIntervalMinutes *int     `json:"refreshIntervalMinutes,omitempty"`
+	AccountID              *string  `json:"accountId,omitempty"`
+	MaxPages               *int     `json:"maxPages,omitempty"`
+}
+
+func (s *Server) handleCatalogSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		val, ok, err := s.store.GetCatalogSettings(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		if !ok {
+			val = engine.DefaultCatalogSettings()
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": engine.NormalizeCatalogSettings(val)})
+	case http.MethodPost:
+		var body catalogSettingsPayload
+		if err := readJSON(r, &body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+
+		current, ok, err := s.store.GetCatalogSettings(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		if !ok {
+			current = engine.DefaultCatalogSettings()
+		}
+
+		next := current
+		if body.Enabled != nil {
+			next.Enabled = *body.Enabled
+		}
+		if body.FrontCategoryIDs != nil {
+			next.FrontCategoryIDs = *body.FrontCategoryIDs
+		}
+		if body.RefreshIntervalMinutes != nil {
+			next.RefreshIntervalMinutes = *body.RefreshIntervalMinutes
+		}
+		if body.AccountID != nil {
+			next.AccountID = *body.AccountID
+		}
+		if body.MaxPages != nil {
+			next.MaxPages = *body.MaxPages
+		}
+		next = engine.NormalizeCatalogSettings(next)
+
+		saved, err := s.store.UpsertCatalogSettings(r.Context(), next)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		if s.engine != nil {
+			saved = s.engine.SetCatalogSettings(saved)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": saved})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCatalogCategories 默认返回缓存的分类；带 ?accountId=（或 ?live=true 使用商品目录设置的账号）时
+// 用该账号实时查询上游分类树。
+func (s *Server) handleCatalogCategories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	frontID, err := queryInt64(r, "frontCategoryId")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid frontCategoryId"})
+		return
+	}
+	if accountID, live := catalogLiveAccount(r); live {
+		if !s.requireCatalogLive(w, frontID) {
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		list, err := s.engine.BrowseCatalogCategories(ctx, accountID, frontID)
+		if err != nil {
+			writeCatalogLiveError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": list, "live": true})
+		return
+	}
+	list, err := s.store.ListCatalogCategories(r.Context(), frontID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": list})
+}
+
+// handleCatalogSkus 默认按条件查询缓存的商品；带 ?accountId=（或 ?live=true）时实时查询上游该前台分类的
+// 第 ?page= 页（每页 ?limit= 条，默认 50、最多 100），再按 categoryId/storeId/q 过滤。
+func (s *Server) handleCatalogSkus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var q model.CatalogSkuQuery
+	for _, f := range []struct {
+		name string
+		dst  *int64
+	}{
+		{"frontCategoryId", &q.FrontCategoryID},
+		{"categoryId", &q.CategoryID},
+		{"storeId", &q.StoreID},
+	} {
+		v, err := queryInt64(r, f.name)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid " + f.name})
+			return
+		}
+		*f.dst = v
+	}
+	limit, err := queryInt64(r, "limit")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid limit"})
+		return
+	}
+	offset, err := queryInt64(r, "offset")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid offset"})
+		return
+	}
+	q.Limit = int(limit)
+	q.Offset = int(offset)
+	q.Keyword = strings.TrimSpace(r.URL.Query().Get("q"))
+
+	if accountID, live := catalogLiveAccount(r); live {
+		page, err := queryInt64(r, "page")
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid page"})
+			return
+		}
+		if !s.requireCatalogLive(w, q.FrontCategoryID) {
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		list, err := s.engine.BrowseCatalogSkus(ctx, accountID, q, int(page))
+		if err != nil {
+			writeCatalogLiveError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": list, "live": true})
+		return
+	}
+
+	list, err := s.store.ListCatalogSkus(r.Context(), q)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": list})
+}
+
+func (s *Server) handleCatalogStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.engine.CatalogStatus()})
+}
+
+// handleCatalogRefresh 立即刷新一次商品目录缓存（同步执行，完成后返回刷新结果）。
+func (s *Server) handleCatalogRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+	st, err := s.engine.RefreshCatalog(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error(), "data": st})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": st})
+}
+
+// catalogLiveAccount 判断是否实时查询上游：带 accountId 或 live=true 时是，accountId 为空时由引擎按商品目录设置选择账号。
+func catalogLiveAccount(r *http.Request) (string, bool) {
+	accountID := strings.TrimSpace(r.URL.Query().Get("accountId"))
+	live, _ := strconv.ParseBool(strings.TrimSpace(r.URL.Query().Get("live")))
+	return accountID, accountID != "" || live
+}
+
+func (s *Server) requireCatalogLive(w http.ResponseWriter, frontID int64) bool {
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return false
+	}
+	if frontID <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "frontCategoryId is required"})
+		return false
+	}
+	return true
+}
+
+func writeCatalogLiveError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "account not found"})
+		return
+	}
+	writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error()})
+}
+
+func queryInt64(r *http.Request, name string) (int64, error) {
+	v := strings.TrimSpace(r.URL.Query().Get(name))
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, strconv.ErrSyntax
+	}
+	return n, nil
+}
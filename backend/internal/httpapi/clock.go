@@ -0,0 +1,30 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// handleEngineClock GET 返回最近一次时钟校准结果，POST 立即重新校准。
+func (s *Server) handleEngineClock(w http.ResponseWriter, r *http.Request) {
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"data": s.engine.ClockCalibration()})
+	case http.MethodPost:
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		cal, err := s.engine.CalibrateClock(ctx)
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error(), "data": cal})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": cal})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
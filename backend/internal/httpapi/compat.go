@@ -0,0 +1,199 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/engine"
+	"sniping_engine/internal/utils"
+)
+
+type compatSettingsPayload struct {
+	EncryptVersion   *string `json:"encryptVersion,omitempty"`
+	EncryptKey       *string `json:"encryptKey,omitempty"`
+	EncryptOffset    *int    `json:"encryptOffset,omitempty"`
+	UAMode           *string `json:"uaMode,omitempty"`
+	DefaultUserAgent *string `json:"defaultUserAgent,omitempty"`
+}
+
+func (s *Server) handleCompatSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		val, ok, err := s.store.GetCompatSettings(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		if !ok {
+			val = engine.DefaultCompatSettings()
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"data": engine.NormalizeCompatSettings(val),
+			"options": map[string]any{
+				"encryptVersions": utils.EncryptVersions(),
+				"uaModes":         utils.UAModes(),
+			},
+		})
+	case http.MethodPost:
+		var body compatSettingsPayload
+		if err := readJSON(r, &body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+
+		current, ok, err := s.store.GetCompatSettings(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		if !ok {
+			current = engine.DefaultCompatSettings()
+		}
+
+		next := current
+		if body.EncryptVersion != nil {
+			next.EncryptVersion = strings.TrimSpace(*body.EncryptVersion)
+		}
+		if body.EncryptKey != nil {
+			next.EncryptKey = *body.EncryptKey
+		}
+		if body.EncryptOffset != nil {
+			next.EncryptOffset = *body.EncryptOffset
+		}
+		if body.UAMode != nil {
+			next.UAMode = strings.TrimSpace(*body.UAMode)
+		}
+		if body.DefaultUserAgent != nil {
+			next.DefaultUserAgent = strings.TrimSpace(*body.DefaultUserAgent)
+		}
+
+		// 先校验再保存，保存成功后才切换全局设置，保存失败时运行中的设置与数据库保持一致。
+		validated, err := engine.ValidateCompatSettings(next)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		saved, err := s.store.UpsertCompatSettings(r.Context(), validated)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		applied, err := engine.ApplyCompatSettings(saved)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": applied})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+type compatVerifyPayload struct {
+	Identify  string `json:"identify"`
+	Password  string `json:"password"`
+	UserAgent string `json:"userAgent,omitempty"`
+}
+
+type compatVerifyResult struct {
+	EncryptVersion string `json:"encryptVersion"`
+	UAMode         string `json:"uaMode"`
+	UserAgent      string `json:"userAgent"`
+	UpstreamStatus int    `json:"upstreamStatus,omitempty"`
+	LoginOK        bool   `json:"loginOk"`
+	Message        string `json:"message,omitempty"`
+}
+
+// handleCompatVerify 用一组已知可用的账号密码走一次上游密码登录，确认当前加密算法/UA 规则仍然有效。
+// 登录结果不会写入账号表。
+func (s *Server) handleCompatVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body compatVerifyPayload
+	if err := readJSON(r, &body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	identify := strings.TrimSpace(body.Identify)
+	if identify == "" || body.Password == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "identify and password are required"})
+		return
+	}
+
+	encrypted := utils.EncryptPayload(body.Password)
+	ua := utils.NormalizeWXAppUserAgent(body.UserAgent)
+	res := compatVerifyResult{
+		EncryptVersion: utils.CurrentEncryptVersion(),
+		UAMode:         utils.CurrentUserAgentMode(),
+		UserAgent:      ua,
+	}
+
+	jar, err := utils.NewCookieJar()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	client, baseURL, err := s.newAnonymousUpstreamClient(jar, ua)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	upURL, err := buildUpstreamURL(baseURL.String(), "/api/user/web/login/identify", "")
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(map[string]any{
+			"identify":   identify,
+			"password":   encrypted,
+			"isApp":      true,
+			"deviceType": "WXAPP",
+			"userAgent":  ua,
+		}).
+		Post(upURL.String())
+	if err != nil {
+		res.Message = err.Error()
+		writeJSON(w, http.StatusOK, map[string]any{"data": res})
+		return
+	}
+
+	res.UpstreamStatus = resp.StatusCode()
+	token, _ := extractLoginToken(resp.Body())
+	res.LoginOK = strings.TrimSpace(token) != ""
+	if !res.LoginOK {
+		var env struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(resp.Body(), &env)
+		res.Message = strings.TrimSpace(env.Error)
+		if res.Message == "" {
+			res.Message = strings.TrimSpace(env.Message)
+		}
+		if res.Message == "" {
+			res.Message = "login failed"
+		}
+	}
+
+	if s.bus != nil {
+		s.bus.Log("info", "兼容性验证完成", map[string]any{
+			"encryptVersion": res.EncryptVersion,
+			"uaMode":         res.UAMode,
+			"loginOk":        res.LoginOK,
+			"status":         res.UpstreamStatus,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": res})
+}
@@ -0,0 +1,68 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sniping_engine/internal/store/sqlite"
+	"sniping_engine/internal/utils"
+)
+
+func postCompat(s *Server, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/settings/compat", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	s.handleCompatSettings(rec, req)
+	return rec
+}
+
+func TestCompatSettingsAppliedOnlyAfterSave(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	st, err := sqlite.Open(ctx, path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	t.Cleanup(func() { _ = utils.SetEncryptVersion(utils.DefaultEncryptVersion, "", 0) })
+	s := New(Options{Store: st})
+
+	if rec := postCompat(s, `{"encryptVersion":"nope"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid version status = %d, want 400", rec.Code)
+	}
+	if got := utils.CurrentEncryptVersion(); got != utils.DefaultEncryptVersion {
+		t.Fatalf("invalid settings applied: %s", got)
+	}
+
+	// 另开连接让 settings 表拒绝写入，模拟保存失败。
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, `CREATE TRIGGER deny_settings BEFORE INSERT ON settings BEGIN SELECT RAISE(ABORT, 'read only'); END`); err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+	custom := `{"encryptVersion":"` + utils.EncryptVersionCustom + `","encryptKey":"k"}`
+	if rec := postCompat(s, custom); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("failed save status = %d, want 500", rec.Code)
+	}
+	if got := utils.CurrentEncryptVersion(); got != utils.DefaultEncryptVersion {
+		t.Fatalf("settings applied although saving failed: %s", got)
+	}
+
+	if _, err := db.ExecContext(ctx, `DROP TRIGGER deny_settings`); err != nil {
+		t.Fatalf("drop trigger: %v", err)
+	}
+	if rec := postCompat(s, custom); rec.Code != http.StatusOK {
+		t.Fatalf("save status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if got := utils.CurrentEncryptVersion(); got != utils.EncryptVersionCustom {
+		t.Fatalf("saved settings not applied: %s", got)
+	}
+}
@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/store/sqlite"
+)
+
+// handleConfigExport 导出目标与账号（不含凭据），?env= 为空时以本实例的环境标记标注整包。
+func (s *Server) handleConfigExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	env := strings.TrimSpace(r.URL.Query().Get("env"))
+	if env == "" {
+		env = s.cfg.Server.Environment
+	}
+	bundle, err := s.store.ExportBundle(r.Context(), env)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": bundle})
+}
+
+type configImportPayload struct {
+	Bundle  model.ConfigBundle  `json:"bundle"`
+	Options model.ImportOptions `json:"options"`
+}
+
+// handleConfigImport 导入其他实例导出的配置；生产环境实例导入其他环境标记的目标时在 warnings 中提示。
+func (s *Server) handleConfigImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body configImportPayload
+	if err := readJSON(r, &body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	if len(body.Bundle.Targets) == 0 && len(body.Bundle.Accounts) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "bundle is empty"})
+		return
+	}
+
+	res, err := s.store.ImportBundle(r.Context(), body.Bundle, body.Options, s.blockDuplicateTargets(r.Context()))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+
+	warnings := []string{}
+	if s.cfg.Server.Environment == model.EnvProduction {
+		for _, it := range res.Targets {
+			if it.Env != "" && it.Env != model.EnvProduction && it.Action != sqlite.ImportSkipped && it.Action != sqlite.ImportFailed {
+				warnings = append(warnings, fmt.Sprintf("target %s is tagged %q on a production instance", it.ID, it.Env))
+			}
+		}
+	}
+
+	if s.bus != nil && !res.DryRun {
+		s.bus.Log("info", "已导入配置", map[string]any{
+			"sourceEnv": body.Bundle.Env,
+			"targets":   len(res.Targets),
+			"accounts":  len(res.Accounts),
+			"warnings":  len(warnings),
+		})
+	}
+	if s.engine != nil && !res.DryRun && body.Options.KeepEnabled {
+		syncCtx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		if err := s.engine.AutoRunByStore(syncCtx); err != nil && s.bus != nil {
+			s.bus.Log("warn", "导入配置后同步引擎失败", map[string]any{"error": err.Error()})
+		}
+		cancel()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": res, "warnings": warnings})
+}
@@ -9,8 +9,8 @@ import (
 )
 
 func corsMiddleware(cfg config.CorsConfig, next http.Handler) http.Handler {
-	allowHeaders := []string{"Content-Type", "Authorization"}
-	allowMethods := []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	allowHeaders := []string{"Content-Type", "Authorization", "X-Client-Id"}
+	allowMethods := []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
 	maxAge := 600
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -34,6 +34,7 @@ func corsMiddleware(cfg config.CorsConfig, next http.Handler) http.Handler {
 			}
 			w.Header().Set("Access-Control-Allow-Headers", strings.Join(allowHeaders, ", "))
 			w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowMethods, ", "))
+			w.Header().Set("Access-Control-Expose-Headers", "Retry-After")
 			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(maxAge))
 		}
 
@@ -0,0 +1,151 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	deleteKindAccount = "account"
+	deleteKindTarget  = "target"
+
+	// deleteTokenTTL 确认令牌的有效期；过期后需要重新 prepare-delete。
+	deleteTokenTTL = 2 * time.Minute
+	// recentOrderWindow 最近这段时间内下过单的账号/目标视为仍在使用。
+	recentOrderWindow = time.Hour
+)
+
+type deleteToken struct {
+	kind      string
+	id        string
+	expiresAt time.Time
+}
+
+// deleteGuard 保存 prepare-delete 签发的一次性确认令牌（仅进程内有效）。
+type deleteGuard struct {
+	mu     sync.Mutex
+	tokens map[string]deleteToken
+}
+
+func newDeleteGuard() *deleteGuard {
+	return &deleteGuard{tokens: make(map[string]deleteToken)}
+}
+
+func (g *deleteGuard) issue(kind, id string, now time.Time) (string, time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for tok, t := range g.tokens {
+		if now.After(t.expiresAt) {
+			delete(g.tokens, tok)
+		}
+	}
+	tok := randHex(16)
+	exp := now.Add(deleteTokenTTL)
+	g.tokens[tok] = deleteToken{kind: kind, id: id, expiresAt: exp}
+	return tok, exp
+}
+
+// consume 校验并作废令牌；令牌必须由同一资源的 prepare-delete 签发且未过期。
+func (g *deleteGuard) consume(token, kind, id string, now time.Time) bool {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	t, ok := g.tokens[token]
+	if !ok {
+		return false
+	}
+	delete(g.tokens, token)
+	return t.kind == kind && t.id == id && !now.After(t.expiresAt)
+}
+
+// deleteReferences 返回删除该资源前需要确认的原因；为空表示可直接删除。
+// 账号被所有启用的目标共用，因此只要存在启用目标就视为在用。
+func (s *Server) deleteReferences(ctx context.Context, kind, id string) ([]string, error) {
+	var reasons []string
+	targets, err := s.store.ListTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range targets {
+		if !t.Enabled {
+			continue
+		}
+		if kind == deleteKindAccount {
+			reasons = append(reasons, "enabled targets use this account")
+			break
+		}
+		if t.ID == id {
+			reasons = append(reasons, "target is enabled")
+			break
+		}
+	}
+
+	if s.engine != nil {
+		sinceMs := time.Now().Add(-recentOrderWindow).UnixMilli()
+		for _, a := range s.engine.RecentAttempts("", 0) {
+			if !a.Success || a.FinishedAtMs < sinceMs {
+				continue
+			}
+			if (kind == deleteKindAccount && a.AccountID == id) || (kind == deleteKindTarget && a.TargetID == id) {
+				reasons = append(reasons, "orders created within the last hour")
+				break
+			}
+		}
+	}
+	return reasons, nil
+}
+
+// guardDelete 在开启删除保护时检查资源是否在用，在用则要求携带 prepare-delete 签发的 confirmToken。
+// 返回 false 时已写入响应。
+func (s *Server) guardDelete(w http.ResponseWriter, r *http.Request, kind, id string) bool {
+	if !s.cfg.Server.DeleteProtection {
+		return true
+	}
+	reasons, err := s.deleteReferences(r.Context(), kind, id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return false
+	}
+	if len(reasons) == 0 {
+		return true
+	}
+	if s.deleteGuard.consume(r.URL.Query().Get("confirmToken"), kind, id, time.Now()) {
+		return true
+	}
+	writeJSON(w, http.StatusConflict, map[string]any{
+		"error":   "confirmation required: call prepare-delete and retry with confirmToken",
+		"reasons": reasons,
+	})
+	return false
+}
+
+// handlePrepareDelete 签发删除确认令牌，并返回资源当前被引用的情况，供前端二次确认。
+func (s *Server) handlePrepareDelete(w http.ResponseWriter, r *http.Request, kind string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimSpace(r.URL.Query().Get("id"))
+	if id == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "id is required"})
+		return
+	}
+	reasons, err := s.deleteReferences(r.Context(), kind, id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	token, exp := s.deleteGuard.issue(kind, id, time.Now())
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+		"confirmToken": token,
+		"expiresAtMs":  exp.UnixMilli(),
+		"protected":    s.cfg.Server.DeleteProtection,
+		"reasons":      reasons,
+	}})
+}
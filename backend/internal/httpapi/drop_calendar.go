@@ -0,0 +1,112 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"sniping_engine/internal/engine"
+	"sniping_engine/internal/model"
+)
+
+// dropCalendarMaxBytes 上传日历请求体的大小上限。
+const dropCalendarMaxBytes = 1 << 20
+
+type dropCalendarPayload struct {
+	Enabled              *bool `json:"enabled,omitempty"`
+	LeadSeconds          *int  `json:"leadSeconds,omitempty"`
+	WindowScanIntervalMs *int  `json:"windowScanIntervalMs,omitempty"`
+	QuietScanIntervalMs  *int  `json:"quietScanIntervalMs,omitempty"`
+	// Windows 传入时整体替换窗口列表，不传则保持不变。
+	Windows *[]model.DropWindow `json:"windows,omitempty"`
+}
+
+// handleDropCalendar GET 返回上新日历及当前生效情况；POST 修改日历，请求体为日历对象（未传字段保持不变），
+// 或直接上传窗口数组（替换全部窗口并启用日历）。
+func (s *Server) handleDropCalendar(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		cal, ok, err := s.store.GetDropCalendar(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		if !ok {
+			cal = engine.DefaultDropCalendar()
+		}
+		out := map[string]any{"data": engine.NormalizeDropCalendar(cal, time.Now())}
+		if s.engine != nil {
+			out["status"] = s.engine.DropCalendarStatus()
+		}
+		writeJSON(w, http.StatusOK, out)
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, dropCalendarMaxBytes)
+		var raw json.RawMessage
+		if err := readJSON(r, &raw); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		var body dropCalendarPayload
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+			var windows []model.DropWindow
+			if err := json.Unmarshal(trimmed, &windows); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+				return
+			}
+			enabled := true
+			body.Enabled, body.Windows = &enabled, &windows
+		} else if err := json.Unmarshal(trimmed, &body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+
+		current, ok, err := s.store.GetDropCalendar(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		if !ok {
+			current = engine.DefaultDropCalendar()
+		}
+
+		next := current
+		if body.Enabled != nil {
+			next.Enabled = *body.Enabled
+		}
+		if body.LeadSeconds != nil {
+			next.LeadSeconds = *body.LeadSeconds
+		}
+		if body.WindowScanIntervalMs != nil {
+			next.WindowScanIntervalMs = *body.WindowScanIntervalMs
+		}
+		if body.QuietScanIntervalMs != nil {
+			next.QuietScanIntervalMs = *body.QuietScanIntervalMs
+		}
+		if body.Windows != nil {
+			next.Windows = *body.Windows
+		}
+		if err := next.Validate(); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		next = engine.NormalizeDropCalendar(next, time.Now())
+
+		saved, err := s.store.UpsertDropCalendar(r.Context(), next)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		out := map[string]any{"data": saved}
+		if s.engine != nil {
+			out["data"] = s.engine.SetDropCalendar(saved)
+			out["status"] = s.engine.DropCalendarStatus()
+			if s.bus != nil {
+				s.bus.Log("info", "上新日历已更新", map[string]any{"enabled": saved.Enabled, "windows": len(saved.Windows)})
+			}
+		}
+		writeJSON(w, http.StatusOK, out)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
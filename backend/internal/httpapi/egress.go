@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// handleEngineEgress GET 返回最近一次出口网络探测结果，POST 立即重新探测。
+func (s *Server) handleEngineEgress(w http.ResponseWriter, r *http.Request) {
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		probes, err := s.engine.EgressProbes(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": probes})
+	case http.MethodPost:
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		probes, err := s.engine.ProbeEgress(ctx)
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error(), "data": probes})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": probes})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
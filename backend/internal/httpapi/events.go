@@ -0,0 +1,73 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+
+	"sniping_engine/internal/config"
+	"sniping_engine/internal/eventschema"
+	"sniping_engine/internal/logbus"
+	"sniping_engine/internal/ws"
+)
+
+// handleEventsSchema 返回总线/WS 消息的类型定义：format=ts 直接输出 TypeScript，
+// format=json-schema 输出 JSON Schema，缺省时两者一起放在 data 中返回。
+func (s *Server) handleEventsSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format"))) {
+	case "ts", "typescript":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(eventschema.TypeScript()))
+	case "json-schema", "jsonschema":
+		writeJSON(w, http.StatusOK, eventschema.JSONSchema())
+	case "":
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+			"types":      eventschema.EventTypes(),
+			"jsonSchema": eventschema.JSONSchema(),
+			"typescript": eventschema.TypeScript(),
+		}})
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid format"})
+	}
+}
+
+// handleEventsRecent 返回总线缓冲区中的最近消息：?since=&before=（毫秒时间戳）、?types=（逗号分隔）、
+// ?limit=（默认 100，最多 1000）。hasMore=true 时可把第一条的 time 作为 before 继续向前翻页。
+// 配置了 server.auth 时与 /ws 一样需要接入令牌（Authorization: Bearer 或 ?token=），viewer 看到的字段同样脱敏。
+func (s *Server) handleEventsRecent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	role := config.RoleAdmin
+	if auth := s.cfg.Server.Auth; auth.Enabled() {
+		t, ok := auth.Lookup(accessToken(r))
+		if !ok {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+			return
+		}
+		role = t.Role
+	}
+	q, err := logbus.ParseQuery(r.URL.Query(), 100)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	res := s.bus.Query(q)
+	for i, msg := range res.Messages {
+		res.Messages[i] = ws.RedactForRole(role, msg)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": res.Messages, "hasMore": res.HasMore})
+}
+
+// accessToken 读取 server.auth 的接入令牌（不同于上游账号 token，见 extractToken）。
+func accessToken(r *http.Request) string {
+	if v := strings.TrimSpace(r.Header.Get("Authorization")); len(v) > 7 && strings.EqualFold(v[:7], "bearer ") {
+		return strings.TrimSpace(v[7:])
+	}
+	return strings.TrimSpace(r.URL.Query().Get("token"))
+}
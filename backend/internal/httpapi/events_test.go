@@ -0,0 +1,58 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sniping_engine/internal/config"
+	"sniping_engine/internal/logbus"
+)
+
+func TestEventsRecentRequiresAuthAndRedactsForViewer(t *testing.T) {
+	bus := logbus.New(10)
+	bus.Log("info", "login", map[string]any{"accountId": "a", "token": "secret"})
+	var cfg config.Config
+	cfg.Server.Auth.Tokens = []config.AuthToken{
+		{Name: "ops", Token: "admin-token", Role: config.RoleAdmin},
+		{Name: "wall", Token: "viewer-token", Role: config.RoleViewer},
+	}
+	s := New(Options{Cfg: cfg, Bus: bus})
+
+	get := func(url, bearer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		rec := httptest.NewRecorder()
+		s.handleEventsRecent(rec, req)
+		return rec
+	}
+	token := func(rec *httptest.ResponseRecorder) any {
+		t.Helper()
+		var body struct {
+			Data []struct {
+				Data struct {
+					Fields map[string]any `json:"fields"`
+				} `json:"data"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || len(body.Data) != 1 {
+			t.Fatalf("body = %s, %v", rec.Body.String(), err)
+		}
+		return body.Data[0].Data.Fields["token"]
+	}
+
+	for _, url := range []string{"/api/v1/events/recent", "/api/v1/events/recent?token=nope"} {
+		if rec := get(url, ""); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("%s: status = %d, want 401", url, rec.Code)
+		}
+	}
+	if got := token(get("/api/v1/events/recent?token=viewer-token", "")); got != logbus.RedactedValue {
+		t.Fatalf("viewer token field = %v", got)
+	}
+	if got := token(get("/api/v1/events/recent", "admin-token")); got != "secret" {
+		t.Fatalf("admin token field = %v", got)
+	}
+}
@@ -0,0 +1,175 @@
+package httpapi
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	manualActionTestBuy      = "test_buy"
+	manualActionPreflightAll = "preflight_all"
+	manualActionPoolFill     = "captcha_pool_fill"
+)
+
+// manualActionCooldowns 同一客户端两次发起同类手动操作的最小间隔；每类操作同一客户端同时只能进行一个。
+// 这些操作会消耗限流令牌和验证码预算，前端连点时直接返回 429，不排队。
+var manualActionCooldowns = map[string]time.Duration{
+	manualActionTestBuy:      2 * time.Second,
+	manualActionPreflightAll: 10 * time.Second,
+	manualActionPoolFill:     5 * time.Second,
+}
+
+// manualIPBurst 同一来源 IP 在一个冷却周期内最多发起的同类手动操作数。X-Client-Id 由客户端自己填写，
+// 每次换一个 ID 就能绕过按客户端的限制，按 IP 的上限作为兜底，同时允许同一机器上几个页面各自操作。
+const manualIPBurst = 4
+
+// manualOp 是一个进行中的手动操作。
+type manualOp struct {
+	Action      string `json:"action"`
+	OpID        string `json:"opId,omitempty"`
+	StartedAtMs int64  `json:"startedAtMs"`
+}
+
+// manualLimiter 按客户端（X-Client-Id 请求头，缺省为来源 IP）限制昂贵的手动操作，并按来源 IP 设上限兜底（仅进程内有效）。
+// 前端每个页面应生成一个 ID 并在该页面的所有请求里沿用，每次请求换新 ID 只会更快撞上按 IP 的上限。
+type manualLimiter struct {
+	mu       sync.Mutex
+	seq      uint64
+	lastAt   map[string]time.Time
+	ipStarts map[string][]time.Time
+	inFlight map[string]map[uint64]manualOp
+	// now 为空时使用 time.Now，测试可注入。
+	now func() time.Time
+}
+
+func newManualLimiter() *manualLimiter {
+	return &manualLimiter{
+		lastAt:   make(map[string]time.Time),
+		ipStarts: make(map[string][]time.Time),
+		inFlight: make(map[string]map[uint64]manualOp),
+	}
+}
+
+func (l *manualLimiter) clock() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
+
+// manualClientKey 标识发起请求的客户端：优先用 X-Client-Id（同一机器多个页面可区分），否则用来源 IP；
+// ip 为来源 IP 的 key，用于按 IP 的兜底上限。
+func manualClientKey(r *http.Request) (client, ip string) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip = "ip:" + host
+	if id := strings.TrimSpace(r.Header.Get("X-Client-Id")); id != "" {
+		if len(id) > 64 {
+			id = id[:64]
+		}
+		return "id:" + id, ip
+	}
+	return ip, ip
+}
+
+// begin 登记一次手动操作；同类操作仍在进行、距上次发起不足冷却时间或来源 IP 在冷却周期内已发起 manualIPBurst 次时，
+// 返回该客户端进行中的操作与建议等待时长。
+func (l *manualLimiter) begin(client, ip, action, opID string, now time.Time) (release func(), retryAfter time.Duration, inProgress []manualOp) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ops := l.inFlight[client]
+	busy := false
+	for _, op := range ops {
+		inProgress = append(inProgress, op)
+		if op.Action == action {
+			busy = true
+		}
+	}
+	sort.Slice(inProgress, func(i, j int) bool { return inProgress[i].StartedAtMs < inProgress[j].StartedAtMs })
+
+	key := client + "|" + action
+	cooldown := manualActionCooldowns[action]
+	wait := l.lastAt[key].Add(cooldown).Sub(now)
+
+	ipKey := ip + "|" + action
+	starts := l.ipStarts[ipKey][:0]
+	for _, at := range l.ipStarts[ipKey] {
+		if now.Sub(at) < cooldown {
+			starts = append(starts, at)
+		}
+	}
+	l.ipStarts[ipKey] = starts
+	if len(starts) >= manualIPBurst {
+		if ipWait := starts[0].Add(cooldown).Sub(now); ipWait > wait {
+			wait = ipWait
+		}
+	}
+
+	if wait > 0 || busy {
+		if wait < time.Second {
+			wait = time.Second
+		}
+		return nil, wait, inProgress
+	}
+
+	for k, at := range l.lastAt {
+		if now.Sub(at) > time.Minute {
+			delete(l.lastAt, k)
+		}
+	}
+	for k, ts := range l.ipStarts {
+		if len(ts) == 0 || now.Sub(ts[len(ts)-1]) > time.Minute {
+			delete(l.ipStarts, k)
+		}
+	}
+	l.lastAt[key] = now
+	l.ipStarts[ipKey] = append(starts, now)
+	l.seq++
+	id := l.seq
+	if ops == nil {
+		ops = make(map[uint64]manualOp)
+		l.inFlight[client] = ops
+	}
+	ops[id] = manualOp{Action: action, OpID: opID, StartedAtMs: now.UnixMilli()}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			delete(l.inFlight[client], id)
+			if len(l.inFlight[client]) == 0 {
+				delete(l.inFlight, client)
+			}
+		})
+	}, 0, nil
+}
+
+// beginManualAction 为当前请求登记手动操作；被限流时写出 429（带 Retry-After 与进行中的 opId）并返回 false。
+func (s *Server) beginManualAction(w http.ResponseWriter, r *http.Request, action, opID string) (func(), bool) {
+	client, ip := manualClientKey(r)
+	release, wait, inProgress := s.manualLimits.begin(client, ip, action, opID, s.manualLimits.clock())
+	if release != nil {
+		return release, true
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	if inProgress == nil {
+		inProgress = []manualOp{}
+	}
+	writeJSON(w, http.StatusTooManyRequests, map[string]any{
+		"error":        "too many " + action + " requests, retry later",
+		"action":       action,
+		"retryAfterMs": wait.Milliseconds(),
+		"inProgress":   inProgress,
+	})
+	return nil, false
+}
@@ -0,0 +1,157 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newManualLimitServer(now *time.Time) *Server {
+	s := New(Options{})
+	s.manualLimits.now = func() time.Time { return *now }
+	return s
+}
+
+// beginAs 以 clientID（为空时不带 X-Client-Id）和来源地址 remote 发起一次手动操作。
+func beginAs(s *Server, clientID, remote, action, opID string) (func(), *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/manual", nil)
+	req.RemoteAddr = remote
+	if clientID != "" {
+		req.Header.Set("X-Client-Id", clientID)
+	}
+	rec := httptest.NewRecorder()
+	release, ok := s.beginManualAction(rec, req, action, opID)
+	if !ok {
+		return nil, rec
+	}
+	return release, rec
+}
+
+type manualLimitBody struct {
+	Action       string     `json:"action"`
+	RetryAfterMs int64      `json:"retryAfterMs"`
+	InProgress   []manualOp `json:"inProgress"`
+}
+
+func decodeLimited(t *testing.T, rec *httptest.ResponseRecorder) manualLimitBody {
+	t.Helper()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	var body manualLimitBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body = %s, %v", rec.Body.String(), err)
+	}
+	if body.InProgress == nil {
+		t.Fatalf("inProgress should be an array: %s", rec.Body.String())
+	}
+	return body
+}
+
+func TestManualActionCooldown(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := newManualLimitServer(&now)
+
+	release, _ := beginAs(s, "tab-1", "10.0.0.1:1000", manualActionTestBuy, "op-1")
+	if release == nil {
+		t.Fatal("first test buy should start")
+	}
+	release()
+
+	now = now.Add(500 * time.Millisecond)
+	again, rec := beginAs(s, "tab-1", "10.0.0.1:1000", manualActionTestBuy, "op-2")
+	if again != nil {
+		t.Fatal("second test buy inside the cooldown should be refused")
+	}
+	body := decodeLimited(t, rec)
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("Retry-After = %q, want 2", got)
+	}
+	if body.Action != manualActionTestBuy || body.RetryAfterMs != 1500 || len(body.InProgress) != 0 {
+		t.Fatalf("body = %+v", body)
+	}
+
+	// 其它客户端、其它操作不受影响。
+	if r, _ := beginAs(s, "tab-2", "10.0.0.1:1000", manualActionTestBuy, ""); r == nil {
+		t.Fatal("another client should not share the cooldown")
+	} else {
+		r()
+	}
+	if r, _ := beginAs(s, "tab-1", "10.0.0.1:1000", manualActionPoolFill, ""); r == nil {
+		t.Fatal("another action should not share the cooldown")
+	} else {
+		r()
+	}
+
+	now = now.Add(1500 * time.Millisecond)
+	if r, _ := beginAs(s, "tab-1", "10.0.0.1:1000", manualActionTestBuy, ""); r == nil {
+		t.Fatal("test buy should start again once the cooldown has passed")
+	} else {
+		r()
+	}
+}
+
+func TestManualActionBusyWhileInFlightAndReleaseOnce(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := newManualLimitServer(&now)
+
+	first, _ := beginAs(s, "", "10.0.0.2:1000", manualActionPreflightAll, "op-1")
+	if first == nil {
+		t.Fatal("first preflight-all should start")
+	}
+	now = now.Add(time.Minute)
+	_, rec := beginAs(s, "", "10.0.0.2:2000", manualActionPreflightAll, "op-2")
+	body := decodeLimited(t, rec)
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Fatalf("Retry-After while busy = %q, want 1", got)
+	}
+	if len(body.InProgress) != 1 || body.InProgress[0].OpID != "op-1" || body.InProgress[0].Action != manualActionPreflightAll {
+		t.Fatalf("inProgress = %+v", body.InProgress)
+	}
+
+	first()
+	second, _ := beginAs(s, "", "10.0.0.2:1000", manualActionPreflightAll, "op-3")
+	if second == nil {
+		t.Fatal("preflight-all should start after the previous one released")
+	}
+	// 重复释放旧操作不能把新操作一起释放。
+	first()
+	now = now.Add(time.Minute)
+	if _, rec := beginAs(s, "", "10.0.0.2:1000", manualActionPreflightAll, "op-4"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429 while op-3 is still running", rec.Code)
+	}
+	second()
+}
+
+func TestManualActionIPFloorAgainstRotatingClientIDs(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := newManualLimitServer(&now)
+
+	for i := 0; i < manualIPBurst; i++ {
+		r, rec := beginAs(s, fmt.Sprintf("rotating-%d", i), "10.0.0.3:1000", manualActionTestBuy, "")
+		if r == nil {
+			t.Fatalf("start %d refused: %s", i, rec.Body.String())
+		}
+		r()
+	}
+	_, rec := beginAs(s, "rotating-new", "10.0.0.3:1000", manualActionTestBuy, "")
+	decodeLimited(t, rec)
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("Retry-After = %q, want 2", got)
+	}
+
+	if r, _ := beginAs(s, "rotating-new", "10.0.0.4:1000", manualActionTestBuy, ""); r == nil {
+		t.Fatal("another IP should not share the floor")
+	} else {
+		r()
+	}
+	now = now.Add(2 * time.Second)
+	if r, _ := beginAs(s, "rotating-later", "10.0.0.3:1000", manualActionTestBuy, ""); r == nil {
+		t.Fatal("IP floor should reset after the cooldown")
+	} else {
+		r()
+	}
+}
@@ -0,0 +1,54 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// handleOrders 返回订单历史（按下单时间倒序）：?targetId=、?accountId= 过滤，
+// ?date=2006-01-02 取本地时间当天，或用 ?fromMs=&toMs= 指定区间；?limit= 默认 100。
+func (s *Server) handleOrders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	q := model.OrderQuery{
+		TargetID:  strings.TrimSpace(query.Get("targetId")),
+		AccountID: strings.TrimSpace(query.Get("accountId")),
+	}
+	q.Limit, _ = strconv.Atoi(strings.TrimSpace(query.Get("limit")))
+
+	if v := strings.TrimSpace(query.Get("date")); v != "" {
+		day, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid date, want YYYY-MM-DD"})
+			return
+		}
+		q.FromMs = day.UnixMilli()
+		q.ToMs = day.AddDate(0, 0, 1).UnixMilli()
+	}
+	for name, dst := range map[string]*int64{"fromMs": &q.FromMs, "toMs": &q.ToMs} {
+		v := strings.TrimSpace(query.Get(name))
+		if v == "" {
+			continue
+		}
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || ms < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid " + name})
+			return
+		}
+		*dst = ms
+	}
+
+	list, err := s.store.ListOrders(r.Context(), q)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": list})
+}
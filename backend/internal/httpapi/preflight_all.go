@@ -0,0 +1,46 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// handleEnginePreflightAll 对所有已启用目标依次预检一次，返回每个目标的 canBuy/needCaptcha/totalFee，
+// 用于开抢前的就绪检查；请求体可选 {"opId": "..."}。
+func (s *Server) handleEnginePreflightAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	var body struct {
+		OpID string `json:"opId,omitempty"`
+	}
+	if err := readJSON(r, &body); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+
+	release, ok := s.beginManualAction(w, r, manualActionPreflightAll, strings.TrimSpace(body.OpID))
+	if !ok {
+		return
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	res, err := s.engine.PreflightAll(ctx, strings.TrimSpace(body.OpID))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": res})
+}
@@ -0,0 +1,139 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/engine"
+)
+
+// privacyWipeConfirm 隐私清除需要在请求体里原样带上的确认词，防止误触。
+const privacyWipeConfirm = "WIPE"
+
+type retentionSettingsPayload struct {
+	OrdersDays         *int `json:"ordersDays,omitempty"`
+	AttemptsDays       *int `json:"attemptsDays,omitempty"`
+	SlowRequestsDays   *int `json:"slowRequestsDays,omitempty"`
+	TokenLifetimesDays *int `json:"tokenLifetimesDays,omitempty"`
+	DailyPurchasesDays *int `json:"dailyPurchasesDays,omitempty"`
+	LogsHours          *int `json:"logsHours,omitempty"`
+}
+
+type privacyWipePayload struct {
+	Confirm string `json:"confirm"`
+}
+
+func (s *Server) handleRetentionSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		val, ok, err := s.store.GetRetentionSettings(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		if !ok {
+			val = engine.DefaultRetentionSettings()
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": engine.NormalizeRetentionSettings(val)})
+	case http.MethodPost:
+		var body retentionSettingsPayload
+		if err := readJSON(r, &body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+
+		current, ok, err := s.store.GetRetentionSettings(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		if !ok {
+			current = engine.DefaultRetentionSettings()
+		}
+
+		next := current
+		if body.OrdersDays != nil {
+			next.OrdersDays = *body.OrdersDays
+		}
+		if body.AttemptsDays != nil {
+			next.AttemptsDays = *body.AttemptsDays
+		}
+		if body.SlowRequestsDays != nil {
+			next.SlowRequestsDays = *body.SlowRequestsDays
+		}
+		if body.TokenLifetimesDays != nil {
+			next.TokenLifetimesDays = *body.TokenLifetimesDays
+		}
+		if body.DailyPurchasesDays != nil {
+			next.DailyPurchasesDays = *body.DailyPurchasesDays
+		}
+		if body.LogsHours != nil {
+			next.LogsHours = *body.LogsHours
+		}
+		next = engine.NormalizeRetentionSettings(next)
+
+		saved, err := s.store.UpsertRetentionSettings(r.Context(), next)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		if s.engine != nil {
+			saved = s.engine.SetRetentionSettings(saved)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": saved})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleRetentionPrune 立即按当前保留设置清理一次（平时每小时自动执行）。
+func (s *Server) handleRetentionPrune(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+	defer cancel()
+	res, err := s.engine.PruneRetention(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": res})
+}
+
+// handleAdminPrivacyWipe 一次性删除账号凭据、手机号、地址、订单历史等隐私数据，保留目标配置；
+// 请求体必须为 {"confirm": "WIPE"}。
+func (s *Server) handleAdminPrivacyWipe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	var body privacyWipePayload
+	if err := readJSON(r, &body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(body.Confirm) != privacyWipeConfirm {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": `confirm must be "` + privacyWipeConfirm + `"`})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+	res, err := s.engine.PrivacyWipe(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error(), "data": res})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": res})
+}
@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/store/sqlite"
+)
+
+const proxyDialTimeout = 3 * time.Second
+
+// checkProxyReachable 校验代理地址格式并尝试建立 TCP 连接，分配给账号前调用。
+func checkProxyReachable(ctx context.Context, raw string) error {
+	if err := model.ValidateProxyURL(raw); err != nil {
+		return err
+	}
+	u, _ := url.Parse(strings.TrimSpace(raw))
+	d := net.Dialer{Timeout: proxyDialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return fmt.Errorf("proxy %s unreachable: %w", model.RedactProxyURL(raw), err)
+	}
+	_ = conn.Close()
+	return nil
+}
+
+// handleProxies 维护代理池：GET 列表（含引用账号数），POST 新增/修改（校验可达），DELETE ?id= 删除（仍被引用时拒绝）。
+func (s *Server) handleProxies(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		proxies, err := s.store.ListProxies(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": proxies})
+	case http.MethodPost:
+		var body model.ProxyEntry
+		if err := readJSON(r, &body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		body.ID = strings.TrimSpace(body.ID)
+		if body.ID != "" {
+			current, err := s.store.GetProxy(r.Context(), body.ID)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]any{"error": "proxy not found"})
+				return
+			}
+			body.CreatedAt = current.CreatedAt
+		}
+		if err := checkProxyReachable(r.Context(), body.URL); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		saved, err := s.store.UpsertProxy(r.Context(), body)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": saved})
+	case http.MethodDelete:
+		id := strings.TrimSpace(r.URL.Query().Get("id"))
+		if id == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "id is required"})
+			return
+		}
+		if err := s.store.DeleteProxy(r.Context(), id); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, sqlite.ErrProxyInUse) {
+				status = http.StatusConflict
+			}
+			writeJSON(w, status, map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// accountView 是账号列表的返回项，附带解析后的实际出口代理（已去掉账号密码）及其来源。
+type accountView struct {
+	model.Account
+	EffectiveProxy string `json:"effectiveProxy,omitempty"`
+	// ProxySource 取值 pool（代理池）、account（账号自填）、global（全局代理）、direct（直连）。
+	ProxySource string `json:"proxySource"`
+}
+
+func (s *Server) accountViews(accounts []model.Account) []accountView {
+	out := make([]accountView, 0, len(accounts))
+	for _, acc := range accounts {
+		v := accountView{Account: acc}
+		switch {
+		case acc.ProxyID != "" && acc.Proxy != "":
+			v.ProxySource, v.EffectiveProxy = "pool", acc.Proxy
+		case acc.Proxy != "":
+			v.ProxySource, v.EffectiveProxy = "account", acc.Proxy
+		case strings.TrimSpace(s.cfg.Proxy.Global) != "":
+			v.ProxySource, v.EffectiveProxy = "global", strings.TrimSpace(s.cfg.Proxy.Global)
+		default:
+			v.ProxySource = "direct"
+		}
+		v.EffectiveProxy = model.RedactProxyURL(v.EffectiveProxy)
+		out = append(out, v)
+	}
+	return out
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
 	"os"
 	"strconv"
@@ -21,6 +20,7 @@ import (
 	"sniping_engine/internal/logbus"
 	"sniping_engine/internal/model"
 	"sniping_engine/internal/notify"
+	"sniping_engine/internal/provider"
 	"sniping_engine/internal/store/sqlite"
 	"sniping_engine/internal/utils"
 	"sniping_engine/internal/ws"
@@ -34,6 +34,8 @@ type Options struct {
 	Store    *sqlite.Store
 	Engine   *engine.Engine
 	Notifier notify.Notifier
+	// CodeBook 为 provider 使用的业务码字典，编辑后立即生效；为空时只修改数据库。
+	CodeBook *provider.CodeBook
 }
 
 type Server struct {
@@ -44,17 +46,30 @@ type Server struct {
 	notif        notify.Notifier
 	ws           *ws.Handler
 	anonSessions *anonSessionStore
+	deleteGuard  *deleteGuard
+	manualLimits *manualLimiter
+	codes        *provider.CodeBook
 }
 
 func New(opts Options) *Server {
+	wsHandler := ws.NewHandler(opts.Bus, opts.Cfg.Server.Cors.AllowOrigins)
+	if auth := opts.Cfg.Server.Auth; auth.Enabled() {
+		wsHandler.SetAuthenticator(func(token string) (ws.Identity, bool) {
+			t, ok := auth.Lookup(token)
+			return ws.Identity{Name: t.Name, Role: t.Role}, ok
+		})
+	}
 	return &Server{
 		cfg:          opts.Cfg,
 		bus:          opts.Bus,
 		store:        opts.Store,
 		engine:       opts.Engine,
 		notif:        opts.Notifier,
-		ws:           ws.NewHandler(opts.Bus, opts.Cfg.Server.Cors.AllowOrigins),
+		ws:           wsHandler,
 		anonSessions: newAnonSessionStore(30*time.Minute, 2000),
+		deleteGuard:  newDeleteGuard(),
+		manualLimits: newManualLimiter(),
+		codes:        opts.CodeBook,
 	}
 }
 
@@ -65,42 +80,124 @@ func (s *Server) Handler() http.Handler {
 
 	api := http.NewServeMux()
 	api.HandleFunc("/api/v1/accounts", s.handleAccounts)
+	api.HandleFunc("/api/v1/accounts/", s.handleAccountSubroutes)
+	api.HandleFunc("/api/v1/accounts/prepare-delete", func(w http.ResponseWriter, r *http.Request) {
+		s.handlePrepareDelete(w, r, deleteKindAccount)
+	})
+	api.HandleFunc("/api/v1/proxies", s.handleProxies)
 	api.HandleFunc("/api/v1/targets", s.handleTargets)
+	api.HandleFunc("/api/v1/targets/", s.handleTargetSubroutes)
+	api.HandleFunc("/api/v1/targets/bulk", s.handleTargetsBulk)
+	api.HandleFunc("/api/v1/targets/import", s.handleTargetsImport)
+	api.HandleFunc("/api/v1/orders", s.handleOrders)
+	api.HandleFunc("/api/v1/attempts", s.handleAttempts)
+	api.HandleFunc("/api/v1/campaigns", s.handleCampaigns)
+	api.HandleFunc("/api/v1/campaigns/", s.handleCampaignSubroutes)
+	api.HandleFunc("/api/v1/targets/prepare-delete", func(w http.ResponseWriter, r *http.Request) {
+		s.handlePrepareDelete(w, r, deleteKindTarget)
+	})
 	api.HandleFunc("/api/v1/engine/start", s.handleEngineStart)
 	api.HandleFunc("/api/v1/engine/stop", s.handleEngineStop)
+	api.HandleFunc("/api/v1/engine/pause", s.handleEnginePause)
+	api.HandleFunc("/api/v1/engine/resume", s.handleEngineResume)
 	api.HandleFunc("/api/v1/engine/state", s.handleEngineState)
+	api.HandleFunc("/api/v1/engine/stats/reset", s.handleEngineStatsReset)
+	api.HandleFunc("/api/v1/engine/loops", s.handleEngineLoops)
+	api.HandleFunc("/api/v1/engine/egress", s.handleEngineEgress)
+	api.HandleFunc("/api/v1/engine/slow-requests", s.handleSlowRequests)
+	api.HandleFunc("/api/v1/engine/clock", s.handleEngineClock)
+	api.HandleFunc("/api/v1/engine/targets/", s.handleEngineTargetSubroutes)
 	api.HandleFunc("/api/v1/engine/preflight", s.handleEnginePreflight)
+	api.HandleFunc("/api/v1/engine/preflight-all", s.handleEnginePreflightAll)
 	api.HandleFunc("/api/v1/engine/test-buy", s.handleEngineTestBuy)
 	api.HandleFunc("/api/v1/captcha/state", s.handleCaptchaState)
+	api.HandleFunc("/api/v1/captcha/queue", s.handleCaptchaQueue)
 	api.HandleFunc("/api/v1/captcha/pool", s.handleCaptchaPool)
 	api.HandleFunc("/api/v1/captcha/pool/fill", s.handleCaptchaPoolFill)
 	api.HandleFunc("/api/v1/captcha/pages", s.handleCaptchaPages)
 	api.HandleFunc("/api/v1/captcha/pages/refresh", s.handleCaptchaPagesRefresh)
 	api.HandleFunc("/api/v1/captcha/pages/stop", s.handleCaptchaPagesStop)
+	api.HandleFunc("/api/v1/captcha/engine/stop", s.handleCaptchaEngineStop)
+	api.HandleFunc("/api/v1/captcha/engine/start", s.handleCaptchaEngineStart)
 	api.HandleFunc("/api/v1/captcha/manual", s.handleCaptchaManualPage)
 	api.HandleFunc("/api/v1/captcha/manual/config", s.handleCaptchaManualConfig)
 	api.HandleFunc("/api/v1/captcha/manual/submit", s.handleCaptchaManualSubmit)
 	api.HandleFunc("/api/v1/settings/email", s.handleEmailSettings)
 	api.HandleFunc("/api/v1/settings/email/test", s.handleEmailTest)
 	api.HandleFunc("/api/v1/settings/notify", s.handleNotifySettings)
+	api.HandleFunc("/api/v1/settings/biz-codes", s.handleBizCodes)
 	api.HandleFunc("/api/v1/settings/limits", s.handleLimitsSettings)
 	api.HandleFunc("/api/v1/settings/captcha-pool", s.handleCaptchaPoolSettings)
+	api.HandleFunc("/api/v1/settings/compat", s.handleCompatSettings)
+	api.HandleFunc("/api/v1/settings/compat/verify", s.handleCompatVerify)
+	api.HandleFunc("/api/v1/settings/catalog", s.handleCatalogSettings)
+	api.HandleFunc("/api/v1/settings/retention", s.handleRetentionSettings)
+	api.HandleFunc("/api/v1/settings/drop-calendar", s.handleDropCalendar)
+	api.HandleFunc("/api/v1/settings/retention/prune", s.handleRetentionPrune)
+	api.HandleFunc("/api/v1/catalog/categories", s.handleCatalogCategories)
+	api.HandleFunc("/api/v1/catalog/skus", s.handleCatalogSkus)
+	api.HandleFunc("/api/v1/catalog/status", s.handleCatalogStatus)
+	api.HandleFunc("/api/v1/catalog/refresh", s.handleCatalogRefresh)
+	api.HandleFunc("/api/v1/events/schema", s.handleEventsSchema)
+	api.HandleFunc("/api/v1/events/recent", s.handleEventsRecent)
+	api.HandleFunc("/api/v1/config/export", s.handleConfigExport)
+	api.HandleFunc("/api/v1/config/import", s.handleConfigImport)
+	api.HandleFunc("/api/v1/admin/drain", s.handleAdminDrain)
+	api.HandleFunc("/api/v1/admin/privacy-wipe", s.handleAdminPrivacyWipe)
 	api.HandleFunc("/api/", s.handleUpstreamProxy)
 
-	mux.Handle("/api/", corsMiddleware(s.cfg.Server.Cors, api))
+	mux.Handle("/api/", corsMiddleware(s.cfg.Server.Cors, s.drainMiddleware(api)))
 	return mux
 }
 
+// handleHealth 排空期间仍返回 200，守护脚本根据 draining/liveAttempts 判断何时可以替换进程。
 func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
-	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	out := map[string]any{"ok": true}
+	if s.engine != nil {
+		for k, v := range s.drainStatus() {
+			out[k] = v
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
 }
 
 func (s *Server) handleCaptchaState(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"data": utils.GetCaptchaEngineStatus()})
+	case http.MethodPost:
+		// 只允许调整运行中可即时生效的参数；无头模式等需要改配置后重启。
+		var body struct {
+			SleepScale     *float64 `json:"sleepScale,omitempty"`
+			BlockResources *bool    `json:"blockResources,omitempty"`
+		}
+		if err := readJSON(r, &body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+		if body.SleepScale != nil && (*body.SleepScale <= 0 || *body.SleepScale > 1) {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "sleepScale must be within (0, 1]"})
+			return
+		}
+		opts := utils.UpdateCaptchaRuntimeOptions(body.SleepScale, body.BlockResources)
+		if s.bus != nil {
+			s.bus.Log("info", "验证码参数已更新", map[string]any{
+				"sleepScale":     opts.SleepScale,
+				"blockResources": opts.BlockResources,
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": utils.GetCaptchaEngineStatus()})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+	}
+}
+
+func (s *Server) handleCaptchaQueue(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"data": utils.GetCaptchaEngineStatus()})
+	writeJSON(w, http.StatusOK, map[string]any{"data": utils.GetCaptchaQueueStatus()})
 }
 
 func (s *Server) handleCaptchaPool(w http.ResponseWriter, r *http.Request) {
@@ -116,7 +213,8 @@ func (s *Server) handleCaptchaPool(w http.ResponseWriter, r *http.Request) {
 }
 
 type captchaPoolFillPayload struct {
-	Count int `json:"count"`
+	Count int    `json:"count"`
+	OpID  string `json:"opId,omitempty"`
 }
 
 func (s *Server) handleCaptchaPoolFill(w http.ResponseWriter, r *http.Request) {
@@ -141,7 +239,12 @@ func (s *Server) handleCaptchaPoolFill(w http.ResponseWriter, r *http.Request) {
 	if count > 50 {
 		count = 50
 	}
-	added, failed, err := s.engine.FillCaptchaPoolManual(r.Context(), count)
+	release, ok := s.beginManualAction(w, r, manualActionPoolFill, body.OpID)
+	if !ok {
+		return
+	}
+	defer release()
+	added, failed, err := s.engine.FillCaptchaPoolManual(r.Context(), count, body.OpID)
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
 		return
@@ -196,6 +299,56 @@ func (s *Server) handleCaptchaPagesStop(w http.ResponseWriter, r *http.Request)
 	writeJSON(w, http.StatusOK, map[string]any{"data": res})
 }
 
+// handleCaptchaEngineStop 关闭验证码浏览器释放内存；有求解在进行时返回 409，?drain=1 则先中止求解再关闭。
+func (s *Server) handleCaptchaEngineStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	drain := r.URL.Query().Get("drain") == "1" || r.URL.Query().Get("drain") == "true"
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	st, err := utils.StopCaptchaEngine(ctx, drain)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, utils.ErrCaptchaSolvesInFlight), errors.Is(err, utils.ErrCaptchaEngineStarting):
+			status = http.StatusConflict
+		case errors.Is(err, context.DeadlineExceeded):
+			status = http.StatusGatewayTimeout
+		}
+		writeJSON(w, status, map[string]any{"error": err.Error(), "data": st})
+		return
+	}
+	if s.bus != nil {
+		s.bus.Log("info", "验证码引擎已停止", map[string]any{"drain": drain})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": st})
+}
+
+// handleCaptchaEngineStart 启动并预热验证码引擎；最多等待 30 秒，未就绪时返回 202，预热在后台继续。
+func (s *Server) handleCaptchaEngineStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	st, err := utils.EnsureCaptchaEngineReady(ctx, 0)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			writeJSON(w, http.StatusAccepted, map[string]any{"data": st})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error(), "data": st})
+		return
+	}
+	if s.bus != nil {
+		s.bus.Log("info", "验证码引擎已启动", map[string]any{"warmPages": st.WarmPages})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": st})
+}
+
 func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -204,7 +357,7 @@ func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"data": accounts})
+		writeJSON(w, http.StatusOK, map[string]any{"data": s.accountViews(accounts)})
 	case http.MethodPost:
 		type accountUpsertPayload struct {
 			ID          string  `json:"id,omitempty"`
@@ -215,8 +368,10 @@ func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
 			DeviceID    *string `json:"deviceId,omitempty"`
 			UUID        *string `json:"uuid,omitempty"`
 			Proxy       *string `json:"proxy,omitempty"`
+			ProxyID     *string `json:"proxyId,omitempty"`
 			AddressID   *int64  `json:"addressId,omitempty"`
 			DivisionIDs *string `json:"divisionIds,omitempty"`
+			Env         *string `json:"env,omitempty"`
 		}
 
 		var body accountUpsertPayload
@@ -229,6 +384,17 @@ func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "mobile is required"})
 			return
 		}
+		// 按默认区号规范化，"+86 138 0013 8000" 与 "13800138000" 视为同一账号。
+		if found, err := s.store.GetAccountByMobile(r.Context(), mobile); err == nil {
+			mobile = found.Mobile
+		} else {
+			normalized, err := s.store.NormalizeMobile(mobile)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+				return
+			}
+			mobile = normalized
+		}
 
 		var current model.Account
 		if strings.TrimSpace(body.ID) != "" {
@@ -259,8 +425,29 @@ func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
 		if body.UUID != nil {
 			next.UUID = strings.TrimSpace(*body.UUID)
 		}
-		if body.Proxy != nil {
+		// 代理分配：proxyId 引用代理池，proxy 为自填地址（同时清除代理池引用）；新分配的代理需先通过可达性校验。
+		if body.ProxyID != nil {
+			next.ProxyID = strings.TrimSpace(*body.ProxyID)
+			if next.ProxyID != "" {
+				entry, err := s.store.GetProxy(r.Context(), next.ProxyID)
+				if err != nil {
+					writeJSON(w, http.StatusBadRequest, map[string]any{"error": "proxy not found: " + next.ProxyID})
+					return
+				}
+				next.Proxy = entry.URL
+			}
+		} else if body.Proxy != nil {
 			next.Proxy = strings.TrimSpace(*body.Proxy)
+			next.ProxyID = ""
+		}
+		if next.Proxy != "" && (next.Proxy != current.Proxy || next.ProxyID != current.ProxyID) {
+			checkCtx, cancel := context.WithTimeout(r.Context(), proxyDialTimeout)
+			err := checkProxyReachable(checkCtx, next.Proxy)
+			cancel()
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+				return
+			}
 		}
 		if body.AddressID != nil {
 			next.AddressID = *body.AddressID
@@ -268,6 +455,9 @@ func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
 		if body.DivisionIDs != nil {
 			next.DivisionIDs = strings.TrimSpace(*body.DivisionIDs)
 		}
+		if body.Env != nil {
+			next.Env = strings.TrimSpace(*body.Env)
+		}
 		if body.Token != nil {
 			t := strings.TrimSpace(*body.Token)
 			next.Token = t
@@ -278,7 +468,11 @@ func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
 
 		acc, err := s.store.UpsertAccount(r.Context(), next)
 		if err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			status := http.StatusBadRequest
+			if errors.Is(err, sqlite.ErrDuplicateMobile) {
+				status = http.StatusConflict
+			}
+			writeJSON(w, status, map[string]any{"error": err.Error()})
 			return
 		}
 		writeJSON(w, http.StatusOK, map[string]any{"data": acc})
@@ -288,6 +482,9 @@ func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "id is required"})
 			return
 		}
+		if !s.guardDelete(w, r, deleteKindAccount, id) {
+			return
+		}
 		if err := s.store.DeleteAccount(r.Context(), id); err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
 			return
@@ -306,6 +503,12 @@ func (s *Server) handleTargets(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
 			return
 		}
+		// ?groupBy=sku 按商品规格分组返回，再加 &duplicates=true 只返回有重复目标的组。
+		if strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("groupBy")), "sku") {
+			onlyDup, _ := strconv.ParseBool(strings.TrimSpace(r.URL.Query().Get("duplicates")))
+			writeJSON(w, http.StatusOK, map[string]any{"data": model.GroupTargetsBySKU(targets, onlyDup)})
+			return
+		}
 		writeJSON(w, http.StatusOK, map[string]any{"data": targets})
 	case http.MethodPost:
 		type targetUpsertPayload struct {
@@ -315,13 +518,34 @@ func (s *Server) handleTargets(w http.ResponseWriter, r *http.Request) {
 			ItemID             int64            `json:"itemId"`
 			SKUID              int64            `json:"skuId"`
 			ShopID             int64            `json:"shopId,omitempty"`
+			CategoryID         *int64           `json:"categoryId,omitempty"`
 			Mode               model.TargetMode `json:"mode"`
 			TargetQty          int              `json:"targetQty"`
 			PerOrderQty        int              `json:"perOrderQty"`
 			RushAtMs           int64            `json:"rushAtMs,omitempty"`
 			RushLeadMs         *int64           `json:"rushLeadMs,omitempty"`
+			ArmAtMs            *int64           `json:"armAtMs,omitempty"` // 传 0 清除定时启用，不传则保持不变
 			CaptchaVerifyParam *string          `json:"captchaVerifyParam,omitempty"`
+			OrderSource        *string          `json:"orderSource,omitempty"`
+			DeviceSource       *string          `json:"deviceSource,omitempty"`
 			Enabled            bool             `json:"enabled"`
+
+			CaptchaOverride         *string  `json:"captchaOverride,omitempty"`
+			AllowMultiplePerAccount *bool    `json:"allowMultiplePerAccount,omitempty"`
+			MinStock                *int64   `json:"minStock,omitempty"`
+			MaxTotalFee             *int64   `json:"maxTotalFee,omitempty"`
+			PrerenderSeconds        *int     `json:"prerenderSeconds,omitempty"`
+			Env                     *string  `json:"env,omitempty"`
+			CampaignID              *string  `json:"campaignId,omitempty"`
+			QPS                     *float64 `json:"qps,omitempty"`
+			Burst                   *int     `json:"burst,omitempty"`
+			MaxInFlight             *int     `json:"maxInFlight,omitempty"`
+			MaxAttempts             *int     `json:"maxAttempts,omitempty"`
+			// ExtraLines 传 [] 表示清空附加订单行，不传则保持不变。
+			ExtraLines *[]model.OrderLine `json:"extraLines,omitempty"`
+			Practice   *bool              `json:"practice,omitempty"`
+			// Notify 不传则保持不变，传 null 清除（恢复使用全局通知设置）。
+			Notify json.RawMessage `json:"notify,omitempty"`
 		}
 
 		var body targetUpsertPayload
@@ -343,19 +567,131 @@ func (s *Server) handleTargets(w http.ResponseWriter, r *http.Request) {
 			RushAtMs:    body.RushAtMs,
 			Enabled:     body.Enabled,
 		}
+
+		// 未传的可选字段沿用已保存的值，避免前端旧版本覆盖掉新字段。
+		var current model.Target
+		if next.ID != "" {
+			if found, err := s.store.GetTarget(r.Context(), next.ID); err == nil {
+				current = found
+			}
+		}
 		if body.RushLeadMs != nil {
 			next.RushLeadMs = *body.RushLeadMs
-		} else if next.ID != "" {
-			if current, err := s.store.GetTarget(r.Context(), next.ID); err == nil {
-				next.RushLeadMs = current.RushLeadMs
-			}
+		} else {
+			next.RushLeadMs = current.RushLeadMs
+		}
+		if body.ArmAtMs != nil {
+			next.ArmAtMs = *body.ArmAtMs
+		} else {
+			next.ArmAtMs = current.ArmAtMs
 		}
 		if body.CaptchaVerifyParam != nil {
 			next.CaptchaVerifyParam = strings.TrimSpace(*body.CaptchaVerifyParam)
-		} else if next.ID != "" {
-			if current, err := s.store.GetTarget(r.Context(), next.ID); err == nil {
-				next.CaptchaVerifyParam = current.CaptchaVerifyParam
+		} else {
+			next.CaptchaVerifyParam = current.CaptchaVerifyParam
+		}
+		if body.CategoryID != nil {
+			next.CategoryID = *body.CategoryID
+		} else {
+			next.CategoryID = current.CategoryID
+		}
+		if body.OrderSource != nil {
+			next.OrderSource = strings.TrimSpace(*body.OrderSource)
+		} else {
+			next.OrderSource = current.OrderSource
+		}
+		if body.DeviceSource != nil {
+			next.DeviceSource = strings.TrimSpace(*body.DeviceSource)
+		} else {
+			next.DeviceSource = current.DeviceSource
+		}
+		if body.CaptchaOverride != nil {
+			next.CaptchaOverride = strings.TrimSpace(*body.CaptchaOverride)
+		} else {
+			next.CaptchaOverride = current.CaptchaOverride
+		}
+		if body.AllowMultiplePerAccount != nil {
+			next.AllowMultiplePerAccount = *body.AllowMultiplePerAccount
+		} else {
+			next.AllowMultiplePerAccount = current.AllowMultiplePerAccount
+		}
+		if body.MinStock != nil {
+			next.MinStock = *body.MinStock
+		} else {
+			next.MinStock = current.MinStock
+		}
+		if body.MaxTotalFee != nil {
+			next.MaxTotalFee = *body.MaxTotalFee
+		} else {
+			next.MaxTotalFee = current.MaxTotalFee
+		}
+		if body.PrerenderSeconds != nil {
+			next.PrerenderSeconds = *body.PrerenderSeconds
+		} else {
+			next.PrerenderSeconds = current.PrerenderSeconds
+		}
+		if body.Env != nil {
+			next.Env = strings.TrimSpace(*body.Env)
+		} else {
+			next.Env = current.Env
+		}
+		if body.CampaignID != nil {
+			next.CampaignID = strings.TrimSpace(*body.CampaignID)
+		} else {
+			next.CampaignID = current.CampaignID
+		}
+		if body.QPS != nil {
+			next.QPS = *body.QPS
+		} else {
+			next.QPS = current.QPS
+		}
+		if body.Burst != nil {
+			next.Burst = *body.Burst
+		} else {
+			next.Burst = current.Burst
+		}
+		if body.MaxInFlight != nil {
+			next.MaxInFlight = *body.MaxInFlight
+		} else {
+			next.MaxInFlight = current.MaxInFlight
+		}
+		if body.MaxAttempts != nil {
+			next.MaxAttempts = *body.MaxAttempts
+		} else {
+			next.MaxAttempts = current.MaxAttempts
+		}
+		if body.ExtraLines != nil {
+			next.ExtraLines = *body.ExtraLines
+		} else {
+			next.ExtraLines = current.ExtraLines
+		}
+		if body.Practice != nil {
+			next.Practice = *body.Practice
+		} else {
+			next.Practice = current.Practice
+		}
+		if len(body.Notify) > 0 {
+			var notify *model.TargetNotify
+			if err := json.Unmarshal(body.Notify, &notify); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid notify: " + err.Error()})
+				return
 			}
+			next.Notify = notify
+		} else {
+			next.Notify = current.Notify
+		}
+
+		duplicates, err := s.store.CheckDuplicateTarget(r.Context(), current, next, s.blockDuplicateTargets(r.Context()))
+		if errors.Is(err, sqlite.ErrDuplicateTarget) {
+			writeJSON(w, http.StatusConflict, map[string]any{
+				"error":      err.Error(),
+				"duplicates": duplicates,
+			})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
 		}
 
 		t, err := s.store.UpsertTarget(r.Context(), next)
@@ -363,6 +699,17 @@ func (s *Server) handleTargets(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
 			return
 		}
+		if len(duplicates) > 0 && s.bus != nil {
+			ids := make([]string, 0, len(duplicates))
+			for _, d := range duplicates {
+				ids = append(ids, d.ID)
+			}
+			s.bus.Log("warn", "目标与已有目标指向同一商品规格", map[string]any{
+				"targetId":     t.ID,
+				"skuId":        t.SKUID,
+				"duplicateIds": ids,
+			})
+		}
 
 		// 单个任务开关变化也要立即生效：自动启动/停止引擎并同步任务列表。
 		if s.engine != nil {
@@ -376,6 +723,10 @@ func (s *Server) handleTargets(w http.ResponseWriter, r *http.Request) {
 			cancel()
 		}
 
+		if len(duplicates) > 0 {
+			writeJSON(w, http.StatusOK, map[string]any{"data": t, "duplicates": duplicates})
+			return
+		}
 		writeJSON(w, http.StatusOK, map[string]any{"data": t})
 	case http.MethodDelete:
 		id := r.URL.Query().Get("id")
@@ -383,11 +734,15 @@ func (s *Server) handleTargets(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "id is required"})
 			return
 		}
+		if !s.guardDelete(w, r, deleteKindTarget, id) {
+			return
+		}
 		if err := s.store.DeleteTarget(r.Context(), id); err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
 			return
 		}
 		if s.engine != nil {
+			s.engine.ForgetTargetOrders(id)
 			syncCtx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 			if err := s.engine.AutoRunByStore(syncCtx); err != nil && s.bus != nil {
 				s.bus.Log("warn", "删除任务后同步引擎失败", map[string]any{
@@ -449,16 +804,81 @@ func (s *Server) handleEngineStop(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 }
 
+func (s *Server) handleEnginePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.engine.Pause(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+func (s *Server) handleEngineResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.engine.Resume(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
 func (s *Server) handleEngineState(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"data": s.engine.State()})
+	state := s.engine.State()
+	if campaigns, err := s.engine.CampaignSummaries(r.Context()); err == nil {
+		state.Campaigns = campaigns
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": state})
+}
+
+func (s *Server) handleEngineStatsReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	runID, err := s.engine.ResetStats()
+	if errors.Is(err, engine.ErrStatsResetBusy) {
+		writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"runId": runID}})
+}
+
+// handleEngineLoops 列出引擎当前所有后台循环（每个目标的抢购/扫货循环、验证码池维护等）及其最近一次触发结果。
+func (s *Server) handleEngineLoops(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.engine.Loops()})
 }
 
 type enginePreflightPayload struct {
 	TargetID string `json:"targetId"`
+	OpID     string `json:"opId,omitempty"`
+	// IncludeRender 为 true 时附带脱敏后的原始 render 报文（也可用查询参数 ?includeRender=true）。
+	IncludeRender bool `json:"includeRender,omitempty"`
 }
 
 func (s *Server) handleEnginePreflight(w http.ResponseWriter, r *http.Request) {
@@ -483,7 +903,11 @@ func (s *Server) handleEnginePreflight(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
-	res, err := s.engine.PreflightOnce(ctx, strings.TrimSpace(body.TargetID))
+	includeRender := body.IncludeRender
+	if v, err := strconv.ParseBool(strings.TrimSpace(r.URL.Query().Get("includeRender"))); err == nil && v {
+		includeRender = true
+	}
+	res, err := s.engine.PreflightOnce(ctx, strings.TrimSpace(body.TargetID), strings.TrimSpace(body.OpID), includeRender)
 	if err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
 		return
@@ -493,6 +917,7 @@ func (s *Server) handleEnginePreflight(w http.ResponseWriter, r *http.Request) {
 
 type engineTestBuyPayload struct {
 	TargetID           string `json:"targetId"`
+	AccountID          string `json:"accountId,omitempty"`
 	CaptchaVerifyParam string `json:"captchaVerifyParam,omitempty"`
 	OpID               string `json:"opId,omitempty"`
 }
@@ -516,10 +941,16 @@ func (s *Server) handleEngineTestBuy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	release, ok := s.beginManualAction(w, r, manualActionTestBuy, strings.TrimSpace(body.OpID))
+	if !ok {
+		return
+	}
+	defer release()
+
 	ctx, cancel := context.WithTimeout(r.Context(), 45*time.Second)
 	defer cancel()
 
-	res, err := s.engine.TestBuyOnce(ctx, strings.TrimSpace(body.TargetID), strings.TrimSpace(body.CaptchaVerifyParam), strings.TrimSpace(body.OpID))
+	res, err := s.engine.TestBuyOnce(ctx, strings.TrimSpace(body.TargetID), strings.TrimSpace(body.AccountID), strings.TrimSpace(body.CaptchaVerifyParam), strings.TrimSpace(body.OpID))
 	if err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
 		return
@@ -596,10 +1027,24 @@ type emailTestPayload struct {
 }
 
 type notifySettingsPayload struct {
-	RushExpireDisableMinutes *int    `json:"rushExpireDisableMinutes,omitempty"`
-	RushMode                 *string `json:"rushMode,omitempty"`
-	RoundRobinIntervalMs     *int    `json:"roundRobinIntervalMs,omitempty"`
-	ScanIntervalMs           *int    `json:"scanIntervalMs,omitempty"`
+	RushExpireDisableMinutes *int     `json:"rushExpireDisableMinutes,omitempty"`
+	RushMode                 *string  `json:"rushMode,omitempty"`
+	RoundRobinIntervalMs     *int     `json:"roundRobinIntervalMs,omitempty"`
+	ScanIntervalMs           *int     `json:"scanIntervalMs,omitempty"`
+	ScanProbeEnabled         *bool    `json:"scanProbeEnabled,omitempty"`
+	ScanFullEvery            *int     `json:"scanFullEvery,omitempty"`
+	ScanRenderCacheMs        *int     `json:"scanRenderCacheMs,omitempty"`
+	RushAtDriftWarnSeconds   *int     `json:"rushAtDriftWarnSeconds,omitempty"`
+	AccountCooldownFailures  *int     `json:"accountCooldownFailures,omitempty"`
+	AccountCooldownSeconds   *int     `json:"accountCooldownSeconds,omitempty"`
+	AccountDailyQuota        *int     `json:"accountDailyQuota,omitempty"`
+	TargetFailureLimit       *int     `json:"targetFailureLimit,omitempty"`
+	ScheduleEnabled          *bool    `json:"scheduleEnabled,omitempty"`
+	ScheduleLeadSeconds      *int     `json:"scheduleLeadSeconds,omitempty"`
+	ScheduleWindowSeconds    *int     `json:"scheduleWindowSeconds,omitempty"`
+	DuplicateTargetPolicy    *string  `json:"duplicateTargetPolicy,omitempty"`
+	CaptchaFeePerSolve       *float64 `json:"captchaFeePerSolve,omitempty"`
+	ProxyFeePerGB            *float64 `json:"proxyFeePerGB,omitempty"`
 }
 
 func (s *Server) handleNotifySettings(w http.ResponseWriter, r *http.Request) {
@@ -644,6 +1089,48 @@ func (s *Server) handleNotifySettings(w http.ResponseWriter, r *http.Request) {
 		if body.ScanIntervalMs != nil {
 			next.ScanIntervalMs = *body.ScanIntervalMs
 		}
+		if body.ScanProbeEnabled != nil {
+			next.ScanProbeEnabled = *body.ScanProbeEnabled
+		}
+		if body.ScanFullEvery != nil {
+			next.ScanFullEvery = *body.ScanFullEvery
+		}
+		if body.ScanRenderCacheMs != nil {
+			next.ScanRenderCacheMs = *body.ScanRenderCacheMs
+		}
+		if body.RushAtDriftWarnSeconds != nil {
+			next.RushAtDriftWarnSeconds = *body.RushAtDriftWarnSeconds
+		}
+		if body.AccountCooldownFailures != nil {
+			next.AccountCooldownFailures = *body.AccountCooldownFailures
+		}
+		if body.AccountCooldownSeconds != nil {
+			next.AccountCooldownSeconds = *body.AccountCooldownSeconds
+		}
+		if body.AccountDailyQuota != nil {
+			next.AccountDailyQuota = *body.AccountDailyQuota
+		}
+		if body.TargetFailureLimit != nil {
+			next.TargetFailureLimit = *body.TargetFailureLimit
+		}
+		if body.ScheduleEnabled != nil {
+			next.ScheduleEnabled = *body.ScheduleEnabled
+		}
+		if body.ScheduleLeadSeconds != nil {
+			next.ScheduleLeadSeconds = *body.ScheduleLeadSeconds
+		}
+		if body.ScheduleWindowSeconds != nil {
+			next.ScheduleWindowSeconds = *body.ScheduleWindowSeconds
+		}
+		if body.DuplicateTargetPolicy != nil {
+			next.DuplicateTargetPolicy = strings.TrimSpace(*body.DuplicateTargetPolicy)
+		}
+		if body.CaptchaFeePerSolve != nil {
+			next.CaptchaFeePerSolve = *body.CaptchaFeePerSolve
+		}
+		if body.ProxyFeePerGB != nil {
+			next.ProxyFeePerGB = *body.ProxyFeePerGB
+		}
 
 		next = engine.NormalizeNotifySettings(next)
 
@@ -662,8 +1149,27 @@ func (s *Server) handleNotifySettings(w http.ResponseWriter, r *http.Request) {
 }
 
 type limitsSettingsPayload struct {
-	MaxPerTargetInFlight *int `json:"maxPerTargetInFlight,omitempty"`
-	CaptchaMaxInFlight   *int `json:"captchaMaxInFlight,omitempty"`
+	MaxPerTargetInFlight *int     `json:"maxPerTargetInFlight,omitempty"`
+	CaptchaMaxInFlight   *int     `json:"captchaMaxInFlight,omitempty"`
+	GlobalQPS            *float64 `json:"globalQPS,omitempty"`
+	GlobalBurst          *int     `json:"globalBurst,omitempty"`
+	PerAccountQPS        *float64 `json:"perAccountQPS,omitempty"`
+	PerAccountBurst      *int     `json:"perAccountBurst,omitempty"`
+	MaxInFlight          *int     `json:"maxInFlight,omitempty"`
+	AutoTune             *bool    `json:"autoTune,omitempty"`
+}
+
+func limitsSettingsFromConfig(c config.LimitsConfig) model.LimitsSettings {
+	c = engine.NormalizeLimits(c)
+	return model.LimitsSettings{
+		MaxPerTargetInFlight: c.MaxPerTargetInFlight,
+		CaptchaMaxInFlight:   c.CaptchaMaxInFlight,
+		GlobalQPS:            c.GlobalQPS,
+		GlobalBurst:          c.GlobalBurst,
+		PerAccountQPS:        c.PerAccountQPS,
+		PerAccountBurst:      c.PerAccountBurst,
+		MaxInFlight:          c.MaxInFlight,
+	}
 }
 
 func (s *Server) handleLimitsSettings(w http.ResponseWriter, r *http.Request) {
@@ -674,24 +1180,19 @@ func (s *Server) handleLimitsSettings(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
 			return
 		}
-		if !ok {
-			maxPerTarget := s.cfg.Limits.MaxPerTargetInFlight
-			if maxPerTarget <= 0 {
-				maxPerTarget = 1
-			}
-			captchaMax := s.cfg.Limits.CaptchaMaxInFlight
-			if captchaMax <= 0 {
-				captchaMax = 1
-			}
-			writeJSON(w, http.StatusOK, map[string]any{
-				"data": model.LimitsSettings{
-					MaxPerTargetInFlight: maxPerTarget,
-					CaptchaMaxInFlight:   captchaMax,
-				},
-			})
-			return
+		base := s.cfg.Limits
+		if s.engine != nil {
+			base = s.engine.Limits()
+		} else if ok {
+			base = base.WithSettings(val)
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"data": val})
+		data := limitsSettingsFromConfig(base)
+		data.AutoTune = val.AutoTune
+		resp := map[string]any{"data": data}
+		if s.engine != nil {
+			resp["estimate"] = s.engine.RateEstimate()
+		}
+		writeJSON(w, http.StatusOK, resp)
 	case http.MethodPost:
 		var body limitsSettingsPayload
 		if err := readJSON(r, &body); err != nil {
@@ -704,25 +1205,40 @@ func (s *Server) handleLimitsSettings(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
 			return
 		}
-		if !ok {
-			current.MaxPerTargetInFlight = s.cfg.Limits.MaxPerTargetInFlight
-			current.CaptchaMaxInFlight = s.cfg.Limits.CaptchaMaxInFlight
+		base := s.cfg.Limits
+		if ok {
+			base = base.WithSettings(current)
 		}
 
-		next := current
+		next := limitsSettingsFromConfig(base)
 		if body.MaxPerTargetInFlight != nil {
 			next.MaxPerTargetInFlight = *body.MaxPerTargetInFlight
 		}
 		if body.CaptchaMaxInFlight != nil {
 			next.CaptchaMaxInFlight = *body.CaptchaMaxInFlight
 		}
-
-		if next.MaxPerTargetInFlight <= 0 {
-			next.MaxPerTargetInFlight = 1
+		if body.GlobalQPS != nil {
+			next.GlobalQPS = *body.GlobalQPS
+		}
+		if body.GlobalBurst != nil {
+			next.GlobalBurst = *body.GlobalBurst
+		}
+		if body.PerAccountQPS != nil {
+			next.PerAccountQPS = *body.PerAccountQPS
+		}
+		if body.PerAccountBurst != nil {
+			next.PerAccountBurst = *body.PerAccountBurst
 		}
-		if next.CaptchaMaxInFlight <= 0 {
-			next.CaptchaMaxInFlight = 1
+		if body.MaxInFlight != nil {
+			next.MaxInFlight = *body.MaxInFlight
 		}
+		autoTune := current.AutoTune
+		if body.AutoTune != nil {
+			autoTune = *body.AutoTune
+		}
+
+		next = limitsSettingsFromConfig(config.LimitsConfig{}.WithSettings(next))
+		next.AutoTune = autoTune
 		if next.MaxPerTargetInFlight > 200 {
 			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "maxPerTargetInFlight is too large"})
 			return
@@ -731,6 +1247,18 @@ func (s *Server) handleLimitsSettings(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "captchaMaxInFlight is too large"})
 			return
 		}
+		if next.GlobalQPS > 1000 || next.GlobalBurst > 1000 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "global rate limit is too large"})
+			return
+		}
+		if next.PerAccountQPS > 100 || next.PerAccountBurst > 100 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "per-account rate limit is too large"})
+			return
+		}
+		if next.MaxInFlight > 1000 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "maxInFlight is too large"})
+			return
+		}
 
 		saved, err := s.store.UpsertLimitsSettings(r.Context(), next)
 		if err != nil {
@@ -739,7 +1267,8 @@ func (s *Server) handleLimitsSettings(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if s.engine != nil {
-			s.engine.SetMaxPerTargetInFlight(saved.MaxPerTargetInFlight)
+			s.engine.ApplyLimits(config.LimitsConfig{}.WithSettings(saved))
+			s.engine.SetRateAutoTune(saved.AutoTune)
 		}
 		utils.SetCaptchaMaxConcurrent(saved.CaptchaMaxInFlight)
 
@@ -753,6 +1282,7 @@ type captchaPoolSettingsPayload struct {
 	WarmupSeconds  *int `json:"warmupSeconds,omitempty"`
 	PoolSize       *int `json:"poolSize,omitempty"`
 	ItemTTLSeconds *int `json:"itemTtlSeconds,omitempty"`
+	ScanPoolSize   *int `json:"scanPoolSize,omitempty"`
 }
 
 func (s *Server) handleCaptchaPoolSettings(w http.ResponseWriter, r *http.Request) {
@@ -794,6 +1324,9 @@ func (s *Server) handleCaptchaPoolSettings(w http.ResponseWriter, r *http.Reques
 		if body.ItemTTLSeconds != nil {
 			next.ItemTTLSeconds = *body.ItemTTLSeconds
 		}
+		if body.ScanPoolSize != nil {
+			next.ScanPoolSize = *body.ScanPoolSize
+		}
 
 		if next.WarmupSeconds <= 0 {
 			next.WarmupSeconds = 30
@@ -816,6 +1349,10 @@ func (s *Server) handleCaptchaPoolSettings(w http.ResponseWriter, r *http.Reques
 			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "itemTtlSeconds is too large"})
 			return
 		}
+		if next.ScanPoolSize < 0 || next.ScanPoolSize > next.PoolSize {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "scanPoolSize must be within 0-poolSize"})
+			return
+		}
 
 		saved, err := s.store.UpsertCaptchaPoolSettings(r.Context(), next)
 		if err != nil {
@@ -970,7 +1507,7 @@ func (s *Server) handleUpstreamProxy(w http.ResponseWriter, r *http.Request) {
 	var (
 		acc        model.Account
 		client     *resty.Client
-		jar        *cookiejar.Jar
+		jar        *utils.CookieJar
 		baseURL    *url.URL
 		persistAcc bool
 	)
@@ -1097,7 +1634,7 @@ func transformIdentifyLoginBody(body []byte) []byte {
 	return out
 }
 
-func (s *Server) newAnonymousUpstreamClient(jar *cookiejar.Jar, userAgent string) (*resty.Client, *url.URL, error) {
+func (s *Server) newAnonymousUpstreamClient(jar *utils.CookieJar, userAgent string) (*resty.Client, *url.URL, error) {
 	if jar == nil {
 		return nil, nil, errors.New("cookie jar is required")
 	}
@@ -1149,7 +1686,7 @@ func (s *Server) newAnonymousUpstreamClient(jar *cookiejar.Jar, userAgent string
 	return client, baseURL, nil
 }
 
-func (s *Server) tryPersistLoginSession(ctx context.Context, reqBody, respBody []byte, baseURL *url.URL, jar *cookiejar.Jar) error {
+func (s *Server) tryPersistLoginSession(ctx context.Context, reqBody, respBody []byte, baseURL *url.URL, jar *utils.CookieJar) error {
 	if s.store == nil {
 		return nil
 	}
@@ -1214,7 +1751,7 @@ func extractLoginRequestFields(body []byte) (mobile string, userAgent string, de
 	return mobile, userAgent, deviceID, uuid, nil
 }
 
-func (s *Server) fetchCurrentUserUsername(ctx context.Context, jar *cookiejar.Jar, token string, userAgent string) (string, error) {
+func (s *Server) fetchCurrentUserUsername(ctx context.Context, jar *utils.CookieJar, token string, userAgent string) (string, error) {
 	if jar == nil {
 		return "", errors.New("cookie jar is required")
 	}
@@ -1368,8 +1905,8 @@ func buildUpstreamURL(base, path, rawQuery string) (*url.URL, error) {
 	return u, nil
 }
 
-func (s *Server) newUpstreamClient(account model.Account) (*resty.Client, *cookiejar.Jar, *url.URL, error) {
-	jar, err := cookiejar.New(nil)
+func (s *Server) newUpstreamClient(account model.Account) (*resty.Client, *utils.CookieJar, *url.URL, error) {
+	jar, err := utils.NewCookieJar()
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -1430,7 +1967,7 @@ func (s *Server) newUpstreamClient(account model.Account) (*resty.Client, *cooki
 	return client, jar, baseURL, nil
 }
 
-func importCookies(jar *cookiejar.Jar, entries []model.CookieJarEntry) {
+func importCookies(jar *utils.CookieJar, entries []model.CookieJarEntry) {
 	for _, entry := range entries {
 		u, err := url.Parse(entry.URL)
 		if err != nil {
@@ -1440,7 +1977,7 @@ func importCookies(jar *cookiejar.Jar, entries []model.CookieJarEntry) {
 	}
 }
 
-func exportCookies(baseURL *url.URL, jar *cookiejar.Jar) []model.CookieJarEntry {
+func exportCookies(baseURL *url.URL, jar *utils.CookieJar) []model.CookieJarEntry {
 	if baseURL == nil {
 		return nil
 	}
@@ -0,0 +1,23 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// handleSlowRequests 返回最近保存的慢请求追踪（?limit= 默认 100，?accountId= 按账号过滤）。
+func (s *Server) handleSlowRequests(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit, _ := strconv.Atoi(strings.TrimSpace(r.URL.Query().Get("limit")))
+	accountID := strings.TrimSpace(r.URL.Query().Get("accountId"))
+	list, err := s.store.ListSlowRequests(r.Context(), accountID, limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": list, "thresholdMs": s.cfg.Provider.SlowRequestThreshold().Milliseconds()})
+}
@@ -0,0 +1,271 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/engine"
+	"sniping_engine/internal/logbus"
+	"sniping_engine/internal/model"
+)
+
+func (s *Server) handleTargetSubroutes(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/targets/"), "/")
+	parts := strings.Split(rest, "/")
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not found"})
+		return
+	}
+	id := strings.TrimSpace(parts[0])
+
+	switch parts[1] {
+	case "export-log":
+		s.handleTargetExportLog(w, r, id)
+	case "enabled":
+		s.handleTargetEnabled(w, r, id)
+	case "merge":
+		s.handleTargetMerge(w, r, id)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not found"})
+	}
+}
+
+// handleTargetExportLog 以附件形式导出目标在某次运行批次中的日志、尝试、订单与验证码使用情况；
+// ?runId= 为空时导出当前批次，?format=text 输出便于直接粘贴的纯文本，默认 JSON。
+func (s *Server) handleTargetExportLog(w http.ResponseWriter, r *http.Request, targetID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	report, err := s.engine.TargetRunReport(ctx, targetID, r.URL.Query().Get("runId"))
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+		return
+	}
+
+	name := fmt.Sprintf("target-%s-%s", safeFileName(targetID), safeFileName(report.RunID))
+	if strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("format")), "text") {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.txt"`)
+		writeRunReportText(w, report)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.json"`)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(report)
+}
+
+func safeFileName(v string) string {
+	v = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, v)
+	if v == "" {
+		return "none"
+	}
+	return v
+}
+
+func formatReportTime(ms int64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return time.UnixMilli(ms).Format("2006-01-02 15:04:05.000")
+}
+
+func writeRunReportText(w http.ResponseWriter, rep engine.TargetRunReport) {
+	t := rep.Target
+	fmt.Fprintf(w, "目标 %s %s\n", t.ID, t.Name)
+	fmt.Fprintf(w, "运行批次 %s，导出时间 %s\n", rep.RunID, formatReportTime(rep.GeneratedAtMs))
+	fmt.Fprintf(w, "模式 %s，itemId=%d skuId=%d，目标数量 %d，每单 %d，开抢 %s，提前量 %dms\n",
+		t.Mode, t.ItemID, t.SKUID, t.TargetQty, t.PerOrderQty, formatReportTime(t.RushAtMs), t.RushLeadMs)
+	if st := rep.State; st != nil {
+		fmt.Fprintf(w, "进度 %d/%d，状态 %s %s，最近错误 %s\n", st.PurchasedQty, st.TargetQty, st.Status, st.StatusReason, st.LastError)
+	}
+	c := rep.Captcha
+	fmt.Fprintf(w, "验证码：需要 %d 次，命中验证码池 %d 次，免滑块凭证 %d 次，失败 %d 次，当前池内 %d 条\n",
+		c.NeedCaptcha, c.FromPool, c.VerifyTokenUsed, c.Errors, c.PoolSize)
+	cost := rep.Cost
+	fmt.Fprintf(w, "估算花费：合计 %.4f 元（验证码 %d 次 %.4f 元，代理流量 %.2f MB %.4f 元），成功 %d 单，每单 %.4f 元\n",
+		cost.Total, cost.CaptchaSolves, cost.CaptchaFee, float64(cost.ProxyBytes)/(1<<20), cost.ProxyFee, cost.Orders, cost.PerOrder)
+
+	fmt.Fprintf(w, "\n== 订单（%d）==\n", len(rep.Orders))
+	for _, o := range rep.Orders {
+		fmt.Fprintf(w, "%s  account=%s  order=%s\n", formatReportTime(o.CreatedAtMs), o.AccountID, o.OrderID)
+	}
+
+	fmt.Fprintf(w, "\n== 尝试（%d）==\n", len(rep.Attempts))
+	for _, a := range rep.Attempts {
+		result := "ok"
+		if !a.Success {
+			result = string(a.ErrorClass)
+		}
+		fmt.Fprintf(w, "%s  #%d account=%s phase=%s result=%s total=%dms preflight=%dms captcha=%dms create=%dms",
+			formatReportTime(a.StartedAtMs), a.ID, a.AccountID, a.Phase, result,
+			a.Latency.TotalMs, a.Latency.PreflightMs, a.Latency.CaptchaMs, a.Latency.CreateMs)
+		if a.BizCode != "" {
+			fmt.Fprintf(w, " bizCode=%s/%s", a.BizCode, a.BizClass)
+		}
+		if a.OrderID != "" {
+			fmt.Fprintf(w, " order=%s", a.OrderID)
+		}
+		if a.Cost.Total > 0 {
+			fmt.Fprintf(w, " cost=%.4f", a.Cost.Total)
+		}
+		if a.Error != "" {
+			fmt.Fprintf(w, " error=%q", a.Error)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "\n== 日志（%d）==\n", len(rep.Logs))
+	for _, m := range rep.Logs {
+		d, ok := m.Data.(logbus.LogData)
+		if !ok {
+			b, _ := json.Marshal(m.Data)
+			fmt.Fprintf(w, "%s  [%s] %s\n", formatReportTime(m.Time), m.Type, b)
+			continue
+		}
+		fmt.Fprintf(w, "%s  [%s] %s", formatReportTime(m.Time), d.Level, d.Msg)
+		keys := make([]string, 0, len(d.Fields))
+		for k := range d.Fields {
+			if k != "targetId" {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, " %s=%v", k, d.Fields[k])
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+type targetEnabledPayload struct {
+	// Enabled 不传时翻转当前状态。
+	Enabled *bool `json:"enabled"`
+}
+
+// handleTargetEnabled 只修改目标的 enabled 字段（避免整条重新提交覆盖其它字段），同步引擎后返回最新任务状态。
+func (s *Server) handleTargetEnabled(w http.ResponseWriter, r *http.Request, targetID string) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body targetEnabledPayload
+	if err := readJSON(r, &body); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+
+	target, err := s.store.GetTarget(r.Context(), targetID)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+		return
+	}
+	enabled := !target.Enabled
+	if body.Enabled != nil {
+		enabled = *body.Enabled
+	}
+	if err := s.store.SetTargetEnabled(r.Context(), target.ID, enabled); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	target.Enabled = enabled
+
+	if s.engine == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"data": model.TaskState{TargetID: target.ID, TargetQty: target.TargetQty}})
+		return
+	}
+	syncCtx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	if err := s.engine.AutoRunByStore(syncCtx); err != nil && s.bus != nil {
+		s.bus.Log("warn", "修改任务启用状态后同步引擎失败", map[string]any{
+			"targetId": target.ID,
+			"enabled":  enabled,
+			"error":    err.Error(),
+		})
+	}
+	cancel()
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.engine.TaskStateOf(r.Context(), target)})
+}
+
+type targetMergePayload struct {
+	SourceIDs []string `json:"sourceIds"`
+}
+
+// handleTargetMerge 把指向同一商品规格的其它目标合并进该目标：目标数量与已购数量相加，
+// 尝试与订单历史改挂到该目标，被合并的目标删除；完成后同步引擎。
+func (s *Server) handleTargetMerge(w http.ResponseWriter, r *http.Request, targetID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.engine == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "engine unavailable"})
+		return
+	}
+	var body targetMergePayload
+	if err := readJSON(r, &body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	if len(body.SourceIDs) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "sourceIds is required"})
+		return
+	}
+
+	res, err := s.engine.MergeTargets(r.Context(), targetID, body.SourceIDs)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, sql.ErrNoRows) {
+			status = http.StatusNotFound
+		}
+		writeJSON(w, status, map[string]any{"error": err.Error()})
+		return
+	}
+
+	syncCtx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	if err := s.engine.AutoRunByStore(syncCtx); err != nil && s.bus != nil {
+		s.bus.Log("warn", "合并任务后同步引擎失败", map[string]any{
+			"targetId": targetID,
+			"error":    err.Error(),
+		})
+	}
+	cancel()
+	writeJSON(w, http.StatusOK, map[string]any{"data": res})
+}
+
+// duplicateTargetPolicy 返回重复目标策略；引擎不可用时读取已保存的设置。
+func (s *Server) duplicateTargetPolicy(ctx context.Context) string {
+	if s.engine != nil {
+		return s.engine.DuplicateTargetPolicy()
+	}
+	if st, ok, err := s.store.GetNotifySettings(ctx); err == nil && ok {
+		return engine.NormalizeNotifySettings(st).DuplicateTargetPolicy
+	}
+	return engine.DuplicateTargetsWarn
+}
+
+// blockDuplicateTargets 返回保存目标时是否拒绝与已有目标指向同一商品规格（block 策略）。
+func (s *Server) blockDuplicateTargets(ctx context.Context) bool {
+	return s.duplicateTargetPolicy(ctx) == engine.DuplicateTargetsBlock
+}
@@ -0,0 +1,63 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/store/sqlite"
+)
+
+type targetsBulkPayload struct {
+	IDs           []string `json:"ids"`
+	Enabled       *bool    `json:"enabled,omitempty"`
+	Mode          *string  `json:"mode,omitempty"`
+	RushAtDeltaMs int64    `json:"rushAtDeltaMs,omitempty"`
+}
+
+// handleTargetsBulk 批量启用/停用、整体平移开抢时间、切换模式（同一事务），完成后同步引擎。
+func (s *Server) handleTargetsBulk(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body targetsBulkPayload
+	if err := readJSON(r, &body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	if len(body.IDs) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "ids is required"})
+		return
+	}
+	if body.Enabled == nil && body.Mode == nil && body.RushAtDeltaMs == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "nothing to update"})
+		return
+	}
+
+	patch := sqlite.TargetBulkPatch{Enabled: body.Enabled, RushAtDeltaMs: body.RushAtDeltaMs}
+	if body.Mode != nil {
+		mode := model.TargetMode(strings.TrimSpace(*body.Mode))
+		patch.Mode = &mode
+	}
+
+	targets, err := s.store.BulkUpdateTargets(r.Context(), body.IDs, patch)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+
+	if s.engine != nil {
+		syncCtx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		if err := s.engine.AutoRunByStore(syncCtx); err != nil && s.bus != nil {
+			s.bus.Log("warn", "批量修改任务后同步引擎失败", map[string]any{
+				"count": len(targets),
+				"error": err.Error(),
+			})
+		}
+		cancel()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": targets})
+}
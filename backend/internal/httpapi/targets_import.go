@@ -0,0 +1,76 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// targetsImportMaxBytes 批量导入请求体的大小上限。
+const targetsImportMaxBytes = 4 << 20
+
+// handleTargetsImport 批量导入目标：请求体为 JSON 数组（或 {"targets": [...]}），Content-Type 为 text/csv
+// 或 ?format=csv 时按带表头的 CSV 解析。?overwrite=true 覆盖同 ID 的已有目标，?dryRun=true 只校验不写入；
+// 返回逐行结果，单行失败不影响其它行。
+func (s *Server) handleTargetsImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	overwrite, _ := strconv.ParseBool(strings.TrimSpace(q.Get("overwrite")))
+	dryRun, _ := strconv.ParseBool(strings.TrimSpace(q.Get("dryRun")))
+
+	raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, targetsImportMaxBytes))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+
+	var rows []model.TargetImportRow
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if strings.EqualFold(strings.TrimSpace(q.Get("format")), "csv") || mediaType == "text/csv" {
+		rows, err = model.ParseTargetsCSV(bytes.NewReader(raw))
+	} else {
+		rows, err = model.ParseTargetsJSON(raw)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	if len(rows) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "no targets to import"})
+		return
+	}
+
+	res, err := s.store.ImportTargets(r.Context(), rows, overwrite, dryRun, s.blockDuplicateTargets(r.Context()))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	if !dryRun && res.Created+res.Updated > 0 {
+		if s.bus != nil {
+			s.bus.Log("info", "已批量导入目标", map[string]any{
+				"created": res.Created,
+				"updated": res.Updated,
+				"skipped": res.Skipped,
+				"failed":  res.Failed,
+			})
+		}
+		if s.engine != nil {
+			syncCtx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+			if err := s.engine.AutoRunByStore(syncCtx); err != nil && s.bus != nil {
+				s.bus.Log("warn", "批量导入目标后同步引擎失败", map[string]any{"error": err.Error()})
+			}
+			cancel()
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": res})
+}
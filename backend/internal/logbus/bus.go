@@ -2,13 +2,15 @@ package logbus
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Message struct {
-	Type string `json:"type"`
-	Time int64  `json:"time"`
-	Data any    `json:"data"`
+	Type  string `json:"type"`
+	Time  int64  `json:"time"`
+	RunID string `json:"runId,omitempty"`
+	Data  any    `json:"data"`
 }
 
 type LogData struct {
@@ -26,6 +28,10 @@ type ProgressData struct {
 	TargetID  string         `json:"targetId,omitempty"`
 	AccountID string         `json:"accountId,omitempty"`
 	Fields    map[string]any `json:"fields,omitempty"`
+	// ElapsedMs 距本次操作开始的毫秒数；PhaseMs 为刚结束的步骤耗时（只出现在步骤结束的消息上），
+	// 前端时间线可直接展示 render/验证码/下单各段耗时，不必自行对齐时间戳。
+	ElapsedMs int64 `json:"elapsedMs"`
+	PhaseMs   int64 `json:"phaseMs,omitempty"`
 }
 
 type Bus struct {
@@ -34,6 +40,11 @@ type Bus struct {
 	cap    int
 	subs   map[chan Message]struct{}
 	closed bool
+	runID  string
+	// nsubs 与 subs 同步维护，供 HasSubscribers 无锁读取。
+	nsubs atomic.Int32
+	// redact 为 Log 字段的脱敏规则，nil 表示不脱敏，见 SetRedaction。
+	redact atomic.Pointer[redactor]
 }
 
 func New(capacity int) *Bus {
@@ -58,9 +69,23 @@ func (b *Bus) Close() {
 		close(ch)
 	}
 	b.subs = nil
+	b.nsubs.Store(0)
 	b.buf = nil
 }
 
+// SetRunID 设置当前运行批次，之后发布的消息都会带上该 runId。
+func (b *Bus) SetRunID(id string) {
+	b.mu.Lock()
+	b.runID = id
+	b.mu.Unlock()
+}
+
+func (b *Bus) RunID() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.runID
+}
+
 func (b *Bus) Snapshot() []Message {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -81,6 +106,7 @@ func (b *Bus) Subscribe(buffer int) (<-chan Message, func()) {
 		return ch, func() {}
 	}
 	b.subs[ch] = struct{}{}
+	b.nsubs.Add(1)
 	b.mu.Unlock()
 
 	cancel := func() {
@@ -88,6 +114,7 @@ func (b *Bus) Subscribe(buffer int) (<-chan Message, func()) {
 		if b.subs != nil {
 			if _, ok := b.subs[ch]; ok {
 				delete(b.subs, ch)
+				b.nsubs.Add(-1)
 				close(ch)
 			}
 		}
@@ -108,6 +135,7 @@ func (b *Bus) Publish(typ string, data any) {
 		b.mu.Unlock()
 		return
 	}
+	msg.RunID = b.runID
 	if len(b.buf) < b.cap {
 		b.buf = append(b.buf, msg)
 	} else if b.cap > 0 {
@@ -123,6 +151,32 @@ func (b *Bus) Publish(typ string, data any) {
 	b.mu.Unlock()
 }
 
+// HasSubscribers 返回当前是否有订阅者（WS 客户端）在线；进度这类只对在线客户端有意义的高频消息可据此跳过构造与发布。
+func (b *Bus) HasSubscribers() bool {
+	return b.nsubs.Load() > 0
+}
+
 func (b *Bus) Log(level, message string, fields map[string]any) {
+	if r := b.redact.Load(); r != nil {
+		fields = r.fields(fields)
+	}
 	b.Publish("log", LogData{Level: level, Msg: message, Fields: fields})
 }
+
+// PruneBefore 丢弃缓冲中时间早于 cutoffMs 的消息（cutoffMs<=0 时清空全部），返回丢弃条数；不影响订阅者已收到的消息。
+func (b *Bus) PruneBefore(cutoffMs int64) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	keep := b.buf[:0]
+	for _, m := range b.buf {
+		if cutoffMs > 0 && m.Time >= cutoffMs {
+			keep = append(keep, m)
+		}
+	}
+	n := len(b.buf) - len(keep)
+	for i := len(keep); i < len(b.buf); i++ {
+		b.buf[i] = Message{}
+	}
+	b.buf = keep
+	return n
+}
@@ -0,0 +1,103 @@
+package logbus
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MaxQueryLimit Query 单次最多返回的消息数。
+const MaxQueryLimit = 1000
+
+// Query 是缓冲区消息的筛选条件，零值表示不限制。
+type Query struct {
+	// SinceMs 只返回 Time 大于该值的消息（用于断线重连后增量补齐）。
+	SinceMs int64
+	// BeforeMs 只返回 Time 小于该值的消息（用于向前翻页）。
+	BeforeMs int64
+	// Types 只返回这些类型的消息。
+	Types []string
+	// Limit 最多返回最近的多少条，<=0 表示不限。
+	Limit int
+}
+
+// QueryResult 是按时间正序排列的筛选结果；HasMore 表示因 Limit 截掉了更早的匹配消息，
+// 可用第一条消息的 Time 作为 BeforeMs 继续向前翻页。
+type QueryResult struct {
+	Messages []Message `json:"messages"`
+	HasMore  bool      `json:"hasMore"`
+}
+
+// Query 按条件筛选缓冲区中的消息，返回满足条件的最近 Limit 条。
+func (b *Bus) Query(q Query) QueryResult {
+	var types map[string]bool
+	if len(q.Types) > 0 {
+		types = make(map[string]bool, len(q.Types))
+		for _, t := range q.Types {
+			types[t] = true
+		}
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	out := QueryResult{Messages: []Message{}}
+	// 从最新往前取，最后再翻转成时间正序。
+	for i := len(b.buf) - 1; i >= 0; i-- {
+		m := b.buf[i]
+		if (q.SinceMs > 0 && m.Time <= q.SinceMs) || (q.BeforeMs > 0 && m.Time >= q.BeforeMs) {
+			continue
+		}
+		if types != nil && !types[m.Type] {
+			continue
+		}
+		if q.Limit > 0 && len(out.Messages) >= q.Limit {
+			out.HasMore = true
+			break
+		}
+		out.Messages = append(out.Messages, m)
+	}
+	for i, j := 0, len(out.Messages)-1; i < j; i, j = i+1, j-1 {
+		out.Messages[i], out.Messages[j] = out.Messages[j], out.Messages[i]
+	}
+	return out
+}
+
+// ParseQuery 从 URL 参数解析筛选条件：since、before（毫秒时间戳）、types（逗号分隔，可重复）、limit。
+// limit 缺省时取 defLimit，超过 MaxQueryLimit 时按上限处理。
+func ParseQuery(v url.Values, defLimit int) (Query, error) {
+	q := Query{Limit: defLimit}
+	for _, key := range []string{"since", "before"} {
+		raw := strings.TrimSpace(v.Get(key))
+		if raw == "" {
+			continue
+		}
+		ms, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || ms < 0 {
+			return Query{}, fmt.Errorf("invalid %s", key)
+		}
+		if key == "since" {
+			q.SinceMs = ms
+		} else {
+			q.BeforeMs = ms
+		}
+	}
+	for _, raw := range v["types"] {
+		for _, t := range strings.Split(raw, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				q.Types = append(q.Types, t)
+			}
+		}
+	}
+	if raw := strings.TrimSpace(v.Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return Query{}, fmt.Errorf("invalid limit")
+		}
+		q.Limit = n
+	}
+	if q.Limit <= 0 || q.Limit > MaxQueryLimit {
+		q.Limit = MaxQueryLimit
+	}
+	return q, nil
+}
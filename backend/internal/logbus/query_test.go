@@ -0,0 +1,49 @@
+package logbus
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestQueryFiltersAndPages(t *testing.T) {
+	b := New(10)
+	for i := 0; i < 4; i++ {
+		b.Publish("task_state", i)
+		b.Log("info", "tick", map[string]any{"i": i})
+	}
+
+	res := b.Query(Query{Types: []string{"task_state"}, Limit: 3})
+	if len(res.Messages) != 3 || !res.HasMore {
+		t.Fatalf("got %d messages, hasMore=%v", len(res.Messages), res.HasMore)
+	}
+	if res.Messages[0].Data != 1 || res.Messages[2].Data != 3 {
+		t.Fatalf("want the latest three in chronological order, got %v", res.Messages)
+	}
+
+	all := b.Snapshot()
+	res = b.Query(Query{SinceMs: all[len(all)-1].Time - 1})
+	for _, m := range res.Messages {
+		if m.Time <= all[len(all)-1].Time-1 {
+			t.Fatalf("message before since: %+v", m)
+		}
+	}
+	if res.HasMore {
+		t.Fatal("no limit should never report hasMore")
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(url.Values{"types": {"log,task_state", "progress"}, "since": {"10"}}, 50)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if q.SinceMs != 10 || q.Limit != 50 || len(q.Types) != 3 {
+		t.Fatalf("query = %+v", q)
+	}
+	if q, _ := ParseQuery(url.Values{"limit": {"5000"}}, 50); q.Limit != MaxQueryLimit {
+		t.Fatalf("limit = %d, want capped at %d", q.Limit, MaxQueryLimit)
+	}
+	if _, err := ParseQuery(url.Values{"before": {"x"}}, 50); err == nil {
+		t.Fatal("invalid before should fail")
+	}
+}
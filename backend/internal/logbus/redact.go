@@ -0,0 +1,176 @@
+package logbus
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// DefaultRedactKeys 默认脱敏的字段名片段（不区分大小写，字段名包含即命中）。
+var DefaultRedactKeys = []string{"token", "authorization", "cookie", "authCode"}
+
+// RedactedValue 替换被脱敏内容的占位值。
+const RedactedValue = "***"
+
+type redactor struct {
+	keys []string
+	// inline 匹配字符串值里 key=value 形式的片段（URL 查询参数、cookie 串等）。
+	inline *regexp.Regexp
+	bearer *regexp.Regexp
+}
+
+func newRedactor(keys []string) *redactor {
+	r := &redactor{bearer: regexp.MustCompile(`(?i)(bearer\s+)[^\s"',;]+`)}
+	quoted := make([]string, 0, len(keys))
+	for _, k := range keys {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" {
+			continue
+		}
+		r.keys = append(r.keys, k)
+		quoted = append(quoted, regexp.QuoteMeta(k))
+	}
+	if len(quoted) > 0 {
+		r.inline = regexp.MustCompile(`(?i)([\w.-]*(?:` + strings.Join(quoted, "|") + `)[\w.-]*\s*[=:]\s*)[^&;\s"',]+`)
+	}
+	return r
+}
+
+func (r *redactor) sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// fields 返回脱敏后的字段副本，调用方传入的 map 不被修改。
+func (r *redactor) fields(in map[string]any) map[string]any {
+	if len(in) == 0 {
+		return in
+	}
+	out := make(map[string]any, len(in))
+	for k, v := range in {
+		if r.sensitive(k) {
+			out[k] = maskValue(v)
+			continue
+		}
+		out[k] = r.value(v)
+	}
+	return out
+}
+
+// maskValue 隐去敏感字段的值；空值、布尔和数字（如 tokenExpired、cookieCount）原样保留。
+func maskValue(v any) any {
+	switch t := v.(type) {
+	case nil, bool, int, int64, float64:
+		return v
+	case string:
+		if t == "" {
+			return t
+		}
+	}
+	return RedactedValue
+}
+
+func (r *redactor) value(v any) any {
+	switch t := v.(type) {
+	case string:
+		return r.text(t)
+	case map[string]any:
+		return r.fields(t)
+	case map[string]string:
+		out := make(map[string]any, len(t))
+		for k, s := range t {
+			out[k] = s
+		}
+		return r.fields(out)
+	case []any:
+		out := make([]any, len(t))
+		for i, x := range t {
+			out[i] = r.value(x)
+		}
+		return out
+	case []string:
+		out := make([]string, len(t))
+		for i, x := range t {
+			out[i] = r.text(x)
+		}
+		return out
+	case error:
+		return r.text(t.Error())
+	}
+	return v
+}
+
+// text 隐去字符串中 token=xxx、Cookie: xxx、Bearer xxx 一类片段的值。
+func (r *redactor) text(s string) string {
+	if s == "" {
+		return s
+	}
+	s = r.bearer.ReplaceAllString(s, "${1}"+RedactedValue)
+	if r.inline != nil {
+		s = r.inline.ReplaceAllString(s, "${1}"+RedactedValue)
+	}
+	return s
+}
+
+// SetRedaction 设置 Log 字段的脱敏规则：enabled=false 关闭（本地调试用），keys 为空时使用 DefaultRedactKeys。
+// 脱敏发生在写入缓冲和广播之前，快照与所有订阅者看到的都是脱敏后的内容。
+func (b *Bus) SetRedaction(enabled bool, keys []string) {
+	if !enabled {
+		b.redact.Store(nil)
+		return
+	}
+	if len(keys) == 0 {
+		keys = DefaultRedactKeys
+	}
+	b.redact.Store(newRedactor(keys))
+}
+
+// RedactionEnabled 返回 Log 字段脱敏是否开启。
+func (b *Bus) RedactionEnabled() bool {
+	return b.redact.Load() != nil
+}
+
+// MessageRedactor 脱敏日志/进度消息的字段，供比总线规则更严格的订阅方使用（如 viewer 角色的 WS 连接）。
+type MessageRedactor struct {
+	r *redactor
+}
+
+// NewMessageRedactor 按 DefaultRedactKeys 加上 extraKeys 创建消息脱敏器。
+func NewMessageRedactor(extraKeys ...string) *MessageRedactor {
+	keys := append(append([]string{}, DefaultRedactKeys...), extraKeys...)
+	return &MessageRedactor{r: newRedactor(keys)}
+}
+
+// Redact 返回字段脱敏后的消息副本，总线里的原消息不受影响；日志/进度以外的消息原样返回。
+func (m *MessageRedactor) Redact(msg Message) Message {
+	switch d := msg.Data.(type) {
+	case LogData:
+		d.Fields = m.r.fields(d.Fields)
+		msg.Data = d
+	case ProgressData:
+		d.Fields = m.r.fields(d.Fields)
+		msg.Data = d
+	}
+	return msg
+}
+
+// RedactJSON 按 keys（为空时用 DefaultRedactKeys）脱敏任意 JSON 文档，返回重新编码后的结果。
+// 用于把上游原始响应交给调试接口前去掉 token、cookie 一类的敏感值。
+func RedactJSON(raw []byte, keys []string) ([]byte, error) {
+	if len(keys) == 0 {
+		keys = DefaultRedactKeys
+	}
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(newRedactor(keys).value(v))
+}
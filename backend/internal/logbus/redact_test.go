@@ -0,0 +1,71 @@
+package logbus
+
+import "testing"
+
+func TestLogRedactsSensitiveFields(t *testing.T) {
+	b := New(10)
+	b.SetRedaction(true, nil)
+	fields := map[string]any{
+		"accountId":    "a1",
+		"token":        "tok-123",
+		"authCode":     "ac-9",
+		"tokenExpired": true,
+		"url":          "https://m.example.com/api/login?authCode=ac-9&itemId=5",
+		"headers":      map[string]any{"Authorization": "Bearer abc.def", "X-Trace": "t1"},
+		"error":        "render-order failed: Authorization=Bearer xyz",
+	}
+	b.Log("info", "login", fields)
+
+	got := b.Snapshot()[0].Data.(LogData).Fields
+	if got["token"] != RedactedValue || got["authCode"] != RedactedValue || got["accountId"] != "a1" {
+		t.Fatalf("fields = %v", got)
+	}
+	if got["tokenExpired"] != true {
+		t.Fatalf("boolean flag should be kept, got %v", got["tokenExpired"])
+	}
+	if want := "https://m.example.com/api/login?authCode=***&itemId=5"; got["url"] != want {
+		t.Fatalf("url = %v, want %v", got["url"], want)
+	}
+	if h := got["headers"].(map[string]any); h["Authorization"] != RedactedValue || h["X-Trace"] != "t1" {
+		t.Fatalf("headers = %v", h)
+	}
+	if got["error"] != "render-order failed: Authorization=*** ***" {
+		t.Fatalf("error = %v", got["error"])
+	}
+	if fields["token"] != "tok-123" {
+		t.Fatal("caller's map must not be modified")
+	}
+
+	b.SetRedaction(false, nil)
+	b.Log("debug", "raw", map[string]any{"token": "tok-123"})
+	if got := b.Snapshot()[1].Data.(LogData).Fields["token"]; got != "tok-123" {
+		t.Fatalf("redaction disabled but token = %v", got)
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	raw := []byte(`{"code":0,"data":{"token":"tok-1","mobile":"13800000000","totalFee":1990,"items":[{"cookie":"a=b","skuId":12345678901234567890}]}}`)
+	out, err := RedactJSON(raw, append(DefaultRedactKeys, "mobile"))
+	if err != nil {
+		t.Fatalf("RedactJSON: %v", err)
+	}
+	want := `{"code":0,"data":{"items":[{"cookie":"***","skuId":12345678901234567890}],"mobile":"***","token":"***","totalFee":1990}}`
+	if string(out) != want {
+		t.Fatalf("out = %s, want %s", out, want)
+	}
+	if _, err := RedactJSON([]byte(`not json`), nil); err == nil {
+		t.Fatal("expected error for invalid JSON")
+	}
+}
+
+func TestMessageRedactorAddsExtraKeys(t *testing.T) {
+	r := NewMessageRedactor("password")
+	msg := Message{Type: "progress", Data: ProgressData{Fields: map[string]any{"password": "p", "token": "t", "step": "login"}}}
+	got := r.Redact(msg).Data.(ProgressData).Fields
+	if got["password"] != RedactedValue || got["token"] != RedactedValue || got["step"] != "login" {
+		t.Fatalf("fields = %v", got)
+	}
+	if msg.Data.(ProgressData).Fields["password"] != "p" {
+		t.Fatal("original message must not be modified")
+	}
+}
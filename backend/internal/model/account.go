@@ -1,19 +1,28 @@
 package model
 
-import "time"
-
 type Account struct {
-	ID        string           `json:"id"`
-	Username  string           `json:"username,omitempty"`
-	Mobile    string           `json:"mobile"`
-	Token     string           `json:"token,omitempty"`
-	UserAgent string           `json:"userAgent,omitempty"`
-	DeviceID  string           `json:"deviceId,omitempty"`
-	UUID      string           `json:"uuid,omitempty"`
-	Proxy     string           `json:"proxy,omitempty"`
-	AddressID int64            `json:"addressId,omitempty"`
-	DivisionIDs string         `json:"divisionIds,omitempty"`
-	Cookies   []CookieJarEntry `json:"cookies,omitempty"`
-	CreatedAt time.Time        `json:"createdAt"`
-	UpdatedAt time.Time        `json:"updatedAt"`
+	ID        string `json:"id"`
+	Username  string `json:"username,omitempty"`
+	Mobile    string `json:"mobile"`
+	Token     string `json:"token,omitempty"`
+	UserAgent string `json:"userAgent,omitempty"`
+	DeviceID  string `json:"deviceId,omitempty"`
+	UUID      string `json:"uuid,omitempty"`
+	Proxy     string `json:"proxy,omitempty"`
+	// ProxyID 引用代理池中的代理；非空时 Proxy 在读取账号时解析为该代理的地址。
+	ProxyID     string           `json:"proxyId,omitempty"`
+	AddressID   int64            `json:"addressId,omitempty"`
+	DivisionIDs string           `json:"divisionIds,omitempty"`
+	Cookies     []CookieJarEntry `json:"cookies,omitempty"`
+	// CredentialRef 非空时 token/cookie 保存在外部凭据来源，读取账号时按引用解析。
+	CredentialRef string `json:"credentialRef,omitempty"`
+	// Env 环境标记（如 staging/production），跨实例导入时用于区分来源。
+	Env string `json:"env,omitempty"`
+	// ValidatedAtMs/Valid/ValidationErr 为最近一次账号校验（current-user 等接口）的时间、结果与失败原因；
+	// 从未校验或更换 token 后 ValidatedAtMs 为 0、Valid 为空。
+	ValidatedAtMs int64     `json:"validatedAtMs,omitempty"`
+	Valid         *bool     `json:"valid,omitempty"`
+	ValidationErr string    `json:"validationError,omitempty"`
+	CreatedAt     Timestamp `json:"createdAt"`
+	UpdatedAt     Timestamp `json:"updatedAt"`
 }
@@ -0,0 +1,174 @@
+package model
+
+// AttemptPhase 是一次下单尝试走到的最后阶段。
+type AttemptPhase string
+
+const (
+	AttemptPhaseStart     AttemptPhase = "start"
+	AttemptPhasePreflight AttemptPhase = "preflight"
+	AttemptPhaseCaptcha   AttemptPhase = "captcha"
+	AttemptPhaseCreate    AttemptPhase = "create"
+	AttemptPhaseDone      AttemptPhase = "done"
+)
+
+// AttemptErrorClass 是尝试未成功的原因分类（成功时为空）。
+type AttemptErrorClass string
+
+const (
+	AttemptErrorQuotaReached     AttemptErrorClass = "quota_reached"
+	AttemptErrorCanceled         AttemptErrorClass = "canceled"
+	AttemptErrorPaused           AttemptErrorClass = "paused"
+	AttemptErrorPreflightBackoff AttemptErrorClass = "preflight_backoff"
+	AttemptErrorPreflight        AttemptErrorClass = "preflight_error"
+	AttemptErrorNotPurchasable   AttemptErrorClass = "not_purchasable"
+	AttemptErrorBelowMinStock    AttemptErrorClass = "below_min_stock"
+	AttemptErrorPriceOverCap     AttemptErrorClass = "price_over_cap"
+	AttemptErrorCaptcha          AttemptErrorClass = "captcha_error"
+	AttemptErrorDuplicateOrder   AttemptErrorClass = "duplicate_order"
+	AttemptErrorCreate           AttemptErrorClass = "create_error"
+	AttemptErrorCircuitOpen      AttemptErrorClass = "circuit_open" // 上游接口熔断中，请求没有发出
+)
+
+// AttemptDisposition 是按上游业务错误分类决定的后续处理方式。
+type AttemptDisposition string
+
+const (
+	// AttemptRetry 缺货：不退避，下一拍立即重试（下单阶段沿用同一 render 重试）。
+	AttemptRetry AttemptDisposition = "retry"
+	// AttemptWaitStart 未开始：预下单暂停到开抢时间再继续。
+	AttemptWaitStart AttemptDisposition = "wait_start"
+	// AttemptBackoff 风控：目标逐级退避。
+	AttemptBackoff AttemptDisposition = "backoff"
+	// AttemptAbort 重复下单：该账号本次运行不再尝试这个目标。
+	AttemptAbort AttemptDisposition = "abort"
+)
+
+// AttemptLatency 是一次尝试各阶段的耗时（毫秒，未走到的阶段为 0）。
+type AttemptLatency struct {
+	PreflightMs int64 `json:"preflightMs,omitempty"`
+	CaptchaMs   int64 `json:"captchaMs,omitempty"`
+	CreateMs    int64 `json:"createMs,omitempty"`
+	TotalMs     int64 `json:"totalMs"`
+}
+
+// AttemptResult 描述一次账号下单尝试的完整结果。
+type AttemptResult struct {
+	ID           uint64            `json:"id"`
+	RunID        string            `json:"runId,omitempty"`
+	TargetID     string            `json:"targetId"`
+	AccountID    string            `json:"accountId"`
+	StartedAtMs  int64             `json:"startedAtMs"`
+	FinishedAtMs int64             `json:"finishedAtMs"`
+	Phase        AttemptPhase      `json:"phase"`
+	Success      bool              `json:"success"`
+	ErrorClass   AttemptErrorClass `json:"errorClass,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	// BizCode/BizClass 为上游业务失败时响应里的 code 及其在业务码字典中的分类（未登记时分类为空）。
+	BizCode  string `json:"bizCode,omitempty"`
+	BizClass string `json:"bizClass,omitempty"`
+	// Disposition 为失败后按业务错误分类采取的处理方式（未识别的错误为空）。
+	Disposition AttemptDisposition `json:"disposition,omitempty"`
+	// Practice 表示这是演练目标的尝试（请求发往 mock 服务）。
+	Practice bool `json:"practice,omitempty"`
+
+	PreflightCached bool           `json:"preflightCached,omitempty"`
+	NeedCaptcha     bool           `json:"needCaptcha,omitempty"`
+	CaptchaFromPool bool           `json:"captchaFromPool,omitempty"`
+	VerifyTokenUsed bool           `json:"verifyTokenUsed,omitempty"`
+	Latency         AttemptLatency `json:"latency"`
+
+	Quantity int    `json:"quantity,omitempty"`
+	TotalFee int64  `json:"totalFee,omitempty"`
+	OrderID  string `json:"orderId,omitempty"`
+	TraceID  string `json:"traceId,omitempty"`
+
+	// ActualFee 为下单后从上游取到的实际订单金额；FeeMismatch 表示与预检 TotalFee 不一致，付款前需人工核对。
+	ActualFee   int64 `json:"actualFee,omitempty"`
+	FeeVerified bool  `json:"feeVerified,omitempty"`
+	FeeMismatch bool  `json:"feeMismatch,omitempty"`
+
+	// OrderVerified 表示下单后已从上游订单详情确认订单存在，OrderStatus/PayDeadlineMs 取自详情；
+	// 确认失败时 OrderVerifyError 为原因（Provider 不支持查询详情时都为空）。
+	OrderVerified    bool   `json:"orderVerified,omitempty"`
+	OrderStatus      string `json:"orderStatus,omitempty"`
+	PayDeadlineMs    int64  `json:"payDeadlineMs,omitempty"`
+	OrderVerifyError string `json:"orderVerifyError,omitempty"`
+
+	// CreateRetries 为下单被限流后沿用同一 render 重试的次数。
+	CreateRetries int `json:"createRetries,omitempty"`
+	// FireSkewMs 发起本次尝试的那次触发相对计划时刻的偏差（毫秒，仅抢购模式）。
+	FireSkewMs int64 `json:"fireSkewMs,omitempty"`
+	// Cost 为本次尝试的估算花费（验证码求解费与代理流量费，按设置中的单价计算）。
+	Cost AttemptCost `json:"cost"`
+}
+
+// AttemptQuery 是持久化尝试记录（attempts 表）的分页查询条件，零值字段不参与过滤；Page 从 1 开始。
+type AttemptQuery struct {
+	TargetID  string
+	AccountID string
+	RunID     string
+	Page      int
+	PageSize  int
+}
+
+// Normalized 补齐分页默认值：Page 至少为 1，PageSize 默认 50、最多 500。
+func (q AttemptQuery) Normalized() AttemptQuery {
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = 50
+	}
+	if q.PageSize > 500 {
+		q.PageSize = 500
+	}
+	return q
+}
+
+// AttemptJournalEntry 是账号尝试日志中的一条精简记录，用于排查单个账号反复失败的规律。
+type AttemptJournalEntry struct {
+	ID         uint64            `json:"id"`
+	RunID      string            `json:"runId,omitempty"`
+	TargetID   string            `json:"targetId"`
+	AtMs       int64             `json:"atMs"`
+	Phase      AttemptPhase      `json:"phase"`
+	Success    bool              `json:"success"`
+	ErrorClass AttemptErrorClass `json:"errorClass,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	BizCode    string            `json:"bizCode,omitempty"`
+	LatencyMs  int64             `json:"latencyMs"`
+	OrderID    string            `json:"orderId,omitempty"`
+}
+
+// JournalEntry 返回该尝试在账号尝试日志中的精简记录。
+func (r AttemptResult) JournalEntry() AttemptJournalEntry {
+	return AttemptJournalEntry{
+		ID:         r.ID,
+		RunID:      r.RunID,
+		TargetID:   r.TargetID,
+		AtMs:       r.StartedAtMs,
+		Phase:      r.Phase,
+		Success:    r.Success,
+		ErrorClass: r.ErrorClass,
+		Error:      r.Error,
+		BizCode:    r.BizCode,
+		LatencyMs:  r.Latency.TotalMs,
+		OrderID:    r.OrderID,
+	}
+}
+
+// AttemptOutcomeCounts 按结果统计一组尝试：成功记为 "success"，失败按 errorClass（未分类为 "unknown"）。
+func AttemptOutcomeCounts(list []AttemptResult) map[string]int {
+	out := make(map[string]int)
+	for _, r := range list {
+		switch {
+		case r.Success:
+			out["success"]++
+		case r.ErrorClass == "":
+			out["unknown"]++
+		default:
+			out[string(r.ErrorClass)]++
+		}
+	}
+	return out
+}
@@ -0,0 +1,23 @@
+package model
+
+import "testing"
+
+func TestAttemptOutcomeCounts(t *testing.T) {
+	got := AttemptOutcomeCounts([]AttemptResult{
+		{Success: true},
+		{ErrorClass: AttemptErrorCreate},
+		{ErrorClass: AttemptErrorCreate},
+		{},
+	})
+	if got["success"] != 1 || got[string(AttemptErrorCreate)] != 2 || got["unknown"] != 1 || len(got) != 3 {
+		t.Fatalf("counts = %v", got)
+	}
+}
+
+func TestAttemptJournalEntry(t *testing.T) {
+	r := AttemptResult{ID: 7, TargetID: "t1", StartedAtMs: 1000, Phase: AttemptPhaseCreate, ErrorClass: AttemptErrorCreate, BizCode: "E1", Latency: AttemptLatency{TotalMs: 120}}
+	e := r.JournalEntry()
+	if e.ID != 7 || e.TargetID != "t1" || e.AtMs != 1000 || e.LatencyMs != 120 || e.BizCode != "E1" || e.ErrorClass != AttemptErrorCreate {
+		t.Fatalf("entry = %+v", e)
+	}
+}
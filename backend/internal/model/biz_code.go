@@ -0,0 +1,44 @@
+package model
+
+// 上游业务码（响应里的 code 字段）映射到的错误分类。
+const (
+	BizClassSoldOut       = "sold_out"
+	BizClassThrottle      = "throttle"
+	BizClassRiskControl   = "risk_control"
+	BizClassAuth          = "auth"
+	BizClassCaptcha       = "captcha"
+	BizClassPurchaseLimit = "purchase_limit"
+	BizClassNotStarted    = "not_started"
+	BizClassDuplicate     = "duplicate_order"
+	BizClassOther         = "other"
+)
+
+var KnownBizClasses = []string{
+	BizClassSoldOut,
+	BizClassThrottle,
+	BizClassRiskControl,
+	BizClassAuth,
+	BizClassCaptcha,
+	BizClassPurchaseLimit,
+	BizClassNotStarted,
+	BizClassDuplicate,
+	BizClassOther,
+}
+
+// BizCode 是业务码字典的一条记录。
+type BizCode struct {
+	Code      string    `json:"code"`
+	Class     string    `json:"class"`
+	Message   string    `json:"message,omitempty"`
+	UpdatedAt Timestamp `json:"updatedAt"`
+}
+
+// UnknownBizCode 是字典里还没有的业务码，记录出现次数和最近一次的上游提示，便于补充字典。
+type UnknownBizCode struct {
+	Code        string `json:"code"`
+	API         string `json:"api"`
+	Message     string `json:"message,omitempty"`
+	Count       int64  `json:"count"`
+	FirstSeenMs int64  `json:"firstSeenMs"`
+	LastSeenMs  int64  `json:"lastSeenMs"`
+}
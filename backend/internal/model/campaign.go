@@ -0,0 +1,24 @@
+package model
+
+// Campaign 是一组目标的展示分组（如 "双11 首发"），可整体启停；目标通过 CampaignID 归属。
+type Campaign struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt Timestamp `json:"createdAt"`
+	UpdatedAt Timestamp `json:"updatedAt"`
+}
+
+// CampaignSummary 是活动下所有目标的汇总进度。
+type CampaignSummary struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Targets int    `json:"targets"`
+	Enabled int    `json:"enabled"`
+	Running int    `json:"running"`
+	// PurchasedQty/TargetQty 为成员目标已购数量与目标数量之和。
+	PurchasedQty int `json:"purchasedQty"`
+	TargetQty    int `json:"targetQty"`
+	// Done 表示每个成员目标都已买够。
+	Done bool `json:"done"`
+}
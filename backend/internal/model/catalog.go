@@ -0,0 +1,68 @@
+package model
+
+import "encoding/json"
+
+// CatalogCategory 是缓存的上游前台分类节点（分类树已展开为平铺列表）。
+type CatalogCategory struct {
+	FrontCategoryID int64           `json:"frontCategoryId"`
+	ID              int64           `json:"id"`
+	PID             int64           `json:"pid"`
+	Level           int             `json:"level"`
+	Name            string          `json:"name"`
+	HasChildren     bool            `json:"hasChildren"`
+	Raw             json.RawMessage `json:"raw,omitempty"`
+	RefreshedAtMs   int64           `json:"refreshedAtMs"`
+}
+
+// CatalogSku 是门店商品（SKU），来自缓存或实时查询。
+type CatalogSku struct {
+	FrontCategoryID int64           `json:"frontCategoryId"`
+	CategoryID      int64           `json:"categoryId"`
+	CategoryName    string          `json:"categoryName,omitempty"`
+	StoreID         int64           `json:"storeId"`
+	ShopID          int64           `json:"shopId,omitempty"`
+	SKUID           int64           `json:"skuId"`
+	ItemID          int64           `json:"itemId"`
+	Name            string          `json:"name"`
+	MainImage       string          `json:"mainImage,omitempty"`
+	Price           int64           `json:"price"`
+	InStock         int64           `json:"inStock"`
+	PurchaseLimit   int64           `json:"purchaseLimit"` // 上游限购件数，0 表示未返回
+	Raw             json.RawMessage `json:"raw,omitempty"`
+	RefreshedAtMs   int64           `json:"refreshedAtMs"`
+}
+
+// CatalogSkuQuery 是查询缓存 SKU 的过滤条件；零值字段不过滤。
+type CatalogSkuQuery struct {
+	FrontCategoryID int64
+	CategoryID      int64
+	StoreID         int64
+	Keyword         string
+	Limit           int
+	Offset          int
+}
+
+type CatalogSettings struct {
+	// Enabled 是否定时刷新分类/商品缓存。
+	Enabled bool `json:"enabled"`
+	// FrontCategoryIDs 需要缓存的前台分类（一级入口）。
+	FrontCategoryIDs []int64 `json:"frontCategoryIds"`
+	// RefreshIntervalMinutes 定时刷新间隔（分钟）。
+	RefreshIntervalMinutes int `json:"refreshIntervalMinutes"`
+	// AccountID 用哪个账号访问上游（决定门店/位置）；为空时自动选一个已登录账号。
+	AccountID string `json:"accountId,omitempty"`
+	// MaxPages 每个前台分类最多拉取多少页商品。
+	MaxPages int `json:"maxPages"`
+}
+
+// CatalogRefreshStatus 描述最近一次缓存刷新的结果。
+type CatalogRefreshStatus struct {
+	Running       bool   `json:"running"`
+	LastStartMs   int64  `json:"lastStartMs,omitempty"`
+	LastFinishMs  int64  `json:"lastFinishMs,omitempty"`
+	LastError     string `json:"lastError,omitempty"`
+	AccountID     string `json:"accountId,omitempty"`
+	Categories    int    `json:"categories"`
+	Skus          int    `json:"skus"`
+	NextRefreshMs int64  `json:"nextRefreshMs,omitempty"`
+}
@@ -0,0 +1,19 @@
+package model
+
+// CircuitState 是一个上游接口的熔断状态；也是 circuit_breaker 消息的数据（状态变化时推送）。
+// State 为 closed（正常）、open（熔断中，请求直接失败）或 half_open（放行少量探测请求）。
+type CircuitState struct {
+	API   string `json:"api"`
+	State string `json:"state"`
+	// Requests/Failures 统计窗口内的请求数与失败数（网络错误与 5xx），ErrorRate 为两者之比。
+	Requests  int     `json:"requests"`
+	Failures  int     `json:"failures"`
+	ErrorRate float64 `json:"errorRate"`
+	// OpenedAtMs 最近一次打开的时间；RetryAtMs 为 open 状态下进入半开的时间。
+	OpenedAtMs int64 `json:"openedAtMs,omitempty"`
+	RetryAtMs  int64 `json:"retryAtMs,omitempty"`
+	// Trips 累计打开次数，Rejected 累计因熔断未发出的请求数（进程内计数，重启清零）。
+	Trips     int64  `json:"trips"`
+	Rejected  int64  `json:"rejected"`
+	LastError string `json:"lastError,omitempty"`
+}
@@ -0,0 +1,81 @@
+package model
+
+import "math"
+
+// bytesPerGB 代理流量计费按 1GB = 1024³ 字节折算。
+const bytesPerGB = 1 << 30
+
+// CostRates 是估算花费用的单价（元）：CaptchaPerSolve 为每次验证码求解费，ProxyPerGB 为代理每 GB 流量费；为 0 时不计。
+type CostRates struct {
+	CaptchaPerSolve float64
+	ProxyPerGB      float64
+}
+
+// AttemptCost 是一次尝试的估算花费（元）。CaptchaSolves 为本次尝试现场调用求解服务的次数（求解失败同样计费）；
+// 验证码池和提前准备的凭证在求解时已计入汇总，使用时不再重复计算。
+// ProxyBytes 为经代理发出的请求与响应字节数；直连的请求、免滑块凭证不计费。
+type AttemptCost struct {
+	CaptchaSolves int     `json:"captchaSolves,omitempty"`
+	CaptchaFee    float64 `json:"captchaFee,omitempty"`
+	ProxyBytes    int64   `json:"proxyBytes,omitempty"`
+	ProxyFee      float64 `json:"proxyFee,omitempty"`
+	Total         float64 `json:"total"`
+}
+
+// Price 按单价计算各项费用与合计（保留到 0.0001 元）。
+func (c AttemptCost) Price(rates CostRates) AttemptCost {
+	c.CaptchaFee = roundCost(float64(c.CaptchaSolves) * rates.CaptchaPerSolve)
+	c.ProxyFee = roundCost(float64(c.ProxyBytes) / bytesPerGB * rates.ProxyPerGB)
+	c.Total = roundCost(c.CaptchaFee + c.ProxyFee)
+	return c
+}
+
+// CostSummary 汇总多次尝试的花费（元）；PerOrder 为平均每个成功订单分摊的花费（含失败尝试的花费）。
+type CostSummary struct {
+	Attempts      int64   `json:"attempts"`
+	Orders        int64   `json:"orders"`
+	CaptchaSolves int64   `json:"captchaSolves"`
+	CaptchaFee    float64 `json:"captchaFee"`
+	ProxyBytes    int64   `json:"proxyBytes"`
+	ProxyFee      float64 `json:"proxyFee"`
+	Total         float64 `json:"total"`
+	PerOrder      float64 `json:"perOrder"`
+}
+
+// Add 把一次尝试的花费计入汇总。
+func (s *CostSummary) Add(res AttemptResult) {
+	s.Attempts++
+	if res.Success {
+		s.Orders++
+	}
+	s.CaptchaSolves += int64(res.Cost.CaptchaSolves)
+	s.CaptchaFee = roundCost(s.CaptchaFee + res.Cost.CaptchaFee)
+	s.ProxyBytes += res.Cost.ProxyBytes
+	s.ProxyFee = roundCost(s.ProxyFee + res.Cost.ProxyFee)
+	s.Total = roundCost(s.Total + res.Cost.Total)
+	s.updatePerOrder()
+}
+
+// AddCaptchaSolves 计入不属于某次尝试的验证码求解（补充验证码池、开抢前提前获取、测试下单），
+// 这些求解无论最终是否用上都已计费。
+func (s *CostSummary) AddCaptchaSolves(n int, rates CostRates) {
+	if n <= 0 {
+		return
+	}
+	fee := roundCost(float64(n) * rates.CaptchaPerSolve)
+	s.CaptchaSolves += int64(n)
+	s.CaptchaFee = roundCost(s.CaptchaFee + fee)
+	s.Total = roundCost(s.Total + fee)
+	s.updatePerOrder()
+}
+
+func (s *CostSummary) updatePerOrder() {
+	s.PerOrder = 0
+	if s.Orders > 0 {
+		s.PerOrder = roundCost(s.Total / float64(s.Orders))
+	}
+}
+
+func roundCost(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
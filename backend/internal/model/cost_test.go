@@ -0,0 +1,36 @@
+package model
+
+import "testing"
+
+func TestAttemptCostPriceAndSummary(t *testing.T) {
+	rates := CostRates{CaptchaPerSolve: 0.02, ProxyPerGB: 8}
+	c := AttemptCost{CaptchaSolves: 1, ProxyBytes: 64 << 20}.Price(rates)
+	if c.CaptchaFee != 0.02 || c.ProxyFee != 0.5 || c.Total != 0.52 {
+		t.Fatalf("priced = %+v", c)
+	}
+	if free := (AttemptCost{CaptchaSolves: 3, ProxyBytes: 1 << 30}).Price(CostRates{}); free.Total != 0 {
+		t.Fatalf("zero rates should cost nothing: %+v", free)
+	}
+
+	var s CostSummary
+	s.Add(AttemptResult{Cost: c})
+	s.Add(AttemptResult{Cost: c})
+	if s.PerOrder != 0 {
+		t.Fatalf("perOrder without orders = %v", s.PerOrder)
+	}
+	s.Add(AttemptResult{Success: true, Cost: c})
+	if s.Attempts != 3 || s.Orders != 1 || s.CaptchaSolves != 3 || s.ProxyBytes != 3*64<<20 || s.Total != 1.56 || s.PerOrder != 1.56 {
+		t.Fatalf("summary = %+v", s)
+	}
+}
+
+func TestCostSummaryAddCaptchaSolves(t *testing.T) {
+	rates := CostRates{CaptchaPerSolve: 0.02}
+	var s CostSummary
+	s.Add(AttemptResult{Success: true, Cost: AttemptCost{CaptchaSolves: 1}.Price(rates)})
+	s.AddCaptchaSolves(2, rates)
+	s.AddCaptchaSolves(0, rates)
+	if s.Attempts != 1 || s.CaptchaSolves != 3 || s.CaptchaFee != 0.06 || s.Total != 0.06 || s.PerOrder != 0.06 {
+		t.Fatalf("summary = %+v", s)
+	}
+}
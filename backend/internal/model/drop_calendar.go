@@ -0,0 +1,83 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DropWindow 是日历中一个已知的上新/促销时间段。
+type DropWindow struct {
+	Name    string `json:"name"`
+	StartMs int64  `json:"startMs"`
+	EndMs   int64  `json:"endMs"`
+	// ScanIntervalMs 该窗口内的扫货间隔，0 使用日历的 windowScanIntervalMs。
+	ScanIntervalMs int    `json:"scanIntervalMs,omitempty"`
+	Note           string `json:"note,omitempty"`
+}
+
+// DropCalendar 是已知上新窗口的日历：窗口开始前 LeadSeconds 起到窗口结束，扫货间隔收紧到窗口设置、
+// 验证码池按满额预热；窗口之外扫货间隔放宽到 QuietScanIntervalMs（0 表示沿用扫货间隔设置）。
+type DropCalendar struct {
+	Enabled              bool         `json:"enabled"`
+	LeadSeconds          int          `json:"leadSeconds"`
+	WindowScanIntervalMs int          `json:"windowScanIntervalMs"`
+	QuietScanIntervalMs  int          `json:"quietScanIntervalMs"`
+	Windows              []DropWindow `json:"windows"`
+}
+
+// Validate 校验窗口：名称必填，结束时间晚于开始时间。
+func (w DropWindow) Validate() error {
+	if strings.TrimSpace(w.Name) == "" {
+		return errors.New("name is required")
+	}
+	if w.StartMs <= 0 || w.EndMs <= w.StartMs {
+		return errors.New("endMs must be after startMs")
+	}
+	if w.ScanIntervalMs < 0 {
+		return errors.New("scanIntervalMs must be >= 0")
+	}
+	return nil
+}
+
+// Validate 校验日历中的每个窗口，出错时指出窗口序号。
+func (c DropCalendar) Validate() error {
+	for i, w := range c.Windows {
+		if err := w.Validate(); err != nil {
+			return fmt.Errorf("windows[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ActiveWindow 返回 nowMs 所在的窗口（含开始前 LeadSeconds 的预热期）；多个窗口重叠时取最早开始的。
+func (c DropCalendar) ActiveWindow(nowMs int64) (DropWindow, bool) {
+	leadMs := int64(c.LeadSeconds) * 1000
+	var out DropWindow
+	found := false
+	for _, w := range c.Windows {
+		if nowMs < w.StartMs-leadMs || nowMs >= w.EndMs {
+			continue
+		}
+		if !found || w.StartMs < out.StartMs {
+			out, found = w, true
+		}
+	}
+	return out, found
+}
+
+// NextWindow 返回 nowMs 之后最早进入预热期的窗口。
+func (c DropCalendar) NextWindow(nowMs int64) (DropWindow, bool) {
+	leadMs := int64(c.LeadSeconds) * 1000
+	var out DropWindow
+	found := false
+	for _, w := range c.Windows {
+		if w.StartMs-leadMs <= nowMs {
+			continue
+		}
+		if !found || w.StartMs < out.StartMs {
+			out, found = w, true
+		}
+	}
+	return out, found
+}
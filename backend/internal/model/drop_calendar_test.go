@@ -0,0 +1,36 @@
+package model
+
+import "testing"
+
+func TestDropCalendarWindows(t *testing.T) {
+	c := DropCalendar{
+		LeadSeconds: 60,
+		Windows: []DropWindow{
+			{Name: "b", StartMs: 500_000, EndMs: 600_000},
+			{Name: "a", StartMs: 100_000, EndMs: 200_000},
+		},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if _, ok := c.ActiveWindow(39_999); ok {
+		t.Fatal("window must not be active before the lead period")
+	}
+	if w, ok := c.ActiveWindow(40_000); !ok || w.Name != "a" {
+		t.Fatalf("active at lead start = %+v, %v", w, ok)
+	}
+	if _, ok := c.ActiveWindow(200_000); ok {
+		t.Fatal("window must end at endMs")
+	}
+	if w, ok := c.NextWindow(200_000); !ok || w.Name != "b" {
+		t.Fatalf("next = %+v, %v", w, ok)
+	}
+	if _, ok := c.NextWindow(450_000); ok {
+		t.Fatal("no window starts its lead period after 450s")
+	}
+
+	bad := DropCalendar{Windows: []DropWindow{{Name: "x", StartMs: 10, EndMs: 10}}}
+	if err := bad.Validate(); err == nil {
+		t.Fatal("expected error for empty window")
+	}
+}
@@ -0,0 +1,18 @@
+package model
+
+// EgressProbe 是一条出口路径（某个代理或直连）到上游主机的网络探测结果。
+// Path 为去掉账号密码后的代理地址，直连为 "direct"；AccountIDs 为使用该出口的已登录账号。
+type EgressProbe struct {
+	Path       string   `json:"path"`
+	AccountIDs []string `json:"accountIds,omitempty"`
+	Samples    int      `json:"samples"`
+	Failures   int      `json:"failures"`
+	LossPct    float64  `json:"lossPct"`
+	AvgMs      int64    `json:"avgMs"`
+	P50Ms      int64    `json:"p50Ms"`
+	MaxMs      int64    `json:"maxMs"`
+	// Slow 表示该出口的延迟或失败率明显差于其它出口。
+	Slow       bool   `json:"slow"`
+	Error      string `json:"error,omitempty"`
+	ProbedAtMs int64  `json:"probedAtMs"`
+}
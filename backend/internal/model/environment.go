@@ -0,0 +1,76 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnvProduction 生产环境标记：以生产环境运行的引擎启动带有其他环境标记的目标时会告警。
+const EnvProduction = "production"
+
+// NormalizeEnv 规范化环境标记（如 production/staging/mock）：小写，只允许字母、数字、'-'、'_'，最长 32 个字符；空值表示不区分环境。
+func NormalizeEnv(v string) (string, error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if len(v) > 32 {
+		return "", fmt.Errorf("env too long: %q", v)
+	}
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' {
+			continue
+		}
+		return "", fmt.Errorf("invalid env: %q", v)
+	}
+	return v, nil
+}
+
+// ConfigBundle 是在不同环境（如 mock 演练实例与生产实例）之间迁移的目标与账号配置。
+// 导出的账号不含 token/cookie/设备身份等环境相关的数据，导入后需重新登录。
+type ConfigBundle struct {
+	Env          string    `json:"env,omitempty"`
+	ExportedAtMs int64     `json:"exportedAtMs"`
+	Targets      []Target  `json:"targets"`
+	Accounts     []Account `json:"accounts,omitempty"`
+}
+
+// ImportOptions 控制导入时的 ID 改写与环境标记。
+type ImportOptions struct {
+	// IDMap 显式指定的 ID 映射（原 ID -> 新 ID），优先于前缀规则。
+	IDMap map[string]string `json:"idMap,omitempty"`
+	// StripPrefix 先去掉原 ID 上的该前缀（如 "staging-"），再加上 IDPrefix。
+	StripPrefix string `json:"stripPrefix,omitempty"`
+	IDPrefix    string `json:"idPrefix,omitempty"`
+	// Env 导入后的环境标记；为空时沿用资源自身或导出包上的标记。
+	Env string `json:"env,omitempty"`
+	// Overwrite 为 true 时覆盖同 ID 的已有目标；默认跳过。账号按手机号唯一，已存在时总是跳过。
+	Overwrite bool `json:"overwrite,omitempty"`
+	// KeepEnabled 保留目标的启用状态；默认导入后一律停用，确认无误再手动启用。
+	KeepEnabled bool `json:"keepEnabled,omitempty"`
+	// DryRun 只计算导入结果，不写入。
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// MapID 按导入选项改写资源 ID；空 ID 保持为空（导入时生成新 ID）。
+func (o ImportOptions) MapID(id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ""
+	}
+	if mapped, ok := o.IDMap[id]; ok && strings.TrimSpace(mapped) != "" {
+		return strings.TrimSpace(mapped)
+	}
+	if o.StripPrefix != "" {
+		id = strings.TrimPrefix(id, o.StripPrefix)
+	}
+	return o.IDPrefix + id
+}
+
+// ResourceEnv 返回导入后资源的环境标记：选项优先，其次资源自身，最后是导出包的标记。
+func (o ImportOptions) ResourceEnv(own, bundle string) string {
+	for _, v := range []string{o.Env, own, bundle} {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package model
+
+import "testing"
+
+func TestImportOptionsMapID(t *testing.T) {
+	opts := ImportOptions{
+		IDMap:       map[string]string{"staging-a": "prod-alpha"},
+		StripPrefix: "staging-",
+		IDPrefix:    "prod-",
+	}
+	cases := map[string]string{
+		"staging-a": "prod-alpha",
+		"staging-b": "prod-b",
+		"c":         "prod-c",
+		"":          "",
+	}
+	for in, want := range cases {
+		if got := opts.MapID(in); got != want {
+			t.Errorf("MapID(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := (ImportOptions{}).ResourceEnv("", "staging"); got != "staging" {
+		t.Errorf("ResourceEnv falls back to bundle env, got %q", got)
+	}
+	if got := (ImportOptions{Env: "production"}).ResourceEnv("staging", "staging"); got != "production" {
+		t.Errorf("ResourceEnv prefers option env, got %q", got)
+	}
+}
+
+func TestNormalizeEnv(t *testing.T) {
+	if got, err := NormalizeEnv(" Staging "); err != nil || got != "staging" {
+		t.Fatalf("NormalizeEnv = %q, %v", got, err)
+	}
+	if _, err := NormalizeEnv("prod env"); err == nil {
+		t.Fatal("expected error for env with space")
+	}
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultMobileCountry 未配置时的默认国家/地区区号（中国大陆）。
+const DefaultMobileCountry = "86"
+
+// ValidateMobileCountry 校验国家/地区区号：1-3 位数字，可带前导 "+"。
+func ValidateMobileCountry(cc string) error {
+	cc = strings.TrimPrefix(strings.TrimSpace(cc), "+")
+	if cc == "" || len(cc) > 3 || !isDigits(cc) || cc[0] == '0' {
+		return fmt.Errorf("invalid country code: %q", cc)
+	}
+	return nil
+}
+
+// NormalizeMobile 把手机号规范化为账号表使用的唯一形式：
+// 默认区号下的号码去掉区号和分隔符，只保留本地号码（如 "+86 138-0013-8000" -> "13800138000"）；
+// 其他国家/地区的号码保存为 "+区号号码"（E.164）。格式不合法时返回错误。
+func NormalizeMobile(raw, defaultCountry string) (string, error) {
+	cc := strings.TrimPrefix(strings.TrimSpace(defaultCountry), "+")
+	if cc == "" {
+		cc = DefaultMobileCountry
+	}
+	s := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '-', '(', ')', '.', '\u00a0', '\u3000':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+	if s == "" {
+		return "", errors.New("mobile is required")
+	}
+
+	international := false
+	switch {
+	case strings.HasPrefix(s, "+"):
+		s, international = s[1:], true
+	case strings.HasPrefix(s, "00"):
+		s, international = s[2:], true
+	}
+	if !isDigits(s) {
+		return "", fmt.Errorf("invalid mobile: %q", raw)
+	}
+
+	national := s
+	if international {
+		if !strings.HasPrefix(s, cc) {
+			// E.164 最长 15 位（含区号）。
+			if len(s) < 8 || len(s) > 15 {
+				return "", fmt.Errorf("invalid mobile: %q", raw)
+			}
+			return "+" + s, nil
+		}
+		national = s[len(cc):]
+	} else if cc == DefaultMobileCountry && len(s) == 13 && strings.HasPrefix(s, "861") {
+		// 省略了 "+" 的 86 前缀。
+		national = s[2:]
+	}
+
+	if cc == DefaultMobileCountry {
+		if len(national) != 11 || national[0] != '1' {
+			return "", fmt.Errorf("invalid mainland China mobile: %q", raw)
+		}
+		return national, nil
+	}
+	if len(national) < 4 || len(national)+len(cc) > 15 {
+		return "", fmt.Errorf("invalid mobile: %q", raw)
+	}
+	return national, nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
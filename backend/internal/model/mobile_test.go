@@ -0,0 +1,48 @@
+package model
+
+import "testing"
+
+func TestNormalizeMobile(t *testing.T) {
+	cases := []struct {
+		raw, cc, want string
+		wantErr       bool
+	}{
+		{raw: "13800138000", cc: "86", want: "13800138000"},
+		{raw: "+86 138-0013-8000", cc: "86", want: "13800138000"},
+		{raw: "0086 13800138000", cc: "", want: "13800138000"},
+		{raw: "8613800138000", cc: "86", want: "13800138000"},
+		{raw: "(+86) 138 0013 8000", cc: "+86", want: "13800138000"},
+		{raw: "+852 9123 4567", cc: "86", want: "+85291234567"},
+		{raw: "+1 (415) 555-2671", cc: "1", want: "4155552671"},
+		{raw: "23800138000", cc: "86", wantErr: true},
+		{raw: "1380013800", cc: "86", wantErr: true},
+		{raw: "abc", cc: "86", wantErr: true},
+		{raw: "  ", cc: "86", wantErr: true},
+		{raw: "+123", cc: "86", wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := NormalizeMobile(c.raw, c.cc)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("NormalizeMobile(%q, %q) = %q, want error", c.raw, c.cc, got)
+			}
+			continue
+		}
+		if err != nil || got != c.want {
+			t.Errorf("NormalizeMobile(%q, %q) = %q, %v; want %q", c.raw, c.cc, got, err, c.want)
+		}
+	}
+}
+
+func TestValidateMobileCountry(t *testing.T) {
+	for _, cc := range []string{"86", "+852", "1"} {
+		if err := ValidateMobileCountry(cc); err != nil {
+			t.Errorf("ValidateMobileCountry(%q): %v", cc, err)
+		}
+	}
+	for _, cc := range []string{"", "0", "8a", "1234"} {
+		if err := ValidateMobileCountry(cc); err == nil {
+			t.Errorf("ValidateMobileCountry(%q) = nil, want error", cc)
+		}
+	}
+}
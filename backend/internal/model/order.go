@@ -0,0 +1,50 @@
+package model
+
+// OrderRecord 是一笔下单成功的订单，保存到订单历史（orders 表）并交给下单后钩子（engine.Options.OnOrderCreated）处理。
+type OrderRecord struct {
+	AttemptID   uint64 `json:"attemptId"`
+	RunID       string `json:"runId,omitempty"`
+	TargetID    string `json:"targetId"`
+	TargetName  string `json:"targetName,omitempty"`
+	Mode        string `json:"mode,omitempty"`
+	AccountID   string `json:"accountId"`
+	Mobile      string `json:"mobile,omitempty"`
+	ItemID      int64  `json:"itemId"`
+	SKUID       int64  `json:"skuId"`
+	ShopID      int64  `json:"shopId,omitempty"`
+	Quantity    int    `json:"quantity"`
+	OrderID     string `json:"orderId"`
+	TraceID     string `json:"traceId,omitempty"`
+	CreatedAtMs int64  `json:"createdAtMs"`
+
+	ExpectedFee int64 `json:"expectedFee,omitempty"`
+	ActualFee   int64 `json:"actualFee,omitempty"`
+	FeeMismatch bool  `json:"feeMismatch,omitempty"`
+
+	OrderVerified bool   `json:"orderVerified,omitempty"`
+	OrderStatus   string `json:"orderStatus,omitempty"`
+	PayDeadlineMs int64  `json:"payDeadlineMs,omitempty"`
+}
+
+// OrderLedgerEntry 是重复下单保护表（order_ledger）中的一条记录。
+type OrderLedgerEntry struct {
+	AccountID   string `json:"accountId"`
+	TargetID    string `json:"targetId"`
+	OrderID     string `json:"orderId,omitempty"`
+	CreatedAtMs int64  `json:"createdAtMs"`
+	// 下单后核对订单详情的结果：VerifiedAtMs 为核对时间（未核对为 0），VerifyError 非空表示未能确认订单存在。
+	OrderStatus   string `json:"orderStatus,omitempty"`
+	PayDeadlineMs int64  `json:"payDeadlineMs,omitempty"`
+	VerifiedAtMs  int64  `json:"verifiedAtMs,omitempty"`
+	VerifyError   string `json:"verifyError,omitempty"`
+}
+
+// OrderQuery 是订单历史（orders 表）的查询条件，零值字段不参与过滤。
+type OrderQuery struct {
+	TargetID  string
+	AccountID string
+	// FromMs/ToMs 按下单时间过滤，区间为 [FromMs, ToMs)。
+	FromMs int64
+	ToMs   int64
+	Limit  int
+}
@@ -0,0 +1,53 @@
+package model
+
+import "fmt"
+
+// OrderLine 是与目标主商品在同一次下单中提交的附加订单行（组合下单）。
+type OrderLine struct {
+	ItemID int64 `json:"itemId"`
+	SKUID  int64 `json:"skuId"`
+	ShopID int64 `json:"shopId,omitempty"`
+	Qty    int   `json:"qty"`
+}
+
+// MaxExtraLines 附加订单行的数量上限（不含主商品）。
+const MaxExtraLines = 9
+
+// ValidateExtraLines 校验附加订单行：商品/SKU 必填、数量为正，且 SKU 不与主商品或其他行重复。
+func (t Target) ValidateExtraLines() error {
+	if len(t.ExtraLines) > MaxExtraLines {
+		return fmt.Errorf("extraLines must not exceed %d", MaxExtraLines)
+	}
+	seen := map[int64]bool{t.SKUID: true}
+	for i, l := range t.ExtraLines {
+		if l.ItemID <= 0 || l.SKUID <= 0 {
+			return fmt.Errorf("extraLines[%d]: itemId and skuId are required", i)
+		}
+		if l.Qty <= 0 {
+			return fmt.Errorf("extraLines[%d]: qty must be > 0", i)
+		}
+		if seen[l.SKUID] {
+			return fmt.Errorf("extraLines: duplicate skuId %d", l.SKUID)
+		}
+		seen[l.SKUID] = true
+	}
+	return nil
+}
+
+// OrderLines 返回一次下单提交的全部订单行：主商品（数量为 perOrderQty）在前，附加行按配置顺序在后。
+// 附加行的 shopId 为空时沿用主商品的 shopId。
+func (t Target) OrderLines() []OrderLine {
+	qty := t.PerOrderQty
+	if qty <= 0 {
+		qty = 1
+	}
+	out := make([]OrderLine, 0, 1+len(t.ExtraLines))
+	out = append(out, OrderLine{ItemID: t.ItemID, SKUID: t.SKUID, ShopID: t.ShopID, Qty: qty})
+	for _, l := range t.ExtraLines {
+		if l.ShopID == 0 {
+			l.ShopID = t.ShopID
+		}
+		out = append(out, l)
+	}
+	return out
+}
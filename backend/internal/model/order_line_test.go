@@ -0,0 +1,42 @@
+package model
+
+import "testing"
+
+func TestTargetOrderLines(t *testing.T) {
+	target := Target{ItemID: 1, SKUID: 10, ShopID: 7, PerOrderQty: 2, ExtraLines: []OrderLine{
+		{ItemID: 2, SKUID: 20, Qty: 1},
+		{ItemID: 3, SKUID: 30, ShopID: 8, Qty: 3},
+	}}
+	if err := target.ValidateExtraLines(); err != nil {
+		t.Fatalf("ValidateExtraLines: %v", err)
+	}
+	lines := target.OrderLines()
+	want := []OrderLine{
+		{ItemID: 1, SKUID: 10, ShopID: 7, Qty: 2},
+		{ItemID: 2, SKUID: 20, ShopID: 7, Qty: 1},
+		{ItemID: 3, SKUID: 30, ShopID: 8, Qty: 3},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("lines = %+v", lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestValidateExtraLinesRejectsBadLines(t *testing.T) {
+	cases := map[string][]OrderLine{
+		"missing sku":   {{ItemID: 2, Qty: 1}},
+		"zero qty":      {{ItemID: 2, SKUID: 20}},
+		"dup of main":   {{ItemID: 1, SKUID: 10, Qty: 1}},
+		"dup of extras": {{ItemID: 2, SKUID: 20, Qty: 1}, {ItemID: 2, SKUID: 20, Qty: 2}},
+	}
+	for name, extra := range cases {
+		target := Target{ItemID: 1, SKUID: 10, ExtraLines: extra}
+		if err := target.ValidateExtraLines(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ProxyEntry 是代理池中的一条代理；账号通过 ProxyID 引用它，代理地址变更后所有引用的账号随之生效。
+type ProxyEntry struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url"`
+	// Accounts 为引用该代理的账号数量（只读）。
+	Accounts  int       `json:"accounts"`
+	CreatedAt Timestamp `json:"createdAt"`
+	UpdatedAt Timestamp `json:"updatedAt"`
+}
+
+// ValidateProxyURL 校验代理地址格式：支持 http/https/socks5，必须带主机和端口。
+func ValidateProxyURL(raw string) error {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return errors.New("proxy url is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid proxy url: %w", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme: %q", u.Scheme)
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return errors.New("proxy url must include host and port")
+	}
+	return nil
+}
+
+// RedactProxyURL 去掉代理地址中的账号密码，用于展示和日志。
+func RedactProxyURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,51 @@
+package model
+
+// RetentionSettings 各类持久化数据按时间保留的期限，0 表示不按时间清理（仍受各表自身的条数上限约束）。
+type RetentionSettings struct {
+	// OrdersDays 订单历史（orders、order_ledger）保留天数。
+	OrdersDays int `json:"ordersDays"`
+	// AttemptsDays 尝试记录（attempts）保留天数。
+	AttemptsDays int `json:"attemptsDays"`
+	// SlowRequestsDays 慢请求追踪（slow_requests）保留天数。
+	SlowRequestsDays int `json:"slowRequestsDays"`
+	// TokenLifetimesDays 已过期 token 的寿命样本（token_lifetimes）保留天数。
+	TokenLifetimesDays int `json:"tokenLifetimesDays"`
+	// DailyPurchasesDays 账号每日下单件数（account_daily_purchases）保留天数。
+	DailyPurchasesDays int `json:"dailyPurchasesDays"`
+	// LogsHours 内存日志缓冲（/ws 快照、/api/v1/events/recent）保留小时数。
+	LogsHours int `json:"logsHours"`
+}
+
+// RetentionPruneResult 为一次按保留期限清理删除的记录数。
+type RetentionPruneResult struct {
+	Orders         int64 `json:"orders"`
+	Attempts       int64 `json:"attempts"`
+	SlowRequests   int64 `json:"slowRequests"`
+	TokenLifetimes int64 `json:"tokenLifetimes"`
+	DailyPurchases int64 `json:"dailyPurchases"`
+	Logs           int64 `json:"logs"`
+	PrunedAtMs     int64 `json:"prunedAtMs"`
+}
+
+// Total 返回本次清理删除的记录总数。
+func (r RetentionPruneResult) Total() int64 {
+	return r.Orders + r.Attempts + r.SlowRequests + r.TokenLifetimes + r.DailyPurchases + r.Logs
+}
+
+// PrivacyWipeResult 为一次隐私清除删除的记录数；目标配置与各项设置不受影响。
+type PrivacyWipeResult struct {
+	Accounts       int64 `json:"accounts"`
+	AccountTokens  int64 `json:"accountTokens"`
+	TokenLifetimes int64 `json:"tokenLifetimes"`
+	DailyPurchases int64 `json:"dailyPurchases"`
+	OrderLedger    int64 `json:"orderLedger"`
+	Orders         int64 `json:"orders"`
+	Attempts       int64 `json:"attempts"`
+	SlowRequests   int64 `json:"slowRequests"`
+	EgressProbes   int64 `json:"egressProbes"`
+	Credentials    int64 `json:"credentials"` // 外部凭据源（加密文件 / Vault）中删除的账号凭据数
+	Logs           int64 `json:"logs"`
+	// CaptureFiles 为已清空的演练录制文件路径。
+	CaptureFiles []string `json:"captureFiles,omitempty"`
+	WipedAtMs    int64    `json:"wipedAtMs"`
+}
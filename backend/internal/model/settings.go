@@ -6,9 +6,17 @@ type EmailSettings struct {
 	AuthCode string `json:"authCode,omitempty"`
 }
 
+// LimitsSettings 为 0 的字段表示沿用配置文件中的值。
 type LimitsSettings struct {
-	MaxPerTargetInFlight int `json:"maxPerTargetInFlight"`
-	CaptchaMaxInFlight   int `json:"captchaMaxInFlight"`
+	MaxPerTargetInFlight int     `json:"maxPerTargetInFlight"`
+	CaptchaMaxInFlight   int     `json:"captchaMaxInFlight"`
+	GlobalQPS            float64 `json:"globalQPS,omitempty"`
+	GlobalBurst          int     `json:"globalBurst,omitempty"`
+	PerAccountQPS        float64 `json:"perAccountQPS,omitempty"`
+	PerAccountBurst      int     `json:"perAccountBurst,omitempty"`
+	MaxInFlight          int     `json:"maxInFlight,omitempty"`
+	// AutoTune 开启后根据上游限流响应自动下调 PerAccountQPS。
+	AutoTune bool `json:"autoTune,omitempty"`
 }
 
 type CaptchaPoolSettings struct {
@@ -18,6 +26,8 @@ type CaptchaPoolSettings struct {
 	PoolSize int `json:"poolSize"`
 	// ItemTTLSeconds 每条验证码（verifyParam）从获取时刻开始的有效期（倒计时）。
 	ItemTTLSeconds int `json:"itemTtlSeconds"`
+	// ScanPoolSize 没有临近开抢的抢购目标、但有需要验证码的扫货目标在运行时维护的常驻数量；0 表示扫货不使用验证码池。
+	ScanPoolSize int `json:"scanPoolSize"`
 }
 
 type NotifySettings struct {
@@ -29,4 +39,41 @@ type NotifySettings struct {
 	RoundRobinIntervalMs int `json:"roundRobinIntervalMs"`
 	// ScanIntervalMs 扫货间隔（毫秒）。
 	ScanIntervalMs int `json:"scanIntervalMs"`
+	// ScanProbeEnabled 扫货时先用商品列表接口探测库存，无货则跳过下单流程。
+	ScanProbeEnabled bool `json:"scanProbeEnabled"`
+	// ScanRenderCacheMs 扫货时 render 的复用时长（毫秒）：有效期内每次触发直接用缓存的 render 下单，不再调用 render-order。
+	ScanRenderCacheMs int `json:"scanRenderCacheMs"`
+	// ScanFullEvery 开启探测时，每 N 次扫货强制走一次完整流程（兜底探测不准的情况）。
+	ScanFullEvery int `json:"scanFullEvery"`
+	// RushAtDriftWarnSeconds rushAtMs 与商品实际开售时间相差超过多少秒时告警。
+	RushAtDriftWarnSeconds int `json:"rushAtDriftWarnSeconds"`
+	// AccountCooldownFailures 账号连续多少次上游鉴权/风控/限流失败后暂停使用。
+	AccountCooldownFailures int `json:"accountCooldownFailures"`
+	// AccountCooldownSeconds 账号被暂停使用的时长（秒），到期后自动恢复轮换。
+	AccountCooldownSeconds int `json:"accountCooldownSeconds"`
+	// AccountDailyQuota 每个账号每天（本地时间）最多成功下单的件数，跨所有目标累计；达到后当天不再使用该账号，0 表示不限制。
+	AccountDailyQuota int `json:"accountDailyQuota,omitempty"`
+	// TargetFailureLimit 目标连续多少次预下单/下单失败后标记为 failed 并自动关闭。
+	TargetFailureLimit int `json:"targetFailureLimit"`
+	// ScheduleEnabled 定时启停：只在抢购目标开抢前 ScheduleLeadSeconds 秒到开抢后 ScheduleWindowSeconds 秒内自动运行引擎。
+	ScheduleEnabled       bool `json:"scheduleEnabled"`
+	ScheduleLeadSeconds   int  `json:"scheduleLeadSeconds"`
+	ScheduleWindowSeconds int  `json:"scheduleWindowSeconds"`
+	// DuplicateTargetPolicy 保存与已有目标指向同一商品规格的目标时的处理：warn(保存并提示) 或 block(拒绝保存)。
+	DuplicateTargetPolicy string `json:"duplicateTargetPolicy"`
+	// CaptchaFeePerSolve 每次验证码求解的费用（元），ProxyFeePerGB 代理每 GB 流量的费用（元），用于估算尝试花费；0 表示不计。
+	CaptchaFeePerSolve float64 `json:"captchaFeePerSolve,omitempty"`
+	ProxyFeePerGB      float64 `json:"proxyFeePerGB,omitempty"`
+}
+
+type CompatSettings struct {
+	// EncryptVersion 密码加密算法版本（xor-20251119 / xor-custom）。
+	EncryptVersion string `json:"encryptVersion"`
+	// EncryptKey/EncryptOffset 仅在 xor-custom 时生效。
+	EncryptKey    string `json:"encryptKey,omitempty"`
+	EncryptOffset int    `json:"encryptOffset,omitempty"`
+	// UAMode UA 规范化规则（wxapp-v1 / passthrough）。
+	UAMode string `json:"uaMode"`
+	// DefaultUserAgent 覆盖内置默认 UA，留空使用内置值。
+	DefaultUserAgent string `json:"defaultUserAgent,omitempty"`
 }
@@ -0,0 +1,18 @@
+package model
+
+import "encoding/json"
+
+// ShippingAddress 是账号在上游的一个收货地址（list-all 接口的常用字段，原始数据保留在 Raw）。
+// Selected 表示账号当前下单使用该地址（AddressID 一致）；Checked/IsDefault 为上游的选中/默认标记，
+// 账号未指定 AddressID 时下单使用上游选中的地址，其次是默认地址。
+type ShippingAddress struct {
+	ID          int64           `json:"id"`
+	Receiver    string          `json:"receiver,omitempty"`
+	Mobile      string          `json:"mobile,omitempty"`
+	Address     string          `json:"address,omitempty"`
+	DivisionIDs string          `json:"divisionIds,omitempty"`
+	Checked     bool            `json:"checked"`
+	IsDefault   bool            `json:"isDefault"`
+	Selected    bool            `json:"selected"`
+	Raw         json.RawMessage `json:"raw,omitempty"`
+}
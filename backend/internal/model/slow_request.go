@@ -0,0 +1,26 @@
+package model
+
+// SlowRequest 是一次耗时超过 provider.slowRequestMs 的上游请求的追踪记录（slow_request 消息的数据）。
+// 各阶段耗时取自最后一次尝试；TotalMs 从第一次尝试开始计算，包含重试等待。
+type SlowRequest struct {
+	ID        int64  `json:"id,omitempty"`
+	AtMs      int64  `json:"atMs"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	AccountID string `json:"accountId,omitempty"`
+	// Proxy 为去掉账号密码后的代理地址，直连为 "direct"。
+	Proxy      string `json:"proxy"`
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+	Status     int    `json:"status,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Attempts   int    `json:"attempts"`
+	TotalMs    int64  `json:"totalMs"`
+	// 最后一次尝试的阶段耗时：DNS 解析、建连（含 TCP 与 TLS）、TLS 握手、服务端处理（首字节）、读取响应体。
+	DNSMs       int64 `json:"dnsMs"`
+	ConnMs      int64 `json:"connMs"`
+	TLSMs       int64 `json:"tlsMs"`
+	ServerMs    int64 `json:"serverMs"`
+	ResponseMs  int64 `json:"responseMs"`
+	ConnReused  bool  `json:"connReused"`
+	ThresholdMs int64 `json:"thresholdMs"`
+}
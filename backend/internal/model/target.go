@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
 
 type TargetMode string
 
@@ -9,6 +13,50 @@ const (
 	TargetModeScan TargetMode = "scan"
 )
 
+// 下单来源/设备来源的已知取值（render-order 与 create-order 的 orderSource/deviceSource 字段）。
+// 部分活动只接受特定入口（直播间、活动页）发起的订单。
+const (
+	OrderSourceProductDetail = "product.detail.page"
+	OrderSourceActivity      = "activity.page"
+	OrderSourceLive          = "live.page"
+
+	DeviceSourceWXAPP = "WXAPP"
+	DeviceSourceH5    = "H5"
+	DeviceSourceApp   = "APP"
+)
+
+var (
+	KnownOrderSources  = []string{OrderSourceProductDetail, OrderSourceActivity, OrderSourceLive}
+	KnownDeviceSources = []string{DeviceSourceWXAPP, DeviceSourceH5, DeviceSourceApp}
+)
+
+// 目标的验证码预期（人工覆盖）：空值表示按最近一次预下单观察到的结果。
+const (
+	CaptchaOverrideAuto     = ""
+	CaptchaOverrideRequired = "required"
+	CaptchaOverrideNone     = "none"
+)
+
+// ValidateCaptchaOverride 校验 captchaOverride 取值。
+func ValidateCaptchaOverride(v string) error {
+	switch v {
+	case CaptchaOverrideAuto, CaptchaOverrideRequired, CaptchaOverrideNone:
+		return nil
+	}
+	return fmt.Errorf("unknown captchaOverride: %s", v)
+}
+
+// ValidateTradeSources 校验 orderSource/deviceSource 是否为已知取值；空值表示使用默认值，视为合法。
+func ValidateTradeSources(orderSource, deviceSource string) error {
+	if orderSource != "" && !slices.Contains(KnownOrderSources, orderSource) {
+		return fmt.Errorf("unknown orderSource: %s", orderSource)
+	}
+	if deviceSource != "" && !slices.Contains(KnownDeviceSources, deviceSource) {
+		return fmt.Errorf("unknown deviceSource: %s", deviceSource)
+	}
+	return nil
+}
+
 type Target struct {
 	ID                 string     `json:"id"`
 	Name               string     `json:"name,omitempty"`
@@ -16,13 +64,128 @@ type Target struct {
 	ItemID             int64      `json:"itemId"`
 	SKUID              int64      `json:"skuId"`
 	ShopID             int64      `json:"shopId,omitempty"`
+	CategoryID         int64      `json:"categoryId,omitempty"`
 	Mode               TargetMode `json:"mode"`
 	TargetQty          int        `json:"targetQty"`
 	PerOrderQty        int        `json:"perOrderQty"`
 	RushAtMs           int64      `json:"rushAtMs,omitempty"`
 	RushLeadMs         int64      `json:"rushLeadMs,omitempty"`
 	CaptchaVerifyParam string     `json:"captchaVerifyParam,omitempty"`
-	Enabled            bool       `json:"enabled"`
-	CreatedAt          time.Time  `json:"createdAt"`
-	UpdatedAt          time.Time  `json:"updatedAt"`
+	OrderSource        string     `json:"orderSource,omitempty"`
+	DeviceSource       string     `json:"deviceSource,omitempty"`
+	// CaptchaOverride 人工指定是否需要验证码（required/none），用于开抢前的验证码池预热与就绪检查。
+	CaptchaOverride string `json:"captchaOverride,omitempty"`
+	// AllowMultiplePerAccount 允许同一账号在该目标上下多单；默认每个账号只成功下单一次。
+	AllowMultiplePerAccount bool `json:"allowMultiplePerAccount,omitempty"`
+	// MinStock 扫货模式下的库存门槛：预下单返回的库存不低于该值才下单，避免抢到零星余量；0 表示不限制。
+	MinStock int64 `json:"minStock,omitempty"`
+	// MaxTotalFee 单笔订单可接受的最高金额（分），预下单金额超过时放弃下单；0 表示不限制。
+	MaxTotalFee int64 `json:"maxTotalFee,omitempty"`
+	// PrerenderSeconds 抢购模式下提前多少秒完成预下单（及验证码），开抢时刻只提交下单；0 表示开抢后再预下单。
+	PrerenderSeconds int `json:"prerenderSeconds,omitempty"`
+	// Env 环境标记（如 staging/production），跨实例导入时用于区分来源，见 ConfigBundle。
+	Env string `json:"env,omitempty"`
+	// QPS/Burst 该目标专用的请求速率与突发，非 0 时替代全局限速（账号限速仍然生效）；
+	// MaxInFlight 覆盖全局的 maxPerTargetInFlight（扫货模式仍为 1）。0 表示沿用全局设置。
+	QPS         float64 `json:"qps,omitempty"`
+	Burst       int     `json:"burst,omitempty"`
+	MaxInFlight int     `json:"maxInFlight,omitempty"`
+	// MaxAttempts 每次运行（启用/开抢一次）最多发起的预下单/下单尝试次数，用完后自动关闭目标；0 表示不限。
+	// 用于控制付费验证码等按次计费的成本。
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// ExtraLines 与主商品在同一次预下单/下单中提交的附加订单行（组合下单）。
+	// targetQty/perOrderQty 只针对主商品计数，每成功一单附加行按各自 qty 一并购买。
+	ExtraLines []OrderLine `json:"extraLines,omitempty"`
+	// CampaignID 所属活动分组，见 Campaign；为空表示未分组。
+	CampaignID string `json:"campaignId,omitempty"`
+	// Practice 演练模式：该目标的预下单/下单改走演练 provider（mock 服务），不产生真实订单，也不发送下单通知。
+	Practice bool `json:"practice,omitempty"`
+	// Notify 目标自己的通知渠道与收件人，为空时沿用全局通知设置，见 TargetNotify。
+	Notify *TargetNotify `json:"notify,omitempty"`
+	// ArmAtMs 定时启用时间：到点前目标不发起任何请求（即使已启用），到点后引擎自动启用并清零；
+	// 与决定下单时刻的 rushAtMs 不同，用于提前几天配置好目标而不提前产生扫货流量。0 表示不定时。
+	ArmAtMs   int64     `json:"armAtMs,omitempty"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt Timestamp `json:"createdAt"`
+	UpdatedAt Timestamp `json:"updatedAt"`
+}
+
+// ValidateArmAt 校验定时启用时间：抢购模式下必须早于开抢时间。
+func (t Target) ValidateArmAt() error {
+	if t.ArmAtMs > 0 && t.Mode == TargetModeRush && t.RushAtMs > 0 && t.ArmAtMs >= t.RushAtMs {
+		return errors.New("armAtMs must be before rushAtMs")
+	}
+	return nil
+}
+
+// ArmPending 返回目标是否还在等待定时启用（armAtMs 未到）。
+func (t Target) ArmPending(nowMs int64) bool {
+	return t.ArmAtMs > 0 && nowMs < t.ArmAtMs
+}
+
+// MaxPrerenderSeconds 提前预下单的最大提前量：render 与验证码凭证都有有效期，提前太多开抢时已失效。
+const MaxPrerenderSeconds = 60
+
+// ValidatePrerenderSeconds 校验 prerenderSeconds 取值。
+func ValidatePrerenderSeconds(v int) error {
+	if v < 0 || v > MaxPrerenderSeconds {
+		return fmt.Errorf("prerenderSeconds must be within 0-%d", MaxPrerenderSeconds)
+	}
+	return nil
+}
+
+// 目标级限速覆盖的上限，防止误填导致上游风控。
+const (
+	MaxTargetQPS         = 100
+	MaxTargetBurst       = 100
+	MaxTargetMaxInFlight = 50
+)
+
+// ValidateTargetLimits 校验目标级限速覆盖（qps/burst/maxInFlight）。
+func (t Target) ValidateTargetLimits() error {
+	if t.QPS < 0 || t.QPS > MaxTargetQPS {
+		return fmt.Errorf("qps must be within 0-%d", MaxTargetQPS)
+	}
+	if t.Burst < 0 || t.Burst > MaxTargetBurst {
+		return fmt.Errorf("burst must be within 0-%d", MaxTargetBurst)
+	}
+	if t.MaxInFlight < 0 || t.MaxInFlight > MaxTargetMaxInFlight {
+		return fmt.Errorf("maxInFlight must be within 0-%d", MaxTargetMaxInFlight)
+	}
+	if t.MaxAttempts < 0 {
+		return errors.New("maxAttempts must be >= 0")
+	}
+	return nil
+}
+
+// ValidateForRun 检查目标是否具备启动条件（启用时调用），返回可直接展示给用户的原因。
+func (t Target) ValidateForRun() error {
+	if t.Mode != TargetModeRush && t.Mode != TargetModeScan {
+		return fmt.Errorf("invalid mode: %s", t.Mode)
+	}
+	if t.ItemID <= 0 || t.SKUID <= 0 {
+		return errors.New("itemId and skuId are required")
+	}
+	if t.TargetQty <= 0 {
+		return errors.New("targetQty must be > 0")
+	}
+	if t.PerOrderQty <= 0 {
+		return errors.New("perOrderQty must be > 0")
+	}
+	if t.PerOrderQty > t.TargetQty {
+		return errors.New("perOrderQty must not exceed targetQty")
+	}
+	if t.Mode == TargetModeRush && t.RushAtMs <= 0 {
+		return errors.New("rushAtMs is required in rush mode")
+	}
+	return ValidateTradeSources(t.OrderSource, t.DeviceSource)
+}
+
+// TargetArmed 是 target_armed 消息的数据：目标到达定时启用时间（armAtMs）后被自动启用；Error 非空表示启用失败。
+type TargetArmed struct {
+	TargetID  string `json:"targetId"`
+	Name      string `json:"name,omitempty"`
+	ArmAtMs   int64  `json:"armAtMs"`
+	ArmedAtMs int64  `json:"armedAtMs"`
+	Error     string `json:"error,omitempty"`
 }
@@ -0,0 +1,76 @@
+package model
+
+import "errors"
+
+// ErrTargetMergeMismatch 表示被合并的目标与保留目标不是同一商品规格，或演练与真实目标混在一起。
+var ErrTargetMergeMismatch = errors.New("target points at a different sku or mixes practice and live")
+
+// TargetSKUKey 标识目标指向的商品规格；同一个 key 下的多个目标会重复下单并拆散统计。
+type TargetSKUKey struct {
+	ItemID int64 `json:"itemId"`
+	SKUID  int64 `json:"skuId"`
+	ShopID int64 `json:"shopId"`
+}
+
+func (t Target) SKUKey() TargetSKUKey {
+	return TargetSKUKey{ItemID: t.ItemID, SKUID: t.SKUID, ShopID: t.ShopID}
+}
+
+// DuplicateOf 判断两个不同的目标是否指向同一商品规格；演练目标只与演练目标比较，
+// 为真实目标配一个同规格的演练目标是正常用法。
+func (t Target) DuplicateOf(other Target) bool {
+	return t.ID != other.ID && t.SKUKey() == other.SKUKey() && t.Practice == other.Practice
+}
+
+// TargetMergeResult 是合并重复目标的结果。
+type TargetMergeResult struct {
+	Target    Target   `json:"target"`
+	MergedIDs []string `json:"mergedIds"`
+	// PurchasedQty 从被合并目标转入的已购数量。
+	PurchasedQty int   `json:"purchasedQty"`
+	Attempts     int64 `json:"attempts"`
+	Orders       int64 `json:"orders"`
+}
+
+// TargetSKUGroup 是按商品规格分组后的一组目标。
+type TargetSKUGroup struct {
+	TargetSKUKey
+	TargetQty int      `json:"targetQty"`
+	Targets   []Target `json:"targets"`
+}
+
+// GroupTargetsBySKU 按 itemId/skuId/shopId 分组，组内保持输入顺序，组按首个目标出现的顺序排列；
+// onlyDuplicates 时只返回含多个真实目标（或多个演练目标）的组。
+func GroupTargetsBySKU(targets []Target, onlyDuplicates bool) []TargetSKUGroup {
+	index := make(map[TargetSKUKey]int)
+	groups := make([]TargetSKUGroup, 0)
+	for _, t := range targets {
+		k := t.SKUKey()
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, TargetSKUGroup{TargetSKUKey: k})
+		}
+		groups[i].Targets = append(groups[i].Targets, t)
+		groups[i].TargetQty += t.TargetQty
+	}
+	if !onlyDuplicates {
+		return groups
+	}
+	out := groups[:0]
+	for _, g := range groups {
+		if hasDuplicate(g.Targets) {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+func hasDuplicate(targets []Target) bool {
+	count := map[bool]int{}
+	for _, t := range targets {
+		count[t.Practice]++
+	}
+	return count[false] > 1 || count[true] > 1
+}
@@ -0,0 +1,36 @@
+package model
+
+import "testing"
+
+func TestGroupTargetsBySKU(t *testing.T) {
+	targets := []Target{
+		{ID: "a", ItemID: 1, SKUID: 10, ShopID: 7, TargetQty: 1},
+		{ID: "b", ItemID: 2, SKUID: 20, TargetQty: 2},
+		{ID: "c", ItemID: 1, SKUID: 10, ShopID: 7, TargetQty: 3},
+		{ID: "d", ItemID: 2, SKUID: 20, TargetQty: 1, Practice: true},
+	}
+
+	groups := GroupTargetsBySKU(targets, false)
+	if len(groups) != 2 {
+		t.Fatalf("groups = %+v", groups)
+	}
+	first := groups[0]
+	if first.SKUID != 10 || len(first.Targets) != 2 || first.TargetQty != 4 || first.Targets[0].ID != "a" || first.Targets[1].ID != "c" {
+		t.Errorf("first group = %+v", first)
+	}
+
+	dups := GroupTargetsBySKU(targets, true)
+	if len(dups) != 1 || dups[0].SKUID != 10 {
+		t.Errorf("duplicate groups = %+v", dups)
+	}
+
+	if !targets[0].DuplicateOf(targets[2]) {
+		t.Error("a and c should be duplicates")
+	}
+	if targets[1].DuplicateOf(targets[3]) {
+		t.Error("practice target should not duplicate a real one")
+	}
+	if targets[0].DuplicateOf(targets[0]) {
+		t.Error("target should not duplicate itself")
+	}
+}
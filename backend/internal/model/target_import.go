@@ -0,0 +1,158 @@
+package model
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TargetImportRow 是批量导入中的一行：Row 从 1 开始（CSV 不计表头），Error 非空表示该行解析失败。
+type TargetImportRow struct {
+	Row    int
+	Target Target
+	Error  string
+}
+
+// ParseTargetsJSON 解析目标数组（或 {"targets": [...]}），每个元素单独解码，某行格式错误不影响其它行。
+func ParseTargetsJSON(raw []byte) ([]TargetImportRow, error) {
+	raw = bytes.TrimSpace(raw)
+	var items []json.RawMessage
+	if len(raw) > 0 && raw[0] == '{' {
+		var wrapped struct {
+			Targets []json.RawMessage `json:"targets"`
+		}
+		if err := json.Unmarshal(raw, &wrapped); err != nil {
+			return nil, err
+		}
+		items = wrapped.Targets
+	} else if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+
+	out := make([]TargetImportRow, 0, len(items))
+	for i, item := range items {
+		row := TargetImportRow{Row: i + 1}
+		if err := json.Unmarshal(item, &row.Target); err != nil {
+			row.Error = err.Error()
+		}
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+// targetCSVColumns 为 CSV 可用的列：Target 上标量字段的 JSON 名（不区分大小写），extraLines、notify 等嵌套字段只能用 JSON 导入。
+var targetCSVColumns = func() map[string][]int {
+	cols := make(map[string][]int)
+	typ := reflect.TypeOf(Target{})
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" || name == "createdAt" || name == "updatedAt" {
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+			cols[strings.ToLower(name)] = f.Index
+		}
+	}
+	return cols
+}()
+
+// ParseTargetsCSV 解析带表头的 CSV（列名同目标的 JSON 字段，如 itemId,skuId,mode,targetQty,rushAtMs）；
+// 表头有未知列时整体报错，单元格格式错误只记在该行，空单元格使用默认值。
+func ParseTargetsCSV(r io.Reader) ([]TargetImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("csv is empty")
+		}
+		return nil, err
+	}
+	index := make([][]int, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		idx, ok := targetCSVColumns[h]
+		if !ok {
+			return nil, fmt.Errorf("unknown csv column: %s", header[i])
+		}
+		index[i] = idx
+	}
+
+	var out []TargetImportRow
+	for n := 1; ; n++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		row := TargetImportRow{Row: n}
+		if err != nil {
+			row.Error = err.Error()
+			out = append(out, row)
+			continue
+		}
+		if isBlankRecord(rec) {
+			n--
+			continue
+		}
+		v := reflect.ValueOf(&row.Target).Elem()
+		for i, cell := range rec {
+			if i >= len(index) {
+				row.Error = fmt.Sprintf("too many columns (%d > %d)", len(rec), len(index))
+				break
+			}
+			if err := setCSVField(v.FieldByIndex(index[i]), strings.TrimSpace(cell)); err != nil {
+				row.Error = fmt.Sprintf("%s: %v", strings.TrimSpace(header[i]), err)
+				break
+			}
+		}
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+func isBlankRecord(rec []string) bool {
+	for _, c := range rec {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func setCSVField(f reflect.Value, cell string) error {
+	if cell == "" {
+		return nil
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(cell)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", cell)
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(cell, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", cell)
+		}
+		f.SetInt(n)
+	case reflect.Float64:
+		x, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", cell)
+		}
+		f.SetFloat(x)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTargetsCSV(t *testing.T) {
+	in := "\ufeffname,itemId,skuId,mode,targetQty,rushAtMs,enabled,qps\n" +
+		"茅台,1,11,rush,2,1748743200000,true,\n" +
+		"\n" +
+		"坏行,x,12,scan,1,,,\n" +
+		"\"带,逗号\",3,13,scan,1,,false,2.5\n"
+	rows, err := ParseTargetsCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseTargetsCSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("rows = %+v", rows)
+	}
+	if r := rows[0]; r.Row != 1 || r.Error != "" || r.Target.Name != "茅台" || r.Target.SKUID != 11 ||
+		r.Target.Mode != TargetModeRush || r.Target.RushAtMs != 1748743200000 || !r.Target.Enabled {
+		t.Fatalf("row 1 = %+v", r)
+	}
+	if r := rows[1]; r.Row != 2 || !strings.Contains(r.Error, "itemId") {
+		t.Fatalf("row 2 = %+v", r)
+	}
+	if r := rows[2]; r.Error != "" || r.Target.Name != "带,逗号" || r.Target.QPS != 2.5 {
+		t.Fatalf("row 3 = %+v", r)
+	}
+
+	if _, err := ParseTargetsCSV(strings.NewReader("itemId,notify\n1,x\n")); err == nil {
+		t.Fatal("non-scalar column should be rejected")
+	}
+}
+
+func TestParseTargetsJSON(t *testing.T) {
+	rows, err := ParseTargetsJSON([]byte(`{"targets":[{"itemId":1,"skuId":2,"mode":"scan","targetQty":1},{"itemId":"bad"}]}`))
+	if err != nil {
+		t.Fatalf("ParseTargetsJSON: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Error != "" || rows[0].Target.SKUID != 2 || rows[1].Error == "" {
+		t.Fatalf("rows = %+v", rows)
+	}
+	if _, err := ParseTargetsJSON([]byte(`not json`)); err == nil {
+		t.Fatal("invalid body should fail")
+	}
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// 通知渠道。目前只有邮件；新增渠道时在 KnownNotifyChannels 里登记。
+const NotifyChannelEmail = "email"
+
+// KnownNotifyChannels 目标通知设置里允许出现的渠道。
+var KnownNotifyChannels = []string{NotifyChannelEmail}
+
+// TargetNotify 是目标自己的通知设置（例如替朋友抢的商品通知对方），覆盖全局设置。
+// Channels 为该目标启用的渠道，空列表且没有收件人表示该目标不发送任何通知（只填 Emails 时视为启用邮件）；
+// Emails 为邮件收件人，为空时发给全局邮件设置里的邮箱（发件账号始终使用全局设置）。
+type TargetNotify struct {
+	Channels []string `json:"channels"`
+	Emails   []string `json:"emails,omitempty"`
+}
+
+// Normalize 去掉空白与重复项，校验渠道与邮箱格式。
+func (n *TargetNotify) Normalize() error {
+	if n == nil {
+		return nil
+	}
+	channels := make([]string, 0, len(n.Channels))
+	seen := map[string]bool{}
+	for _, c := range n.Channels {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == "" || seen[c] {
+			continue
+		}
+		known := false
+		for _, k := range KnownNotifyChannels {
+			known = known || k == c
+		}
+		if !known {
+			return fmt.Errorf("notify.channels: unknown channel %q", c)
+		}
+		seen[c] = true
+		channels = append(channels, c)
+	}
+	n.Channels = channels
+
+	var emails []string
+	seen = map[string]bool{}
+	for _, e := range n.Emails {
+		e = strings.TrimSpace(e)
+		if e == "" || seen[strings.ToLower(e)] {
+			continue
+		}
+		if _, err := mail.ParseAddress(e); err != nil {
+			return fmt.Errorf("notify.emails: invalid address %q", e)
+		}
+		seen[strings.ToLower(e)] = true
+		emails = append(emails, e)
+	}
+	n.Emails = emails
+	if len(n.Channels) == 0 && len(n.Emails) > 0 {
+		n.Channels = []string{NotifyChannelEmail}
+	}
+	return nil
+}
+
+// HasChannel 判断目标是否启用了某个渠道。
+func (n *TargetNotify) HasChannel(channel string) bool {
+	if n == nil {
+		return false
+	}
+	for _, c := range n.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
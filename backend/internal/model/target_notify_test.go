@@ -0,0 +1,36 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTargetNotifyNormalize(t *testing.T) {
+	n := &TargetNotify{Channels: []string{" Email ", "email"}, Emails: []string{" a@example.com", "A@example.com", ""}}
+	if err := n.Normalize(); err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if !reflect.DeepEqual(n.Channels, []string{NotifyChannelEmail}) || !reflect.DeepEqual(n.Emails, []string{"a@example.com"}) {
+		t.Fatalf("normalized = %+v", n)
+	}
+
+	onlyEmails := &TargetNotify{Emails: []string{"friend@example.com"}}
+	if err := onlyEmails.Normalize(); err != nil || !onlyEmails.HasChannel(NotifyChannelEmail) {
+		t.Fatalf("recipients without channels should enable email: %+v, %v", onlyEmails, err)
+	}
+	muted := &TargetNotify{Channels: []string{}}
+	if err := muted.Normalize(); err != nil || muted.HasChannel(NotifyChannelEmail) {
+		t.Fatalf("empty channels should mute the target: %+v, %v", muted, err)
+	}
+
+	if err := (&TargetNotify{Channels: []string{"sms"}}).Normalize(); err == nil {
+		t.Fatal("unknown channel should be rejected")
+	}
+	if err := (&TargetNotify{Emails: []string{"not-an-email"}}).Normalize(); err == nil {
+		t.Fatal("invalid address should be rejected")
+	}
+	var unset *TargetNotify
+	if err := unset.Normalize(); err != nil || unset.HasChannel(NotifyChannelEmail) {
+		t.Fatal("nil settings should be a no-op")
+	}
+}
@@ -1,17 +1,135 @@
 package model
 
+// TaskStatus 描述任务不在正常运行时的原因；正常运行/停止时为空。
+type TaskStatus string
+
+const (
+	TaskStatusConfigError TaskStatus = "config_error"
+	// TaskStatusFailed 连续失败次数达到上限，任务已被自动关闭。
+	TaskStatusFailed TaskStatus = "failed"
+	// TaskStatusBudgetExhausted 本次运行的尝试次数达到目标的 maxAttempts，任务已被自动关闭。
+	TaskStatusBudgetExhausted TaskStatus = "budget_exhausted"
+)
+
 type TaskState struct {
-	TargetID      string `json:"targetId"`
-	Running       bool   `json:"running"`
-	PurchasedQty  int    `json:"purchasedQty"`
-	TargetQty     int    `json:"targetQty"`
-	NeedCaptcha   *bool  `json:"needCaptcha,omitempty"`
-	LastError     string `json:"lastError,omitempty"`
-	LastAttemptMs int64  `json:"lastAttemptMs,omitempty"`
-	LastSuccessMs int64  `json:"lastSuccessMs,omitempty"`
+	TargetID      string     `json:"targetId"`
+	Running       bool       `json:"running"`
+	Status        TaskStatus `json:"status,omitempty"`
+	StatusReason  string     `json:"statusReason,omitempty"`
+	PurchasedQty  int        `json:"purchasedQty"`
+	TargetQty     int        `json:"targetQty"`
+	NeedCaptcha   *bool      `json:"needCaptcha,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastAttemptMs int64      `json:"lastAttemptMs,omitempty"`
+	LastSuccessMs int64      `json:"lastSuccessMs,omitempty"`
+	// PracticeQty 演练模式下单成功的件数，只在内存里用于判断演练是否买满，不计入 PurchasedQty、不落库。
+	PracticeQty int `json:"practiceQty,omitempty"`
+	// BackoffLevel 风控退避等级（0 为正常节奏，每级触发间隔翻倍、并发账号数减半）。
+	BackoffLevel int `json:"backoffLevel,omitempty"`
+	// ConsecutiveFailures 连续预下单/下单失败次数，达到 targetFailureLimit 时任务被关闭。
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+	// 抢购触发时刻相对计划时刻的偏差（毫秒，正值为晚于计划）：RushFireSkewMs 为开抢那一次，
+	// LastFireSkewMs/MaxFireSkewMs 为最近一次与本次运行以来偏差最大的一次节拍。
+	RushFireSkewMs *int64 `json:"rushFireSkewMs,omitempty"`
+	LastFireSkewMs int64  `json:"lastFireSkewMs,omitempty"`
+	MaxFireSkewMs  int64  `json:"maxFireSkewMs,omitempty"`
+	// AttemptBudget 为目标的 maxAttempts（0 表示不限），BudgetUsed 为本次运行已用的尝试次数。
+	AttemptBudget int `json:"attemptBudget,omitempty"`
+	BudgetUsed    int `json:"budgetUsed,omitempty"`
+
+	// 累计计数（本次运行/重置统计以来）与最近一分钟的速率。
+	Attempts          int64     `json:"attempts"`
+	PreflightFailures int64     `json:"preflightFailures"`
+	CreateFailures    int64     `json:"createFailures"`
+	CaptchaSolves     int64     `json:"captchaSolves"`
+	RatesPerMin       TaskRates `json:"ratesPerMin"`
+	// Cost 本次运行以来该目标尝试的估算花费；还没有尝试时为空。
+	Cost *CostSummary `json:"cost,omitempty"`
+}
+
+// TaskRates 是最近 60 秒内各类事件的次数（即每分钟速率）。
+type TaskRates struct {
+	Attempts          int64 `json:"attempts"`
+	PreflightFailures int64 `json:"preflightFailures"`
+	CreateFailures    int64 `json:"createFailures"`
+	CaptchaSolves     int64 `json:"captchaSolves"`
 }
 
+// EngineLifecycle 是引擎的生命周期状态；状态切换由引擎串行执行。
+type EngineLifecycle string
+
+const (
+	EngineStopped  EngineLifecycle = "stopped"
+	EngineStarting EngineLifecycle = "starting"
+	EngineRunning  EngineLifecycle = "running"
+	EngineStopping EngineLifecycle = "stopping"
+)
+
 type EngineState struct {
-	Running bool        `json:"running"`
-	Tasks   []TaskState `json:"tasks"`
+	Running   bool            `json:"running"`
+	Lifecycle EngineLifecycle `json:"lifecycle"`
+	Paused    bool            `json:"paused"`
+	// Draining 排空模式（滚动升级前）：不再发起新的尝试，只等进行中的尝试完成。
+	Draining     bool          `json:"draining,omitempty"`
+	RushMode     string        `json:"rushMode"`
+	RunID        string        `json:"runId,omitempty"`
+	RunStartedMs int64         `json:"runStartedMs,omitempty"`
+	Tasks        []TaskState   `json:"tasks"`
+	Metrics      EngineMetrics `json:"metrics"`
+	// Notifier 下单通知队列状况；通知器不支持时为空。
+	Notifier *NotifierStatus `json:"notifier,omitempty"`
+	// AccountCooldowns 当前因连续上游失败被暂停轮换的账号。
+	AccountCooldowns []AccountCooldown `json:"accountCooldowns,omitempty"`
+	// Accounts 各账号本次运行以来的尝试统计（重置统计时清零）。
+	Accounts []AccountStats `json:"accounts,omitempty"`
+	// Campaigns 各活动分组的汇总进度（由接口层附加）。
+	Campaigns []CampaignSummary `json:"campaigns,omitempty"`
+}
+
+// NotifierStatus 是下单通知队列的运行状况（进程内计数，重启清零）。
+type NotifierStatus struct {
+	QueueDepth      int    `json:"queueDepth"`
+	QueueCapacity   int    `json:"queueCapacity"`
+	Overflow        string `json:"overflow"`
+	Dropped         int64  `json:"dropped"`
+	Sent            int64  `json:"sent"`
+	LastSentMs      int64  `json:"lastSentMs,omitempty"`
+	LastSendError   string `json:"lastSendError,omitempty"`
+	LastSendErrorMs int64  `json:"lastSendErrorMs,omitempty"`
+}
+
+// AccountCooldown 描述一个被暂停轮换的账号；也是 account_cooldown 消息的数据（Benched=false 表示已恢复）。
+type AccountCooldown struct {
+	AccountID string `json:"accountId"`
+	Benched   bool   `json:"benched"`
+	Failures  int    `json:"failures"`
+	UntilMs   int64  `json:"untilMs,omitempty"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// AccountStats 是单个账号的尝试统计；只统计实际请求了上游的尝试（排除退避、暂停、取消、已达目标数量）。
+// Errors 只计预下单/验证码/下单出错，售罄、价格超限等与账号无关的放弃不计。
+type AccountStats struct {
+	AccountID      string            `json:"accountId"`
+	Attempts       int64             `json:"attempts"`
+	Successes      int64             `json:"successes"`
+	Errors         int64             `json:"errors"`
+	LastError      string            `json:"lastError,omitempty"`
+	LastErrorClass AttemptErrorClass `json:"lastErrorClass,omitempty"`
+	LastErrorMs    int64             `json:"lastErrorMs,omitempty"`
+	LastAttemptMs  int64             `json:"lastAttemptMs,omitempty"`
+	// LastLatencyMs 最近一次尝试的总耗时。
+	LastLatencyMs int64 `json:"lastLatencyMs,omitempty"`
+}
+
+// EngineMetrics 是引擎的累计观测指标（进程内计数，重启清零）。
+type EngineMetrics struct {
+	ReservedDriftTotal  int64 `json:"reservedDriftTotal"`
+	ReservedDriftLastMs int64 `json:"reservedDriftLastMs,omitempty"`
+	// CreatingOrders 正在请求 create-order 的尝试数（停止引擎时会等它们在宽限期内完成）。
+	CreatingOrders int64 `json:"creatingOrders"`
+	// CircuitBreakers 各受保护上游接口的熔断状态；provider 未开启熔断时为空。
+	CircuitBreakers []CircuitState `json:"circuitBreakers,omitempty"`
+	// Cost 本次运行（重置统计以来）所有尝试的估算花费。
+	Cost CostSummary `json:"cost"`
 }
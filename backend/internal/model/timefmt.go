@@ -0,0 +1,119 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// 接口中的时间字段统一约定：
+//   - 时刻以毫秒时间戳（int64，字段名以 Ms 结尾，如 rushAtMs）为准；
+//   - 需要人读的地方附带 ISO 8601 字符串（TimeLayout，毫秒精度、带时区），字段名去掉 Ms 后缀；
+//   - 时长一律为毫秒整数（字段名以 Ms 结尾），不直接输出 time.Duration（纳秒）。
+
+// TimeLayout 是接口中 ISO 时间字符串的格式（RFC 3339，固定 3 位毫秒）。
+const TimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// FormatTimeMs 把毫秒时间戳格式化为 TimeLayout 字符串；ms <= 0 时返回空串。
+func FormatTimeMs(ms int64) string {
+	if ms <= 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).Format(TimeLayout)
+}
+
+// Timestamp 是实体上的创建/更新时间，JSON 为 TimeLayout 字符串（零值为 null）；
+// 反序列化同时接受 RFC 3339 字符串与毫秒时间戳。
+type Timestamp struct {
+	time.Time
+}
+
+// TimestampMs 由毫秒时间戳构造 Timestamp；ms <= 0 时为零值。
+func TimestampMs(ms int64) Timestamp {
+	if ms <= 0 {
+		return Timestamp{}
+	}
+	return Timestamp{Time: time.UnixMilli(ms)}
+}
+
+// Ms 返回毫秒时间戳（零值为 0）。
+func (t Timestamp) Ms() int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.Format(TimeLayout))
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" || string(data) == `""` {
+		*t = Timestamp{}
+		return nil
+	}
+	if data[0] != '"' {
+		ms, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return errors.New("timestamp must be an RFC 3339 string or unix milliseconds")
+		}
+		*t = TimestampMs(ms)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return err
+	}
+	*t = Timestamp{Time: v}
+	return nil
+}
+
+// DurationMs 是以毫秒整数序列化的时长；反序列化同时接受毫秒数与 Go 时长字符串（如 "1.5s"）。
+type DurationMs time.Duration
+
+// Duration 返回对应的 time.Duration。
+func (d DurationMs) Duration() time.Duration { return time.Duration(d) }
+
+// Milliseconds 返回毫秒数。
+func (d DurationMs) Milliseconds() int64 { return time.Duration(d).Milliseconds() }
+
+func (d DurationMs) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, d.Milliseconds(), 10), nil
+}
+
+func (d *DurationMs) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		*d = 0
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*d = DurationMs(v)
+		return nil
+	}
+	ms, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return errors.New("duration must be milliseconds or a duration string")
+	}
+	*d = DurationMs(time.Duration(ms * float64(time.Millisecond)))
+	return nil
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimestampJSON(t *testing.T) {
+	ts := Timestamp{Time: time.Date(2026, 1, 2, 3, 4, 5, 678_900_000, time.UTC)}
+	b, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(b) != `"2026-01-02T03:04:05.678Z"` {
+		t.Fatalf("marshal = %s", b)
+	}
+	if b, _ := json.Marshal(Timestamp{}); string(b) != "null" {
+		t.Fatalf("zero marshal = %s", b)
+	}
+
+	for _, in := range []string{`"2026-01-02T03:04:05.678Z"`, `1767323045678`} {
+		var got Timestamp
+		if err := json.Unmarshal([]byte(in), &got); err != nil {
+			t.Fatalf("unmarshal %s: %v", in, err)
+		}
+		if got.Ms() != ts.UnixMilli() {
+			t.Fatalf("unmarshal %s = %d, want %d", in, got.Ms(), ts.UnixMilli())
+		}
+	}
+}
+
+func TestDurationMsJSON(t *testing.T) {
+	b, err := json.Marshal(struct {
+		D DurationMs `json:"d"`
+	}{DurationMs(1500 * time.Millisecond)})
+	if err != nil || string(b) != `{"d":1500}` {
+		t.Fatalf("marshal = %s, %v", b, err)
+	}
+	for in, want := range map[string]time.Duration{`250`: 250 * time.Millisecond, `"2s"`: 2 * time.Second, `null`: 0} {
+		var d DurationMs
+		if err := json.Unmarshal([]byte(in), &d); err != nil {
+			t.Fatalf("unmarshal %s: %v", in, err)
+		}
+		if d.Duration() != want {
+			t.Fatalf("unmarshal %s = %v, want %v", in, d.Duration(), want)
+		}
+	}
+}
+
+func TestFormatTimeMs(t *testing.T) {
+	if FormatTimeMs(0) != "" {
+		t.Fatalf("zero should format as empty")
+	}
+	got, err := time.Parse(TimeLayout, FormatTimeMs(1767323045678))
+	if err != nil || got.UnixMilli() != 1767323045678 {
+		t.Fatalf("round trip = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,22 @@
+package model
+
+// TokenExpiry 描述账号当前 token 的有效期估计；也是 token_expiry 消息的数据。
+// IssuedAtMs 为本地首次见到该 token 的时间，ExpiredAtMs 为签发后首次被上游拒绝（401/鉴权失败）的时间。
+type TokenExpiry struct {
+	AccountID   string `json:"accountId"`
+	IssuedAtMs  int64  `json:"issuedAtMs"`
+	ExpiredAtMs int64  `json:"expiredAtMs,omitempty"`
+	// ExpectedExpiryMs 按历史观测的 token 寿命推算的过期时间；观测数据不足时为 0。
+	ExpectedExpiryMs int64 `json:"expectedExpiryMs,omitempty"`
+	// LifetimeMs 推算时使用的 token 寿命估计。
+	LifetimeMs int64 `json:"lifetimeMs,omitempty"`
+	// AtRisk 列出开抢时 token 预计已过期的已启用抢购目标。
+	AtRisk []TokenExpiryTarget `json:"atRisk,omitempty"`
+}
+
+// TokenExpiryTarget 是 token 预计在开抢前过期的目标。
+type TokenExpiryTarget struct {
+	TargetID   string `json:"targetId"`
+	TargetName string `json:"targetName,omitempty"`
+	RushAtMs   int64  `json:"rushAtMs"`
+}
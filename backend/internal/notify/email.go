@@ -8,9 +8,11 @@ import (
 	"html/template"
 	"net/mail"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/gomail.v2"
@@ -32,24 +34,83 @@ type EmailNotifier struct {
 
 	summaryWindow time.Duration
 	maxBatch      int
+	overflow      string
+
+	dropped         atomic.Int64
+	sent            atomic.Int64
+	lastSentMs      atomic.Int64
+	statusMu        sync.Mutex
+	lastSendError   string
+	lastSendErrorMs int64
+}
+
+// 队列满时的处理策略。
+const (
+	OverflowDropNewest = "drop-newest"
+	OverflowDropOldest = "drop-oldest"
+)
+
+const defaultEmailQueueSize = 200
+
+// EmailNotifierOptions 零值即默认：队列 200 条，满了丢弃新事件。
+type EmailNotifierOptions struct {
+	QueueSize int
+	Overflow  string
 }
 
 func NewEmailNotifier(store *sqlite.Store, bus *logbus.Bus) *EmailNotifier {
+	return NewEmailNotifierWithOptions(store, bus, EmailNotifierOptions{})
+}
+
+func NewEmailNotifierWithOptions(store *sqlite.Store, bus *logbus.Bus, opts EmailNotifierOptions) *EmailNotifier {
+	size := opts.QueueSize
+	if size <= 0 {
+		size = defaultEmailQueueSize
+	}
+	overflow := OverflowDropNewest
+	if strings.TrimSpace(opts.Overflow) == OverflowDropOldest {
+		overflow = OverflowDropOldest
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	n := &EmailNotifier{
-		store:  store,
-		bus:    bus,
-		queue:  make(chan OrderCreatedEvent, 200),
-		ctx:    ctx,
-		cancel: cancel,
+		store:         store,
+		bus:           bus,
+		queue:         make(chan OrderCreatedEvent, size),
+		ctx:           ctx,
+		cancel:        cancel,
 		summaryWindow: emailSummaryWindow(),
 		maxBatch:      80,
+		overflow:      overflow,
 	}
 	n.wg.Add(1)
 	go n.loop()
 	return n
 }
 
+// Status 返回通知队列的当前深度、丢弃次数与最近一次发送错误。
+func (n *EmailNotifier) Status() model.NotifierStatus {
+	n.statusMu.Lock()
+	lastErr, lastErrMs := n.lastSendError, n.lastSendErrorMs
+	n.statusMu.Unlock()
+	return model.NotifierStatus{
+		QueueDepth:      len(n.queue),
+		QueueCapacity:   cap(n.queue),
+		Overflow:        n.overflow,
+		Dropped:         n.dropped.Load(),
+		Sent:            n.sent.Load(),
+		LastSentMs:      n.lastSentMs.Load(),
+		LastSendError:   lastErr,
+		LastSendErrorMs: lastErrMs,
+	}
+}
+
+func (n *EmailNotifier) recordSendError(err error) {
+	n.statusMu.Lock()
+	n.lastSendError = err.Error()
+	n.lastSendErrorMs = time.Now().UnixMilli()
+	n.statusMu.Unlock()
+}
+
 func (n *EmailNotifier) Close(ctx context.Context) error {
 	n.mu.Lock()
 	cancel := n.cancel
@@ -76,14 +137,75 @@ func (n *EmailNotifier) Close(ctx context.Context) error {
 func (n *EmailNotifier) NotifyOrderCreated(_ context.Context, evt OrderCreatedEvent) {
 	select {
 	case n.queue <- evt:
+		return
 	default:
+	}
+
+	dropped := evt
+	if n.overflow == OverflowDropOldest {
+		// 挤掉最早的一条再入队；与消费协程并发时可能已被取走，此时直接入队即可。
+		select {
+		case old := <-n.queue:
+			dropped = old
+		default:
+		}
+		select {
+		case n.queue <- evt:
+		default:
+			dropped = evt
+		}
+	}
+	n.dropped.Add(1)
+	if n.bus != nil {
+		n.bus.Log("warn", "email notify dropped (queue full)", map[string]any{
+			"targetId":  dropped.TargetID,
+			"accountId": dropped.AccountID,
+			"orderId":   dropped.OrderID,
+			"overflow":  n.overflow,
+		})
+	}
+}
+
+// NotifyAlert 告警不参与汇总窗口，直接异步发送。
+func (n *EmailNotifier) NotifyAlert(_ context.Context, evt AlertEvent) {
+	n.mu.Lock()
+	closed := n.cancel == nil
+	if !closed {
+		n.wg.Add(1)
+	}
+	n.mu.Unlock()
+	if closed {
+		return
+	}
+	go func() {
+		defer n.wg.Done()
+		n.sendAlert(evt)
+	}()
+}
+
+func (n *EmailNotifier) sendAlert(evt AlertEvent) {
+	if n.store == nil {
+		return
+	}
+	settings, ok, err := n.store.GetEmailSettings(n.ctx)
+	if err != nil || !ok || !settings.Enabled {
+		return
+	}
+	to, send := n.emailRecipients(evt.TargetID, settings)
+	if !send {
+		return
+	}
+	if err := sendAlertEmail(n.ctx, settings, to, evt); err != nil {
 		if n.bus != nil {
-			n.bus.Log("warn", "email notify dropped (queue full)", map[string]any{
-				"targetId":  evt.TargetID,
-				"accountId": evt.AccountID,
-				"orderId":   evt.OrderID,
+			n.bus.Log("warn", "alert email send failed", map[string]any{
+				"error": err.Error(),
+				"title": evt.Title,
 			})
 		}
+		return
+	}
+	if n.bus != nil {
+		n.bus.Log("info", "alert email sent", map[string]any{"title": evt.Title})
 	}
 }
 
@@ -168,6 +290,7 @@ func (n *EmailNotifier) handleBatch(reason string, events []OrderCreatedEvent) {
 
 	settings, ok, err := n.store.GetEmailSettings(n.ctx)
 	if err != nil {
+		n.recordSendError(err)
 		if n.bus != nil {
 			n.bus.Log("warn", "load email settings failed", map[string]any{"error": err.Error()})
 		}
@@ -184,13 +307,21 @@ func (n *EmailNotifier) handleBatch(reason string, events []OrderCreatedEvent) {
 	}
 
 	if err := validateEmailSettings(settings); err != nil {
+		n.recordSendError(err)
 		if n.bus != nil {
 			n.bus.Log("warn", "email settings invalid", map[string]any{"error": err.Error()})
 		}
 		return
 	}
 
-	if err := SendOrderSummaryEmail(n.ctx, settings, events); err != nil {
+	for _, g := range n.routeEvents(events, settings) {
+		n.sendBatch(reason, settings, g.to, g.events)
+	}
+}
+
+func (n *EmailNotifier) sendBatch(reason string, settings model.EmailSettings, to []string, events []OrderCreatedEvent) {
+	if err := sendOrderSummaryEmail(n.ctx, settings, to, events); err != nil {
+		n.recordSendError(err)
 		if n.bus != nil {
 			n.bus.Log("warn", "email send failed", map[string]any{
 				"error":  err.Error(),
@@ -200,14 +331,72 @@ func (n *EmailNotifier) handleBatch(reason string, events []OrderCreatedEvent) {
 		}
 		return
 	}
+	n.sent.Add(int64(len(events)))
+	n.lastSentMs.Store(time.Now().UnixMilli())
 
-		if n.bus != nil {
-			n.bus.Log("info", "email sent", map[string]any{
-				"count":  len(events),
-				"reason": reason,
-				"to":     strings.TrimSpace(settings.Email),
-			})
+	if n.bus != nil {
+		n.bus.Log("info", "email sent", map[string]any{
+			"count":  len(events),
+			"reason": reason,
+			"to":     strings.Join(to, ","),
+		})
+	}
+}
+
+// emailRoute 是发给同一组收件人的一批订单事件。
+type emailRoute struct {
+	to     []string
+	events []OrderCreatedEvent
+}
+
+// routeEvents 按目标的通知设置把一批事件分组到各自的收件人（保持首次出现的顺序），未启用邮件渠道的目标不发送。
+func (n *EmailNotifier) routeEvents(events []OrderCreatedEvent, settings model.EmailSettings) []emailRoute {
+	var routes []emailRoute
+	index := map[string]int{}
+	resolved := map[string][]string{}
+	for _, evt := range events {
+		to, ok := resolved[evt.TargetID]
+		if !ok {
+			var send bool
+			to, send = n.emailRecipients(evt.TargetID, settings)
+			if !send {
+				to = nil
+			}
+			resolved[evt.TargetID] = to
+		}
+		if len(to) == 0 {
+			continue
+		}
+		key := strings.Join(to, ",")
+		i, ok := index[key]
+		if !ok {
+			i = len(routes)
+			index[key] = i
+			routes = append(routes, emailRoute{to: to})
+		}
+		routes[i].events = append(routes[i].events, evt)
 	}
+	return routes
+}
+
+// emailRecipients 按目标的通知设置解析邮件收件人：目标没有单独设置（或已删除）时发给全局邮箱；
+// 目标设置了但没有启用邮件渠道时 send=false。
+func (n *EmailNotifier) emailRecipients(targetID string, settings model.EmailSettings) (to []string, send bool) {
+	global := []string{strings.TrimSpace(settings.Email)}
+	if targetID == "" || n.store == nil {
+		return global, true
+	}
+	t, err := n.store.GetTarget(n.ctx, targetID)
+	if err != nil || t.Notify == nil {
+		return global, true
+	}
+	if !t.Notify.HasChannel(model.NotifyChannelEmail) {
+		return nil, false
+	}
+	if len(t.Notify.Emails) == 0 {
+		return global, true
+	}
+	return t.Notify.Emails, true
 }
 
 func validateEmailSettings(s model.EmailSettings) error {
@@ -256,6 +445,11 @@ func SendOrderCreatedEmail(ctx context.Context, settings model.EmailSettings, ev
 }
 
 func SendOrderSummaryEmail(ctx context.Context, settings model.EmailSettings, events []OrderCreatedEvent) error {
+	return sendOrderSummaryEmail(ctx, settings, []string{strings.TrimSpace(settings.Email)}, events)
+}
+
+// sendOrderSummaryEmail 用全局邮件设置里的账号发信，收件人为 to。
+func sendOrderSummaryEmail(ctx context.Context, settings model.EmailSettings, to []string, events []OrderCreatedEvent) error {
 	if err := validateEmailSettings(settings); err != nil {
 		return err
 	}
@@ -279,7 +473,7 @@ func SendOrderSummaryEmail(ctx context.Context, settings model.EmailSettings, ev
 
 	msg := gomail.NewMessage()
 	msg.SetHeader("From", msg.FormatAddress(email, "抢购助手"))
-	msg.SetHeader("To", email)
+	msg.SetHeader("To", to...)
 	msg.SetHeader("Subject", subject)
 	msg.SetBody("text/plain", textBody)
 	msg.AddAlternative("text/html", htmlBody)
@@ -289,6 +483,57 @@ func SendOrderSummaryEmail(ctx context.Context, settings model.EmailSettings, ev
 	return d.DialAndSend(msg)
 }
 
+func SendAlertEmail(ctx context.Context, settings model.EmailSettings, evt AlertEvent) error {
+	return sendAlertEmail(ctx, settings, []string{strings.TrimSpace(settings.Email)}, evt)
+}
+
+func sendAlertEmail(ctx context.Context, settings model.EmailSettings, to []string, evt AlertEvent) error {
+	if err := validateEmailSettings(settings); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	email := strings.TrimSpace(settings.Email)
+	host, port, useSSL, err := smtpConfigForEmail(email)
+	if err != nil {
+		return err
+	}
+
+	at := time.Now()
+	if evt.At > 0 {
+		at = time.UnixMilli(evt.At)
+	}
+	text := new(strings.Builder)
+	text.WriteString(evt.Title + "\n")
+	text.WriteString("时间：" + at.Format("2006-01-02 15:04:05") + "\n")
+	if evt.TargetID != "" {
+		text.WriteString("目标：" + evt.TargetID + "\n")
+	}
+	if evt.Message != "" {
+		text.WriteString("\n" + evt.Message + "\n")
+	}
+	keys := make([]string, 0, len(evt.Fields))
+	for k := range evt.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		text.WriteString(fmt.Sprintf("%s：%v\n", k, evt.Fields[k]))
+	}
+
+	msg := gomail.NewMessage()
+	msg.SetHeader("From", msg.FormatAddress(email, "抢购助手"))
+	msg.SetHeader("To", to...)
+	msg.SetHeader("Subject", "告警："+evt.Title)
+	msg.SetBody("text/plain", text.String())
+
+	d := gomail.NewDialer(host, port, email, strings.TrimSpace(settings.AuthCode))
+	d.SSL = useSSL
+	return d.DialAndSend(msg)
+}
+
 func smtpConfigForEmail(email string) (host string, port int, useSSL bool, err error) {
 	parts := strings.Split(strings.TrimSpace(email), "@")
 	if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
@@ -329,13 +574,26 @@ func buildSubject(evt OrderCreatedEvent) string {
 	if qty <= 0 {
 		qty = 1
 	}
-	return fmt.Sprintf("下单成功（%s）：%s × %d", modeLabel(evt.Mode), name, qty)
+	subject := fmt.Sprintf("下单成功（%s）：%s × %d", modeLabel(evt.Mode), name, qty)
+	if evt.FeeMismatch {
+		subject = "【金额异常】" + subject
+	}
+	return subject
 }
 
 func buildSummarySubject(events []OrderCreatedEvent) string {
 	if len(events) == 0 {
 		return "抢购结果汇总"
 	}
+	mismatch := 0
+	for _, evt := range events {
+		if evt.FeeMismatch {
+			mismatch++
+		}
+	}
+	if mismatch > 0 {
+		return fmt.Sprintf("抢购结果汇总（%d单，%d单金额异常）", len(events), mismatch)
+	}
 	return fmt.Sprintf("抢购结果汇总（%d单）", len(events))
 }
 
@@ -416,6 +674,7 @@ var emailSummaryHTMLTpl = template.Must(template.New("email-summary").Parse(`
                   <th style="padding:10px 12px;text-align:left;font-size:12px;color:#6b7280;border-bottom:1px solid #eef0f6;">商品</th>
                   <th style="padding:10px 12px;text-align:left;font-size:12px;color:#6b7280;border-bottom:1px solid #eef0f6;">账号</th>
                   <th style="padding:10px 12px;text-align:left;font-size:12px;color:#6b7280;border-bottom:1px solid #eef0f6;">数量</th>
+                  <th style="padding:10px 12px;text-align:left;font-size:12px;color:#6b7280;border-bottom:1px solid #eef0f6;">金额</th>
                   <th style="padding:10px 12px;text-align:left;font-size:12px;color:#6b7280;border-bottom:1px solid #eef0f6;">订单号</th>
                 </tr>
               </thead>
@@ -426,6 +685,7 @@ var emailSummaryHTMLTpl = template.Must(template.New("email-summary").Parse(`
                   <td style="padding:10px 12px;font-size:12px;color:#111827;border-bottom:1px solid #eef0f6;">{{ .Target }}</td>
                   <td style="padding:10px 12px;font-size:12px;color:#111827;border-bottom:1px solid #eef0f6;">{{ .Account }}</td>
                   <td style="padding:10px 12px;font-size:12px;color:#111827;border-bottom:1px solid #eef0f6;">{{ .Qty }}</td>
+                  <td style="padding:10px 12px;font-size:12px;color:#111827;border-bottom:1px solid #eef0f6;">{{ .Fee }}</td>
                   <td style="padding:10px 12px;font-size:12px;color:#111827;border-bottom:1px solid #eef0f6;">{{ .OrderID }}</td>
                 </tr>
                 {{ end }}
@@ -472,6 +732,15 @@ func buildEmailBody(evt OrderCreatedEvent) (htmlBody string, textBody string, er
 		{K: "模式", V: modeLabel(evt.Mode)},
 		{K: "数量", V: strconv.Itoa(qty)},
 	}
+	if evt.ExpectedFee > 0 {
+		rows = append(rows, rowKV{K: "预检金额", V: formatFee(evt.ExpectedFee)})
+	}
+	if evt.ActualFee > 0 {
+		rows = append(rows, rowKV{K: "订单金额", V: formatFee(evt.ActualFee)})
+	}
+	if evt.FeeMismatch {
+		rows = append(rows, rowKV{K: "金额核对", V: "与预检不一致，付款前请核对"})
+	}
 
 	data := struct {
 		TargetName string
@@ -511,6 +780,7 @@ func buildSummaryEmailBody(events []OrderCreatedEvent) (htmlBody string, textBod
 		Target  string
 		Account string
 		Qty     string
+		Fee     string
 		OrderID string
 	}
 
@@ -545,6 +815,7 @@ func buildSummaryEmailBody(events []OrderCreatedEvent) (htmlBody string, textBod
 			Target:  name,
 			Account: safeText(evt.Mobile, evt.AccountID),
 			Qty:     strconv.Itoa(qty),
+			Fee:     summaryFee(evt),
 			OrderID: strings.TrimSpace(evt.OrderID),
 		})
 	}
@@ -570,7 +841,7 @@ func buildSummaryEmailBody(events []OrderCreatedEvent) (htmlBody string, textBod
 	text.WriteString("抢购结果汇总\n")
 	text.WriteString(fmt.Sprintf("共 %d 单，时间范围：%s ~ %s\n", len(events), data.Start, data.End))
 	for _, row := range rows {
-		text.WriteString(fmt.Sprintf("- %s | %s | %s | 数量 %s | 订单 %s\n", row.At, row.Target, row.Account, row.Qty, row.OrderID))
+		text.WriteString(fmt.Sprintf("- %s | %s | %s | 数量 %s | 金额 %s | 订单 %s\n", row.At, row.Target, row.Account, row.Qty, row.Fee, row.OrderID))
 	}
 
 	return buf.String(), text.String(), nil
@@ -584,6 +855,25 @@ func safeText(prefer, fallback string) string {
 	return strings.TrimSpace(fallback)
 }
 
+// formatFee 把以分为单位的金额格式化为元。
+func formatFee(fee int64) string {
+	return fmt.Sprintf("¥%d.%02d", fee/100, fee%100)
+}
+
+func summaryFee(evt OrderCreatedEvent) string {
+	fee := evt.ActualFee
+	if fee <= 0 {
+		fee = evt.ExpectedFee
+	}
+	if fee <= 0 {
+		return "-"
+	}
+	if evt.FeeMismatch {
+		return fmt.Sprintf("%s（预检 %s，金额异常）", formatFee(evt.ActualFee), formatFee(evt.ExpectedFee))
+	}
+	return formatFee(fee)
+}
+
 func modeLabel(mode string) string {
 	switch strings.ToLower(strings.TrimSpace(mode)) {
 	case "scan":
@@ -1,9 +1,14 @@
 package notify
 
-import "context"
+import (
+	"context"
+
+	"sniping_engine/internal/model"
+)
 
 type OrderCreatedEvent struct {
 	At         int64  `json:"atMs"`
+	RunID      string `json:"runId,omitempty"`
 	AccountID  string `json:"accountId"`
 	Mobile     string `json:"mobile,omitempty"`
 	TargetID   string `json:"targetId"`
@@ -15,9 +20,27 @@ type OrderCreatedEvent struct {
 	Quantity   int    `json:"quantity,omitempty"`
 	OrderID    string `json:"orderId,omitempty"`
 	TraceID    string `json:"traceId,omitempty"`
+	// ExpectedFee 为预检金额，ActualFee 为下单后核对到的金额（分）；FeeMismatch=true 时付款前需核对。
+	ExpectedFee int64 `json:"expectedFee,omitempty"`
+	ActualFee   int64 `json:"actualFee,omitempty"`
+	FeeMismatch bool  `json:"feeMismatch,omitempty"`
+}
+
+// AlertEvent 是需要人工介入的告警（例如开抢时间与商品实际开售时间不一致）。
+type AlertEvent struct {
+	At       int64          `json:"atMs"`
+	Title    string         `json:"title"`
+	Message  string         `json:"message"`
+	TargetID string         `json:"targetId,omitempty"`
+	Fields   map[string]any `json:"fields,omitempty"`
 }
 
 type Notifier interface {
 	NotifyOrderCreated(ctx context.Context, evt OrderCreatedEvent)
+	NotifyAlert(ctx context.Context, evt AlertEvent)
 }
 
+// StatusReporter 由带内部队列的通知器实现，引擎状态会附带其队列状况。
+type StatusReporter interface {
+	Status() model.NotifierStatus
+}
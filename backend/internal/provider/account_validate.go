@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"context"
+
+	"sniping_engine/internal/model"
+)
+
+// AccountCheck 是账号校验时一次上游访问的结果。
+type AccountCheck struct {
+	Path   string `json:"path"`
+	Status int    `json:"status,omitempty"`
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+}
+
+// AccountValidator 由支持账号校验的 Provider 可选实现：用账号的 token/cookie 访问当前用户接口
+// （checkAddress 时再访问收货地址列表），确认登录态仍然有效。
+// 返回的 error 只表示无法发起校验（如代理配置错误）；单个接口失败体现在 AccountCheck 上，请求未送达时 Status 为 0。
+type AccountValidator interface {
+	ValidateAccount(ctx context.Context, account model.Account, checkAddress bool) (model.Account, []AccountCheck, error)
+}
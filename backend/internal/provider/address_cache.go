@@ -0,0 +1,6 @@
+package provider
+
+// AddressCacheForgetter 由按账号缓存收货地址信息（如定位经纬度）的 Provider 可选实现，账号更换下单地址后引擎调用它清除缓存。
+type AddressCacheForgetter interface {
+	ForgetAccountAddress(accountID string)
+}
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"errors"
+	"strings"
+
+	"sniping_engine/internal/model"
+)
+
+// ErrAttemptAccountMismatch 表示试图把一个账号预下单得到的 render 交给另一个账号提交。
+var ErrAttemptAccountMismatch = errors.New("preflight belongs to another account")
+
+// AttemptContext 把一次下单尝试的账号、render 与验证码绑定在一起（会话亲和）。
+// 字段不导出，只能经 NewAttemptContext 用同一账号的预下单结果构造；CreateOrder 只接受它，
+// 这样 render 不可能被换到别的账号上提交。同一账号内可以拿同一个 AttemptContext 多次重试下单。
+type AttemptContext struct {
+	account            model.Account
+	target             model.Target
+	preflight          PreflightResult
+	renderedAtMs       int64
+	captchaVerifyParam string
+}
+
+// NewAttemptContext 校验 preflight 确实出自 account 后构造尝试上下文；renderedAtMs 为 render 的生成时间。
+func NewAttemptContext(account model.Account, target model.Target, preflight PreflightResult, renderedAtMs int64) (*AttemptContext, error) {
+	if account.ID == "" {
+		return nil, errors.New("account id is required")
+	}
+	if preflight.AccountID != account.ID {
+		return nil, ErrAttemptAccountMismatch
+	}
+	return &AttemptContext{
+		account:            account,
+		target:             target,
+		preflight:          preflight,
+		renderedAtMs:       renderedAtMs,
+		captchaVerifyParam: strings.TrimSpace(target.CaptchaVerifyParam),
+	}, nil
+}
+
+func (a *AttemptContext) Account() model.Account { return a.account }
+
+func (a *AttemptContext) Target() model.Target { return a.target }
+
+func (a *AttemptContext) Preflight() PreflightResult { return a.preflight }
+
+func (a *AttemptContext) RenderedAtMs() int64 { return a.renderedAtMs }
+
+func (a *AttemptContext) CaptchaVerifyParam() string { return a.captchaVerifyParam }
+
+// SetCaptchaVerifyParam 记录本次尝试求得的验证码参数（为空表示不带验证码或改用免滑块凭证）。
+func (a *AttemptContext) SetCaptchaVerifyParam(v string) {
+	a.captchaVerifyParam = strings.TrimSpace(v)
+}
+
+// UpdateAccount 用上游回写的账号快照（cookie 等）刷新上下文，供重试下单沿用最新会话；换账号会被拒绝。
+func (a *AttemptContext) UpdateAccount(account model.Account) error {
+	if account.ID != a.account.ID {
+		return ErrAttemptAccountMismatch
+	}
+	a.account = account
+	return nil
+}
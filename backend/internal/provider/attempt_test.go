@@ -0,0 +1,23 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"sniping_engine/internal/model"
+)
+
+func TestNewAttemptContextRejectsForeignRender(t *testing.T) {
+	pre := PreflightResult{CanBuy: true, AccountID: "a", Render: []byte(`{}`)}
+	if _, err := NewAttemptContext(model.Account{ID: "b"}, model.Target{}, pre, 0); !errors.Is(err, ErrAttemptAccountMismatch) {
+		t.Fatalf("err = %v, want ErrAttemptAccountMismatch", err)
+	}
+
+	attempt, err := NewAttemptContext(model.Account{ID: "a"}, model.Target{CaptchaVerifyParam: " p "}, pre, 0)
+	if err != nil {
+		t.Fatalf("new attempt: %v", err)
+	}
+	if got := attempt.CaptchaVerifyParam(); got != "p" {
+		t.Fatalf("captcha = %q, want p", got)
+	}
+}
@@ -0,0 +1,198 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// BizCodeError 是上游返回业务失败（success=false）且带有 code 时的错误；Class 为字典中的分类，未登记时为空。
+// Error() 保持原有的 "<api> failed: <msg>" 格式，基于文本的限流/风控识别不受影响。
+type BizCodeError struct {
+	API     string
+	Code    string
+	Class   string
+	Message string
+}
+
+func (e *BizCodeError) Error() string {
+	if e.Class != "" {
+		return fmt.Sprintf("%s failed: %s (code %s, %s)", e.API, e.Message, e.Code, e.Class)
+	}
+	return fmt.Sprintf("%s failed: %s (code %s)", e.API, e.Message, e.Code)
+}
+
+// BizCodeOf 返回错误链中的上游业务码及其分类；不是业务码错误时返回空。
+func BizCodeOf(err error) (code string, class string) {
+	var be *BizCodeError
+	if errors.As(err, &be) {
+		return be.Code, be.Class
+	}
+	return "", ""
+}
+
+// NormalizeBizCode 把响应里的 code（数字或字符串）转成字典使用的字符串形式；0/空/成功码返回空。
+func NormalizeBizCode(v any) string {
+	switch c := v.(type) {
+	case nil:
+		return ""
+	case string:
+		c = strings.TrimSpace(c)
+		if c == "0" || strings.EqualFold(c, "success") {
+			return ""
+		}
+		return c
+	case float64:
+		if c == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(c, 'f', -1, 64)
+	case json.Number:
+		if c.String() == "0" {
+			return ""
+		}
+		return c.String()
+	case bool:
+		return ""
+	default:
+		return strings.TrimSpace(fmt.Sprint(c))
+	}
+}
+
+// unknownReportInterval 同一个未登记业务码两次回调之间的最小间隔（首次遇到时立即回调）。
+const unknownReportInterval = time.Minute
+
+// CodeBook 是进程内的业务码字典（持久化在 SQLite，由调用方加载），同时统计未登记的业务码。
+type CodeBook struct {
+	mu        sync.RWMutex
+	codes     map[string]model.BizCode
+	unknown   map[string]*model.UnknownBizCode
+	reported  map[string]int64
+	onUnknown func(model.UnknownBizCode)
+}
+
+func NewCodeBook() *CodeBook {
+	return &CodeBook{
+		codes:    make(map[string]model.BizCode),
+		unknown:  make(map[string]*model.UnknownBizCode),
+		reported: make(map[string]int64),
+	}
+}
+
+// Load 用已保存的字典和未登记记录替换当前内容。
+func (b *CodeBook) Load(codes []model.BizCode, unknown []model.UnknownBizCode) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.codes = make(map[string]model.BizCode, len(codes))
+	for _, c := range codes {
+		b.codes[c.Code] = c
+	}
+	b.unknown = make(map[string]*model.UnknownBizCode, len(unknown))
+	for _, u := range unknown {
+		if _, known := b.codes[u.Code]; known {
+			continue
+		}
+		u := u
+		b.unknown[u.Code] = &u
+	}
+}
+
+// SetUnknownHandler 设置遇到未登记业务码时的回调（用于持久化/告警），回调在独立 goroutine 中执行；
+// 首次遇到时立即回调，之后同一业务码每分钟最多回调一次。
+func (b *CodeBook) SetUnknownHandler(fn func(model.UnknownBizCode)) {
+	b.mu.Lock()
+	b.onUnknown = fn
+	b.mu.Unlock()
+}
+
+// Put 新增或修改一条字典记录，并移出未登记列表。
+func (b *CodeBook) Put(c model.BizCode) {
+	b.mu.Lock()
+	b.codes[c.Code] = c
+	delete(b.unknown, c.Code)
+	b.mu.Unlock()
+}
+
+func (b *CodeBook) Delete(code string) {
+	b.mu.Lock()
+	delete(b.codes, code)
+	b.mu.Unlock()
+}
+
+func (b *CodeBook) Lookup(code string) (model.BizCode, bool) {
+	if b == nil {
+		return model.BizCode{}, false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	c, ok := b.codes[code]
+	return c, ok
+}
+
+// Observe 查询业务码的分类；未登记时计数并返回空。
+func (b *CodeBook) Observe(api, code, msg string) string {
+	if b == nil || code == "" {
+		return ""
+	}
+	b.mu.RLock()
+	c, ok := b.codes[code]
+	b.mu.RUnlock()
+	if ok {
+		return c.Class
+	}
+
+	nowMs := time.Now().UnixMilli()
+	b.mu.Lock()
+	u := b.unknown[code]
+	if u == nil {
+		u = &model.UnknownBizCode{Code: code, FirstSeenMs: nowMs}
+		b.unknown[code] = u
+	}
+	u.API = api
+	u.Message = msg
+	u.Count++
+	u.LastSeenMs = nowMs
+	snapshot := *u
+	fn := b.onUnknown
+	report := fn != nil && nowMs-b.reported[code] >= unknownReportInterval.Milliseconds()
+	if report {
+		b.reported[code] = nowMs
+	}
+	b.mu.Unlock()
+
+	if report {
+		go fn(snapshot)
+	}
+	return ""
+}
+
+// Codes 返回字典内容（按 code 排序）。
+func (b *CodeBook) Codes() []model.BizCode {
+	b.mu.RLock()
+	out := make([]model.BizCode, 0, len(b.codes))
+	for _, c := range b.codes {
+		out = append(out, c)
+	}
+	b.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out
+}
+
+// Unknown 返回未登记的业务码（最近出现的在前）。
+func (b *CodeBook) Unknown() []model.UnknownBizCode {
+	b.mu.RLock()
+	out := make([]model.UnknownBizCode, 0, len(b.unknown))
+	for _, u := range b.unknown {
+		out = append(out, *u)
+	}
+	b.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].LastSeenMs > out[j].LastSeenMs })
+	return out
+}
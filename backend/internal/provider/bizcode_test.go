@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"sniping_engine/internal/model"
+)
+
+func TestNormalizeBizCode(t *testing.T) {
+	cases := []struct {
+		in   any
+		want string
+	}{
+		{nil, ""},
+		{float64(0), ""},
+		{"0", ""},
+		{float64(10021), "10021"},
+		{json.Number("500"), "500"},
+		{" TRADE_LIMIT ", "TRADE_LIMIT"},
+	}
+	for _, c := range cases {
+		if got := NormalizeBizCode(c.in); got != c.want {
+			t.Errorf("NormalizeBizCode(%#v) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestCodeBookClassifiesAndTracksUnknown(t *testing.T) {
+	b := NewCodeBook()
+	b.Load([]model.BizCode{{Code: "429001", Class: model.BizClassThrottle}}, nil)
+
+	if got := b.Observe("create-order", "429001", "请求频繁"); got != model.BizClassThrottle {
+		t.Fatalf("known code class = %q", got)
+	}
+	for i := 0; i < 3; i++ {
+		if got := b.Observe("render-order", "777", "新提示"); got != "" {
+			t.Fatalf("unknown code class = %q", got)
+		}
+	}
+	unknown := b.Unknown()
+	if len(unknown) != 1 || unknown[0].Code != "777" || unknown[0].Count != 3 || unknown[0].API != "render-order" {
+		t.Fatalf("unknown = %+v", unknown)
+	}
+
+	b.Put(model.BizCode{Code: "777", Class: model.BizClassSoldOut})
+	if len(b.Unknown()) != 0 {
+		t.Fatal("registered code should leave the unknown list")
+	}
+
+	err := fmt.Errorf("wrapped: %w", &BizCodeError{API: "create-order", Code: "777", Class: model.BizClassSoldOut, Message: "已售罄"})
+	if code, class := BizCodeOf(err); code != "777" || class != model.BizClassSoldOut {
+		t.Fatalf("BizCodeOf = %q, %q", code, class)
+	}
+	if code, _ := BizCodeOf(errors.New("create-order failed: x")); code != "" {
+		t.Fatalf("plain error code = %q", code)
+	}
+}
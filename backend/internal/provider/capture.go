@@ -0,0 +1,7 @@
+package provider
+
+// CaptureWiper 由会把上游响应录制到本地文件的 Provider 可选实现，隐私清除时用它清空录制文件。
+type CaptureWiper interface {
+	// WipeCapture 清空录制文件并返回其路径；没有配置录制文件时返回空串。
+	WipeCapture() (string, error)
+}
@@ -0,0 +1,301 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// 上游接口熔断：create-order 等接口对所有账号同时大面积 5xx 时，各目标各自重试只会加重上游压力、
+// 白白消耗验证码。熔断器按接口统计最近一段时间的失败率，超过阈值后整个 Provider 对该接口快速失败，
+// 冷却后放行少量探测请求，探测成功即恢复。
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// circuitBuckets 统计窗口切分的桶数，窗口按桶滚动。
+const circuitBuckets = 10
+
+// CircuitOpenError 表示接口处于熔断中，请求没有发出。
+type CircuitOpenError struct {
+	API        string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s circuit open, retry after %s", e.API, e.RetryAfter.Round(time.Millisecond))
+}
+
+// IsCircuitOpen 判断错误是否为熔断导致的快速失败。
+func IsCircuitOpen(err error) bool {
+	var ce *CircuitOpenError
+	return errors.As(err, &ce)
+}
+
+// CircuitReporter 由带熔断的 Provider 可选实现，引擎据此在发起尝试前避让熔断中的接口，并在状态中展示。
+type CircuitReporter interface {
+	// CircuitAllows 判断接口当前是否会放行请求（不占用半开探测名额）。
+	CircuitAllows(api string) bool
+	CircuitStates() []model.CircuitState
+}
+
+// CircuitOptions 是熔断器参数；APIs 为空时不保护任何接口。
+type CircuitOptions struct {
+	APIs []string
+	// Window 统计失败率的滑动窗口，MinRequests 为窗口内判断失败率所需的最少请求数。
+	Window      time.Duration
+	MinRequests int
+	// ErrorRate 失败率达到该值（0-1）时打开。
+	ErrorRate float64
+	// OpenFor 打开后多久进入半开，HalfOpenProbes 为半开时同时放行的探测请求数。
+	OpenFor        time.Duration
+	HalfOpenProbes int
+	// Now 为空时使用 time.Now，测试时注入。
+	Now func() time.Time
+	// OnChange 在状态切换后调用（不持有锁）。
+	OnChange func(model.CircuitState)
+}
+
+// CircuitBreaker 按接口维护熔断状态，并发安全；nil 表示不熔断。
+type CircuitBreaker struct {
+	opts CircuitOptions
+
+	mu   sync.Mutex
+	apis map[string]*circuit
+}
+
+type circuitBucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+type circuit struct {
+	state     string
+	buckets   [circuitBuckets]circuitBucket
+	openedAt  time.Time
+	retryAt   time.Time
+	probes    int
+	trips     int64
+	rejected  int64
+	lastError string
+}
+
+func NewCircuitBreaker(opts CircuitOptions) *CircuitBreaker {
+	if opts.Window <= 0 {
+		opts.Window = 30 * time.Second
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 20
+	}
+	if opts.ErrorRate <= 0 || opts.ErrorRate > 1 {
+		opts.ErrorRate = 0.5
+	}
+	if opts.OpenFor <= 0 {
+		opts.OpenFor = 15 * time.Second
+	}
+	if opts.HalfOpenProbes <= 0 {
+		opts.HalfOpenProbes = 1
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	b := &CircuitBreaker{opts: opts, apis: make(map[string]*circuit, len(opts.APIs))}
+	for _, api := range opts.APIs {
+		b.apis[api] = &circuit{state: CircuitClosed}
+	}
+	return b
+}
+
+// Allow 在发出请求前调用：熔断中返回 *CircuitOpenError；半开时占用一个探测名额，请求结束后必须调用 Record。
+func (b *CircuitBreaker) Allow(api string) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	c := b.apis[api]
+	if c == nil {
+		b.mu.Unlock()
+		return nil
+	}
+	now := b.opts.Now()
+	var changed *model.CircuitState
+	if c.state == CircuitOpen && !now.Before(c.retryAt) {
+		c.state = CircuitHalfOpen
+		c.probes = 0
+		st := b.snapshotLocked(api, c, now)
+		changed = &st
+	}
+	var err error
+	switch c.state {
+	case CircuitOpen:
+		c.rejected++
+		err = &CircuitOpenError{API: api, RetryAfter: c.retryAt.Sub(now)}
+	case CircuitHalfOpen:
+		if c.probes >= b.opts.HalfOpenProbes {
+			c.rejected++
+			err = &CircuitOpenError{API: api, RetryAfter: b.opts.OpenFor / circuitBuckets}
+		} else {
+			c.probes++
+		}
+	}
+	b.mu.Unlock()
+	b.notify(changed)
+	return err
+}
+
+// Record 记录一次已发出请求的结果：failure 为网络错误或 5xx 时传入，成功（含业务失败）传 nil；
+// 请求被调用方取消（failure 为 context.Canceled）时不计入，只释放探测名额。
+func (b *CircuitBreaker) Record(api string, failure error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	c := b.apis[api]
+	if c == nil {
+		b.mu.Unlock()
+		return
+	}
+	now := b.opts.Now()
+	canceled := errors.Is(failure, context.Canceled)
+	var changed *model.CircuitState
+	switch c.state {
+	case CircuitHalfOpen:
+		if c.probes > 0 {
+			c.probes--
+		}
+		switch {
+		case canceled:
+		case failure != nil:
+			c.lastError = failure.Error()
+			b.tripLocked(c, now)
+			st := b.snapshotLocked(api, c, now)
+			changed = &st
+		default:
+			c.state = CircuitClosed
+			c.buckets = [circuitBuckets]circuitBucket{}
+			st := b.snapshotLocked(api, c, now)
+			changed = &st
+		}
+	case CircuitClosed:
+		if canceled {
+			break
+		}
+		bk := b.bucketLocked(c, now)
+		bk.requests++
+		if failure != nil {
+			bk.failures++
+			c.lastError = failure.Error()
+		}
+		requests, failures := b.countLocked(c, now)
+		if requests >= b.opts.MinRequests && float64(failures) >= b.opts.ErrorRate*float64(requests) {
+			b.tripLocked(c, now)
+			st := b.snapshotLocked(api, c, now)
+			st.Requests, st.Failures, st.ErrorRate = requests, failures, float64(failures)/float64(requests)
+			changed = &st
+		}
+	}
+	b.mu.Unlock()
+	b.notify(changed)
+}
+
+// Allows 判断接口当前是否会放行请求，不改变状态。
+func (b *CircuitBreaker) Allows(api string) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.apis[api]
+	if c == nil {
+		return true
+	}
+	switch c.state {
+	case CircuitOpen:
+		return !b.opts.Now().Before(c.retryAt)
+	case CircuitHalfOpen:
+		return c.probes < b.opts.HalfOpenProbes
+	}
+	return true
+}
+
+// States 返回各受保护接口的当前状态（按接口名排序）。
+func (b *CircuitBreaker) States() []model.CircuitState {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.opts.Now()
+	out := make([]model.CircuitState, 0, len(b.apis))
+	for api, c := range b.apis {
+		out = append(out, b.snapshotLocked(api, c, now))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].API < out[j].API })
+	return out
+}
+
+func (b *CircuitBreaker) tripLocked(c *circuit, now time.Time) {
+	c.state = CircuitOpen
+	c.openedAt = now
+	c.retryAt = now.Add(b.opts.OpenFor)
+	c.probes = 0
+	c.trips++
+	c.buckets = [circuitBuckets]circuitBucket{}
+}
+
+// bucketLocked 返回 now 所在的桶，桶已过期时清零复用。
+func (b *CircuitBreaker) bucketLocked(c *circuit, now time.Time) *circuitBucket {
+	width := b.opts.Window / circuitBuckets
+	start := now.Truncate(width)
+	bk := &c.buckets[(start.UnixNano()/int64(width))%circuitBuckets]
+	if !bk.start.Equal(start) {
+		*bk = circuitBucket{start: start}
+	}
+	return bk
+}
+
+func (b *CircuitBreaker) countLocked(c *circuit, now time.Time) (requests, failures int) {
+	cutoff := now.Add(-b.opts.Window)
+	for _, bk := range c.buckets {
+		if bk.start.After(cutoff) {
+			requests += bk.requests
+			failures += bk.failures
+		}
+	}
+	return requests, failures
+}
+
+func (b *CircuitBreaker) snapshotLocked(api string, c *circuit, now time.Time) model.CircuitState {
+	st := model.CircuitState{
+		API:       api,
+		State:     c.state,
+		Trips:     c.trips,
+		Rejected:  c.rejected,
+		LastError: c.lastError,
+	}
+	st.Requests, st.Failures = b.countLocked(c, now)
+	if st.Requests > 0 {
+		st.ErrorRate = float64(st.Failures) / float64(st.Requests)
+	}
+	if !c.openedAt.IsZero() {
+		st.OpenedAtMs = c.openedAt.UnixMilli()
+	}
+	if c.state == CircuitOpen {
+		st.RetryAtMs = c.retryAt.UnixMilli()
+	}
+	return st
+}
+
+func (b *CircuitBreaker) notify(st *model.CircuitState) {
+	if st != nil && b.opts.OnChange != nil {
+		b.opts.OnChange(*st)
+	}
+}
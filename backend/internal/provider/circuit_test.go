@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+func TestCircuitBreakerTripsAndRecovers(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	var changes []model.CircuitState
+	b := NewCircuitBreaker(CircuitOptions{
+		APIs:           []string{"create-order"},
+		Window:         10 * time.Second,
+		MinRequests:    4,
+		ErrorRate:      0.5,
+		OpenFor:        5 * time.Second,
+		HalfOpenProbes: 1,
+		Now:            func() time.Time { return now },
+		OnChange:       func(st model.CircuitState) { changes = append(changes, st) },
+	})
+	fail := errors.New("create-order status 502")
+
+	if err := b.Allow("render-order"); err != nil {
+		t.Fatalf("unprotected api should pass: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := b.Allow("create-order"); err != nil {
+			t.Fatalf("closed circuit should allow: %v", err)
+		}
+		b.Record("create-order", fail)
+	}
+	b.Record("create-order", context.Canceled)
+	if len(changes) != 0 {
+		t.Fatalf("tripped before minRequests: %+v", changes)
+	}
+	b.Record("create-order", nil)
+	if len(changes) != 1 || changes[0].State != CircuitOpen || changes[0].Requests != 4 || changes[0].Failures != 3 {
+		t.Fatalf("changes = %+v", changes)
+	}
+
+	err := b.Allow("create-order")
+	if !IsCircuitOpen(err) {
+		t.Fatalf("open circuit should reject, got %v", err)
+	}
+	if b.Allows("create-order") {
+		t.Fatalf("Allows should be false while open")
+	}
+
+	now = now.Add(5 * time.Second)
+	if !b.Allows("create-order") {
+		t.Fatalf("Allows should be true once retry time passed")
+	}
+	if err := b.Allow("create-order"); err != nil {
+		t.Fatalf("first probe should pass: %v", err)
+	}
+	if !IsCircuitOpen(b.Allow("create-order")) {
+		t.Fatalf("second concurrent probe should be rejected")
+	}
+	b.Record("create-order", fail)
+	if st := changes[len(changes)-1]; st.State != CircuitOpen || st.Trips != 2 {
+		t.Fatalf("failed probe should reopen: %+v", st)
+	}
+
+	now = now.Add(5 * time.Second)
+	if err := b.Allow("create-order"); err != nil {
+		t.Fatalf("probe should pass: %v", err)
+	}
+	b.Record("create-order", nil)
+	states := b.States()
+	if len(states) != 1 || states[0].State != CircuitClosed || states[0].Rejected != 2 {
+		t.Fatalf("states = %+v", states)
+	}
+}
+
+func TestCircuitBreakerWindowSlides(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	b := NewCircuitBreaker(CircuitOptions{
+		APIs:        []string{"create-order"},
+		Window:      10 * time.Second,
+		MinRequests: 4,
+		ErrorRate:   0.5,
+		Now:         func() time.Time { return now },
+	})
+	fail := errors.New("timeout")
+	for i := 0; i < 3; i++ {
+		b.Record("create-order", fail)
+	}
+	now = now.Add(11 * time.Second)
+	b.Record("create-order", fail)
+	if st := b.States()[0]; st.State != CircuitClosed || st.Requests != 1 {
+		t.Fatalf("old failures should have expired: %+v", st)
+	}
+
+	var nilBreaker *CircuitBreaker
+	if err := nilBreaker.Allow("create-order"); err != nil || !nilBreaker.Allows("create-order") {
+		t.Fatalf("nil breaker should allow")
+	}
+	nilBreaker.Record("create-order", fail)
+}
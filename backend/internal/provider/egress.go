@@ -0,0 +1,16 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// EgressProber 由支持出口探测的 Provider 可选实现，引擎在开抢前用它测量各代理/直连到上游主机的延迟与失败率。
+type EgressProber interface {
+	// EgressProxy 返回账号实际使用的代理（账号未设置时为全局代理），空串表示直连。
+	EgressProxy(account model.Account) string
+	// ProbeEgress 经 proxy（空串为直连）向上游主机发 samples 次轻量请求，返回成功请求的耗时与失败次数。
+	ProbeEgress(ctx context.Context, proxy string, samples int) (latencies []time.Duration, failures int, err error)
+}
@@ -0,0 +1,27 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"sniping_engine/internal/model"
+)
+
+// ErrOrderNotFound 表示上游查不到该订单（可能尚未落库，也可能下单实际没有成功）。
+var ErrOrderNotFound = errors.New("order not found")
+
+// OrderDetail 是上游订单详情中引擎关心的字段（原始数据保留在 Raw）。
+type OrderDetail struct {
+	OrderID  string `json:"orderId"`
+	Status   string `json:"status,omitempty"`
+	TotalFee int64  `json:"totalFee,omitempty"`
+	// PayDeadlineMs 为未付款订单的付款截止时间（超时自动取消），上游未返回时为 0。
+	PayDeadlineMs int64           `json:"payDeadlineMs,omitempty"`
+	Raw           json.RawMessage `json:"raw,omitempty"`
+}
+
+// OrderDetailer 由支持查询订单详情的 Provider 可选实现，引擎在下单成功后用它确认订单确实存在。
+type OrderDetailer interface {
+	GetOrderDetail(ctx context.Context, account model.Account, orderID string) (OrderDetail, model.Account, error)
+}
@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"sniping_engine/internal/model"
 )
@@ -13,12 +14,44 @@ type PreflightResult struct {
 	TotalFee    int64           `json:"totalFee"`
 	TraceID     string          `json:"traceId,omitempty"`
 	Render      json.RawMessage `json:"render,omitempty"`
+	// AccountID 为执行该预下单的账号；render 只能由同一账号提交（见 AttemptContext）。
+	AccountID string `json:"accountId,omitempty"`
+	// VerifyTokenAvailable 表示该账号当前缓存着可用的免滑块凭证，下单时无需求解验证码。
+	VerifyTokenAvailable bool `json:"verifyTokenAvailable,omitempty"`
+	// InStock 为 render 中带出的当前库存；StockKnown=false 表示上游没有返回库存。
+	InStock    int64 `json:"inStock,omitempty"`
+	StockKnown bool  `json:"stockKnown,omitempty"`
 }
 
 type CreateResult struct {
 	Success bool   `json:"success"`
 	OrderID string `json:"orderId,omitempty"`
 	TraceID string `json:"traceId,omitempty"`
+	// TotalFee 为 create-order 响应里带回的订单金额（分），上游未返回时为 0。
+	TotalFee int64 `json:"totalFee,omitempty"`
+	// UsedVerifyToken 表示本次下单使用了免滑块凭证代替验证码。
+	UsedVerifyToken bool `json:"usedVerifyToken,omitempty"`
+}
+
+// ProbeResult 是轻量库存探测的结果；Known=false 表示无法判断（例如缺少分类或未找到该 SKU）。
+// SaleStartMs 为商品数据中带出的开售时间（毫秒），上游未提供时为 0。
+type ProbeResult struct {
+	Known       bool  `json:"known"`
+	InStock     int64 `json:"inStock"`
+	Price       int64 `json:"price,omitempty"`
+	SaleStartMs int64 `json:"saleStartMs,omitempty"`
+}
+
+// UpstreamOrder 是上游订单列表中的一条记录（字段按常见命名做了归一化，原始数据保留在 Raw）。
+type UpstreamOrder struct {
+	OrderID     string          `json:"orderId"`
+	Status      string          `json:"status,omitempty"`
+	TotalFee    int64           `json:"totalFee"`
+	CreatedAtMs int64           `json:"createdAtMs,omitempty"`
+	ItemName    string          `json:"itemName,omitempty"`
+	SKUIDs      []int64         `json:"skuIds,omitempty"`
+	Quantity    int             `json:"quantity,omitempty"`
+	Raw         json.RawMessage `json:"raw,omitempty"`
 }
 
 type ShippingAddressParams struct {
@@ -26,6 +59,7 @@ type ShippingAddressParams struct {
 	IsAllCover int    `json:"isAllCover"`
 }
 
+// CategoryTreeParams/StoreSkuByCategoryParams 的经纬度都为 0 时，使用账号当前收货地址的位置。
 type CategoryTreeParams struct {
 	FrontCategoryID int64   `json:"frontCategoryId"`
 	Longitude       float64 `json:"longitude"`
@@ -47,7 +81,12 @@ type Provider interface {
 
 	LoginBySMS(ctx context.Context, account model.Account, mobile, smsCode string) (model.Account, error)
 	Preflight(ctx context.Context, account model.Account, target model.Target) (PreflightResult, model.Account, error)
-	CreateOrder(ctx context.Context, account model.Account, target model.Target, preflight PreflightResult) (CreateResult, model.Account, error)
+	// CreateOrder 提交 attempt 中绑定的 render；账号、商品与验证码均取自 attempt。
+	CreateOrder(ctx context.Context, attempt *AttemptContext) (CreateResult, model.Account, error)
+	ProbeStock(ctx context.Context, account model.Account, target model.Target) (ProbeResult, model.Account, error)
+	ListRecentOrders(ctx context.Context, account model.Account, since time.Time) ([]UpstreamOrder, model.Account, error)
+	// RefreshSession 访问一个需要登录态的轻量接口，让上游重新下发会话/风控 cookie。
+	RefreshSession(ctx context.Context, account model.Account) (model.Account, error)
 
 	GetShippingAddresses(ctx context.Context, account model.Account, params ShippingAddressParams) (json.RawMessage, model.Account, error)
 	GetCategoryTree(ctx context.Context, account model.Account, params CategoryTreeParams) (json.RawMessage, model.Account, error)
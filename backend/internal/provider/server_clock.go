@@ -0,0 +1,12 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// ServerClock 由能读取上游服务器时间的 Provider 可选实现，引擎用它校准本机时钟与上游的偏差。
+type ServerClock interface {
+	// ServerDate 发一次轻量请求并返回上游响应的 Date 头（精确到秒）。
+	ServerDate(ctx context.Context) (time.Time, error)
+}
@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"context"
+
+	"sniping_engine/internal/model"
+)
+
+// BootstrapStep 是补全会话时一次上游访问的结果。
+type BootstrapStep struct {
+	Path   string `json:"path"`
+	Status int    `json:"status,omitempty"`
+	// CookiesSet 本次访问新下发或更新的 cookie 名。
+	CookiesSet []string `json:"cookiesSet,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// SessionBootstrapper 由支持补全会话的 Provider 可选实现：只有 token（手工粘贴）的账号缺少登录流程中下发的 cookie
+// （如验证码求解需要的 draco_local），按顺序访问入口页面和几个需要登录态的接口，让上游下发标准 cookie。
+type SessionBootstrapper interface {
+	BootstrapSession(ctx context.Context, account model.Account) (model.Account, []BootstrapStep, error)
+}
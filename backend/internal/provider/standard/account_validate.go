@@ -0,0 +1,108 @@
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+const (
+	currentUserPath     = "/api/user/web/current-user"
+	shippingAddressPath = "/api/user/web/shipping-address/self/list-all"
+)
+
+// ValidateAccount 访问 current-user（checkAddress 时再访问收货地址列表）确认 token/cookie 仍然有效；
+// current-user 返回用户名时写回账号的 Username。
+func (p *StandardProvider) ValidateAccount(ctx context.Context, account model.Account, checkAddress bool) (model.Account, []provider.AccountCheck, error) {
+	if strings.TrimSpace(account.Token) == "" {
+		return model.Account{}, nil, errors.New("account has no token")
+	}
+	client, jar, err := p.newClient(account)
+	if err != nil {
+		return model.Account{}, nil, err
+	}
+	updated := account
+
+	var checks []provider.AccountCheck
+	user := provider.AccountCheck{Path: currentUserPath}
+	var env apiEnvelope[json.RawMessage]
+	resp, err := client.R().SetContext(ctx).SetResult(&env).Get(currentUserPath)
+	switch {
+	case err != nil:
+		user.Error = err.Error()
+	case resp.StatusCode() >= 400:
+		user.Status = resp.StatusCode()
+		user.Error = fmt.Sprintf("status %d: %s", resp.StatusCode(), httpErrorSummary(resp))
+	case !env.Success:
+		user.Status = resp.StatusCode()
+		user.Error = envelopeError(env.Error, env.Message, "current-user failed")
+	default:
+		user.Status = resp.StatusCode()
+		user.OK = true
+		if name := extractUsername(env.Data); name != "" {
+			updated.Username = name
+		}
+	}
+	checks = append(checks, user)
+
+	if checkAddress && ctx.Err() == nil {
+		addr := provider.AccountCheck{Path: shippingAddressPath}
+		var env apiEnvelope[json.RawMessage]
+		resp, err := client.R().
+			SetContext(ctx).
+			SetQueryParams(map[string]string{"app": "o2o", "isAllCover": "1"}).
+			SetResult(&env).
+			Get(shippingAddressPath)
+		switch {
+		case err != nil:
+			addr.Error = err.Error()
+		case resp.StatusCode() >= 400:
+			addr.Status = resp.StatusCode()
+			addr.Error = fmt.Sprintf("status %d: %s", resp.StatusCode(), httpErrorSummary(resp))
+		case !env.Success:
+			addr.Status = resp.StatusCode()
+			addr.Error = envelopeError(env.Error, env.Message, "fetch shipping address failed")
+		default:
+			addr.Status = resp.StatusCode()
+			var list []map[string]any
+			if err := decodeUseNumber(env.Data, &list); err != nil || len(list) == 0 {
+				addr.Error = "no shipping address"
+			} else {
+				addr.OK = true
+			}
+		}
+		checks = append(checks, addr)
+	}
+
+	updated.Cookies = p.exportCookies(jar)
+	return updated, checks, nil
+}
+
+func envelopeError(errMsg, message, fallback string) string {
+	if msg := strings.TrimSpace(errMsg); msg != "" {
+		return msg
+	}
+	if msg := strings.TrimSpace(message); msg != "" {
+		return msg
+	}
+	return fallback
+}
+
+func extractUsername(data json.RawMessage) string {
+	var m map[string]any
+	if err := decodeUseNumber(data, &m); err != nil {
+		return ""
+	}
+	switch v := m["username"].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package standard
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"sniping_engine/internal/model"
+)
+
+// CaptureRecord 是演练录制文件（JSONL）中的一行：一次 render-order/create-order 的上游响应。
+// OffsetMs 为响应时刻相对目标 rushAtMs 的偏移（扫货目标或没有开抢时间时为 0），mock 回放时据此对齐时间线。
+type CaptureRecord struct {
+	AtMs      int64           `json:"atMs"`
+	API       string          `json:"api"`
+	TargetID  string          `json:"targetId,omitempty"`
+	AccountID string          `json:"accountId,omitempty"`
+	RushAtMs  int64           `json:"rushAtMs,omitempty"`
+	OffsetMs  int64           `json:"offsetMs"`
+	Status    int             `json:"status"`
+	LatencyMs int64           `json:"latencyMs"`
+	Body      json.RawMessage `json:"body"`
+}
+
+type captureWriter struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	err  bool
+}
+
+// capture 在配置了 provider.captureFile 时追加一条响应记录；写入失败只告警一次，不影响下单流程。
+func (p *StandardProvider) capture(api string, account model.Account, target model.Target, resp *resty.Response) {
+	if p == nil || resp == nil || strings.TrimSpace(p.cfg.CaptureFile) == "" {
+		return
+	}
+	at := resp.ReceivedAt()
+	if at.IsZero() {
+		at = time.Now()
+	}
+	rec := CaptureRecord{
+		AtMs:      at.UnixMilli(),
+		API:       api,
+		TargetID:  target.ID,
+		AccountID: account.ID,
+		Status:    resp.StatusCode(),
+		LatencyMs: resp.Time().Milliseconds(),
+	}
+	if target.Mode == model.TargetModeRush && target.RushAtMs > 0 {
+		rec.RushAtMs = target.RushAtMs
+		rec.OffsetMs = rec.AtMs - target.RushAtMs
+	}
+	if body := resp.Body(); json.Valid(body) {
+		rec.Body = json.RawMessage(body)
+	} else {
+		b, _ := json.Marshal(string(body))
+		rec.Body = b
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+
+	w := &p.captureOut
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err {
+		return
+	}
+	if w.f == nil {
+		w.path = strings.TrimSpace(p.cfg.CaptureFile)
+		w.f, err = os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			w.err = true
+			if p.bus != nil {
+				p.bus.Log("warn", "演练录制文件打开失败", map[string]any{"path": w.path, "error": err.Error()})
+			}
+			return
+		}
+	}
+	if _, err := w.f.Write(append(line, '\n')); err != nil {
+		w.err = true
+		if p.bus != nil {
+			p.bus.Log("warn", "演练录制写入失败", map[string]any{"path": w.path, "error": err.Error()})
+		}
+	}
+}
+
+// WipeCapture 清空演练录制文件（保留文件本身，之后的记录从头写入）；未配置 provider.captureFile 时不做任何事。
+func (p *StandardProvider) WipeCapture() (string, error) {
+	if p == nil {
+		return "", nil
+	}
+	path := strings.TrimSpace(p.cfg.CaptureFile)
+	if path == "" {
+		return "", nil
+	}
+	w := &p.captureOut
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f != nil {
+		return path, w.f.Truncate(0)
+	}
+	if err := os.Truncate(path, 0); err != nil && !os.IsNotExist(err) {
+		return path, err
+	}
+	return path, nil
+}
@@ -0,0 +1,78 @@
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-resty/resty/v2"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+func (p *StandardProvider) newCircuitBreaker() *provider.CircuitBreaker {
+	cb := p.cfg.CircuitBreaker
+	apis := cb.ProtectedAPIs()
+	if len(apis) == 0 {
+		return nil
+	}
+	return provider.NewCircuitBreaker(provider.CircuitOptions{
+		APIs:           apis,
+		Window:         cb.Window(),
+		MinRequests:    cb.MinRequests,
+		ErrorRate:      cb.ErrorRate,
+		OpenFor:        cb.OpenFor(),
+		HalfOpenProbes: cb.HalfOpenProbes,
+		OnChange:       p.publishCircuitState,
+	})
+}
+
+// publishCircuitState 推送 circuit_breaker 消息，并记一条日志（打开为 warn，恢复为 info）。
+func (p *StandardProvider) publishCircuitState(st model.CircuitState) {
+	if p.bus == nil {
+		return
+	}
+	p.bus.Publish("circuit_breaker", st)
+	fields := map[string]any{
+		"api":       st.API,
+		"state":     st.State,
+		"requests":  st.Requests,
+		"failures":  st.Failures,
+		"errorRate": st.ErrorRate,
+	}
+	switch st.State {
+	case provider.CircuitOpen:
+		fields["retryAtMs"] = st.RetryAtMs
+		fields["lastError"] = st.LastError
+		p.bus.Log("warn", "上游接口熔断", fields)
+	case provider.CircuitHalfOpen:
+		p.bus.Log("info", "上游接口熔断进入半开，放行探测请求", fields)
+	default:
+		p.bus.Log("info", "上游接口熔断已恢复", fields)
+	}
+}
+
+// CircuitAllows 实现 provider.CircuitReporter。
+func (p *StandardProvider) CircuitAllows(api string) bool {
+	return p.breaker.Allows(api)
+}
+
+// CircuitStates 实现 provider.CircuitReporter。
+func (p *StandardProvider) CircuitStates() []model.CircuitState {
+	return p.breaker.States()
+}
+
+// upstreamFailure 返回计入熔断的失败：网络错误（含超时）或 5xx；4xx 与业务失败说明上游仍在正常处理，不计入。
+// 调用方自己取消（停止引擎、尝试超时）时返回 context.Canceled，不计入。
+func upstreamFailure(ctx context.Context, resp *resty.Response, err error) error {
+	if err != nil {
+		if ctx.Err() != nil {
+			return context.Canceled
+		}
+		return err
+	}
+	if resp != nil && resp.StatusCode() >= 500 {
+		return fmt.Errorf("status %d", resp.StatusCode())
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package standard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// EgressProxy 与 newClient 的代理选择保持一致：账号代理优先，其次全局代理。
+func (p *StandardProvider) EgressProxy(account model.Account) string {
+	if proxy := strings.TrimSpace(account.Proxy); proxy != "" {
+		return proxy
+	}
+	return strings.TrimSpace(p.proxyCfg.Global)
+}
+
+// ProbeEgress 对 baseURL 发 HEAD 请求测量往返耗时；收到任何 HTTP 响应都算成功，连接/超时错误计为失败。
+// 同一出口复用连接，与开抢时的长连接行为一致。
+func (p *StandardProvider) ProbeEgress(ctx context.Context, proxy string, samples int) ([]time.Duration, int, error) {
+	base := strings.TrimSpace(p.cfg.BaseURL)
+	if base == "" {
+		return nil, 0, errors.New("provider baseURL is empty")
+	}
+	if samples <= 0 {
+		samples = 1
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, 0, err
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: p.cfg.Timeout()}
+
+	var latencies []time.Duration
+	failures := 0
+	for i := 0; i < samples; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return latencies, failures, ctx.Err()
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, base, nil)
+		if err != nil {
+			return nil, 0, err
+		}
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return latencies, failures, ctx.Err()
+			}
+			failures++
+			continue
+		}
+		_ = resp.Body.Close()
+		latencies = append(latencies, time.Since(start))
+	}
+	return latencies, failures, nil
+}
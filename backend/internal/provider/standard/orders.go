@@ -0,0 +1,267 @@
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+const (
+	orderListPageSize = 20
+	orderListMaxPages = 5
+)
+
+// ListRecentOrders 拉取账号在 since 之后创建的上游订单（按页向前翻，最多 orderListMaxPages 页）。
+func (p *StandardProvider) ListRecentOrders(ctx context.Context, account model.Account, since time.Time) ([]provider.UpstreamOrder, model.Account, error) {
+	client, jar, err := p.newClient(account)
+	if err != nil {
+		return nil, model.Account{}, err
+	}
+
+	sinceMs := int64(0)
+	if !since.IsZero() {
+		sinceMs = since.UnixMilli()
+	}
+
+	var out []provider.UpstreamOrder
+	for pageNo := 1; pageNo <= orderListMaxPages; pageNo++ {
+		var env apiEnvelope[json.RawMessage]
+		resp, err := client.R().
+			SetContext(ctx).
+			SetQueryParams(map[string]string{
+				"pageNo":   strconv.Itoa(pageNo),
+				"pageSize": strconv.Itoa(orderListPageSize),
+			}).
+			SetResult(&env).
+			Get("/api/trade/order/list")
+		if err != nil {
+			return nil, model.Account{}, err
+		}
+		if resp.StatusCode() >= 400 {
+			p.logUpstreamFailure("trade.order.list", resp, "http status error", map[string]any{"accountId": account.ID})
+			return nil, model.Account{}, fmt.Errorf("order-list status %d: %s", resp.StatusCode(), httpErrorSummary(resp))
+		}
+		if !env.Success {
+			msg := strings.TrimSpace(env.Error)
+			if msg == "" {
+				msg = strings.TrimSpace(env.Message)
+			}
+			if msg == "" {
+				msg = "list orders failed"
+			}
+			return nil, model.Account{}, errors.New(msg)
+		}
+
+		page := parseUpstreamOrders(env.Data)
+		reachedSince := false
+		for _, o := range page {
+			if sinceMs > 0 && o.CreatedAtMs > 0 && o.CreatedAtMs < sinceMs {
+				reachedSince = true
+				continue
+			}
+			out = append(out, o)
+		}
+		if reachedSince || len(page) < orderListPageSize {
+			break
+		}
+	}
+
+	updated := account
+	updated.Cookies = p.exportCookies(jar)
+	return out, updated, nil
+}
+
+// GetOrderDetail 查询单个订单的详情；上游返回成功但没有订单数据时返回 provider.ErrOrderNotFound。
+func (p *StandardProvider) GetOrderDetail(ctx context.Context, account model.Account, orderID string) (provider.OrderDetail, model.Account, error) {
+	client, jar, err := p.newClient(account)
+	if err != nil {
+		return provider.OrderDetail{}, model.Account{}, err
+	}
+
+	var env apiEnvelope[json.RawMessage]
+	resp, err := client.R().
+		SetContext(ctx).
+		SetQueryParam("orderId", orderID).
+		SetResult(&env).
+		Get("/api/trade/order/detail")
+	if err != nil {
+		return provider.OrderDetail{}, model.Account{}, err
+	}
+	if resp.StatusCode() == 404 {
+		return provider.OrderDetail{}, model.Account{}, provider.ErrOrderNotFound
+	}
+	if resp.StatusCode() >= 400 {
+		p.logUpstreamFailure("trade.order.detail", resp, "http status error", map[string]any{"accountId": account.ID, "orderId": orderID})
+		return provider.OrderDetail{}, model.Account{}, fmt.Errorf("order-detail status %d: %s", resp.StatusCode(), httpErrorSummary(resp))
+	}
+	if !env.Success {
+		msg := strings.TrimSpace(env.Error)
+		if msg == "" {
+			msg = strings.TrimSpace(env.Message)
+		}
+		if msg == "" {
+			msg = "get order detail failed"
+		}
+		return provider.OrderDetail{}, model.Account{}, errors.New(msg)
+	}
+
+	detail, ok := parseOrderDetail(env.Data)
+	if !ok {
+		return provider.OrderDetail{}, model.Account{}, provider.ErrOrderNotFound
+	}
+	updated := account
+	updated.Cookies = p.exportCookies(jar)
+	return detail, updated, nil
+}
+
+func parseOrderDetail(data json.RawMessage) (provider.OrderDetail, bool) {
+	var root any
+	if err := decodeUseNumber(data, &root); err != nil {
+		return provider.OrderDetail{}, false
+	}
+	m, ok := asMap(root)
+	if !ok {
+		return provider.OrderDetail{}, false
+	}
+	for _, k := range []string{"order", "orderInfo", "purchaseOrder"} {
+		if inner, ok := asMap(m[k]); ok {
+			m = inner
+			break
+		}
+	}
+	d := provider.OrderDetail{
+		OrderID:       firstString(m, "orderId", "purchaseOrderId", "id"),
+		Status:        firstString(m, "status", "orderStatus", "statusDesc"),
+		TotalFee:      firstInt64(m, "totalFee", "actualPayFee", "fee"),
+		PayDeadlineMs: parseUpstreamTimeMs(firstValue(m, "payDeadline", "payExpireTime", "expireTime", "autoCancelTime")),
+	}
+	if d.OrderID == "" {
+		return provider.OrderDetail{}, false
+	}
+	if b, err := json.Marshal(m); err == nil {
+		d.Raw = b
+	}
+	return d, true
+}
+
+func parseUpstreamOrders(data json.RawMessage) []provider.UpstreamOrder {
+	var root any
+	if err := decodeUseNumber(data, &root); err != nil {
+		return nil
+	}
+
+	list, ok := asSlice(root)
+	if !ok {
+		m, _ := asMap(root)
+		for _, k := range []string{"data", "list", "records", "rows"} {
+			if l, ok := asSlice(m[k]); ok {
+				list = l
+				break
+			}
+		}
+	}
+
+	out := make([]provider.UpstreamOrder, 0, len(list))
+	for _, raw := range list {
+		m, ok := asMap(raw)
+		if !ok {
+			continue
+		}
+		o := provider.UpstreamOrder{
+			OrderID:     firstString(m, "orderId", "purchaseOrderId", "id"),
+			Status:      firstString(m, "status", "orderStatus", "statusDesc"),
+			TotalFee:    firstInt64(m, "totalFee", "actualPayFee", "fee"),
+			CreatedAtMs: parseUpstreamTimeMs(firstValue(m, "createdAt", "createTime", "gmtCreate")),
+			ItemName:    firstString(m, "itemName", "title"),
+		}
+		for _, k := range []string{"orderLineList", "itemList", "skuList"} {
+			lines, ok := asSlice(m[k])
+			if !ok {
+				continue
+			}
+			for _, rawLine := range lines {
+				line, ok := asMap(rawLine)
+				if !ok {
+					continue
+				}
+				if id, ok := toInt64(line["skuId"]); ok && id > 0 {
+					o.SKUIDs = append(o.SKUIDs, id)
+				}
+				if q, ok := toInt64(line["quantity"]); ok && q > 0 {
+					o.Quantity += int(q)
+				}
+				if o.ItemName == "" {
+					o.ItemName = firstString(line, "itemName", "skuName")
+				}
+			}
+			break
+		}
+		if b, err := json.Marshal(m); err == nil {
+			o.Raw = b
+		}
+		if o.OrderID == "" {
+			continue
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+func firstValue(m map[string]any, keys ...string) any {
+	for _, k := range keys {
+		if v, ok := m[k]; ok && v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func firstString(m map[string]any, keys ...string) string {
+	for _, k := range keys {
+		switch v := m[k].(type) {
+		case string:
+			if s := strings.TrimSpace(v); s != "" {
+				return s
+			}
+		case json.Number:
+			return v.String()
+		}
+	}
+	return ""
+}
+
+func firstInt64(m map[string]any, keys ...string) int64 {
+	for _, k := range keys {
+		if n, ok := toInt64(m[k]); ok {
+			return n
+		}
+	}
+	return 0
+}
+
+// parseUpstreamTimeMs 兼容毫秒/秒时间戳和 "2006-01-02 15:04:05" 字符串。
+func parseUpstreamTimeMs(v any) int64 {
+	if n, ok := toInt64(v); ok && n > 0 {
+		if n < 1e12 {
+			return n * 1000
+		}
+		return n
+	}
+	s, ok := v.(string)
+	if !ok || strings.TrimSpace(s) == "" {
+		return 0
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(s), time.Local); err == nil {
+			return t.UnixMilli()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,27 @@
+package standard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// ServerDate 对 baseURL 发 HEAD 请求并解析 Date 头；走全局代理，与大多数账号的出口一致。
+func (p *StandardProvider) ServerDate(ctx context.Context) (time.Time, error) {
+	client, _, err := p.newClient(model.Account{})
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp, err := client.SetRetryCount(0).R().SetContext(ctx).Head("/")
+	if err != nil {
+		return time.Time{}, err
+	}
+	raw := resp.Header().Get("Date")
+	if raw == "" {
+		return time.Time{}, errors.New("upstream response has no Date header")
+	}
+	return http.ParseTime(raw)
+}
@@ -0,0 +1,92 @@
+package standard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/provider"
+)
+
+// defaultBootstrapPaths 补全会话时依次访问的路径（相对 baseURL）：H5 入口页、当前用户、收货地址列表。
+var defaultBootstrapPaths = []string{
+	"/",
+	"/api/user/web/current-user",
+	"/api/user/web/shipping-address/self/list-all?app=o2o&isAllCover=1",
+}
+
+// BootstrapSession 带着账号 token 依次访问 bootstrapPaths，收集上游下发的 cookie；
+// 单个路径失败不影响后续访问，全部失败时返回错误。
+func (p *StandardProvider) BootstrapSession(ctx context.Context, account model.Account) (model.Account, []provider.BootstrapStep, error) {
+	if strings.TrimSpace(account.Token) == "" {
+		return model.Account{}, nil, errors.New("account has no token")
+	}
+	client, jar, err := p.newClient(account)
+	if err != nil {
+		return model.Account{}, nil, err
+	}
+	paths := p.cfg.BootstrapPaths
+	if len(paths) == 0 {
+		paths = defaultBootstrapPaths
+	}
+
+	steps := make([]provider.BootstrapStep, 0, len(paths))
+	ok := 0
+	for _, raw := range paths {
+		path := strings.TrimSpace(raw)
+		if path == "" {
+			continue
+		}
+		step := provider.BootstrapStep{Path: path}
+		before := cookieValues(p.exportCookies(jar))
+
+		req := client.R().SetContext(ctx)
+		if u, err := url.Parse(path); err == nil && u.RawQuery != "" {
+			path = u.Path
+			req.SetQueryString(u.RawQuery)
+		}
+		if !strings.HasPrefix(path, "/api/") {
+			req.SetHeader("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+		}
+		resp, err := req.Get(path)
+		if err != nil {
+			step.Error = err.Error()
+		} else {
+			step.Status = resp.StatusCode()
+			if step.Status >= 400 {
+				step.Error = fmt.Sprintf("status %d", step.Status)
+			} else {
+				ok++
+			}
+		}
+		for name, value := range cookieValues(p.exportCookies(jar)) {
+			if prev, seen := before[name]; !seen || prev != value {
+				step.CookiesSet = append(step.CookiesSet, name)
+			}
+		}
+		steps = append(steps, step)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if ok == 0 {
+		return model.Account{}, steps, errors.New("all bootstrap requests failed")
+	}
+
+	updated := account
+	updated.Cookies = p.exportCookies(jar)
+	return updated, steps, nil
+}
+
+func cookieValues(entries []model.CookieJarEntry) map[string]string {
+	out := make(map[string]string)
+	for _, entry := range entries {
+		for _, c := range entry.Cookies {
+			out[c.Name] = c.Value
+		}
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package standard
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"sniping_engine/internal/model"
+)
+
+type slowTraceStartKey struct{}
+
+// SetSlowRequestHandler 设置慢请求的落库回调（在单独的 goroutine 中调用，不阻塞请求方）。
+func (p *StandardProvider) SetSlowRequestHandler(fn func(model.SlowRequest)) {
+	p.slowHandler = fn
+}
+
+// installSlowTrace 为客户端开启阶段追踪：请求（含重试）结束后耗时达到阈值的记为慢请求，快的请求不产生任何输出。
+func (p *StandardProvider) installSlowTrace(client *resty.Client, account model.Account, proxy string) {
+	threshold := p.cfg.SlowRequestThreshold()
+	if threshold <= 0 {
+		return
+	}
+	egress := "direct"
+	if proxy != "" {
+		egress = model.RedactProxyURL(proxy)
+	}
+
+	client.EnableTrace()
+	// OnBeforeRequest 每次尝试都会执行，只在第一次记下起点，TotalMs 因而包含重试等待。
+	client.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+		if _, ok := req.Context().Value(slowTraceStartKey{}).(time.Time); !ok {
+			req.SetContext(context.WithValue(req.Context(), slowTraceStartKey{}, time.Now()))
+		}
+		return nil
+	})
+	client.OnSuccess(func(_ *resty.Client, resp *resty.Response) {
+		p.recordSlowRequest(resp.Request, resp, nil, account.ID, egress, threshold)
+	})
+	client.OnError(func(req *resty.Request, err error) {
+		var resp *resty.Response
+		var respErr *resty.ResponseError
+		if errors.As(err, &respErr) {
+			resp, err = respErr.Response, respErr.Err
+		}
+		p.recordSlowRequest(req, resp, err, account.ID, egress, threshold)
+	})
+}
+
+func (p *StandardProvider) recordSlowRequest(req *resty.Request, resp *resty.Response, err error, accountID, egress string, threshold time.Duration) {
+	if req == nil {
+		return
+	}
+	started, ok := req.Context().Value(slowTraceStartKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	total := time.Since(started)
+	if total < threshold {
+		return
+	}
+
+	ti := req.TraceInfo()
+	rec := model.SlowRequest{
+		AtMs:        time.Now().UnixMilli(),
+		Method:      req.Method,
+		Path:        requestPath(req),
+		AccountID:   accountID,
+		Proxy:       egress,
+		Attempts:    req.Attempt,
+		TotalMs:     total.Milliseconds(),
+		DNSMs:       ti.DNSLookup.Milliseconds(),
+		ConnMs:      ti.ConnTime.Milliseconds(),
+		TLSMs:       ti.TLSHandshake.Milliseconds(),
+		ServerMs:    ti.ServerTime.Milliseconds(),
+		ResponseMs:  ti.ResponseTime.Milliseconds(),
+		ConnReused:  ti.IsConnReused,
+		ThresholdMs: threshold.Milliseconds(),
+	}
+	if ti.RemoteAddr != nil {
+		rec.RemoteAddr = ti.RemoteAddr.String()
+	}
+	if resp != nil {
+		rec.Status = resp.StatusCode()
+	}
+	if err != nil {
+		rec.Error = err.Error()
+		// url.Error 会带上完整地址（含查询串），只保留操作和底层错误。
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			rec.Error = ue.Op + ": " + ue.Err.Error()
+		}
+	}
+
+	if p.bus != nil {
+		p.bus.Publish("slow_request", rec)
+	}
+	if fn := p.slowHandler; fn != nil {
+		go fn(rec)
+	}
+}
+
+// requestPath 返回请求路径（不含查询串，避免把参数里的凭据写进日志）。
+func requestPath(req *resty.Request) string {
+	if req.RawRequest != nil && req.RawRequest.URL != nil {
+		return req.RawRequest.URL.Path
+	}
+	if u, err := url.Parse(req.URL); err == nil && u.Path != "" {
+		return u.Path
+	}
+	raw := req.URL
+	if i := strings.IndexByte(raw, '?'); i >= 0 {
+		raw = raw[:i]
+	}
+	return raw
+}
@@ -6,11 +6,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http/cookiejar"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 
@@ -26,20 +27,66 @@ type StandardProvider struct {
 	proxyCfg config.ProxyConfig
 	bus      *logbus.Bus
 	baseURL  *url.URL
+
+	geoMu sync.Mutex
+	geo   map[string]geoPoint
+
+	tokenMu      sync.Mutex
+	verifyTokens map[string]verifyToken
+
+	// codes 业务码字典；为空时业务失败只返回上游提示文本。
+	codes *provider.CodeBook
+
+	captureOut captureWriter
+
+	// slowHandler 慢请求落库回调，见 SetSlowRequestHandler。
+	slowHandler func(model.SlowRequest)
+
+	// breaker 按接口熔断，见 provider.CircuitBreaker；关闭时为 nil。
+	breaker *provider.CircuitBreaker
+}
+
+type geoPoint struct {
+	Longitude float64
+	Latitude  float64
 }
 
 func New(cfg config.ProviderConfig, proxyCfg config.ProxyConfig, bus *logbus.Bus) *StandardProvider {
 	u, _ := url.Parse(cfg.BaseURL)
-	return &StandardProvider{
+	p := &StandardProvider{
 		cfg:      cfg,
 		proxyCfg: proxyCfg,
 		bus:      bus,
 		baseURL:  u,
+		geo:      make(map[string]geoPoint),
+
+		verifyTokens: make(map[string]verifyToken),
 	}
+	p.breaker = p.newCircuitBreaker()
+	return p
 }
 
 func (p *StandardProvider) Name() string { return "standard" }
 
+// SetCodeBook 设置业务码字典，render-order/create-order 的业务失败会带上字典中的分类。
+func (p *StandardProvider) SetCodeBook(codes *provider.CodeBook) {
+	p.codes = codes
+}
+
+// bizFailure 构造业务失败（success=false）的错误：响应带 code 时返回 *provider.BizCodeError 并记录未登记的业务码。
+func (p *StandardProvider) bizFailure(api string, code any, msg string) (map[string]any, error) {
+	c := provider.NormalizeBizCode(code)
+	if c == "" {
+		return nil, fmt.Errorf("%s failed: %s", api, msg)
+	}
+	class := p.codes.Observe(api, c, msg)
+	fields := map[string]any{"bizCode": c}
+	if class != "" {
+		fields["bizClass"] = class
+	}
+	return fields, &provider.BizCodeError{API: api, Code: c, Class: class, Message: msg}
+}
+
 type apiEnvelope[T any] struct {
 	Success bool   `json:"success"`
 	Error   string `json:"error,omitempty"`
@@ -63,10 +110,24 @@ type loginBySMSResp struct {
 	} `json:"data"`
 }
 
-const (
-	tradeDeviceSourceWXAPP  = "WXAPP"
-	tradeOrderSourceProduct = "product.detail.page"
-)
+// tradeSources 返回下单使用的 deviceSource/orderSource：目标覆盖 > provider 配置 > 默认值。
+func (p *StandardProvider) tradeSources(target model.Target) (deviceSource, orderSource string) {
+	deviceSource = strings.TrimSpace(target.DeviceSource)
+	if deviceSource == "" {
+		deviceSource = strings.TrimSpace(p.cfg.DeviceSource)
+	}
+	if deviceSource == "" {
+		deviceSource = model.DeviceSourceWXAPP
+	}
+	orderSource = strings.TrimSpace(target.OrderSource)
+	if orderSource == "" {
+		orderSource = strings.TrimSpace(p.cfg.OrderSource)
+	}
+	if orderSource == "" {
+		orderSource = model.OrderSourceProductDetail
+	}
+	return deviceSource, orderSource
+}
 
 type tradeBuyConfig struct {
 	LineGrouped    bool `json:"lineGrouped"`
@@ -84,6 +145,24 @@ type tradeRenderOrderLine struct {
 	ShopActivityID any            `json:"shopActivityId,omitempty"`
 }
 
+// renderOrderLines 把目标的主商品与附加订单行转换为 render-order 的 orderLineList。
+func renderOrderLines(target model.Target) []tradeRenderOrderLine {
+	lines := target.OrderLines()
+	out := make([]tradeRenderOrderLine, 0, len(lines))
+	for _, l := range lines {
+		out = append(out, tradeRenderOrderLine{
+			SKUID:        l.SKUID,
+			ItemID:       l.ItemID,
+			Quantity:     l.Qty,
+			PromotionTag: nil,
+			ActivityID:   nil,
+			Extra:        map[string]any{},
+			ShopID:       l.ShopID,
+		})
+	}
+	return out
+}
+
 type tradeRenderOrderRequest struct {
 	DeviceSource  string                 `json:"deviceSource"`
 	OrderSource   string                 `json:"orderSource"`
@@ -150,11 +229,6 @@ func (p *StandardProvider) Preflight(ctx context.Context, account model.Account,
 		return provider.PreflightResult{}, model.Account{}, errors.New("deviceId is required")
 	}
 
-	qty := target.PerOrderQty
-	if qty <= 0 {
-		qty = 1
-	}
-
 	var addrPtr *int64
 	if updated.AddressID > 0 {
 		v := updated.AddressID
@@ -166,22 +240,13 @@ func (p *StandardProvider) Preflight(ctx context.Context, account model.Account,
 		itemName = strings.TrimSpace(target.Name)
 	}
 
+	deviceSource, orderSource := p.tradeSources(target)
 	payload := tradeRenderOrderRequest{
-		DeviceSource: tradeDeviceSourceWXAPP,
-		OrderSource:  tradeOrderSourceProduct,
-		BuyConfig:    tradeBuyConfig{LineGrouped: true, MultipleCoupon: true},
-		ItemName:     itemName,
-		OrderLineList: []tradeRenderOrderLine{
-			{
-				SKUID:        target.SKUID,
-				ItemID:       target.ItemID,
-				Quantity:     qty,
-				PromotionTag: nil,
-				ActivityID:   nil,
-				Extra:        map[string]any{},
-				ShopID:       target.ShopID,
-			},
-		},
+		DeviceSource:  deviceSource,
+		OrderSource:   orderSource,
+		BuyConfig:     tradeBuyConfig{LineGrouped: true, MultipleCoupon: true},
+		ItemName:      itemName,
+		OrderLineList: renderOrderLines(target),
 		DivisionIDs:   strings.TrimSpace(updated.DivisionIDs),
 		AddressID:     addrPtr,
 		CouponParams:  []any{},
@@ -195,15 +260,20 @@ func (p *StandardProvider) Preflight(ctx context.Context, account model.Account,
 		DevicesID: devicesID,
 	}
 
+	if err := p.breaker.Allow("render-order"); err != nil {
+		return provider.PreflightResult{}, model.Account{}, err
+	}
 	var env apiEnvelope[json.RawMessage]
 	resp, err := client.R().
 		SetContext(ctx).
 		SetBody(payload).
 		SetResult(&env).
 		Post("/api/trade/buy/render-order")
+	p.breaker.Record("render-order", upstreamFailure(ctx, resp, err))
 	if err != nil {
 		return provider.PreflightResult{}, model.Account{}, err
 	}
+	p.capture("render-order", account, target, resp)
 	if resp.StatusCode() >= 400 {
 		msg := httpErrorSummary(resp)
 		p.logUpstreamFailure("render-order", resp, msg, map[string]any{
@@ -220,26 +290,40 @@ func (p *StandardProvider) Preflight(ctx context.Context, account model.Account,
 		if msg == "" {
 			msg = "render-order failed"
 		}
+		bizFields, bizErr := p.bizFailure("render-order", env.Code, msg)
 		p.logUpstreamFailure("render-order", resp, msg, map[string]any{
 			"accountId": account.ID,
 			"targetId":  target.ID,
+			"bizCode":   bizFields["bizCode"],
+			"bizClass":  bizFields["bizClass"],
 		})
-		return provider.PreflightResult{}, model.Account{}, fmt.Errorf("render-order failed: %s", msg)
+		return provider.PreflightResult{}, model.Account{}, bizErr
 	}
 
 	canBuy, totalFee := parseRenderCanBuyAndTotalFee(env.Data)
 	needCaptcha := parseRenderNeedCaptcha(env.Data)
+	inStock, stockKnown := parseRenderStock(env.Data, target.SKUID)
+	p.rememberVerifyToken(account.ID, env.Data)
+	_, tokenOK := p.verifyTokenFor(account.ID)
 
 	updated.Cookies = p.exportCookies(jar)
 	return provider.PreflightResult{
-		CanBuy:      canBuy,
-		NeedCaptcha: needCaptcha,
-		TotalFee:    totalFee,
-		Render:      env.Data,
+		CanBuy:               canBuy,
+		NeedCaptcha:          needCaptcha,
+		TotalFee:             totalFee,
+		Render:               env.Data,
+		AccountID:            account.ID,
+		VerifyTokenAvailable: tokenOK,
+		InStock:              inStock,
+		StockKnown:           stockKnown,
 	}, updated, nil
 }
 
-func (p *StandardProvider) CreateOrder(ctx context.Context, account model.Account, target model.Target, preflight provider.PreflightResult) (provider.CreateResult, model.Account, error) {
+func (p *StandardProvider) CreateOrder(ctx context.Context, attempt *provider.AttemptContext) (provider.CreateResult, model.Account, error) {
+	if attempt == nil {
+		return provider.CreateResult{}, model.Account{}, errors.New("attempt context is required")
+	}
+	account, target, preflight := attempt.Account(), attempt.Target(), attempt.Preflight()
 	client, jar, err := p.newClient(account)
 	if err != nil {
 		return provider.CreateResult{}, model.Account{}, err
@@ -248,35 +332,54 @@ func (p *StandardProvider) CreateOrder(ctx context.Context, account model.Accoun
 		return provider.CreateResult{}, model.Account{}, errors.New("missing render data from preflight")
 	}
 
-	captchaVerifyParam := strings.TrimSpace(target.CaptchaVerifyParam)
+	captchaVerifyParam := attempt.CaptchaVerifyParam()
+	verifyTokenValue := ""
 	if preflight.NeedCaptcha {
 		if captchaVerifyParam == "" {
-			return provider.CreateResult{}, model.Account{}, errors.New("missing captchaVerifyParam for captcha-required order")
+			tok, ok := p.verifyTokenFor(account.ID)
+			if !ok {
+				return provider.CreateResult{}, model.Account{}, errors.New("missing captchaVerifyParam for captcha-required order")
+			}
+			verifyTokenValue = tok
 		}
 	} else {
 		captchaVerifyParam = ""
 	}
 
-	payload, err := buildTradeCreateOrderPayloadFromRender(preflight.Render, strings.TrimSpace(target.Name), strings.TrimSpace(account.DeviceID), captchaVerifyParam)
+	deviceSource, orderSource := p.tradeSources(target)
+	payload, err := buildTradeCreateOrderPayloadFromRender(preflight.Render, strings.TrimSpace(target.Name), strings.TrimSpace(account.DeviceID), captchaVerifyParam, deviceSource, orderSource)
 	if err != nil {
 		return provider.CreateResult{}, model.Account{}, err
 	}
+	if verifyTokenValue != "" {
+		if extra, ok := payload["extra"].(map[string]any); ok {
+			extra[p.cfg.VerifyToken.Field()] = verifyTokenValue
+		}
+	}
 
+	if err := p.breaker.Allow("create-order"); err != nil {
+		return provider.CreateResult{}, model.Account{}, err
+	}
 	var env apiEnvelope[json.RawMessage]
 	resp, err := client.R().
 		SetContext(ctx).
 		SetBody(payload).
 		SetResult(&env).
 		Post("/api/trade/buy/create-order")
+	p.breaker.Record("create-order", upstreamFailure(ctx, resp, err))
 	if err != nil {
 		return provider.CreateResult{}, model.Account{}, err
 	}
+	p.capture("create-order", account, target, resp)
 	if resp.StatusCode() >= 400 {
 		msg := httpErrorSummary(resp)
 		p.logUpstreamFailure("create-order", resp, msg, map[string]any{
 			"accountId": account.ID,
 			"targetId":  target.ID,
 		})
+		if verifyTokenValue != "" {
+			p.forgetVerifyToken(account.ID)
+		}
 		return provider.CreateResult{}, model.Account{}, fmt.Errorf("create-order status %d: %s", resp.StatusCode(), msg)
 	}
 	if !env.Success {
@@ -287,22 +390,33 @@ func (p *StandardProvider) CreateOrder(ctx context.Context, account model.Accoun
 		if msg == "" {
 			msg = "create-order failed"
 		}
+		bizFields, bizErr := p.bizFailure("create-order", env.Code, msg)
 		p.logUpstreamFailure("create-order", resp, msg, map[string]any{
 			"accountId": account.ID,
 			"targetId":  target.ID,
+			"bizCode":   bizFields["bizCode"],
+			"bizClass":  bizFields["bizClass"],
 		})
-		return provider.CreateResult{}, model.Account{}, fmt.Errorf("create-order failed: %s", msg)
+		if verifyTokenValue != "" {
+			p.forgetVerifyToken(account.ID)
+		}
+		return provider.CreateResult{}, model.Account{}, bizErr
 	}
+	p.rememberVerifyToken(account.ID, env.Data)
 
 	orderID, traceID := extractCreateOrderIDs(env.Data)
 
 	updated := account
 	updated.Cookies = p.exportCookies(jar)
 
+	totalFee, _ := extractCreateOrderFee(env.Data)
+
 	return provider.CreateResult{
-		Success: true,
-		OrderID: orderID,
-		TraceID: traceID,
+		Success:         true,
+		OrderID:         orderID,
+		TraceID:         traceID,
+		TotalFee:        totalFee,
+		UsedVerifyToken: verifyTokenValue != "",
 	}, updated, nil
 }
 
@@ -350,6 +464,13 @@ func (p *StandardProvider) GetCategoryTree(ctx context.Context, account model.Ac
 	if err != nil {
 		return nil, model.Account{}, err
 	}
+	if params.Longitude == 0 && params.Latitude == 0 {
+		geo, err := p.accountGeo(ctx, client, account)
+		if err != nil {
+			return nil, model.Account{}, err
+		}
+		params.Longitude, params.Latitude = geo.Longitude, geo.Latitude
+	}
 
 	var resp apiEnvelope[json.RawMessage]
 	_, err = client.R().
@@ -386,6 +507,13 @@ func (p *StandardProvider) GetStoreSkuByCategory(ctx context.Context, account mo
 	if err != nil {
 		return nil, model.Account{}, err
 	}
+	if params.Longitude == 0 && params.Latitude == 0 {
+		geo, err := p.accountGeo(ctx, client, account)
+		if err != nil {
+			return nil, model.Account{}, err
+		}
+		params.Longitude, params.Latitude = geo.Longitude, geo.Latitude
+	}
 
 	pageNo := params.PageNo
 	if pageNo <= 0 {
@@ -428,8 +556,175 @@ func (p *StandardProvider) GetStoreSkuByCategory(ctx context.Context, account mo
 	return resp.Data, updated, nil
 }
 
-func (p *StandardProvider) newClient(account model.Account) (*resty.Client, *cookiejar.Jar, error) {
-	jar, err := cookiejar.New(nil)
+func (p *StandardProvider) ProbeStock(ctx context.Context, account model.Account, target model.Target) (provider.ProbeResult, model.Account, error) {
+	if target.CategoryID <= 0 || target.SKUID <= 0 {
+		return provider.ProbeResult{}, account, nil
+	}
+
+	client, jar, err := p.newClient(account)
+	if err != nil {
+		return provider.ProbeResult{}, model.Account{}, err
+	}
+
+	geo, err := p.accountGeo(ctx, client, account)
+	if err != nil {
+		return provider.ProbeResult{}, model.Account{}, err
+	}
+
+	var resp apiEnvelope[json.RawMessage]
+	httpResp, err := client.R().
+		SetContext(ctx).
+		SetQueryParams(map[string]string{
+			"pageNo":          "1",
+			"pageSize":        "500",
+			"frontCategoryId": strconv.FormatInt(target.CategoryID, 10),
+			"longitude":       strconv.FormatFloat(geo.Longitude, 'f', -1, 64),
+			"latitude":        strconv.FormatFloat(geo.Latitude, 'f', -1, 64),
+			"isFinish":        "true",
+		}).
+		SetResult(&resp).
+		Get("/api/item/store/item/searchStoreSkuByCategory")
+	if err != nil {
+		return provider.ProbeResult{}, model.Account{}, err
+	}
+	if httpResp.StatusCode() >= 400 {
+		return provider.ProbeResult{}, model.Account{}, fmt.Errorf("probe status %d: %s", httpResp.StatusCode(), httpErrorSummary(httpResp))
+	}
+	if !resp.Success {
+		msg := resp.Error
+		if msg == "" {
+			msg = resp.Message
+		}
+		if msg == "" {
+			msg = "probe stock failed"
+		}
+		return provider.ProbeResult{}, model.Account{}, errors.New(msg)
+	}
+
+	updated := account
+	updated.Cookies = p.exportCookies(jar)
+	return findSkuStock(resp.Data, target.SKUID), updated, nil
+}
+
+func (p *StandardProvider) RefreshSession(ctx context.Context, account model.Account) (model.Account, error) {
+	client, jar, err := p.newClient(account)
+	if err != nil {
+		return model.Account{}, err
+	}
+	if _, err := fetchPickedShippingAddress(ctx, client, account.AddressID); err != nil {
+		return model.Account{}, err
+	}
+	updated := account
+	updated.Cookies = p.exportCookies(jar)
+	return updated, nil
+}
+
+// accountGeo 返回账号下单地址的经纬度：已选择下单地址时取该地址，否则取上游选中/默认地址。
+// 缓存按账号与地址 ID 区分，选择地址时由 ForgetAccountAddress 清除。
+func (p *StandardProvider) accountGeo(ctx context.Context, client *resty.Client, account model.Account) (geoPoint, error) {
+	key := geoKey(account.ID, account.AddressID)
+	p.geoMu.Lock()
+	cached, ok := p.geo[key]
+	p.geoMu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	pick, err := fetchPickedShippingAddress(ctx, client, account.AddressID)
+	if err != nil {
+		return geoPoint{}, err
+	}
+	lng, okLng := toFloat64(pick["longitude"])
+	lat, okLat := toFloat64(pick["latitude"])
+	if !okLng || !okLat {
+		return geoPoint{}, errors.New("shipping address has no location")
+	}
+
+	point := geoPoint{Longitude: lng, Latitude: lat}
+	if account.ID != "" {
+		p.geoMu.Lock()
+		p.geo[key] = point
+		p.geoMu.Unlock()
+	}
+	return point, nil
+}
+
+func geoKey(accountID string, addressID int64) string {
+	return accountID + "|" + strconv.FormatInt(addressID, 10)
+}
+
+// ForgetAccountAddress 清除账号缓存的收货地址位置，账号更换下单地址后调用。
+func (p *StandardProvider) ForgetAccountAddress(accountID string) {
+	if p == nil {
+		return
+	}
+	prefix := accountID + "|"
+	p.geoMu.Lock()
+	for k := range p.geo {
+		if strings.HasPrefix(k, prefix) {
+			delete(p.geo, k)
+		}
+	}
+	p.geoMu.Unlock()
+}
+
+func findSkuStock(data json.RawMessage, skuID int64) provider.ProbeResult {
+	var groups []map[string]any
+	if err := decodeUseNumber(data, &groups); err != nil {
+		return provider.ProbeResult{}
+	}
+	for _, g := range groups {
+		list, ok := asSlice(g["storeSkuModelList"])
+		if !ok {
+			continue
+		}
+		for _, raw := range list {
+			sku, ok := asMap(raw)
+			if !ok {
+				continue
+			}
+			if id, ok := toInt64(sku["skuId"]); !ok || id != skuID {
+				continue
+			}
+			saleStartMs := parseSaleStartMs(sku)
+			stock, ok := toInt64(sku["inStock"])
+			if !ok {
+				return provider.ProbeResult{SaleStartMs: saleStartMs}
+			}
+			price, _ := toInt64(sku["price"])
+			return provider.ProbeResult{Known: true, InStock: stock, Price: price, SaleStartMs: saleStartMs}
+		}
+	}
+	return provider.ProbeResult{}
+}
+
+// saleStartKeys 是商品数据里可能出现的开售时间字段（不同活动类型命名不一致）。
+var saleStartKeys = []string{"saleStartTime", "sellStartTime", "startSaleTime", "saleBeginTime", "onSaleTime", "activityStartTime"}
+
+// parseSaleStartMs 从 SKU 数据中取开售时间，兼容秒/毫秒时间戳和 "2006-01-02 15:04:05" 字符串。
+func parseSaleStartMs(sku map[string]any) int64 {
+	for _, key := range saleStartKeys {
+		v, ok := sku[key]
+		if !ok || v == nil {
+			continue
+		}
+		if n, ok := toInt64(v); ok && n > 0 {
+			if n < 1e12 {
+				n *= 1000
+			}
+			return n
+		}
+		if str, ok := v.(string); ok {
+			if t, err := time.ParseInLocation("2006-01-02 15:04:05", strings.TrimSpace(str), time.Local); err == nil {
+				return t.UnixMilli()
+			}
+		}
+	}
+	return 0
+}
+
+func (p *StandardProvider) newClient(account model.Account) (*resty.Client, *utils.CookieJar, error) {
+	jar, err := utils.NewCookieJar()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -482,11 +777,13 @@ func (p *StandardProvider) newClient(account model.Account) (*resty.Client, *coo
 		}
 		return nil
 	})
+	p.installSlowTrace(client, account, proxy)
+	installTrafficMeter(client, proxy)
 
 	return client, jar, nil
 }
 
-func (p *StandardProvider) importCookies(jar *cookiejar.Jar, entries []model.CookieJarEntry) {
+func (p *StandardProvider) importCookies(jar *utils.CookieJar, entries []model.CookieJarEntry) {
 	for _, entry := range entries {
 		u, err := url.Parse(entry.URL)
 		if err != nil {
@@ -496,7 +793,7 @@ func (p *StandardProvider) importCookies(jar *cookiejar.Jar, entries []model.Coo
 	}
 }
 
-func (p *StandardProvider) exportCookies(jar *cookiejar.Jar) []model.CookieJarEntry {
+func (p *StandardProvider) exportCookies(jar *utils.CookieJar) []model.CookieJarEntry {
 	if p.baseURL == nil {
 		return nil
 	}
@@ -516,6 +813,28 @@ func (p *StandardProvider) ensureAccountTradeContext(ctx context.Context, client
 		return account, nil
 	}
 
+	pick, err := fetchPickedShippingAddress(ctx, client, account.AddressID)
+	if err != nil {
+		return model.Account{}, err
+	}
+
+	id, ok := toInt64(pick["id"])
+	if !ok || id <= 0 {
+		return model.Account{}, errors.New("invalid address id")
+	}
+
+	next := account
+	if next.AddressID <= 0 {
+		next.AddressID = id
+	}
+	if strings.TrimSpace(next.DivisionIDs) == "" {
+		next.DivisionIDs = resolveDivisionIDs(pick)
+	}
+	return next, nil
+}
+
+// fetchPickedShippingAddress 返回账号的收货地址：addressID 大于 0 且在列表中时取该地址，否则取上游选中、默认或第一个地址。
+func fetchPickedShippingAddress(ctx context.Context, client *resty.Client, addressID int64) (map[string]any, error) {
 	var env apiEnvelope[json.RawMessage]
 	resp, err := client.R().
 		SetContext(ctx).
@@ -526,10 +845,10 @@ func (p *StandardProvider) ensureAccountTradeContext(ctx context.Context, client
 		SetResult(&env).
 		Get("/api/user/web/shipping-address/self/list-all")
 	if err != nil {
-		return model.Account{}, err
+		return nil, err
 	}
 	if resp.StatusCode() >= 400 {
-		return model.Account{}, fmt.Errorf("shipping-address status %d: %s", resp.StatusCode(), httpErrorSummary(resp))
+		return nil, fmt.Errorf("shipping-address status %d: %s", resp.StatusCode(), httpErrorSummary(resp))
 	}
 	if !env.Success {
 		msg := strings.TrimSpace(env.Error)
@@ -539,15 +858,22 @@ func (p *StandardProvider) ensureAccountTradeContext(ctx context.Context, client
 		if msg == "" {
 			msg = "fetch shipping address failed"
 		}
-		return model.Account{}, errors.New(msg)
+		return nil, errors.New(msg)
 	}
 
 	var list []map[string]any
 	if err := decodeUseNumber(env.Data, &list); err != nil {
-		return model.Account{}, err
+		return nil, err
 	}
 	if len(list) == 0 {
-		return model.Account{}, errors.New("no shipping address")
+		return nil, errors.New("no shipping address")
+	}
+	if addressID > 0 {
+		for _, a := range list {
+			if id, ok := toInt64(a["id"]); ok && id == addressID {
+				return a, nil
+			}
+		}
 	}
 	pick := list[0]
 	for _, a := range list {
@@ -564,20 +890,7 @@ func (p *StandardProvider) ensureAccountTradeContext(ctx context.Context, client
 			}
 		}
 	}
-
-	id, ok := toInt64(pick["id"])
-	if !ok || id <= 0 {
-		return model.Account{}, errors.New("invalid address id")
-	}
-
-	next := account
-	if next.AddressID <= 0 {
-		next.AddressID = id
-	}
-	if strings.TrimSpace(next.DivisionIDs) == "" {
-		next.DivisionIDs = resolveDivisionIDs(pick)
-	}
-	return next, nil
+	return pick, nil
 }
 
 func parseRenderCanBuyAndTotalFee(renderData json.RawMessage) (canBuy bool, totalFee int64) {
@@ -605,6 +918,56 @@ func parseRenderCanBuyAndTotalFee(renderData json.RawMessage) (canBuy bool, tota
 	return canBuy, 0
 }
 
+// renderStockKeys 为 render 订单行里可能表示库存的字段，按优先级排列。
+var renderStockKeys = []string{"inStock", "stock", "stockQuantity"}
+
+// parseRenderStock 在 render 中查找 skuId 匹配的订单行（含嵌套的 sku/skuInfo）并读取库存；找不到时 ok=false。
+func parseRenderStock(renderData json.RawMessage, skuID int64) (stock int64, ok bool) {
+	var m any
+	if err := decodeUseNumber(renderData, &m); err != nil {
+		return 0, false
+	}
+	return findRenderSkuStock(m, skuID, 0)
+}
+
+func findRenderSkuStock(v any, skuID int64, depth int) (int64, bool) {
+	if depth > 8 {
+		return 0, false
+	}
+	if obj, ok := asMap(v); ok {
+		if id, ok := toInt64(obj["skuId"]); ok && id == skuID {
+			for _, key := range renderStockKeys {
+				if n, ok := toInt64(obj[key]); ok {
+					return n, true
+				}
+			}
+			for _, key := range []string{"sku", "skuInfo"} {
+				if sub, ok := asMap(obj[key]); ok {
+					for _, k := range renderStockKeys {
+						if n, ok := toInt64(sub[k]); ok {
+							return n, true
+						}
+					}
+				}
+			}
+		}
+		for _, child := range obj {
+			if n, ok := findRenderSkuStock(child, skuID, depth+1); ok {
+				return n, true
+			}
+		}
+		return 0, false
+	}
+	if list, ok := asSlice(v); ok {
+		for _, child := range list {
+			if n, ok := findRenderSkuStock(child, skuID, depth+1); ok {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
 func parseRenderNeedCaptcha(renderData json.RawMessage) bool {
 	var m map[string]any
 	if err := decodeUseNumber(renderData, &m); err != nil {
@@ -634,20 +997,13 @@ func parseRenderNeedCaptcha(renderData json.RawMessage) bool {
 	return false
 }
 
-func buildTradeCreateOrderPayloadFromRender(renderData json.RawMessage, fallbackItemName string, fallbackDevicesID string, captchaVerifyParam string) (map[string]any, error) {
+// deviceSource/orderSource 与 render-order 时使用的取值保持一致（见 tradeSources）。
+func buildTradeCreateOrderPayloadFromRender(renderData json.RawMessage, fallbackItemName string, fallbackDevicesID string, captchaVerifyParam string, deviceSource string, orderSource string) (map[string]any, error) {
 	var render map[string]any
 	if err := decodeUseNumber(renderData, &render); err != nil {
 		return nil, err
 	}
 
-	deviceSource := tradeDeviceSourceWXAPP
-	orderSource := tradeOrderSourceProduct
-	if extra, ok := asMap(render["extra"]); ok {
-		if v, ok := extra["orderSource"].(string); ok && strings.TrimSpace(v) != "" {
-			orderSource = strings.TrimSpace(v)
-		}
-	}
-
 	addressID := pickRenderAddressID(render)
 	if addressID <= 0 {
 		return nil, errors.New("render-order missing addressId")
@@ -811,6 +1167,38 @@ func extractCreateOrderIDs(createData json.RawMessage) (orderID string, traceID
 	return "", traceID
 }
 
+// extractCreateOrderFee 从 create-order 响应中取订单金额；不同版本的字段位置不一致，逐个尝试。
+func extractCreateOrderFee(createData json.RawMessage) (int64, bool) {
+	var m map[string]any
+	if err := decodeUseNumber(createData, &m); err != nil {
+		return 0, false
+	}
+	if v, ok := pickRenderTotalFee(m); ok && v > 0 {
+		return v, true
+	}
+	if infos, ok := asSlice(m["orderInfos"]); ok && len(infos) > 0 {
+		var sum int64
+		found := false
+		for _, info := range infos {
+			m0, ok := asMap(info)
+			if !ok {
+				continue
+			}
+			for _, key := range []string{"totalFee", "fee", "actualFee"} {
+				if v, ok := toInt64(m0[key]); ok && v > 0 {
+					sum += v
+					found = true
+					break
+				}
+			}
+		}
+		if found {
+			return sum, true
+		}
+	}
+	return 0, false
+}
+
 func resolveDivisionIDs(address map[string]any) string {
 	candidates := []any{
 		address["divisionIds"],
@@ -902,6 +1290,24 @@ func toInt64(v any) (int64, bool) {
 	}
 }
 
+func toFloat64(v any) (float64, bool) {
+	switch t := v.(type) {
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	case float64:
+		return t, true
+	case string:
+		if strings.TrimSpace(t) == "" {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
 func isTruthy(v any) bool {
 	switch t := v.(type) {
 	case bool:
@@ -0,0 +1,47 @@
+package standard
+
+import (
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+
+	"sniping_engine/internal/provider"
+)
+
+// installTrafficMeter 在走代理的客户端上把每次请求（含重试）的字节数计入 ctx 上的 provider.TrafficMeter。
+func installTrafficMeter(client *resty.Client, proxy string) {
+	if proxy == "" {
+		return
+	}
+	client.OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+		req := resp.Request
+		if req == nil {
+			return nil
+		}
+		m := provider.TrafficMeterFromContext(req.Context())
+		if m == nil {
+			return nil
+		}
+		var sent int64
+		if raw := req.RawRequest; raw != nil {
+			sent = int64(len(raw.Method)+len(raw.URL.RequestURI())+len(raw.Host)+16) + headerBytes(raw.Header)
+			if raw.ContentLength > 0 {
+				sent += raw.ContentLength
+			}
+		}
+		received := int64(len(resp.Status())+12) + headerBytes(resp.Header()) + resp.Size()
+		m.Add(sent, received)
+		return nil
+	})
+}
+
+// headerBytes 估算头部在报文中的字节数（"Key: value\r\n"）。
+func headerBytes(h http.Header) int64 {
+	var n int64
+	for k, vs := range h {
+		for _, v := range vs {
+			n += int64(len(k) + len(v) + 4)
+		}
+	}
+	return n
+}
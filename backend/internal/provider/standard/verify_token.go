@@ -0,0 +1,97 @@
+package standard
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// verifyToken 是上游下发的“一键验证”免滑块凭证，按账号缓存。
+type verifyToken struct {
+	Value       string
+	ExpiresAtMs int64
+}
+
+// rememberVerifyToken 从 render-order/create-order 响应中识别免滑块凭证并缓存到账号上。
+func (p *StandardProvider) rememberVerifyToken(accountID string, data json.RawMessage) {
+	cfg := p.cfg.VerifyToken
+	if cfg.Disabled || accountID == "" {
+		return
+	}
+	value, expiresAtMs := extractVerifyToken(data, cfg.Keys())
+	if value == "" {
+		return
+	}
+	if expiresAtMs <= 0 {
+		expiresAtMs = time.Now().Add(cfg.TTL()).UnixMilli()
+	}
+
+	p.tokenMu.Lock()
+	prev := p.verifyTokens[accountID]
+	p.verifyTokens[accountID] = verifyToken{Value: value, ExpiresAtMs: expiresAtMs}
+	p.tokenMu.Unlock()
+
+	if prev.Value != value && p.bus != nil {
+		p.bus.Log("debug", "识别到免滑块凭证", map[string]any{
+			"accountId":   accountID,
+			"expiresAtMs": expiresAtMs,
+		})
+	}
+}
+
+// verifyTokenFor 返回账号当前可用的免滑块凭证；过期的凭证会被顺手清掉。
+func (p *StandardProvider) verifyTokenFor(accountID string) (string, bool) {
+	if p.cfg.VerifyToken.Disabled || accountID == "" {
+		return "", false
+	}
+	p.tokenMu.Lock()
+	defer p.tokenMu.Unlock()
+	tok, ok := p.verifyTokens[accountID]
+	if !ok {
+		return "", false
+	}
+	if time.Now().UnixMilli() >= tok.ExpiresAtMs {
+		delete(p.verifyTokens, accountID)
+		return "", false
+	}
+	return tok.Value, true
+}
+
+// forgetVerifyToken 在凭证下单失败后丢弃，下次改走滑块求解。
+func (p *StandardProvider) forgetVerifyToken(accountID string) {
+	p.tokenMu.Lock()
+	delete(p.verifyTokens, accountID)
+	p.tokenMu.Unlock()
+}
+
+// extractVerifyToken 在响应顶层及 extra 中查找凭证；过期时间取同级的 <key>ExpireTime / expireTime（秒或毫秒）。
+func extractVerifyToken(data json.RawMessage, keys []string) (string, int64) {
+	var m map[string]any
+	if err := decodeUseNumber(data, &m); err != nil {
+		return "", 0
+	}
+	scopes := []map[string]any{m}
+	if extra, ok := asMap(m["extra"]); ok {
+		scopes = append(scopes, extra)
+	}
+	for _, scope := range scopes {
+		for _, key := range keys {
+			v, ok := scope[key].(string)
+			if !ok || strings.TrimSpace(v) == "" {
+				continue
+			}
+			var expiresAtMs int64
+			for _, ek := range []string{key + "ExpireTime", key + "ExpireAt", "expireTime"} {
+				if n, ok := toInt64(scope[ek]); ok && n > 0 {
+					if n < 1e12 {
+						n *= 1000
+					}
+					expiresAtMs = n
+					break
+				}
+			}
+			return strings.TrimSpace(v), expiresAtMs
+		}
+	}
+	return "", 0
+}
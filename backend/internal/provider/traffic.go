@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// TrafficMeter 统计一次尝试经代理发出的请求与收到的响应字节数（按请求行、头部与报文体估算，不含 TLS 开销），
+// 用于按流量计费的代理估算花费；直连请求不计入。并发安全，nil 时不统计。
+type TrafficMeter struct {
+	sent     atomic.Int64
+	received atomic.Int64
+}
+
+// Add 累加一次请求的发送与接收字节数。
+func (m *TrafficMeter) Add(sent, received int64) {
+	if m == nil {
+		return
+	}
+	m.sent.Add(sent)
+	m.received.Add(received)
+}
+
+// Bytes 返回累计的发送与接收字节数之和。
+func (m *TrafficMeter) Bytes() int64 {
+	if m == nil {
+		return 0
+	}
+	return m.sent.Load() + m.received.Load()
+}
+
+type trafficMeterKey struct{}
+
+// WithTrafficMeter 让 ctx 下发出的上游请求计入 m。
+func WithTrafficMeter(ctx context.Context, m *TrafficMeter) context.Context {
+	return context.WithValue(ctx, trafficMeterKey{}, m)
+}
+
+// TrafficMeterFromContext 返回 ctx 上的流量统计，未设置时为 nil。
+func TrafficMeterFromContext(ctx context.Context) *TrafficMeter {
+	if ctx == nil {
+		return nil
+	}
+	m, _ := ctx.Value(trafficMeterKey{}).(*TrafficMeter)
+	return m
+}
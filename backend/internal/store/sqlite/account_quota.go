@@ -0,0 +1,36 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// AddAccountDailyPurchase 累加账号在某一天（day 形如 2006-01-02）成功下单的件数与订单数。
+func (s *Store) AddAccountDailyPurchase(ctx context.Context, accountID, day string, qty int) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO account_daily_purchases (account_id, day, qty, orders, updated_at)
+		VALUES (?, ?, ?, 1, ?)
+		ON CONFLICT(account_id, day) DO UPDATE SET
+			qty = qty + excluded.qty,
+			orders = orders + 1,
+			updated_at = excluded.updated_at
+	`, accountID, day, qty, time.Now().UnixMilli())
+	return err
+}
+
+// GetAccountDailyPurchase 返回账号某一天已成功下单的件数；没有记录时为 0。
+func (s *Store) GetAccountDailyPurchase(ctx context.Context, accountID, day string) (int, error) {
+	var qty int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT qty FROM account_daily_purchases WHERE account_id = ? AND day = ?
+	`, accountID, day).Scan(&qty)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return qty, nil
+}
@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"sniping_engine/internal/model"
+)
+
+// tokenLifetimeSamples 估算 token 寿命时最多参考的最近观测数。
+const tokenLifetimeSamples = 20
+
+// NoteTokenIssued 记录账号当前 token 的首次出现时间；token 未变化时不更新，变化时重置过期观测。
+func (s *Store) NoteTokenIssued(ctx context.Context, accountID, token string, atMs int64) error {
+	hash := tokenHash(token)
+	if accountID == "" || hash == "" {
+		return nil
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO account_tokens (account_id, token_hash, issued_at_ms, expired_at_ms, expected_expiry_ms)
+		VALUES (?, ?, ?, 0, 0)
+		ON CONFLICT(account_id) DO UPDATE SET
+			token_hash = excluded.token_hash,
+			issued_at_ms = excluded.issued_at_ms,
+			expired_at_ms = 0,
+			expected_expiry_ms = 0
+		WHERE account_tokens.token_hash <> excluded.token_hash
+	`, accountID, hash, atMs)
+	return err
+}
+
+// RecordTokenExpired 记录当前 token 签发后首次被上游拒绝的时间，并把这次寿命加入观测；
+// 同一个 token 只记录第一次，返回是否新增了观测。
+func (s *Store) RecordTokenExpired(ctx context.Context, accountID string, atMs int64) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var issuedAtMs int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT issued_at_ms FROM account_tokens WHERE account_id = ? AND expired_at_ms = 0
+	`, accountID).Scan(&issuedAtMs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if issuedAtMs <= 0 || atMs <= issuedAtMs {
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE account_tokens SET expired_at_ms = ? WHERE account_id = ?`, atMs, accountID); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO token_lifetimes (account_id, issued_at_ms, expired_at_ms) VALUES (?, ?, ?)
+	`, accountID, issuedAtMs, atMs); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// ListTokenLifetimes 返回最近观测到的 token 寿命（毫秒，最新在前）。
+func (s *Store) ListTokenLifetimes(ctx context.Context) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT expired_at_ms - issued_at_ms FROM token_lifetimes ORDER BY id DESC LIMIT ?
+	`, tokenLifetimeSamples)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []int64
+	for rows.Next() {
+		var ms int64
+		if err := rows.Scan(&ms); err != nil {
+			return nil, err
+		}
+		out = append(out, ms)
+	}
+	return out, rows.Err()
+}
+
+// ListAccountTokens 返回各账号当前 token 的签发与过期记录（不含 AtRisk/LifetimeMs）。
+func (s *Store) ListAccountTokens(ctx context.Context) ([]model.TokenExpiry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT account_id, issued_at_ms, expired_at_ms, expected_expiry_ms FROM account_tokens
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.TokenExpiry
+	for rows.Next() {
+		var t model.TokenExpiry
+		if err := rows.Scan(&t.AccountID, &t.IssuedAtMs, &t.ExpiredAtMs, &t.ExpectedExpiryMs); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// SetTokenExpectedExpiry 保存账号当前 token 的预计过期时间。
+func (s *Store) SetTokenExpectedExpiry(ctx context.Context, accountID string, expectedMs int64) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE account_tokens SET expected_expiry_ms = ? WHERE account_id = ?`, expectedMs, accountID)
+	return err
+}
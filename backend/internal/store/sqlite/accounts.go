@@ -2,37 +2,256 @@ package sqlite
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/google/uuid"
 
+	"sniping_engine/internal/credentials"
 	"sniping_engine/internal/model"
 )
 
+// ErrCredentialSourceMismatch 账号表里的 credential_ref 指向的来源与当前配置的凭据来源不一致。
+// 此时读不到账号的真实凭据，继续运行会把空凭据写回、丢失原有 token/cookie。
+var ErrCredentialSourceMismatch = errors.New("account credentials are stored in another source")
+
+const accountColumns = `id, username, mobile, token, user_agent, device_id, uuid, proxy, proxy_id, address_id, division_ids, cookies_json, credential_ref, env, validated_at_ms, validation_ok, validation_error, created_at, updated_at`
+
+// SetCredentialSource 配置外部凭据来源；nil 表示 token/cookie 直接保存在账号表。
+// 使用外部来源时，账号表只保存 credential_ref 和 token 的哈希（用于按 token 查账号）。
+func (s *Store) SetCredentialSource(src credentials.Source) {
+	s.creds = src
+	s.credsMu.Lock()
+	s.credsCache = nil
+	s.credsMu.Unlock()
+}
+
+// credentialsCacheTTL 外部凭据在进程内缓存的时长：过期后重新读取，Vault 等来源里轮换的凭据最迟这么久后生效。
+const credentialsCacheTTL = 30 * time.Second
+
+type cachedCreds struct {
+	c         credentials.Credentials
+	expiresAt time.Time
+}
+
+// cachedCredentials 返回进程内缓存且未过期的账号凭据，避免每次读取账号都访问外部来源。
+func (s *Store) cachedCredentials(accountID string) (credentials.Credentials, bool) {
+	s.credsMu.Lock()
+	defer s.credsMu.Unlock()
+	entry, ok := s.credsCache[accountID]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return credentials.Credentials{}, false
+	}
+	return entry.c, true
+}
+
+func (s *Store) cacheCredentials(accountID string, c credentials.Credentials) {
+	ttl := s.credsTTL
+	if ttl <= 0 {
+		ttl = credentialsCacheTTL
+	}
+	s.credsMu.Lock()
+	if s.credsCache == nil {
+		s.credsCache = make(map[string]cachedCreds)
+	}
+	s.credsCache[accountID] = cachedCreds{c: c, expiresAt: time.Now().Add(ttl)}
+	s.credsMu.Unlock()
+}
+
+func (s *Store) forgetCredentials(accountID string) {
+	s.credsMu.Lock()
+	delete(s.credsCache, accountID)
+	s.credsMu.Unlock()
+}
+
+// saveCredentials 只在 token/cookie 与未过期的缓存不同时写入外部来源（预下单等操作每次都会保存账号）。
+func (s *Store) saveCredentials(ctx context.Context, accountID string, c credentials.Credentials) error {
+	if cached, ok := s.cachedCredentials(accountID); ok && cached.Token == c.Token && reflect.DeepEqual(cached.Cookies, c.Cookies) {
+		return nil
+	}
+	if err := s.creds.Save(ctx, accountID, c); err != nil {
+		return err
+	}
+	s.cacheCredentials(accountID, c)
+	return nil
+}
+
+// checkCredentialRef 确认账号保存的凭据引用属于当前配置的来源；ref 为空表示凭据仍在账号表里。
+func (s *Store) checkCredentialRef(accountID, ref string) error {
+	if ref == "" {
+		return nil
+	}
+	if s.creds == nil {
+		return fmt.Errorf("%w: account %s uses %s, credentials.source is %s", ErrCredentialSourceMismatch, accountID, ref, credentials.SourceSQLite)
+	}
+	if want := s.creds.Ref(accountID); ref != want {
+		return fmt.Errorf("%w: account %s uses %s, credentials.source expects %s", ErrCredentialSourceMismatch, accountID, ref, want)
+	}
+	return nil
+}
+
+func tokenHash(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func scanAccount(sc rowScanner) (model.Account, error) {
+	var row struct {
+		id            string
+		username      string
+		mobile        string
+		token         string
+		userAgent     string
+		deviceID      string
+		uuid          string
+		proxy         string
+		proxyID       string
+		addressID     int64
+		divisionIDs   string
+		cookies       string
+		credentialRef string
+		env           string
+		validatedAtMs int64
+		validationOK  bool
+		validationErr string
+		createdAt     int64
+		updatedAt     int64
+	}
+	if err := sc.Scan(&row.id, &row.username, &row.mobile, &row.token, &row.userAgent, &row.deviceID, &row.uuid, &row.proxy, &row.proxyID, &row.addressID, &row.divisionIDs, &row.cookies, &row.credentialRef, &row.env, &row.validatedAtMs, &row.validationOK, &row.validationErr, &row.createdAt, &row.updatedAt); err != nil {
+		return model.Account{}, err
+	}
+	var cookies []model.CookieJarEntry
+	_ = json.Unmarshal([]byte(row.cookies), &cookies)
+	var valid *bool
+	if row.validatedAtMs > 0 {
+		valid = &row.validationOK
+	}
+	return model.Account{
+		ID:            row.id,
+		Username:      row.username,
+		Mobile:        row.mobile,
+		Token:         row.token,
+		UserAgent:     row.userAgent,
+		DeviceID:      row.deviceID,
+		UUID:          row.uuid,
+		Proxy:         row.proxy,
+		ProxyID:       row.proxyID,
+		AddressID:     row.addressID,
+		DivisionIDs:   row.divisionIDs,
+		Cookies:       cookies,
+		CredentialRef: row.credentialRef,
+		Env:           row.env,
+		ValidatedAtMs: row.validatedAtMs,
+		Valid:         valid,
+		ValidationErr: row.validationErr,
+		CreatedAt:     model.TimestampMs(row.createdAt),
+		UpdatedAt:     model.TimestampMs(row.updatedAt),
+	}, nil
+}
+
+// resolveCredentials 对保存为引用的账号，到外部来源读取 token/cookie。
+func (s *Store) resolveCredentials(ctx context.Context, acc model.Account) (model.Account, error) {
+	if acc.CredentialRef == "" {
+		return acc, nil
+	}
+	if err := s.checkCredentialRef(acc.ID, acc.CredentialRef); err != nil {
+		return model.Account{}, err
+	}
+	c, ok := s.cachedCredentials(acc.ID)
+	if !ok {
+		var err error
+		c, ok, err = s.creds.Load(ctx, acc.ID)
+		if err != nil {
+			return model.Account{}, fmt.Errorf("load credentials for account %s: %w", acc.ID, err)
+		}
+		if ok {
+			s.cacheCredentials(acc.ID, c)
+		}
+	}
+	if ok {
+		acc.Token = c.Token
+		acc.Cookies = c.Cookies
+	}
+	return acc, nil
+}
+
+func (s *Store) getAccount(ctx context.Context, query string, args ...any) (model.Account, error) {
+	acc, err := scanAccount(s.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		return model.Account{}, err
+	}
+	if acc, err = s.resolveProxy(ctx, acc); err != nil {
+		return model.Account{}, err
+	}
+	return s.resolveCredentials(ctx, acc)
+}
+
 func (s *Store) UpsertAccount(ctx context.Context, acc model.Account) (model.Account, error) {
-	if acc.Mobile == "" {
-		return model.Account{}, errors.New("mobile is required")
+	mobile, err := s.accountMobileKey(ctx, acc.Mobile)
+	if err != nil {
+		return model.Account{}, err
+	}
+	acc.Mobile = mobile
+	if acc.Env, err = model.NormalizeEnv(acc.Env); err != nil {
+		return model.Account{}, err
+	}
+	// 以规范化后的手机号为唯一键：已存在时沿用原 ID，保证外部凭据的 key 稳定。
+	var existingID, existingRef string
+	if err := s.db.QueryRowContext(ctx, `SELECT id, credential_ref FROM accounts WHERE mobile = ?`, acc.Mobile).Scan(&existingID, &existingRef); err == nil {
+		if acc.ID != "" && acc.ID != existingID {
+			return model.Account{}, fmt.Errorf("%w: %s (account %s)", ErrDuplicateMobile, acc.Mobile, existingID)
+		}
+		acc.ID = existingID
+		if err := s.checkCredentialRef(existingID, existingRef); err != nil {
+			return model.Account{}, err
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return model.Account{}, err
 	}
 	if acc.ID == "" {
 		acc.ID = uuid.NewString()
 	}
 	now := time.Now()
 	if acc.CreatedAt.IsZero() {
-		acc.CreatedAt = now
+		acc.CreatedAt = model.Timestamp{Time: now}
 	}
-	acc.UpdatedAt = now
+	acc.UpdatedAt = model.Timestamp{Time: now}
 
-	cookiesJSON, err := json.Marshal(acc.Cookies)
+	token, cookies, ref, hash := acc.Token, acc.Cookies, "", ""
+	if s.creds != nil && existingRef != "" && acc.Token == "" && len(acc.Cookies) == 0 {
+		// 不用空凭据覆盖外部来源里已有的凭据；确实要清空时先调用 DeleteAccountCredentials。
+		c, ok, err := s.creds.Load(ctx, acc.ID)
+		if err != nil {
+			return model.Account{}, fmt.Errorf("load credentials for account %s: %w", acc.ID, err)
+		}
+		if ok {
+			acc.Token, acc.Cookies = c.Token, c.Cookies
+		}
+	}
+	if s.creds != nil {
+		if err := s.saveCredentials(ctx, acc.ID, credentials.Credentials{Token: acc.Token, Cookies: acc.Cookies}); err != nil {
+			return model.Account{}, fmt.Errorf("save credentials: %w", err)
+		}
+		token, cookies, ref, hash = "", nil, s.creds.Ref(acc.ID), tokenHash(acc.Token)
+	}
+
+	cookiesJSON, err := json.Marshal(cookies)
 	if err != nil {
 		return model.Account{}, err
 	}
 
 	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO accounts (id, username, mobile, token, user_agent, device_id, uuid, proxy, address_id, division_ids, cookies_json, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO accounts (`+accountColumns+`, token_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, 0, '', ?, ?, ?)
 		ON CONFLICT(mobile) DO UPDATE SET
 			username = excluded.username,
 			token = excluded.token,
@@ -40,150 +259,62 @@ func (s *Store) UpsertAccount(ctx context.Context, acc model.Account) (model.Acc
 			device_id = excluded.device_id,
 			uuid = excluded.uuid,
 			proxy = excluded.proxy,
+			proxy_id = excluded.proxy_id,
 			address_id = excluded.address_id,
 			division_ids = excluded.division_ids,
 			cookies_json = excluded.cookies_json,
+			credential_ref = excluded.credential_ref,
+			env = excluded.env,
+			validated_at_ms = CASE WHEN accounts.token = excluded.token AND accounts.token_hash = excluded.token_hash THEN accounts.validated_at_ms ELSE 0 END,
+			validation_ok = CASE WHEN accounts.token = excluded.token AND accounts.token_hash = excluded.token_hash THEN accounts.validation_ok ELSE 0 END,
+			validation_error = CASE WHEN accounts.token = excluded.token AND accounts.token_hash = excluded.token_hash THEN accounts.validation_error ELSE '' END,
+			token_hash = excluded.token_hash,
 			updated_at = excluded.updated_at
-	`, acc.ID, acc.Username, acc.Mobile, acc.Token, acc.UserAgent, acc.DeviceID, acc.UUID, acc.Proxy, acc.AddressID, acc.DivisionIDs, string(cookiesJSON), acc.CreatedAt.UnixMilli(), acc.UpdatedAt.UnixMilli())
+	`, acc.ID, acc.Username, acc.Mobile, token, acc.UserAgent, acc.DeviceID, acc.UUID, acc.Proxy, acc.ProxyID, acc.AddressID, acc.DivisionIDs, string(cookiesJSON), ref, acc.Env, acc.CreatedAt.UnixMilli(), acc.UpdatedAt.UnixMilli(), hash)
 	if err != nil {
 		return model.Account{}, err
 	}
+	if err := s.NoteTokenIssued(ctx, acc.ID, acc.Token, now.UnixMilli()); err != nil {
+		return model.Account{}, err
+	}
 
 	return s.GetAccountByMobile(ctx, acc.Mobile)
 }
 
 func (s *Store) GetAccountByMobile(ctx context.Context, mobile string) (model.Account, error) {
-	var row struct {
-		id        string
-		username  string
-		mobile    string
-		token     string
-		userAgent string
-		deviceID  string
-		uuid      string
-		proxy     string
-		addressID int64
-		divisionIDs string
-		cookies   string
-		createdAt int64
-		updatedAt int64
-	}
-	err := s.db.QueryRowContext(ctx, `
-		SELECT id, username, mobile, token, user_agent, device_id, uuid, proxy, address_id, division_ids, cookies_json, created_at, updated_at
-		FROM accounts WHERE mobile = ?
-	`, mobile).Scan(&row.id, &row.username, &row.mobile, &row.token, &row.userAgent, &row.deviceID, &row.uuid, &row.proxy, &row.addressID, &row.divisionIDs, &row.cookies, &row.createdAt, &row.updatedAt)
-	if err != nil {
-		return model.Account{}, err
+	const q = `SELECT ` + accountColumns + ` FROM accounts WHERE mobile = ?`
+	normalized, err := s.NormalizeMobile(mobile)
+	if err != nil || normalized == mobile {
+		return s.getAccount(ctx, q, mobile)
 	}
-	var cookies []model.CookieJarEntry
-	_ = json.Unmarshal([]byte(row.cookies), &cookies)
-	return model.Account{
-		ID:        row.id,
-		Username:  row.username,
-		Mobile:    row.mobile,
-		Token:     row.token,
-		UserAgent: row.userAgent,
-		DeviceID:  row.deviceID,
-		UUID:      row.uuid,
-		Proxy:     row.proxy,
-		AddressID: row.addressID,
-		DivisionIDs: row.divisionIDs,
-		Cookies:   cookies,
-		CreatedAt: time.UnixMilli(row.createdAt),
-		UpdatedAt: time.UnixMilli(row.updatedAt),
-	}, nil
+	if acc, err := s.getAccount(ctx, q, normalized); err == nil {
+		return acc, nil
+	}
+	// 迁移时因重复或格式无法识别而保留原值的旧记录。
+	return s.getAccount(ctx, q, mobile)
 }
 
 func (s *Store) GetAccount(ctx context.Context, id string) (model.Account, error) {
-	var row struct {
-		id        string
-		username  string
-		mobile    string
-		token     string
-		userAgent string
-		deviceID  string
-		uuid      string
-		proxy     string
-		addressID int64
-		divisionIDs string
-		cookies   string
-		createdAt int64
-		updatedAt int64
-	}
-	err := s.db.QueryRowContext(ctx, `
-		SELECT id, username, mobile, token, user_agent, device_id, uuid, proxy, address_id, division_ids, cookies_json, created_at, updated_at
-		FROM accounts WHERE id = ?
-	`, id).Scan(&row.id, &row.username, &row.mobile, &row.token, &row.userAgent, &row.deviceID, &row.uuid, &row.proxy, &row.addressID, &row.divisionIDs, &row.cookies, &row.createdAt, &row.updatedAt)
-	if err != nil {
-		return model.Account{}, err
-	}
-	var cookies []model.CookieJarEntry
-	_ = json.Unmarshal([]byte(row.cookies), &cookies)
-	return model.Account{
-		ID:        row.id,
-		Username:  row.username,
-		Mobile:    row.mobile,
-		Token:     row.token,
-		UserAgent: row.userAgent,
-		DeviceID:  row.deviceID,
-		UUID:      row.uuid,
-		Proxy:     row.proxy,
-		AddressID: row.addressID,
-		DivisionIDs: row.divisionIDs,
-		Cookies:   cookies,
-		CreatedAt: time.UnixMilli(row.createdAt),
-		UpdatedAt: time.UnixMilli(row.updatedAt),
-	}, nil
+	return s.getAccount(ctx, `SELECT `+accountColumns+` FROM accounts WHERE id = ?`, id)
 }
 
 func (s *Store) GetAccountByToken(ctx context.Context, token string) (model.Account, error) {
 	if token == "" {
 		return model.Account{}, errors.New("token is required")
 	}
-	var row struct {
-		id        string
-		username  string
-		mobile    string
-		token     string
-		userAgent string
-		deviceID  string
-		uuid      string
-		proxy     string
-		addressID int64
-		divisionIDs string
-		cookies   string
-		createdAt int64
-		updatedAt int64
-	}
-	err := s.db.QueryRowContext(ctx, `
-		SELECT id, username, mobile, token, user_agent, device_id, uuid, proxy, address_id, division_ids, cookies_json, created_at, updated_at
-		FROM accounts WHERE token = ? ORDER BY updated_at DESC LIMIT 1
-	`, token).Scan(&row.id, &row.username, &row.mobile, &row.token, &row.userAgent, &row.deviceID, &row.uuid, &row.proxy, &row.addressID, &row.divisionIDs, &row.cookies, &row.createdAt, &row.updatedAt)
+	acc, err := s.getAccount(ctx, `
+		SELECT `+accountColumns+`
+		FROM accounts WHERE token = ? OR token_hash = ? ORDER BY updated_at DESC LIMIT 1
+	`, token, tokenHash(token))
 	if err != nil {
 		return model.Account{}, fmt.Errorf("get account by token: %w", err)
 	}
-	var cookies []model.CookieJarEntry
-	_ = json.Unmarshal([]byte(row.cookies), &cookies)
-	return model.Account{
-		ID:        row.id,
-		Username:  row.username,
-		Mobile:    row.mobile,
-		Token:     row.token,
-		UserAgent: row.userAgent,
-		DeviceID:  row.deviceID,
-		UUID:      row.uuid,
-		Proxy:     row.proxy,
-		AddressID: row.addressID,
-		DivisionIDs: row.divisionIDs,
-		Cookies:   cookies,
-		CreatedAt: time.UnixMilli(row.createdAt),
-		UpdatedAt: time.UnixMilli(row.updatedAt),
-	}, nil
+	return acc, nil
 }
 
 func (s *Store) ListAccounts(ctx context.Context) ([]model.Account, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, username, mobile, token, user_agent, device_id, uuid, proxy, address_id, division_ids, cookies_json, created_at, updated_at
+		SELECT `+accountColumns+`
 		FROM accounts ORDER BY updated_at DESC
 	`)
 	if err != nil {
@@ -193,49 +324,116 @@ func (s *Store) ListAccounts(ctx context.Context) ([]model.Account, error) {
 
 	var out []model.Account
 	for rows.Next() {
-		var row struct {
-			id        string
-			username  string
-			mobile    string
-			token     string
-			userAgent string
-			deviceID  string
-			uuid      string
-			proxy     string
-			addressID int64
-			divisionIDs string
-			cookies   string
-			createdAt int64
-			updatedAt int64
-		}
-		if err := rows.Scan(&row.id, &row.username, &row.mobile, &row.token, &row.userAgent, &row.deviceID, &row.uuid, &row.proxy, &row.addressID, &row.divisionIDs, &row.cookies, &row.createdAt, &row.updatedAt); err != nil {
+		acc, err := scanAccount(rows)
+		if err != nil {
 			return nil, err
 		}
-		var cookies []model.CookieJarEntry
-		_ = json.Unmarshal([]byte(row.cookies), &cookies)
-		out = append(out, model.Account{
-			ID:        row.id,
-			Username:  row.username,
-			Mobile:    row.mobile,
-			Token:     row.token,
-			UserAgent: row.userAgent,
-			DeviceID:  row.deviceID,
-			UUID:      row.uuid,
-			Proxy:     row.proxy,
-			AddressID: row.addressID,
-			DivisionIDs: row.divisionIDs,
-			Cookies:   cookies,
-			CreatedAt: time.UnixMilli(row.createdAt),
-			UpdatedAt: time.UnixMilli(row.updatedAt),
-		})
+		out = append(out, acc)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	// 先关闭游标再访问外部来源，避免网络请求期间一直占着 sqlite 唯一的连接。
+	_ = rows.Close()
+	for i := range out {
+		if out[i], err = s.resolveProxy(ctx, out[i]); err != nil {
+			return nil, err
+		}
+		if out[i], err = s.resolveCredentials(ctx, out[i]); err != nil {
+			return nil, err
+		}
+	}
 	return out, nil
 }
 
 func (s *Store) DeleteAccount(ctx context.Context, id string) error {
-	_, err := s.db.ExecContext(ctx, `DELETE FROM accounts WHERE id = ?`, id)
-	return err
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM accounts WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM account_tokens WHERE account_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM account_daily_purchases WHERE account_id = ?`, id); err != nil {
+		return err
+	}
+	if s.creds != nil {
+		s.forgetCredentials(id)
+		return s.creds.Delete(ctx, id)
+	}
+	return nil
+}
+
+// DeleteAccountCredentials 删除账号在外部来源里的凭据（如重置设备身份时），之后保存的空 token/cookie 才会生效。
+// 凭据直接保存在账号表时无需处理。
+func (s *Store) DeleteAccountCredentials(ctx context.Context, id string) error {
+	if s.creds == nil {
+		return nil
+	}
+	s.forgetCredentials(id)
+	return s.creds.Delete(ctx, id)
+}
+
+// MigrateCredentials 把仍直接保存在账号表里的 token/cookie 迁移到当前配置的外部来源，返回迁移的账号数。
+// 已有账号的凭据引用属于其他来源（切换了 credentials.source）时返回 ErrCredentialSourceMismatch，
+// 启动时据此拒绝运行，而不是把读不到的凭据当作空值写回。
+func (s *Store) MigrateCredentials(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, credential_ref FROM accounts WHERE credential_ref != ''`)
+	if err != nil {
+		return 0, err
+	}
+	var mismatch error
+	for rows.Next() {
+		var id, ref string
+		if err := rows.Scan(&id, &ref); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		if mismatch == nil {
+			mismatch = s.checkCredentialRef(id, ref)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return 0, err
+	}
+	_ = rows.Close()
+	if mismatch != nil {
+		return 0, mismatch
+	}
+	if s.creds == nil {
+		return 0, nil
+	}
+	accounts, err := s.ListAccounts(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, acc := range accounts {
+		if acc.CredentialRef == s.creds.Ref(acc.ID) {
+			continue
+		}
+		if _, err := s.UpsertAccount(ctx, acc); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// SetAccountValidation 保存账号最近一次校验的时间、结果与失败原因（不改动 updated_at）。
+func (s *Store) SetAccountValidation(ctx context.Context, id string, atMs int64, valid bool, errMsg string) error {
+	ok := 0
+	if valid {
+		ok = 1
+	}
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE accounts SET validated_at_ms = ?, validation_ok = ?, validation_error = ? WHERE id = ?
+	`, atMs, ok, errMsg, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
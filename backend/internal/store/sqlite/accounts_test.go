@@ -0,0 +1,171 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"sniping_engine/internal/credentials"
+	"sniping_engine/internal/model"
+)
+
+// fakeSource 是内存里的凭据来源。
+type fakeSource struct {
+	name string
+	mu   sync.Mutex
+	data map[string]credentials.Credentials
+}
+
+func newFakeSource(name string) *fakeSource {
+	return &fakeSource{name: name, data: make(map[string]credentials.Credentials)}
+}
+
+func (f *fakeSource) Name() string { return f.name }
+
+func (f *fakeSource) Ref(accountID string) string { return f.name + ":" + accountID }
+
+func (f *fakeSource) Load(_ context.Context, accountID string) (credentials.Credentials, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.data[accountID]
+	return c, ok, nil
+}
+
+func (f *fakeSource) Save(_ context.Context, accountID string, c credentials.Credentials) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data[accountID] = c
+	return nil
+}
+
+func (f *fakeSource) Delete(_ context.Context, accountID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.data, accountID)
+	return nil
+}
+
+func (f *fakeSource) set(accountID string, c credentials.Credentials) {
+	f.mu.Lock()
+	f.data[accountID] = c
+	f.mu.Unlock()
+}
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	st, err := Open(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	return st
+}
+
+func TestExternalCredentialsRoundTrip(t *testing.T) {
+	st := openTestStore(t)
+	ctx := context.Background()
+	src := newFakeSource("fake")
+	st.SetCredentialSource(src)
+
+	acc, err := st.UpsertAccount(ctx, model.Account{Mobile: "13800000000", Token: "tok"})
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if acc.Token != "tok" || acc.CredentialRef != "fake:"+acc.ID {
+		t.Fatalf("account = %+v", acc)
+	}
+	var rawToken string
+	if err := st.db.QueryRowContext(ctx, `SELECT token FROM accounts WHERE id = ?`, acc.ID).Scan(&rawToken); err != nil || rawToken != "" {
+		t.Fatalf("token column = %q, %v", rawToken, err)
+	}
+	if got, err := st.GetAccountByToken(ctx, "tok"); err != nil || got.ID != acc.ID {
+		t.Fatalf("by token = %+v, %v", got, err)
+	}
+}
+
+func TestUpsertAccountKeepsExternalCredentialsOnEmptySave(t *testing.T) {
+	st := openTestStore(t)
+	ctx := context.Background()
+	src := newFakeSource("fake")
+	st.SetCredentialSource(src)
+
+	acc, err := st.UpsertAccount(ctx, model.Account{Mobile: "13800000000", Token: "tok"})
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	acc.Token, acc.Cookies = "", nil
+	saved, err := st.UpsertAccount(ctx, acc)
+	if err != nil {
+		t.Fatalf("upsert empty: %v", err)
+	}
+	if saved.Token != "tok" || src.data[acc.ID].Token != "tok" {
+		t.Fatalf("empty save overwrote credentials: account=%q source=%+v", saved.Token, src.data[acc.ID])
+	}
+
+	// 明确清空：先删除外部凭据，再保存空值。
+	if err := st.DeleteAccountCredentials(ctx, acc.ID); err != nil {
+		t.Fatalf("delete credentials: %v", err)
+	}
+	if saved, err = st.UpsertAccount(ctx, acc); err != nil || saved.Token != "" {
+		t.Fatalf("cleared account = %+v, %v", saved, err)
+	}
+}
+
+func TestCredentialSourceMismatchRefusesToLoad(t *testing.T) {
+	st := openTestStore(t)
+	ctx := context.Background()
+	st.SetCredentialSource(newFakeSource("file"))
+	acc, err := st.UpsertAccount(ctx, model.Account{Mobile: "13800000000", Token: "tok"})
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	for name, src := range map[string]credentials.Source{"sqlite": nil, "vault": newFakeSource("vault")} {
+		st.SetCredentialSource(src)
+		if _, err := st.MigrateCredentials(ctx); !errors.Is(err, ErrCredentialSourceMismatch) {
+			t.Fatalf("%s: migrate err = %v, want mismatch", name, err)
+		}
+		if _, err := st.GetAccount(ctx, acc.ID); !errors.Is(err, ErrCredentialSourceMismatch) {
+			t.Fatalf("%s: get err = %v, want mismatch", name, err)
+		}
+		if _, err := st.UpsertAccount(ctx, model.Account{Mobile: acc.Mobile}); !errors.Is(err, ErrCredentialSourceMismatch) {
+			t.Fatalf("%s: upsert err = %v, want mismatch", name, err)
+		}
+	}
+
+	// 切回原来源后照常读取。
+	file := newFakeSource("file")
+	file.set(acc.ID, credentials.Credentials{Token: "tok"})
+	st.SetCredentialSource(file)
+	if n, err := st.MigrateCredentials(ctx); err != nil || n != 0 {
+		t.Fatalf("migrate = %d, %v", n, err)
+	}
+	if got, err := st.GetAccount(ctx, acc.ID); err != nil || got.Token != "tok" {
+		t.Fatalf("get = %+v, %v", got, err)
+	}
+}
+
+func TestExternalCredentialsCacheExpires(t *testing.T) {
+	st := openTestStore(t)
+	ctx := context.Background()
+	src := newFakeSource("vault")
+	st.SetCredentialSource(src)
+	st.credsTTL = 20 * time.Millisecond
+
+	acc, err := st.UpsertAccount(ctx, model.Account{Mobile: "13800000000", Token: "old"})
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	// 在外部轮换凭据：缓存有效期内仍是旧值，过期后读到新值。
+	src.set(acc.ID, credentials.Credentials{Token: "rotated"})
+	if got, err := st.GetAccount(ctx, acc.ID); err != nil || got.Token != "old" {
+		t.Fatalf("within ttl = %+v, %v", got, err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if got, err := st.GetAccount(ctx, acc.ID); err != nil || got.Token != "rotated" {
+		t.Fatalf("after ttl = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"sniping_engine/internal/model"
+)
+
+// attemptsKeep 为 attempts 表保留的最近记录条数，超出后按写入顺序删除最旧的记录。
+const attemptsKeep = 200000
+
+// InsertAttempts 在一个事务里批量保存尝试结果，并只保留最近 attemptsKeep 条。
+func (s *Store) InsertAttempts(ctx context.Context, results []model.AttemptResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, res := range results {
+		raw, err := json.Marshal(res)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO attempts (attempt_id, run_id, target_id, account_id, started_at, result_json)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, int64(res.ID), res.RunID, res.TargetID, res.AccountID, res.StartedAtMs, string(raw)); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM attempts WHERE id <= (SELECT id FROM attempts ORDER BY id DESC LIMIT 1 OFFSET ?)
+	`, attemptsKeep); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListAttempts 按写入顺序倒序分页返回尝试记录，同时返回满足条件的总条数。
+func (s *Store) ListAttempts(ctx context.Context, q model.AttemptQuery) ([]model.AttemptResult, int, error) {
+	q = q.Normalized()
+
+	var where []string
+	var args []any
+	if q.TargetID != "" {
+		where = append(where, "target_id = ?")
+		args = append(args, q.TargetID)
+	}
+	if q.AccountID != "" {
+		where = append(where, "account_id = ?")
+		args = append(args, q.AccountID)
+	}
+	if q.RunID != "" {
+		where = append(where, "run_id = ?")
+		args = append(args, q.RunID)
+	}
+	cond := ""
+	if len(where) > 0 {
+		cond = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM attempts`+cond, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT result_json FROM attempts`+cond+` ORDER BY id DESC LIMIT ? OFFSET ?`,
+		append(args, q.PageSize, (q.Page-1)*q.PageSize)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	out := []model.AttemptResult{}
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, 0, err
+		}
+		var res model.AttemptResult
+		if err := json.Unmarshal([]byte(raw), &res); err != nil {
+			continue
+		}
+		out = append(out, res)
+	}
+	return out, total, rows.Err()
+}
@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+func (s *Store) ListBizCodes(ctx context.Context) ([]model.BizCode, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT code, class, message, updated_at FROM biz_codes ORDER BY code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.BizCode
+	for rows.Next() {
+		var c model.BizCode
+		var updatedAt int64
+		if err := rows.Scan(&c.Code, &c.Class, &c.Message, &updatedAt); err != nil {
+			return nil, err
+		}
+		c.UpdatedAt = model.TimestampMs(updatedAt)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// UpsertBizCode 新增或修改一条业务码，并把它从未登记列表中移除。
+func (s *Store) UpsertBizCode(ctx context.Context, c model.BizCode) (model.BizCode, error) {
+	c.Code = strings.TrimSpace(c.Code)
+	c.Class = strings.TrimSpace(c.Class)
+	c.Message = strings.TrimSpace(c.Message)
+	if c.Code == "" {
+		return model.BizCode{}, errors.New("code is required")
+	}
+	if !slices.Contains(model.KnownBizClasses, c.Class) {
+		return model.BizCode{}, errors.New("unknown class: " + c.Class)
+	}
+	c.UpdatedAt = model.Timestamp{Time: time.Now()}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.BizCode{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO biz_codes (code, class, message, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(code) DO UPDATE SET
+			class = excluded.class,
+			message = excluded.message,
+			updated_at = excluded.updated_at
+	`, c.Code, c.Class, c.Message, c.UpdatedAt.UnixMilli()); err != nil {
+		return model.BizCode{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM biz_codes_unknown WHERE code = ?`, c.Code); err != nil {
+		return model.BizCode{}, err
+	}
+	return c, tx.Commit()
+}
+
+func (s *Store) DeleteBizCode(ctx context.Context, code string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM biz_codes WHERE code = ?`, code)
+	return err
+}
+
+func (s *Store) ListUnknownBizCodes(ctx context.Context) ([]model.UnknownBizCode, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT code, api, message, count, first_seen_ms, last_seen_ms
+		FROM biz_codes_unknown
+		ORDER BY last_seen_ms DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.UnknownBizCode
+	for rows.Next() {
+		var u model.UnknownBizCode
+		if err := rows.Scan(&u.Code, &u.API, &u.Message, &u.Count, &u.FirstSeenMs, &u.LastSeenMs); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// UpsertUnknownBizCode 保存未登记业务码的最新统计。
+func (s *Store) UpsertUnknownBizCode(ctx context.Context, u model.UnknownBizCode) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO biz_codes_unknown (code, api, message, count, first_seen_ms, last_seen_ms)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(code) DO UPDATE SET
+			api = excluded.api,
+			message = excluded.message,
+			count = MAX(biz_codes_unknown.count, excluded.count),
+			last_seen_ms = excluded.last_seen_ms
+	`, u.Code, u.API, u.Message, u.Count, u.FirstSeenMs, u.LastSeenMs)
+	return err
+}
@@ -0,0 +1,264 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// ExportBundle 导出全部目标与账号，账号去掉 token/cookie、设备身份和代理池引用（这些只在原环境有效）。
+// env 为空时各资源保留自身的环境标记，否则整包标记为 env。
+func (s *Store) ExportBundle(ctx context.Context, env string) (model.ConfigBundle, error) {
+	env, err := model.NormalizeEnv(env)
+	if err != nil {
+		return model.ConfigBundle{}, err
+	}
+	targets, err := s.ListTargets(ctx)
+	if err != nil {
+		return model.ConfigBundle{}, err
+	}
+	accounts, err := s.ListAccounts(ctx)
+	if err != nil {
+		return model.ConfigBundle{}, err
+	}
+	for i := range accounts {
+		a := &accounts[i]
+		a.Token, a.Cookies, a.CredentialRef = "", nil, ""
+		a.DeviceID, a.UUID, a.ProxyID = "", "", ""
+	}
+	return model.ConfigBundle{
+		Env:          env,
+		ExportedAtMs: time.Now().UnixMilli(),
+		Targets:      targets,
+		Accounts:     accounts,
+	}, nil
+}
+
+// 导入结果中每项资源的处理方式。
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// ImportItem 是单个目标/账号的导入结果。
+type ImportItem struct {
+	// Row 为批量导入目标时在文件中的行号（从 1 开始），见 ImportTargets。
+	Row      int    `json:"row,omitempty"`
+	SourceID string `json:"sourceId,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Env      string `json:"env,omitempty"`
+	Action   string `json:"action"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// ImportResult 是 ImportBundle 的结果。
+type ImportResult struct {
+	DryRun   bool         `json:"dryRun,omitempty"`
+	Targets  []ImportItem `json:"targets"`
+	Accounts []ImportItem `json:"accounts"`
+}
+
+// ImportBundle 按选项改写 ID 与环境标记后导入目标与账号。
+// 同 ID 的目标仅在 Overwrite 时覆盖；账号以手机号为唯一键，已存在的一律跳过，不会覆盖现有登录态。
+// 单项失败只记入结果，不影响其他项。与已有目标指向同一商品规格的目标按 blockDuplicates 拒绝（记为失败）或照常导入并注明。
+func (s *Store) ImportBundle(ctx context.Context, b model.ConfigBundle, opts model.ImportOptions, blockDuplicates bool) (ImportResult, error) {
+	out := ImportResult{DryRun: opts.DryRun, Targets: []ImportItem{}, Accounts: []ImportItem{}}
+	if _, err := model.NormalizeEnv(opts.Env); err != nil {
+		return out, err
+	}
+
+	for _, t := range b.Targets {
+		item := ImportItem{SourceID: t.ID, ID: opts.MapID(t.ID), Env: opts.ResourceEnv(t.Env, b.Env)}
+		t.ID, t.Env = item.ID, item.Env
+		// 活动分组不随配置迁移（覆盖已有目标时保留其原分组）。
+		t.CampaignID = ""
+		if !opts.KeepEnabled {
+			t.Enabled = false
+		}
+		t.CreatedAt = model.Timestamp{}
+
+		item.Action = ImportCreated
+		var current model.Target
+		if t.ID != "" {
+			if existing, err := s.GetTarget(ctx, t.ID); err == nil {
+				if !opts.Overwrite {
+					item.Action, item.Reason = ImportSkipped, "target id already exists"
+					out.Targets = append(out.Targets, item)
+					continue
+				}
+				item.Action = ImportUpdated
+				t.CampaignID = existing.CampaignID
+				current = existing
+			} else if !errors.Is(err, sql.ErrNoRows) {
+				return out, err
+			}
+		}
+		if ok, err := s.checkImportDuplicate(ctx, &item, current, t, blockDuplicates); err != nil {
+			return out, err
+		} else if !ok {
+			out.Targets = append(out.Targets, item)
+			continue
+		}
+		if !opts.DryRun {
+			saved, err := s.UpsertTarget(ctx, t)
+			if err != nil {
+				item.Action, item.Reason = ImportFailed, err.Error()
+			} else {
+				item.ID = saved.ID
+			}
+		}
+		out.Targets = append(out.Targets, item)
+	}
+
+	for _, a := range b.Accounts {
+		item := ImportItem{SourceID: a.ID, ID: opts.MapID(a.ID), Env: opts.ResourceEnv(a.Env, b.Env), Action: ImportCreated}
+		mobile, err := s.NormalizeMobile(a.Mobile)
+		if err != nil {
+			item.Action, item.Reason = ImportFailed, err.Error()
+			out.Accounts = append(out.Accounts, item)
+			continue
+		}
+		if existing, err := s.GetAccountByMobile(ctx, mobile); err == nil {
+			item.ID, item.Action, item.Reason = existing.ID, ImportSkipped, "mobile already exists"
+			out.Accounts = append(out.Accounts, item)
+			continue
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return out, err
+		}
+		if item.ID != "" {
+			if _, err := s.GetAccount(ctx, item.ID); err == nil {
+				item.Action, item.Reason = ImportSkipped, "account id already exists"
+				out.Accounts = append(out.Accounts, item)
+				continue
+			} else if !errors.Is(err, sql.ErrNoRows) {
+				return out, err
+			}
+		}
+		if !opts.DryRun {
+			next := model.Account{
+				ID:          item.ID,
+				Username:    strings.TrimSpace(a.Username),
+				Mobile:      mobile,
+				UserAgent:   a.UserAgent,
+				Proxy:       a.Proxy,
+				AddressID:   a.AddressID,
+				DivisionIDs: a.DivisionIDs,
+				Env:         item.Env,
+			}
+			saved, err := s.UpsertAccount(ctx, next)
+			if err != nil {
+				item.Action, item.Reason = ImportFailed, err.Error()
+			} else {
+				item.ID = saved.ID
+			}
+		}
+		out.Accounts = append(out.Accounts, item)
+	}
+	return out, nil
+}
+
+// TargetImportResult 是 ImportTargets 的结果，Rows 与输入逐行对应。
+type TargetImportResult struct {
+	DryRun  bool         `json:"dryRun,omitempty"`
+	Created int          `json:"created"`
+	Updated int          `json:"updated"`
+	Skipped int          `json:"skipped"`
+	Failed  int          `json:"failed"`
+	Rows    []ImportItem `json:"rows"`
+}
+
+// ImportTargets 逐行校验并保存批量导入的目标：同 ID 的已有目标仅在 overwrite 时覆盖，
+// 同一批里重复的 ID 只保留第一行；单行失败只记入结果，不影响其他行。dryRun 时只校验不写入。
+// 重复商品规格的处理同 ImportBundle。
+func (s *Store) ImportTargets(ctx context.Context, rows []model.TargetImportRow, overwrite, dryRun, blockDuplicates bool) (TargetImportResult, error) {
+	out := TargetImportResult{DryRun: dryRun, Rows: make([]ImportItem, 0, len(rows))}
+	seen := make(map[string]int, len(rows))
+	for _, row := range rows {
+		t := row.Target
+		t.ID = strings.TrimSpace(t.ID)
+		t.Name = strings.TrimSpace(t.Name)
+		t.CreatedAt = model.Timestamp{}
+		item := ImportItem{Row: row.Row, SourceID: t.ID, ID: t.ID, Action: ImportCreated}
+
+		switch {
+		case row.Error != "":
+			item.Action, item.Reason = ImportFailed, row.Error
+		case t.ID != "" && seen[t.ID] > 0:
+			item.Action, item.Reason = ImportFailed, fmt.Sprintf("duplicate id (same as row %d)", seen[t.ID])
+		default:
+			var current model.Target
+			if t.ID != "" {
+				seen[t.ID] = row.Row
+				if existing, err := s.GetTarget(ctx, t.ID); err == nil {
+					if !overwrite {
+						item.Action, item.Reason = ImportSkipped, "target id already exists"
+						break
+					}
+					item.Action = ImportUpdated
+					current = existing
+				} else if !errors.Is(err, sql.ErrNoRows) {
+					return out, err
+				}
+			}
+			if ok, err := s.checkImportDuplicate(ctx, &item, current, t, blockDuplicates); err != nil {
+				return out, err
+			} else if !ok {
+				break
+			}
+			if dryRun {
+				if _, err := s.normalizeTarget(ctx, t); err != nil {
+					item.Action, item.Reason = ImportFailed, err.Error()
+				}
+				break
+			}
+			saved, err := s.UpsertTarget(ctx, t)
+			if err != nil {
+				item.Action, item.Reason = ImportFailed, err.Error()
+				break
+			}
+			item.ID = saved.ID
+		}
+
+		switch item.Action {
+		case ImportCreated:
+			out.Created++
+		case ImportUpdated:
+			out.Updated++
+		case ImportSkipped:
+			out.Skipped++
+		case ImportFailed:
+			out.Failed++
+		}
+		out.Rows = append(out.Rows, item)
+	}
+	return out, nil
+}
+
+// checkImportDuplicate 对导入的目标执行重复商品规格检查（见 CheckDuplicateTarget）：被拦截时把 item 记为失败并返回 false，
+// 照常导入时在 Reason 里注明重复的目标。
+func (s *Store) checkImportDuplicate(ctx context.Context, item *ImportItem, current, next model.Target, block bool) (bool, error) {
+	duplicates, err := s.CheckDuplicateTarget(ctx, current, next, block)
+	if err != nil && !errors.Is(err, ErrDuplicateTarget) {
+		return false, err
+	}
+	if len(duplicates) == 0 {
+		return true, nil
+	}
+	ids := make([]string, 0, len(duplicates))
+	for _, d := range duplicates {
+		ids = append(ids, d.ID)
+	}
+	if err != nil {
+		item.Action, item.Reason = ImportFailed, fmt.Sprintf("%v: %s", err, strings.Join(ids, ", "))
+		return false, nil
+	}
+	item.Reason = "same sku as target " + strings.Join(ids, ", ")
+	return true, nil
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"sniping_engine/internal/model"
+)
+
+func TestImportBlocksDuplicateTargets(t *testing.T) {
+	st := openTestStore(t)
+	ctx := context.Background()
+	existing, err := st.UpsertTarget(ctx, model.Target{Name: "live", ItemID: 1, SKUID: 1, Mode: model.TargetModeScan, TargetQty: 1, PerOrderQty: 1})
+	if err != nil {
+		t.Fatalf("upsert target: %v", err)
+	}
+	dup := model.Target{Name: "dup", ItemID: 1, SKUID: 1, Mode: model.TargetModeScan, TargetQty: 1, PerOrderQty: 1}
+	practice := dup
+	practice.Name, practice.Practice = "practice", true
+
+	rows := []model.TargetImportRow{{Row: 1, Target: dup}, {Row: 2, Target: practice}}
+	res, err := st.ImportTargets(ctx, rows, false, false, true)
+	if err != nil {
+		t.Fatalf("ImportTargets: %v", err)
+	}
+	if res.Failed != 1 || res.Created != 1 || res.Rows[0].Action != ImportFailed || res.Rows[1].Action != ImportCreated {
+		t.Fatalf("import result = %+v; want the live duplicate refused and the practice twin created", res)
+	}
+
+	bundle, err := st.ImportBundle(ctx, model.ConfigBundle{Targets: []model.Target{dup}}, model.ImportOptions{}, true)
+	if err != nil {
+		t.Fatalf("ImportBundle: %v", err)
+	}
+	if len(bundle.Targets) != 1 || bundle.Targets[0].Action != ImportFailed {
+		t.Fatalf("bundle result = %+v; want the duplicate refused", bundle.Targets)
+	}
+
+	// warn 策略照常导入，并在结果里注明重复的目标。
+	res, err = st.ImportTargets(ctx, rows[:1], false, false, false)
+	if err != nil {
+		t.Fatalf("ImportTargets: %v", err)
+	}
+	if res.Created != 1 || res.Rows[0].Reason != "same sku as target "+existing.ID {
+		t.Fatalf("import result = %+v; want the duplicate saved with a note", res)
+	}
+
+	// 已有的重复目标仍可覆盖更新。
+	saved := res.Rows[0].ID
+	update := dup
+	update.ID, update.TargetQty = saved, 5
+	res, err = st.ImportTargets(ctx, []model.TargetImportRow{{Row: 1, Target: update}}, true, false, true)
+	if err != nil {
+		t.Fatalf("ImportTargets: %v", err)
+	}
+	if res.Updated != 1 {
+		t.Fatalf("import result = %+v; want the existing duplicate updated", res)
+	}
+}
@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sniping_engine/internal/model"
+)
+
+const campaignColumns = `id, name, note, created_at, updated_at`
+
+func scanCampaign(sc rowScanner) (model.Campaign, error) {
+	var c model.Campaign
+	var createdAt, updatedAt int64
+	if err := sc.Scan(&c.ID, &c.Name, &c.Note, &createdAt, &updatedAt); err != nil {
+		return model.Campaign{}, err
+	}
+	c.CreatedAt = model.TimestampMs(createdAt)
+	c.UpdatedAt = model.TimestampMs(updatedAt)
+	return c, nil
+}
+
+func (s *Store) UpsertCampaign(ctx context.Context, c model.Campaign) (model.Campaign, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Note = strings.TrimSpace(c.Note)
+	if c.Name == "" {
+		return model.Campaign{}, errors.New("name is required")
+	}
+	if c.ID == "" {
+		c.ID = uuid.NewString()
+	}
+	now := time.Now()
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = model.Timestamp{Time: now}
+	}
+	c.UpdatedAt = model.Timestamp{Time: now}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO campaigns (`+campaignColumns+`)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			note = excluded.note,
+			updated_at = excluded.updated_at
+	`, c.ID, c.Name, c.Note, c.CreatedAt.UnixMilli(), c.UpdatedAt.UnixMilli())
+	if err != nil {
+		return model.Campaign{}, err
+	}
+	return s.GetCampaign(ctx, c.ID)
+}
+
+func (s *Store) GetCampaign(ctx context.Context, id string) (model.Campaign, error) {
+	return scanCampaign(s.db.QueryRowContext(ctx, `SELECT `+campaignColumns+` FROM campaigns WHERE id = ?`, id))
+}
+
+func (s *Store) ListCampaigns(ctx context.Context) ([]model.Campaign, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+campaignColumns+` FROM campaigns ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.Campaign
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// CampaignTargetIDs 返回活动下的目标 ID。
+func (s *Store) CampaignTargetIDs(ctx context.Context, id string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM targets WHERE campaign_id = ? ORDER BY created_at ASC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var tid string
+		if err := rows.Scan(&tid); err != nil {
+			return nil, err
+		}
+		out = append(out, tid)
+	}
+	return out, rows.Err()
+}
+
+// DeleteCampaign 删除活动，成员目标保留并解除分组。
+func (s *Store) DeleteCampaign(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `UPDATE targets SET campaign_id = '' WHERE campaign_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM campaigns WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,151 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+func rawOrEmpty(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return "{}"
+	}
+	return string(raw)
+}
+
+// ReplaceCatalogCategories 用最新结果整体替换某个前台分类下缓存的分类节点。
+func (s *Store) ReplaceCatalogCategories(ctx context.Context, frontCategoryID int64, items []model.CatalogCategory) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM catalog_categories WHERE front_category_id = ?`, frontCategoryID); err != nil {
+		return err
+	}
+	now := time.Now().UnixMilli()
+	for _, c := range items {
+		hasChildren := 0
+		if c.HasChildren {
+			hasChildren = 1
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR REPLACE INTO catalog_categories (front_category_id, id, pid, level, name, has_children, raw_json, refreshed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, frontCategoryID, c.ID, c.PID, c.Level, c.Name, hasChildren, rawOrEmpty(c.Raw), now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) ListCatalogCategories(ctx context.Context, frontCategoryID int64) ([]model.CatalogCategory, error) {
+	query := `SELECT front_category_id, id, pid, level, name, has_children, raw_json, refreshed_at FROM catalog_categories`
+	var args []any
+	if frontCategoryID > 0 {
+		query += ` WHERE front_category_id = ?`
+		args = append(args, frontCategoryID)
+	}
+	query += ` ORDER BY front_category_id, level, id`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.CatalogCategory
+	for rows.Next() {
+		var c model.CatalogCategory
+		var hasChildren int
+		var raw string
+		if err := rows.Scan(&c.FrontCategoryID, &c.ID, &c.PID, &c.Level, &c.Name, &hasChildren, &raw, &c.RefreshedAtMs); err != nil {
+			return nil, err
+		}
+		c.HasChildren = hasChildren == 1
+		c.Raw = json.RawMessage(raw)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// ReplaceCatalogSkus 用最新结果整体替换某个前台分类下缓存的商品。
+func (s *Store) ReplaceCatalogSkus(ctx context.Context, frontCategoryID int64, items []model.CatalogSku) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM catalog_skus WHERE front_category_id = ?`, frontCategoryID); err != nil {
+		return err
+	}
+	now := time.Now().UnixMilli()
+	for _, k := range items {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR REPLACE INTO catalog_skus (front_category_id, store_id, sku_id, item_id, category_id, category_name, name, main_image, price, in_stock, shop_id, purchase_limit, raw_json, refreshed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, frontCategoryID, k.StoreID, k.SKUID, k.ItemID, k.CategoryID, k.CategoryName, k.Name, k.MainImage, k.Price, k.InStock, k.ShopID, k.PurchaseLimit, rawOrEmpty(k.Raw), now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) ListCatalogSkus(ctx context.Context, q model.CatalogSkuQuery) ([]model.CatalogSku, error) {
+	var where []string
+	var args []any
+	if q.FrontCategoryID > 0 {
+		where = append(where, `front_category_id = ?`)
+		args = append(args, q.FrontCategoryID)
+	}
+	if q.CategoryID > 0 {
+		where = append(where, `category_id = ?`)
+		args = append(args, q.CategoryID)
+	}
+	if q.StoreID > 0 {
+		where = append(where, `store_id = ?`)
+		args = append(args, q.StoreID)
+	}
+	if kw := strings.TrimSpace(q.Keyword); kw != "" {
+		where = append(where, `name LIKE ?`)
+		args = append(args, "%"+kw+"%")
+	}
+	limit := q.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	offset := q.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `SELECT front_category_id, store_id, sku_id, item_id, category_id, category_name, name, main_image, price, in_stock, shop_id, purchase_limit, raw_json, refreshed_at FROM catalog_skus`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY category_id, sku_id LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.CatalogSku
+	for rows.Next() {
+		var k model.CatalogSku
+		var raw string
+		if err := rows.Scan(&k.FrontCategoryID, &k.StoreID, &k.SKUID, &k.ItemID, &k.CategoryID, &k.CategoryName, &k.Name, &k.MainImage, &k.Price, &k.InStock, &k.ShopID, &k.PurchaseLimit, &raw, &k.RefreshedAtMs); err != nil {
+			return nil, err
+		}
+		k.Raw = json.RawMessage(raw)
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
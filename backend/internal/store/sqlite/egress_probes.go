@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+
+	"sniping_engine/internal/model"
+)
+
+// ReplaceEgressProbes 用最新一轮探测结果替换 egress_probes 表。
+func (s *Store) ReplaceEgressProbes(ctx context.Context, probes []model.EgressProbe) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM egress_probes`); err != nil {
+		return err
+	}
+	for _, p := range probes {
+		accounts, err := json.Marshal(p.AccountIDs)
+		if err != nil {
+			return err
+		}
+		slow := 0
+		if p.Slow {
+			slow = 1
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO egress_probes (path, account_ids_json, samples, failures, loss_pct, avg_ms, p50_ms, max_ms, slow, error, probed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, p.Path, string(accounts), p.Samples, p.Failures, p.LossPct, p.AvgMs, p.P50Ms, p.MaxMs, slow, p.Error, p.ProbedAtMs); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) ListEgressProbes(ctx context.Context) ([]model.EgressProbe, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT path, account_ids_json, samples, failures, loss_pct, avg_ms, p50_ms, max_ms, slow, error, probed_at
+		FROM egress_probes ORDER BY p50_ms ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.EgressProbe
+	for rows.Next() {
+		var p model.EgressProbe
+		var accounts string
+		var slow int
+		if err := rows.Scan(&p.Path, &accounts, &p.Samples, &p.Failures, &p.LossPct, &p.AvgMs, &p.P50Ms, &p.MaxMs, &slow, &p.Error, &p.ProbedAtMs); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(accounts), &p.AccountIDs)
+		p.Slow = slow == 1
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
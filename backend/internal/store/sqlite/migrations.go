@@ -45,6 +45,152 @@ func (s *Store) migrate(ctx context.Context) error {
 			value_json TEXT NOT NULL DEFAULT '{}',
 			updated_at INTEGER NOT NULL
 		);`,
+		`CREATE TABLE IF NOT EXISTS catalog_categories (
+			front_category_id INTEGER NOT NULL,
+			id INTEGER NOT NULL,
+			pid INTEGER NOT NULL DEFAULT 0,
+			level INTEGER NOT NULL DEFAULT 0,
+			name TEXT NOT NULL DEFAULT '',
+			has_children INTEGER NOT NULL DEFAULT 0,
+			raw_json TEXT NOT NULL DEFAULT '{}',
+			refreshed_at INTEGER NOT NULL,
+			PRIMARY KEY (front_category_id, id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS catalog_skus (
+			front_category_id INTEGER NOT NULL,
+			store_id INTEGER NOT NULL DEFAULT 0,
+			sku_id INTEGER NOT NULL,
+			item_id INTEGER NOT NULL DEFAULT 0,
+			category_id INTEGER NOT NULL DEFAULT 0,
+			category_name TEXT NOT NULL DEFAULT '',
+			name TEXT NOT NULL DEFAULT '',
+			main_image TEXT NOT NULL DEFAULT '',
+			price INTEGER NOT NULL DEFAULT 0,
+			in_stock INTEGER NOT NULL DEFAULT 0,
+			raw_json TEXT NOT NULL DEFAULT '{}',
+			refreshed_at INTEGER NOT NULL,
+			PRIMARY KEY (front_category_id, store_id, sku_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_catalog_skus_category ON catalog_skus (category_id);`,
+		`CREATE TABLE IF NOT EXISTS task_states (
+			target_id TEXT PRIMARY KEY,
+			purchased_qty INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			last_attempt_ms INTEGER NOT NULL DEFAULT 0,
+			last_success_ms INTEGER NOT NULL DEFAULT 0,
+			need_captcha INTEGER,
+			updated_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS order_ledger (
+			account_id TEXT NOT NULL,
+			target_id TEXT NOT NULL,
+			order_id TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL,
+			PRIMARY KEY (account_id, target_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS biz_codes (
+			code TEXT PRIMARY KEY,
+			class TEXT NOT NULL DEFAULT '',
+			message TEXT NOT NULL DEFAULT '',
+			updated_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS biz_codes_unknown (
+			code TEXT PRIMARY KEY,
+			api TEXT NOT NULL DEFAULT '',
+			message TEXT NOT NULL DEFAULT '',
+			count INTEGER NOT NULL DEFAULT 0,
+			first_seen_ms INTEGER NOT NULL,
+			last_seen_ms INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS proxies (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT '',
+			url TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS campaigns (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			note TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS slow_requests (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			at_ms INTEGER NOT NULL,
+			account_id TEXT NOT NULL DEFAULT '',
+			trace_json TEXT NOT NULL DEFAULT '{}'
+		);`,
+		`CREATE TABLE IF NOT EXISTS egress_probes (
+			path TEXT PRIMARY KEY,
+			account_ids_json TEXT NOT NULL DEFAULT '[]',
+			samples INTEGER NOT NULL DEFAULT 0,
+			failures INTEGER NOT NULL DEFAULT 0,
+			loss_pct REAL NOT NULL DEFAULT 0,
+			avg_ms INTEGER NOT NULL DEFAULT 0,
+			p50_ms INTEGER NOT NULL DEFAULT 0,
+			max_ms INTEGER NOT NULL DEFAULT 0,
+			slow INTEGER NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			probed_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS account_tokens (
+			account_id TEXT PRIMARY KEY,
+			token_hash TEXT NOT NULL,
+			issued_at_ms INTEGER NOT NULL,
+			expired_at_ms INTEGER NOT NULL DEFAULT 0,
+			expected_expiry_ms INTEGER NOT NULL DEFAULT 0
+		);`,
+		`CREATE TABLE IF NOT EXISTS token_lifetimes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id TEXT NOT NULL,
+			issued_at_ms INTEGER NOT NULL,
+			expired_at_ms INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS orders (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			attempt_id INTEGER NOT NULL DEFAULT 0,
+			run_id TEXT NOT NULL DEFAULT '',
+			target_id TEXT NOT NULL,
+			target_name TEXT NOT NULL DEFAULT '',
+			mode TEXT NOT NULL DEFAULT '',
+			account_id TEXT NOT NULL,
+			mobile TEXT NOT NULL DEFAULT '',
+			item_id INTEGER NOT NULL DEFAULT 0,
+			sku_id INTEGER NOT NULL DEFAULT 0,
+			shop_id INTEGER NOT NULL DEFAULT 0,
+			quantity INTEGER NOT NULL DEFAULT 0,
+			order_id TEXT NOT NULL DEFAULT '',
+			trace_id TEXT NOT NULL DEFAULT '',
+			expected_fee INTEGER NOT NULL DEFAULT 0,
+			actual_fee INTEGER NOT NULL DEFAULT 0,
+			fee_mismatch INTEGER NOT NULL DEFAULT 0,
+			order_verified INTEGER NOT NULL DEFAULT 0,
+			order_status TEXT NOT NULL DEFAULT '',
+			pay_deadline_ms INTEGER NOT NULL DEFAULT 0,
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders (created_at);`,
+		`CREATE TABLE IF NOT EXISTS attempts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			attempt_id INTEGER NOT NULL DEFAULT 0,
+			run_id TEXT NOT NULL DEFAULT '',
+			target_id TEXT NOT NULL,
+			account_id TEXT NOT NULL,
+			started_at INTEGER NOT NULL,
+			result_json TEXT NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_attempts_target ON attempts (target_id, id);`,
+		`CREATE INDEX IF NOT EXISTS idx_attempts_account ON attempts (account_id, id);`,
+		`CREATE TABLE IF NOT EXISTS account_daily_purchases (
+			account_id TEXT NOT NULL,
+			day TEXT NOT NULL,
+			qty INTEGER NOT NULL DEFAULT 0,
+			orders INTEGER NOT NULL DEFAULT 0,
+			updated_at INTEGER NOT NULL,
+			PRIMARY KEY (account_id, day)
+		);`,
 	}
 
 	for _, stmt := range stmts {
@@ -54,40 +200,57 @@ func (s *Store) migrate(ctx context.Context) error {
 	}
 
 	// Backward compatible migrations for existing DBs.
-	if _, err := s.db.ExecContext(ctx, `ALTER TABLE accounts ADD COLUMN username TEXT NOT NULL DEFAULT ''`); err != nil {
-		// SQLite returns "duplicate column name: username" if it already exists.
-		if !strings.Contains(strings.ToLower(err.Error()), "duplicate") {
-			return fmt.Errorf("migrate accounts.username: %w", err)
-		}
-	}
-
-	if _, err := s.db.ExecContext(ctx, `ALTER TABLE accounts ADD COLUMN address_id INTEGER NOT NULL DEFAULT 0`); err != nil {
-		if !strings.Contains(strings.ToLower(err.Error()), "duplicate") {
-			return fmt.Errorf("migrate accounts.address_id: %w", err)
-		}
+	columns := []struct {
+		table  string
+		column string
+		ddl    string
+	}{
+		{"accounts", "username", `TEXT NOT NULL DEFAULT ''`},
+		{"accounts", "address_id", `INTEGER NOT NULL DEFAULT 0`},
+		{"accounts", "division_ids", `TEXT NOT NULL DEFAULT ''`},
+		{"targets", "image_url", `TEXT NOT NULL DEFAULT ''`},
+		{"targets", "captcha_verify_param", `TEXT NOT NULL DEFAULT ''`},
+		{"targets", "rush_lead_ms", `INTEGER NOT NULL DEFAULT 500`},
+		{"targets", "category_id", `INTEGER NOT NULL DEFAULT 0`},
+		{"targets", "order_source", `TEXT NOT NULL DEFAULT ''`},
+		{"targets", "device_source", `TEXT NOT NULL DEFAULT ''`},
+		{"accounts", "credential_ref", `TEXT NOT NULL DEFAULT ''`},
+		{"accounts", "token_hash", `TEXT NOT NULL DEFAULT ''`},
+		{"targets", "allow_multi_per_account", `INTEGER NOT NULL DEFAULT 0`},
+		{"targets", "captcha_override", `TEXT NOT NULL DEFAULT ''`},
+		{"task_states", "need_captcha", `INTEGER`},
+		{"targets", "min_stock", `INTEGER NOT NULL DEFAULT 0`},
+		{"targets", "max_total_fee", `INTEGER NOT NULL DEFAULT 0`},
+		{"targets", "prerender_seconds", `INTEGER NOT NULL DEFAULT 0`},
+		{"accounts", "proxy_id", `TEXT NOT NULL DEFAULT ''`},
+		{"targets", "env", `TEXT NOT NULL DEFAULT ''`},
+		{"accounts", "env", `TEXT NOT NULL DEFAULT ''`},
+		{"targets", "campaign_id", `TEXT NOT NULL DEFAULT ''`},
+		{"targets", "qps", `REAL NOT NULL DEFAULT 0`},
+		{"targets", "burst", `INTEGER NOT NULL DEFAULT 0`},
+		{"targets", "max_in_flight", `INTEGER NOT NULL DEFAULT 0`},
+		{"targets", "max_attempts", `INTEGER NOT NULL DEFAULT 0`},
+		{"targets", "extra_lines_json", `TEXT NOT NULL DEFAULT '[]'`},
+		{"order_ledger", "order_status", `TEXT NOT NULL DEFAULT ''`},
+		{"order_ledger", "pay_deadline_ms", `INTEGER NOT NULL DEFAULT 0`},
+		{"order_ledger", "verified_at", `INTEGER NOT NULL DEFAULT 0`},
+		{"order_ledger", "verify_error", `TEXT NOT NULL DEFAULT ''`},
+		{"targets", "practice", `INTEGER NOT NULL DEFAULT 0`},
+		{"targets", "notify_json", `TEXT NOT NULL DEFAULT ''`},
+		{"targets", "arm_at_ms", `INTEGER NOT NULL DEFAULT 0`},
+		{"accounts", "validated_at_ms", `INTEGER NOT NULL DEFAULT 0`},
+		{"accounts", "validation_ok", `INTEGER NOT NULL DEFAULT 0`},
+		{"accounts", "validation_error", `TEXT NOT NULL DEFAULT ''`},
+		{"catalog_skus", "shop_id", `INTEGER NOT NULL DEFAULT 0`},
+		{"catalog_skus", "purchase_limit", `INTEGER NOT NULL DEFAULT 0`},
 	}
-
-	if _, err := s.db.ExecContext(ctx, `ALTER TABLE accounts ADD COLUMN division_ids TEXT NOT NULL DEFAULT ''`); err != nil {
-		if !strings.Contains(strings.ToLower(err.Error()), "duplicate") {
-			return fmt.Errorf("migrate accounts.division_ids: %w", err)
-		}
-	}
-
-	if _, err := s.db.ExecContext(ctx, `ALTER TABLE targets ADD COLUMN image_url TEXT NOT NULL DEFAULT ''`); err != nil {
-		if !strings.Contains(strings.ToLower(err.Error()), "duplicate") {
-			return fmt.Errorf("migrate targets.image_url: %w", err)
-		}
-	}
-
-	if _, err := s.db.ExecContext(ctx, `ALTER TABLE targets ADD COLUMN captcha_verify_param TEXT NOT NULL DEFAULT ''`); err != nil {
-		if !strings.Contains(strings.ToLower(err.Error()), "duplicate") {
-			return fmt.Errorf("migrate targets.captcha_verify_param: %w", err)
-		}
-	}
-
-	if _, err := s.db.ExecContext(ctx, `ALTER TABLE targets ADD COLUMN rush_lead_ms INTEGER NOT NULL DEFAULT 500`); err != nil {
-		if !strings.Contains(strings.ToLower(err.Error()), "duplicate") {
-			return fmt.Errorf("migrate targets.rush_lead_ms: %w", err)
+	for _, c := range columns {
+		stmt := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, c.table, c.column, c.ddl)
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			// SQLite returns "duplicate column name: xxx" if it already exists.
+			if !strings.Contains(strings.ToLower(err.Error()), "duplicate") {
+				return fmt.Errorf("migrate %s.%s: %w", c.table, c.column, err)
+			}
 		}
 	}
 
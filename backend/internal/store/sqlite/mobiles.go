@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"sniping_engine/internal/model"
+)
+
+// ErrDuplicateMobile 表示规范化后的手机号已属于另一个账号。
+var ErrDuplicateMobile = errors.New("mobile already belongs to another account")
+
+// SetMobileCountry 配置手机号规范化使用的默认区号（如 "86"）。
+func (s *Store) SetMobileCountry(cc string) {
+	s.mobileCountry = cc
+}
+
+// NormalizeMobile 按 store 的默认区号规范化手机号，结果即账号表中保存的唯一形式。
+func (s *Store) NormalizeMobile(raw string) (string, error) {
+	return model.NormalizeMobile(raw, s.mobileCountry)
+}
+
+// accountMobileKey 返回写入账号表时使用的手机号：与已有记录完全一致时原样沿用
+// （包括迁移时因重复或格式无法识别而保留原值的旧记录），否则规范化并校验。
+func (s *Store) accountMobileKey(ctx context.Context, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.New("mobile is required")
+	}
+	var id string
+	if err := s.db.QueryRowContext(ctx, `SELECT id FROM accounts WHERE mobile = ?`, raw).Scan(&id); err == nil {
+		return raw, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	return s.NormalizeMobile(raw)
+}
+
+// MobileConflict 是迁移时规范化后与其他账号重复的手机号，保持原值不动，需要人工合并。
+type MobileConflict struct {
+	AccountID     string `json:"accountId"`
+	Mobile        string `json:"mobile"`
+	Normalized    string `json:"normalized"`
+	ConflictsWith string `json:"conflictsWith"`
+}
+
+// MobileMigration 是 NormalizeMobiles 的结果。
+type MobileMigration struct {
+	Normalized int              `json:"normalized"`
+	Conflicts  []MobileConflict `json:"conflicts,omitempty"`
+	// Invalid 无法规范化的账号 ID（保持原值）。
+	Invalid []string `json:"invalid,omitempty"`
+}
+
+// NormalizeMobiles 把账号表中已有的手机号改写为规范化形式。
+// 规范化后与其他账号重复的、以及格式无法识别的号码保持原值并在结果中列出，不会中断迁移。
+func (s *Store) NormalizeMobiles(ctx context.Context) (MobileMigration, error) {
+	var out MobileMigration
+	rows, err := s.db.QueryContext(ctx, `SELECT id, mobile FROM accounts ORDER BY created_at ASC`)
+	if err != nil {
+		return out, err
+	}
+	type row struct{ id, mobile string }
+	var all []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.mobile); err != nil {
+			rows.Close()
+			return out, err
+		}
+		all = append(all, r)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return out, err
+	}
+	rows.Close()
+
+	for _, r := range all {
+		normalized, err := s.NormalizeMobile(r.mobile)
+		if err != nil {
+			out.Invalid = append(out.Invalid, r.id)
+			continue
+		}
+		if normalized == r.mobile {
+			continue
+		}
+		var otherID string
+		if err := s.db.QueryRowContext(ctx, `SELECT id FROM accounts WHERE mobile = ? AND id <> ?`, normalized, r.id).Scan(&otherID); err == nil {
+			out.Conflicts = append(out.Conflicts, MobileConflict{AccountID: r.id, Mobile: r.mobile, Normalized: normalized, ConflictsWith: otherID})
+			continue
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return out, err
+		}
+		if _, err := s.db.ExecContext(ctx, `UPDATE accounts SET mobile = ? WHERE id = ?`, normalized, r.id); err != nil {
+			return out, err
+		}
+		out.Normalized++
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// ClaimOrderLedger 记录账号在目标上成功下过单；已有记录时返回 false（不覆盖原订单号）。
+func (s *Store) ClaimOrderLedger(ctx context.Context, accountID, targetID, orderID string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO order_ledger (account_id, target_id, order_id, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(account_id, target_id) DO NOTHING
+	`, accountID, targetID, orderID, time.Now().UnixMilli())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// GetOrderLedger 返回账号在目标上已记录的订单号；没有记录时 ok=false。
+func (s *Store) GetOrderLedger(ctx context.Context, accountID, targetID string) (orderID string, ok bool, err error) {
+	err = s.db.QueryRowContext(ctx, `
+		SELECT order_id FROM order_ledger WHERE account_id = ? AND target_id = ?
+	`, accountID, targetID).Scan(&orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return orderID, true, nil
+}
+
+// UpdateOrderLedgerVerification 记录下单后核对订单详情的结果；只更新订单号一致的记录，没有记录时忽略。
+func (s *Store) UpdateOrderLedgerVerification(ctx context.Context, en model.OrderLedgerEntry) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE order_ledger SET order_status = ?, pay_deadline_ms = ?, verified_at = ?, verify_error = ?
+		WHERE account_id = ? AND target_id = ? AND order_id = ?
+	`, en.OrderStatus, en.PayDeadlineMs, en.VerifiedAtMs, en.VerifyError, en.AccountID, en.TargetID, en.OrderID)
+	return err
+}
+
+func (s *Store) DeleteOrderLedger(ctx context.Context, targetID string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM order_ledger WHERE target_id = ?`, targetID)
+	return err
+}
+
+// ListOrderLedger 返回目标上记录的所有成功订单（按下单时间排序）。
+func (s *Store) ListOrderLedger(ctx context.Context, targetID string) ([]model.OrderLedgerEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT account_id, target_id, order_id, created_at, order_status, pay_deadline_ms, verified_at, verify_error
+		FROM order_ledger WHERE target_id = ? ORDER BY created_at ASC
+	`, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.OrderLedgerEntry
+	for rows.Next() {
+		var en model.OrderLedgerEntry
+		if err := rows.Scan(&en.AccountID, &en.TargetID, &en.OrderID, &en.CreatedAtMs, &en.OrderStatus, &en.PayDeadlineMs, &en.VerifiedAtMs, &en.VerifyError); err != nil {
+			return nil, err
+		}
+		out = append(out, en)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+
+	"sniping_engine/internal/model"
+)
+
+// ordersMaxLimit 单次查询订单历史最多返回的条数。
+const ordersMaxLimit = 1000
+
+// InsertOrder 保存一笔下单成功的订单到订单历史。
+func (s *Store) InsertOrder(ctx context.Context, rec model.OrderRecord) error {
+	feeMismatch, verified := 0, 0
+	if rec.FeeMismatch {
+		feeMismatch = 1
+	}
+	if rec.OrderVerified {
+		verified = 1
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO orders (
+			attempt_id, run_id, target_id, target_name, mode, account_id, mobile,
+			item_id, sku_id, shop_id, quantity, order_id, trace_id,
+			expected_fee, actual_fee, fee_mismatch, order_verified, order_status, pay_deadline_ms, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		int64(rec.AttemptID), rec.RunID, rec.TargetID, rec.TargetName, rec.Mode, rec.AccountID, rec.Mobile,
+		rec.ItemID, rec.SKUID, rec.ShopID, rec.Quantity, rec.OrderID, rec.TraceID,
+		rec.ExpectedFee, rec.ActualFee, feeMismatch, verified, rec.OrderStatus, rec.PayDeadlineMs, rec.CreatedAtMs,
+	)
+	return err
+}
+
+// ListOrders 按下单时间倒序返回订单历史（Limit 默认 100，最多 ordersMaxLimit）。
+func (s *Store) ListOrders(ctx context.Context, q model.OrderQuery) ([]model.OrderRecord, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > ordersMaxLimit {
+		limit = ordersMaxLimit
+	}
+
+	var where []string
+	var args []any
+	if q.TargetID != "" {
+		where = append(where, "target_id = ?")
+		args = append(args, q.TargetID)
+	}
+	if q.AccountID != "" {
+		where = append(where, "account_id = ?")
+		args = append(args, q.AccountID)
+	}
+	if q.FromMs > 0 {
+		where = append(where, "created_at >= ?")
+		args = append(args, q.FromMs)
+	}
+	if q.ToMs > 0 {
+		where = append(where, "created_at < ?")
+		args = append(args, q.ToMs)
+	}
+	query := `
+		SELECT attempt_id, run_id, target_id, target_name, mode, account_id, mobile,
+			item_id, sku_id, shop_id, quantity, order_id, trace_id,
+			expected_fee, actual_fee, fee_mismatch, order_verified, order_status, pay_deadline_ms, created_at
+		FROM orders`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []model.OrderRecord{}
+	for rows.Next() {
+		var rec model.OrderRecord
+		var attemptID int64
+		var feeMismatch, verified int
+		if err := rows.Scan(
+			&attemptID, &rec.RunID, &rec.TargetID, &rec.TargetName, &rec.Mode, &rec.AccountID, &rec.Mobile,
+			&rec.ItemID, &rec.SKUID, &rec.ShopID, &rec.Quantity, &rec.OrderID, &rec.TraceID,
+			&rec.ExpectedFee, &rec.ActualFee, &feeMismatch, &verified, &rec.OrderStatus, &rec.PayDeadlineMs, &rec.CreatedAtMs,
+		); err != nil {
+			return nil, err
+		}
+		rec.AttemptID = uint64(attemptID)
+		rec.FeeMismatch = feeMismatch != 0
+		rec.OrderVerified = verified != 0
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
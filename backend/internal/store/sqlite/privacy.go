@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// PruneRetention 按保留期限删除早于截止时间的记录；天数为 0 的类别不清理。
+func (s *Store) PruneRetention(ctx context.Context, r model.RetentionSettings, now time.Time) (model.RetentionPruneResult, error) {
+	out := model.RetentionPruneResult{PrunedAtMs: now.UnixMilli()}
+	cutoff := func(days int) int64 {
+		return now.Add(-time.Duration(days) * 24 * time.Hour).UnixMilli()
+	}
+	steps := []struct {
+		days  int
+		query string
+		n     *int64
+	}{
+		{r.OrdersDays, `DELETE FROM orders WHERE created_at < ?`, &out.Orders},
+		{r.OrdersDays, `DELETE FROM order_ledger WHERE created_at < ?`, nil},
+		{r.AttemptsDays, `DELETE FROM attempts WHERE started_at < ?`, &out.Attempts},
+		{r.SlowRequestsDays, `DELETE FROM slow_requests WHERE at_ms < ?`, &out.SlowRequests},
+		{r.TokenLifetimesDays, `DELETE FROM token_lifetimes WHERE expired_at_ms < ?`, &out.TokenLifetimes},
+		{r.DailyPurchasesDays, `DELETE FROM account_daily_purchases WHERE updated_at < ?`, &out.DailyPurchases},
+	}
+	for _, st := range steps {
+		if st.days <= 0 {
+			continue
+		}
+		res, err := s.db.ExecContext(ctx, st.query, cutoff(st.days))
+		if err != nil {
+			return out, err
+		}
+		if st.n != nil {
+			*st.n, _ = res.RowsAffected()
+		}
+	}
+	return out, nil
+}
+
+// WipePrivateData 在一个事务里删除所有能关联到上游身份的数据（账号及其 token/cookie/手机号/地址、
+// 订单历史、尝试记录、慢请求追踪、出口探测），保留目标配置与各项设置；配置了外部凭据源时一并删除各账号的凭据，
+// 之后 VACUUM 回收已删除的页。
+func (s *Store) WipePrivateData(ctx context.Context) (model.PrivacyWipeResult, error) {
+	var out model.PrivacyWipeResult
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return out, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 外部凭据源按账号 ID 存储，删除账号前先记下 ID，提交后逐个删除。
+	var accountIDs []string
+	if s.creds != nil {
+		rows, err := tx.QueryContext(ctx, `SELECT id FROM accounts`)
+		if err != nil {
+			return out, err
+		}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				_ = rows.Close()
+				return out, err
+			}
+			accountIDs = append(accountIDs, id)
+		}
+		if err := rows.Close(); err != nil {
+			return out, err
+		}
+	}
+
+	steps := []struct {
+		table string
+		n     *int64
+	}{
+		{"accounts", &out.Accounts},
+		{"account_tokens", &out.AccountTokens},
+		{"token_lifetimes", &out.TokenLifetimes},
+		{"account_daily_purchases", &out.DailyPurchases},
+		{"order_ledger", &out.OrderLedger},
+		{"orders", &out.Orders},
+		{"attempts", &out.Attempts},
+		{"slow_requests", &out.SlowRequests},
+		{"egress_probes", &out.EgressProbes},
+	}
+	for _, st := range steps {
+		res, err := tx.ExecContext(ctx, `DELETE FROM `+st.table)
+		if err != nil {
+			return out, err
+		}
+		*st.n, _ = res.RowsAffected()
+	}
+	if err := tx.Commit(); err != nil {
+		return out, err
+	}
+	var credErrs []error
+	for _, id := range accountIDs {
+		s.forgetCredentials(id)
+		if err := s.creds.Delete(ctx, id); err != nil {
+			credErrs = append(credErrs, fmt.Errorf("delete credentials for account %s: %w", id, err))
+			continue
+		}
+		out.Credentials++
+	}
+	if err := errors.Join(credErrs...); err != nil {
+		return out, err
+	}
+	if _, err := s.db.ExecContext(ctx, `VACUUM`); err != nil {
+		return out, err
+	}
+	out.WipedAtMs = time.Now().UnixMilli()
+	return out, nil
+}
@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sniping_engine/internal/model"
+)
+
+// ErrProxyInUse 表示代理仍被账号引用，不能删除。
+var ErrProxyInUse = errors.New("proxy is still referenced by accounts")
+
+const proxyColumns = `id, name, url, created_at, updated_at, (SELECT COUNT(*) FROM accounts WHERE accounts.proxy_id = proxies.id)`
+
+func scanProxy(sc rowScanner) (model.ProxyEntry, error) {
+	var p model.ProxyEntry
+	var createdAt, updatedAt int64
+	if err := sc.Scan(&p.ID, &p.Name, &p.URL, &createdAt, &updatedAt, &p.Accounts); err != nil {
+		return model.ProxyEntry{}, err
+	}
+	p.CreatedAt = model.TimestampMs(createdAt)
+	p.UpdatedAt = model.TimestampMs(updatedAt)
+	return p, nil
+}
+
+// resolveProxy 对引用代理池的账号，把 Proxy 替换为代理池中的当前地址。
+func (s *Store) resolveProxy(ctx context.Context, acc model.Account) (model.Account, error) {
+	if acc.ProxyID == "" {
+		return acc, nil
+	}
+	var proxyURL string
+	err := s.db.QueryRowContext(ctx, `SELECT url FROM proxies WHERE id = ?`, acc.ProxyID).Scan(&proxyURL)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return acc, nil
+		}
+		return model.Account{}, err
+	}
+	acc.Proxy = proxyURL
+	return acc, nil
+}
+
+func (s *Store) UpsertProxy(ctx context.Context, p model.ProxyEntry) (model.ProxyEntry, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	p.URL = strings.TrimSpace(p.URL)
+	if err := model.ValidateProxyURL(p.URL); err != nil {
+		return model.ProxyEntry{}, err
+	}
+	if p.ID == "" {
+		p.ID = uuid.NewString()
+	}
+	now := time.Now()
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = model.Timestamp{Time: now}
+	}
+	p.UpdatedAt = model.Timestamp{Time: now}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO proxies (id, name, url, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			url = excluded.url,
+			updated_at = excluded.updated_at
+	`, p.ID, p.Name, p.URL, p.CreatedAt.UnixMilli(), p.UpdatedAt.UnixMilli())
+	if err != nil {
+		return model.ProxyEntry{}, err
+	}
+	return s.GetProxy(ctx, p.ID)
+}
+
+func (s *Store) GetProxy(ctx context.Context, id string) (model.ProxyEntry, error) {
+	return scanProxy(s.db.QueryRowContext(ctx, `SELECT `+proxyColumns+` FROM proxies WHERE id = ?`, id))
+}
+
+func (s *Store) ListProxies(ctx context.Context) ([]model.ProxyEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+proxyColumns+` FROM proxies ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.ProxyEntry
+	for rows.Next() {
+		p, err := scanProxy(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// DeleteProxy 删除代理；仍有账号引用时返回 ErrProxyInUse。
+func (s *Store) DeleteProxy(ctx context.Context, id string) error {
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM accounts WHERE proxy_id = ?`, id).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("%w (%d accounts)", ErrProxyInUse, n)
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM proxies WHERE id = ?`, id)
+	return err
+}
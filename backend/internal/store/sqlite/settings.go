@@ -15,6 +15,10 @@ const emailSettingsKey = "email_settings"
 const limitsSettingsKey = "limits_settings"
 const captchaPoolSettingsKey = "captcha_pool_settings"
 const notifySettingsKey = "notify_settings"
+const compatSettingsKey = "compat_settings"
+const catalogSettingsKey = "catalog_settings"
+const retentionSettingsKey = "retention_settings"
+const dropCalendarSettingsKey = "drop_calendar"
 
 func (s *Store) GetEmailSettings(ctx context.Context) (model.EmailSettings, bool, error) {
 	var row struct {
@@ -189,3 +193,163 @@ func (s *Store) UpsertNotifySettings(ctx context.Context, v model.NotifySettings
 	}
 	return v, nil
 }
+
+func (s *Store) GetCompatSettings(ctx context.Context) (model.CompatSettings, bool, error) {
+	var row struct {
+		valueJSON string
+		updatedAt int64
+	}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT value_json, updated_at FROM settings WHERE key = ?
+	`, compatSettingsKey).Scan(&row.valueJSON, &row.updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.CompatSettings{}, false, nil
+		}
+		return model.CompatSettings{}, false, err
+	}
+	var out model.CompatSettings
+	if err := json.Unmarshal([]byte(row.valueJSON), &out); err != nil {
+		return model.CompatSettings{}, false, err
+	}
+	return out, true, nil
+}
+
+func (s *Store) UpsertCompatSettings(ctx context.Context, v model.CompatSettings) (model.CompatSettings, error) {
+	now := time.Now().UnixMilli()
+	b, err := json.Marshal(v)
+	if err != nil {
+		return model.CompatSettings{}, err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO settings (key, value_json, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			value_json = excluded.value_json,
+			updated_at = excluded.updated_at
+	`, compatSettingsKey, string(b), now)
+	if err != nil {
+		return model.CompatSettings{}, err
+	}
+	return v, nil
+}
+
+func (s *Store) GetCatalogSettings(ctx context.Context) (model.CatalogSettings, bool, error) {
+	var row struct {
+		valueJSON string
+		updatedAt int64
+	}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT value_json, updated_at FROM settings WHERE key = ?
+	`, catalogSettingsKey).Scan(&row.valueJSON, &row.updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.CatalogSettings{}, false, nil
+		}
+		return model.CatalogSettings{}, false, err
+	}
+	var out model.CatalogSettings
+	if err := json.Unmarshal([]byte(row.valueJSON), &out); err != nil {
+		return model.CatalogSettings{}, false, err
+	}
+	return out, true, nil
+}
+
+func (s *Store) UpsertCatalogSettings(ctx context.Context, v model.CatalogSettings) (model.CatalogSettings, error) {
+	now := time.Now().UnixMilli()
+	b, err := json.Marshal(v)
+	if err != nil {
+		return model.CatalogSettings{}, err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO settings (key, value_json, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			value_json = excluded.value_json,
+			updated_at = excluded.updated_at
+	`, catalogSettingsKey, string(b), now)
+	if err != nil {
+		return model.CatalogSettings{}, err
+	}
+	return v, nil
+}
+
+func (s *Store) GetRetentionSettings(ctx context.Context) (model.RetentionSettings, bool, error) {
+	var row struct {
+		valueJSON string
+		updatedAt int64
+	}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT value_json, updated_at FROM settings WHERE key = ?
+	`, retentionSettingsKey).Scan(&row.valueJSON, &row.updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.RetentionSettings{}, false, nil
+		}
+		return model.RetentionSettings{}, false, err
+	}
+	var out model.RetentionSettings
+	if err := json.Unmarshal([]byte(row.valueJSON), &out); err != nil {
+		return model.RetentionSettings{}, false, err
+	}
+	return out, true, nil
+}
+
+func (s *Store) UpsertRetentionSettings(ctx context.Context, v model.RetentionSettings) (model.RetentionSettings, error) {
+	now := time.Now().UnixMilli()
+	b, err := json.Marshal(v)
+	if err != nil {
+		return model.RetentionSettings{}, err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO settings (key, value_json, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			value_json = excluded.value_json,
+			updated_at = excluded.updated_at
+	`, retentionSettingsKey, string(b), now)
+	if err != nil {
+		return model.RetentionSettings{}, err
+	}
+	return v, nil
+}
+
+func (s *Store) GetDropCalendar(ctx context.Context) (model.DropCalendar, bool, error) {
+	var row struct {
+		valueJSON string
+		updatedAt int64
+	}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT value_json, updated_at FROM settings WHERE key = ?
+	`, dropCalendarSettingsKey).Scan(&row.valueJSON, &row.updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.DropCalendar{}, false, nil
+		}
+		return model.DropCalendar{}, false, err
+	}
+	var out model.DropCalendar
+	if err := json.Unmarshal([]byte(row.valueJSON), &out); err != nil {
+		return model.DropCalendar{}, false, err
+	}
+	return out, true, nil
+}
+
+func (s *Store) UpsertDropCalendar(ctx context.Context, v model.DropCalendar) (model.DropCalendar, error) {
+	now := time.Now().UnixMilli()
+	b, err := json.Marshal(v)
+	if err != nil {
+		return model.DropCalendar{}, err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO settings (key, value_json, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			value_json = excluded.value_json,
+			updated_at = excluded.updated_at
+	`, dropCalendarSettingsKey, string(b), now)
+	if err != nil {
+		return model.DropCalendar{}, err
+	}
+	return v, nil
+}
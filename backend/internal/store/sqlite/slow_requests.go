@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+
+	"sniping_engine/internal/model"
+)
+
+// slowRequestsKeep 为 slow_requests 表保留的最近记录条数。
+const slowRequestsKeep = 1000
+
+// InsertSlowRequest 保存一条慢请求追踪，并只保留最近 slowRequestsKeep 条。
+func (s *Store) InsertSlowRequest(ctx context.Context, rec model.SlowRequest) error {
+	rec.ID = 0
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO slow_requests (at_ms, account_id, trace_json) VALUES (?, ?, ?)
+	`, rec.AtMs, rec.AccountID, string(raw)); err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		DELETE FROM slow_requests WHERE id <= (SELECT id FROM slow_requests ORDER BY id DESC LIMIT 1 OFFSET ?)
+	`, slowRequestsKeep)
+	return err
+}
+
+// ListSlowRequests 按时间倒序返回最近的慢请求；accountID 非空时只返回该账号的记录。
+func (s *Store) ListSlowRequests(ctx context.Context, accountID string, limit int) ([]model.SlowRequest, error) {
+	if limit <= 0 || limit > slowRequestsKeep {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, trace_json FROM slow_requests
+		WHERE ? = '' OR account_id = ?
+		ORDER BY id DESC LIMIT ?
+	`, accountID, accountID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []model.SlowRequest{}
+	for rows.Next() {
+		var id int64
+		var raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, err
+		}
+		var rec model.SlowRequest
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			continue
+		}
+		rec.ID = id
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
@@ -6,12 +6,23 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	_ "modernc.org/sqlite"
+
+	"sniping_engine/internal/credentials"
 )
 
 type Store struct {
-	db *sql.DB
+	db    *sql.DB
+	creds credentials.Source
+	// credsCache 外部来源里各账号凭据的短期内存副本，credsTTL 为 0 时按 credentialsCacheTTL，见 accounts.go。
+	credsMu    sync.Mutex
+	credsCache map[string]cachedCreds
+	credsTTL   time.Duration
+	// mobileCountry 手机号规范化使用的默认区号，空值表示 86。
+	mobileCountry string
 }
 
 func Open(ctx context.Context, path string) (*Store, error) {
@@ -48,4 +59,3 @@ func Open(ctx context.Context, path string) (*Store, error) {
 func (s *Store) Close() error {
 	return s.db.Close()
 }
-
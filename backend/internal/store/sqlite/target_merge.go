@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// FindDuplicateTargets 返回与 t 指向同一商品规格（itemId/skuId/shopId）的其它目标，见 model.Target.DuplicateOf。
+func (s *Store) FindDuplicateTargets(ctx context.Context, t model.Target) ([]model.Target, error) {
+	practice := 0
+	if t.Practice {
+		practice = 1
+	}
+	return s.queryTargets(ctx, `
+		SELECT `+targetColumns+`
+		FROM targets
+		WHERE item_id = ? AND sku_id = ? AND shop_id = ? AND practice = ? AND id != ?
+		ORDER BY created_at ASC
+	`, t.ItemID, t.SKUID, t.ShopID, practice, strings.TrimSpace(t.ID))
+}
+
+// ErrDuplicateTarget 表示按 block 策略拒绝保存会与已有目标重复的目标。
+var ErrDuplicateTarget = errors.New("another target already points at this sku")
+
+// CheckDuplicateTarget 返回保存 next 会新产生的重复目标；current 为保存前的目标（新建时为零值）。
+// 已有的重复目标仍可编辑，只有新建或改了商品规格、演练开关才算新产生重复，合并后消除。
+// block 为 true 且有重复时同时返回 ErrDuplicateTarget，调用方不应保存。
+func (s *Store) CheckDuplicateTarget(ctx context.Context, current, next model.Target, block bool) ([]model.Target, error) {
+	if current.ID != "" && current.SKUKey() == next.SKUKey() && current.Practice == next.Practice {
+		return nil, nil
+	}
+	duplicates, err := s.FindDuplicateTargets(ctx, next)
+	if err != nil || len(duplicates) == 0 {
+		return nil, err
+	}
+	if block {
+		return duplicates, ErrDuplicateTarget
+	}
+	return duplicates, nil
+}
+
+// MergeTargets 在同一个事务里把 sourceIDs 合并进 keepID：目标数量与已购数量相加，尝试、订单与下单记录
+// 改挂到保留的目标上（同一账号在两边都有下单记录时保留原记录），然后删除被合并的目标。
+// 所有目标必须指向同一商品规格，且同为真实目标或同为演练目标（见 model.Target.DuplicateOf）。
+func (s *Store) MergeTargets(ctx context.Context, keepID string, sourceIDs []string) (model.TargetMergeResult, error) {
+	keepID = strings.TrimSpace(keepID)
+	if keepID == "" {
+		return model.TargetMergeResult{}, errors.New("target id is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.TargetMergeResult{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	keep, err := scanTarget(tx.QueryRowContext(ctx, `SELECT `+targetColumns+` FROM targets WHERE id = ?`, keepID))
+	if err != nil {
+		return model.TargetMergeResult{}, fmt.Errorf("target %s: %w", keepID, err)
+	}
+
+	out := model.TargetMergeResult{MergedIDs: make([]string, 0, len(sourceIDs))}
+	seen := map[string]bool{keepID: true}
+	qty := keep.TargetQty
+	for _, raw := range sourceIDs {
+		id := strings.TrimSpace(raw)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		src, err := scanTarget(tx.QueryRowContext(ctx, `SELECT `+targetColumns+` FROM targets WHERE id = ?`, id))
+		if err != nil {
+			return model.TargetMergeResult{}, fmt.Errorf("target %s: %w", id, err)
+		}
+		if !src.DuplicateOf(keep) {
+			return model.TargetMergeResult{}, fmt.Errorf("target %s: %w", id, model.ErrTargetMergeMismatch)
+		}
+		qty += src.TargetQty
+
+		var purchased int
+		if err := tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(purchased_qty), 0) FROM task_states WHERE target_id = ?`, id).Scan(&purchased); err != nil {
+			return model.TargetMergeResult{}, err
+		}
+		out.PurchasedQty += purchased
+
+		res, err := tx.ExecContext(ctx, `
+			UPDATE attempts SET target_id = ?, result_json = json_set(result_json, '$.targetId', ?) WHERE target_id = ?
+		`, keepID, keepID, id)
+		if err != nil {
+			return model.TargetMergeResult{}, err
+		}
+		n, _ := res.RowsAffected()
+		out.Attempts += n
+
+		res, err = tx.ExecContext(ctx, `UPDATE orders SET target_id = ?, target_name = ? WHERE target_id = ?`, keepID, keep.Name, id)
+		if err != nil {
+			return model.TargetMergeResult{}, err
+		}
+		n, _ = res.RowsAffected()
+		out.Orders += n
+
+		if _, err := tx.ExecContext(ctx, `UPDATE OR IGNORE order_ledger SET target_id = ? WHERE target_id = ?`, keepID, id); err != nil {
+			return model.TargetMergeResult{}, err
+		}
+		for _, stmt := range []string{
+			`DELETE FROM order_ledger WHERE target_id = ?`,
+			`DELETE FROM task_states WHERE target_id = ?`,
+			`DELETE FROM targets WHERE id = ?`,
+		} {
+			if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
+				return model.TargetMergeResult{}, err
+			}
+		}
+		out.MergedIDs = append(out.MergedIDs, id)
+	}
+	if len(out.MergedIDs) == 0 {
+		return model.TargetMergeResult{}, errors.New("sourceIds is required")
+	}
+
+	now := time.Now().UnixMilli()
+	if _, err := tx.ExecContext(ctx, `UPDATE targets SET target_qty = ?, updated_at = ? WHERE id = ?`, qty, now, keepID); err != nil {
+		return model.TargetMergeResult{}, err
+	}
+	if out.PurchasedQty > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO task_states (target_id, purchased_qty, updated_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT(target_id) DO UPDATE SET
+				purchased_qty = task_states.purchased_qty + excluded.purchased_qty,
+				updated_at = excluded.updated_at
+		`, keepID, out.PurchasedQty, now); err != nil {
+			return model.TargetMergeResult{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return model.TargetMergeResult{}, err
+	}
+
+	out.Target, err = s.GetTarget(ctx, keepID)
+	if err != nil {
+		return model.TargetMergeResult{}, err
+	}
+	return out, nil
+}
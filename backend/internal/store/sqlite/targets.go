@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,7 +13,102 @@ import (
 	"sniping_engine/internal/model"
 )
 
-func (s *Store) UpsertTarget(ctx context.Context, t model.Target) (model.Target, error) {
+const targetColumns = `id, name, image_url, item_id, sku_id, shop_id, category_id, mode, target_qty, per_order_qty, rush_at_ms, rush_lead_ms, captcha_verify_param, order_source, device_source, captcha_override, allow_multi_per_account, min_stock, max_total_fee, prerender_seconds, env, campaign_id, qps, burst, max_in_flight, max_attempts, extra_lines_json, practice, notify_json, arm_at_ms, enabled, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTarget(sc rowScanner) (model.Target, error) {
+	var row struct {
+		id                 string
+		name               string
+		imageURL           string
+		itemID             int64
+		skuID              int64
+		shopID             int64
+		categoryID         int64
+		mode               string
+		targetQty          int
+		perOrderQty        int
+		rushAtMs           int64
+		rushLeadMs         int64
+		captchaVerifyParam string
+		orderSource        string
+		deviceSource       string
+		captchaOverride    string
+		allowMulti         int
+		minStock           int64
+		maxTotalFee        int64
+		prerenderSeconds   int
+		env                string
+		campaignID         string
+		qps                float64
+		burst              int
+		maxInFlight        int
+		maxAttempts        int
+		extraLines         string
+		practice           int
+		notify             string
+		armAtMs            int64
+		enabled            int
+		createdAt          int64
+		updatedAt          int64
+	}
+	if err := sc.Scan(&row.id, &row.name, &row.imageURL, &row.itemID, &row.skuID, &row.shopID, &row.categoryID, &row.mode, &row.targetQty, &row.perOrderQty, &row.rushAtMs, &row.rushLeadMs, &row.captchaVerifyParam, &row.orderSource, &row.deviceSource, &row.captchaOverride, &row.allowMulti, &row.minStock, &row.maxTotalFee, &row.prerenderSeconds, &row.env, &row.campaignID, &row.qps, &row.burst, &row.maxInFlight, &row.maxAttempts, &row.extraLines, &row.practice, &row.notify, &row.armAtMs, &row.enabled, &row.createdAt, &row.updatedAt); err != nil {
+		return model.Target{}, err
+	}
+	var extraLines []model.OrderLine
+	if err := json.Unmarshal([]byte(row.extraLines), &extraLines); err != nil || len(extraLines) == 0 {
+		extraLines = nil
+	}
+	var notify *model.TargetNotify
+	if row.notify != "" {
+		notify = &model.TargetNotify{}
+		if err := json.Unmarshal([]byte(row.notify), notify); err != nil {
+			notify = nil
+		}
+	}
+	return model.Target{
+		ID:                 row.id,
+		Name:               row.name,
+		ImageURL:           row.imageURL,
+		ItemID:             row.itemID,
+		SKUID:              row.skuID,
+		ShopID:             row.shopID,
+		CategoryID:         row.categoryID,
+		Mode:               model.TargetMode(row.mode),
+		TargetQty:          row.targetQty,
+		PerOrderQty:        row.perOrderQty,
+		RushAtMs:           row.rushAtMs,
+		RushLeadMs:         row.rushLeadMs,
+		CaptchaVerifyParam: row.captchaVerifyParam,
+		OrderSource:        row.orderSource,
+		DeviceSource:       row.deviceSource,
+		CaptchaOverride:    row.captchaOverride,
+		Enabled:            row.enabled == 1,
+
+		AllowMultiplePerAccount: row.allowMulti == 1,
+		MinStock:                row.minStock,
+		MaxTotalFee:             row.maxTotalFee,
+		PrerenderSeconds:        row.prerenderSeconds,
+		Env:                     row.env,
+		CampaignID:              row.campaignID,
+		QPS:                     row.qps,
+		Burst:                   row.burst,
+		MaxInFlight:             row.maxInFlight,
+		MaxAttempts:             row.maxAttempts,
+		ExtraLines:              extraLines,
+		Practice:                row.practice == 1,
+		Notify:                  notify,
+		ArmAtMs:                 row.armAtMs,
+		CreatedAt:               model.TimestampMs(row.createdAt),
+		UpdatedAt:               model.TimestampMs(row.updatedAt),
+	}, nil
+}
+
+// normalizeTarget 校验目标并补齐默认值（不写库），UpsertTarget 与导入预检共用。
+func (s *Store) normalizeTarget(ctx context.Context, t model.Target) (model.Target, error) {
 	if t.Mode != model.TargetModeRush && t.Mode != model.TargetModeScan {
 		return model.Target{}, fmt.Errorf("invalid mode: %s", t.Mode)
 	}
@@ -28,38 +124,139 @@ func (s *Store) UpsertTarget(ctx context.Context, t model.Target) (model.Target,
 	if t.RushLeadMs <= 0 {
 		t.RushLeadMs = 500
 	}
+	if t.CategoryID < 0 {
+		t.CategoryID = 0
+	}
+	if t.MinStock < 0 {
+		t.MinStock = 0
+	}
+	if t.MaxTotalFee < 0 {
+		t.MaxTotalFee = 0
+	}
+	if t.ArmAtMs < 0 {
+		t.ArmAtMs = 0
+	}
+	if err := t.ValidateArmAt(); err != nil {
+		return model.Target{}, err
+	}
+	if err := model.ValidatePrerenderSeconds(t.PrerenderSeconds); err != nil {
+		return model.Target{}, err
+	}
+	if err := t.ValidateTargetLimits(); err != nil {
+		return model.Target{}, err
+	}
+	if err := t.ValidateExtraLines(); err != nil {
+		return model.Target{}, err
+	}
+	if t.ExtraLines == nil {
+		t.ExtraLines = []model.OrderLine{}
+	}
+	env, err := model.NormalizeEnv(t.Env)
+	if err != nil {
+		return model.Target{}, err
+	}
+	t.Env = env
+	t.CampaignID = strings.TrimSpace(t.CampaignID)
+	if t.CampaignID != "" {
+		if _, err := s.GetCampaign(ctx, t.CampaignID); err != nil {
+			return model.Target{}, fmt.Errorf("campaign not found: %s", t.CampaignID)
+		}
+	}
+	t.OrderSource = strings.TrimSpace(t.OrderSource)
+	t.DeviceSource = strings.TrimSpace(t.DeviceSource)
+	if err := model.ValidateTradeSources(t.OrderSource, t.DeviceSource); err != nil {
+		return model.Target{}, err
+	}
+	t.CaptchaOverride = strings.TrimSpace(t.CaptchaOverride)
+	if err := model.ValidateCaptchaOverride(t.CaptchaOverride); err != nil {
+		return model.Target{}, err
+	}
+	if err := t.Notify.Normalize(); err != nil {
+		return model.Target{}, err
+	}
+	if t.Enabled {
+		if err := t.ValidateForRun(); err != nil {
+			return model.Target{}, err
+		}
+	}
+	return t, nil
+}
+
+func (s *Store) UpsertTarget(ctx context.Context, t model.Target) (model.Target, error) {
+	t, err := s.normalizeTarget(ctx, t)
+	if err != nil {
+		return model.Target{}, err
+	}
+	extraLines, err := json.Marshal(t.ExtraLines)
+	if err != nil {
+		return model.Target{}, err
+	}
+	notify := ""
+	if t.Notify != nil {
+		raw, err := json.Marshal(t.Notify)
+		if err != nil {
+			return model.Target{}, err
+		}
+		notify = string(raw)
+	}
 	if t.ID == "" {
 		t.ID = uuid.NewString()
 	}
 	now := time.Now()
 	if t.CreatedAt.IsZero() {
-		t.CreatedAt = now
+		t.CreatedAt = model.Timestamp{Time: now}
 	}
-	t.UpdatedAt = now
+	t.UpdatedAt = model.Timestamp{Time: now}
 
 	enabled := 0
 	if t.Enabled {
 		enabled = 1
 	}
+	allowMulti := 0
+	if t.AllowMultiplePerAccount {
+		allowMulti = 1
+	}
+	practice := 0
+	if t.Practice {
+		practice = 1
+	}
 
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO targets (id, name, image_url, item_id, sku_id, shop_id, mode, target_qty, per_order_qty, rush_at_ms, rush_lead_ms, captcha_verify_param, enabled, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO targets (`+targetColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			name = excluded.name,
 			image_url = excluded.image_url,
 			item_id = excluded.item_id,
 			sku_id = excluded.sku_id,
 			shop_id = excluded.shop_id,
+			category_id = excluded.category_id,
 			mode = excluded.mode,
 			target_qty = excluded.target_qty,
 			per_order_qty = excluded.per_order_qty,
 			rush_at_ms = excluded.rush_at_ms,
 			rush_lead_ms = excluded.rush_lead_ms,
 			captcha_verify_param = excluded.captcha_verify_param,
+			order_source = excluded.order_source,
+			device_source = excluded.device_source,
+			captcha_override = excluded.captcha_override,
+			allow_multi_per_account = excluded.allow_multi_per_account,
+			min_stock = excluded.min_stock,
+			max_total_fee = excluded.max_total_fee,
+			prerender_seconds = excluded.prerender_seconds,
+			env = excluded.env,
+			campaign_id = excluded.campaign_id,
+			qps = excluded.qps,
+			burst = excluded.burst,
+			max_in_flight = excluded.max_in_flight,
+			max_attempts = excluded.max_attempts,
+			extra_lines_json = excluded.extra_lines_json,
+			practice = excluded.practice,
+			notify_json = excluded.notify_json,
+			arm_at_ms = excluded.arm_at_ms,
 			enabled = excluded.enabled,
 			updated_at = excluded.updated_at
-	`, t.ID, t.Name, t.ImageURL, t.ItemID, t.SKUID, t.ShopID, string(t.Mode), t.TargetQty, t.PerOrderQty, t.RushAtMs, t.RushLeadMs, t.CaptchaVerifyParam, enabled, t.CreatedAt.UnixMilli(), t.UpdatedAt.UnixMilli())
+	`, t.ID, t.Name, t.ImageURL, t.ItemID, t.SKUID, t.ShopID, t.CategoryID, string(t.Mode), t.TargetQty, t.PerOrderQty, t.RushAtMs, t.RushLeadMs, t.CaptchaVerifyParam, t.OrderSource, t.DeviceSource, t.CaptchaOverride, allowMulti, t.MinStock, t.MaxTotalFee, t.PrerenderSeconds, t.Env, t.CampaignID, t.QPS, t.Burst, t.MaxInFlight, t.MaxAttempts, string(extraLines), practice, notify, t.ArmAtMs, enabled, t.CreatedAt.UnixMilli(), t.UpdatedAt.UnixMilli())
 	if err != nil {
 		return model.Target{}, err
 	}
@@ -67,110 +264,28 @@ func (s *Store) UpsertTarget(ctx context.Context, t model.Target) (model.Target,
 }
 
 func (s *Store) GetTarget(ctx context.Context, id string) (model.Target, error) {
-	var row struct {
-		id                 string
-		name               string
-		imageURL           string
-		itemID             int64
-		skuID              int64
-		shopID             int64
-		mode               string
-		targetQty          int
-		perOrderQty        int
-		rushAtMs           int64
-		rushLeadMs         int64
-		captchaVerifyParam string
-		enabled            int
-		createdAt          int64
-		updatedAt          int64
-	}
-	err := s.db.QueryRowContext(ctx, `
-		SELECT id, name, image_url, item_id, sku_id, shop_id, mode, target_qty, per_order_qty, rush_at_ms, rush_lead_ms, captcha_verify_param, enabled, created_at, updated_at
+	return scanTarget(s.db.QueryRowContext(ctx, `
+		SELECT `+targetColumns+`
 		FROM targets WHERE id = ?
-	`, id).Scan(&row.id, &row.name, &row.imageURL, &row.itemID, &row.skuID, &row.shopID, &row.mode, &row.targetQty, &row.perOrderQty, &row.rushAtMs, &row.rushLeadMs, &row.captchaVerifyParam, &row.enabled, &row.createdAt, &row.updatedAt)
-	if err != nil {
-		return model.Target{}, err
-	}
-	return model.Target{
-		ID:                 row.id,
-		Name:               row.name,
-		ImageURL:           row.imageURL,
-		ItemID:             row.itemID,
-		SKUID:              row.skuID,
-		ShopID:             row.shopID,
-		Mode:               model.TargetMode(row.mode),
-		TargetQty:          row.targetQty,
-		PerOrderQty:        row.perOrderQty,
-		RushAtMs:           row.rushAtMs,
-		RushLeadMs:         row.rushLeadMs,
-		CaptchaVerifyParam: row.captchaVerifyParam,
-		Enabled:            row.enabled == 1,
-		CreatedAt:          time.UnixMilli(row.createdAt),
-		UpdatedAt:          time.UnixMilli(row.updatedAt),
-	}, nil
+	`, id))
 }
 
 func (s *Store) ListTargets(ctx context.Context) ([]model.Target, error) {
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, name, image_url, item_id, sku_id, shop_id, mode, target_qty, per_order_qty, rush_at_ms, rush_lead_ms, captcha_verify_param, enabled, created_at, updated_at
+	return s.queryTargets(ctx, `
+		SELECT `+targetColumns+`
 		FROM targets ORDER BY updated_at DESC
 	`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var out []model.Target
-	for rows.Next() {
-		var row struct {
-			id                 string
-			name               string
-			imageURL           string
-			itemID             int64
-			skuID              int64
-			shopID             int64
-			mode               string
-			targetQty          int
-			perOrderQty        int
-			rushAtMs           int64
-			rushLeadMs         int64
-			captchaVerifyParam string
-			enabled            int
-			createdAt          int64
-			updatedAt          int64
-		}
-		if err := rows.Scan(&row.id, &row.name, &row.imageURL, &row.itemID, &row.skuID, &row.shopID, &row.mode, &row.targetQty, &row.perOrderQty, &row.rushAtMs, &row.rushLeadMs, &row.captchaVerifyParam, &row.enabled, &row.createdAt, &row.updatedAt); err != nil {
-			return nil, err
-		}
-		out = append(out, model.Target{
-			ID:                 row.id,
-			Name:               row.name,
-			ImageURL:           row.imageURL,
-			ItemID:             row.itemID,
-			SKUID:              row.skuID,
-			ShopID:             row.shopID,
-			Mode:               model.TargetMode(row.mode),
-			TargetQty:          row.targetQty,
-			PerOrderQty:        row.perOrderQty,
-			RushAtMs:           row.rushAtMs,
-			RushLeadMs:         row.rushLeadMs,
-			CaptchaVerifyParam: row.captchaVerifyParam,
-			Enabled:            row.enabled == 1,
-			CreatedAt:          time.UnixMilli(row.createdAt),
-			UpdatedAt:          time.UnixMilli(row.updatedAt),
-		})
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return out, nil
 }
 
 func (s *Store) ListEnabledTargets(ctx context.Context) ([]model.Target, error) {
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, name, image_url, item_id, sku_id, shop_id, mode, target_qty, per_order_qty, rush_at_ms, rush_lead_ms, captcha_verify_param, enabled, created_at, updated_at
+	return s.queryTargets(ctx, `
+		SELECT `+targetColumns+`
 		FROM targets WHERE enabled = 1 ORDER BY updated_at DESC
 	`)
+}
+
+func (s *Store) queryTargets(ctx context.Context, query string, args ...any) ([]model.Target, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -178,43 +293,11 @@ func (s *Store) ListEnabledTargets(ctx context.Context) ([]model.Target, error)
 
 	var out []model.Target
 	for rows.Next() {
-		var row struct {
-			id                 string
-			name               string
-			imageURL           string
-			itemID             int64
-			skuID              int64
-			shopID             int64
-			mode               string
-			targetQty          int
-			perOrderQty        int
-			rushAtMs           int64
-			rushLeadMs         int64
-			captchaVerifyParam string
-			enabled            int
-			createdAt          int64
-			updatedAt          int64
-		}
-		if err := rows.Scan(&row.id, &row.name, &row.imageURL, &row.itemID, &row.skuID, &row.shopID, &row.mode, &row.targetQty, &row.perOrderQty, &row.rushAtMs, &row.rushLeadMs, &row.captchaVerifyParam, &row.enabled, &row.createdAt, &row.updatedAt); err != nil {
+		t, err := scanTarget(rows)
+		if err != nil {
 			return nil, err
 		}
-		out = append(out, model.Target{
-			ID:                 row.id,
-			Name:               row.name,
-			ImageURL:           row.imageURL,
-			ItemID:             row.itemID,
-			SKUID:              row.skuID,
-			ShopID:             row.shopID,
-			Mode:               model.TargetMode(row.mode),
-			TargetQty:          row.targetQty,
-			PerOrderQty:        row.perOrderQty,
-			RushAtMs:           row.rushAtMs,
-			RushLeadMs:         row.rushLeadMs,
-			CaptchaVerifyParam: row.captchaVerifyParam,
-			Enabled:            row.enabled == 1,
-			CreatedAt:          time.UnixMilli(row.createdAt),
-			UpdatedAt:          time.UnixMilli(row.updatedAt),
-		})
+		out = append(out, t)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -223,8 +306,13 @@ func (s *Store) ListEnabledTargets(ctx context.Context) ([]model.Target, error)
 }
 
 func (s *Store) DeleteTarget(ctx context.Context, id string) error {
-	_, err := s.db.ExecContext(ctx, `DELETE FROM targets WHERE id = ?`, id)
-	return err
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM targets WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if err := s.DeleteOrderLedger(ctx, id); err != nil {
+		return err
+	}
+	return s.DeleteTaskState(ctx, id)
 }
 
 func (s *Store) SetTargetEnabled(ctx context.Context, id string, enabled bool) error {
@@ -234,10 +322,128 @@ func (s *Store) SetTargetEnabled(ctx context.Context, id string, enabled bool) e
 	v := 0
 	if enabled {
 		v = 1
+		t, err := s.GetTarget(ctx, strings.TrimSpace(id))
+		if err != nil {
+			return err
+		}
+		if err := t.ValidateForRun(); err != nil {
+			return err
+		}
 	}
 	now := time.Now().UnixMilli()
+	// 手动启用即立刻生效，清掉尚未到达的定时启用（armAtMs）；停用时保留，到点仍会自动启用。
 	_, err := s.db.ExecContext(ctx, `
-		UPDATE targets SET enabled = ?, updated_at = ? WHERE id = ?
-	`, v, now, strings.TrimSpace(id))
+		UPDATE targets SET enabled = ?, arm_at_ms = CASE WHEN ? = 1 THEN 0 ELSE arm_at_ms END, updated_at = ? WHERE id = ?
+	`, v, v, now, strings.TrimSpace(id))
 	return err
 }
+
+// ListArmDueTargets 返回定时启用时间（armAtMs）已到的目标。
+func (s *Store) ListArmDueTargets(ctx context.Context, nowMs int64) ([]model.Target, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+targetColumns+`
+		FROM targets
+		WHERE arm_at_ms > 0 AND arm_at_ms <= ?
+		ORDER BY arm_at_ms ASC
+	`, nowMs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.Target
+	for rows.Next() {
+		t, err := scanTarget(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// ClearTargetArm 清除目标的定时启用时间（启用失败时调用，避免每轮重复尝试）。
+func (s *Store) ClearTargetArm(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE targets SET arm_at_ms = 0, updated_at = ? WHERE id = ?
+	`, time.Now().UnixMilli(), strings.TrimSpace(id))
+	return err
+}
+
+// TargetBulkPatch 描述一次批量修改；nil/0 的字段保持不变。
+type TargetBulkPatch struct {
+	Enabled       *bool
+	Mode          *model.TargetMode
+	RushAtDeltaMs int64
+}
+
+// BulkUpdateTargets 在同一个事务里对多个目标应用 patch；任一目标失败则全部回滚。
+func (s *Store) BulkUpdateTargets(ctx context.Context, ids []string, patch TargetBulkPatch) ([]model.Target, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("ids is required")
+	}
+	if patch.Mode != nil && *patch.Mode != model.TargetModeRush && *patch.Mode != model.TargetModeScan {
+		return nil, fmt.Errorf("invalid mode: %s", *patch.Mode)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now()
+	seen := make(map[string]bool, len(ids))
+	out := make([]model.Target, 0, len(ids))
+	for _, raw := range ids {
+		id := strings.TrimSpace(raw)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		t, err := scanTarget(tx.QueryRowContext(ctx, `SELECT `+targetColumns+` FROM targets WHERE id = ?`, id))
+		if err != nil {
+			return nil, fmt.Errorf("target %s: %w", id, err)
+		}
+		if patch.Enabled != nil {
+			t.Enabled = *patch.Enabled
+			if t.Enabled {
+				t.ArmAtMs = 0
+			}
+		}
+		if patch.Mode != nil {
+			t.Mode = *patch.Mode
+		}
+		if patch.RushAtDeltaMs != 0 && t.RushAtMs > 0 {
+			t.RushAtMs += patch.RushAtDeltaMs
+			if t.RushAtMs <= 0 {
+				return nil, fmt.Errorf("target %s: shifted rushAtMs must be > 0", id)
+			}
+			if t.ArmAtMs > 0 {
+				t.ArmAtMs = max(t.ArmAtMs+patch.RushAtDeltaMs, 1)
+			}
+		}
+		if t.Enabled {
+			if err := t.ValidateForRun(); err != nil {
+				return nil, fmt.Errorf("target %s: %w", id, err)
+			}
+		}
+		t.UpdatedAt = model.Timestamp{Time: now}
+
+		enabled := 0
+		if t.Enabled {
+			enabled = 1
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE targets SET enabled = ?, mode = ?, rush_at_ms = ?, arm_at_ms = ?, updated_at = ? WHERE id = ?
+		`, enabled, string(t.Mode), t.RushAtMs, t.ArmAtMs, now.UnixMilli(), id); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
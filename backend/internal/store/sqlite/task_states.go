@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+// UpsertTaskStates 批量保存任务进度（已购数量、最近错误/尝试/成功时间、最近观察到的是否需要验证码），进程重启后据此恢复，避免超买。
+func (s *Store) UpsertTaskStates(ctx context.Context, states []model.TaskState) error {
+	if len(states) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now().UnixMilli()
+	for _, st := range states {
+		if st.TargetID == "" {
+			continue
+		}
+		var needCaptcha sql.NullInt64
+		if st.NeedCaptcha != nil {
+			needCaptcha.Valid = true
+			if *st.NeedCaptcha {
+				needCaptcha.Int64 = 1
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO task_states (target_id, purchased_qty, last_error, last_attempt_ms, last_success_ms, need_captcha, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(target_id) DO UPDATE SET
+				purchased_qty = excluded.purchased_qty,
+				last_error = excluded.last_error,
+				last_attempt_ms = excluded.last_attempt_ms,
+				last_success_ms = excluded.last_success_ms,
+				need_captcha = COALESCE(excluded.need_captcha, task_states.need_captcha),
+				updated_at = excluded.updated_at
+		`, st.TargetID, st.PurchasedQty, st.LastError, st.LastAttemptMs, st.LastSuccessMs, needCaptcha, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListTaskStates 返回已保存的任务进度；只包含持久化的字段，其余字段为零值。
+func (s *Store) ListTaskStates(ctx context.Context) ([]model.TaskState, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT target_id, purchased_qty, last_error, last_attempt_ms, last_success_ms, need_captcha
+		FROM task_states
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.TaskState
+	for rows.Next() {
+		var st model.TaskState
+		var needCaptcha sql.NullInt64
+		if err := rows.Scan(&st.TargetID, &st.PurchasedQty, &st.LastError, &st.LastAttemptMs, &st.LastSuccessMs, &needCaptcha); err != nil {
+			return nil, err
+		}
+		if needCaptcha.Valid {
+			v := needCaptcha.Int64 == 1
+			st.NeedCaptcha = &v
+		}
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ClearTaskStates(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM task_states`)
+	return err
+}
+
+func (s *Store) DeleteTaskState(ctx context.Context, targetID string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM task_states WHERE target_id = ?`, targetID)
+	return err
+}
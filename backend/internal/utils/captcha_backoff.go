@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// 验证码求解失败后的退避：按“账号 + 场景”分别计算，指数增长并封顶，
+// 避免求解持续失败时每个 tick 都重新发起完整求解、打爆求解服务。
+const (
+	CaptchaSceneOrder = "order"
+	CaptchaScenePool  = "pool"
+
+	captchaBackoffBase = time.Second
+	captchaBackoffMax  = 30 * time.Second
+)
+
+type CaptchaBackoffEntry struct {
+	AccountID   string `json:"accountId,omitempty"`
+	Scene       string `json:"scene"`
+	Failures    int    `json:"failures"`
+	UntilMs     int64  `json:"untilMs"`
+	LastError   string `json:"lastError,omitempty"`
+	LastFailMs  int64  `json:"lastFailMs"`
+	RemainingMs int64  `json:"remainingMs"`
+}
+
+type captchaBackoffKey struct {
+	accountID string
+	scene     string
+}
+
+var (
+	captchaBackoffMu sync.Mutex
+	captchaBackoffs  = make(map[captchaBackoffKey]*CaptchaBackoffEntry)
+)
+
+// CaptchaBackoffRemaining 返回该账号/场景还需要等待多久才能再次求解；0 表示可以立即求解。
+func CaptchaBackoffRemaining(accountID, scene string) time.Duration {
+	captchaBackoffMu.Lock()
+	defer captchaBackoffMu.Unlock()
+	st := captchaBackoffs[captchaBackoffKey{accountID: accountID, scene: scene}]
+	if st == nil {
+		return 0
+	}
+	left := st.UntilMs - time.Now().UnixMilli()
+	if left <= 0 {
+		return 0
+	}
+	return time.Duration(left) * time.Millisecond
+}
+
+// CaptchaBackoffFail 记录一次求解失败并返回新的退避时长。
+func CaptchaBackoffFail(accountID, scene string, err error) time.Duration {
+	captchaBackoffMu.Lock()
+	defer captchaBackoffMu.Unlock()
+	key := captchaBackoffKey{accountID: accountID, scene: scene}
+	st := captchaBackoffs[key]
+	if st == nil {
+		st = &CaptchaBackoffEntry{AccountID: accountID, Scene: scene}
+		captchaBackoffs[key] = st
+	}
+	st.Failures++
+
+	wait := captchaBackoffBase
+	for i := 1; i < st.Failures && wait < captchaBackoffMax; i++ {
+		wait *= 2
+	}
+	if wait > captchaBackoffMax {
+		wait = captchaBackoffMax
+	}
+
+	nowMs := time.Now().UnixMilli()
+	st.LastFailMs = nowMs
+	st.UntilMs = nowMs + wait.Milliseconds()
+	st.LastError = ""
+	if err != nil {
+		st.LastError = err.Error()
+	}
+	return wait
+}
+
+// CaptchaBackoffReset 求解成功后清除退避状态。
+func CaptchaBackoffReset(accountID, scene string) {
+	captchaBackoffMu.Lock()
+	delete(captchaBackoffs, captchaBackoffKey{accountID: accountID, scene: scene})
+	captchaBackoffMu.Unlock()
+}
+
+// GetCaptchaBackoffStatus 返回当前所有退避记录（按结束时间排序）。
+func GetCaptchaBackoffStatus() []CaptchaBackoffEntry {
+	nowMs := time.Now().UnixMilli()
+	captchaBackoffMu.Lock()
+	out := make([]CaptchaBackoffEntry, 0, len(captchaBackoffs))
+	for _, st := range captchaBackoffs {
+		v := *st
+		if v.UntilMs > nowMs {
+			v.RemainingMs = v.UntilMs - nowMs
+		}
+		out = append(out, v)
+	}
+	captchaBackoffMu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].UntilMs < out[j].UntilMs })
+	return out
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrCaptchaSolvesInFlight 表示仍有求解占用名额，未要求 drain 时拒绝停止引擎。
+var ErrCaptchaSolvesInFlight = errors.New("captcha solves in flight")
+
+// ErrCaptchaEngineStarting 表示引擎正在预热，此时不允许停止。
+var ErrCaptchaEngineStarting = errors.New("captcha engine is starting")
+
+// StopCaptchaEngine 关闭无头浏览器和全部页面以释放内存，引擎状态置为“已停止”。
+// 有求解在进行时：drain=false 返回 ErrCaptchaSolvesInFlight；drain=true 先中止所有求解（同 StopAllCaptchaFetching），
+// 等待名额全部归还（受 ctx 约束）后再关闭。
+// 停止后新的求解请求（如开抢前的验证码池维护）仍会按需重新拉起浏览器。
+func StopCaptchaEngine(ctx context.Context, drain bool) (CaptchaEngineStatus, error) {
+	captchaWarmupMu.Lock()
+	starting := captchaWarmupRunning
+	captchaWarmupMu.Unlock()
+	if starting || GetCaptchaEngineStatus().State == CaptchaEngineStateStarting {
+		return GetCaptchaEngineStatus(), ErrCaptchaEngineStarting
+	}
+
+	if GetCaptchaQueueStatus().Busy > 0 {
+		if !drain {
+			return GetCaptchaEngineStatus(), ErrCaptchaSolvesInFlight
+		}
+		StopAllCaptchaFetching()
+		ticker := time.NewTicker(50 * time.Millisecond)
+		defer ticker.Stop()
+		for GetCaptchaQueueStatus().Busy > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return GetCaptchaEngineStatus(), ctx.Err()
+			}
+		}
+	}
+
+	err := CloseCaptchaBrowser()
+	errText := ""
+	if err != nil {
+		errText = err.Error()
+	}
+	SetCaptchaEngineState(CaptchaEngineStateStopped, errText, 0)
+	return GetCaptchaEngineStatus(), err
+}
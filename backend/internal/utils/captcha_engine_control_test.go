@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStopCaptchaEngineRefusesWhileSolving(t *testing.T) {
+	captchaOptionsMu.RLock()
+	prev, prevSet := captchaOptions, captchaOptionsSet
+	captchaOptionsMu.RUnlock()
+	t.Cleanup(func() {
+		captchaOptionsMu.Lock()
+		captchaOptions, captchaOptionsSet = prev, prevSet
+		captchaOptionsMu.Unlock()
+		SetCaptchaEngineState(CaptchaEngineStateStopped, "", 0)
+	})
+
+	opts := DefaultCaptchaOptions()
+	opts.Solver = CaptchaSolverMock
+	SetCaptchaOptions(opts)
+	if err := WarmupCaptchaEngine(1); err != nil {
+		t.Fatalf("warmup: %v", err)
+	}
+
+	release, err := acquireCaptchaSlot(context.Background())
+	if err != nil {
+		t.Fatalf("acquire slot: %v", err)
+	}
+	if _, err := StopCaptchaEngine(context.Background(), false); !errors.Is(err, ErrCaptchaSolvesInFlight) {
+		release()
+		t.Fatalf("stop without drain: err = %v, want ErrCaptchaSolvesInFlight", err)
+	}
+	if st := GetCaptchaEngineStatus(); st.State != CaptchaEngineStateReady {
+		release()
+		t.Fatalf("state = %s, want ready", st.State)
+	}
+
+	// drain 会等待名额归还后再关闭。
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		release()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	st, err := StopCaptchaEngine(ctx, true)
+	if err != nil {
+		t.Fatalf("stop with drain: %v", err)
+	}
+	if st.State != CaptchaEngineStateStopped {
+		t.Fatalf("state = %s, want stopped", st.State)
+	}
+
+	if _, err := EnsureCaptchaEngineReady(ctx, 1); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+}
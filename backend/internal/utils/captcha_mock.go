@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"sniping_engine/internal/model"
+)
+
+var captchaMockSeq atomic.Int64
+
+func captchaMockEnabled() bool {
+	return GetCaptchaOptions().Solver == CaptchaSolverMock
+}
+
+// solveCaptchaMock 不启动浏览器，延迟 MockDelayMs 后返回确定性的伪 verifyParam（与真实结果同为 base64(JSON)），
+// 让 CI 容器和演示环境在没有 Chromium 的情况下也能跑通引擎 + 验证码池链路。
+// 同样占用求解名额，排队与并发行为与真实求解一致。
+func solveCaptchaMock(ctx context.Context, timestamp int64) (string, CaptchaSolveMetrics, error) {
+	started := time.Now()
+	metrics := CaptchaSolveMetrics{Attempts: 1}
+
+	release, err := acquireCaptchaSlot(ctx)
+	if err != nil {
+		return "", metrics, err
+	}
+	defer release()
+
+	if delay := time.Duration(GetCaptchaOptions().MockDelayMs) * time.Millisecond; delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			metrics.Duration = model.DurationMs(time.Since(started))
+			return "", metrics, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	seq := captchaMockSeq.Add(1)
+	out, _ := json.Marshal(OutputResult{
+		CertifyId:     fmt.Sprintf("mock-%d-%d", timestamp, seq),
+		SceneId:       "mock",
+		IsSign:        true,
+		SecurityToken: fmt.Sprintf("mock-token-%d", seq),
+	})
+
+	metrics.Duration = model.DurationMs(time.Since(started))
+	captchaSolveCount.Add(1)
+	captchaSolveTotalMs.Add(metrics.Duration.Milliseconds())
+	captchaLastSolveAtMs.Store(time.Now().UnixMilli())
+	captchaLastSolveMs.Store(metrics.Duration.Milliseconds())
+	captchaLastAttempts.Store(int64(metrics.Attempts))
+	return base64.StdEncoding.EncodeToString(out), metrics, nil
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+func TestMockSolverReturnsVerifyParamWithoutBrowser(t *testing.T) {
+	captchaOptionsMu.RLock()
+	prev, prevSet := captchaOptions, captchaOptionsSet
+	captchaOptionsMu.RUnlock()
+	t.Cleanup(func() {
+		captchaOptionsMu.Lock()
+		captchaOptions, captchaOptionsSet = prev, prevSet
+		captchaOptionsMu.Unlock()
+		SetCaptchaEngineState(CaptchaEngineStateStopped, "", 0)
+	})
+
+	opts := DefaultCaptchaOptions()
+	opts.Solver = CaptchaSolverMock
+	opts.MockDelayMs = 1
+	SetCaptchaOptions(opts)
+
+	if err := WarmupCaptchaEngine(1); err != nil {
+		t.Fatalf("warmup: %v", err)
+	}
+	param, metrics, err := SolveAliyunCaptchaWithMetrics(context.Background(), 1700000000000, "")
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	if metrics.Attempts != 1 {
+		t.Fatalf("attempts = %d, want 1", metrics.Attempts)
+	}
+	raw, err := base64.StdEncoding.DecodeString(param)
+	if err != nil {
+		t.Fatalf("verifyParam is not base64: %v", err)
+	}
+	var out OutputResult
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatalf("verifyParam is not json: %v", err)
+	}
+	if out.SceneId != "mock" || !out.IsSign || out.SecurityToken == "" {
+		t.Fatalf("unexpected mock result: %+v", out)
+	}
+}
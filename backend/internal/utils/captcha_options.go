@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CaptchaOptions 汇总验证码求解的运行参数（原先分散在 SNIPING_ENGINE_CAPTCHA_* 环境变量里）。
+type CaptchaOptions struct {
+	// Headless 无头模式；本地调试可关闭以打开浏览器窗口。
+	Headless bool `json:"headless"`
+	// SleepScale 求解过程中各步等待时间的倍率，(0, 1]，越小越快。
+	SleepScale float64 `json:"sleepScale"`
+	// WarmPages 预热的页面数，0 表示跟随验证码并发上限。
+	WarmPages int `json:"warmPages"`
+	// BlockResources 拦截验证码页面的非必须资源；拦截过多可能导致页面白屏，默认关闭。
+	BlockResources bool `json:"blockResources"`
+	Debug          bool `json:"debug"`
+	// MaxTries 单次求解的最大尝试次数。
+	MaxTries int `json:"maxTries"`
+	// Solver 为 mock 时不启动浏览器，延迟 MockDelayMs 后返回伪造的 verifyParam（CI/演示用）。
+	Solver      string `json:"solver"`
+	MockDelayMs int    `json:"mockDelayMs"`
+}
+
+const (
+	CaptchaSolverBrowser = "browser"
+	CaptchaSolverMock    = "mock"
+)
+
+const (
+	captchaFastSleepScale = 0.35
+	captchaMaxWarmPages   = 8
+	captchaMaxTriesLimit  = 10
+)
+
+func DefaultCaptchaOptions() CaptchaOptions {
+	return CaptchaOptions{Headless: true, SleepScale: 1.0, MaxTries: 3, Solver: CaptchaSolverBrowser, MockDelayMs: 300}
+}
+
+// NormalizeCaptchaOptions 把越界的值收敛到可用范围。
+func NormalizeCaptchaOptions(o CaptchaOptions) CaptchaOptions {
+	if o.SleepScale <= 0 || o.SleepScale > 1 {
+		o.SleepScale = 1.0
+	}
+	if o.WarmPages < 0 || o.WarmPages > captchaMaxWarmPages {
+		o.WarmPages = 0
+	}
+	if o.MaxTries <= 0 {
+		o.MaxTries = 3
+	}
+	if o.MaxTries > captchaMaxTriesLimit {
+		o.MaxTries = captchaMaxTriesLimit
+	}
+	o.Solver = strings.ToLower(strings.TrimSpace(o.Solver))
+	if o.Solver != CaptchaSolverMock {
+		o.Solver = CaptchaSolverBrowser
+	}
+	if o.MockDelayMs < 0 {
+		o.MockDelayMs = 0
+	}
+	return o
+}
+
+// CaptchaOptionsFromEnv 用环境变量覆盖 base 中的对应项（未设置或无法解析的变量保持 base 的值）：
+// SNIPING_ENGINE_CAPTCHA_HEADLESS / _FAST / _SLEEP_SCALE / _WARM_PAGES / _BLOCK_RESOURCES / _DEBUG / _MAX_TRIES /
+// _SOLVER / _MOCK_DELAY_MS。
+func CaptchaOptionsFromEnv(base CaptchaOptions) CaptchaOptions {
+	o := base
+	if v, ok := envBool("SNIPING_ENGINE_CAPTCHA_HEADLESS"); ok {
+		o.Headless = v
+	}
+	if v, ok := envBool("SNIPING_ENGINE_CAPTCHA_FAST"); ok && v {
+		o.SleepScale = captchaFastSleepScale
+	}
+	if v := strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_SLEEP_SCALE")); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 && f <= 1.0 {
+			o.SleepScale = f
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_WARM_PAGES")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= captchaMaxWarmPages {
+			o.WarmPages = n
+		}
+	}
+	if v, ok := envBool("SNIPING_ENGINE_CAPTCHA_BLOCK_RESOURCES"); ok {
+		o.BlockResources = v
+	}
+	if v, ok := envBool("SNIPING_ENGINE_CAPTCHA_DEBUG"); ok {
+		o.Debug = v
+	}
+	if v := strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_MAX_TRIES")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			o.MaxTries = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_SOLVER")); v != "" {
+		o.Solver = v
+	}
+	if v := strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_MOCK_DELAY_MS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			o.MockDelayMs = n
+		}
+	}
+	return NormalizeCaptchaOptions(o)
+}
+
+func envBool(key string) (bool, bool) {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	switch v {
+	case "1", "true", "yes", "on":
+		return true, true
+	case "0", "false", "no", "off":
+		return false, true
+	}
+	return false, false
+}
+
+var (
+	captchaOptionsMu  sync.RWMutex
+	captchaOptions    CaptchaOptions
+	captchaOptionsSet bool
+)
+
+// SetCaptchaOptions 设置验证码求解参数（启动时由配置注入）。
+func SetCaptchaOptions(o CaptchaOptions) {
+	captchaOptionsMu.Lock()
+	captchaOptions = NormalizeCaptchaOptions(o)
+	captchaOptionsSet = true
+	captchaOptionsMu.Unlock()
+}
+
+// GetCaptchaOptions 返回当前的验证码求解参数。
+// 未经 SetCaptchaOptions 注入时（如单独跑 utils 的本地测试）每次按环境变量现算，
+// 因为测试会在用例开始时才从 backend/.env 注入环境变量。
+func GetCaptchaOptions() CaptchaOptions {
+	captchaOptionsMu.RLock()
+	o, ok := captchaOptions, captchaOptionsSet
+	captchaOptionsMu.RUnlock()
+	if ok {
+		return o
+	}
+	return CaptchaOptionsFromEnv(DefaultCaptchaOptions())
+}
+
+// UpdateCaptchaRuntimeOptions 运行中调整可即时生效的参数（等待倍率、资源拦截）；nil 表示不修改。
+// 无头模式、预热页面数等需要重建浏览器的参数只能通过配置修改后重启。
+func UpdateCaptchaRuntimeOptions(sleepScale *float64, blockResources *bool) CaptchaOptions {
+	o := GetCaptchaOptions()
+	if sleepScale != nil {
+		o.SleepScale = *sleepScale
+	}
+	if blockResources != nil {
+		o.BlockResources = *blockResources
+	}
+	SetCaptchaOptions(o)
+	return GetCaptchaOptions()
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// CaptchaPriority 验证码求解排队优先级，数值越大越先拿到浏览器页面。
+type CaptchaPriority int
+
+const (
+	// CaptchaPriorityBackground 验证码池后台补充。
+	CaptchaPriorityBackground CaptchaPriority = iota
+	// CaptchaPriorityScan 扫货下单。
+	CaptchaPriorityScan
+	// CaptchaPriorityRush 抢购进行中。
+	CaptchaPriorityRush
+	// CaptchaPriorityImminent 距离开抢只剩几秒的抢购。
+	CaptchaPriorityImminent
+)
+
+func (p CaptchaPriority) String() string {
+	switch p {
+	case CaptchaPriorityImminent:
+		return "imminent"
+	case CaptchaPriorityRush:
+		return "rush"
+	case CaptchaPriorityScan:
+		return "scan"
+	default:
+		return "background"
+	}
+}
+
+type captchaPriorityKey struct{}
+
+// WithCaptchaPriority 给求解请求标记优先级；未标记时按 CaptchaPriorityScan 处理。
+func WithCaptchaPriority(ctx context.Context, p CaptchaPriority) context.Context {
+	return context.WithValue(ctx, captchaPriorityKey{}, p)
+}
+
+func CaptchaPriorityFromContext(ctx context.Context) CaptchaPriority {
+	if ctx != nil {
+		if p, ok := ctx.Value(captchaPriorityKey{}).(CaptchaPriority); ok {
+			return p
+		}
+	}
+	return CaptchaPriorityScan
+}
+
+type captchaWaiter struct {
+	prio    CaptchaPriority
+	seq     uint64
+	ready   chan struct{}
+	granted bool
+}
+
+// captchaSlotQueue 是带优先级的计数信号量：有空位时直接放行，否则按优先级（同级 FIFO）唤醒。
+type captchaSlotQueue struct {
+	mu       sync.Mutex
+	capacity int
+	busy     int
+	seq      uint64
+	waiters  []*captchaWaiter
+}
+
+func newCaptchaSlotQueue(capacity int) *captchaSlotQueue {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &captchaSlotQueue{capacity: capacity}
+}
+
+func (q *captchaSlotQueue) Capacity() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.capacity
+}
+
+func (q *captchaSlotQueue) SetCapacity(n int) {
+	if n <= 0 {
+		n = 1
+	}
+	q.mu.Lock()
+	q.capacity = n
+	q.dispatchLocked()
+	q.mu.Unlock()
+}
+
+func (q *captchaSlotQueue) Acquire(ctx context.Context, prio CaptchaPriority) (func(), error) {
+	q.mu.Lock()
+	if q.busy < q.capacity && len(q.waiters) == 0 {
+		q.busy++
+		q.mu.Unlock()
+		return q.releaseFunc(), nil
+	}
+	q.seq++
+	w := &captchaWaiter{prio: prio, seq: q.seq, ready: make(chan struct{})}
+	q.waiters = append(q.waiters, w)
+	sort.SliceStable(q.waiters, func(i, j int) bool {
+		if q.waiters[i].prio != q.waiters[j].prio {
+			return q.waiters[i].prio > q.waiters[j].prio
+		}
+		return q.waiters[i].seq < q.waiters[j].seq
+	})
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return q.releaseFunc(), nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		if w.granted {
+			// 名额已分配但调用方已放弃：归还名额给下一个等待者。
+			q.busy--
+			q.dispatchLocked()
+		} else {
+			q.removeLocked(w)
+		}
+		q.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (q *captchaSlotQueue) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			if q.busy > 0 {
+				q.busy--
+			}
+			q.dispatchLocked()
+			q.mu.Unlock()
+		})
+	}
+}
+
+func (q *captchaSlotQueue) dispatchLocked() {
+	for q.busy < q.capacity && len(q.waiters) > 0 {
+		w := q.waiters[0]
+		q.waiters = q.waiters[1:]
+		w.granted = true
+		q.busy++
+		close(w.ready)
+	}
+}
+
+func (q *captchaSlotQueue) removeLocked(target *captchaWaiter) {
+	for i, w := range q.waiters {
+		if w == target {
+			q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// CaptchaQueueStatus 求解队列快照。
+type CaptchaQueueStatus struct {
+	Capacity int            `json:"capacity"`
+	Busy     int            `json:"busy"`
+	Waiting  map[string]int `json:"waiting,omitempty"`
+}
+
+func GetCaptchaQueueStatus() CaptchaQueueStatus {
+	q := captchaSlots
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	st := CaptchaQueueStatus{Capacity: q.capacity, Busy: q.busy}
+	if len(q.waiters) > 0 {
+		st.Waiting = make(map[string]int)
+		for _, w := range q.waiters {
+			st.Waiting[w.prio.String()]++
+		}
+	}
+	return st
+}
@@ -27,13 +27,12 @@ import (
 	"github.com/go-rod/rod/lib/launcher"
 	"github.com/go-rod/rod/lib/proto"
 	"github.com/go-rod/stealth"
+
+	"sniping_engine/internal/model"
 )
 
 // --- 并发配置 ---
-var (
-	captchaSemaphoreMu sync.RWMutex
-	captchaSemaphore   = make(chan struct{}, 1)
-)
+var captchaSlots = newCaptchaSlotQueue(1)
 
 type CaptchaEngineState string
 
@@ -47,22 +46,24 @@ const (
 const aliyunCaptchaTargetURL = "https://m.4008117117.com/aliyun-captcha&cookie=true"
 
 type CaptchaEngineStatus struct {
-	State         CaptchaEngineState `json:"state"`
-	StartedAtMs   int64              `json:"startedAtMs"`
-	ReadyAtMs     int64              `json:"readyAtMs"`
-	LastError     string             `json:"lastError,omitempty"`
-	WarmPages     int                `json:"warmPages"`
-	PagePoolSize  int                `json:"pagePoolSize"`
-	TotalPages    int                `json:"totalPages"`
-	IdlePages     int                `json:"idlePages"`
-	BusyPages     int                `json:"busyPages"`
-	Refreshing    int                `json:"refreshingPages"`
-	SolveCount    int64              `json:"solveCount"`
-	TotalSolveMs  int64              `json:"totalSolveMs"`
-	LastSolveAtMs int64              `json:"lastSolveAtMs"`
-	LastSolveMs   int64              `json:"lastSolveMs"`
-	LastAttempts  int64              `json:"lastAttempts"`
-	GoRoutines    int                `json:"goRoutines"`
+	State         CaptchaEngineState    `json:"state"`
+	StartedAtMs   int64                 `json:"startedAtMs"`
+	ReadyAtMs     int64                 `json:"readyAtMs"`
+	LastError     string                `json:"lastError,omitempty"`
+	WarmPages     int                   `json:"warmPages"`
+	PagePoolSize  int                   `json:"pagePoolSize"`
+	TotalPages    int                   `json:"totalPages"`
+	IdlePages     int                   `json:"idlePages"`
+	BusyPages     int                   `json:"busyPages"`
+	Refreshing    int                   `json:"refreshingPages"`
+	SolveCount    int64                 `json:"solveCount"`
+	TotalSolveMs  int64                 `json:"totalSolveMs"`
+	LastSolveAtMs int64                 `json:"lastSolveAtMs"`
+	LastSolveMs   int64                 `json:"lastSolveMs"`
+	LastAttempts  int64                 `json:"lastAttempts"`
+	Backoff       []CaptchaBackoffEntry `json:"backoff"`
+	GoRoutines    int                   `json:"goRoutines"`
+	Options       CaptchaOptions        `json:"options"`
 }
 
 type CaptchaPageInfo struct {
@@ -75,13 +76,13 @@ type CaptchaPageInfo struct {
 }
 
 type CaptchaPagesStatus struct {
-	NowMs       int64             `json:"nowMs"`
-	Total       int               `json:"total"`
-	Idle        int               `json:"idle"`
-	Busy        int               `json:"busy"`
-	Refreshing  int               `json:"refreshing"`
-	PagePool    int               `json:"pagePool"`
-	Pages       []CaptchaPageInfo `json:"pages"`
+	NowMs      int64             `json:"nowMs"`
+	Total      int               `json:"total"`
+	Idle       int               `json:"idle"`
+	Busy       int               `json:"busy"`
+	Refreshing int               `json:"refreshing"`
+	PagePool   int               `json:"pagePool"`
+	Pages      []CaptchaPageInfo `json:"pages"`
 }
 
 type CaptchaPagesRefreshOptions struct {
@@ -101,8 +102,8 @@ type CaptchaStopAllResult struct {
 }
 
 type CaptchaSolveMetrics struct {
-	Attempts int           `json:"attempts"`
-	Duration time.Duration `json:"duration"`
+	Attempts int              `json:"attempts"`
+	Duration model.DurationMs `json:"durationMs"`
 }
 
 // SetCaptchaMaxConcurrent 设置验证码求解（无头浏览器）的并发数上限。
@@ -111,27 +112,12 @@ func SetCaptchaMaxConcurrent(n int) {
 	if n <= 0 {
 		n = 1
 	}
-	captchaSemaphoreMu.Lock()
-	captchaSemaphore = make(chan struct{}, n)
-	captchaSemaphoreMu.Unlock()
+	captchaSlots.SetCapacity(n)
 }
 
+// acquireCaptchaSlot 按 ctx 中的优先级（见 WithCaptchaPriority）排队获取求解名额。
 func acquireCaptchaSlot(ctx context.Context) (func(), error) {
-	captchaSemaphoreMu.RLock()
-	sem := captchaSemaphore
-	captchaSemaphoreMu.RUnlock()
-
-	select {
-	case sem <- struct{}{}:
-		return func() {
-			select {
-			case <-sem:
-			default:
-			}
-		}, nil
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	}
+	return captchaSlots.Acquire(ctx, CaptchaPriorityFromContext(ctx))
 }
 
 const (
@@ -144,17 +130,9 @@ const (
 )
 
 // captchaHeadlessMode 无头模式开关：默认 true（生产环境）。
-// 如需本地调试打开浏览器窗口，可设置环境变量：SNIPING_ENGINE_CAPTCHA_HEADLESS=0
-//
-// 注意：这里必须“动态读取环境变量”，不能在包初始化时只读一次。
-// 因为本项目的本地验证码测试会从 backend/.env 注入环境变量，而注入发生在测试用例开始时。
+// 如需本地调试打开浏览器窗口，可配置 captcha.headless=false 或设置 SNIPING_ENGINE_CAPTCHA_HEADLESS=0。
 func captchaHeadlessMode() bool {
-	v := strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_HEADLESS"))
-	if v == "" {
-		return true
-	}
-	v = strings.ToLower(v)
-	return !(v == "0" || v == "false" || v == "no" || v == "off")
+	return GetCaptchaOptions().Headless
 }
 
 type solveRequest struct {
@@ -206,9 +184,6 @@ var (
 	// 复用 HTTP Client，利用 Keep-Alive 连接池，减少 TCP/TLS 握手开销。
 	captchaHTTPClient = newCaptchaHTTPClient()
 
-	captchaSleepScaleOnce sync.Once
-	captchaSleepScaleVal  float64
-
 	captchaPagePoolMu sync.Mutex
 	captchaPagePool   []*captchaPage
 
@@ -380,7 +355,9 @@ func GetCaptchaEngineStatus() CaptchaEngineStatus {
 		LastSolveAtMs: captchaLastSolveAtMs.Load(),
 		LastSolveMs:   captchaLastSolveMs.Load(),
 		LastAttempts:  captchaLastAttempts.Load(),
+		Backoff:       GetCaptchaBackoffStatus(),
 		GoRoutines:    runtime.NumGoroutine(),
+		Options:       GetCaptchaOptions(),
 	}
 }
 
@@ -412,13 +389,7 @@ func WaitCaptchaEngineReady(ctx context.Context) (CaptchaEngineStatus, error) {
 }
 
 func getCaptchaMaxConcurrent() int {
-	captchaSemaphoreMu.RLock()
-	sem := captchaSemaphore
-	captchaSemaphoreMu.RUnlock()
-	if sem == nil || cap(sem) <= 0 {
-		return 1
-	}
-	return cap(sem)
+	return captchaSlots.Capacity()
 }
 
 // EnsureCaptchaEngineReady 确保验证码引擎已启动并就绪：
@@ -476,10 +447,12 @@ func WarmupCaptchaEngine(maxWarmPages int) error {
 	if warmPages > 6 {
 		warmPages = 6
 	}
-	if v := strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_WARM_PAGES")); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 8 {
-			warmPages = n
-		}
+	if n := GetCaptchaOptions().WarmPages; n > 0 {
+		warmPages = n
+	}
+	if captchaMockEnabled() {
+		SetCaptchaEngineState(CaptchaEngineStateReady, "", 0)
+		return nil
 	}
 
 	SetCaptchaEngineState(CaptchaEngineStateStarting, "", warmPages)
@@ -657,42 +630,13 @@ func newCaptchaHTTPClient() *http.Client {
 	}
 }
 
+// captchaSleepScale 默认 1.0；captcha.fast / captcha.sleepScale 可缩短等待，运行中修改立即生效。
 func captchaSleepScale() float64 {
-	captchaSleepScaleOnce.Do(func() {
-		// 默认 1.0；设置 SNIPING_ENGINE_CAPTCHA_FAST=1 则会更快（更短等待）。
-		captchaSleepScaleVal = 1.0
-
-		fast := strings.EqualFold(strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_FAST")), "1") ||
-			strings.EqualFold(strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_FAST")), "true")
-		if fast {
-			captchaSleepScaleVal = 0.35
-		}
-
-		if v := strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_SLEEP_SCALE")); v != "" {
-			if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 && f <= 1.0 {
-				captchaSleepScaleVal = f
-			}
-		}
-	})
-	return captchaSleepScaleVal
+	return GetCaptchaOptions().SleepScale
 }
 
 func captchaMaxSolveAttempts() int {
-	v := strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_MAX_TRIES"))
-	if v == "" {
-		return 3
-	}
-	n, err := strconv.Atoi(v)
-	if err != nil {
-		return 3
-	}
-	if n <= 0 {
-		return 3
-	}
-	if n > 10 {
-		return 10
-	}
-	return n
+	return GetCaptchaOptions().MaxTries
 }
 
 func captchaSleep(base time.Duration, jitter time.Duration) {
@@ -805,7 +749,7 @@ func GetCaptchaPagesStatus() CaptchaPagesStatus {
 }
 
 func EnsureCaptchaPagePool(ctx context.Context, ensureTotalPages int) error {
-	if ensureTotalPages <= 0 {
+	if ensureTotalPages <= 0 || captchaMockEnabled() {
 		return nil
 	}
 	if ensureTotalPages > 20 {
@@ -845,6 +789,9 @@ func EnsureCaptchaPagePool(ctx context.Context, ensureTotalPages int) error {
 
 func RefreshCaptchaPages(ctx context.Context, opts CaptchaPagesRefreshOptions) (CaptchaPagesRefreshResult, error) {
 	var res CaptchaPagesRefreshResult
+	if captchaMockEnabled() {
+		return res, nil
+	}
 	if opts.EnsurePages > 0 {
 		if err := EnsureCaptchaPagePool(ctx, opts.EnsurePages); err != nil {
 			return res, err
@@ -1056,8 +1003,7 @@ func releaseCaptchaPage(cp *captchaPage) {
 
 func clickCaptchaButton(page *rod.Page) error {
 	debugEnabled := func() bool {
-		v := strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_DEBUG"))
-		return strings.EqualFold(v, "1") || strings.EqualFold(v, "true")
+		return GetCaptchaOptions().Debug
 	}
 	debugf := func(format string, args ...any) {
 		if !debugEnabled() {
@@ -1188,6 +1134,9 @@ func SolveAliyunCaptchaWithContext(parent context.Context, timestamp int64, drac
 }
 
 func solveAliyunCaptchaWithMetrics(parent context.Context, timestamp int64, dracoToken string) (string, CaptchaSolveMetrics, error) {
+	if captchaMockEnabled() {
+		return solveCaptchaMock(parent, timestamp)
+	}
 	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 	started := time.Now()
 	metrics := CaptchaSolveMetrics{Attempts: 0, Duration: 0}
@@ -1209,7 +1158,7 @@ func solveAliyunCaptchaWithMetrics(parent context.Context, timestamp int64, drac
 
 	// 如果验证码引擎还没就绪（首次启动可能在下载浏览器），这里先等待，避免抢购阶段因为超时而直接失败。
 	if _, err := EnsureCaptchaEngineReady(ctx, 0); err != nil {
-		metrics.Duration = time.Since(started)
+		metrics.Duration = model.DurationMs(time.Since(started))
 		return "", metrics, err
 	}
 
@@ -1296,8 +1245,8 @@ func solveAliyunCaptchaWithMetrics(parent context.Context, timestamp int64, drac
 		shadowB64    string
 		hasTriggered bool
 
-		pageSceneID   string
-		finalResult   string
+		pageSceneID string
+		finalResult string
 	)
 
 	type apiSolveResult struct {
@@ -1309,8 +1258,7 @@ func solveAliyunCaptchaWithMetrics(parent context.Context, timestamp int64, drac
 	verifyResultCh := make(chan string, 10)
 
 	debugEnabled := func() bool {
-		v := strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_DEBUG"))
-		return strings.EqualFold(v, "1") || strings.EqualFold(v, "true")
+		return GetCaptchaOptions().Debug
 	}
 	debugf := func(format string, args ...any) {
 		if !debugEnabled() {
@@ -1510,10 +1458,8 @@ func solveAliyunCaptchaWithMetrics(parent context.Context, timestamp int64, drac
 	defer func() { _ = router.Stop() }()
 
 	// 注意：拦截过多资源可能导致验证码页面“白屏/不渲染”。
-	// 默认不做额外拦截；如你确认页面能正常显示，再通过环境变量开启：
-	// SNIPING_ENGINE_CAPTCHA_BLOCK_RESOURCES=1
-	blockResources := strings.EqualFold(strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_BLOCK_RESOURCES")), "1") ||
-		strings.EqualFold(strings.TrimSpace(os.Getenv("SNIPING_ENGINE_CAPTCHA_BLOCK_RESOURCES")), "true")
+	// 默认不做额外拦截；如你确认页面能正常显示，再通过 captcha.blockResources 开启（运行中可切换，下一次求解生效）。
+	blockResources := GetCaptchaOptions().BlockResources
 	if blockResources {
 		router.MustAdd("*", func(ctx *rod.Hijack) {
 			u := ctx.Request.URL().String()
@@ -1634,7 +1580,7 @@ func solveAliyunCaptchaWithMetrics(parent context.Context, timestamp int64, drac
 
 	if err := ensureCaptchaPageOpened(); err != nil {
 		lastErr = fmt.Errorf("打开页面失败: %v", err)
-		metrics.Duration = time.Since(started)
+		metrics.Duration = model.DurationMs(time.Since(started))
 		return "", metrics, lastErr
 	}
 	pageSceneID = extractSceneID(page)
@@ -1651,11 +1597,11 @@ func solveAliyunCaptchaWithMetrics(parent context.Context, timestamp int64, drac
 		select {
 		case <-ctx.Done():
 			if lastErr != nil {
-				metrics.Duration = time.Since(started)
+				metrics.Duration = model.DurationMs(time.Since(started))
 				return "", metrics, lastErr
 			}
 			lastErr = errors.New("验证码流程超时")
-			metrics.Duration = time.Since(started)
+			metrics.Duration = model.DurationMs(time.Since(started))
 			return "", metrics, lastErr
 		default:
 		}
@@ -1705,7 +1651,7 @@ func solveAliyunCaptchaWithMetrics(parent context.Context, timestamp int64, drac
 			lastErr = errors.New("等待打码结果超时")
 			continue
 		case <-ctx.Done():
-			metrics.Duration = time.Since(started)
+			metrics.Duration = model.DurationMs(time.Since(started))
 			return "", metrics, errors.New("等待打码结果超时")
 		}
 
@@ -1820,7 +1766,7 @@ func solveAliyunCaptchaWithMetrics(parent context.Context, timestamp int64, drac
 			lastErr = errors.New("等待验证结果超时")
 			captchaSleep(350*time.Millisecond, 150*time.Millisecond)
 		case <-ctx.Done():
-			metrics.Duration = time.Since(started)
+			metrics.Duration = model.DurationMs(time.Since(started))
 			return "", metrics, errors.New("等待验证结果超时")
 		}
 	}
@@ -1837,7 +1783,7 @@ func solveAliyunCaptchaWithMetrics(parent context.Context, timestamp int64, drac
 	}
 
 	if verifySuccess {
-		metrics.Duration = time.Since(started)
+		metrics.Duration = model.DurationMs(time.Since(started))
 		captchaSolveCount.Add(1)
 		captchaSolveTotalMs.Add(metrics.Duration.Milliseconds())
 		captchaLastSolveAtMs.Store(time.Now().UnixMilli())
@@ -1846,10 +1792,10 @@ func solveAliyunCaptchaWithMetrics(parent context.Context, timestamp int64, drac
 		return finalResult, metrics, nil
 	}
 	if lastErr != nil {
-		metrics.Duration = time.Since(started)
+		metrics.Duration = model.DurationMs(time.Since(started))
 		return "", metrics, lastErr
 	}
-	metrics.Duration = time.Since(started)
+	metrics.Duration = model.DurationMs(time.Since(started))
 	return "", metrics, errors.New("验证码验证失败")
 }
 
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CookieJar 在标准库 cookiejar 的基础上记录每个 cookie 的过期时间。
+// 标准库 Jar.Cookies 只返回 name/value，导出到数据库时会丢失有效期，这里补回来。
+type CookieJar struct {
+	*cookiejar.Jar
+
+	mu      sync.Mutex
+	expires map[cookieKey]int64 // cookie name/domain/path -> 过期时间（unix ms）
+}
+
+// cookieKey 与 cookiejar 一样按 name、domain、path 区分 cookie：不同域名或路径下的同名 cookie 是两个 cookie。
+type cookieKey struct {
+	name   string
+	domain string
+	path   string
+}
+
+func NewCookieJar() (*CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &CookieJar{Jar: jar, expires: make(map[cookieKey]int64)}, nil
+}
+
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	now := time.Now()
+	j.mu.Lock()
+	for _, c := range cookies {
+		if c == nil {
+			continue
+		}
+		key := cookieKeyFor(u, c)
+		switch {
+		case c.MaxAge > 0:
+			j.expires[key] = now.Add(time.Duration(c.MaxAge) * time.Second).UnixMilli()
+		case c.MaxAge < 0:
+			delete(j.expires, key)
+		case !c.Expires.IsZero():
+			j.expires[key] = c.Expires.UnixMilli()
+		default:
+			delete(j.expires, key)
+		}
+	}
+	j.mu.Unlock()
+	j.Jar.SetCookies(u, cookies)
+}
+
+// Cookies 返回的 cookie 会带上记录到的过期时间（会话 cookie 的 Expires 仍为零值）。
+// 同名 cookie 按 cookiejar 的顺序（路径长的在前）对应到适用于 u 的记录上。
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	out := j.Jar.Cookies(u)
+	host := strings.ToLower(u.Hostname())
+	reqPath := u.Path
+	if reqPath == "" {
+		reqPath = "/"
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	matched := make(map[string][]cookieKey)
+	for key := range j.expires {
+		if domainMatch(host, key.domain) && pathMatch(reqPath, key.path) {
+			matched[key.name] = append(matched[key.name], key)
+		}
+	}
+	for _, keys := range matched {
+		sort.Slice(keys, func(a, b int) bool { return len(keys[a].path) > len(keys[b].path) })
+	}
+	used := make(map[string]int)
+	for _, c := range out {
+		keys := matched[c.Name]
+		i := used[c.Name]
+		used[c.Name]++
+		if i >= len(keys) {
+			continue
+		}
+		if ms := j.expires[keys[i]]; ms > 0 {
+			c.Expires = time.UnixMilli(ms)
+		}
+	}
+	return out
+}
+
+// cookieKeyFor 按 cookiejar 的规则取 cookie 的生效域名与路径：未设置 Domain 时为请求主机，未设置 Path 时为请求路径的目录。
+func cookieKeyFor(u *url.URL, c *http.Cookie) cookieKey {
+	domain := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(c.Domain)), ".")
+	if domain == "" {
+		domain = strings.ToLower(u.Hostname())
+	}
+	path := c.Path
+	if path == "" || path[0] != '/' {
+		path = defaultCookiePath(u.Path)
+	}
+	return cookieKey{name: c.Name, domain: domain, path: path}
+}
+
+func defaultCookiePath(p string) string {
+	if p == "" || p[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(p, "/")
+	if i == 0 {
+		return "/"
+	}
+	return p[:i]
+}
+
+func domainMatch(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func pathMatch(reqPath, cookiePath string) bool {
+	if reqPath == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(reqPath, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || reqPath[len(cookiePath)] == '/'
+}
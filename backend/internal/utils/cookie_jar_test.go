@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCookieJarKeepsExpiryPerDomainAndPath(t *testing.T) {
+	jar, err := NewCookieJar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _ := url.Parse("https://shop.example.com/")
+	api, _ := url.Parse("https://shop.example.com/api/order")
+	other, _ := url.Parse("https://other.example.com/")
+
+	rootExp := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	apiExp := time.Now().Add(2 * time.Hour).Truncate(time.Millisecond)
+	jar.SetCookies(root, []*http.Cookie{{Name: "sid", Value: "root", Path: "/", Expires: rootExp}})
+	jar.SetCookies(api, []*http.Cookie{{Name: "sid", Value: "api", Path: "/api", Expires: apiExp}})
+	// 其他子域名下的同名会话 cookie 不应清掉 shop 下记录的有效期。
+	jar.SetCookies(other, []*http.Cookie{{Name: "sid", Value: "other"}})
+
+	got := map[string]time.Time{}
+	for _, c := range jar.Cookies(api) {
+		got[c.Value] = c.Expires
+	}
+	if !got["api"].Equal(apiExp) || !got["root"].Equal(rootExp) {
+		t.Fatalf("expiries for /api = %v", got)
+	}
+
+	cs := jar.Cookies(root)
+	if len(cs) != 1 || cs[0].Value != "root" || !cs[0].Expires.Equal(rootExp) {
+		t.Fatalf("root cookies = %+v", cs)
+	}
+	cs = jar.Cookies(other)
+	if len(cs) != 1 || !cs[0].Expires.IsZero() {
+		t.Fatalf("other cookies = %+v", cs)
+	}
+}
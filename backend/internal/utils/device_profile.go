@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// DeviceProfile 是一套模拟的手机端设备身份（与前端新建账号时生成的格式一致）。
+type DeviceProfile struct {
+	DeviceID  string `json:"deviceId"`
+	UUID      string `json:"uuid"`
+	UserAgent string `json:"userAgent"`
+}
+
+var deviceProfileUserAgents = []string{
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 18_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 MicroMessenger/8.0.66(0x18004235) NetType/WIFI Language/zh_CN",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 18_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 MicroMessenger/8.0.61(0x18003d2c) NetType/4G Language/zh_CN",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 MicroMessenger/8.0.58(0x18003a2b) NetType/WIFI Language/zh_CN",
+	"Mozilla/5.0 (Linux; Android 14; 23127PN0CC Build/UKQ1.230804.001; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/130.0.6723.103 Mobile Safari/537.36 XWEB/1300333 MMWEBSDK/20241202 MicroMessenger/8.0.56.2800(0x28003856) WeChat/arm64 Weixin NetType/WIFI Language/zh_CN ABI/arm64",
+	"Mozilla/5.0 (Linux; Android 13; V2307A Build/TP1A.220624.014; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/122.0.6261.120 Mobile Safari/537.36 XWEB/1220133 MMWEBSDK/20240404 MicroMessenger/8.0.49.2600(0x28003133) WeChat/arm64 Weixin NetType/5G Language/zh_CN ABI/arm64",
+}
+
+// NewDeviceProfile 随机生成一套新的设备身份：deviceId 为 16 字节 hex，uuid 为 "<毫秒时间戳>_<10 字节 hex>"。
+func NewDeviceProfile() (DeviceProfile, error) {
+	deviceID, err := randomHex(16)
+	if err != nil {
+		return DeviceProfile{}, err
+	}
+	suffix, err := randomHex(10)
+	if err != nil {
+		return DeviceProfile{}, err
+	}
+	idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(deviceProfileUserAgents))))
+	if err != nil {
+		return DeviceProfile{}, err
+	}
+	return DeviceProfile{
+		DeviceID:  deviceID,
+		UUID:      fmt.Sprintf("%d_%s", time.Now().UnixMilli(), suffix),
+		UserAgent: deviceProfileUserAgents[idx.Int64()],
+	}, nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -2,19 +2,125 @@ package utils
 
 import (
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
 	"strings"
+	"sync"
 )
 
-// EncryptPayload 按目标站点的规则加密密码等字段。
+// 加密算法版本。目标站点改规则时通常只是换 key，新增版本或用 custom 指定 key/offset 即可，不必改代码。
+const (
+	EncryptVersion20251119 = "xor-20251119"
+	EncryptVersionCustom   = "xor-custom"
+
+	DefaultEncryptVersion = EncryptVersion20251119
+)
+
+type xorOffsetParams struct {
+	Key    string
+	Offset int
+}
+
+var builtinEncryptVersions = map[string]xorOffsetParams{
+	EncryptVersion20251119: {Key: "sxdSybCzy20251119ModifyVeryGood", Offset: 17},
+}
+
+var (
+	encryptMu      sync.RWMutex
+	encryptVersion = DefaultEncryptVersion
+	encryptCustom  xorOffsetParams
+)
+
+// EncryptVersions 返回所有可选的算法版本（含 custom）。
+func EncryptVersions() []string {
+	out := make([]string, 0, len(builtinEncryptVersions)+1)
+	for v := range builtinEncryptVersions {
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return append(out, EncryptVersionCustom)
+}
+
+// ValidateEncryptVersion 检查算法版本是否可用；version 为 custom 时要求提供 customKey。
+func ValidateEncryptVersion(version, customKey string) error {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return nil
+	}
+	if version == EncryptVersionCustom {
+		if customKey == "" {
+			return errors.New("custom encrypt key is required")
+		}
+	} else if _, ok := builtinEncryptVersions[version]; !ok {
+		return fmt.Errorf("unknown encrypt version: %s", version)
+	}
+	return nil
+}
+
+// SetEncryptVersion 切换当前使用的算法版本；version 为 custom 时使用 customKey/customOffset。
+func SetEncryptVersion(version, customKey string, customOffset int) error {
+	if err := ValidateEncryptVersion(version, customKey); err != nil {
+		return err
+	}
+	version = strings.TrimSpace(version)
+	if version == "" {
+		version = DefaultEncryptVersion
+	}
+
+	encryptMu.Lock()
+	encryptVersion = version
+	encryptCustom = xorOffsetParams{Key: customKey, Offset: customOffset}
+	encryptMu.Unlock()
+	return nil
+}
+
+// CurrentEncryptVersion 返回当前使用的算法版本。
+func CurrentEncryptVersion() string {
+	encryptMu.RLock()
+	defer encryptMu.RUnlock()
+	return encryptVersion
+}
+
+func currentEncryptParams() xorOffsetParams {
+	encryptMu.RLock()
+	defer encryptMu.RUnlock()
+	if encryptVersion == EncryptVersionCustom {
+		return encryptCustom
+	}
+	return builtinEncryptVersions[encryptVersion]
+}
+
+// EncryptPayload 按目标站点的规则加密密码等字段（使用当前选中的算法版本）。
 // 算法：
 // 1) 逐字符取 key（循环）
 // 2) (char ^ keyChar) + index
-// 3) 再整体偏移 +17
+// 3) 再整体偏移 +offset（当前站点为 17）
 // 4) Base64 编码后，前后各拼接 "=="
 func EncryptPayload(input string) string {
-	keyStr := "sxdSybCzy20251119ModifyVeryGood"
-	key := []rune(keyStr)
+	return encryptXorOffset(input, currentEncryptParams())
+}
+
+// EncryptPayloadWithVersion 使用指定版本加密，便于在切换前做对比验证。
+func EncryptPayloadWithVersion(version, input string) (string, error) {
+	params, ok := builtinEncryptVersions[strings.TrimSpace(version)]
+	if !ok {
+		return "", fmt.Errorf("unknown encrypt version: %s", version)
+	}
+	return encryptXorOffset(input, params), nil
+}
+
+// DecryptPayload 是 EncryptPayload 的逆运算（使用当前算法版本），用于自检。
+func DecryptPayload(encrypted string) (string, error) {
+	return decryptXorOffset(encrypted, currentEncryptParams())
+}
+
+func encryptXorOffset(input string, params xorOffsetParams) string {
+	key := []rune(params.Key)
 	inputRunes := []rune(input)
+	if len(key) == 0 {
+		key = []rune{0}
+	}
 
 	var sb strings.Builder
 	sb.Grow(len(inputRunes))
@@ -22,7 +128,7 @@ func EncryptPayload(input string) string {
 	for n, char := range inputRunes {
 		keyChar := key[n%len(key)]
 		step1 := (int(char) ^ int(keyChar)) + n
-		finalVal := step1 + 17
+		finalVal := step1 + params.Offset
 		sb.WriteRune(rune(finalVal))
 	}
 
@@ -30,3 +136,23 @@ func EncryptPayload(input string) string {
 	return "==" + encoded + "=="
 }
 
+func decryptXorOffset(encrypted string, params xorOffsetParams) (string, error) {
+	if !strings.HasPrefix(encrypted, "==") || !strings.HasSuffix(encrypted, "==") || len(encrypted) < 4 {
+		return "", errors.New("invalid encrypted payload")
+	}
+	raw, err := base64.StdEncoding.DecodeString(encrypted[2 : len(encrypted)-2])
+	if err != nil {
+		return "", err
+	}
+	key := []rune(params.Key)
+	if len(key) == 0 {
+		key = []rune{0}
+	}
+
+	var sb strings.Builder
+	for n, v := range []rune(string(raw)) {
+		keyChar := key[n%len(key)]
+		sb.WriteRune(rune((int(v) - params.Offset - n) ^ int(keyChar)))
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,73 @@
+package utils
+
+import "testing"
+
+// 固定向量来自目标站点 2025-11-19 版本的加密规则；站点改规则时应新增版本而不是改这里。
+var encryptFixtures20251119 = []struct {
+	in   string
+	want string
+}{
+	{"", "===="},
+	{"123456", "==U1xqe2Fq=="},
+	{"Passw0rd!", "==NCsqNCNoSDZx=="},
+	{"测试abc", "==5rWJ6K6/GEUv=="},
+}
+
+func resetEncryptVersion(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() { _ = SetEncryptVersion(DefaultEncryptVersion, "", 0) })
+}
+
+func TestEncryptPayloadFixtures(t *testing.T) {
+	resetEncryptVersion(t)
+	for _, tc := range encryptFixtures20251119 {
+		if got := EncryptPayload(tc.in); got != tc.want {
+			t.Fatalf("EncryptPayload(%q)=%q, want %q", tc.in, got, tc.want)
+		}
+		got, err := EncryptPayloadWithVersion(EncryptVersion20251119, tc.in)
+		if err != nil || got != tc.want {
+			t.Fatalf("EncryptPayloadWithVersion(%q)=%q,%v, want %q", tc.in, got, err, tc.want)
+		}
+		plain, err := DecryptPayload(tc.want)
+		if err != nil || plain != tc.in {
+			t.Fatalf("DecryptPayload(%q)=%q,%v, want %q", tc.want, plain, err, tc.in)
+		}
+	}
+}
+
+func TestEncryptVersionSwitch(t *testing.T) {
+	resetEncryptVersion(t)
+
+	if err := SetEncryptVersion("xor-unknown", "", 0); err == nil {
+		t.Fatalf("expected error for unknown version")
+	}
+	if err := SetEncryptVersion(EncryptVersionCustom, "", 0); err == nil {
+		t.Fatalf("expected error for custom version without key")
+	}
+	if CurrentEncryptVersion() != DefaultEncryptVersion {
+		t.Fatalf("failed switch must keep current version, got %s", CurrentEncryptVersion())
+	}
+
+	// custom 使用与内置版本相同的参数时结果应一致。
+	if err := SetEncryptVersion(EncryptVersionCustom, "sxdSybCzy20251119ModifyVeryGood", 17); err != nil {
+		t.Fatalf("SetEncryptVersion custom: %v", err)
+	}
+	if got := EncryptPayload("123456"); got != "==U1xqe2Fq==" {
+		t.Fatalf("custom with builtin params got %q", got)
+	}
+
+	if err := SetEncryptVersion(EncryptVersionCustom, "another-key", 3); err != nil {
+		t.Fatalf("SetEncryptVersion custom: %v", err)
+	}
+	enc := EncryptPayload("Passw0rd!")
+	if enc == "==NCsqNCNoSDZx==" {
+		t.Fatalf("custom key should change output")
+	}
+	if plain, err := DecryptPayload(enc); err != nil || plain != "Passw0rd!" {
+		t.Fatalf("round trip with custom key got %q,%v", plain, err)
+	}
+
+	if _, err := DecryptPayload("U1xqe2Fq"); err == nil {
+		t.Fatalf("expected error for payload without == markers")
+	}
+}
@@ -1,24 +1,82 @@
 package utils
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
 
 const defaultWXAppUserAgent = "Mozilla/5.0 (iPhone; CPU iPhone OS 18_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 MicroMessenger/8.0.66(0x18004235) NetType/WIFI Language/zh_CN"
 
+// UA 规范化规则版本：
+// - wxapp-v1：非手机 UA 一律替换成默认微信小程序 UA（当前站点规则）
+// - passthrough：原样透传，只在为空时使用默认 UA
+const (
+	UAModeWXAppV1     = "wxapp-v1"
+	UAModePassthrough = "passthrough"
+
+	DefaultUAMode = UAModeWXAppV1
+)
+
+var (
+	uaMu        sync.RWMutex
+	uaMode      = DefaultUAMode
+	uaDefaultUA = defaultWXAppUserAgent
+)
+
+// UAModes 返回所有可选的 UA 规则版本。
+func UAModes() []string {
+	return []string{UAModeWXAppV1, UAModePassthrough}
+}
+
+// SetUserAgentMode 切换 UA 规则版本；defaultUA 非空时覆盖内置默认 UA。
+func SetUserAgentMode(mode, defaultUA string) error {
+	mode = strings.TrimSpace(mode)
+	if mode == "" {
+		mode = DefaultUAMode
+	}
+	if mode != UAModeWXAppV1 && mode != UAModePassthrough {
+		return fmt.Errorf("unknown user agent mode: %s", mode)
+	}
+	defaultUA = strings.TrimSpace(defaultUA)
+	if defaultUA == "" {
+		defaultUA = defaultWXAppUserAgent
+	}
+	uaMu.Lock()
+	uaMode = mode
+	uaDefaultUA = defaultUA
+	uaMu.Unlock()
+	return nil
+}
+
+// CurrentUserAgentMode 返回当前使用的 UA 规则版本。
+func CurrentUserAgentMode() string {
+	uaMu.RLock()
+	defer uaMu.RUnlock()
+	return uaMode
+}
+
 // DefaultWXAppUserAgent 返回默认的“微信小程序/手机端”UA。
 func DefaultWXAppUserAgent() string {
-	return defaultWXAppUserAgent
+	uaMu.RLock()
+	defer uaMu.RUnlock()
+	return uaDefaultUA
 }
 
 // NormalizeWXAppUserAgent 把 UA 规范为“手机端”风格；当入参为空或不像手机 UA 时，返回默认 UA。
 func NormalizeWXAppUserAgent(ua string) string {
+	uaMu.RLock()
+	mode, fallback := uaMode, uaDefaultUA
+	uaMu.RUnlock()
+
 	v := strings.TrimSpace(ua)
 	if v == "" {
-		return defaultWXAppUserAgent
+		return fallback
 	}
-	if looksLikeMobileUA(v) {
+	if mode == UAModePassthrough || looksLikeMobileUA(v) {
 		return v
 	}
-	return defaultWXAppUserAgent
+	return fallback
 }
 
 func looksLikeMobileUA(ua string) bool {
@@ -34,4 +92,3 @@ func looksLikeMobileUA(ua string) bool {
 	}
 	return false
 }
-
@@ -0,0 +1,46 @@
+package utils
+
+import "testing"
+
+func TestNormalizeWXAppUserAgent(t *testing.T) {
+	t.Cleanup(func() { _ = SetUserAgentMode(DefaultUAMode, "") })
+
+	const (
+		desktop = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+		android = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36"
+		wechat  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) MicroMessenger/8.0.50"
+		custom  = "Mozilla/5.0 (iPhone) custom-default"
+	)
+
+	cases := []struct {
+		mode      string
+		defaultUA string
+		in        string
+		want      string
+	}{
+		{UAModeWXAppV1, "", "", defaultWXAppUserAgent},
+		{UAModeWXAppV1, "", "   ", defaultWXAppUserAgent},
+		{UAModeWXAppV1, "", desktop, defaultWXAppUserAgent},
+		{UAModeWXAppV1, "", android, android},
+		{UAModeWXAppV1, "", "  " + wechat + "  ", wechat},
+		{UAModeWXAppV1, custom, desktop, custom},
+		{UAModePassthrough, "", desktop, desktop},
+		{UAModePassthrough, "", "", defaultWXAppUserAgent},
+		{UAModePassthrough, custom, "", custom},
+	}
+	for _, tc := range cases {
+		if err := SetUserAgentMode(tc.mode, tc.defaultUA); err != nil {
+			t.Fatalf("SetUserAgentMode(%q): %v", tc.mode, err)
+		}
+		if got := NormalizeWXAppUserAgent(tc.in); got != tc.want {
+			t.Fatalf("mode=%s in=%q: got %q, want %q", tc.mode, tc.in, got, tc.want)
+		}
+	}
+
+	if err := SetUserAgentMode("wxapp-v0", ""); err == nil {
+		t.Fatalf("expected error for unknown mode")
+	}
+	if CurrentUserAgentMode() != UAModePassthrough {
+		t.Fatalf("failed switch must keep current mode, got %s", CurrentUserAgentMode())
+	}
+}
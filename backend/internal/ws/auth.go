@@ -0,0 +1,67 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"sniping_engine/internal/config"
+	"sniping_engine/internal/logbus"
+)
+
+// Identity 是一个 WS 连接的接入身份，鉴权成功后以 type=auth 消息回给客户端。
+type Identity struct {
+	Name string `json:"name,omitempty"`
+	Role string `json:"role"`
+}
+
+// Authenticator 按令牌返回接入身份；Handler 未设置时不鉴权，所有连接按 admin 处理。
+type Authenticator func(token string) (Identity, bool)
+
+// authFirstMessageTimeout 未在 URL 中带令牌时，等待首条 {"type":"auth","token":"..."} 消息的时间。
+const authFirstMessageTimeout = 5 * time.Second
+
+var errUnauthorized = errors.New("unauthorized")
+
+type authMessage struct {
+	Type  string `json:"type"`
+	Token string `json:"token"`
+}
+
+// SetAuthenticator 开启 WS 鉴权。
+func (h *Handler) SetAuthenticator(a Authenticator) {
+	h.auth = a
+}
+
+// authenticateFirstMessage 读取连接上的首条消息作为鉴权消息。
+func (h *Handler) authenticateFirstMessage(conn *websocket.Conn) (Identity, error) {
+	_ = conn.SetReadDeadline(time.Now().Add(authFirstMessageTimeout))
+	defer func() { _ = conn.SetReadDeadline(time.Time{}) }()
+	_, raw, err := conn.ReadMessage()
+	if err != nil {
+		return Identity{}, err
+	}
+	var msg authMessage
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.Type != "auth" {
+		return Identity{}, errUnauthorized
+	}
+	id, ok := h.auth(msg.Token)
+	if !ok {
+		return Identity{}, errUnauthorized
+	}
+	return id, nil
+}
+
+// viewerRedactor 在总线默认的脱敏字段之外，再为 viewer 连接隐去密码、密钥、凭据与验证码参数。
+var viewerRedactor = logbus.NewMessageRedactor("password", "secret", "credential", "verifyparam")
+
+// RedactForRole 为 viewer 身份隐去日志/进度消息字段中的凭据类内容；admin 原样返回。
+// 只复制被改动的消息，总线里的原消息不受影响。REST 的最近消息接口也按同样规则处理。
+func RedactForRole(role string, msg logbus.Message) logbus.Message {
+	if role != config.RoleViewer {
+		return msg
+	}
+	return viewerRedactor.Redact(msg)
+}
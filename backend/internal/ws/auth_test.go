@@ -0,0 +1,100 @@
+package ws
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"sniping_engine/internal/config"
+	"sniping_engine/internal/logbus"
+)
+
+func newAuthServer(t *testing.T, bus *logbus.Bus) string {
+	t.Helper()
+	h := NewHandler(bus, nil)
+	h.SetAuthenticator(func(token string) (Identity, bool) {
+		switch token {
+		case "admin-token":
+			return Identity{Name: "ops", Role: config.RoleAdmin}, true
+		case "viewer-token":
+			return Identity{Name: "guest", Role: config.RoleViewer}, true
+		}
+		return Identity{}, false
+	})
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) map[string]any {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg map[string]any
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return msg
+}
+
+func TestWSRejectsInvalidQueryToken(t *testing.T) {
+	url := newAuthServer(t, logbus.New(10))
+	_, resp, err := websocket.DefaultDialer.Dial(url+"?token=nope", nil)
+	if err == nil || resp == nil || resp.StatusCode != 401 {
+		t.Fatalf("dial with bad token: err=%v resp=%v, want 401", err, resp)
+	}
+}
+
+func TestWSViewerGetsRedactedFields(t *testing.T) {
+	bus := logbus.New(10)
+	bus.Log("info", "login", map[string]any{"accountId": "a", "token": "secret", "nested": map[string]any{"cookies": "c=1"}})
+	url := newAuthServer(t, bus)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteJSON(map[string]string{"type": "auth", "token": "viewer-token"}); err != nil {
+		t.Fatalf("write auth: %v", err)
+	}
+	ack := readMessage(t, conn)
+	if ack["type"] != "auth" || ack["data"].(map[string]any)["role"] != config.RoleViewer {
+		t.Fatalf("ack = %v", ack)
+	}
+	msg := readMessage(t, conn)
+	fields := msg["data"].(map[string]any)["fields"].(map[string]any)
+	if fields["token"] != logbus.RedactedValue || fields["accountId"] != "a" {
+		t.Fatalf("fields = %v", fields)
+	}
+	if fields["nested"].(map[string]any)["cookies"] != logbus.RedactedValue {
+		t.Fatalf("nested fields = %v", fields["nested"])
+	}
+
+	// 总线里的原消息不受影响。
+	orig := bus.Snapshot()[0].Data.(logbus.LogData)
+	if orig.Fields["token"] != "secret" {
+		t.Fatalf("bus message was modified: %v", orig.Fields)
+	}
+}
+
+func TestWSAdminGetsFullFields(t *testing.T) {
+	bus := logbus.New(10)
+	bus.Log("info", "login", map[string]any{"token": "secret"})
+	url := newAuthServer(t, bus)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?token=admin-token", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if ack := readMessage(t, conn); ack["type"] != "auth" {
+		t.Fatalf("ack = %v", ack)
+	}
+	msg := readMessage(t, conn)
+	if got := msg["data"].(map[string]any)["fields"].(map[string]any)["token"]; got != "secret" {
+		t.Fatalf("admin token field = %v", got)
+	}
+}
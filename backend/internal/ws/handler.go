@@ -7,13 +7,18 @@ import (
 
 	"github.com/gorilla/websocket"
 
+	"sniping_engine/internal/config"
 	"sniping_engine/internal/logbus"
 )
 
+// defaultReplayLimit 连接时未指定 limit 时回放的消息条数。
+const defaultReplayLimit = 200
+
 type Handler struct {
 	bus          *logbus.Bus
 	allowOrigins []string
 	upgrader     websocket.Upgrader
+	auth         Authenticator
 }
 
 func NewHandler(bus *logbus.Bus, allowOrigins []string) *Handler {
@@ -27,16 +32,50 @@ func NewHandler(bus *logbus.Bus, allowOrigins []string) *Handler {
 	return h
 }
 
+// ServeHTTP 开启鉴权时，令牌可放在 URL（?token=，无效直接返回 401），
+// 也可在连接建立后 5 秒内发送 {"type":"auth","token":"..."}；成功后先回一条 type=auth 消息（data 为身份）。
+//
+// 连接后先回放缓冲区中的最近消息，可用 ?since=&types=&limit= 筛选（见 logbus.ParseQuery），默认最近 200 条。
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	replay, err := logbus.ParseQuery(r.URL.Query(), defaultReplayLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id := Identity{Role: config.RoleAdmin}
+	queryToken := strings.TrimSpace(r.URL.Query().Get("token"))
+	if h.auth != nil && queryToken != "" {
+		var ok bool
+		if id, ok = h.auth(queryToken); !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
 	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return
 	}
 	defer conn.Close()
 
+	if h.auth != nil {
+		if queryToken == "" {
+			if id, err = h.authenticateFirstMessage(conn); err != nil {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "unauthorized"),
+					time.Now().Add(time.Second))
+				return
+			}
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		if err := conn.WriteJSON(logbus.Message{Type: "auth", Time: time.Now().UnixMilli(), Data: id}); err != nil {
+			return
+		}
+	}
+
 	_ = conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
-	for _, msg := range h.bus.Snapshot() {
-		if err := conn.WriteJSON(msg); err != nil {
+	for _, msg := range h.bus.Query(replay).Messages {
+		if err := conn.WriteJSON(RedactForRole(id.Role, msg)); err != nil {
 			return
 		}
 	}
@@ -63,7 +102,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			_ = conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
-			if err := conn.WriteJSON(msg); err != nil {
+			if err := conn.WriteJSON(RedactForRole(id.Role, msg)); err != nil {
 				return
 			}
 		}
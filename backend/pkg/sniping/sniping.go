@@ -0,0 +1,289 @@
+// Package sniping 是引擎对外公开的嵌入接口。
+//
+// 引擎通过 Store 与 Provider 两个接口访问存储和上游，嵌入方可以用内置实现（OpenStore、NewStandardProvider），
+// 也可以换成自己的实现；引擎的组装参数见 Options，不依赖内部的配置与存储类型。
+// 模块路径 sniping_engine 不能通过 go get 获取，外部程序需以 replace 指令引用本地检出的仓库。
+//
+//	store, _ := sniping.OpenStore(ctx, "./data/sniping_engine.db")
+//	bus := sniping.NewBus(200)
+//	eng, err := sniping.New(sniping.Options{
+//		Store:    store,
+//		Provider: myProvider,
+//		Bus:      bus,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	_ = eng.StartAll(ctx)
+//
+// 下单成功后的自定义动作通过 Options.OnOrderCreated 挂接（异步执行、失败重试），不需要修改引擎内部：
+//
+//	OnOrderCreated: []sniping.OrderHook{func(ctx context.Context, rec sniping.OrderRecord) error {
+//		return report(ctx, rec.OrderID)
+//	}},
+package sniping
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"sniping_engine/internal/clock"
+	"sniping_engine/internal/config"
+	"sniping_engine/internal/engine"
+	"sniping_engine/internal/logbus"
+	"sniping_engine/internal/model"
+	"sniping_engine/internal/notify"
+	"sniping_engine/internal/provider"
+	"sniping_engine/internal/provider/standard"
+	"sniping_engine/internal/store/sqlite"
+)
+
+// Store 是引擎用到的持久化接口，只包含引擎实际调用的方法。
+// OpenStore 返回的内置 SQLite 存储实现了它，嵌入方也可以提供自己的实现。
+type Store interface {
+	// 账号。
+	ListAccounts(ctx context.Context) ([]Account, error)
+	GetAccount(ctx context.Context, id string) (Account, error)
+	UpsertAccount(ctx context.Context, acc Account) (Account, error)
+	SetAccountValidation(ctx context.Context, id string, atMs int64, valid bool, errMsg string) error
+	DeleteAccountCredentials(ctx context.Context, id string) error
+	GetAccountDailyPurchase(ctx context.Context, accountID, day string) (int, error)
+	AddAccountDailyPurchase(ctx context.Context, accountID, day string, qty int) error
+
+	// 登录凭证有效期。
+	ListAccountTokens(ctx context.Context) ([]TokenExpiry, error)
+	ListTokenLifetimes(ctx context.Context) ([]int64, error)
+	RecordTokenExpired(ctx context.Context, accountID string, atMs int64) (bool, error)
+	SetTokenExpectedExpiry(ctx context.Context, accountID string, expectedMs int64) error
+
+	// 目标。
+	ListTargets(ctx context.Context) ([]Target, error)
+	ListEnabledTargets(ctx context.Context) ([]Target, error)
+	GetTarget(ctx context.Context, id string) (Target, error)
+	SetTargetEnabled(ctx context.Context, id string, enabled bool) error
+	ListArmDueTargets(ctx context.Context, nowMs int64) ([]Target, error)
+	ClearTargetArm(ctx context.Context, id string) error
+	MergeTargets(ctx context.Context, keepID string, sourceIDs []string) (TargetMergeResult, error)
+	ListCampaigns(ctx context.Context) ([]Campaign, error)
+
+	// 任务进度。
+	ListTaskStates(ctx context.Context) ([]TaskState, error)
+	UpsertTaskStates(ctx context.Context, states []TaskState) error
+	ClearTaskStates(ctx context.Context) error
+
+	// 尝试、订单与下单记录（每个账号每个目标一笔）。
+	InsertAttempts(ctx context.Context, results []AttemptResult) error
+	InsertOrder(ctx context.Context, rec OrderRecord) error
+	ClaimOrderLedger(ctx context.Context, accountID, targetID, orderID string) (bool, error)
+	GetOrderLedger(ctx context.Context, accountID, targetID string) (orderID string, ok bool, err error)
+	ListOrderLedger(ctx context.Context, targetID string) ([]OrderLedgerEntry, error)
+	UpdateOrderLedgerVerification(ctx context.Context, en OrderLedgerEntry) error
+
+	// 商品目录缓存。
+	ReplaceCatalogCategories(ctx context.Context, frontCategoryID int64, items []CatalogCategory) error
+	ReplaceCatalogSkus(ctx context.Context, frontCategoryID int64, items []CatalogSku) error
+
+	// 出口探测结果。
+	ListEgressProbes(ctx context.Context) ([]EgressProbe, error)
+	ReplaceEgressProbes(ctx context.Context, probes []EgressProbe) error
+
+	// 历史清理。
+	PruneRetention(ctx context.Context, r RetentionSettings, now time.Time) (RetentionPruneResult, error)
+	WipePrivateData(ctx context.Context) (PrivacyWipeResult, error)
+}
+
+// 两边的方法集合必须一致，引擎内部的接口变化会在这里编译失败。
+var (
+	_ engine.Store = Store(nil)
+	_ Store        = engine.Store(nil)
+	_ Store        = (*SQLiteStore)(nil)
+)
+
+// 引擎与扩展点。
+type (
+	Engine        = engine.Engine
+	Bus           = logbus.Bus
+	BusMessage    = logbus.Message
+	Notifier      = notify.Notifier
+	EmailNotifier = notify.EmailNotifier
+	EmailOptions  = notify.EmailNotifierOptions
+	OrderCreated  = notify.OrderCreatedEvent
+	AlertEvent    = notify.AlertEvent
+	Clock         = clock.Clock
+	FakeClock     = clock.Fake
+	// SQLiteStore 内置的 SQLite 存储，由 OpenStore 创建，退出前需调用 Close。
+	SQLiteStore = sqlite.Store
+	// OrderHook 下单成功后的扩展点，通过 Options.OnOrderCreated 或 Engine.AddOrderHook 挂接。
+	OrderHook = engine.OrderHook
+	// AttemptHook 下单尝试各阶段的扩展点，通过 Options.AttemptHooks 或 Engine.AddAttemptHook 挂接。
+	AttemptHook      = engine.AttemptHook
+	AttemptHookFuncs = engine.AttemptHookFuncs
+	AttemptInfo      = engine.AttemptInfo
+)
+
+// Provider 接口及其参数/结果。
+type (
+	Provider                 = provider.Provider
+	PreflightResult          = provider.PreflightResult
+	CreateResult             = provider.CreateResult
+	AttemptContext           = provider.AttemptContext
+	ProbeResult              = provider.ProbeResult
+	UpstreamOrder            = provider.UpstreamOrder
+	ShippingAddressParams    = provider.ShippingAddressParams
+	CategoryTreeParams       = provider.CategoryTreeParams
+	StoreSkuByCategoryParams = provider.StoreSkuByCategoryParams
+	CodeBook                 = provider.CodeBook
+	BizCodeError             = provider.BizCodeError
+	EgressProber             = provider.EgressProber
+	SessionBootstrapper      = provider.SessionBootstrapper
+	ServerClock              = provider.ServerClock
+	BootstrapStep            = provider.BootstrapStep
+	// ProviderConfig/ProxyConfig 仅用于 NewStandardProvider。
+	ProviderConfig = config.ProviderConfig
+	ProxyConfig    = config.ProxyConfig
+)
+
+// 数据模型。
+type (
+	Account              = model.Account
+	Target               = model.Target
+	TargetMode           = model.TargetMode
+	TargetMergeResult    = model.TargetMergeResult
+	TaskState            = model.TaskState
+	EngineState          = model.EngineState
+	NotifierStatus       = model.NotifierStatus
+	NotifySettings       = model.NotifySettings
+	LimitsSettings       = model.LimitsSettings
+	CaptchaPoolSettings  = model.CaptchaPoolSettings
+	AttemptResult        = model.AttemptResult
+	OrderRecord          = model.OrderRecord
+	OrderLedgerEntry     = model.OrderLedgerEntry
+	EgressProbe          = model.EgressProbe
+	TokenExpiry          = model.TokenExpiry
+	Campaign             = model.Campaign
+	CatalogCategory      = model.CatalogCategory
+	CatalogSku           = model.CatalogSku
+	RetentionSettings    = model.RetentionSettings
+	RetentionPruneResult = model.RetentionPruneResult
+	PrivacyWipeResult    = model.PrivacyWipeResult
+)
+
+const (
+	TargetModeRush = model.TargetModeRush
+	TargetModeScan = model.TargetModeScan
+)
+
+// Limits 是并发与限速参数，为 0 的字段使用内置默认值。
+type Limits struct {
+	GlobalQPS       float64
+	GlobalBurst     int
+	PerAccountQPS   float64
+	PerAccountBurst int
+	MaxInFlight     int
+	// MaxPerTargetInFlight 同一目标同时下单的账号数上限，默认 1。
+	MaxPerTargetInFlight int
+	// CaptchaMaxInFlight 验证码求解并发数上限，默认 1。
+	CaptchaMaxInFlight int
+}
+
+// Timing 是任务节拍参数，为 0 的字段使用内置默认值。
+type Timing struct {
+	RushIntervalMs int
+	ScanIntervalMs int
+	// StopGraceMs 停止引擎时等待进行中的下单完成的最长时间，0 为默认 3000，-1 表示立即取消。
+	StopGraceMs int
+}
+
+// Options 是 New 的参数；Store 与 Provider 必填。
+type Options struct {
+	Store    Store
+	Provider Provider
+	// PracticeProvider 演练目标使用的 provider，通常指向 mock 服务；为空时不允许运行演练目标。
+	PracticeProvider Provider
+	Bus              *Bus
+	Notifier         Notifier
+	// Clock 为空时使用真实时钟；测试可注入 NewFakeClock 创建的模拟时钟。
+	Clock  Clock
+	Limits Limits
+	Timing Timing
+	// CriticalCookies 参与有效期检查的 cookie 名，为空时检查所有带有效期的 cookie。
+	CriticalCookies []string
+	// OnOrderCreated 下单成功后依次分发的钩子（异步执行，失败重试）。
+	OnOrderCreated []OrderHook
+	// AttemptHooks 下单尝试各阶段的钩子（同步执行）。
+	AttemptHooks []AttemptHook
+	// Environment 本实例的环境标记，与目标/账号上的标记不一致时告警。
+	Environment string
+}
+
+// New 创建引擎。
+func New(opts Options) (*Engine, error) {
+	if opts.Store == nil {
+		return nil, errors.New("sniping: store is required")
+	}
+	if opts.Provider == nil {
+		return nil, errors.New("sniping: provider is required")
+	}
+	return engine.New(engine.Options{
+		Store:            opts.Store,
+		Provider:         opts.Provider,
+		PracticeProvider: opts.PracticeProvider,
+		Bus:              opts.Bus,
+		Notifier:         opts.Notifier,
+		Clock:            opts.Clock,
+		Limits: config.LimitsConfig{
+			GlobalQPS:            opts.Limits.GlobalQPS,
+			GlobalBurst:          opts.Limits.GlobalBurst,
+			PerAccountQPS:        opts.Limits.PerAccountQPS,
+			PerAccountBurst:      opts.Limits.PerAccountBurst,
+			MaxInFlight:          opts.Limits.MaxInFlight,
+			MaxPerTargetInFlight: opts.Limits.MaxPerTargetInFlight,
+			CaptchaMaxInFlight:   opts.Limits.CaptchaMaxInFlight,
+		},
+		Task: config.TaskConfig{
+			RushIntervalMs: opts.Timing.RushIntervalMs,
+			ScanIntervalMs: opts.Timing.ScanIntervalMs,
+			StopGraceMs:    opts.Timing.StopGraceMs,
+		},
+		CriticalCookies: opts.CriticalCookies,
+		OnOrderCreated:  opts.OnOrderCreated,
+		AttemptHooks:    opts.AttemptHooks,
+		Environment:     opts.Environment,
+	}), nil
+}
+
+// OpenStore 打开（必要时创建并迁移）内置的 SQLite 存储。
+func OpenStore(ctx context.Context, path string) (*SQLiteStore, error) {
+	return sqlite.Open(ctx, path)
+}
+
+// NewBus 创建日志/事件总线，capacity 为环形缓冲区大小。
+func NewBus(capacity int) *Bus {
+	return logbus.New(capacity)
+}
+
+// NewFakeClock 创建手动推进的模拟时钟，通过 Options.Clock 注入后可做确定性的调度测试。
+func NewFakeClock(start time.Time) *FakeClock {
+	return clock.NewFake(start)
+}
+
+// NewStandardProvider 创建内置的 Resty Provider。
+func NewStandardProvider(cfg ProviderConfig, proxyCfg ProxyConfig, bus *Bus) Provider {
+	return standard.New(cfg, proxyCfg, bus)
+}
+
+// NewEmailNotifier 创建基于 store 中邮件设置的下单通知器，退出前需调用 Close；邮件设置只保存在内置存储中。
+func NewEmailNotifier(store *SQLiteStore, bus *Bus) *EmailNotifier {
+	return notify.NewEmailNotifier(store, bus)
+}
+
+// NewEmailNotifierWithOptions 同 NewEmailNotifier，可指定队列长度与队列满时的策略。
+func NewEmailNotifierWithOptions(store *SQLiteStore, bus *Bus, opts EmailOptions) *EmailNotifier {
+	return notify.NewEmailNotifierWithOptions(store, bus, opts)
+}
+
+// NewCodeBook 创建空的业务码字典；加载内容后通过 StandardProvider.SetCodeBook 生效。
+func NewCodeBook() *CodeBook {
+	return provider.NewCodeBook()
+}
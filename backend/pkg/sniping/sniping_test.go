@@ -0,0 +1,167 @@
+package sniping_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"sniping_engine/pkg/sniping"
+)
+
+// 只用 sniping 包导出的名字实现 Provider 和 Store，确认嵌入方不需要引用 internal/。
+var (
+	_ sniping.Provider = stubProvider{}
+	_ sniping.Store    = stubStore{}
+)
+
+var errStub = errors.New("stub")
+
+type stubProvider struct{}
+
+func (stubProvider) Name() string { return "stub" }
+
+func (stubProvider) LoginBySMS(ctx context.Context, account sniping.Account, mobile, smsCode string) (sniping.Account, error) {
+	return account, errStub
+}
+
+func (stubProvider) Preflight(ctx context.Context, account sniping.Account, target sniping.Target) (sniping.PreflightResult, sniping.Account, error) {
+	return sniping.PreflightResult{}, account, errStub
+}
+
+func (stubProvider) CreateOrder(ctx context.Context, attempt *sniping.AttemptContext) (sniping.CreateResult, sniping.Account, error) {
+	return sniping.CreateResult{}, attempt.Account(), errStub
+}
+
+func (stubProvider) ProbeStock(ctx context.Context, account sniping.Account, target sniping.Target) (sniping.ProbeResult, sniping.Account, error) {
+	return sniping.ProbeResult{}, account, errStub
+}
+
+func (stubProvider) ListRecentOrders(ctx context.Context, account sniping.Account, since time.Time) ([]sniping.UpstreamOrder, sniping.Account, error) {
+	return nil, account, errStub
+}
+
+func (stubProvider) RefreshSession(ctx context.Context, account sniping.Account) (sniping.Account, error) {
+	return account, errStub
+}
+
+func (stubProvider) GetShippingAddresses(ctx context.Context, account sniping.Account, params sniping.ShippingAddressParams) (json.RawMessage, sniping.Account, error) {
+	return nil, account, errStub
+}
+
+func (stubProvider) GetCategoryTree(ctx context.Context, account sniping.Account, params sniping.CategoryTreeParams) (json.RawMessage, sniping.Account, error) {
+	return nil, account, errStub
+}
+
+func (stubProvider) GetStoreSkuByCategory(ctx context.Context, account sniping.Account, params sniping.StoreSkuByCategoryParams) (json.RawMessage, sniping.Account, error) {
+	return nil, account, errStub
+}
+
+type stubStore struct{}
+
+func (stubStore) ListAccounts(ctx context.Context) ([]sniping.Account, error) { return nil, nil }
+func (stubStore) GetAccount(ctx context.Context, id string) (sniping.Account, error) {
+	return sniping.Account{}, errStub
+}
+func (stubStore) UpsertAccount(ctx context.Context, acc sniping.Account) (sniping.Account, error) {
+	return acc, nil
+}
+func (stubStore) SetAccountValidation(ctx context.Context, id string, atMs int64, valid bool, errMsg string) error {
+	return nil
+}
+func (stubStore) DeleteAccountCredentials(ctx context.Context, id string) error { return nil }
+func (stubStore) GetAccountDailyPurchase(ctx context.Context, accountID, day string) (int, error) {
+	return 0, nil
+}
+func (stubStore) AddAccountDailyPurchase(ctx context.Context, accountID, day string, qty int) error {
+	return nil
+}
+func (stubStore) ListAccountTokens(ctx context.Context) ([]sniping.TokenExpiry, error) {
+	return nil, nil
+}
+func (stubStore) ListTokenLifetimes(ctx context.Context) ([]int64, error) { return nil, nil }
+func (stubStore) RecordTokenExpired(ctx context.Context, accountID string, atMs int64) (bool, error) {
+	return false, nil
+}
+func (stubStore) SetTokenExpectedExpiry(ctx context.Context, accountID string, expectedMs int64) error {
+	return nil
+}
+func (stubStore) ListTargets(ctx context.Context) ([]sniping.Target, error)        { return nil, nil }
+func (stubStore) ListEnabledTargets(ctx context.Context) ([]sniping.Target, error) { return nil, nil }
+func (stubStore) GetTarget(ctx context.Context, id string) (sniping.Target, error) {
+	return sniping.Target{}, errStub
+}
+func (stubStore) SetTargetEnabled(ctx context.Context, id string, enabled bool) error { return nil }
+func (stubStore) ListArmDueTargets(ctx context.Context, nowMs int64) ([]sniping.Target, error) {
+	return nil, nil
+}
+func (stubStore) ClearTargetArm(ctx context.Context, id string) error { return nil }
+func (stubStore) MergeTargets(ctx context.Context, keepID string, sourceIDs []string) (sniping.TargetMergeResult, error) {
+	return sniping.TargetMergeResult{}, errStub
+}
+func (stubStore) ListCampaigns(ctx context.Context) ([]sniping.Campaign, error)   { return nil, nil }
+func (stubStore) ListTaskStates(ctx context.Context) ([]sniping.TaskState, error) { return nil, nil }
+func (stubStore) UpsertTaskStates(ctx context.Context, states []sniping.TaskState) error {
+	return nil
+}
+func (stubStore) ClearTaskStates(ctx context.Context) error { return nil }
+func (stubStore) InsertAttempts(ctx context.Context, results []sniping.AttemptResult) error {
+	return nil
+}
+func (stubStore) InsertOrder(ctx context.Context, rec sniping.OrderRecord) error { return nil }
+func (stubStore) ClaimOrderLedger(ctx context.Context, accountID, targetID, orderID string) (bool, error) {
+	return true, nil
+}
+func (stubStore) GetOrderLedger(ctx context.Context, accountID, targetID string) (string, bool, error) {
+	return "", false, nil
+}
+func (stubStore) ListOrderLedger(ctx context.Context, targetID string) ([]sniping.OrderLedgerEntry, error) {
+	return nil, nil
+}
+func (stubStore) UpdateOrderLedgerVerification(ctx context.Context, en sniping.OrderLedgerEntry) error {
+	return nil
+}
+func (stubStore) ReplaceCatalogCategories(ctx context.Context, frontCategoryID int64, items []sniping.CatalogCategory) error {
+	return nil
+}
+func (stubStore) ReplaceCatalogSkus(ctx context.Context, frontCategoryID int64, items []sniping.CatalogSku) error {
+	return nil
+}
+func (stubStore) ListEgressProbes(ctx context.Context) ([]sniping.EgressProbe, error) {
+	return nil, nil
+}
+func (stubStore) ReplaceEgressProbes(ctx context.Context, probes []sniping.EgressProbe) error {
+	return nil
+}
+func (stubStore) PruneRetention(ctx context.Context, r sniping.RetentionSettings, now time.Time) (sniping.RetentionPruneResult, error) {
+	return sniping.RetentionPruneResult{}, nil
+}
+func (stubStore) WipePrivateData(ctx context.Context) (sniping.PrivacyWipeResult, error) {
+	return sniping.PrivacyWipeResult{}, nil
+}
+
+func TestNewWithExternalStoreAndProvider(t *testing.T) {
+	eng, err := sniping.New(sniping.Options{
+		Store:    stubStore{},
+		Provider: stubProvider{},
+		Bus:      sniping.NewBus(10),
+		Limits:   sniping.Limits{MaxInFlight: 2},
+	})
+	if err != nil || eng == nil {
+		t.Fatalf("New: eng=%v err=%v", eng, err)
+	}
+	// 存储里没有账号时启动失败，但不会触碰 internal 的具体存储类型。
+	if err := eng.StartAll(context.Background()); err == nil {
+		t.Fatalf("StartAll with empty store should fail")
+	}
+}
+
+func TestNewRequiresStoreAndProvider(t *testing.T) {
+	if _, err := sniping.New(sniping.Options{Provider: stubProvider{}}); err == nil {
+		t.Fatalf("New without store should fail")
+	}
+	if _, err := sniping.New(sniping.Options{Store: stubStore{}}); err == nil {
+		t.Fatalf("New without provider should fail")
+	}
+}